@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestRedactConfig(t *testing.T) {
+	patterns := []string{"password", "secret", "token", "key", "dsn", "credentials"}
+	in := map[string]any{
+		"database":   map[string]any{"host": "db", "password": "hunter2"},
+		"jwt_secret": "s3cret",
+		"API_KEY":    "abc",
+		"auth":       map[any]any{"oauth": map[string]any{"refresh_token": "r1", "issuer": "idp"}},
+	}
+	out := redactConfig(in, patterns)
+
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"nested password", out["database"].(map[string]any)["password"], redactedValue},
+		{"nested host kept", out["database"].(map[string]any)["host"], "db"},
+		{"jwt_secret", out["jwt_secret"], redactedValue},
+		{"upper-case key", out["API_KEY"], redactedValue},
+		{"yaml map", out["auth"].(map[string]any)["oauth"].(map[string]any)["refresh_token"], redactedValue},
+		{"yaml map entry kept", out["auth"].(map[string]any)["oauth"].(map[string]any)["issuer"], "idp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+	if in["database"].(map[string]any)["password"] != "hunter2" {
+		t.Error("input map was modified")
+	}
+}
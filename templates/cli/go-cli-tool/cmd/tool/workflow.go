@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/viper"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/log"
+	"go.uber.org/zap"
+
+	"github.com/example/tool/internal/errcodes"
+)
+
+// submitRequest describes an ad-hoc workflow execution
+type submitRequest struct {
+	WorkflowType string
+	WorkflowID   string // empty: generated by the server
+	TaskQueue    string
+	Input        string // JSON; empty means no arguments
+	Wait         bool
+}
+
+// submitWorkflow starts a workflow on the Temporal cluster configured under
+// temporal.* and prints its IDs; with Wait it also prints the JSON result
+func submitWorkflow(ctx context.Context, req submitRequest) error {
+	var args []interface{}
+	if req.Input != "" {
+		var in interface{}
+		if err := json.Unmarshal([]byte(req.Input), &in); err != nil {
+			return errcodes.New("INVALID_REQUEST", fmt.Sprintf("--input is not valid JSON: %v", err))
+		}
+		args = append(args, in)
+	}
+
+	c, err := client.DialContext(ctx, client.Options{
+		HostPort:  viper.GetString("temporal.host_port"),
+		Namespace: viper.GetString("temporal.namespace"),
+		Logger:    temporalLogger{zap.L().Sugar()},
+	})
+	if err != nil {
+		return fmt.Errorf("connect to temporal at %s: %w", viper.GetString("temporal.host_port"), err)
+	}
+	defer c.Close()
+
+	run, err := c.ExecuteWorkflow(ctx, client.StartWorkflowOptions{
+		ID:        req.WorkflowID,
+		TaskQueue: req.TaskQueue,
+	}, req.WorkflowType, args...)
+	if err != nil {
+		return fmt.Errorf("start workflow %s: %w", req.WorkflowType, err)
+	}
+	fmt.Printf("workflow_id=%s run_id=%s\n", run.GetID(), run.GetRunID())
+	if !req.Wait {
+		return nil
+	}
+
+	var result interface{}
+	if err := run.Get(ctx, &result); err != nil {
+		return fmt.Errorf("workflow %s failed: %w", run.GetID(), err)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
+
+// temporalLogger routes Temporal SDK logs through zap
+type temporalLogger struct{ s *zap.SugaredLogger }
+
+var _ log.Logger = temporalLogger{}
+
+func (l temporalLogger) Debug(msg string, keyvals ...interface{}) { l.s.Debugw(msg, keyvals...) }
+func (l temporalLogger) Info(msg string, keyvals ...interface{})  { l.s.Infow(msg, keyvals...) }
+func (l temporalLogger) Warn(msg string, keyvals ...interface{})  { l.s.Warnw(msg, keyvals...) }
+func (l temporalLogger) Error(msg string, keyvals ...interface{}) { l.s.Errorw(msg, keyvals...) }
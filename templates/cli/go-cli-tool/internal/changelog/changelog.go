@@ -0,0 +1,227 @@
+// Package changelog turns Conventional Commits (https://www.conventionalcommits.org)
+// between two git revisions into a Markdown changelog section and the next
+// semantic version.
+package changelog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/semver"
+)
+
+// Commit is one parsed commit. Type is empty for messages that do not follow
+// the Conventional Commits format.
+type Commit struct {
+	Hash     string
+	Type     string // feat, fix, ...
+	Scope    string
+	Subject  string // description after "type(scope): "
+	Breaking bool   // "!" after the type/scope or a BREAKING CHANGE footer
+	Note     string // text of the BREAKING CHANGE footer, if any
+}
+
+var (
+	header         = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(!)?: (.+)$`)
+	breakingFooter = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE: (.+)$`)
+)
+
+// Parse reads a commit from its subject and body
+func Parse(hash, subject, body string) Commit {
+	c := Commit{Hash: hash, Subject: strings.TrimSpace(subject)}
+	if m := header.FindStringSubmatch(c.Subject); m != nil {
+		c.Type, c.Scope, c.Breaking, c.Subject = strings.ToLower(m[1]), m[2], m[3] == "!", m[4]
+	}
+	if m := breakingFooter.FindStringSubmatch(body); m != nil {
+		c.Breaking, c.Note = true, strings.TrimSpace(m[1])
+	}
+	return c
+}
+
+// sections in the order they are rendered; commits of other types, and
+// those that are not Conventional Commits, go to "Other changes"
+var sections = []struct{ typ, title string }{
+	{"feat", "Features"},
+	{"fix", "Bug fixes"},
+	{"perf", "Performance"},
+	{"revert", "Reverts"},
+	{"refactor", "Refactoring"},
+	{"docs", "Documentation"},
+	{"", "Other changes"},
+}
+
+// field separators in the git log format; neither appears in commit messages
+const (
+	unitSep   = "\x1f"
+	recordSep = "\x1e"
+)
+
+// Log returns the commits in from..to of the repository in dir, newest
+// first. An empty from lists the whole history up to to.
+func Log(ctx context.Context, dir, from, to string) ([]Commit, error) {
+	rng := to
+	if from != "" {
+		rng = from + ".." + to
+	}
+	out, err := git(ctx, dir, "log", "--format=%H"+unitSep+"%s"+unitSep+"%b"+recordSep, rng)
+	if err != nil {
+		return nil, err
+	}
+	var commits []Commit
+	for _, rec := range strings.Split(out, recordSep) {
+		parts := strings.SplitN(strings.TrimLeft(rec, "\n"), unitSep, 3)
+		if len(parts) != 3 {
+			continue
+		}
+		commits = append(commits, Parse(parts[0], parts[1], parts[2]))
+	}
+	return commits, nil
+}
+
+// ErrNothingToRelease is returned by NextVersion when no commit is a
+// feature, fix or breaking change
+var ErrNothingToRelease = errors.New("no feat, fix or breaking commits to release")
+
+// NextVersion bumps current, a semantic version such as v1.4.2, by the most
+// significant change in commits: major for breaking changes, minor for
+// features and patch for fixes. Before v1.0.0 a breaking change bumps the
+// minor version instead, as semver reserves 0.x for unstable APIs.
+func NextVersion(current string, commits []Commit) (string, error) {
+	if !strings.HasPrefix(current, "v") {
+		current = "v" + current
+	}
+	if !semver.IsValid(current) {
+		return "", fmt.Errorf("changelog: %q is not a semantic version", current)
+	}
+	parts := strings.SplitN(strings.TrimPrefix(semver.Canonical(current), "v"), ".", 3)
+	major, _ := strconv.Atoi(parts[0])
+	minor, _ := strconv.Atoi(parts[1])
+	patch, _ := strconv.Atoi(strings.FieldsFunc(parts[2], func(r rune) bool { return r == '-' || r == '+' })[0])
+
+	var breaking, feat, fix bool
+	for _, c := range commits {
+		breaking = breaking || c.Breaking
+		feat = feat || c.Type == "feat"
+		fix = fix || c.Type == "fix"
+	}
+	switch {
+	case breaking && major > 0:
+		major, minor, patch = major+1, 0, 0
+	case breaking || feat:
+		minor, patch = minor+1, 0
+	case fix:
+		patch++
+	default:
+		return "", ErrNothingToRelease
+	}
+	return fmt.Sprintf("v%d.%d.%d", major, minor, patch), nil
+}
+
+// Render formats commits as a Markdown section titled title, e.g. "v1.5.0"
+// or "Unreleased". Breaking changes come first; empty sections are left out.
+func Render(title string, date time.Time, commits []Commit) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s (%s)\n", title, date.Format("2006-01-02"))
+
+	var breaking []string
+	for _, c := range commits {
+		if c.Breaking {
+			note := c.Note
+			if note == "" {
+				note = c.Subject
+			}
+			breaking = append(breaking, entry(c, note))
+		}
+	}
+	writeSection(&b, "⚠ Breaking changes", breaking)
+
+	grouped := map[string][]string{}
+	for _, c := range commits {
+		typ := "" // other
+		for _, s := range sections {
+			if s.typ == c.Type {
+				typ = c.Type
+				break
+			}
+		}
+		grouped[typ] = append(grouped[typ], entry(c, c.Subject))
+	}
+	for _, s := range sections {
+		writeSection(&b, s.title, grouped[s.typ])
+	}
+	return b.String()
+}
+
+func writeSection(b *strings.Builder, title string, entries []string) {
+	if len(entries) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n### %s\n\n", title)
+	for _, e := range entries {
+		b.WriteString("* " + e + "\n")
+	}
+}
+
+func entry(c Commit, text string) string {
+	if c.Scope != "" {
+		text = "**" + c.Scope + ":** " + text
+	}
+	hash := c.Hash
+	if len(hash) > 7 {
+		hash = hash[:7]
+	}
+	return text + " (" + hash + ")"
+}
+
+// Prepend writes section to the top of the changelog at path, below its
+// "# " title, creating the file with a "# Changelog" title when missing
+func Prepend(path, section string) error {
+	existing, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if len(existing) == 0 {
+		existing = []byte("# Changelog\n")
+	}
+	at := 0
+	if bytes.HasPrefix(existing, []byte("# ")) {
+		if i := bytes.IndexByte(existing, '\n'); i >= 0 {
+			at = i + 1
+		} else {
+			existing, at = append(existing, '\n'), len(existing)+1
+		}
+	}
+	var out bytes.Buffer
+	out.Write(existing[:at])
+	out.WriteString("\n" + section)
+	if rest := bytes.TrimLeft(existing[at:], "\n"); len(rest) > 0 {
+		out.WriteString("\n")
+		out.Write(rest)
+	}
+	return os.WriteFile(path, out.Bytes(), 0o644)
+}
+
+// Tag creates the annotated tag version on rev in the repository in dir
+func Tag(ctx context.Context, dir, version, rev string) error {
+	_, err := git(ctx, dir, "tag", "-a", version, "-m", "Release "+version, rev)
+	return err
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,169 @@
+package changelog
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newRepo creates a git repository in a temp dir with an initial commit
+// tagged v1.2.3 followed by commits, one per message
+func newRepo(t *testing.T, messages ...string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir, err := os.MkdirTemp("", "changelog-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	run("init", "-q")
+	// Tag runs git itself, so the identity goes in the repo config
+	run("config", "user.name", "Test")
+	run("config", "user.email", "test@example.com")
+	run("config", "tag.gpgSign", "false")
+	run("config", "commit.gpgSign", "false")
+	run("commit", "-q", "--allow-empty", "-m", "chore: initial commit")
+	run("tag", "v1.2.3")
+	for _, m := range messages {
+		run("commit", "-q", "--allow-empty", "-m", m)
+	}
+	return dir
+}
+
+func TestChangelogFromRepo(t *testing.T) {
+	dir := newRepo(t,
+		"feat(api): add order export",
+		"fix: handle empty input",
+		"docs: describe the export format",
+		"refactor(store)!: drop the v1 schema",
+		"feat: stream results\n\nBREAKING CHANGE: results are now written as JSON lines",
+		"update dependencies",
+	)
+	ctx := context.Background()
+	commits, err := Log(ctx, dir, "v1.2.3", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 6 {
+		t.Fatalf("got %d commits, want 6", len(commits))
+	}
+
+	md := Render("v2.0.0", time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC), commits)
+	headings := []string{
+		"## v2.0.0 (2026-10-15)",
+		"### ⚠ Breaking changes",
+		"### Features",
+		"### Bug fixes",
+		"### Refactoring",
+		"### Documentation",
+		"### Other changes",
+	}
+	last := -1
+	for _, h := range headings {
+		i := strings.Index(md, h+"\n")
+		if i < 0 {
+			t.Errorf("missing %q in:\n%s", h, md)
+			continue
+		}
+		if i < last {
+			t.Errorf("%q out of order in:\n%s", h, md)
+		}
+		last = i
+	}
+	for _, absent := range []string{"### Performance", "### Reverts", "initial commit"} {
+		if strings.Contains(md, absent) {
+			t.Errorf("changelog contains %q:\n%s", absent, md)
+		}
+	}
+	for _, want := range []string{
+		"* **api:** add order export (",
+		"* **store:** drop the v1 schema (",
+		"* results are now written as JSON lines (",
+		"* update dependencies (",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("changelog lacks %q:\n%s", want, md)
+		}
+	}
+
+	version, err := NextVersion("v1.2.3", commits)
+	if err != nil || version != "v2.0.0" {
+		t.Fatalf("NextVersion = %q, %v; want v2.0.0", version, err)
+	}
+	if err := Tag(ctx, dir, version, "HEAD"); err != nil {
+		t.Fatal(err)
+	}
+	if tags, err := git(ctx, dir, "tag", "--points-at", "HEAD"); err != nil || strings.TrimSpace(tags) != "v2.0.0" {
+		t.Errorf("tags at HEAD = %q, %v", tags, err)
+	}
+}
+
+func TestNextVersion(t *testing.T) {
+	tests := []struct {
+		name, current string
+		commits       []Commit
+		want          string
+	}{
+		{"patch", "v1.2.3", []Commit{{Type: "fix"}, {Type: "docs"}}, "v1.2.4"},
+		{"minor", "1.2.3", []Commit{{Type: "fix"}, {Type: "feat"}}, "v1.3.0"},
+		{"major", "v1.2.3", []Commit{{Type: "chore", Breaking: true}}, "v2.0.0"},
+		{"breaking before 1.0", "v0.4.1", []Commit{{Type: "feat", Breaking: true}}, "v0.5.0"},
+		{"prerelease", "v1.2.3-rc.1", []Commit{{Type: "fix"}}, "v1.2.4"},
+		{"nothing", "v1.2.3", []Commit{{Type: "docs"}, {}}, ""},
+		{"invalid", "latest", []Commit{{Type: "fix"}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NextVersion(tt.current, tt.commits)
+			if got != tt.want || (tt.want == "") != (err != nil) {
+				t.Errorf("NextVersion = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		subject, body string
+		want          Commit
+	}{
+		{"feat(cli): add --dry-run", "", Commit{Type: "feat", Scope: "cli", Subject: "add --dry-run"}},
+		{"Fix!: reject empty names", "", Commit{Type: "fix", Subject: "reject empty names", Breaking: true}},
+		{"perf: cache lookups", "BREAKING-CHANGE: cache is required", Commit{Type: "perf", Subject: "cache lookups", Breaking: true, Note: "cache is required"}},
+		{"Merge branch 'main'", "", Commit{Subject: "Merge branch 'main'"}},
+	}
+	for _, tt := range tests {
+		if got := Parse("", tt.subject, tt.body); got != tt.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.subject, got, tt.want)
+		}
+	}
+}
+
+func TestPrepend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "CHANGELOG.md")
+	if err := Prepend(path, "## v1.0.0 (2026-01-01)\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Prepend(path, "## v1.1.0 (2026-02-01)\n"); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := os.ReadFile(path)
+	want := "# Changelog\n\n## v1.1.0 (2026-02-01)\n\n## v1.0.0 (2026-01-01)\n"
+	if string(got) != want {
+		t.Errorf("CHANGELOG.md = %q, want %q", got, want)
+	}
+}
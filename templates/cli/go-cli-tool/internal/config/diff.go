@@ -0,0 +1,156 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/viper"
+)
+
+// Kinds of Change
+const (
+	Added   = "added"   // only in the second environment
+	Removed = "removed" // only in the first environment
+	Changed = "changed"
+)
+
+// Masked replaces secret values in diff output
+const Masked = "****"
+
+// secretMarkers flag keys whose values are never printed
+var secretMarkers = []string{"password", "secret", "token", "apikey", "api_key", "private_key", "credentials", "dsn"}
+
+// Change is one key that differs between two environments; secret values are masked
+type Change struct {
+	Key  string `json:"key"`
+	Kind string `json:"kind"`
+	From any    `json:"from,omitempty"`
+	To   any    `json:"to,omitempty"`
+}
+
+// IsSecret reports whether key names a value that must be masked
+func IsSecret(key string) bool {
+	k := strings.ToLower(key)
+	for _, m := range secretMarkers {
+		if strings.Contains(k, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff returns the keys that differ between a and b, sorted by key
+func Diff(a, b *viper.Viper) []Change {
+	va, vb := flatten(a), flatten(b)
+	var changes []Change
+	for _, key := range unionKeys(va, vb) {
+		from, inA := va[key]
+		to, inB := vb[key]
+		c := Change{Key: key, From: from, To: to}
+		switch {
+		case !inA:
+			c.Kind = Added
+		case !inB:
+			c.Kind = Removed
+		case !reflect.DeepEqual(from, to):
+			c.Kind = Changed
+		default:
+			continue
+		}
+		if IsSecret(key) {
+			if inA {
+				c.From = Masked
+			}
+			if inB {
+				c.To = Masked
+			}
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+// ANSI colors used by WriteDiff when color is on
+const (
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorCyan  = "\x1b[36m"
+	colorReset = "\x1b[0m"
+)
+
+// WriteDiff prints a line-by-line diff of the effective configs, one "key: value"
+// line per key, with removed lines prefixed "-" and added ones "+". Secret
+// values are masked; a changed secret is shown as "**** (changed)".
+func WriteDiff(w io.Writer, nameA, nameB string, a, b *viper.Viper, color bool) error {
+	va, vb := flatten(a), flatten(b)
+	var linesA, linesB []string
+	for _, key := range unionKeys(va, vb) {
+		from, inA := va[key]
+		to, inB := vb[key]
+		fromText, toText := fmt.Sprint(from), fmt.Sprint(to)
+		if IsSecret(key) {
+			fromText, toText = Masked, Masked
+			if inA && inB && !reflect.DeepEqual(from, to) {
+				toText = Masked + " (changed)"
+			}
+		}
+		if inA {
+			linesA = append(linesA, key+": "+fromText)
+		}
+		if inB {
+			linesB = append(linesB, key+": "+toText)
+		}
+	}
+
+	paint := func(c, s string) string {
+		if !color {
+			return s
+		}
+		return c + s + colorReset
+	}
+	if _, err := fmt.Fprintf(w, "%s\n%s\n", paint(colorRed, "--- "+nameA), paint(colorGreen, "+++ "+nameB)); err != nil {
+		return err
+	}
+	for _, op := range difflib.NewMatcher(linesA, linesB).GetOpCodes() {
+		switch op.Tag {
+		case 'e':
+			continue
+		case 'r', 'd', 'i':
+			for _, l := range linesA[op.I1:op.I2] {
+				fmt.Fprintln(w, paint(colorRed, "- "+l))
+			}
+			for _, l := range linesB[op.J1:op.J2] {
+				fmt.Fprintln(w, paint(colorGreen, "+ "+l))
+			}
+		}
+	}
+	_, err := fmt.Fprintln(w, paint(colorCyan, fmt.Sprintf("%d key(s) differ", len(Diff(a, b)))))
+	return err
+}
+
+// flatten maps every dotted key of v to its value
+func flatten(v *viper.Viper) map[string]any {
+	m := make(map[string]any)
+	for _, key := range v.AllKeys() {
+		m[key] = v.Get(key)
+	}
+	return m
+}
+
+func unionKeys(a, b map[string]any) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// loadEnv writes a base and an env file to a fresh dir and loads env from it
+func loadEnv(t *testing.T, env, base, overlay string) *viper.Viper {
+	t.Helper()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, BaseFile), base)
+	writeFile(t, filepath.Join(dir, EnvFile(env)), overlay)
+	v, err := Load(dir, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+const diffBase = "log_level: info\ndb:\n  host: localhost\n  password: dev\nworkers: 4\n"
+
+func TestDiff(t *testing.T) {
+	staging := loadEnv(t, "staging", diffBase, "db:\n  host: staging-db\n")
+	prod := loadEnv(t, "production", diffBase, "db:\n  host: prod-db\n  password: s3cret\nworkers: 16\nreplicas: 3\n")
+	changes := Diff(staging, prod)
+
+	want := []Change{
+		{Key: "db.host", Kind: Changed, From: "staging-db", To: "prod-db"},
+		{Key: "db.password", Kind: Changed, From: Masked, To: Masked},
+		{Key: "replicas", Kind: Added},
+		{Key: "workers", Kind: Changed},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("changes = %+v, want keys db.host, db.password, replicas, workers", changes)
+	}
+	for i, w := range want {
+		c := changes[i]
+		if c.Key != w.Key || c.Kind != w.Kind {
+			t.Errorf("change %d = %s %s, want %s %s", i, c.Key, c.Kind, w.Key, w.Kind)
+		}
+		if w.From != nil && (c.From != w.From || c.To != w.To) {
+			t.Errorf("%s: %v -> %v, want %v -> %v", c.Key, c.From, c.To, w.From, w.To)
+		}
+	}
+}
+
+func TestWriteDiffMasksSecrets(t *testing.T) {
+	staging := loadEnv(t, "staging", diffBase, "")
+	prod := loadEnv(t, "production", diffBase, "db:\n  password: s3cret\n")
+	var buf bytes.Buffer
+	if err := WriteDiff(&buf, "staging", "production", staging, prod, false); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, s := range []string{"--- staging", "+++ production", "+ db.password: **** (changed)", "1 key(s) differ"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("output lacks %q:\n%s", s, out)
+		}
+	}
+	if strings.Contains(out, "s3cret") || strings.Contains(out, "dev") || strings.Contains(out, "log_level") {
+		t.Errorf("output leaks a secret or an unchanged key:\n%s", out)
+	}
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/viper"
+)
+
+// BaseFile is merged first for every environment; config.<env>.yaml is overlaid on it
+const BaseFile = "config.base.yaml"
+
+// EnvFile returns the overlay file name for env
+func EnvFile(env string) string {
+	return "config." + env + ".yaml"
+}
+
+// MergeConfigFile overlays a YAML file onto v; keys it does not mention keep
+// their earlier values. A missing file returns an error wrapping os.ErrNotExist.
+func MergeConfigFile(v *viper.Viper, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var m map[string]interface{}
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := v.MergeConfigMap(m); err != nil {
+		return fmt.Errorf("merge %s: %w", path, err)
+	}
+	return nil
+}
+
+// MergeLayers merges dir/config.base.yaml and then dir/config.<env>.yaml into v,
+// skipping either when absent. It returns how many files were merged.
+func MergeLayers(v *viper.Viper, dir, env string) (int, error) {
+	merged := 0
+	for _, name := range []string{BaseFile, EnvFile(env)} {
+		err := MergeConfigFile(v, filepath.Join(dir, name))
+		switch {
+		case err == nil:
+			merged++
+		case !errors.Is(err, os.ErrNotExist):
+			return merged, err
+		}
+	}
+	return merged, nil
+}
+
+// Replace swaps the file settings of dst for everything in src, typically a
+// fresh instance a reload has just loaded. Flags, environment variables and
+// defaults bound to dst are kept; keys missing from src are dropped.
+func Replace(dst, src *viper.Viper) error {
+	b, err := json.Marshal(src.AllSettings())
+	if err != nil {
+		return fmt.Errorf("replace config: %w", err)
+	}
+	dst.SetConfigType("json")
+	if err := dst.ReadConfig(bytes.NewReader(b)); err != nil {
+		return fmt.Errorf("replace config: %w", err)
+	}
+	return nil
+}
+
+// Load returns the file-based configuration for env (base plus overlay) in a
+// fresh viper instance, without environment variables or flags applied
+func Load(dir, env string) (*viper.Viper, error) {
+	v := viper.New()
+	n, err := MergeLayers(v, dir, env)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("no config for environment %q: neither %s nor %s found in %s", env, BaseFile, EnvFile(env), dir)
+	}
+	return v, nil
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadMergesEnvOverBase(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, BaseFile), "db:\n  host: localhost\n  port: 5432\nlog_level: info\n")
+	writeFile(t, filepath.Join(dir, EnvFile("staging")), "db:\n  host: staging-db\n")
+
+	v, err := Load(dir, "staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{"db.host": "staging-db", "db.port": "5432", "log_level": "info"} {
+		if got := v.GetString(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if _, err := Load(t.TempDir(), "staging"); err == nil {
+		t.Error("Load of an empty dir succeeded")
+	}
+}
+
+func TestReplace(t *testing.T) {
+	dst := viper.New()
+	dst.SetDefault("timeout", "5s")
+	dst.Set("env", "production") // stands in for a bound flag
+	if err := dst.MergeConfigMap(map[string]any{"db": map[string]any{"host": "old", "user": "app"}, "stale": true}); err != nil {
+		t.Fatal(err)
+	}
+
+	src := viper.New()
+	if err := src.MergeConfigMap(map[string]any{"db": map[string]any{"host": "new"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Replace(dst, src); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key  string
+		want any
+	}{
+		{"db.host", "new"},
+		{"db.user", nil},
+		{"stale", nil},
+		{"timeout", "5s"},
+		{"env", "production"},
+	}
+	for _, tt := range tests {
+		if got := dst.Get(tt.key); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
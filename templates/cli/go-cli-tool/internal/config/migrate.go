@@ -0,0 +1,188 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"go.uber.org/zap"
+)
+
+// VersionKey holds the schema version of a config file; files without it are version 1
+const VersionKey = "config_version"
+
+// Migration transforms a config document from one schema version to the next
+type Migration func(map[string]any) (map[string]any, error)
+
+// Migrations[i] upgrades version i+1 to i+2. Append new steps; never edit
+// released ones, files in the wild depend on them.
+var Migrations = []Migration{
+	// v1 → v2: listener settings move under server
+	func(m map[string]any) (map[string]any, error) {
+		return m, moveKey(m, "bind_addr", "server.bind_addr")
+	},
+	// v2 → v3: flat metrics_* keys become the metrics section
+	func(m map[string]any) (map[string]any, error) {
+		if err := moveKey(m, "metrics_enabled", "metrics.enabled"); err != nil {
+			return nil, err
+		}
+		return m, moveKey(m, "metrics_listen", "metrics.listen")
+	},
+}
+
+// CurrentVersion is the schema version written by this build
+var CurrentVersion = len(Migrations) + 1
+
+// MigrateConfig upgrades the YAML or JSON config file at path to
+// targetVersion by applying each step in Migrations in turn, then replaces
+// the file atomically (temp file + rename). Downgrades are refused. Comments
+// and key order are not preserved.
+func MigrateConfig(path string, targetVersion int) error {
+	if targetVersion < 1 || targetVersion > CurrentVersion {
+		return fmt.Errorf("target version %d out of range (1..%d)", targetVersion, CurrentVersion)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var m map[string]any
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	if m == nil {
+		m = map[string]any{}
+	}
+
+	version, err := fileVersion(m)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if version > targetVersion {
+		return fmt.Errorf("%s is at version %d; downgrading to %d is not supported", path, version, targetVersion)
+	}
+	if version == targetVersion {
+		zap.L().Info("config already at target version", zap.String("file", path), zap.Int("version", version))
+		return nil
+	}
+
+	for v := version; v < targetVersion; v++ {
+		if m, err = Migrations[v-1](m); err != nil {
+			return fmt.Errorf("migrate %s from v%d to v%d: %w", path, v, v+1, err)
+		}
+		zap.L().Info("config migration applied", zap.String("file", path), zap.Int("from", v), zap.Int("to", v+1))
+	}
+	m[VersionKey] = targetVersion
+
+	var out []byte
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		out, err = json.MarshalIndent(m, "", "  ")
+		out = append(out, '\n')
+	} else {
+		out, err = yaml.Marshal(m)
+	}
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, out)
+}
+
+// fileVersion reads config_version; YAML and JSON decode numbers as float64
+func fileVersion(m map[string]any) (int, error) {
+	raw, ok := m[VersionKey]
+	if !ok {
+		return 1, nil
+	}
+	f, ok := raw.(float64)
+	if !ok || f != float64(int(f)) || f < 1 || int(f) > CurrentVersion {
+		return 0, fmt.Errorf("unsupported %s %v (this build knows 1..%d)", VersionKey, raw, CurrentVersion)
+	}
+	return int(f), nil
+}
+
+// moveKey moves the value at dotted path from to dotted path to, creating
+// intermediate maps. A missing source is a no-op; an existing target is an error.
+func moveKey(m map[string]any, from, to string) error {
+	val, ok := lookup(m, from)
+	if !ok {
+		return nil
+	}
+	parts := strings.Split(to, ".")
+	cur := m
+	for _, p := range parts[:len(parts)-1] {
+		next, ok := cur[p].(map[string]any)
+		if !ok {
+			if _, exists := cur[p]; exists {
+				return fmt.Errorf("cannot move %s to %s: %s is not a section", from, to, p)
+			}
+			next = map[string]any{}
+			cur[p] = next
+		}
+		cur = next
+	}
+	last := parts[len(parts)-1]
+	if _, exists := cur[last]; exists {
+		return fmt.Errorf("cannot move %s to %s: target already set", from, to)
+	}
+	cur[last] = val
+	deleteKey(m, from)
+	return nil
+}
+
+func lookup(m map[string]any, path string) (any, bool) {
+	parts := strings.Split(path, ".")
+	cur := m
+	for _, p := range parts[:len(parts)-1] {
+		next, ok := cur[p].(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		cur = next
+	}
+	v, ok := cur[parts[len(parts)-1]]
+	return v, ok
+}
+
+func deleteKey(m map[string]any, path string) {
+	parts := strings.Split(path, ".")
+	cur := m
+	for _, p := range parts[:len(parts)-1] {
+		next, ok := cur[p].(map[string]any)
+		if !ok {
+			return
+		}
+		cur = next
+	}
+	delete(cur, parts[len(parts)-1])
+}
+
+// writeFileAtomic replaces path with data so readers see either the old or
+// the new file, never a partial write; the original permissions are kept
+func writeFileAtomic(path string, data []byte) error {
+	mode := os.FileMode(0o644)
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
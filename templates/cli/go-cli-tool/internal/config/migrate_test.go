@@ -0,0 +1,131 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+)
+
+const v1Config = `log_level: debug
+bind_addr: ":9090"
+metrics_enabled: true
+metrics_listen: ":9100"
+`
+
+func readConfig(t *testing.T, path string) map[string]any {
+	t.Helper()
+	var m map[string]any
+	if err := yaml.Unmarshal(mustRead(t, path), &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestMigrateConfig(t *testing.T) {
+	for _, name := range []string{"config.yaml", "config.json"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			data := []byte(v1Config)
+			if strings.HasSuffix(name, ".json") {
+				var err error
+				if data, err = yaml.YAMLToJSON(data); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := os.WriteFile(path, data, 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := MigrateConfig(path, 3); err != nil {
+				t.Fatal(err)
+			}
+			m := readConfig(t, path)
+			want := map[string]any{
+				"config_version": float64(3),
+				"log_level":      "debug",
+				"server":         map[string]any{"bind_addr": ":9090"},
+				"metrics":        map[string]any{"enabled": true, "listen": ":9100"},
+			}
+			if got, _ := json.Marshal(m); string(got) != mustJSON(t, want) {
+				t.Errorf("migrated config = %s, want %s", got, mustJSON(t, want))
+			}
+			if fi, _ := os.Stat(path); fi.Mode().Perm() != 0o600 {
+				t.Errorf("mode = %v, want the original 0600", fi.Mode().Perm())
+			}
+			if name == "config.json" && !json.Valid(mustRead(t, path)) {
+				t.Error("JSON config rewritten in another format")
+			}
+		})
+	}
+}
+
+func TestMigrateConfigStepwise(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(path, []byte(v1Config), 0o644)
+
+	if err := MigrateConfig(path, 2); err != nil {
+		t.Fatal(err)
+	}
+	m := readConfig(t, path)
+	if m[VersionKey] != float64(2) || m["metrics_enabled"] != true || m["server"] == nil {
+		t.Errorf("v2 config = %v", m)
+	}
+	if err := MigrateConfig(path, 3); err != nil {
+		t.Fatal(err)
+	}
+	// already at the target: the file is left as is
+	before := mustRead(t, path)
+	if err := MigrateConfig(path, 3); err != nil || string(mustRead(t, path)) != string(before) {
+		t.Errorf("re-running the migration changed the file or failed: %v", err)
+	}
+}
+
+func TestMigrateConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		target  int
+		wantErr string
+	}{
+		{"downgrade", "config_version: 3\n", 2, "downgrading"},
+		{"unknown version", "config_version: 99\n", 3, "unsupported"},
+		{"target out of range", v1Config, 99, "out of range"},
+		{"target already set", "bind_addr: \":1\"\nserver:\n  bind_addr: \":2\"\n", 2, "already set"},
+		{"target not a section", "bind_addr: \":1\"\nserver: on\n", 2, "not a section"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			os.WriteFile(path, []byte(tt.content), 0o644)
+			err := MigrateConfig(path, tt.target)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+			if string(mustRead(t, path)) != tt.content {
+				t.Error("file modified by a failed migration")
+			}
+		})
+	}
+}
+
+func mustRead(t *testing.T, path string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
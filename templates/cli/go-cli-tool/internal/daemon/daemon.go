@@ -0,0 +1,61 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// childEnv marks the re-executed background process
+const childEnv = "TOOL_DAEMON_CHILD"
+
+// readyFD is the inherited pipe the child writes to once it is up
+const readyFD = 3
+
+// Config controls how the daemon is started
+type Config struct {
+	PIDFile string
+	LogFile string
+	Args    []string // arguments for the re-executed child (without argv[0])
+}
+
+var ErrNotRunning = errors.New("daemon is not running")
+
+// IsChild reports whether the current process is the daemonized child
+func IsChild() bool {
+	return os.Getenv(childEnv) == "1"
+}
+
+// ReadPID returns the PID stored in pidFile
+func ReadPID(pidFile string) (int, error) {
+	b, err := os.ReadFile(pidFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, ErrNotRunning
+		}
+		return 0, fmt.Errorf("read pid file: %w", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("invalid pid file %s", pidFile)
+	}
+	return pid, nil
+}
+
+// writePID atomically writes pid to pidFile
+func writePID(pidFile string, pid int) error {
+	tmp := pidFile + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.Itoa(pid)+"\n"), 0o644); err != nil {
+		return fmt.Errorf("write pid file: %w", err)
+	}
+	return os.Rename(tmp, pidFile)
+}
+
+// RemovePID deletes the PID file if it still belongs to the current process
+func RemovePID(pidFile string) {
+	if pid, err := ReadPID(pidFile); err == nil && pid == os.Getpid() {
+		os.Remove(pidFile)
+	}
+}
@@ -0,0 +1,75 @@
+//go:build linux
+
+package daemon
+
+import (
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestMain turns the re-executed test binary into a minimal daemon: it
+// reports ready, waits for SIGTERM and removes its PID file (os.Args[1])
+func TestMain(m *testing.M) {
+	if IsChild() {
+		term := make(chan os.Signal, 1)
+		signal.Notify(term, syscall.SIGTERM)
+		NotifyReady()
+		<-term
+		RemovePID(os.Args[1])
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestStartStop(t *testing.T) {
+	dir := t.TempDir()
+	pidFile := filepath.Join(dir, "tool.pid")
+	pid, err := Start(Config{PIDFile: pidFile, LogFile: filepath.Join(dir, "tool.log"), Args: []string{pidFile}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ReadPID(pidFile); err != nil || got != pid {
+		t.Fatalf("pid file holds %d (%v), want %d", got, err, pid)
+	}
+	if _, running, _ := Status(pidFile); !running {
+		t.Fatal("Status reports the daemon as not running")
+	}
+	if _, err := Start(Config{PIDFile: pidFile, LogFile: filepath.Join(dir, "tool.log")}); err == nil {
+		t.Error("second Start succeeded while the daemon runs")
+	}
+
+	if _, err := Stop(pidFile, false); err != nil {
+		t.Fatal(err)
+	}
+	// the daemon is our child, so reap it to read its exit status
+	exited := make(chan syscall.WaitStatus, 1)
+	go func() {
+		var ws syscall.WaitStatus
+		syscall.Wait4(pid, &ws, 0, nil)
+		exited <- ws
+	}()
+	select {
+	case ws := <-exited:
+		if !ws.Exited() || ws.ExitStatus() != 0 {
+			t.Errorf("daemon exit status = %v, want a clean exit", ws)
+		}
+	case <-time.After(5 * time.Second):
+		syscall.Kill(pid, syscall.SIGKILL)
+		t.Fatal("daemon did not exit after SIGTERM")
+	}
+	if _, err := ReadPID(pidFile); err != ErrNotRunning {
+		t.Errorf("pid file left behind: %v", err)
+	}
+}
+
+func TestReadPIDInvalid(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "tool.pid")
+	os.WriteFile(pidFile, []byte("not-a-pid\n"), 0o644)
+	if _, err := ReadPID(pidFile); err == nil || err == ErrNotRunning {
+		t.Errorf("ReadPID() = %v, want an invalid pid file error", err)
+	}
+}
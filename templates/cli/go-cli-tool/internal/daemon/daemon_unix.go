@@ -0,0 +1,138 @@
+//go:build !windows
+
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// Start forks a detached copy of the current binary, waits until it reports ready
+// over a pipe and records its PID. The caller (the parent) should exit 0 afterwards.
+func Start(cfg Config) (int, error) {
+	if pid, err := ReadPID(cfg.PIDFile); err == nil && alive(pid) {
+		return 0, fmt.Errorf("daemon already running with pid %d", pid)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return 0, fmt.Errorf("resolve executable: %w", err)
+	}
+
+	logFile, err := os.OpenFile(cfg.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return 0, fmt.Errorf("open log file: %w", err)
+	}
+	defer logFile.Close()
+
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		return 0, err
+	}
+	defer devNull.Close()
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return 0, fmt.Errorf("create ready pipe: %w", err)
+	}
+	defer readyR.Close()
+
+	argv := append([]string{exe}, cfg.Args...)
+	pid, err := syscall.ForkExec(exe, argv, &syscall.ProcAttr{
+		Env:   append(os.Environ(), childEnv+"=1"),
+		Files: []uintptr{devNull.Fd(), logFile.Fd(), logFile.Fd(), readyW.Fd()},
+		Sys:   &syscall.SysProcAttr{Setsid: true},
+	})
+	readyW.Close()
+	if err != nil {
+		return 0, fmt.Errorf("fork daemon: %w", err)
+	}
+
+	// the child writes one byte once initialised; EOF means it died
+	readyR.SetReadDeadline(time.Now().Add(10 * time.Second))
+	buf := make([]byte, 1)
+	if n, err := readyR.Read(buf); n != 1 {
+		return 0, fmt.Errorf("daemon failed to start (see %s): %v", cfg.LogFile, err)
+	}
+
+	if err := writePID(cfg.PIDFile, pid); err != nil {
+		syscall.Kill(pid, syscall.SIGTERM)
+		return 0, err
+	}
+	return pid, nil
+}
+
+// NotifyReady tells the waiting parent that the child has started
+func NotifyReady() {
+	f := os.NewFile(readyFD, "ready")
+	if f == nil {
+		return
+	}
+	f.Write([]byte{1})
+	f.Close()
+}
+
+// Stop sends SIGTERM (or SIGKILL when force is set) to the daemon in pidFile
+func Stop(pidFile string, force bool) (int, error) {
+	pid, err := ReadPID(pidFile)
+	if err != nil {
+		return 0, err
+	}
+	if !alive(pid) {
+		os.Remove(pidFile)
+		return pid, ErrNotRunning
+	}
+	sig := syscall.SIGTERM
+	if force {
+		sig = syscall.SIGKILL
+	}
+	if err := syscall.Kill(pid, sig); err != nil {
+		return pid, fmt.Errorf("signal daemon: %w", err)
+	}
+	if force {
+		os.Remove(pidFile)
+	}
+	return pid, nil
+}
+
+// Status returns the daemon PID and whether the process is alive
+func Status(pidFile string) (int, bool, error) {
+	pid, err := ReadPID(pidFile)
+	if err != nil {
+		return 0, false, err
+	}
+	return pid, alive(pid), nil
+}
+
+// ReopenLog reopens path and points stdout/stderr at it (for SIGUSR1 after rotation)
+func ReopenLog(path string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("reopen log file: %w", err)
+	}
+	defer f.Close()
+	if err := unix.Dup2(int(f.Fd()), int(os.Stdout.Fd())); err != nil {
+		return err
+	}
+	return unix.Dup2(int(f.Fd()), int(os.Stderr.Fd()))
+}
+
+// NotifyControl relays SIGHUP (reload config) and SIGUSR1 (reopen logs) to c
+func NotifyControl(c chan<- os.Signal) {
+	signal.Notify(c, syscall.SIGHUP, syscall.SIGUSR1)
+}
+
+// IsReload reports whether sig requests a config reload
+func IsReload(sig os.Signal) bool { return sig == syscall.SIGHUP }
+
+// IsReopen reports whether sig requests reopening log files
+func IsReopen(sig os.Signal) bool { return sig == syscall.SIGUSR1 }
+
+func alive(pid int) bool {
+	return syscall.Kill(pid, 0) == nil
+}
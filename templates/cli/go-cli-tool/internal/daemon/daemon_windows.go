@@ -0,0 +1,34 @@
+//go:build windows
+
+package daemon
+
+import (
+	"errors"
+	"os"
+)
+
+var errUnsupported = errors.New("daemon mode is not supported on windows; use a service manager instead")
+
+// Start is not supported on Windows
+func Start(cfg Config) (int, error) { return 0, errUnsupported }
+
+// NotifyReady is a no-op on Windows
+func NotifyReady() {}
+
+// Stop is not supported on Windows
+func Stop(pidFile string, force bool) (int, error) { return 0, errUnsupported }
+
+// Status is not supported on Windows
+func Status(pidFile string) (int, bool, error) { return 0, false, errUnsupported }
+
+// ReopenLog is not supported on Windows
+func ReopenLog(path string) error { return errUnsupported }
+
+// NotifyControl is a no-op on Windows (no SIGHUP/SIGUSR1)
+func NotifyControl(c chan<- os.Signal) {}
+
+// IsReload always returns false on Windows
+func IsReload(sig os.Signal) bool { return false }
+
+// IsReopen always returns false on Windows
+func IsReopen(sig os.Signal) bool { return false }
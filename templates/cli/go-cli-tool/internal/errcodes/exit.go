@@ -0,0 +1,27 @@
+package errcodes
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ExitCode maps an error to a process exit status: 2 for client-side (4xx) codes
+// such as invalid input, 1 for everything else.
+func ExitCode(err error) int {
+	var appErr *AppError
+	if errors.As(err, &appErr) && appErr.Status >= 400 && appErr.Status < 500 {
+		return 2
+	}
+	return 1
+}
+
+// Print writes err in a human-readable "error [CODE]: message" form
+func Print(w io.Writer, err error) {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		fmt.Fprintf(w, "error [%s]: %s\n", appErr.Code, appErr.Message)
+		return
+	}
+	fmt.Fprintln(w, err)
+}
@@ -0,0 +1,37 @@
+package errcodes
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"client error", New("INVALID_REQUEST", "bad flag"), 2},
+		{"wrapped client error", fmt.Errorf("run: %w", New("RESOURCE_NOT_FOUND", "file", "x")), 2},
+		{"server error", New("SERVICE_UNAVAILABLE"), 1},
+		{"plain error", errors.New("boom"), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrint(t *testing.T) {
+	var buf bytes.Buffer
+	Print(&buf, New("UNAUTHORIZED"))
+	Print(&buf, errors.New("boom"))
+	if want := "error [UNAUTHORIZED]: authentication required\nboom\n"; buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,111 @@
+package errcodes
+
+import (
+	_ "embed"
+	"fmt"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed codes.yaml
+var defaultCodes []byte
+
+const (
+	// CodeInternal is returned for unknown codes
+	CodeInternal = "INTERNAL_ERROR"
+	// DefaultLocale is used when a message has no translation for the requested locale
+	DefaultLocale = "en"
+)
+
+// AppError is the structured error shared by HTTP responses and CLI output
+type AppError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Status  int    `json:"-"`
+}
+
+func (e *AppError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+type codeDef struct {
+	Status   int               `yaml:"status"`
+	Messages map[string]string `yaml:"messages"`
+}
+
+// Registry maps error codes to localized message templates and HTTP statuses
+type Registry struct {
+	codes  map[string]codeDef
+	locale string
+}
+
+// Load parses a YAML code catalogue
+func Load(data []byte) (*Registry, error) {
+	codes := make(map[string]codeDef)
+	if err := yaml.Unmarshal(data, &codes); err != nil {
+		return nil, fmt.Errorf("parse error codes: %w", err)
+	}
+	if _, ok := codes[CodeInternal]; !ok {
+		return nil, fmt.Errorf("error codes: %s must be defined", CodeInternal)
+	}
+	for code, def := range codes {
+		if _, ok := def.Messages[DefaultLocale]; !ok {
+			return nil, fmt.Errorf("error codes: %s has no %q message", code, DefaultLocale)
+		}
+	}
+	return &Registry{codes: codes, locale: DefaultLocale}, nil
+}
+
+var (
+	defaultOnce     sync.Once
+	defaultRegistry *Registry
+)
+
+// Default returns the registry built from the embedded codes.yaml
+func Default() *Registry {
+	defaultOnce.Do(func() {
+		r, err := Load(defaultCodes)
+		if err != nil {
+			panic(err) // embedded catalogue is validated at build/test time
+		}
+		defaultRegistry = r
+	})
+	return defaultRegistry
+}
+
+// WithLocale returns a registry producing messages in lang, falling back to English
+func (r *Registry) WithLocale(lang string) *Registry {
+	return &Registry{codes: r.codes, locale: lang}
+}
+
+// New builds an AppError for code, interpolating args into the message template.
+// Unknown codes produce INTERNAL_ERROR.
+func (r *Registry) New(code string, args ...any) *AppError {
+	def, ok := r.codes[code]
+	if !ok {
+		code, def, args = CodeInternal, r.codes[CodeInternal], nil
+	}
+	tmpl, ok := def.Messages[r.locale]
+	if !ok {
+		tmpl = def.Messages[DefaultLocale]
+	}
+	msg := tmpl
+	if len(args) > 0 {
+		msg = fmt.Sprintf(tmpl, args...)
+	}
+	return &AppError{Code: code, Message: msg, Status: def.Status}
+}
+
+// Status returns the HTTP status for code (500 for unknown codes)
+func (r *Registry) Status(code string) int {
+	if def, ok := r.codes[code]; ok {
+		return def.Status
+	}
+	return r.codes[CodeInternal].Status
+}
+
+// New builds an AppError from the default registry
+func New(code string, args ...any) *AppError {
+	return Default().New(code, args...)
+}
@@ -0,0 +1,131 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+var breakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "http_client_circuit_state",
+	Help: "Circuit breaker state per client: 0 closed, 1 half-open, 2 open.",
+}, []string{"name"})
+
+// ErrCircuitOpen is returned without contacting the server while the circuit is open
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// CircuitBreakerConfig controls when the breaker trips and recovers
+type CircuitBreakerConfig struct {
+	FailureThreshold int           `mapstructure:"failure_threshold"` // consecutive failures that open the circuit
+	OpenTimeout      time.Duration `mapstructure:"open_timeout"`      // time before a half-open probe is allowed
+	HalfOpenMax      int           `mapstructure:"half_open_max"`     // concurrent probes while half-open
+}
+
+func (c CircuitBreakerConfig) withDefaults() CircuitBreakerConfig {
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = 5
+	}
+	if c.OpenTimeout <= 0 {
+		c.OpenTimeout = 30 * time.Second
+	}
+	if c.HalfOpenMax <= 0 {
+		c.HalfOpenMax = 1
+	}
+	return c
+}
+
+type state int
+
+const (
+	closed state = iota
+	halfOpen
+	open
+)
+
+func (s state) String() string {
+	return [...]string{"closed", "half-open", "open"}[s]
+}
+
+// CircuitBreaker is an http.RoundTripper that stops calling a failing upstream.
+// Transport errors and 5xx responses count as failures.
+type CircuitBreaker struct {
+	name string
+	cfg  CircuitBreakerConfig
+	next http.RoundTripper
+
+	mu       sync.Mutex
+	state    state
+	failures int
+	openedAt time.Time
+	probes   int
+}
+
+// NewCircuitBreaker wraps next (http.DefaultTransport when nil)
+func NewCircuitBreaker(name string, cfg CircuitBreakerConfig, next http.RoundTripper) *CircuitBreaker {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	breakerState.WithLabelValues(name).Set(float64(closed))
+	return &CircuitBreaker{name: name, cfg: cfg.withDefaults(), next: next}
+}
+
+// RoundTrip implements http.RoundTripper
+func (cb *CircuitBreaker) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !cb.allow() {
+		return nil, ErrCircuitOpen
+	}
+	resp, err := cb.next.RoundTrip(req)
+	cb.record(err == nil && resp.StatusCode < 500)
+	return resp, err
+}
+
+func (cb *CircuitBreaker) allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	switch cb.state {
+	case open:
+		if time.Since(cb.openedAt) < cb.cfg.OpenTimeout {
+			return false
+		}
+		cb.setState(halfOpen)
+		cb.probes = 0
+		fallthrough
+	case halfOpen:
+		if cb.probes >= cb.cfg.HalfOpenMax {
+			return false
+		}
+		cb.probes++
+	}
+	return true
+}
+
+func (cb *CircuitBreaker) record(success bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if success {
+		cb.failures = 0
+		if cb.state != closed {
+			cb.setState(closed)
+		}
+		return
+	}
+	cb.failures++
+	if cb.state == halfOpen || cb.failures >= cb.cfg.FailureThreshold {
+		cb.openedAt = time.Now()
+		cb.setState(open)
+	}
+}
+
+func (cb *CircuitBreaker) setState(s state) {
+	if cb.state != s {
+		zap.L().Info("circuit breaker state change", zap.String("client", cb.name),
+			zap.String("from", cb.state.String()), zap.String("to", s.String()))
+	}
+	cb.state = s
+	breakerState.WithLabelValues(cb.name).Set(float64(s))
+}
@@ -0,0 +1,274 @@
+// Package importer posts JSON Lines records to an HTTP endpoint in batches
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+
+	"github.com/example/tool/internal/progress"
+	"github.com/example/tool/internal/retry"
+)
+
+var recordsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "import_records_total",
+	Help: "Records handled by the import command, by result (succeeded|failed).",
+}, []string{"result"})
+
+// ErrorField is added to records written to the error file
+const ErrorField = "_error"
+
+// Importer sends batches of records to Endpoint
+type Importer struct {
+	Client      *http.Client
+	Endpoint    string
+	BatchSize   int               // records per POST (default 100)
+	Concurrency int               // batches in flight (default 1)
+	Retry       retry.RetryConfig // per batch; default 3 retries after the first attempt
+	Progress    progress.ProgressReporter
+}
+
+// Summary counts records by outcome
+type Summary struct {
+	Processed int `json:"processed"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+}
+
+// permanentError is a failure that retrying will not fix, e.g. a 4xx other than 429
+type permanentError struct{ error }
+
+type batch struct {
+	n       int // 1-based
+	records []json.RawMessage
+}
+
+type batchResult struct {
+	batch
+	err error
+}
+
+// Run reads one JSON object per line from in and POSTs them to Endpoint as
+// JSON arrays of up to BatchSize records. Batches that fail with a transport
+// error, 429 or 5xx are retried with backoff; records of batches that still
+// fail, and lines that are not JSON objects, are written to errOut as the
+// original object plus an "_error" field. errOut opens that file on the first
+// failure and is only used from the calling goroutine. total is the number of
+// records expected, used for progress (0 when unknown).
+func (im *Importer) Run(ctx context.Context, in io.Reader, total int, errOut func() (io.Writer, error)) (Summary, error) {
+	size := im.BatchSize
+	if size <= 0 {
+		size = 100
+	}
+	workers := im.Concurrency
+	if workers <= 0 {
+		workers = 1
+	}
+	rc := im.Retry
+	if rc.Operation == "" {
+		rc.Operation = "import"
+	}
+	if rc.MaxAttempts <= 0 {
+		rc.MaxAttempts = 4
+	}
+	rc.RetryOn = func(err error) bool {
+		var pe permanentError
+		return !errors.As(err, &pe)
+	}
+	reporter := im.Progress
+	if reporter == nil {
+		reporter, _ = progress.New(progress.ModeNone, io.Discard)
+	}
+	reporter.Start((total + size - 1) / size)
+
+	var (
+		sum     Summary
+		done    int // batches completed
+		failErr error
+		enc     *json.Encoder
+	)
+	// fail records lines that never reach the endpoint
+	fail := func(records []json.RawMessage, cause error) {
+		sum.Processed += len(records)
+		sum.Failed += len(records)
+		recordsTotal.WithLabelValues("failed").Add(float64(len(records)))
+		if failErr != nil {
+			return
+		}
+		if enc == nil {
+			w, err := errOut()
+			if err != nil {
+				failErr = fmt.Errorf("open error file: %w", err)
+				return
+			}
+			enc = json.NewEncoder(w)
+		}
+		for _, rec := range records {
+			if err := enc.Encode(withError(rec, cause)); err != nil {
+				failErr = fmt.Errorf("write error file: %w", err)
+				return
+			}
+		}
+	}
+
+	jobs := make(chan batch)
+	results := make(chan batchResult)
+	invalid := make(chan batchResult)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range jobs {
+				err := retry.Do(ctx, rc, func(ctx context.Context) error { return im.post(ctx, b.records) })
+				results <- batchResult{b, err}
+			}
+		}()
+	}
+
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(jobs)
+		defer close(invalid)
+		sc := bufio.NewScanner(in)
+		sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		var cur []json.RawMessage
+		n := 0
+		send := func() bool {
+			n++
+			select {
+			case jobs <- batch{n: n, records: cur}:
+				cur = nil
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		for line := 1; sc.Scan(); line++ {
+			raw := bytes.TrimSpace(sc.Bytes())
+			if len(raw) == 0 {
+				continue
+			}
+			var obj map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &obj); err != nil || obj == nil {
+				rec := json.RawMessage(append([]byte(nil), raw...))
+				select {
+				case invalid <- batchResult{batch{records: []json.RawMessage{rec}}, fmt.Errorf("line %d: not a JSON object", line)}:
+				case <-ctx.Done():
+					readErr <- ctx.Err()
+					return
+				}
+				continue
+			}
+			cur = append(cur, append(json.RawMessage(nil), raw...))
+			if len(cur) == size && !send() {
+				readErr <- ctx.Err()
+				return
+			}
+		}
+		if len(cur) > 0 && !send() {
+			readErr <- ctx.Err()
+			return
+		}
+		readErr <- sc.Err()
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// collect on this goroutine: the error file and the reporter are not shared
+	for results != nil || invalid != nil {
+		select {
+		case r, ok := <-invalid:
+			if !ok {
+				invalid = nil
+				continue
+			}
+			fail(r.records, r.err)
+		case r, ok := <-results:
+			if !ok {
+				results = nil
+				continue
+			}
+			if r.err != nil {
+				zap.L().Warn("import batch failed", zap.Int("batch", r.n), zap.Int("records", len(r.records)), zap.Error(r.err))
+				fail(r.records, r.err)
+			} else {
+				sum.Processed += len(r.records)
+				sum.Succeeded += len(r.records)
+				recordsTotal.WithLabelValues("succeeded").Add(float64(len(r.records)))
+			}
+			done++
+			reporter.Step(done, fmt.Sprintf("%d/%d records imported", sum.Succeeded, sum.Processed))
+		}
+	}
+
+	err := errors.Join(<-readErr, failErr)
+	if err == nil {
+		err = ctx.Err()
+	}
+	reporter.Done(err)
+	return sum, err
+}
+
+// post sends one batch; 2xx is success
+func (im *Importer) post(ctx context.Context, records []json.RawMessage) error {
+	body, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, im.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return permanentError{err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := im.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	switch {
+	case resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("endpoint returned %d", resp.StatusCode)
+	default:
+		return permanentError{fmt.Errorf("endpoint returned %d", resp.StatusCode)}
+	}
+}
+
+// withError returns rec with an "_error" field; lines that are not objects
+// are kept as a string under "_raw"
+func withError(rec json.RawMessage, cause error) map[string]any {
+	out := map[string]any{}
+	if err := json.Unmarshal(rec, &out); err != nil || out == nil {
+		out = map[string]any{"_raw": string(rec)}
+	}
+	out[ErrorField] = cause.Error()
+	return out
+}
+
+// CountRecords counts the non-empty lines of r, for progress totals
+func CountRecords(r io.Reader) (int, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	n := 0
+	for sc.Scan() {
+		if len(bytes.TrimSpace(sc.Bytes())) > 0 {
+			n++
+		}
+	}
+	return n, sc.Err()
+}
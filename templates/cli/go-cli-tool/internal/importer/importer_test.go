@@ -0,0 +1,155 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/example/tool/internal/retry"
+)
+
+type item struct {
+	ID int `json:"id"`
+}
+
+// flakyEndpoint fails every request carrying an ID divisible by 10 with 503,
+// and fails the first attempt of every other request with 502 to exercise retries
+type flakyEndpoint struct {
+	mu       sync.Mutex
+	attempts map[int]int
+	stored   map[int]bool
+}
+
+func (f *flakyEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var batch []item
+	if err := json.NewDecoder(r.Body).Decode(&batch); err != nil || r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "bad batch", http.StatusBadRequest)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, it := range batch {
+		if it.ID%10 == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+	}
+	f.attempts[batch[0].ID]++
+	if f.attempts[batch[0].ID] == 1 {
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	for _, it := range batch {
+		f.stored[it.ID] = true
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func newImporter(url string) *Importer {
+	return &Importer{
+		Client:      http.DefaultClient,
+		Endpoint:    url,
+		BatchSize:   1,
+		Concurrency: 4,
+		Retry:       retry.RetryConfig{InitialDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond},
+	}
+}
+
+func records(n int) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&b, "{\"id\":%d}\n", i)
+	}
+	return b.String()
+}
+
+func TestRunWritesFailedRecords(t *testing.T) {
+	endpoint := &flakyEndpoint{attempts: map[int]int{}, stored: map[int]bool{}}
+	srv := httptest.NewServer(endpoint)
+	defer srv.Close()
+
+	var errFile bytes.Buffer
+	sum, err := newImporter(srv.URL).Run(context.Background(), strings.NewReader(records(100)), 100,
+		func() (io.Writer, error) { return &errFile, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum != (Summary{Processed: 100, Succeeded: 90, Failed: 10}) {
+		t.Errorf("summary = %+v", sum)
+	}
+	if len(endpoint.stored) != 90 {
+		t.Errorf("endpoint stored %d records, want 90", len(endpoint.stored))
+	}
+
+	failed := map[int]bool{}
+	sc := bufio.NewScanner(&errFile)
+	for sc.Scan() {
+		var rec struct {
+			ID    int    `json:"id"`
+			Error string `json:"_error"`
+		}
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			t.Fatalf("error file line %q: %v", sc.Text(), err)
+		}
+		if rec.ID%10 != 0 || !strings.Contains(rec.Error, "503") {
+			t.Errorf("unexpected error record %s", sc.Text())
+		}
+		failed[rec.ID] = true
+	}
+	if len(failed) != 10 {
+		t.Errorf("error file holds %d records, want the 10 failing ones", len(failed))
+	}
+}
+
+func TestRunInvalidLines(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	var errFile bytes.Buffer
+	in := "{\"id\":1}\nnot json\n\n[1,2]\n{\"id\":2}\n"
+	sum, err := newImporter(srv.URL).Run(context.Background(), strings.NewReader(in), 0,
+		func() (io.Writer, error) { return &errFile, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum != (Summary{Processed: 4, Succeeded: 2, Failed: 2}) {
+		t.Errorf("summary = %+v", sum)
+	}
+	lines := strings.Split(strings.TrimSpace(errFile.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"_raw":"not json"`) || !strings.Contains(lines[0], "line 2") {
+		t.Errorf("error file = %s", errFile.String())
+	}
+}
+
+// A 4xx other than 429 is not retried
+func TestRunPermanentFailure(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}))
+	defer srv.Close()
+
+	im := newImporter(srv.URL)
+	im.Concurrency = 1
+	sum, err := im.Run(context.Background(), strings.NewReader(records(1)), 1,
+		func() (io.Writer, error) { return io.Discard, nil })
+	if err != nil || sum.Failed != 1 || calls != 1 {
+		t.Errorf("sum = %+v, err = %v, calls = %d; want one failed record after one call", sum, err, calls)
+	}
+}
+
+func TestCountRecords(t *testing.T) {
+	if n, err := CountRecords(strings.NewReader("{}\n\n  \n{}\n{}")); err != nil || n != 3 {
+		t.Errorf("CountRecords = %d, %v", n, err)
+	}
+}
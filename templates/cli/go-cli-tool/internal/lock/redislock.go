@@ -0,0 +1,136 @@
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/example/tool/internal/retry"
+)
+
+var acquisitionDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "lock_acquisition_duration_seconds",
+	Help:    "Time spent acquiring distributed locks, by outcome.",
+	Buckets: []float64{.001, .005, .01, .05, .1, .5, 1, 5, 10, 30},
+}, []string{"result"})
+
+var (
+	// ErrLockHeld is returned when another holder owns the lock after all retries
+	ErrLockHeld = errors.New("lock: already held")
+	// ErrNotHeld is returned by Release/Extend when the lock expired or was taken over
+	ErrNotHeld = errors.New("lock: not held")
+)
+
+// releaseScript deletes the key only if it still holds our token
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// extendScript resets the TTL only if the key still holds our token
+var extendScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// RedisLock hands out locks stored as Redis keys (SET NX PX)
+type RedisLock struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisLock wraps client; keys are stored as "lock:<key>"
+func NewRedisLock(client redis.UniversalClient) *RedisLock {
+	return &RedisLock{client: client, prefix: "lock:"}
+}
+
+// Lock is a held lock identified by a random holder token
+type Lock struct {
+	client redis.UniversalClient
+	key    string
+	token  string
+}
+
+// Key returns the Redis key backing the lock
+func (l *Lock) Key() string { return l.key }
+
+// AcquireWithRetry tries to take key for ttl, retrying with exponential backoff
+// while another holder owns it. retries is the number of additional attempts.
+func (rl *RedisLock) AcquireWithRetry(ctx context.Context, key string, ttl time.Duration, retries int) (*Lock, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	l := &Lock{client: rl.client, key: rl.prefix + key, token: token}
+
+	start := time.Now()
+	err = retry.Do(ctx, retry.RetryConfig{
+		Operation:    "lock_acquire",
+		MaxAttempts:  retries + 1,
+		InitialDelay: 100 * time.Millisecond,
+		MaxDelay:     5 * time.Second,
+		Multiplier:   2,
+		Jitter:       true,
+		RetryOn:      func(err error) bool { return errors.Is(err, ErrLockHeld) },
+	}, func(ctx context.Context) error {
+		ok, err := rl.client.SetNX(ctx, l.key, token, ttl).Result()
+		if err != nil {
+			return fmt.Errorf("lock: acquire %s: %w", key, err)
+		}
+		if !ok {
+			return ErrLockHeld
+		}
+		return nil
+	})
+
+	result := "acquired"
+	if err != nil {
+		result = "failed"
+	}
+	acquisitionDuration.WithLabelValues(result).Observe(time.Since(start).Seconds())
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Release deletes the lock if this holder still owns it
+func (l *Lock) Release(ctx context.Context) error {
+	n, err := releaseScript.Run(ctx, l.client, []string{l.key}, l.token).Int()
+	if err != nil {
+		return fmt.Errorf("lock: release %s: %w", l.key, err)
+	}
+	if n == 0 {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// Extend resets the lock TTL if this holder still owns it
+func (l *Lock) Extend(ctx context.Context, ttl time.Duration) error {
+	n, err := extendScript.Run(ctx, l.client, []string{l.key}, l.token, ttl.Milliseconds()).Int()
+	if err != nil {
+		return fmt.Errorf("lock: extend %s: %w", l.key, err)
+	}
+	if n == 0 {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("lock: generate token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,75 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestLock(t *testing.T) (*RedisLock, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	return NewRedisLock(redis.NewClient(&redis.Options{Addr: mr.Addr()})), mr
+}
+
+func TestAcquireExcludesOtherHolders(t *testing.T) {
+	rl, mr := newTestLock(t)
+	ctx := context.Background()
+
+	first, err := rl.AcquireWithRetry(ctx, "job", time.Minute, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := mr.Get("lock:job"); got != first.token {
+		t.Errorf("lock:job = %q, want holder token", got)
+	}
+	if ttl := mr.TTL("lock:job"); ttl != time.Minute {
+		t.Errorf("TTL = %v, want 1m", ttl)
+	}
+	if _, err := rl.AcquireWithRetry(ctx, "job", time.Minute, 1); !errors.Is(err, ErrLockHeld) {
+		t.Errorf("second acquire err = %v, want ErrLockHeld", err)
+	}
+
+	if err := first.Release(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rl.AcquireWithRetry(ctx, "job", time.Minute, 0); err != nil {
+		t.Errorf("acquire after release: %v", err)
+	}
+}
+
+func TestReleaseAndExtendCheckOwnership(t *testing.T) {
+	rl, mr := newTestLock(t)
+	ctx := context.Background()
+
+	l, err := rl.AcquireWithRetry(ctx, "job", time.Second, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Extend(ctx, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := mr.TTL("lock:job"); ttl != time.Minute {
+		t.Errorf("TTL after Extend = %v, want 1m", ttl)
+	}
+
+	// the lock expires and another holder takes it over
+	mr.FastForward(2 * time.Minute)
+	other, err := rl.AcquireWithRetry(ctx, "job", time.Minute, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Extend(ctx, time.Minute); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("stale Extend err = %v, want ErrNotHeld", err)
+	}
+	if err := l.Release(ctx); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("stale Release err = %v, want ErrNotHeld", err)
+	}
+	if got, _ := mr.Get("lock:job"); got != other.token {
+		t.Error("stale holder released the new holder's lock")
+	}
+}
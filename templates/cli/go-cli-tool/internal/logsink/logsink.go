@@ -0,0 +1,125 @@
+// Package logsink builds zap loggers that write to several outputs at once:
+// stdout, stderr, rotated files and syslog
+package logsink
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Build returns a logger configured like cfg.Build() (level, encoding,
+// sampling, development mode) that writes to outputs instead of
+// cfg.OutputPaths. Accepted outputs:
+//
+//	stdout, stderr
+//	file:///var/log/app.log[?max_size_mb=100&max_backups=5&max_age_days=28&compress=true]
+//	syslog:///dev/log, syslog://host:514 (UDP), syslog+tcp://host:514
+//
+// The returned func closes files and syslog connections; call it when the
+// logger is replaced.
+func Build(cfg zap.Config, outputs []string) (*zap.Logger, func() error, error) {
+	var enc zapcore.Encoder
+	if cfg.Encoding == "console" {
+		enc = zapcore.NewConsoleEncoder(cfg.EncoderConfig)
+	} else {
+		enc = zapcore.NewJSONEncoder(cfg.EncoderConfig)
+	}
+
+	var (
+		writers []zapcore.WriteSyncer
+		cores   []zapcore.Core
+		closers []func() error
+	)
+	closeAll := func() error {
+		var errs []error
+		for _, c := range closers {
+			errs = append(errs, c())
+		}
+		return errors.Join(errs...)
+	}
+	for _, out := range outputs {
+		switch {
+		case out == "stdout":
+			writers = append(writers, zapcore.Lock(os.Stdout))
+		case out == "stderr":
+			writers = append(writers, zapcore.Lock(os.Stderr))
+		case strings.HasPrefix(out, "file://"):
+			lj, err := newFileWriter(out)
+			if err != nil {
+				closeAll()
+				return nil, nil, err
+			}
+			writers = append(writers, zapcore.AddSync(lj))
+			closers = append(closers, lj.Close)
+		case strings.HasPrefix(out, "syslog://"), strings.HasPrefix(out, "syslog+tcp://"):
+			core, closeFn, err := newSyslogCore(out, enc, cfg.Level)
+			if err != nil {
+				closeAll()
+				return nil, nil, fmt.Errorf("log output %s: %w", out, err)
+			}
+			cores = append(cores, core)
+			closers = append(closers, closeFn)
+		default:
+			closeAll()
+			return nil, nil, fmt.Errorf("unknown log output %q (want stdout, stderr, file://path or syslog://addr)", out)
+		}
+	}
+	if len(writers) > 0 {
+		cores = append(cores, zapcore.NewCore(enc, zapcore.NewMultiWriteSyncer(writers...), cfg.Level))
+	}
+
+	core := zapcore.NewTee(cores...)
+	if cfg.Sampling != nil {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, cfg.Sampling.Initial, cfg.Sampling.Thereafter)
+	}
+	// the same options zap.Config.Build derives from cfg
+	opts := []zap.Option{zap.ErrorOutput(zapcore.Lock(os.Stderr))}
+	stackLevel := zapcore.ErrorLevel
+	if cfg.Development {
+		opts = append(opts, zap.Development())
+		stackLevel = zapcore.WarnLevel
+	}
+	if !cfg.DisableCaller {
+		opts = append(opts, zap.AddCaller())
+	}
+	if !cfg.DisableStacktrace {
+		opts = append(opts, zap.AddStacktrace(stackLevel))
+	}
+	return zap.New(core, opts...), closeAll, nil
+}
+
+// newFileWriter opens a size-rotated log file; rotation settings come from
+// the URL query
+func newFileWriter(raw string) (*lumberjack.Logger, error) {
+	path, query, _ := strings.Cut(strings.TrimPrefix(raw, "file://"), "?")
+	if path == "" {
+		return nil, fmt.Errorf("log output %q has no file path", raw)
+	}
+	q, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("log output %q: %w", raw, err)
+	}
+	lj := &lumberjack.Logger{Filename: path, MaxSize: 100, MaxBackups: 5, MaxAge: 28, Compress: true}
+	for key, dst := range map[string]*int{"max_size_mb": &lj.MaxSize, "max_backups": &lj.MaxBackups, "max_age_days": &lj.MaxAge} {
+		if v := q.Get(key); v != "" {
+			if *dst, err = strconv.Atoi(v); err != nil {
+				return nil, fmt.Errorf("log output %q: %s: %w", raw, key, err)
+			}
+		}
+	}
+	if v := q.Get("compress"); v != "" {
+		if lj.Compress, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("log output %q: compress: %w", raw, err)
+		}
+	}
+	return lj, nil
+}
@@ -0,0 +1,14 @@
+//go:build windows || nosyslog
+
+package logsink
+
+import (
+	"errors"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// newSyslogCore is unavailable: built for Windows or with -tags nosyslog
+func newSyslogCore(string, zapcore.Encoder, zapcore.LevelEnabler) (zapcore.Core, func() error, error) {
+	return nil, nil, errors.New("syslog output is not supported by this build")
+}
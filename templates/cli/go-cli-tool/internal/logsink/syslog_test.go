@@ -0,0 +1,53 @@
+//go:build !windows && !nosyslog
+
+package logsink
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Each zap level arrives with its syslog severity (PRI = facility daemon*8 + severity)
+func TestSyslogSeverity(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	cfg := zap.NewProductionConfig()
+	cfg.Level = zap.NewAtomicLevelAt(zap.DebugLevel)
+	logger, closeFn, err := Build(cfg, []string{"syslog://" + conn.LocalAddr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeFn()
+
+	tests := []struct {
+		log     func(string, ...zap.Field)
+		msg     string
+		wantPRI string
+	}{
+		{logger.Debug, "debug line", "<31>"},
+		{logger.Info, "info line", "<30>"},
+		{logger.Warn, "warn line", "<28>"},
+		{logger.Error, "error line", "<27>"},
+	}
+	buf := make([]byte, 4096)
+	for _, tt := range tests {
+		tt.log(tt.msg)
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.msg, err)
+		}
+		packet := string(buf[:n])
+		if !strings.HasPrefix(packet, tt.wantPRI) || !strings.Contains(packet, `"msg":"`+tt.msg+`"`) {
+			t.Errorf("packet = %q, want priority %s", packet, tt.wantPRI)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	otelprom "go.opentelemetry.io/contrib/bridges/prometheus"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// ExporterPrometheus serves /metrics for scraping only, without pushing
+const ExporterPrometheus = "prometheus"
+
+// PushConfig configures pushing Prometheus metrics over OTLP
+type PushConfig struct {
+	Exporter string        `mapstructure:"exporter"` // prometheus | otlp-grpc | otlp-http | stdout
+	Endpoint string        `mapstructure:"endpoint"` // collector host:port; empty uses OTEL_EXPORTER_OTLP_* env vars
+	Insecure bool          `mapstructure:"insecure"`
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+// StartPush bridges the default Prometheus registry to the configured OTLP
+// exporter. Scraping /metrics keeps working; the push is an additional copy.
+// The returned func flushes pending data and stops pushing.
+func StartPush(ctx context.Context, cfg PushConfig) (func(), error) {
+	var (
+		exp sdkmetric.Exporter
+		err error
+	)
+	switch cfg.Exporter {
+	case "", ExporterPrometheus:
+		return func() {}, nil
+	case "otlp-grpc":
+		opts := []otlpmetricgrpc.Option{}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlpmetricgrpc.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlpmetricgrpc.WithInsecure())
+		}
+		exp, err = otlpmetricgrpc.New(ctx, opts...)
+	case "otlp-http":
+		opts := []otlpmetrichttp.Option{}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlpmetrichttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		}
+		exp, err = otlpmetrichttp.New(ctx, opts...)
+	case "stdout":
+		exp, err = stdoutmetric.New()
+	default:
+		return nil, fmt.Errorf("unknown metrics exporter %q (want prometheus, otlp-grpc, otlp-http or stdout)", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create %s metrics exporter: %w", cfg.Exporter, err)
+	}
+
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exp,
+		sdkmetric.WithInterval(interval),
+		sdkmetric.WithProducer(otelprom.NewMetricProducer()),
+	)))
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		mp.Shutdown(ctx)
+	}, nil
+}
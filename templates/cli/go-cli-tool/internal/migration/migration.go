@@ -0,0 +1,164 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/pgx/v5" // registers pgx5://
+	_ "github.com/golang-migrate/migrate/v4/source/file"     // registers file://
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+var (
+	appliedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "migration_applied_total",
+		Help: "Database migrations applied, by direction.",
+	}, []string{"direction"})
+	failedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "migration_failed_total",
+		Help: "Database migrations that failed, by direction.",
+	}, []string{"direction"})
+)
+
+// Migrator applies SQL migrations from a directory to a PostgreSQL database
+type Migrator struct {
+	m   *migrate.Migrate
+	dir string
+}
+
+// New opens the migrations in dir against dsn. postgres:// and postgresql://
+// DSNs are rewritten to the pgx5:// scheme used by the driver.
+func New(dsn, dir string) (*Migrator, error) {
+	if dsn == "" {
+		return nil, errors.New("database.dsn is not set")
+	}
+	for _, prefix := range []string{"postgres://", "postgresql://"} {
+		if strings.HasPrefix(dsn, prefix) {
+			dsn = "pgx5://" + strings.TrimPrefix(dsn, prefix)
+			break
+		}
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	m, err := migrate.New("file://"+filepath.ToSlash(abs), dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open migrations: %w", err)
+	}
+	return &Migrator{m: m, dir: dir}, nil
+}
+
+// Up applies up to steps pending migrations; 0 applies all. It returns the number applied.
+func (mg *Migrator) Up(steps int) (int, error) {
+	return mg.step("up", 1, steps)
+}
+
+// Down rolls back up to steps migrations; 0 rolls back all. It returns the number rolled back.
+func (mg *Migrator) Down(steps int) (int, error) {
+	return mg.step("down", -1, steps)
+}
+
+// step moves one migration at a time so each one is counted and a failure
+// can be attributed to a specific version and file
+func (mg *Migrator) step(direction string, delta, limit int) (int, error) {
+	n := 0
+	for limit == 0 || n < limit {
+		before, _, _ := mg.m.Version()
+		err := mg.m.Steps(delta)
+		if errors.Is(err, migrate.ErrNoChange) || errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		if err != nil {
+			failedTotal.WithLabelValues(direction).Inc()
+			// a failed up leaves the target version dirty; a failed down is the version we started from
+			version := before
+			if direction == "up" {
+				version, _, _ = mg.m.Version()
+			}
+			zap.L().Error("migration failed",
+				zap.String("direction", direction),
+				zap.Uint("version", version),
+				zap.String("file", mg.filename(version, direction)),
+				zap.Error(err),
+			)
+			return n, fmt.Errorf("migration %d %s: %w", version, direction, err)
+		}
+		appliedTotal.WithLabelValues(direction).Inc()
+		n++
+		version, _, _ := mg.m.Version()
+		zap.L().Info("migration applied", zap.String("direction", direction), zap.Uint("version", version))
+	}
+	return n, nil
+}
+
+// ErrNilVersion is returned by Version when no migration has been applied yet
+var ErrNilVersion = migrate.ErrNilVersion
+
+// Version returns the current schema version and whether the last migration
+// left it dirty
+func (mg *Migrator) Version() (version uint, dirty bool, err error) {
+	return mg.m.Version()
+}
+
+// Force sets the schema version without running migrations and clears the dirty flag
+func (mg *Migrator) Force(version int) error {
+	return mg.m.Force(version)
+}
+
+// Close releases the source and database connections
+func (mg *Migrator) Close() error {
+	srcErr, dbErr := mg.m.Close()
+	return errors.Join(srcErr, dbErr)
+}
+
+// filename finds the migration file for version, or "" if none matches
+func (mg *Migrator) filename(version uint, direction string) string {
+	matches, _ := filepath.Glob(filepath.Join(mg.dir, fmt.Sprintf("%d_*.%s.sql", version, direction)))
+	if len(matches) == 0 {
+		return ""
+	}
+	return matches[0]
+}
+
+var nameSanitizer = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Create writes empty <timestamp>_<name>.up.sql and .down.sql files to dir
+// and returns their paths
+func Create(dir, name string) (up, down string, err error) {
+	name = strings.Trim(nameSanitizer.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return "", "", errors.New("migration name must contain letters or digits")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", "", err
+	}
+	base := filepath.Join(dir, time.Now().UTC().Format("20060102150405")+"_"+name)
+	up, down = base+".up.sql", base+".down.sql"
+	for _, path := range []string{up, down} {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return "", "", err
+		}
+		f.Close()
+	}
+	return up, down, nil
+}
+
+// ParseVersion parses a migration version argument such as 20240101120000
+func ParseVersion(s string) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil || v < -1 {
+		return 0, fmt.Errorf("invalid migration version %q", s)
+	}
+	return v, nil
+}
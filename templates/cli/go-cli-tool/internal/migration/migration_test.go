@@ -0,0 +1,122 @@
+package migration
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite3" // registers sqlite3://
+)
+
+// newTestMigrator writes two migrations plus extra and opens them against a
+// temporary SQLite database
+func newTestMigrator(t *testing.T, extra map[string]string) *Migrator {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"1_create_users.up.sql":     "CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT NOT NULL);",
+		"1_create_users.down.sql":   "DROP TABLE users;",
+		"2_add_users_name.up.sql":   "ALTER TABLE users ADD COLUMN name TEXT;",
+		"2_add_users_name.down.sql": "ALTER TABLE users DROP COLUMN name;",
+	}
+	for name, sql := range extra {
+		files[name] = sql
+	}
+	for name, sql := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(sql), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mg, err := New("sqlite3://"+filepath.Join(t.TempDir(), "test.db"), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { mg.Close() })
+	return mg
+}
+
+func wantVersion(t *testing.T, mg *Migrator, want uint) {
+	t.Helper()
+	v, dirty, err := mg.Version()
+	if want == 0 {
+		if !errors.Is(err, ErrNilVersion) {
+			t.Errorf("Version = %d, %v, want no version", v, err)
+		}
+		return
+	}
+	if err != nil || v != want || dirty {
+		t.Errorf("Version = %d dirty=%v err=%v, want %d clean", v, dirty, err, want)
+	}
+}
+
+func TestUpDownIdempotent(t *testing.T) {
+	mg := newTestMigrator(t, nil)
+	for cycle := 0; cycle < 2; cycle++ {
+		if n, err := mg.Up(0); err != nil || n != 2 {
+			t.Fatalf("cycle %d: Up = %d, %v, want 2", cycle, n, err)
+		}
+		wantVersion(t, mg, 2)
+		if n, err := mg.Up(0); err != nil || n != 0 {
+			t.Fatalf("cycle %d: repeated Up = %d, %v, want 0", cycle, n, err)
+		}
+		if n, err := mg.Down(0); err != nil || n != 2 {
+			t.Fatalf("cycle %d: Down = %d, %v, want 2", cycle, n, err)
+		}
+		wantVersion(t, mg, 0)
+		if n, err := mg.Down(0); err != nil || n != 0 {
+			t.Fatalf("cycle %d: repeated Down = %d, %v, want 0", cycle, n, err)
+		}
+	}
+}
+
+func TestUpSteps(t *testing.T) {
+	mg := newTestMigrator(t, nil)
+	if n, err := mg.Up(1); err != nil || n != 1 {
+		t.Fatalf("Up(1) = %d, %v", n, err)
+	}
+	wantVersion(t, mg, 1)
+	if n, err := mg.Up(5); err != nil || n != 1 {
+		t.Fatalf("Up(5) = %d, %v, want the one remaining migration", n, err)
+	}
+	if n, err := mg.Down(1); err != nil || n != 1 {
+		t.Fatalf("Down(1) = %d, %v", n, err)
+	}
+	wantVersion(t, mg, 1)
+}
+
+func TestFailedMigrationReportsVersion(t *testing.T) {
+	mg := newTestMigrator(t, map[string]string{"3_broken.up.sql": "NOT SQL;", "3_broken.down.sql": ""})
+	n, err := mg.Up(0)
+	if err == nil || n != 2 || !strings.Contains(err.Error(), "migration 3 up") {
+		t.Fatalf("Up = %d, %v, want 2 applied then a version 3 failure", n, err)
+	}
+	if got := mg.filename(3, "up"); filepath.Base(got) != "3_broken.up.sql" {
+		t.Errorf("filename = %q", got)
+	}
+	if _, dirty, _ := mg.Version(); !dirty {
+		t.Error("failed migration did not leave the version dirty")
+	}
+	if err := mg.Force(2); err != nil {
+		t.Fatal(err)
+	}
+	wantVersion(t, mg, 2)
+}
+
+func TestCreate(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "migrations")
+	up, down, err := Create(dir, "Add Users Table!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(up, "_add_users_table.up.sql") || !strings.HasSuffix(down, "_add_users_table.down.sql") {
+		t.Errorf("files = %s, %s", up, down)
+	}
+	if _, err := ParseVersion(strings.SplitN(filepath.Base(up), "_", 2)[0]); err != nil {
+		t.Errorf("timestamp prefix: %v", err)
+	}
+	if _, _, err := Create(dir, "!!!"); err == nil {
+		t.Error("Create accepted a name without letters or digits")
+	}
+}
@@ -0,0 +1,45 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Formats accepted by --output
+const (
+	FormatTable = "table"
+	FormatCSV   = "csv"
+	FormatJSON  = "json"
+	FormatYAML  = "yaml"
+)
+
+// Render writes data in the requested format. Tabular formats use headers/rows,
+// structured formats marshal v.
+func Render(w io.Writer, format string, headers []string, rows [][]string, v any) error {
+	switch format {
+	case FormatTable:
+		PrintTable(w, headers, rows)
+		return nil
+	case FormatCSV:
+		return PrintCSV(w, headers, rows)
+	case FormatJSON:
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	case FormatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	default:
+		return fmt.Errorf("unknown output format %q (expected table|csv|json|yaml)", format)
+	}
+}
@@ -0,0 +1,121 @@
+package output
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattn/go-isatty"
+)
+
+// ColorEnabled selects Unicode box-drawing tables. It defaults to true only when stdout
+// is a terminal, so piped output stays plain ASCII; --color/--no-color override it.
+var ColorEnabled = isatty.IsTerminal(os.Stdout.Fd())
+
+// MaxColumnWidth caps a column; longer cells are truncated with an ellipsis
+var MaxColumnWidth = 60
+
+type border struct {
+	h, v                               string
+	tl, tm, tr, ml, mm, mr, bl, bm, br string
+}
+
+var (
+	asciiBorder = border{
+		h: "-", v: "|",
+		tl: "+", tm: "+", tr: "+",
+		ml: "+", mm: "+", mr: "+",
+		bl: "+", bm: "+", br: "+",
+	}
+	unicodeBorder = border{
+		h: "─", v: "│",
+		tl: "┌", tm: "┬", tr: "┐",
+		ml: "├", mm: "┼", mr: "┤",
+		bl: "└", bm: "┴", br: "┘",
+	}
+)
+
+// PrintTable renders rows as an aligned table. Column widths are computed from the
+// headers and data, capped at MaxColumnWidth.
+func PrintTable(w io.Writer, headers []string, rows [][]string) {
+	b := asciiBorder
+	if ColorEnabled {
+		b = unicodeBorder
+	}
+
+	widths := make([]int, len(headers))
+	for i, h := range headers {
+		widths[i] = utf8.RuneCountInString(h)
+	}
+	for _, row := range rows {
+		for i := 0; i < len(row) && i < len(widths); i++ {
+			if n := utf8.RuneCountInString(row[i]); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	for i := range widths {
+		if widths[i] > MaxColumnWidth {
+			widths[i] = MaxColumnWidth
+		}
+	}
+
+	line := func(left, mid, right string) {
+		parts := make([]string, len(widths))
+		for i, wd := range widths {
+			parts[i] = strings.Repeat(b.h, wd+2)
+		}
+		fmt.Fprintln(w, left+strings.Join(parts, mid)+right)
+	}
+	row := func(cells []string) {
+		var sb strings.Builder
+		sb.WriteString(b.v)
+		for i, wd := range widths {
+			cell := ""
+			if i < len(cells) {
+				cell = truncate(cells[i], wd)
+			}
+			sb.WriteString(" ")
+			sb.WriteString(cell)
+			sb.WriteString(strings.Repeat(" ", wd-utf8.RuneCountInString(cell)))
+			sb.WriteString(" ")
+			sb.WriteString(b.v)
+		}
+		fmt.Fprintln(w, sb.String())
+	}
+
+	line(b.tl, b.tm, b.tr)
+	row(headers)
+	line(b.ml, b.mm, b.mr)
+	for _, r := range rows {
+		row(r)
+	}
+	line(b.bl, b.bm, b.br)
+}
+
+// PrintCSV writes headers and rows as RFC 4180 CSV
+func PrintCSV(w io.Writer, headers []string, rows [][]string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(headers); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// truncate shortens s to max runes, replacing the tail with "…"
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	if max <= 1 {
+		return "…"
+	}
+	r := []rune(s)
+	return string(r[:max-1]) + "…"
+}
@@ -0,0 +1,81 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestPrintTable(t *testing.T) {
+	defer func(c bool, w int) { ColorEnabled, MaxColumnWidth = c, w }(ColorEnabled, MaxColumnWidth)
+	MaxColumnWidth = 10
+	headers := []string{"KEY", "VALUE"}
+	rows := [][]string{
+		{"log_level", "info"},
+		{"database.url", "postgres://localhost:5432/app"},
+	}
+
+	for _, color := range []bool{false, true} {
+		ColorEnabled = color
+		var buf bytes.Buffer
+		PrintTable(&buf, headers, rows)
+		lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+		if len(lines) != 6 {
+			t.Fatalf("color=%v: %d lines:\n%s", color, len(lines), buf.String())
+		}
+		for _, l := range lines[1:] {
+			if utf8.RuneCountInString(l) != utf8.RuneCountInString(lines[0]) {
+				t.Errorf("color=%v: ragged line %q", color, l)
+			}
+		}
+		if !strings.Contains(lines[4], "database.…") || !strings.Contains(lines[4], "postgres:…") {
+			t.Errorf("color=%v: long cells not truncated: %q", color, lines[4])
+		}
+	}
+	ColorEnabled = false
+	var buf bytes.Buffer
+	PrintTable(&buf, headers, rows[:1])
+	want := "" +
+		"+-----------+-------+\n" +
+		"| KEY       | VALUE |\n" +
+		"+-----------+-------+\n" +
+		"| log_level | info  |\n" +
+		"+-----------+-------+\n"
+	if buf.String() != want {
+		t.Errorf("table =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestRenderCSV(t *testing.T) {
+	var buf bytes.Buffer
+	err := Render(&buf, FormatCSV, []string{"key", "value"}, [][]string{{"greeting", `hello, "world"`}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "key,value\ngreeting,\"hello, \"\"world\"\"\"\n"; buf.String() != want {
+		t.Errorf("csv = %q, want %q", buf.String(), want)
+	}
+	if err := Render(&buf, "xml", nil, nil, nil); err == nil {
+		t.Error("unknown format accepted")
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		max  int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"exactly10!", 10, "exactly10!"},
+		{"much too long", 5, "much…"},
+		{"ünïcödé", 4, "ünï…"},
+		{"abc", 1, "…"},
+	}
+	for _, tt := range tests {
+		if got := truncate(tt.s, tt.max); got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.s, tt.max, got, tt.want)
+		}
+	}
+}
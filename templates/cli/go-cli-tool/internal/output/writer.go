@@ -0,0 +1,193 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap"
+)
+
+// Item stream formats accepted by NewWriter (FormatCSV is shared with Render)
+const (
+	FormatJSONL = "jsonl"
+	FormatTSV   = "tsv"
+)
+
+// ItemWriter streams result items to a file as they are produced
+type ItemWriter interface {
+	Write(v any) error
+	Close() error
+}
+
+// Discard is an ItemWriter that drops every item
+var Discard ItemWriter = discard{}
+
+type discard struct{}
+
+func (discard) Write(any) error { return nil }
+func (discard) Close() error    { return nil }
+
+// NewWriter opens path ("-" for stdout) and returns a writer encoding items
+// as JSON lines, or as CSV/TSV with a header row taken from the first item's
+// fields. Each item is flushed through to the file (and gzip stream) before
+// Write returns, so partial output survives a crash and readers can tail it.
+// A closed downstream pipe (EPIPE) is logged once; later items are dropped.
+func NewWriter(path, format string, compress bool) (ItemWriter, error) {
+	switch format {
+	case FormatJSONL, FormatCSV, FormatTSV:
+	default:
+		return nil, fmt.Errorf("unknown output format %q (expected jsonl|csv|tsv)", format)
+	}
+
+	var f *os.File
+	if path == "-" {
+		// without a handler, writing to a closed stdout pipe kills the process
+		signal.Notify(make(chan os.Signal, 1), syscall.SIGPIPE)
+		f = os.Stdout
+	} else {
+		var err error
+		if f, err = os.Create(path); err != nil {
+			return nil, err
+		}
+	}
+
+	w := &itemWriter{file: f, format: format, buf: bufio.NewWriter(f)}
+	w.out = w.buf
+	if compress {
+		w.gz = gzip.NewWriter(w.buf)
+		w.out = w.gz
+	}
+	if format != FormatJSONL {
+		w.csv = csv.NewWriter(w.out)
+		if format == FormatTSV {
+			w.csv.Comma = '\t'
+		}
+	}
+	return w, nil
+}
+
+type itemWriter struct {
+	file   *os.File
+	format string
+	buf    *bufio.Writer
+	gz     *gzip.Writer
+	out    io.Writer
+	csv    *csv.Writer
+	header []string
+	broken bool
+}
+
+func (w *itemWriter) Write(v any) error {
+	if w.broken {
+		return nil
+	}
+	if err := w.encode(v); err != nil {
+		return w.check(err)
+	}
+	return w.check(w.flush())
+}
+
+func (w *itemWriter) encode(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if w.csv == nil {
+		_, err = w.out.Write(append(b, '\n'))
+		return err
+	}
+
+	keys, values, err := flatten(b)
+	if err != nil {
+		return err
+	}
+	if w.header == nil {
+		w.header = keys
+		if err := w.csv.Write(w.header); err != nil {
+			return err
+		}
+	}
+	row := make([]string, len(w.header))
+	for i, k := range w.header {
+		row[i] = values[k]
+	}
+	return w.csv.Write(row)
+}
+
+func (w *itemWriter) flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		if err := w.csv.Error(); err != nil {
+			return err
+		}
+	}
+	if w.gz != nil {
+		if err := w.gz.Flush(); err != nil {
+			return err
+		}
+	}
+	return w.buf.Flush()
+}
+
+// check turns a broken pipe into a one-time warning so processing can go on
+func (w *itemWriter) check(err error) error {
+	if err != nil && errors.Is(err, syscall.EPIPE) {
+		w.broken = true
+		zap.L().Warn("output pipe closed; further items are not written", zap.String("file", w.file.Name()))
+		return nil
+	}
+	return err
+}
+
+// Close flushes buffered data, ends the gzip stream and closes the file
+func (w *itemWriter) Close() error {
+	var errs []error
+	if !w.broken {
+		if w.gz != nil {
+			errs = append(errs, w.gz.Close())
+		}
+		errs = append(errs, w.check(w.flush()))
+	}
+	if w.file != os.Stdout {
+		errs = append(errs, w.file.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// flatten returns the top-level keys of a JSON object in document order and
+// their values as cell text: strings unquoted, everything else as raw JSON
+func flatten(b []byte) ([]string, map[string]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, nil, fmt.Errorf("csv/tsv output needs JSON objects, got %s", b)
+	}
+	var keys []string
+	values := make(map[string]string)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key := tok.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, nil, err
+		}
+		var s string
+		if json.Unmarshal(raw, &s) != nil {
+			s = string(raw)
+		}
+		keys = append(keys, key)
+		values[key] = s
+	}
+	return keys, values, nil
+}
@@ -0,0 +1,138 @@
+package output
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+type record struct {
+	ID    int     `json:"id"`
+	Name  string  `json:"name"`
+	Score float64 `json:"score"`
+}
+
+func TestNewWriterRoundTrip(t *testing.T) {
+	const n = 1000
+	tests := []struct {
+		format   string
+		compress bool
+	}{
+		{FormatJSONL, false},
+		{FormatJSONL, true},
+		{FormatCSV, false},
+		{FormatTSV, false},
+		{FormatTSV, true},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s gzip=%v", tt.format, tt.compress), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "out")
+			w, err := NewWriter(path, tt.format, tt.compress)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < n; i++ {
+				if err := w.Write(record{ID: i, Name: fmt.Sprintf("item, \"%d\"", i), Score: float64(i) / 2}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			got := readRecords(t, path, tt.format, tt.compress)
+			if len(got) != n {
+				t.Fatalf("read %d items, want %d", len(got), n)
+			}
+			for i, r := range got {
+				if want := (record{ID: i, Name: fmt.Sprintf("item, \"%d\"", i), Score: float64(i) / 2}); r != want {
+					t.Fatalf("item %d = %+v, want %+v", i, r, want)
+				}
+			}
+		})
+	}
+}
+
+func readRecords(t *testing.T, path, format string, compressed bool) []record {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if compressed {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = gz
+	}
+
+	var out []record
+	if format == FormatJSONL {
+		sc := bufio.NewScanner(r)
+		for sc.Scan() {
+			var rec record
+			if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+				t.Fatalf("line %q: %v", sc.Text(), err)
+			}
+			out = append(out, rec)
+		}
+		return out
+	}
+
+	cr := csv.NewReader(r)
+	if format == FormatTSV {
+		cr.Comma = '\t'
+	}
+	rows, err := cr.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) == 0 || fmt.Sprint(rows[0]) != "[id name score]" {
+		t.Fatalf("header = %v", rows)
+	}
+	for _, row := range rows[1:] {
+		id, _ := strconv.Atoi(row[0])
+		score, _ := strconv.ParseFloat(row[2], 64)
+		out = append(out, record{ID: id, Name: row[1], Score: score})
+	}
+	return out
+}
+
+// Every item is on disk as soon as Write returns
+func TestNewWriterFlushesEachItem(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.jsonl")
+	w, err := NewWriter(path, FormatJSONL, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	w.Write(record{ID: 1})
+	b, err := os.ReadFile(path)
+	if err != nil || string(b) != `{"id":1,"name":"","score":0}`+"\n" {
+		t.Errorf("file before Close = %q, %v", b, err)
+	}
+}
+
+func TestNewWriterRejects(t *testing.T) {
+	if _, err := NewWriter(filepath.Join(t.TempDir(), "out"), "xml", false); err == nil {
+		t.Error("unknown format accepted")
+	}
+	w, err := NewWriter(filepath.Join(t.TempDir(), "out"), FormatCSV, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if err := w.Write([]int{1, 2}); err == nil {
+		t.Error("csv accepted a non-object item")
+	}
+}
@@ -0,0 +1,58 @@
+// Package pause lets operators suspend a batch job without killing it:
+// SIGTSTP (Ctrl-Z) pauses processing and SIGCONT resumes it. Processing
+// loops call Wait between units of work.
+package pause
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+// pollInterval is how often Wait rechecks the flag while paused
+const pollInterval = 100 * time.Millisecond
+
+var pausedGauge = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "worker_paused",
+	Help: "1 while processing is paused by SIGTSTP, 0 otherwise.",
+})
+
+var paused atomic.Bool
+
+// Pause stops processing at the next Wait
+func Pause() {
+	if !paused.Swap(true) {
+		pausedGauge.Set(1)
+		zap.L().Warn("worker paused")
+	}
+}
+
+// Resume lets waiting processing loops continue
+func Resume() {
+	if paused.Swap(false) {
+		pausedGauge.Set(0)
+		zap.L().Info("worker resumed")
+	}
+}
+
+// Paused reports whether processing is paused
+func Paused() bool {
+	return paused.Load()
+}
+
+// Wait blocks while processing is paused; it returns ctx.Err() if ctx is
+// cancelled first, so a paused job can still be terminated
+func Wait(ctx context.Context) error {
+	for paused.Load() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+	return ctx.Err()
+}
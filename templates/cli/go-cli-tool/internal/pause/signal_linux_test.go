@@ -0,0 +1,75 @@
+//go:build linux
+
+package pause
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// waitFor polls cond for up to a second
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestHandleSignals(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer Resume()
+	HandleSignals(ctx)
+
+	var processed atomic.Int64
+	go func() {
+		for Wait(ctx) == nil {
+			processed.Add(1)
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	waitFor(t, "processing to start", func() bool { return processed.Load() > 0 })
+
+	syscall.Kill(os.Getpid(), syscall.SIGTSTP)
+	waitFor(t, "pause", Paused)
+	if testutil.ToFloat64(pausedGauge) != 1 {
+		t.Error("worker_paused gauge not set while paused")
+	}
+	// the unit of work in progress may still finish
+	time.Sleep(2 * pollInterval)
+	before := processed.Load()
+	time.Sleep(3 * pollInterval)
+	if n := processed.Load(); n != before {
+		t.Fatalf("processed %d items while paused", n-before)
+	}
+
+	syscall.Kill(os.Getpid(), syscall.SIGCONT)
+	waitFor(t, "processing to resume", func() bool { return processed.Load() > before })
+	if Paused() || testutil.ToFloat64(pausedGauge) != 0 {
+		t.Error("still reported as paused after SIGCONT")
+	}
+}
+
+func TestWaitCancelledWhilePaused(t *testing.T) {
+	Pause()
+	defer Resume()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Wait = %v, want context.DeadlineExceeded", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("Wait ignored cancellation while paused")
+	}
+}
@@ -0,0 +1,33 @@
+//go:build !windows
+
+package pause
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// HandleSignals pauses on SIGTSTP and resumes on SIGCONT until ctx is done.
+// Catching SIGTSTP means the process keeps running instead of being stopped
+// by the kernel, so shell job control (fg/bg) no longer applies.
+func HandleSignals(ctx context.Context) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGTSTP, syscall.SIGCONT)
+	go func() {
+		defer signal.Stop(sigCh)
+		for {
+			select {
+			case sig := <-sigCh:
+				if sig == syscall.SIGTSTP {
+					Pause()
+				} else {
+					Resume()
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package pause
+
+import "context"
+
+// HandleSignals is a no-op on Windows, which has no SIGTSTP/SIGCONT
+func HandleSignals(ctx context.Context) {}
@@ -0,0 +1,147 @@
+// Package pipeline processes newline-delimited records from a reader
+// concurrently and writes the results in input order, for use in Unix pipes
+package pipeline
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// Pipeline processes records with a bounded number of workers
+type Pipeline struct {
+	// Concurrency is the number of records processed at once (default: NumCPU)
+	Concurrency int
+	// MaxLineBytes bounds a single input line (default 1 MiB)
+	MaxLineBytes int
+}
+
+// Run processes in with NumCPU workers; see Pipeline.Run
+func Run(ctx context.Context, in io.Reader, out io.Writer, fn func([]byte) ([]byte, error)) error {
+	return (&Pipeline{}).Run(ctx, in, out, fn)
+}
+
+// result is the outcome of one record, delivered on the record's own channel
+type result struct {
+	line int
+	data []byte
+	err  error
+}
+
+type job struct {
+	line int
+	data []byte
+	res  chan result
+}
+
+// Run reads lines from in, applies fn to each non-empty line and writes the
+// outputs to out, one per line, in input order. At most Concurrency records
+// are in flight: when out blocks, reading from in pauses instead of buffering.
+// The first error from fn, in or out stops the pipeline and is returned;
+// cancelling ctx stops reading before the next line and returns ctx.Err().
+// A reader blocked inside in.Read (e.g. an idle stdin) is not interrupted;
+// Run returns without waiting for it.
+func (p *Pipeline) Run(ctx context.Context, in io.Reader, out io.Writer, fn func([]byte) ([]byte, error)) error {
+	n := p.Concurrency
+	if n <= 0 {
+		n = runtime.NumCPU()
+	}
+	maxLine := p.MaxLineBytes
+	if maxLine <= 0 {
+		maxLine = 1 << 20
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	jobs := make(chan job)
+	// pending holds the result channels in input order; its capacity is what
+	// bounds the records between the reader and the writer
+	pending := make(chan chan result, n)
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				data, err := fn(j.data)
+				j.res <- result{line: j.line, data: data, err: err}
+			}
+		}()
+	}
+
+	// reader: one goroutine feeds workers and records the output order
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(pending)
+		defer close(jobs)
+		sc := bufio.NewScanner(in)
+		sc.Buffer(make([]byte, 0, 64*1024), maxLine)
+		line := 0
+		for sc.Scan() {
+			line++
+			if len(sc.Bytes()) == 0 {
+				continue
+			}
+			j := job{line: line, data: append([]byte(nil), sc.Bytes()...), res: make(chan result, 1)}
+			select {
+			case pending <- j.res:
+			case <-ctx.Done():
+				readErr <- ctx.Err()
+				return
+			}
+			select {
+			case jobs <- j:
+			case <-ctx.Done():
+				readErr <- ctx.Err()
+				return
+			}
+		}
+		readErr <- sc.Err()
+	}()
+
+	// writer: the calling goroutine emits results as their turn comes
+	w := bufio.NewWriter(out)
+	for {
+		var res chan result
+		var more bool
+		select {
+		case res, more = <-pending:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if !more {
+			break // pending closed: every record has been written
+		}
+		var r result
+		select {
+		case r = <-res:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if r.err != nil {
+			// returning cancels ctx; the reader stops before its next line
+			return fmt.Errorf("line %d: %w", r.line, r.err)
+		}
+		w.Write(r.data)
+		w.WriteByte('\n')
+		// flush per record so downstream sees output promptly and a blocked
+		// consumer stalls this loop (backpressure)
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	// pending is closed: the reader is done and workers drain the closed jobs channel
+	wg.Wait()
+	if err := <-readErr; err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+	return parent.Err()
+}
@@ -0,0 +1,125 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// jitter echoes the record after a random delay so workers finish out of order
+func jitter(b []byte) ([]byte, error) {
+	time.Sleep(time.Duration(rand.Intn(500)) * time.Microsecond)
+	return bytes.ToUpper(b), nil
+}
+
+func TestRunPreservesOrder(t *testing.T) {
+	var in strings.Builder
+	var want strings.Builder
+	for i := 0; i < 500; i++ {
+		fmt.Fprintf(&in, "{\"n\":%d,\"v\":\"x\"}\n", i)
+		fmt.Fprintf(&want, "{\"N\":%d,\"V\":\"X\"}\n", i)
+		if i%100 == 0 {
+			in.WriteString("\n") // blank lines are skipped
+		}
+	}
+	for _, c := range []int{1, 4, 32} {
+		t.Run(fmt.Sprintf("concurrency=%d", c), func(t *testing.T) {
+			var out bytes.Buffer
+			p := &Pipeline{Concurrency: c}
+			if err := p.Run(context.Background(), strings.NewReader(in.String()), &out, jitter); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != want.String() {
+				t.Errorf("output out of order or incomplete:\n%.200s", out.String())
+			}
+		})
+	}
+}
+
+func TestRunStopsOnError(t *testing.T) {
+	in := strings.NewReader("a\nb\nboom\nd\n")
+	var out bytes.Buffer
+	err := (&Pipeline{Concurrency: 1}).Run(context.Background(), in, &out, func(b []byte) ([]byte, error) {
+		if string(b) == "boom" {
+			return nil, errors.New("bad record")
+		}
+		return b, nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("err = %v, want the failing line number", err)
+	}
+	if out.String() != "a\nb\n" {
+		t.Errorf("output = %q, want the records before the failure", out.String())
+	}
+}
+
+// endless produces records forever and counts how many were read
+type endless struct{ reads atomic.Int64 }
+
+func (e *endless) Read(p []byte) (int, error) {
+	e.reads.Add(1)
+	return copy(p, "{\"n\":1}\n"), nil
+}
+
+func TestRunCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	src := &endless{}
+	var processed atomic.Int64
+	done := make(chan error, 1)
+	go func() {
+		done <- (&Pipeline{Concurrency: 4}).Run(ctx, src, io.Discard, func(b []byte) ([]byte, error) {
+			if processed.Add(1) == 100 {
+				cancel()
+			}
+			return b, nil
+		})
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("err = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after cancellation")
+	}
+	// the reader may finish the line it was scanning, then must stop
+	time.Sleep(20 * time.Millisecond)
+	reads := src.reads.Load()
+	time.Sleep(50 * time.Millisecond)
+	if src.reads.Load() != reads {
+		t.Error("input still being read after Run returned")
+	}
+}
+
+// A consumer that stops draining blocks the writer, which in turn stops the reader
+func TestRunBackpressure(t *testing.T) {
+	src := &endless{}
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- (&Pipeline{Concurrency: 2}).Run(context.Background(), src, pw, func(b []byte) ([]byte, error) { return b, nil })
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	// the scanner reads 4 KiB chunks; a bounded pipeline never gets far ahead
+	if n := src.reads.Load(); n > 10 {
+		t.Errorf("%d reads with a blocked consumer, want reading to pause", n)
+	}
+	pr.CloseWithError(errors.New("consumer gone"))
+	select {
+	case err := <-done:
+		if err == nil || err.Error() != "consumer gone" {
+			t.Errorf("err = %v, want the write error", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after the consumer went away")
+	}
+}
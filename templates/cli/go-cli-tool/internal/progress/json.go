@@ -0,0 +1,71 @@
+package progress
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Event is a newline-delimited JSON progress record for machine consumers
+type Event struct {
+	Event   string    `json:"event"` // start|step|done
+	Step    int       `json:"step,omitempty"`
+	Total   int       `json:"total,omitempty"`
+	Message string    `json:"message,omitempty"`
+	Status  string    `json:"status,omitempty"` // ok|error on done
+	Error   string    `json:"error,omitempty"`
+	Time    time.Time `json:"ts"`
+}
+
+// JSONReporter emits one Event per line
+type JSONReporter struct {
+	mu    sync.Mutex
+	enc   *json.Encoder
+	total int
+}
+
+// NewJSONReporter writes events to w
+func NewJSONReporter(w io.Writer) *JSONReporter {
+	return &JSONReporter{enc: json.NewEncoder(w)}
+}
+
+func (r *JSONReporter) Start(total int) {
+	r.total = total
+	r.emit(Event{Event: "start", Total: total})
+}
+
+func (r *JSONReporter) Step(n int, message string) {
+	r.emit(Event{Event: "step", Step: n, Total: r.total, Message: message})
+}
+
+func (r *JSONReporter) Done(err error) {
+	ev := Event{Event: "done", Total: r.total, Status: "ok"}
+	if err != nil {
+		ev.Status = "error"
+		ev.Error = err.Error()
+	}
+	r.emit(ev)
+}
+
+func (r *JSONReporter) emit(ev Event) {
+	ev.Time = time.Now().UTC()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.enc.Encode(ev); err != nil {
+		zap.L().Warn("failed to write progress event", zap.Error(err))
+	}
+}
+
+// logReporter keeps the plain zap log output (mode "none")
+type logReporter struct{}
+
+func (logReporter) Start(total int) {}
+
+func (logReporter) Step(n int, message string) {
+	zap.L().Info("processing step", zap.Int("step", n), zap.String("message", message))
+}
+
+func (logReporter) Done(err error) {}
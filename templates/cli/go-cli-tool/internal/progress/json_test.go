@@ -0,0 +1,70 @@
+package progress
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// events decodes the NDJSON written by a JSON reporter
+func events(t *testing.T, buf *bytes.Buffer) []Event {
+	t.Helper()
+	var out []Event
+	sc := bufio.NewScanner(buf)
+	for sc.Scan() {
+		var ev Event
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil {
+			t.Fatalf("line %q: %v", sc.Text(), err)
+		}
+		out = append(out, ev)
+	}
+	return out
+}
+
+func TestJSONReporter(t *testing.T) {
+	var buf bytes.Buffer
+	r, err := New(ModeJSON, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Start(3)
+	for i := 1; i <= 3; i++ {
+		r.Step(i, "item")
+	}
+	r.Done(nil)
+
+	evs := events(t, &buf)
+	if len(evs) != 5 || evs[0].Event != "start" || evs[0].Total != 3 {
+		t.Fatalf("events = %+v", evs)
+	}
+	for i, ev := range evs[1:4] {
+		if ev.Event != "step" || ev.Step != i+1 || ev.Total != 3 || ev.Time.IsZero() {
+			t.Errorf("step event %d = %+v", i+1, ev)
+		}
+	}
+	if done := evs[4]; done.Event != "done" || done.Status != "ok" || done.Error != "" {
+		t.Errorf("done event = %+v", done)
+	}
+}
+
+func TestJSONReporterError(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewJSONReporter(&buf)
+	r.Start(0)
+	r.Done(errors.New("disk full"))
+	evs := events(t, &buf)
+	if done := evs[len(evs)-1]; done.Status != "error" || done.Error != "disk full" {
+		t.Errorf("done event = %+v", done)
+	}
+}
+
+func TestNewUnknownMode(t *testing.T) {
+	if _, err := New("fancy", &bytes.Buffer{}); err == nil {
+		t.Error("unknown mode accepted")
+	}
+	if DetectMode(ModeSpinner) != ModeSpinner {
+		t.Error("explicit mode not kept")
+	}
+}
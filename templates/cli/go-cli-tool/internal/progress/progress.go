@@ -0,0 +1,53 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mattn/go-isatty"
+)
+
+// Supported progress modes
+const (
+	ModeNone    = "none"
+	ModeBar     = "bar"
+	ModeSpinner = "spinner"
+	ModeJSON    = "json"
+)
+
+// ProgressReporter receives progress updates from long-running operations.
+// Implementations must be safe to call from a single producer goroutine.
+type ProgressReporter interface {
+	// Start announces the total number of steps (0 when unknown)
+	Start(total int)
+	// Step reports that step n (1-based) completed
+	Step(n int, message string)
+	// Done finishes reporting; err is nil on success
+	Done(err error)
+}
+
+// DetectMode resolves an empty mode: json when stdout is not a terminal, bar otherwise
+func DetectMode(mode string) string {
+	if mode != "" {
+		return mode
+	}
+	if !isatty.IsTerminal(os.Stdout.Fd()) && !isatty.IsCygwinTerminal(os.Stdout.Fd()) {
+		return ModeJSON
+	}
+	return ModeBar
+}
+
+// New returns a reporter for mode writing to w
+func New(mode string, w io.Writer) (ProgressReporter, error) {
+	switch mode {
+	case ModeNone:
+		return logReporter{}, nil
+	case ModeJSON:
+		return NewJSONReporter(w), nil
+	case ModeBar, ModeSpinner:
+		return newTUIReporter(mode, w), nil
+	default:
+		return nil, fmt.Errorf("unknown progress mode %q (expected none|bar|spinner|json)", mode)
+	}
+}
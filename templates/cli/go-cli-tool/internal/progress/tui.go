@@ -0,0 +1,102 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	bprogress "github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type startMsg struct{ total int }
+
+type stepMsg struct {
+	n       int
+	message string
+}
+
+type doneMsg struct{ err error }
+
+// tuiModel renders either a progress bar or a spinner with elapsed time
+type tuiModel struct {
+	mode    string
+	bar     bprogress.Model
+	spin    spinner.Model
+	total   int
+	done    int
+	message string
+	started time.Time
+	err     error
+}
+
+func (m tuiModel) Init() tea.Cmd {
+	if m.mode == ModeSpinner {
+		return m.spin.Tick
+	}
+	return nil
+}
+
+func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case startMsg:
+		m.total = msg.total
+	case stepMsg:
+		m.done, m.message = msg.n, msg.message
+	case doneMsg:
+		m.err = msg.err
+		return m, tea.Quit
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spin, cmd = m.spin.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m tuiModel) View() string {
+	if m.mode == ModeSpinner {
+		return fmt.Sprintf("%s %s (%s)\n", m.spin.View(), m.message, time.Since(m.started).Round(time.Second))
+	}
+	pct := 0.0
+	if m.total > 0 {
+		pct = float64(m.done) / float64(m.total)
+	}
+	return fmt.Sprintf("%s %d/%d %s\n", m.bar.ViewAs(pct), m.done, m.total, m.message)
+}
+
+// tuiReporter drives a bubbletea program from the processing goroutine
+type tuiReporter struct {
+	program *tea.Program
+	exited  chan struct{}
+}
+
+func newTUIReporter(mode string, w io.Writer) *tuiReporter {
+	m := tuiModel{
+		mode:    mode,
+		bar:     bprogress.New(bprogress.WithDefaultGradient()),
+		spin:    spinner.New(spinner.WithSpinner(spinner.Dot)),
+		started: time.Now(),
+	}
+	// no input: signals are handled by the command's own context
+	p := tea.NewProgram(m, tea.WithOutput(w), tea.WithInput(nil))
+	r := &tuiReporter{program: p, exited: make(chan struct{})}
+	go func() {
+		defer close(r.exited)
+		p.Run()
+	}()
+	return r
+}
+
+func (r *tuiReporter) Start(total int) { r.program.Send(startMsg{total: total}) }
+
+func (r *tuiReporter) Step(n int, message string) {
+	r.program.Send(stepMsg{n: n, message: message})
+}
+
+// Done renders the final frame and waits for the terminal to be restored
+func (r *tuiReporter) Done(err error) {
+	r.program.Send(doneMsg{err: err})
+	<-r.exited
+}
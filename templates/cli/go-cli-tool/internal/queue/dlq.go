@@ -0,0 +1,245 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+var (
+	dlqMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "sqs_dlq_messages_total",
+		Help: "Dead-letter queue messages handled by the dlq command, by queue and operation (list|requeue|purge).",
+	}, []string{"queue", "operation"})
+	dlqErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "sqs_dlq_errors_total",
+		Help: "Failed dead-letter queue operations, by queue and operation.",
+	}, []string{"queue", "operation"})
+)
+
+// dlqVisibility hides received DLQ messages while they are listed or
+// requeued; skipped messages are released before it runs out
+const dlqVisibility = 60 * time.Second
+
+// sqsAPI is the part of *sqs.Client used by DLQ
+type sqsAPI interface {
+	ReceiveMessage(context.Context, *sqs.ReceiveMessageInput, ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
+	SendMessage(context.Context, *sqs.SendMessageInput, ...func(*sqs.Options)) (*sqs.SendMessageOutput, error)
+	DeleteMessage(context.Context, *sqs.DeleteMessageInput, ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error)
+	ChangeMessageVisibility(context.Context, *sqs.ChangeMessageVisibilityInput, ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error)
+	GetQueueAttributes(context.Context, *sqs.GetQueueAttributesInput, ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error)
+	PurgeQueue(context.Context, *sqs.PurgeQueueInput, ...func(*sqs.Options)) (*sqs.PurgeQueueOutput, error)
+}
+
+// DLQMessage is a message waiting in the dead-letter queue
+type DLQMessage struct {
+	ID             string
+	Body           []byte
+	SentAt         time.Time // when the message was first sent to the source queue
+	ReceiveCount   int
+	MessageGroupID string // FIFO queues only
+	Attributes     map[string]types.MessageAttributeValue
+
+	receipt *string
+}
+
+// DLQ inspects cfg.DLQURL and moves messages back to cfg.QueueURL
+type DLQ struct {
+	client sqsAPI
+	cfg    SQSConfig
+}
+
+// NewDLQ returns a DLQ for cfg.DLQURL; requeued messages go to cfg.QueueURL
+func NewDLQ(cfg SQSConfig) *DLQ {
+	return newDLQ(sqs.NewFromConfig(cfg.AWS), cfg)
+}
+
+func newDLQ(client sqsAPI, cfg SQSConfig) *DLQ {
+	return &DLQ{client: client, cfg: cfg.withDefaults()}
+}
+
+// Depth returns the approximate number of visible messages in the DLQ
+func (d *DLQ) Depth(ctx context.Context) (int, error) {
+	out, err := d.client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(d.cfg.DLQURL),
+		AttributeNames: []types.QueueAttributeName{types.QueueAttributeNameApproximateNumberOfMessages},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(out.Attributes[string(types.QueueAttributeNameApproximateNumberOfMessages)])
+}
+
+// List returns up to limit DLQ messages (0 = all that can be received). The
+// messages stay in the queue: they are made visible again before returning.
+func (d *DLQ) List(ctx context.Context, limit int) ([]DLQMessage, error) {
+	var out []DLQMessage
+	err := d.scan(ctx, func(m DLQMessage) (bool, bool, error) {
+		out = append(out, m)
+		return false, limit <= 0 || len(out) < limit, nil
+	})
+	dlqMessages.WithLabelValues(queueName(d.cfg.DLQURL), "list").Add(float64(len(out)))
+	if err != nil {
+		dlqErrors.WithLabelValues(queueName(d.cfg.DLQURL), "list").Inc()
+	}
+	return out, err
+}
+
+// Requeue sends the DLQ messages matching filter (nil matches all) to the
+// source queue and deletes them from the DLQ, one at a time. A message is
+// deleted only after it was sent, so a failure can duplicate a message but
+// never lose it. It returns the number of messages moved.
+func (d *DLQ) Requeue(ctx context.Context, filter MessageFilter) (int, error) {
+	if d.cfg.QueueURL == "" {
+		return 0, fmt.Errorf("requeue: source queue url is not set")
+	}
+	name := queueName(d.cfg.DLQURL)
+	moved := 0
+	err := d.scan(ctx, func(m DLQMessage) (bool, bool, error) {
+		if filter != nil && !filter(m.Body) {
+			return false, true, nil
+		}
+		if err := d.move(ctx, m); err != nil {
+			return false, false, err
+		}
+		moved++
+		dlqMessages.WithLabelValues(name, "requeue").Inc()
+		zap.L().Info("dlq message requeued", zap.String("queue", name), zap.String("message_id", m.ID))
+		return true, true, nil
+	})
+	if err != nil {
+		dlqErrors.WithLabelValues(name, "requeue").Inc()
+	}
+	return moved, err
+}
+
+// Purge deletes every message in the DLQ and returns the approximate count
+// removed. SQS allows one purge per queue every 60 seconds.
+func (d *DLQ) Purge(ctx context.Context) (int, error) {
+	name := queueName(d.cfg.DLQURL)
+	depth, err := d.Depth(ctx)
+	if err != nil {
+		dlqErrors.WithLabelValues(name, "purge").Inc()
+		return 0, err
+	}
+	if _, err := d.client.PurgeQueue(ctx, &sqs.PurgeQueueInput{QueueUrl: aws.String(d.cfg.DLQURL)}); err != nil {
+		dlqErrors.WithLabelValues(name, "purge").Inc()
+		return 0, fmt.Errorf("sqs purge: %w", err)
+	}
+	dlqMessages.WithLabelValues(name, "purge").Add(float64(depth))
+	dlqDepth.WithLabelValues(name).Set(0)
+	return depth, nil
+}
+
+// move sends m to the source queue, then deletes it from the DLQ
+func (d *DLQ) move(ctx context.Context, m DLQMessage) error {
+	in := &sqs.SendMessageInput{
+		QueueUrl:          aws.String(d.cfg.QueueURL),
+		MessageBody:       aws.String(string(m.Body)),
+		MessageAttributes: m.Attributes,
+	}
+	if isFIFO(d.cfg.QueueURL) {
+		group := m.MessageGroupID
+		if group == "" {
+			group = d.cfg.MessageGroupID
+		}
+		if group == "" {
+			group = "default"
+		}
+		in.MessageGroupId = aws.String(group)
+		// the DLQ message ID keeps a retried requeue from sending twice
+		in.MessageDeduplicationId = aws.String(m.ID)
+	}
+	if _, err := d.client.SendMessage(ctx, in); err != nil {
+		return fmt.Errorf("requeue %s: sqs send: %w", m.ID, err)
+	}
+	if _, err := d.client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(d.cfg.DLQURL),
+		ReceiptHandle: m.receipt,
+	}); err != nil {
+		return fmt.Errorf("requeue %s: sent but not deleted from dlq: %w", m.ID, err)
+	}
+	return nil
+}
+
+// scan receives DLQ messages until the queue returns none or fn reports no
+// more are wanted, hiding each one so it is seen once. fn reports whether it
+// removed the message from the DLQ; the others are made visible again when
+// scan returns.
+func (d *DLQ) scan(ctx context.Context, fn func(DLQMessage) (removed, more bool, err error)) error {
+	var held []*string
+	defer func() {
+		// release with a fresh context: ctx may be what stopped the scan
+		rctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		for _, receipt := range held {
+			if _, err := d.client.ChangeMessageVisibility(rctx, &sqs.ChangeMessageVisibilityInput{
+				QueueUrl:          aws.String(d.cfg.DLQURL),
+				ReceiptHandle:     receipt,
+				VisibilityTimeout: 0,
+			}); err != nil {
+				zap.L().Warn("sqs dlq release failed; message stays hidden until its visibility timeout", zap.Error(err))
+			}
+		}
+	}()
+
+	seen := map[string]bool{}
+	for {
+		out, err := d.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:                    aws.String(d.cfg.DLQURL),
+			MaxNumberOfMessages:         10,
+			WaitTimeSeconds:             1,
+			VisibilityTimeout:           int32(dlqVisibility / time.Second),
+			MessageAttributeNames:       []string{"All"},
+			MessageSystemAttributeNames: []types.MessageSystemAttributeName{types.MessageSystemAttributeNameAll},
+		})
+		if err != nil {
+			return fmt.Errorf("sqs receive: %w", err)
+		}
+		if len(out.Messages) == 0 {
+			return nil
+		}
+		more := true
+		for _, raw := range out.Messages {
+			m := toDLQMessage(raw)
+			if !more || seen[m.ID] {
+				held = append(held, m.receipt)
+				continue
+			}
+			seen[m.ID] = true
+			var removed bool
+			if removed, more, err = fn(m); !removed {
+				held = append(held, m.receipt)
+			}
+			if err != nil {
+				more = false
+			}
+		}
+		if !more {
+			return err
+		}
+	}
+}
+
+func toDLQMessage(m types.Message) DLQMessage {
+	msg := DLQMessage{
+		ID:             aws.ToString(m.MessageId),
+		Body:           []byte(aws.ToString(m.Body)),
+		MessageGroupID: m.Attributes[string(types.MessageSystemAttributeNameMessageGroupId)],
+		Attributes:     m.MessageAttributes,
+		receipt:        m.ReceiptHandle,
+	}
+	msg.ReceiveCount, _ = strconv.Atoi(m.Attributes[string(types.MessageSystemAttributeNameApproximateReceiveCount)])
+	if ms, err := strconv.ParseInt(m.Attributes[string(types.MessageSystemAttributeNameSentTimestamp)], 10, 64); err == nil {
+		msg.SentAt = time.UnixMilli(ms)
+	}
+	return msg
+}
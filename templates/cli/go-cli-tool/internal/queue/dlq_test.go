@@ -0,0 +1,139 @@
+package queue
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+const (
+	testQueueURL = "https://sqs.eu-west-1.amazonaws.com/123/orders.fifo"
+	testDLQURL   = "https://sqs.eu-west-1.amazonaws.com/123/orders-dlq.fifo"
+)
+
+// fakeSQS keeps queues in memory; received messages are hidden until
+// deleted or released
+type fakeSQS struct {
+	sqsAPI // unused methods panic
+
+	queues map[string][]types.Message
+	hidden map[string]bool // by receipt handle
+	sent   []*sqs.SendMessageInput
+}
+
+func newFakeSQS(dlqBodies ...string) *fakeSQS {
+	f := &fakeSQS{queues: map[string][]types.Message{}, hidden: map[string]bool{}}
+	for i, body := range dlqBodies {
+		id := "m" + strconv.Itoa(i)
+		f.queues[testDLQURL] = append(f.queues[testDLQURL], types.Message{
+			MessageId: aws.String(id), ReceiptHandle: aws.String("r-" + id), Body: aws.String(body),
+			Attributes: map[string]string{string(types.MessageSystemAttributeNameMessageGroupId): "g1"},
+		})
+	}
+	return f
+}
+
+func (f *fakeSQS) ReceiveMessage(_ context.Context, in *sqs.ReceiveMessageInput, _ ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	out := &sqs.ReceiveMessageOutput{}
+	for _, m := range f.queues[*in.QueueUrl] {
+		if len(out.Messages) < int(in.MaxNumberOfMessages) && !f.hidden[*m.ReceiptHandle] {
+			f.hidden[*m.ReceiptHandle] = true
+			out.Messages = append(out.Messages, m)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeSQS) SendMessage(_ context.Context, in *sqs.SendMessageInput, _ ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+	f.sent = append(f.sent, in)
+	return &sqs.SendMessageOutput{}, nil
+}
+
+func (f *fakeSQS) DeleteMessage(_ context.Context, in *sqs.DeleteMessageInput, _ ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error) {
+	msgs := f.queues[*in.QueueUrl]
+	for i, m := range msgs {
+		if *m.ReceiptHandle == *in.ReceiptHandle {
+			f.queues[*in.QueueUrl] = append(msgs[:i:i], msgs[i+1:]...)
+		}
+	}
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func (f *fakeSQS) ChangeMessageVisibility(_ context.Context, in *sqs.ChangeMessageVisibilityInput, _ ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error) {
+	delete(f.hidden, *in.ReceiptHandle)
+	return &sqs.ChangeMessageVisibilityOutput{}, nil
+}
+
+func TestDLQRequeue(t *testing.T) {
+	api := newFakeSQS(`{"status":"failed"}`, `{"status":"ok"}`, `{"status":"failed"}`)
+	dlq := newDLQ(api, SQSConfig{QueueURL: testQueueURL, DLQURL: testDLQURL})
+	filter, err := ParseFilter(`$.status == "failed"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	moved, err := dlq.Requeue(context.Background(), filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved != 2 || len(api.sent) != 2 {
+		t.Fatalf("moved %d, sent %d; want 2", moved, len(api.sent))
+	}
+	for i, in := range api.sent {
+		if *in.QueueUrl != testQueueURL || *in.MessageBody != `{"status":"failed"}` {
+			t.Errorf("send %d = %s %s", i, *in.QueueUrl, *in.MessageBody)
+		}
+		if aws.ToString(in.MessageGroupId) != "g1" || aws.ToString(in.MessageDeduplicationId) == "" {
+			t.Errorf("send %d: group %q, dedup %q", i, aws.ToString(in.MessageGroupId), aws.ToString(in.MessageDeduplicationId))
+		}
+	}
+	left := api.queues[testDLQURL]
+	if len(left) != 1 || *left[0].Body != `{"status":"ok"}` {
+		t.Errorf("dlq left with %d messages", len(left))
+	}
+	if api.hidden["r-m1"] {
+		t.Error("skipped message was not released")
+	}
+}
+
+func TestDLQList(t *testing.T) {
+	api := newFakeSQS("a", "b", "c")
+	dlq := newDLQ(api, SQSConfig{DLQURL: testDLQURL})
+	msgs, err := dlq.List(context.Background(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 2 || string(msgs[0].Body) != "a" || msgs[0].MessageGroupID != "g1" {
+		t.Errorf("list = %+v", msgs)
+	}
+	if len(api.hidden) != 0 || len(api.queues[testDLQURL]) != 3 {
+		t.Error("list removed or kept hiding messages")
+	}
+}
+
+func TestSQSProducerDedupByMessageID(t *testing.T) {
+	api := newFakeSQS()
+	p := &SQSProducer{client: api, cfg: SQSConfig{QueueURL: testQueueURL}}
+	for i := 0; i < 2; i++ {
+		if _, err := p.Send(context.Background(), map[string]int{"n": 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := p.SendWithID(context.Background(), "order-7", map[string]int{"n": 1}); err != nil {
+		t.Fatal(err)
+	}
+	a, b := aws.ToString(api.sent[0].MessageDeduplicationId), aws.ToString(api.sent[1].MessageDeduplicationId)
+	if a == "" || a == b {
+		t.Errorf("identical payloads share dedup id %q", a)
+	}
+	if got := aws.ToString(api.sent[2].MessageDeduplicationId); got != "order-7" {
+		t.Errorf("dedup id = %q, want order-7", got)
+	}
+	if aws.ToString(api.sent[0].MessageGroupId) != "default" {
+		t.Errorf("group = %q, want default", aws.ToString(api.sent[0].MessageGroupId))
+	}
+}
@@ -0,0 +1,176 @@
+package queue
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// MessageFilter reports whether a message body matches
+type MessageFilter func(body []byte) bool
+
+// ParseFilter compiles a JSON path expression over the message body:
+//
+//	$.order.status == "failed"
+//	$.items[0].sku != "X-1"
+//	$.retryable              (the field exists and is not null or false)
+//
+// The right-hand side of == and != is a JSON literal. Bodies that are not
+// JSON never match.
+func ParseFilter(expr string) (MessageFilter, error) {
+	expr = strings.TrimSpace(expr)
+	path, op, literal := expr, "", ""
+	for _, o := range []string{"==", "!="} {
+		if i := strings.Index(expr, o); i >= 0 {
+			path, op, literal = strings.TrimSpace(expr[:i]), o, strings.TrimSpace(expr[i+len(o):])
+			break
+		}
+	}
+	steps, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	var want any
+	if op != "" {
+		if want, err = decodeJSON([]byte(literal)); err != nil {
+			return nil, fmt.Errorf("filter %q: right-hand side must be a JSON value: %w", expr, err)
+		}
+		want = normalizeNumbers(want)
+	}
+	return func(body []byte) bool {
+		doc, err := decodeJSON(body)
+		if err != nil {
+			return false
+		}
+		got, ok := lookupPath(doc, steps)
+		switch op {
+		case "==":
+			return ok && jsonEqual(got, want)
+		case "!=":
+			return !ok || !jsonEqual(got, want)
+		default:
+			return ok && got != nil && got != false
+		}
+	}, nil
+}
+
+// pathStep is a field name or, when index >= 0, an array index
+type pathStep struct {
+	field string
+	index int
+}
+
+// parsePath parses $.a.b[0].c
+func parsePath(path string) ([]pathStep, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("filter path %q must start with $", path)
+	}
+	var steps []pathStep
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			name := rest[1 : end+1]
+			if name == "" {
+				return nil, fmt.Errorf("filter path %q: empty field name", path)
+			}
+			steps = append(steps, pathStep{field: name, index: -1})
+			rest = rest[end+1:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("filter path %q: unclosed [", path)
+			}
+			n, err := strconv.Atoi(rest[1:end])
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("filter path %q: invalid index %q", path, rest[1:end])
+			}
+			steps = append(steps, pathStep{index: n})
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("filter path %q: unexpected %q", path, rest[0])
+		}
+	}
+	return steps, nil
+}
+
+func lookupPath(doc any, steps []pathStep) (any, bool) {
+	cur := doc
+	for _, s := range steps {
+		if s.index >= 0 {
+			arr, ok := cur.([]any)
+			if !ok || s.index >= len(arr) {
+				return nil, false
+			}
+			cur = arr[s.index]
+			continue
+		}
+		obj, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = obj[s.field]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// decodeJSON decodes one JSON value, keeping numbers as json.Number so large
+// integers survive exactly
+func decodeJSON(data []byte) (any, error) {
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("trailing data after JSON value")
+	}
+	return v, nil
+}
+
+// number is a json.Number in lowest terms, so 1, 1.0 and 1e0 are equal
+type number string
+
+// normalizeNumbers replaces every json.Number in v, including inside arrays
+// and objects, with its number form
+func normalizeNumbers(v any) any {
+	switch t := v.(type) {
+	case json.Number:
+		r, ok := new(big.Rat).SetString(t.String())
+		if !ok {
+			return t
+		}
+		return number(r.RatString())
+	case []any:
+		out := make([]any, len(t))
+		for i, e := range t {
+			out[i] = normalizeNumbers(e)
+		}
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for k, e := range t {
+			out[k] = normalizeNumbers(e)
+		}
+		return out
+	}
+	return v
+}
+
+// jsonEqual compares a decoded value with a normalized literal; numbers
+// compare by value at any depth
+func jsonEqual(got, want any) bool {
+	return reflect.DeepEqual(normalizeNumbers(got), want)
+}
@@ -0,0 +1,43 @@
+package queue
+
+import "testing"
+
+func TestParseFilter(t *testing.T) {
+	body := []byte(`{"order":{"status":"failed","total":12.50,"id":9007199254740993,"tags":[1,2.0]},"items":[{"sku":"X-1"}],"retryable":true}`)
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`$.order.status == "failed"`, true},
+		{`$.order.status != "failed"`, false},
+		{`$.order.total == 12.5`, true},
+		{`$.order.total == 1.25e1`, true},
+		{`$.order.id == 9007199254740993`, true},
+		{`$.order.id == 9007199254740992`, false}, // equal as float64
+		{`$.order.tags == [1.0, 2]`, true},
+		{`$.order == {"status":"failed","total":12.5,"id":9007199254740993,"tags":[1,2]}`, true},
+		{`$.items[0].sku == "X-1"`, true},
+		{`$.items[1].sku != "X-1"`, true},
+		{`$.retryable`, true},
+		{`$.missing`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			f, err := ParseFilter(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := f(body); got != tt.want {
+				t.Errorf("match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if f, _ := ParseFilter(`$.a == 1`); f([]byte("not json")) {
+		t.Error("non-JSON body matched")
+	}
+	for _, bad := range []string{`order.status == "x"`, `$.a == nope`, `$.a[x]`, `$.a == 1 2`} {
+		if _, err := ParseFilter(bad); err == nil {
+			t.Errorf("ParseFilter(%q) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+var dlqDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "sqs_consumer_dlq_depth",
+	Help: "Approximate number of messages waiting in the consumer's dead-letter queue.",
+}, []string{"queue"})
+
+// longPollSeconds is the maximum SQS long-polling wait
+const longPollSeconds = 20
+
+// SQSConfig configures an SQS producer or consumer
+type SQSConfig struct {
+	// AWS is the SDK config, usually from config.LoadDefaultConfig
+	AWS aws.Config `mapstructure:"-"`
+
+	QueueURL          string        `mapstructure:"queue_url"`
+	DLQURL            string        `mapstructure:"dlq_url"`          // optional; enables depth monitoring
+	MessageGroupID    string        `mapstructure:"message_group_id"` // FIFO queues only
+	MaxConcurrency    int           `mapstructure:"max_concurrency"`
+	VisibilityTimeout time.Duration `mapstructure:"visibility_timeout"`
+	DLQPollInterval   time.Duration `mapstructure:"dlq_poll_interval"`
+}
+
+func (c SQSConfig) withDefaults() SQSConfig {
+	if c.MaxConcurrency <= 0 {
+		c.MaxConcurrency = 10
+	}
+	if c.VisibilityTimeout < 2*time.Second {
+		c.VisibilityTimeout = 30 * time.Second
+	}
+	if c.DLQPollInterval <= 0 {
+		c.DLQPollInterval = time.Minute
+	}
+	return c
+}
+
+func isFIFO(queueURL string) bool {
+	return strings.HasSuffix(queueURL, ".fifo")
+}
+
+// queueName returns the last path segment of a queue URL
+func queueName(queueURL string) string {
+	return queueURL[strings.LastIndex(queueURL, "/")+1:]
+}
+
+// SQSProducer sends JSON messages to a queue
+type SQSProducer struct {
+	client sqsAPI
+	cfg    SQSConfig
+}
+
+// NewSQSProducer returns a producer for cfg.QueueURL
+func NewSQSProducer(cfg SQSConfig) *SQSProducer {
+	cfg = cfg.withDefaults()
+	return &SQSProducer{client: sqs.NewFromConfig(cfg.AWS), cfg: cfg}
+}
+
+// Send JSON-encodes payload and sends it under a fresh message ID; see
+// SendWithID.
+func (p *SQSProducer) Send(ctx context.Context, payload any) (*sqs.SendMessageOutput, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("generate message id: %w", err)
+	}
+	return p.SendWithID(ctx, hex.EncodeToString(b), payload)
+}
+
+// SendWithID JSON-encodes payload and sends it. On FIFO queues the message
+// goes to MessageGroupID ("default" when unset) and id is its deduplication
+// ID, so retrying with the same id within five minutes is a no-op while
+// distinct messages with identical payloads are all delivered.
+func (p *SQSProducer) SendWithID(ctx context.Context, id string, payload any) (*sqs.SendMessageOutput, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("encode payload: %w", err)
+	}
+	in := &sqs.SendMessageInput{
+		QueueUrl:    aws.String(p.cfg.QueueURL),
+		MessageBody: aws.String(string(body)),
+	}
+	if isFIFO(p.cfg.QueueURL) {
+		group := p.cfg.MessageGroupID
+		if group == "" {
+			group = "default"
+		}
+		in.MessageGroupId = aws.String(group)
+		in.MessageDeduplicationId = aws.String(id)
+	}
+	out, err := p.client.SendMessage(ctx, in)
+	if err != nil {
+		return nil, fmt.Errorf("sqs send: %w", err)
+	}
+	return out, nil
+}
+
+// Message is a received SQS message handed to consumer handlers
+type Message struct {
+	ID           string
+	Body         []byte
+	Attributes   map[string]string
+	ReceiveCount int
+}
+
+// Decode unmarshals the JSON body into v
+func (m Message) Decode(v any) error {
+	return json.Unmarshal(m.Body, v)
+}
+
+// SQSConsumer long-polls a queue and dispatches messages to a handler
+type SQSConsumer struct {
+	client *sqs.Client
+	cfg    SQSConfig
+}
+
+// NewSQSConsumer returns a consumer for cfg.QueueURL
+func NewSQSConsumer(cfg SQSConfig) *SQSConsumer {
+	cfg = cfg.withDefaults()
+	return &SQSConsumer{client: sqs.NewFromConfig(cfg.AWS), cfg: cfg}
+}
+
+// Poll receives messages until ctx is cancelled, running up to MaxConcurrency
+// handlers at once. A message is deleted only when its handler returns nil;
+// otherwise it becomes visible again and, after the queue's maxReceiveCount,
+// moves to the dead-letter queue. Poll waits for in-flight handlers before returning.
+func (c *SQSConsumer) Poll(ctx context.Context, handler func(Message) error) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	if c.cfg.DLQURL != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.monitorDLQ(ctx)
+		}()
+	}
+
+	slots := make(chan struct{}, c.cfg.MaxConcurrency)
+	for ctx.Err() == nil {
+		// only ask for as many messages as there are free slots
+		free := cap(slots) - len(slots)
+		if free == 0 {
+			select {
+			case slots <- struct{}{}:
+				<-slots
+				continue
+			case <-ctx.Done():
+				return nil
+			}
+		}
+		if free > 10 {
+			free = 10
+		}
+
+		out, err := c.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:                    aws.String(c.cfg.QueueURL),
+			MaxNumberOfMessages:         int32(free),
+			WaitTimeSeconds:             longPollSeconds,
+			VisibilityTimeout:           int32(c.cfg.VisibilityTimeout / time.Second),
+			MessageSystemAttributeNames: []types.MessageSystemAttributeName{types.MessageSystemAttributeNameApproximateReceiveCount},
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			zap.L().Error("sqs receive failed", zap.String("queue", queueName(c.cfg.QueueURL)), zap.Error(err))
+			select {
+			case <-time.After(time.Second):
+			case <-ctx.Done():
+			}
+			continue
+		}
+
+		for _, m := range out.Messages {
+			m := m
+			slots <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer func() {
+					<-slots
+					wg.Done()
+				}()
+				c.process(m, handler)
+			}()
+		}
+	}
+	return nil
+}
+
+// process runs handler for one message, extending its visibility while the
+// handler is still working. It deliberately ignores Poll's ctx so a shutdown
+// lets in-flight messages finish and be deleted.
+func (c *SQSConsumer) process(m types.Message, handler func(Message) error) {
+	msg := Message{
+		ID:         aws.ToString(m.MessageId),
+		Body:       []byte(aws.ToString(m.Body)),
+		Attributes: make(map[string]string, len(m.Attributes)),
+	}
+	for k, v := range m.Attributes {
+		msg.Attributes[k] = v
+	}
+	msg.ReceiveCount, _ = strconv.Atoi(m.Attributes[string(types.MessageSystemAttributeNameApproximateReceiveCount)])
+
+	done := make(chan struct{})
+	go c.extendVisibility(m.ReceiptHandle, done)
+	err := handler(msg)
+	close(done)
+
+	log := zap.L().With(zap.String("queue", queueName(c.cfg.QueueURL)), zap.String("message_id", msg.ID))
+	if err != nil {
+		log.Warn("sqs handler failed; message will be redelivered", zap.Int("receive_count", msg.ReceiveCount), zap.Error(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := c.client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(c.cfg.QueueURL),
+		ReceiptHandle: m.ReceiptHandle,
+	}); err != nil {
+		log.Error("sqs delete failed; message will be redelivered", zap.Error(err))
+	}
+}
+
+// extendVisibility pushes the visibility timeout out every half-period until done is closed
+func (c *SQSConsumer) extendVisibility(receipt *string, done <-chan struct{}) {
+	ticker := time.NewTicker(c.cfg.VisibilityTimeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			_, err := c.client.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+				QueueUrl:          aws.String(c.cfg.QueueURL),
+				ReceiptHandle:     receipt,
+				VisibilityTimeout: int32(c.cfg.VisibilityTimeout / time.Second),
+			})
+			cancel()
+			if err != nil {
+				zap.L().Warn("sqs visibility extension failed", zap.String("queue", queueName(c.cfg.QueueURL)), zap.Error(err))
+			}
+		}
+	}
+}
+
+// monitorDLQ samples the dead-letter queue depth until ctx is cancelled
+func (c *SQSConsumer) monitorDLQ(ctx context.Context) {
+	name := queueName(c.cfg.DLQURL)
+	ticker := time.NewTicker(c.cfg.DLQPollInterval)
+	defer ticker.Stop()
+	for {
+		depth, err := c.dlqDepth(ctx)
+		switch {
+		case err == nil:
+			dlqDepth.WithLabelValues(name).Set(float64(depth))
+		case !errors.Is(err, context.Canceled):
+			zap.L().Warn("sqs dlq depth check failed", zap.String("queue", name), zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *SQSConsumer) dlqDepth(ctx context.Context) (int, error) {
+	out, err := c.client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(c.cfg.DLQURL),
+		AttributeNames: []types.QueueAttributeName{types.QueueAttributeNameApproximateNumberOfMessages},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(out.Attributes[string(types.QueueAttributeNameApproximateNumberOfMessages)])
+}
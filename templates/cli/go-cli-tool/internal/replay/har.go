@@ -0,0 +1,190 @@
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// HAR 1.2 subset: enough to read browser/proxy exports and to write replay results
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	Cookies     []harNameValue `json:"cookies"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	Cookies     []harNameValue `json:"cookies"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harTimings struct {
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	SSL     float64 `json:"ssl"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+func readHAR(r io.Reader) ([]Record, error) {
+	var h harFile
+	if err := json.NewDecoder(r).Decode(&h); err != nil {
+		return nil, fmt.Errorf("parse har: %w", err)
+	}
+	records := make([]Record, 0, len(h.Log.Entries))
+	for _, e := range h.Log.Entries {
+		rec := Record{Method: e.Request.Method, URL: e.Request.URL, Headers: map[string]string{}}
+		for _, hv := range e.Request.Headers {
+			// HTTP/2 pseudo headers (":authority", ...) cannot be replayed
+			if len(hv.Name) > 0 && hv.Name[0] != ':' {
+				rec.Headers[hv.Name] = hv.Value
+			}
+		}
+		if e.Request.PostData != nil {
+			rec.Body = e.Request.PostData.Text
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// WriteHAR writes results as a HAR 1.2 document
+func WriteHAR(w io.Writer, creator, version string, results []Result) error {
+	h := harFile{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: creator, Version: version},
+		Entries: make([]harEntry, 0, len(results)),
+	}}
+	for _, r := range results {
+		ms := float64(r.Latency) / float64(time.Millisecond)
+		e := harEntry{
+			StartedDateTime: r.Started.UTC().Format(time.RFC3339Nano),
+			Time:            ms,
+			Request: harRequest{
+				Method:      r.Record.Method,
+				URL:         r.Record.URL,
+				HTTPVersion: "HTTP/1.1",
+				Headers:     []harNameValue{},
+				QueryString: []harNameValue{},
+				Cookies:     []harNameValue{},
+				HeadersSize: -1,
+				BodySize:    len(r.Record.Body),
+			},
+			Response: harResponse{
+				Status:      r.Status,
+				HTTPVersion: "HTTP/1.1",
+				Headers:     []harNameValue{},
+				Cookies:     []harNameValue{},
+				Content:     harContent{Size: len(r.Body), MimeType: r.Header.Get("Content-Type"), Text: string(r.Body)},
+				HeadersSize: -1,
+				BodySize:    len(r.Body),
+			},
+			Timings: harTimings{
+				DNS:     harMillis(r.Timings.DNS),
+				Connect: harMillis(r.Timings.Connect),
+				SSL:     harMillis(r.Timings.TLS),
+				Wait:    float64(r.Timings.TTFB) / float64(time.Millisecond),
+				Receive: float64(r.Timings.Transfer) / float64(time.Millisecond),
+			},
+		}
+		for k, v := range r.Record.Headers {
+			e.Request.Headers = append(e.Request.Headers, harNameValue{Name: k, Value: v})
+		}
+		if r.Record.Body != "" {
+			e.Request.PostData = &harPostData{MimeType: r.Record.Headers["Content-Type"], Text: r.Record.Body}
+		}
+		for k, vs := range r.Header {
+			for _, v := range vs {
+				e.Response.Headers = append(e.Response.Headers, harNameValue{Name: k, Value: v})
+			}
+		}
+		if r.Err != nil {
+			e.Comment = r.Err.Error()
+		} else if r.Mismatch != "" {
+			e.Comment = r.Mismatch
+		}
+		h.Log.Entries = append(h.Log.Entries, e)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(h)
+}
+
+// WriteHARFile writes results as a HAR 1.2 document to path
+func WriteHARFile(path, creator, version string, results []Result) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteHAR(f, creator, version, results); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// harMillis converts a phase duration; negative means not applicable (-1)
+func harMillis(d time.Duration) float64 {
+	if d < 0 {
+		return -1
+	}
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,293 @@
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Record is one request to replay
+type Record struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+}
+
+// Expected is the recorded response a replayed request is compared against
+type Expected struct {
+	Status int    `json:"status"`
+	Body   string `json:"body"`
+}
+
+// Result describes the outcome of one replayed request
+type Result struct {
+	Index    int
+	Record   Record
+	Status   int
+	Latency  time.Duration
+	Body     []byte
+	Header   http.Header
+	Started  time.Time
+	Timings  Timings
+	Err      error
+	Mismatch string // non-empty when the status or body did not match expectations
+}
+
+// Timings splits a request's latency into phases. DNS, Connect and TLS are
+// -1 when a pooled connection was reused.
+type Timings struct {
+	DNS      time.Duration
+	Connect  time.Duration
+	TLS      time.Duration
+	TTFB     time.Duration // request written → first response byte
+	Transfer time.Duration // first response byte → body read
+}
+
+// Failed reports whether the request errored or did not match expectations
+func (r Result) Failed() bool {
+	return r.Err != nil || r.Mismatch != ""
+}
+
+// Replayer sends records to BaseURL
+type Replayer struct {
+	Client      *http.Client
+	BaseURL     string
+	Concurrency int
+	Delay       time.Duration // pause between dispatching requests
+	MatchStatus []int         // allowed statuses; empty accepts any
+	Expected    []Expected    // optional, aligned with the input records by index
+}
+
+// Run replays records and returns results in input order
+func (rp *Replayer) Run(ctx context.Context, records []Record) []Result {
+	base, err := url.Parse(rp.BaseURL)
+	results := make([]Result, len(records))
+	if err != nil {
+		for i := range records {
+			results[i] = Result{Index: i, Record: records[i], Err: fmt.Errorf("invalid base url: %w", err)}
+		}
+		return results
+	}
+	concurrency := rp.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, rec := range records {
+		if ctx.Err() != nil {
+			results[i] = Result{Index: i, Record: rec, Err: ctx.Err()}
+			continue
+		}
+		if i > 0 && rp.Delay > 0 {
+			select {
+			case <-time.After(rp.Delay):
+			case <-ctx.Done():
+			}
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, rec Record) {
+			defer func() { <-sem; wg.Done() }()
+			results[i] = rp.send(ctx, base, i, rec)
+		}(i, rec)
+	}
+	wg.Wait()
+	return results
+}
+
+func (rp *Replayer) send(ctx context.Context, base *url.URL, i int, rec Record) Result {
+	res := Result{Index: i, Record: rec, Started: time.Now()}
+
+	target, err := rebase(base, rec.URL)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	req, err := http.NewRequestWithContext(ctx, rec.Method, target, strings.NewReader(rec.Body))
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	for k, v := range rec.Headers {
+		if strings.EqualFold(k, "Host") || strings.EqualFold(k, "Content-Length") {
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+	// dials may finish on transport goroutines after Do returns, hence mu
+	var (
+		mu                                          sync.Mutex
+		dnsStart, connStart, tlsStart, wrote, first time.Time
+	)
+	timings := Timings{DNS: -1, Connect: -1, TLS: -1}
+	set := func(f func()) { mu.Lock(); f(); mu.Unlock() }
+	req = req.WithContext(httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { set(func() { dnsStart = time.Now() }) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { set(func() { timings.DNS = time.Since(dnsStart) }) },
+		ConnectStart:         func(string, string) { set(func() { connStart = time.Now() }) },
+		ConnectDone:          func(string, string, error) { set(func() { timings.Connect = time.Since(connStart) }) },
+		TLSHandshakeStart:    func() { set(func() { tlsStart = time.Now() }) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { set(func() { timings.TLS = time.Since(tlsStart) }) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { set(func() { wrote = time.Now() }) },
+		GotFirstResponseByte: func() { set(func() { first = time.Now() }) },
+	}))
+
+	resp, err := rp.Client.Do(req)
+	if err != nil {
+		res.Latency = time.Since(res.Started)
+		mu.Lock()
+		res.Timings = timings
+		mu.Unlock()
+		res.Err = err
+		return res
+	}
+	defer resp.Body.Close()
+	res.Status = resp.StatusCode
+	res.Header = resp.Header
+	res.Body, res.Err = io.ReadAll(resp.Body)
+	res.Latency = time.Since(res.Started)
+	mu.Lock()
+	res.Timings = timings
+	if !wrote.IsZero() && !first.IsZero() {
+		res.Timings.TTFB = first.Sub(wrote)
+		res.Timings.Transfer = time.Since(first)
+	}
+	mu.Unlock()
+
+	if len(rp.MatchStatus) > 0 && !containsInt(rp.MatchStatus, res.Status) {
+		res.Mismatch = fmt.Sprintf("status %d not in %v", res.Status, rp.MatchStatus)
+	}
+	if i < len(rp.Expected) && res.Mismatch == "" {
+		res.Mismatch = compare(rp.Expected[i], res.Status, res.Body)
+	}
+	return res
+}
+
+// rebase keeps the path and query of raw and points it at base
+func rebase(base *url.URL, raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid record url %q: %w", raw, err)
+	}
+	out := *base
+	out.Path = strings.TrimSuffix(base.Path, "/") + "/" + strings.TrimPrefix(u.Path, "/")
+	out.RawQuery = u.RawQuery
+	return out.String(), nil
+}
+
+// compare returns a short description of how the response differs from exp
+func compare(exp Expected, status int, body []byte) string {
+	if exp.Status != 0 && exp.Status != status {
+		return fmt.Sprintf("status: expected %d, got %d", exp.Status, status)
+	}
+	if exp.Body == "" {
+		return ""
+	}
+	want, got := []byte(exp.Body), body
+	// compare JSON semantically so key order and whitespace do not matter
+	var wv, gv any
+	if json.Unmarshal(want, &wv) == nil && json.Unmarshal(got, &gv) == nil {
+		want, _ = json.Marshal(wv)
+		got, _ = json.Marshal(gv)
+	}
+	if bytes.Equal(want, got) {
+		return ""
+	}
+	n := 0
+	for n < len(want) && n < len(got) && want[n] == got[n] {
+		n++
+	}
+	return fmt.Sprintf("body differs at byte %d: expected %q, got %q", n, snippet(want, n), snippet(got, n))
+}
+
+func snippet(b []byte, at int) string {
+	end := at + 20
+	if end > len(b) {
+		end = len(b)
+	}
+	return string(b[at:end])
+}
+
+func containsInt(xs []int, v int) bool {
+	for _, x := range xs {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadRecords reads a HAR file (.har) or JSON Lines file of Records
+func LoadRecords(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".har") {
+		return readHAR(f)
+	}
+	var records []Record
+	err = readJSONL(f, func(line []byte) error {
+		var rec Record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return err
+		}
+		if rec.Method == "" {
+			rec.Method = http.MethodGet
+		}
+		records = append(records, rec)
+		return nil
+	})
+	return records, err
+}
+
+// LoadExpected reads a JSON Lines file of Expected responses
+func LoadExpected(path string) ([]Expected, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var out []Expected
+	err = readJSONL(f, func(line []byte) error {
+		var e Expected
+		if err := json.Unmarshal(line, &e); err != nil {
+			return err
+		}
+		out = append(out, e)
+		return nil
+	})
+	return out, err
+}
+
+func readJSONL(r io.Reader, fn func([]byte) error) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for n := 1; sc.Scan(); n++ {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+	}
+	return sc.Err()
+}
@@ -0,0 +1,143 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+type seen struct {
+	method, uri, header, body string
+}
+
+// recorder is a target server remembering every request by its X-Seq header
+func recorder(t *testing.T) (*httptest.Server, func(string) seen) {
+	t.Helper()
+	var (
+		mu  sync.Mutex
+		got = map[string]seen{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		got[r.Header.Get("X-Seq")] = seen{r.Method, r.URL.RequestURI(), r.Header.Get("X-Test"), string(body)}
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		io.WriteString(w, `{"ok": true, "n": 1}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func(seq string) seen {
+		mu.Lock()
+		defer mu.Unlock()
+		return got[seq]
+	}
+}
+
+var records = []Record{
+	{Method: http.MethodGet, URL: "https://prod.example.com/items?page=2", Headers: map[string]string{"X-Seq": "0", "X-Test": "a"}},
+	{Method: http.MethodPost, URL: "/items", Headers: map[string]string{"X-Seq": "1", "X-Test": "b", "Host": "prod.example.com", "Content-Length": "999"}, Body: `{"name":"x"}`},
+	{Method: http.MethodDelete, URL: "/items/1", Headers: map[string]string{"X-Seq": "2"}},
+}
+
+func TestRunSendsRecords(t *testing.T) {
+	srv, got := recorder(t)
+	rp := &Replayer{Client: srv.Client(), BaseURL: srv.URL + "/base/", Concurrency: 2}
+	results := rp.Run(context.Background(), records)
+
+	want := []seen{
+		{http.MethodGet, "/base/items?page=2", "a", ""},
+		{http.MethodPost, "/base/items", "b", `{"name":"x"}`},
+		{http.MethodDelete, "/base/items/1", "", ""},
+	}
+	wantStatus := []int{200, 200, 404}
+	for i, w := range want {
+		if g := got(records[i].Headers["X-Seq"]); g != w {
+			t.Errorf("record %d: server saw %+v, want %+v", i, g, w)
+		}
+		if r := results[i]; r.Index != i || r.Status != wantStatus[i] || r.Err != nil || r.Failed() {
+			t.Errorf("result %d = index %d status %d err %v mismatch %q", i, r.Index, r.Status, r.Err, r.Mismatch)
+		}
+	}
+}
+
+func TestRunMismatches(t *testing.T) {
+	srv, _ := recorder(t)
+	tests := []struct {
+		name     string
+		match    []int
+		expected []Expected
+		want     []bool // Failed per record
+	}{
+		{"match status", []int{200}, nil, []bool{false, false, true}},
+		{"expected body is compared as JSON", nil, []Expected{{Status: 200, Body: `{"n":1,"ok":true}`}, {Body: `{"ok":false}`}}, []bool{false, true, false}},
+		{"expected status", nil, []Expected{{}, {}, {Status: 204}}, []bool{false, false, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := &Replayer{Client: srv.Client(), BaseURL: srv.URL, MatchStatus: tt.match, Expected: tt.expected}
+			for i, r := range rp.Run(context.Background(), records) {
+				if r.Failed() != tt.want[i] {
+					t.Errorf("record %d failed = %v (%q), want %v", i, r.Failed(), r.Mismatch, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	srv, _ := recorder(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, r := range (&Replayer{Client: srv.Client(), BaseURL: srv.URL}).Run(ctx, records) {
+		if r.Err == nil {
+			t.Errorf("record %d ran after cancellation", r.Index)
+		}
+	}
+}
+
+func TestLoadRecordsJSONL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "requests.jsonl")
+	data := `{"url":"/a"}` + "\n\n" + `{"method":"POST","url":"/b","body":"x"}` + "\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadRecords(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Method != http.MethodGet || got[1].Method != http.MethodPost || got[1].Body != "x" {
+		t.Errorf("records = %+v", got)
+	}
+}
+
+func TestHARRoundTrip(t *testing.T) {
+	srv, _ := recorder(t)
+	results := (&Replayer{Client: srv.Client(), BaseURL: srv.URL}).Run(context.Background(), records[:2])
+
+	var buf bytes.Buffer
+	if err := WriteHAR(&buf, "tool", "dev", results); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readHAR(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("read %d records, want 2", len(got))
+	}
+	for i, r := range got {
+		w := records[i]
+		if r.Method != w.Method || r.URL != w.URL || r.Body != w.Body || r.Headers["X-Test"] != w.Headers["X-Test"] {
+			t.Errorf("record %d = %+v, want %+v", i, r, w)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package replay
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/example/tool/internal/output"
+)
+
+var resultHeaders = []string{"#", "METHOD", "PATH", "STATUS", "LATENCY", "RESULT"}
+
+func resultRows(results []Result) [][]string {
+	rows := make([][]string, 0, len(results))
+	for _, r := range results {
+		path := r.Record.URL
+		if u, err := url.Parse(r.Record.URL); err == nil {
+			path = u.RequestURI()
+		}
+		status := "-"
+		if r.Status != 0 {
+			status = strconv.Itoa(r.Status)
+		}
+		outcome := "ok"
+		switch {
+		case r.Err != nil:
+			outcome = "error: " + r.Err.Error()
+		case r.Mismatch != "":
+			outcome = "mismatch: " + r.Mismatch
+		}
+		rows = append(rows, []string{
+			strconv.Itoa(r.Index + 1), r.Record.Method, path, status,
+			r.Latency.Round(time.Millisecond).String(), outcome,
+		})
+	}
+	return rows
+}
+
+// PrintCSV writes one CSV row per replayed request
+func PrintCSV(w io.Writer, results []Result) error {
+	return output.PrintCSV(w, resultHeaders, resultRows(results))
+}
+
+// PrintSummary writes the per-request table, aggregate latency figures and a table
+// of failures grouped by reason
+func PrintSummary(w io.Writer, results []Result) {
+	output.PrintTable(w, resultHeaders, resultRows(results))
+
+	var (
+		total   time.Duration
+		maxLat  time.Duration
+		failed  int
+		reasons = map[string]int{}
+	)
+	for _, r := range results {
+		total += r.Latency
+		if r.Latency > maxLat {
+			maxLat = r.Latency
+		}
+		switch {
+		case r.Err != nil:
+			failed++
+			reasons["error: "+r.Err.Error()]++
+		case r.Mismatch != "":
+			failed++
+			reasons["mismatch: "+r.Mismatch]++
+		}
+	}
+	avg := time.Duration(0)
+	if len(results) > 0 {
+		avg = total / time.Duration(len(results))
+	}
+	fmt.Fprintf(w, "\n%d requests, %d failed, avg latency %s, max latency %s\n",
+		len(results), failed, avg.Round(time.Millisecond), maxLat.Round(time.Millisecond))
+
+	if failed == 0 {
+		return
+	}
+	keys := make([]string, 0, len(reasons))
+	for k := range reasons {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return reasons[keys[i]] > reasons[keys[j]] })
+	rows := make([][]string, 0, len(keys))
+	for _, k := range keys {
+		rows = append(rows, []string{k, strconv.Itoa(reasons[k])})
+	}
+	fmt.Fprintln(w)
+	output.PrintTable(w, []string{"FAILURE", "COUNT"}, rows)
+}
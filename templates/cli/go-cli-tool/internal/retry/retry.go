@@ -0,0 +1,203 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+var attemptsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "retry_attempts_total",
+	Help: "Attempts made by the retry utility, by operation and attempt number.",
+}, []string{"operation", "attempt"})
+
+// RetryConfig controls exponential backoff
+type RetryConfig struct {
+	Operation    string        `mapstructure:"operation"` // metrics/log label
+	MaxAttempts  int           `mapstructure:"max_attempts"`
+	InitialDelay time.Duration `mapstructure:"initial_delay"`
+	MaxDelay     time.Duration `mapstructure:"max_delay"`
+	Multiplier   float64       `mapstructure:"multiplier"`
+	Jitter       bool          `mapstructure:"jitter"`
+
+	// RetryOn decides whether an error is worth retrying. nil retries every error.
+	RetryOn func(error) bool `mapstructure:"-"`
+}
+
+func (c RetryConfig) withDefaults() RetryConfig {
+	if c.Operation == "" {
+		c.Operation = "default"
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 3
+	}
+	if c.InitialDelay <= 0 {
+		c.InitialDelay = 100 * time.Millisecond
+	}
+	if c.MaxDelay <= 0 {
+		c.MaxDelay = 5 * time.Second
+	}
+	if c.Multiplier < 1 {
+		c.Multiplier = 2
+	}
+	return c
+}
+
+// Do calls fn until it succeeds, RetryOn rejects the error, attempts are exhausted
+// or ctx is cancelled. The last error from fn is returned.
+func Do(ctx context.Context, cfg RetryConfig, fn func(context.Context) error) error {
+	cfg = cfg.withDefaults()
+	delay := cfg.InitialDelay
+
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		attemptsTotal.WithLabelValues(cfg.Operation, strconv.Itoa(attempt)).Inc()
+
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		if cfg.RetryOn != nil && !cfg.RetryOn(err) {
+			return err
+		}
+		if attempt >= cfg.MaxAttempts {
+			return fmt.Errorf("%s: giving up after %d attempts: %w", cfg.Operation, attempt, err)
+		}
+
+		wait := delay
+		if cfg.Jitter {
+			// equal jitter: keep half the delay, randomize the rest
+			half := int64(wait) / 2
+			wait = time.Duration(half + rand.Int63n(half+1))
+		}
+		zap.L().Debug("retrying operation",
+			zap.String("operation", cfg.Operation),
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", wait),
+			zap.Error(err),
+		)
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+
+		delay = time.Duration(float64(delay) * cfg.Multiplier)
+		if delay > cfg.MaxDelay {
+			delay = cfg.MaxDelay
+		}
+	}
+}
+
+// statusError marks a response whose status code is retryable
+type statusError struct{ code int }
+
+func (e *statusError) Error() string { return "retryable status " + strconv.Itoa(e.code) }
+
+// retryableStatus reports whether an HTTP status is transient
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// RetryableHTTPClient returns a copy of base that retries transport errors and
+// 429/502/503/504 responses. Requests with a body are only retried when GetBody is set
+// (true for bodies built from bytes/strings readers by http.NewRequest).
+func RetryableHTTPClient(cfg RetryConfig, base *http.Client) *http.Client {
+	if base == nil {
+		base = http.DefaultClient
+	}
+	next := base.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if cfg.Operation == "" {
+		cfg.Operation = "http"
+	}
+	c := *base
+	c.Transport = &retryTransport{cfg: cfg, next: next}
+	return &c
+}
+
+type retryTransport struct {
+	cfg  RetryConfig
+	next http.RoundTripper
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return t.next.RoundTrip(req)
+	}
+
+	cfg := t.cfg
+	userRetryOn := cfg.RetryOn
+	cfg.RetryOn = func(err error) bool {
+		var se *statusError
+		if errors.As(err, &se) {
+			return true
+		}
+		return userRetryOn == nil || userRetryOn(err)
+	}
+
+	var resp *http.Response
+	first := true
+	err := Do(req.Context(), cfg, func(ctx context.Context) error {
+		r := req
+		if !first && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return err
+			}
+			r = req.Clone(ctx)
+			r.Body = body
+		}
+		first = false
+
+		// release the previous attempt's connection before retrying
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			resp = nil
+		}
+
+		res, err := t.next.RoundTrip(r)
+		if err != nil {
+			return err
+		}
+		resp = res
+		if retryableStatus(res.StatusCode) {
+			return &statusError{code: res.StatusCode}
+		}
+		return nil
+	})
+
+	if err != nil {
+		var se *statusError
+		if errors.As(err, &se) && resp != nil {
+			// attempts exhausted: hand the last response to the caller
+			return resp, nil
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return nil, err
+	}
+	return resp, nil
+}
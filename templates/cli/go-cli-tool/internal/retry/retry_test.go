@@ -0,0 +1,93 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var fast = RetryConfig{MaxAttempts: 4, InitialDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+
+func TestDoMaxAttempts(t *testing.T) {
+	calls := 0
+	err := Do(context.Background(), fast, func(context.Context) error {
+		calls++
+		return errors.New("boom")
+	})
+	if calls != 4 || err == nil || !strings.Contains(err.Error(), "giving up after 4 attempts") {
+		t.Errorf("calls = %d, err = %v", calls, err)
+	}
+}
+
+func TestDoStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cfg := fast
+	cfg.InitialDelay, cfg.MaxAttempts = time.Hour, 10
+	calls := 0
+	start := time.Now()
+	err := Do(ctx, cfg, func(context.Context) error {
+		calls++
+		cancel()
+		return errors.New("boom")
+	})
+	if !errors.Is(err, context.Canceled) || calls != 1 || time.Since(start) > time.Second {
+		t.Errorf("calls = %d, err = %v after %v", calls, err, time.Since(start))
+	}
+}
+
+func TestDoRetryOn(t *testing.T) {
+	permanent := errors.New("permanent")
+	cfg := fast
+	cfg.RetryOn = func(err error) bool { return !errors.Is(err, permanent) }
+	calls := 0
+	err := Do(context.Background(), cfg, func(context.Context) error {
+		calls++
+		if calls == 2 {
+			return permanent
+		}
+		return errors.New("transient")
+	})
+	if calls != 2 || !errors.Is(err, permanent) {
+		t.Errorf("calls = %d, err = %v", calls, err)
+	}
+}
+
+func TestRetryableHTTPClient(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		wantCalls int32
+		want      int
+	}{
+		{"4xx not retried", []int{http.StatusBadRequest}, 1, http.StatusBadRequest},
+		{"503 then ok", []int{http.StatusServiceUnavailable, http.StatusOK}, 2, http.StatusOK},
+		{"429 exhausted", []int{429, 429, 429, 429, 429}, 4, http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := calls.Add(1)
+				if body := r.Header.Get("Content-Length"); body != "4" {
+					t.Errorf("attempt %d: Content-Length %q", n, body)
+				}
+				w.WriteHeader(tt.statuses[n-1])
+			}))
+			defer srv.Close()
+			client := RetryableHTTPClient(fast, srv.Client())
+			resp, err := client.Post(srv.URL, "text/plain", strings.NewReader("ping"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want || calls.Load() != tt.wantCalls {
+				t.Errorf("status %d after %d calls, want %d after %d", resp.StatusCode, calls.Load(), tt.want, tt.wantCalls)
+			}
+		})
+	}
+}
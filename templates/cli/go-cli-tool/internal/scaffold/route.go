@@ -0,0 +1,302 @@
+package scaffold
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// routeGroup is the prefix of the chi template's versioned route group
+const routeGroup = "/api/v1"
+
+// routeMarker and routeReturn are the places in the chi template's
+// internal/server/router.go where routes inside and outside the group are
+// appended
+const (
+	routeMarker = "// register other handlers here"
+	routeReturn = "\n\treturn r\n}"
+)
+
+var (
+	validRouteName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*$`)
+	pathParam      = regexp.MustCompile(`\{[^}]*\}`)
+	modulePath     = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+)
+
+// routeMethods maps the accepted methods to their chi router method and the
+// status the stub handler answers with
+var routeMethods = map[string]struct{ chi, status string }{
+	"GET":    {"Get", "http.StatusOK"},
+	"POST":   {"Post", "http.StatusCreated"},
+	"PUT":    {"Put", "http.StatusOK"},
+	"PATCH":  {"Patch", "http.StatusOK"},
+	"DELETE": {"Delete", "http.StatusNoContent"},
+}
+
+// RouteOptions describe one generated route
+type RouteOptions struct {
+	Path    string   // e.g. /api/v1/orders/{id}
+	Methods []string // any of GET, POST, PUT, PATCH, DELETE
+	Name    string   // Go name of the resource, e.g. Orders
+	Dir     string   // root of a service generated from the chi template; default .
+	Force   bool     // overwrite existing handler files
+}
+
+type routeMethod struct {
+	Method string // GET
+	Chi    string // Get
+	Func   string // GetOrders
+	Status string // http.StatusOK
+}
+
+// GenerateRoute writes a stub handler, its unit test and an integration test
+// (build tag integration) to internal/handler, plus the package's writeJSON
+// helper when missing, and registers the route in internal/server/router.go
+// when the service has one. Routes under /api/v1 go into that group. It
+// returns the written paths; nothing is written when a handler file exists
+// and Force is not set.
+func GenerateRoute(opts RouteOptions) ([]string, error) {
+	if !strings.HasPrefix(opts.Path, "/") {
+		return nil, fmt.Errorf("scaffold: path %q must start with /", opts.Path)
+	}
+	if !validRouteName.MatchString(opts.Name) {
+		return nil, fmt.Errorf("scaffold: name %q must be a Go identifier of letters and digits", opts.Name)
+	}
+	name := string(unicode.ToUpper(rune(opts.Name[0]))) + opts.Name[1:]
+	if opts.Dir == "" {
+		opts.Dir = "."
+	}
+	var methods []routeMethod
+	seen := map[string]bool{}
+	for _, m := range opts.Methods {
+		m = strings.ToUpper(strings.TrimSpace(m))
+		if m == "" || seen[m] {
+			continue
+		}
+		rm, ok := routeMethods[m]
+		if !ok {
+			return nil, fmt.Errorf("scaffold: unsupported method %q (want GET, POST, PUT, PATCH or DELETE)", m)
+		}
+		seen[m] = true
+		methods = append(methods, routeMethod{Method: m, Chi: rm.chi, Func: rm.chi + name, Status: rm.status})
+	}
+	if len(methods) == 0 {
+		return nil, errors.New("scaffold: no methods given")
+	}
+
+	funcs := template.FuncMap{
+		"Name":       func() string { return name },
+		"Path":       func() string { return opts.Path },
+		"SamplePath": func() string { return pathParam.ReplaceAllString(opts.Path, "1") },
+		"Param": func() string {
+			// first path parameter without its pattern: {id:[0-9]+} gives id
+			param := strings.Trim(pathParam.FindString(opts.Path), "{}")
+			if i := strings.IndexByte(param, ':'); i >= 0 {
+				param = param[:i]
+			}
+			return param
+		},
+		"Methods": func() []routeMethod { return methods },
+	}
+	base := filepath.Join(opts.Dir, "internal", "handler")
+	file := snakeCase(name)
+	outputs := []struct{ skeleton, target string }{
+		{"handler.go", file + ".go"},
+		{"handler_test.go", file + "_test.go"},
+		{"handler_integration_test.go", file + "_integration_test.go"},
+	}
+	files := map[string][]byte{}
+	var order, existing []string
+	for _, o := range outputs {
+		target := filepath.Join(base, o.target)
+		if _, err := os.Stat(target); err == nil && !opts.Force {
+			existing = append(existing, target)
+		}
+		src, err := renderGo(o.skeleton, funcs)
+		if err != nil {
+			return nil, err
+		}
+		files[target] = src
+		order = append(order, target)
+	}
+	if len(existing) > 0 {
+		return nil, &ExistsError{Paths: existing}
+	}
+	// the helper is shared by every generated handler; never overwrite it
+	if helper := filepath.Join(base, "json.go"); !exists(helper) {
+		src, err := renderGo("json.go", funcs)
+		if err != nil {
+			return nil, err
+		}
+		files[helper] = src
+		order = append(order, helper)
+	}
+
+	routerPath := filepath.Join(opts.Dir, "internal", "server", "router.go")
+	if exists(routerPath) {
+		src, err := registerRoute(opts.Dir, routerPath, opts.Path, methods)
+		if err != nil {
+			return nil, err
+		}
+		if src != nil {
+			files[routerPath] = src
+			order = append(order, routerPath)
+		}
+	}
+
+	written := make([]string, 0, len(order))
+	for _, p := range order {
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			return written, err
+		}
+		if err := os.WriteFile(p, files[p], 0o644); err != nil {
+			return written, err
+		}
+		written = append(written, p)
+	}
+	return written, nil
+}
+
+// renderGo renders a route skeleton and gofmts it, which also rejects output
+// that is not valid Go
+func renderGo(skeleton string, funcs template.FuncMap) ([]byte, error) {
+	p := "templates/route/" + skeleton + ".tmpl"
+	text, err := skeletons.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	src, err := render(p, string(text), funcs)
+	if err != nil {
+		return nil, err
+	}
+	out, err := format.Source([]byte(src))
+	if err != nil {
+		return nil, fmt.Errorf("scaffold: %s: generated invalid Go: %w", skeleton, err)
+	}
+	return out, nil
+}
+
+// registerRoute returns router.go with a registration for every method that
+// is not registered yet and the handler package imported, or nil when there
+// is nothing to add
+func registerRoute(dir, routerPath, path string, methods []routeMethod) ([]byte, error) {
+	src, err := os.ReadFile(routerPath)
+	if err != nil {
+		return nil, err
+	}
+	mod, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return nil, fmt.Errorf("scaffold: reading module path: %w", err)
+	}
+	m := modulePath.FindSubmatch(mod)
+	if m == nil {
+		return nil, fmt.Errorf("scaffold: no module line in %s", filepath.Join(dir, "go.mod"))
+	}
+	handlerImport := string(m[1]) + "/internal/handler"
+
+	route, anchor, indent := path, routeReturn, "\t"
+	if path == routeGroup || strings.HasPrefix(path, routeGroup+"/") {
+		route, anchor, indent = strings.TrimPrefix(path, routeGroup), routeMarker, "\t\t"
+		if route == "" {
+			route = "/"
+		}
+	}
+	var lines bytes.Buffer
+	for _, rm := range methods {
+		line := fmt.Sprintf("r.%s(%q, handler.%s)", rm.Chi, route, rm.Func)
+		if !bytes.Contains(src, []byte(line)) {
+			lines.WriteString(indent + line + "\n")
+		}
+	}
+	if lines.Len() == 0 {
+		return nil, nil
+	}
+
+	var at int
+	if anchor == routeMarker {
+		if at = bytes.Index(src, []byte(anchor)); at < 0 {
+			return nil, fmt.Errorf("scaffold: %s has no %q line to add the route before", routerPath, routeMarker)
+		}
+		at = bytes.LastIndexByte(src[:at], '\n') + 1
+	} else {
+		if at = bytes.LastIndex(src, []byte(anchor)); at < 0 {
+			return nil, fmt.Errorf("scaffold: %s: cannot find the end of the router function", routerPath)
+		}
+		at++ // after the blank line before return, keeping one after the routes
+		lines.WriteString("\n")
+	}
+	out := make([]byte, 0, len(src)+lines.Len()+len(handlerImport)+4)
+	out = append(out, src[:at]...)
+	out = append(out, lines.Bytes()...)
+	out = append(out, src[at:]...)
+
+	if out, err = addImport(routerPath, out, handlerImport); err != nil {
+		return nil, err
+	}
+	formatted, err := format.Source(out)
+	if err != nil {
+		return nil, fmt.Errorf("scaffold: %s: %w", routerPath, err)
+	}
+	return formatted, nil
+}
+
+// addImport appends path to the last import block of src unless it is
+// already imported; gofmt then sorts it into its group
+func addImport(filename string, src []byte, path string) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, parser.ImportsOnly)
+	if err != nil {
+		return nil, fmt.Errorf("scaffold: %w", err)
+	}
+	for _, imp := range f.Imports {
+		if p, _ := strconv.Unquote(imp.Path.Value); p == path {
+			return src, nil
+		}
+	}
+	var block *ast.GenDecl
+	for _, d := range f.Decls {
+		if gd, ok := d.(*ast.GenDecl); ok && gd.Tok == token.IMPORT && gd.Rparen.IsValid() {
+			block = gd
+		}
+	}
+	if block == nil {
+		return nil, fmt.Errorf("scaffold: %s has no parenthesized import block", filename)
+	}
+	at := fset.Position(block.Rparen).Offset
+	out := append([]byte{}, src[:at]...)
+	out = append(out, "\t"+strconv.Quote(path)+"\n"...)
+	return append(out, src[at:]...), nil
+}
+
+// snakeCase turns a Go name into a file name: OrderItems becomes
+// order_items, HTTPLogs http_logs
+func snakeCase(s string) string {
+	rs := []rune(s)
+	var b strings.Builder
+	for i, r := range rs {
+		if unicode.IsUpper(r) {
+			if i > 0 && (!unicode.IsUpper(rs[i-1]) || i+1 < len(rs) && unicode.IsLower(rs[i+1])) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func exists(p string) bool {
+	_, err := os.Stat(p)
+	return err == nil
+}
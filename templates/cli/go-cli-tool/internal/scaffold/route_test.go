@@ -0,0 +1,187 @@
+package scaffold
+
+import (
+	"errors"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// routerSrc is the shape of internal/server/router.go in the chi template
+const routerSrc = `package server
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func NewRouter() *chi.Mux {
+	r := chi.NewRouter()
+	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {})
+
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Get("/ping", func(w http.ResponseWriter, r *http.Request) {})
+		// register other handlers here
+	})
+
+	return r
+}
+`
+
+// newChiService writes a go.mod and router.go like a generated chi service
+func newChiService(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/shop\n\ngo 1.22\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "internal", "server"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "internal", "server", "router.go"), []byte(routerSrc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func parseGo(t *testing.T, p string) string {
+	t.Helper()
+	src, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), p, src, parser.AllErrors|parser.ParseComments); err != nil {
+		t.Errorf("%s is not valid Go: %v", p, err)
+	}
+	return string(src)
+}
+
+func TestGenerateRoute(t *testing.T) {
+	dir := newChiService(t)
+	written, err := GenerateRoute(RouteOptions{Path: "/api/v1/orders/{id}", Methods: []string{"get", "POST", "GET"}, Name: "orderItems", Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handlerDir := filepath.Join(dir, "internal", "handler")
+	want := []string{
+		filepath.Join(handlerDir, "order_items.go"),
+		filepath.Join(handlerDir, "order_items_test.go"),
+		filepath.Join(handlerDir, "order_items_integration_test.go"),
+		filepath.Join(handlerDir, "json.go"),
+		filepath.Join(dir, "internal", "server", "router.go"),
+	}
+	if strings.Join(written, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("written = %v, want %v", written, want)
+	}
+	for _, p := range written {
+		parseGo(t, p)
+	}
+
+	handler := parseGo(t, want[0])
+	for _, fn := range []string{"func GetOrderItems(", "func PostOrderItems("} {
+		if !strings.Contains(handler, fn) {
+			t.Errorf("handler lacks %s", fn)
+		}
+	}
+	if integration := parseGo(t, want[2]); !strings.HasPrefix(integration, "//go:build integration\n") {
+		t.Errorf("integration test does not start with the build tag:\n%s", integration)
+	}
+
+	router := parseGo(t, want[4])
+	get := strings.Index(router, `r.Get("/orders/{id}", handler.GetOrderItems)`)
+	post := strings.Index(router, `r.Post("/orders/{id}", handler.PostOrderItems)`)
+	marker := strings.Index(router, routeMarker)
+	if get < 0 || post < 0 || get > marker || post > marker {
+		t.Errorf("routes not registered inside /api/v1:\n%s", router)
+	}
+	if !strings.Contains(router, `"example.com/shop/internal/handler"`) {
+		t.Errorf("handler package not imported:\n%s", router)
+	}
+}
+
+func TestGenerateRouteOutsideGroup(t *testing.T) {
+	dir := newChiService(t)
+	if _, err := GenerateRoute(RouteOptions{Path: "/webhooks/stripe", Methods: []string{"POST"}, Name: "Stripe", Dir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	router := parseGo(t, filepath.Join(dir, "internal", "server", "router.go"))
+	route := strings.Index(router, `r.Post("/webhooks/stripe", handler.PostStripe)`)
+	if route < 0 || route < strings.Index(router, routeMarker) || route > strings.LastIndex(router, "return r") {
+		t.Errorf("route not registered after the group:\n%s", router)
+	}
+}
+
+func TestGenerateRouteAgain(t *testing.T) {
+	dir := newChiService(t)
+	opts := RouteOptions{Path: "/api/v1/orders", Methods: []string{"GET"}, Name: "Orders", Dir: dir}
+	if _, err := GenerateRoute(opts); err != nil {
+		t.Fatal(err)
+	}
+
+	var exists *ExistsError
+	if _, err := GenerateRoute(opts); !errors.As(err, &exists) {
+		t.Fatalf("second GenerateRoute = %v, want *ExistsError", err)
+	}
+
+	// with Force the handler is rewritten but the route is not added twice
+	opts.Force, opts.Methods = true, []string{"GET", "DELETE"}
+	if _, err := GenerateRoute(opts); err != nil {
+		t.Fatal(err)
+	}
+	router := parseGo(t, filepath.Join(dir, "internal", "server", "router.go"))
+	if n := strings.Count(router, `handler.GetOrders`); n != 1 {
+		t.Errorf("GET registered %d times", n)
+	}
+	if !strings.Contains(router, `r.Delete("/orders", handler.DeleteOrders)`) {
+		t.Errorf("DELETE not registered:\n%s", router)
+	}
+	if n := strings.Count(router, `"example.com/shop/internal/handler"`); n != 1 {
+		t.Errorf("handler package imported %d times", n)
+	}
+}
+
+func TestGenerateRouteWithoutRouter(t *testing.T) {
+	dir := t.TempDir()
+	written, err := GenerateRoute(RouteOptions{Path: "/orders", Methods: []string{"GET"}, Name: "Orders", Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(written) != 4 {
+		t.Errorf("written = %v, want the handler files only", written)
+	}
+	for _, p := range written {
+		parseGo(t, p)
+	}
+}
+
+func TestGenerateRouteRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		opts RouteOptions
+	}{
+		{"relative path", RouteOptions{Path: "orders", Methods: []string{"GET"}, Name: "Orders"}},
+		{"bad name", RouteOptions{Path: "/orders", Methods: []string{"GET"}, Name: "order-items"}},
+		{"no methods", RouteOptions{Path: "/orders", Methods: []string{" "}, Name: "Orders"}},
+		{"unsupported method", RouteOptions{Path: "/orders", Methods: []string{"TRACE"}, Name: "Orders"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Dir = t.TempDir()
+			if _, err := GenerateRoute(tt.opts); err == nil {
+				t.Error("GenerateRoute succeeded")
+			}
+		})
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	for in, want := range map[string]string{"Orders": "orders", "OrderItems": "order_items", "HTTPLogs": "http_logs", "UserID": "user_id"} {
+		if got := snakeCase(in); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Package scaffold generates a new service from the skeletons embedded under
+// templates/. Every file there ends in .tmpl, so the skeletons are neither
+// built with this module nor treated as nested modules by go:embed; the
+// suffix is dropped on output. File contents and paths are rendered with
+// text/template, where {{ServiceName}}, {{ModulePath}}, {{Year}} and
+// {{WithDB}} are functions.
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed templates/*
+var skeletons embed.FS
+
+// dbOverlay holds the PostgreSQL files added by WithDB on top of any template
+const dbOverlay = "db"
+
+// Templates lists the skeletons that can be generated
+var Templates = []string{"chi-rest", "grpc", "graphql", "cli"}
+
+// ExistsError lists output files that already exist when Force is not set
+type ExistsError struct {
+	Paths []string
+}
+
+func (e *ExistsError) Error() string {
+	return "scaffold: " + strings.Join(e.Paths, ", ") + " already exist; use --force to overwrite"
+}
+
+var validName = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// Options describe one generated service
+type Options struct {
+	Template   string // one of Templates
+	Name       string // service name, e.g. my-service
+	ModulePath string // Go module path; default github.com/example/<Name>
+	Output     string // target directory; default ./<Name>
+	WithDB     bool   // add the PostgreSQL files
+	Force      bool   // overwrite existing files
+}
+
+// Generate renders the template into opts.Output and returns the written
+// paths. Nothing is written when a target exists and Force is not set.
+func Generate(opts Options) ([]string, error) {
+	if !isTemplate(opts.Template) {
+		return nil, fmt.Errorf("scaffold: unknown template %q (want one of %s)", opts.Template, strings.Join(Templates, ", "))
+	}
+	if !validName.MatchString(opts.Name) {
+		return nil, fmt.Errorf("scaffold: name %q must be lowercase letters, digits and dashes, starting with a letter", opts.Name)
+	}
+	if opts.ModulePath == "" {
+		opts.ModulePath = "github.com/example/" + opts.Name
+	}
+	if opts.Output == "" {
+		opts.Output = opts.Name
+	}
+	funcs := template.FuncMap{
+		"ServiceName": func() string { return opts.Name },
+		"ModulePath":  func() string { return opts.ModulePath },
+		"Year":        func() int { return time.Now().Year() },
+		"WithDB":      func() bool { return opts.WithDB },
+	}
+
+	roots := []string{path.Join("templates", opts.Template)}
+	if opts.WithDB {
+		roots = append(roots, path.Join("templates", dbOverlay))
+	}
+	files := map[string][]byte{} // output path (slash separated) -> content
+	for _, root := range roots {
+		err := fs.WalkDir(skeletons, root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel := strings.TrimSuffix(strings.TrimPrefix(p, root+"/"), ".tmpl")
+			out, err := render(p+":path", rel, funcs)
+			if err != nil {
+				return err
+			}
+			src, err := skeletons.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			content, err := render(p, string(src), funcs)
+			if err != nil {
+				return err
+			}
+			files[out] = []byte(content)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	if !opts.Force {
+		var existing []string
+		for _, p := range paths {
+			if _, err := os.Stat(filepath.Join(opts.Output, filepath.FromSlash(p))); err == nil {
+				existing = append(existing, filepath.Join(opts.Output, filepath.FromSlash(p)))
+			}
+		}
+		if len(existing) > 0 {
+			return nil, &ExistsError{Paths: existing}
+		}
+	}
+
+	written := make([]string, 0, len(paths))
+	for _, p := range paths {
+		target := filepath.Join(opts.Output, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return written, err
+		}
+		if err := os.WriteFile(target, files[p], 0o644); err != nil {
+			return written, err
+		}
+		written = append(written, target)
+	}
+	return written, nil
+}
+
+func render(name, text string, funcs template.FuncMap) (string, error) {
+	t, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("scaffold: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, nil); err != nil {
+		return "", fmt.Errorf("scaffold: %w", err)
+	}
+	return buf.String(), nil
+}
+
+func isTemplate(name string) bool {
+	for _, t := range Templates {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
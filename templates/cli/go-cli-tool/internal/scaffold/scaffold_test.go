@@ -0,0 +1,128 @@
+package scaffold
+
+import (
+	"errors"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var placeholders = []string{"{{", "ServiceName", "ModulePath", "WithDB"}
+
+func TestGenerate(t *testing.T) {
+	for _, tmpl := range Templates {
+		for _, withDB := range []bool{false, true} {
+			name := tmpl
+			if withDB {
+				name += "+db"
+			}
+			t.Run(name, func(t *testing.T) {
+				out, err := os.MkdirTemp("", "scaffold-")
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer os.RemoveAll(out)
+
+				written, err := Generate(Options{Template: tmpl, Name: "billing-api", ModulePath: "example.com/acme/billing-api", Output: out, WithDB: withDB})
+				if err != nil {
+					t.Fatal(err)
+				}
+				sawName, sawDB := false, false
+				for _, p := range written {
+					rel, _ := filepath.Rel(out, p)
+					b, err := os.ReadFile(p)
+					if err != nil {
+						t.Fatal(err)
+					}
+					content := string(b)
+					for _, ph := range placeholders {
+						if strings.Contains(rel, ph) || strings.Contains(content, ph) {
+							t.Errorf("%s still contains %q", rel, ph)
+						}
+					}
+					if strings.HasSuffix(rel, ".tmpl") {
+						t.Errorf("%s kept its .tmpl suffix", rel)
+					}
+					if strings.Contains(content, "billing-api") {
+						sawName = true
+					}
+					if strings.HasPrefix(filepath.ToSlash(rel), "migrations/") {
+						sawDB = true
+					}
+					switch {
+					case rel == "go.mod":
+						if !strings.HasPrefix(content, "module example.com/acme/billing-api\n") {
+							t.Errorf("go.mod = %q", content)
+						}
+					case strings.HasSuffix(rel, ".go"):
+						if _, err := parser.ParseFile(token.NewFileSet(), rel, b, parser.AllErrors); err != nil {
+							t.Errorf("%s is not valid Go: %v", rel, err)
+						}
+					}
+				}
+				if !sawName {
+					t.Error("no generated file contains the service name")
+				}
+				if sawDB != withDB {
+					t.Errorf("migrations generated = %v, want %v", sawDB, withDB)
+				}
+				if tmpl == "cli" {
+					if _, err := os.Stat(filepath.Join(out, "cmd", "billing-api", "main.go")); err != nil {
+						t.Errorf("templated path not rendered: %v", err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestGenerateForce(t *testing.T) {
+	out := t.TempDir()
+	opts := Options{Template: "chi-rest", Name: "svc", Output: out}
+	if _, err := Generate(opts); err != nil {
+		t.Fatal(err)
+	}
+	main := filepath.Join(out, "cmd", "server", "main.go")
+	if err := os.WriteFile(main, []byte("edited"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var exists *ExistsError
+	if _, err := Generate(opts); !errors.As(err, &exists) || len(exists.Paths) == 0 {
+		t.Fatalf("second Generate = %v, want *ExistsError", err)
+	}
+	if b, _ := os.ReadFile(main); string(b) != "edited" {
+		t.Error("existing file overwritten without Force")
+	}
+
+	opts.Force = true
+	if _, err := Generate(opts); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(main); string(b) == "edited" {
+		t.Error("Force did not overwrite")
+	}
+}
+
+func TestGenerateRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"unknown template", Options{Template: "rails", Name: "svc"}},
+		{"upper-case name", Options{Template: "cli", Name: "Svc"}},
+		{"name with slash", Options{Template: "cli", Name: "a/b"}},
+		{"empty name", Options{Template: "cli"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Output = t.TempDir()
+			if _, err := Generate(tt.opts); err == nil {
+				t.Error("Generate succeeded")
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// Package tracing exports the CLI's spans over OpenTelemetry and links them to
+// the trace of the system that invoked the command (CI job, workflow engine)
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ExporterNone records no spans; trace context is still propagated
+const ExporterNone = "none"
+
+// TracerName identifies the CLI's spans
+const TracerName = "github.com/example/tool"
+
+// Config configures span export
+type Config struct {
+	Exporter    string `mapstructure:"exporter"` // none | otlp-grpc | otlp-http | stdout
+	Endpoint    string `mapstructure:"endpoint"` // collector host:port; empty uses OTEL_EXPORTER_OTLP_* env vars
+	Insecure    bool   `mapstructure:"insecure"`
+	ServiceName string `mapstructure:"service_name"`
+}
+
+// Init installs the global TracerProvider and the W3C trace context
+// propagator. The returned func flushes pending spans; call it before the
+// process exits or they are lost.
+func Init(ctx context.Context, cfg Config) (func(), error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var (
+		exp sdktrace.SpanExporter
+		err error
+	)
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return func() {}, nil
+	case "otlp-grpc":
+		opts := []otlptracegrpc.Option{}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exp, err = otlptracegrpc.New(ctx, opts...)
+	case "otlp-http":
+		opts := []otlptracehttp.Option{}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exp, err = otlptracehttp.New(ctx, opts...)
+	case "stdout":
+		exp, err = stdouttrace.New()
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q (want none, otlp-grpc, otlp-http or stdout)", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create %s trace exporter: %w", cfg.Exporter, err)
+	}
+
+	// schemaless, so the merge never conflicts with the SDK's semconv version
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("build trace resource: %w", err)
+	}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exp), sdktrace.WithResource(res))
+	otel.SetTracerProvider(tp)
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		tp.Shutdown(ctx)
+	}, nil
+}
+
+// WithTraceParent returns ctx carrying the remote span described by a W3C
+// traceparent value (00-<trace id>-<span id>-<flags>), so spans started from
+// it become its children. An empty value returns ctx unchanged.
+func WithTraceParent(ctx context.Context, traceparent string) (context.Context, error) {
+	if traceparent == "" {
+		return ctx, nil
+	}
+	ctx = propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier{"traceparent": traceparent})
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return nil, fmt.Errorf("invalid traceparent %q", traceparent)
+	}
+	return ctx, nil
+}
+
+// Start starts a span with the CLI's tracer
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(TracerName).Start(ctx, name, opts...)
+}
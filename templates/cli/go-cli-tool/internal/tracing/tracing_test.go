@@ -0,0 +1,74 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const (
+	parentTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentSpanID  = "00f067aa0ba902b7"
+)
+
+func TestStartWithTraceParent(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+	otel.SetTracerProvider(tp)
+
+	ctx, err := WithTraceParent(context.Background(), "00-"+parentTraceID+"-"+parentSpanID+"-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, span := Start(ctx, "cli.run")
+	span.End()
+
+	spans := exp.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("exported %d spans, want 1", len(spans))
+	}
+	got := spans[0]
+	if got.Name != "cli.run" {
+		t.Errorf("name = %q", got.Name)
+	}
+	if got.Parent.SpanID().String() != parentSpanID || !got.Parent.IsRemote() {
+		t.Errorf("parent span = %s (remote %v), want remote %s", got.Parent.SpanID(), got.Parent.IsRemote(), parentSpanID)
+	}
+	if got.SpanContext.TraceID().String() != parentTraceID {
+		t.Errorf("trace ID = %s, want the parent's %s", got.SpanContext.TraceID(), parentTraceID)
+	}
+}
+
+func TestWithTraceParent(t *testing.T) {
+	tests := []struct {
+		name, value string
+		wantErr     bool
+	}{
+		{"empty", "", false},
+		{"valid", "00-" + parentTraceID + "-" + parentSpanID + "-01", false},
+		{"garbage", "not-a-traceparent", true},
+		{"zero trace id", "00-00000000000000000000000000000000-" + parentSpanID + "-01", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := WithTraceParent(context.Background(), tt.value); (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestInitExporters(t *testing.T) {
+	if _, err := Init(context.Background(), Config{Exporter: "zipkin"}); err == nil {
+		t.Error("unknown exporter accepted")
+	}
+	flush, err := Init(context.Background(), Config{Exporter: ExporterNone})
+	if err != nil {
+		t.Fatal(err)
+	}
+	flush()
+}
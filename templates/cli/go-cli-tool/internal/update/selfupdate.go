@@ -0,0 +1,235 @@
+package update
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/minio/selfupdate"
+	"go.uber.org/zap"
+	"golang.org/x/mod/semver"
+)
+
+// SelfUpdateConfig describes where releases are published
+type SelfUpdateConfig struct {
+	GitHubOwner    string
+	GitHubRepo     string
+	CurrentVersion string
+	// PublicKeyFile holds a base64 Ed25519 public key; when set every binary must
+	// have a matching "<asset>.sig" release asset.
+	PublicKeyFile string
+	// APIBaseURL defaults to https://api.github.com (override for GitHub Enterprise)
+	APIBaseURL string
+}
+
+// Release is the subset of the GitHub release payload we need
+type Release struct {
+	TagName string  `json:"tag_name"`
+	Assets  []Asset `json:"assets"`
+}
+
+// Asset is a downloadable release file
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// Updater checks for and applies new releases
+type Updater struct {
+	cfg    SelfUpdateConfig
+	client *http.Client
+}
+
+// New returns an Updater whose HTTP client honours HTTPS_PROXY/https_proxy
+func New(cfg SelfUpdateConfig) *Updater {
+	if cfg.APIBaseURL == "" {
+		cfg.APIBaseURL = "https://api.github.com"
+	}
+	return &Updater{
+		cfg: cfg,
+		client: &http.Client{
+			Timeout:   5 * time.Minute,
+			Transport: &http.Transport{Proxy: http.ProxyFromEnvironment},
+		},
+	}
+}
+
+// Latest fetches the latest release and reports whether it is newer than CurrentVersion
+func (u *Updater) Latest(ctx context.Context) (*Release, bool, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/releases/latest", strings.TrimRight(u.cfg.APIBaseURL, "/"), u.cfg.GitHubOwner, u.cfg.GitHubRepo)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("query releases: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("query releases: unexpected status %d", resp.StatusCode)
+	}
+
+	var rel Release
+	if err := json.NewDecoder(resp.Body).Decode(&rel); err != nil {
+		return nil, false, fmt.Errorf("decode release: %w", err)
+	}
+	return &rel, newer(rel.TagName, u.cfg.CurrentVersion), nil
+}
+
+// Apply downloads the asset for the running GOOS/GOARCH, verifies its checksum (and
+// signature when configured) and atomically replaces the current executable.
+// Nothing on disk is touched unless every verification passes.
+func (u *Updater) Apply(ctx context.Context, rel *Release) error {
+	bin := findAsset(rel.Assets, func(name string) bool {
+		n := strings.ToLower(name)
+		return strings.Contains(n, runtime.GOOS) && strings.Contains(n, runtime.GOARCH) &&
+			!strings.HasSuffix(n, ".sig") && !strings.HasSuffix(n, ".txt")
+	})
+	if bin == nil {
+		return fmt.Errorf("release %s has no asset for %s/%s", rel.TagName, runtime.GOOS, runtime.GOARCH)
+	}
+	sums := findAsset(rel.Assets, func(name string) bool { return name == "checksums.txt" })
+	if sums == nil {
+		return fmt.Errorf("release %s has no checksums.txt", rel.TagName)
+	}
+
+	sumData, err := u.download(ctx, sums.URL)
+	if err != nil {
+		return err
+	}
+	want, err := lookupChecksum(sumData, bin.Name)
+	if err != nil {
+		return err
+	}
+
+	data, err := u.download(ctx, bin.URL)
+	if err != nil {
+		return err
+	}
+	got := sha256.Sum256(data)
+	if !bytes.Equal(got[:], want) {
+		return fmt.Errorf("%w for %s", ErrChecksumMismatch, bin.Name)
+	}
+
+	if u.cfg.PublicKeyFile != "" {
+		if err := u.verifySignature(ctx, rel, bin, data); err != nil {
+			return err
+		}
+	}
+
+	zap.L().Info("applying update", zap.String("version", rel.TagName), zap.String("asset", bin.Name))
+	if err := selfupdate.Apply(bytes.NewReader(data), selfupdate.Options{Checksum: want}); err != nil {
+		if rerr := selfupdate.RollbackError(err); rerr != nil {
+			return fmt.Errorf("update failed and rollback failed: %w", rerr)
+		}
+		return fmt.Errorf("apply update: %w", err)
+	}
+	return nil
+}
+
+func (u *Updater) verifySignature(ctx context.Context, rel *Release, bin *Asset, data []byte) error {
+	keyData, err := os.ReadFile(u.cfg.PublicKeyFile)
+	if err != nil {
+		return fmt.Errorf("read public key: %w", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(keyData)))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid ed25519 public key in %s", u.cfg.PublicKeyFile)
+	}
+
+	sigAsset := findAsset(rel.Assets, func(name string) bool { return name == bin.Name+".sig" })
+	if sigAsset == nil {
+		return fmt.Errorf("release %s has no signature for %s", rel.TagName, bin.Name)
+	}
+	sigData, err := u.download(ctx, sigAsset.URL)
+	if err != nil {
+		return err
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sigData)))
+	if err != nil {
+		sig = sigData // raw signature bytes
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), data, sig) {
+		return fmt.Errorf("signature verification failed for %s", bin.Name)
+	}
+	return nil
+}
+
+func (u *Updater) download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/octet-stream")
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download %s: unexpected status %d", url, resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// lookupChecksum finds name in a "sha256  filename" checksums file
+func lookupChecksum(data []byte, name string) ([]byte, error) {
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return hex.DecodeString(fields[0])
+		}
+	}
+	return nil, fmt.Errorf("no checksum for %s in checksums.txt", name)
+}
+
+func findAsset(assets []Asset, match func(string) bool) *Asset {
+	for i := range assets {
+		if match(assets[i].Name) {
+			return &assets[i]
+		}
+	}
+	return nil
+}
+
+// newer reports whether latest is a higher semantic version than current.
+// Unparseable current versions (e.g. dev builds) are always considered older.
+func newer(latest, current string) bool {
+	l, c := canonical(latest), canonical(current)
+	if !semver.IsValid(l) {
+		return false
+	}
+	if !semver.IsValid(c) {
+		return true
+	}
+	return semver.Compare(l, c) > 0
+}
+
+func canonical(v string) string {
+	if v != "" && !strings.HasPrefix(v, "v") {
+		v = "v" + v
+	}
+	return v
+}
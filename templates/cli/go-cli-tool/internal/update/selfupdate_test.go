@@ -0,0 +1,150 @@
+package update
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeGitHub serves a latest release with a binary for this platform, its
+// checksums.txt and signature; requested paths are recorded
+type fakeGitHub struct {
+	*httptest.Server
+	tag, checksum, sig string
+	binary             []byte
+
+	mu        sync.Mutex
+	requested []string
+}
+
+func newFakeGitHub(t *testing.T, tag string, binary []byte) *fakeGitHub {
+	sum := sha256.Sum256(binary)
+	f := &fakeGitHub{tag: tag, binary: binary, checksum: hex.EncodeToString(sum[:])}
+	asset := "tool_" + runtime.GOOS + "_" + runtime.GOARCH
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.requested = append(f.requested, r.URL.Path)
+		f.mu.Unlock()
+		switch r.URL.Path {
+		case "/repos/acme/tool/releases/latest":
+			json.NewEncoder(w).Encode(Release{TagName: f.tag, Assets: []Asset{
+				{Name: asset, URL: f.URL + "/dl/bin"},
+				{Name: asset + ".sig", URL: f.URL + "/dl/sig"},
+				{Name: "checksums.txt", URL: f.URL + "/dl/checksums"},
+			}})
+		case "/dl/bin":
+			w.Write(f.binary)
+		case "/dl/sig":
+			w.Write([]byte(f.sig))
+		case "/dl/checksums":
+			w.Write([]byte(f.checksum + "  " + asset + "\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeGitHub) downloaded(path string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, p := range f.requested {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLatest(t *testing.T) {
+	gh := newFakeGitHub(t, "v1.3.0", nil)
+	tests := []struct {
+		current string
+		want    bool
+	}{
+		{"v1.2.9", true},
+		{"1.2.9", true},
+		{"v1.3.0", false},
+		{"v2.0.0", false},
+		{"dev", true},
+	}
+	for _, tt := range tests {
+		u := New(SelfUpdateConfig{GitHubOwner: "acme", GitHubRepo: "tool", CurrentVersion: tt.current, APIBaseURL: gh.URL})
+		rel, isNewer, err := u.Latest(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if isNewer != tt.want || rel.TagName != "v1.3.0" {
+			t.Errorf("current %s: newer = %v, want %v", tt.current, isNewer, tt.want)
+		}
+	}
+}
+
+// The running test binary must stay untouched whenever verification fails
+func assertExecutableUnchanged(t *testing.T, before os.FileInfo) {
+	t.Helper()
+	exe, _ := os.Executable()
+	after, err := os.Stat(exe)
+	if err != nil || !after.ModTime().Equal(before.ModTime()) || after.Size() != before.Size() {
+		t.Error("executable was modified")
+	}
+}
+
+func TestApplyChecksumMismatch(t *testing.T) {
+	exe, _ := os.Executable()
+	before, err := os.Stat(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gh := newFakeGitHub(t, "v1.3.0", []byte("new binary"))
+	gh.checksum = strings.Repeat("00", sha256.Size)
+	u := New(SelfUpdateConfig{GitHubOwner: "acme", GitHubRepo: "tool", CurrentVersion: "v1.2.0", APIBaseURL: gh.URL})
+	rel, isNewer, err := u.Latest(context.Background())
+	if err != nil || !isNewer {
+		t.Fatalf("Latest() = %v, %v", isNewer, err)
+	}
+	if err := u.Apply(context.Background(), rel); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("Apply() = %v, want ErrChecksumMismatch", err)
+	}
+	if !gh.downloaded("/dl/bin") {
+		t.Error("newer release was not downloaded")
+	}
+	assertExecutableUnchanged(t, before)
+}
+
+func TestApplyBadSignature(t *testing.T) {
+	exe, _ := os.Executable()
+	before, err := os.Stat(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, _, _ := ed25519.GenerateKey(nil)
+	_, otherPriv, _ := ed25519.GenerateKey(nil)
+	keyFile := filepath.Join(t.TempDir(), "release.pub")
+	os.WriteFile(keyFile, []byte(base64.StdEncoding.EncodeToString(pub)), 0o600)
+
+	gh := newFakeGitHub(t, "v1.3.0", []byte("new binary"))
+	gh.sig = base64.StdEncoding.EncodeToString(ed25519.Sign(otherPriv, gh.binary))
+	u := New(SelfUpdateConfig{GitHubOwner: "acme", GitHubRepo: "tool", CurrentVersion: "v1.2.0", APIBaseURL: gh.URL, PublicKeyFile: keyFile})
+	rel, _, err := u.Latest(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := u.Apply(context.Background(), rel); err == nil || !strings.Contains(err.Error(), "signature verification failed") {
+		t.Fatalf("Apply() = %v, want a signature error", err)
+	}
+	assertExecutableUnchanged(t, before)
+}
@@ -0,0 +1,66 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Condition types reported in FooServiceStatus.Conditions
+const (
+	// ConditionAvailable is true once every desired replica is ready
+	ConditionAvailable = "Available"
+)
+
+// FooServiceSpec is the desired state of a FooService
+type FooServiceSpec struct {
+	// Replicas is the number of pods to run
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=1
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Image is the container image to run, e.g. ghcr.io/example/foo:1.2.3
+	// +kubebuilder:validation:MinLength=1
+	Image string `json:"image"`
+
+	// Port the container listens on; exposed by the Service
+	// +kubebuilder:default=8080
+	Port int32 `json:"port,omitempty"`
+}
+
+// FooServiceStatus is the observed state of a FooService
+type FooServiceStatus struct {
+	// ObservedGeneration is the spec generation the status reflects
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// ReadyReplicas is copied from the owned Deployment
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// FooService runs an image as a Deployment fronted by a Service
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Image",type=string,JSONPath=`.spec.image`
+// +kubebuilder:printcolumn:name="Ready",type=integer,JSONPath=`.status.readyReplicas`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type FooService struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FooServiceSpec   `json:"spec,omitempty"`
+	Status FooServiceStatus `json:"status,omitempty"`
+}
+
+// FooServiceList contains a list of FooService
+// +kubebuilder:object:root=true
+type FooServiceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FooService `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&FooService{}, &FooServiceList{})
+}
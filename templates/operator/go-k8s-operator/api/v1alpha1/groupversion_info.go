@@ -0,0 +1,20 @@
+// Package v1alpha1 contains the FooService API of the apps.example.com group
+// +kubebuilder:object:generate=true
+// +groupName=apps.example.com
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/scheme"
+)
+
+var (
+	// GroupVersion is the API group and version of the types in this package
+	GroupVersion = schema.GroupVersion{Group: "apps.example.com", Version: "v1alpha1"}
+
+	// SchemeBuilder registers the types with a runtime.Scheme
+	SchemeBuilder = &scheme.Builder{GroupVersion: GroupVersion}
+
+	// AddToScheme adds the types in this group-version to a scheme
+	AddToScheme = SchemeBuilder.AddToScheme
+)
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/zapr"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	crzap "sigs.k8s.io/controller-runtime/pkg/log/zap"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	appsv1alpha1 "github.com/example/go-k8s-operator/api/v1alpha1"
+	"github.com/example/go-k8s-operator/internal/controller"
+)
+
+// Build-time variables (set with -ldflags)
+var (
+	version   = "0.0.0"
+	buildTime = "unknown"
+	commit    = ""
+)
+
+// ManagerConfig holds runtime configuration for the controller manager
+type ManagerConfig struct {
+	MetricsListen           string        `mapstructure:"metrics_listen"`
+	HealthProbeListen       string        `mapstructure:"health_probe_listen"`
+	LeaderElection          bool          `mapstructure:"leader_election"`
+	LeaderElectionID        string        `mapstructure:"leader_election_id"`
+	LeaderElectionNamespace string        `mapstructure:"leader_election_namespace"` // empty: the pod's namespace
+	WatchNamespace          string        `mapstructure:"watch_namespace"`           // empty: all namespaces
+	ShutdownTimeout         time.Duration `mapstructure:"shutdown_timeout"`
+	LogLevel                string        `mapstructure:"log_level"`
+	Environment             string        `mapstructure:"environment"`
+}
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(appsv1alpha1.AddToScheme(scheme))
+}
+
+func main() {
+	// Parse flags
+	pflag.String("config", "", "Path to config file (YAML/JSON/TOML)")
+	pflag.String("env", "development", "Environment name (development|staging|production)")
+	pflag.Bool("leader-elect", false, "Enable leader election so only one replica reconciles")
+	pflag.Parse()
+	viper.BindPFlags(pflag.CommandLine)
+	viper.BindPFlag("leader_election", pflag.Lookup("leader-elect"))
+
+	// Init config
+	if err := initConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "config init failed: %v\n", err)
+		os.Exit(2)
+	}
+
+	// Load typed config
+	var cfg ManagerConfig
+	if err := viper.Unmarshal(&cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to parse config: %v\n", err)
+		os.Exit(3)
+	}
+
+	// Set sensible defaults if missing
+	setDefaults(&cfg)
+
+	// Init logger; controller-runtime logs through the same zap core
+	logger := initLogger(cfg)
+	defer logger.Sync()
+	zap.ReplaceGlobals(logger)
+	ctrl.SetLogger(zapr.NewLogger(logger))
+
+	zap.L().Info("starting prodstarter go-k8s-operator manager",
+		zap.String("version", version),
+		zap.String("commit", commit),
+		zap.String("buildTime", buildTime),
+		zap.String("env", cfg.Environment),
+		zap.Bool("leaderElection", cfg.LeaderElection),
+	)
+
+	opts := ctrl.Options{
+		Scheme:                  scheme,
+		Metrics:                 metricsserver.Options{BindAddress: cfg.MetricsListen},
+		HealthProbeBindAddress:  cfg.HealthProbeListen,
+		LeaderElection:          cfg.LeaderElection,
+		LeaderElectionID:        cfg.LeaderElectionID,
+		LeaderElectionNamespace: cfg.LeaderElectionNamespace,
+		// step down immediately on shutdown so a standby takes over without waiting for the lease to expire
+		LeaderElectionReleaseOnCancel: true,
+		GracefulShutdownTimeout:       &cfg.ShutdownTimeout,
+	}
+	if cfg.WatchNamespace != "" {
+		opts.Cache = cache.Options{DefaultNamespaces: map[string]cache.Config{cfg.WatchNamespace: {}}}
+	}
+
+	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), opts)
+	if err != nil {
+		zap.L().Fatal("unable to create manager", zap.Error(err))
+	}
+
+	if err := (&controller.FooServiceReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		zap.L().Fatal("unable to set up FooService controller", zap.Error(err))
+	}
+
+	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
+		zap.L().Fatal("unable to set up health check", zap.Error(err))
+	}
+	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+		zap.L().Fatal("unable to set up ready check", zap.Error(err))
+	}
+
+	// Start blocks until SIGINT/SIGTERM, then stops controllers within ShutdownTimeout
+	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+		zap.L().Fatal("manager exited with error", zap.Error(err))
+	}
+	zap.L().Info("shutdown complete")
+}
+
+// initConfig initializes viper configuration: file, env, defaults
+func initConfig() error {
+	cfgFile := viper.GetString("config")
+	viper.SetEnvPrefix("APP")
+	viper.AutomaticEnv()
+
+	if cfgFile != "" {
+		viper.SetConfigFile(cfgFile)
+		if err := viper.ReadInConfig(); err != nil {
+			return fmt.Errorf("read config file: %w", err)
+		}
+	}
+
+	// set defaults
+	viper.SetDefault("metrics_listen", ":9090")
+	viper.SetDefault("health_probe_listen", ":8081")
+	viper.SetDefault("leader_election_id", "go-k8s-operator.apps.example.com")
+	viper.SetDefault("shutdown_timeout", "15s")
+	viper.SetDefault("log_level", "info")
+	viper.SetDefault("environment", viper.GetString("env"))
+
+	return nil
+}
+
+func setDefaults(cfg *ManagerConfig) {
+	if cfg.MetricsListen == "" {
+		cfg.MetricsListen = viper.GetString("metrics_listen")
+	}
+	if cfg.HealthProbeListen == "" {
+		cfg.HealthProbeListen = viper.GetString("health_probe_listen")
+	}
+	if cfg.LeaderElectionID == "" {
+		cfg.LeaderElectionID = viper.GetString("leader_election_id")
+	}
+	if cfg.ShutdownTimeout == 0 {
+		cfg.ShutdownTimeout = parseDurationOrDefault(viper.GetString("shutdown_timeout"), 15*time.Second)
+	}
+	if cfg.Environment == "" {
+		cfg.Environment = viper.GetString("environment")
+	}
+	if cfg.LogLevel == "" {
+		cfg.LogLevel = viper.GetString("log_level")
+	}
+}
+
+func parseDurationOrDefault(s string, d time.Duration) time.Duration {
+	if s == "" {
+		return d
+	}
+	if dur, err := time.ParseDuration(s); err == nil {
+		return dur
+	}
+	// maybe provided as seconds integer
+	if secs, err := strconv.Atoi(s); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	return d
+}
+
+// initLogger builds the zap logger with controller-runtime's zap helpers:
+// console output in development, JSON in production
+func initLogger(cfg ManagerConfig) *zap.Logger {
+	var lvl zapcore.Level
+	switch cfg.LogLevel {
+	case "debug":
+		lvl = zapcore.DebugLevel
+	case "warn":
+		lvl = zapcore.WarnLevel
+	case "error":
+		lvl = zapcore.ErrorLevel
+	default:
+		lvl = zapcore.InfoLevel
+	}
+	return crzap.NewRaw(
+		crzap.UseDevMode(cfg.Environment != "production"),
+		crzap.Level(lvl),
+	)
+}
@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	appsv1alpha1 "github.com/example/go-k8s-operator/api/v1alpha1"
+)
+
+// FooServiceReconciler keeps a Deployment and a Service in line with each FooService
+type FooServiceReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=apps.example.com,resources=fooservices,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=apps.example.com,resources=fooservices/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile creates or updates the owned Deployment and Service, then reports
+// ready replicas and the Available condition. Owned objects are garbage
+// collected through their owner references when the FooService is deleted.
+func (r *FooServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	var foo appsv1alpha1.FooService
+	if err := r.Get(ctx, req.NamespacedName, &foo); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	replicas := int32(1)
+	if foo.Spec.Replicas != nil {
+		replicas = *foo.Spec.Replicas
+	}
+	port := foo.Spec.Port
+	if port == 0 {
+		port = 8080
+	}
+	labels := map[string]string{
+		"app.kubernetes.io/name":       foo.Name,
+		"app.kubernetes.io/managed-by": "go-k8s-operator",
+	}
+
+	deploy := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: foo.Name, Namespace: foo.Namespace}}
+	op, err := controllerutil.CreateOrUpdate(ctx, r.Client, deploy, func() error {
+		deploy.Labels = labels
+		deploy.Spec.Replicas = &replicas
+		// the selector is immutable; only set it on create
+		if deploy.Spec.Selector == nil {
+			deploy.Spec.Selector = &metav1.LabelSelector{MatchLabels: labels}
+		}
+		deploy.Spec.Template.Labels = labels
+		deploy.Spec.Template.Spec.Containers = []corev1.Container{{
+			Name:  "app",
+			Image: foo.Spec.Image,
+			Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: port}},
+		}}
+		return controllerutil.SetControllerReference(&foo, deploy, r.Scheme)
+	})
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("reconcile deployment: %w", err)
+	}
+	if op != controllerutil.OperationResultNone {
+		logger.Info("deployment reconciled", "deployment", deploy.Name, "operation", op)
+	}
+
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: foo.Name, Namespace: foo.Namespace}}
+	op, err = controllerutil.CreateOrUpdate(ctx, r.Client, svc, func() error {
+		svc.Labels = labels
+		svc.Spec.Selector = labels
+		svc.Spec.Ports = []corev1.ServicePort{{
+			Name:       "http",
+			Port:       port,
+			TargetPort: intstr.FromString("http"),
+		}}
+		return controllerutil.SetControllerReference(&foo, svc, r.Scheme)
+	})
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("reconcile service: %w", err)
+	}
+	if op != controllerutil.OperationResultNone {
+		logger.Info("service reconciled", "service", svc.Name, "operation", op)
+	}
+
+	foo.Status.ObservedGeneration = foo.Generation
+	foo.Status.ReadyReplicas = deploy.Status.ReadyReplicas
+	cond := metav1.Condition{
+		Type:               appsv1alpha1.ConditionAvailable,
+		Status:             metav1.ConditionFalse,
+		Reason:             "ReplicasNotReady",
+		Message:            fmt.Sprintf("%d/%d replicas ready", deploy.Status.ReadyReplicas, replicas),
+		ObservedGeneration: foo.Generation,
+	}
+	if deploy.Status.ReadyReplicas >= replicas {
+		cond.Status, cond.Reason = metav1.ConditionTrue, "ReplicasReady"
+	}
+	meta.SetStatusCondition(&foo.Status.Conditions, cond)
+	if err := r.Status().Update(ctx, &foo); err != nil {
+		if apierrors.IsConflict(err) {
+			// a newer version is already queued
+			return ctrl.Result{Requeue: true}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("update status: %w", err)
+	}
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager registers the reconciler; changes to owned Deployments and
+// Services (e.g. replicas becoming ready) re-trigger their FooService
+func (r *FooServiceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&appsv1alpha1.FooService{}).
+		Owns(&appsv1.Deployment{}).
+		Owns(&corev1.Service{}).
+		Complete(r)
+}
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+
+	appsv1alpha1 "github.com/example/go-k8s-operator/api/v1alpha1"
+)
+
+// startEnv runs a real kube-apiserver and etcd with the FooService CRD
+// installed. The binaries come from KUBEBUILDER_ASSETS, e.g.
+//
+//	export KUBEBUILDER_ASSETS=$(go run sigs.k8s.io/controller-runtime/tools/setup-envtest@latest use -p path)
+func startEnv(t *testing.T) (client.Client, *runtime.Scheme) {
+	t.Helper()
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("KUBEBUILDER_ASSETS not set; envtest binaries unavailable")
+	}
+	env := &envtest.Environment{
+		CRDDirectoryPaths:     []string{filepath.Join("..", "..", "config", "crd", "bases")},
+		ErrorIfCRDPathMissing: true,
+	}
+	cfg, err := env.Start()
+	if err != nil {
+		t.Fatalf("start envtest: %v", err)
+	}
+	t.Cleanup(func() { env.Stop() })
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := appsv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, scheme
+}
+
+func TestReconcile(t *testing.T) {
+	c, scheme := startEnv(t)
+	ctx := context.Background()
+	r := &FooServiceReconciler{Client: c, Scheme: scheme}
+
+	replicas := int32(3)
+	foo := &appsv1alpha1.FooService{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+		Spec:       appsv1alpha1.FooServiceSpec{Replicas: &replicas, Image: "nginx:1.27"},
+	}
+	if err := c.Create(ctx, foo); err != nil {
+		t.Fatal(err)
+	}
+	key := types.NamespacedName{Name: "foo", Namespace: "default"}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+
+	var deploy appsv1.Deployment
+	if err := c.Get(ctx, key, &deploy); err != nil {
+		t.Fatalf("get deployment: %v", err)
+	}
+	if *deploy.Spec.Replicas != 3 || deploy.Spec.Template.Spec.Containers[0].Image != "nginx:1.27" {
+		t.Errorf("deployment spec = %d replicas of %s", *deploy.Spec.Replicas, deploy.Spec.Template.Spec.Containers[0].Image)
+	}
+	if owner := metav1.GetControllerOf(&deploy); owner == nil || owner.Name != "foo" {
+		t.Errorf("deployment controller = %v, want the FooService", owner)
+	}
+
+	var svc corev1.Service
+	if err := c.Get(ctx, key, &svc); err != nil {
+		t.Fatalf("get service: %v", err)
+	}
+	if svc.Spec.Ports[0].Port != 8080 {
+		t.Errorf("service port = %d, want the CRD default 8080", svc.Spec.Ports[0].Port)
+	}
+
+	if err := c.Get(ctx, key, foo); err != nil {
+		t.Fatal(err)
+	}
+	if foo.Status.ObservedGeneration != foo.Generation {
+		t.Errorf("observedGeneration = %d, want %d", foo.Status.ObservedGeneration, foo.Generation)
+	}
+	// envtest runs no kube-controller-manager, so no replica ever becomes ready
+	if meta.IsStatusConditionTrue(foo.Status.Conditions, appsv1alpha1.ConditionAvailable) {
+		t.Error("Available = true with no ready replicas")
+	}
+
+	// scaling the FooService updates the Deployment in place
+	replicas = 5
+	foo.Spec.Replicas = &replicas
+	if err := c.Update(ctx, foo); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile after update: %v", err)
+	}
+	if err := c.Get(ctx, key, &deploy); err != nil {
+		t.Fatal(err)
+	}
+	if *deploy.Spec.Replicas != 5 {
+		t.Errorf("replicas after update = %d, want 5", *deploy.Spec.Replicas)
+	}
+}
+
+func TestReconcileDeleted(t *testing.T) {
+	c, scheme := startEnv(t)
+	r := &FooServiceReconciler{Client: c, Scheme: scheme}
+	key := types.NamespacedName{Name: "missing", Namespace: "default"}
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+		t.Errorf("Reconcile of a deleted FooService = %v, want nil", err)
+	}
+}
@@ -0,0 +1,54 @@
+// Command configdocs writes CONFIG.md, the reference of every ServerConfig
+// key, from the struct, its field comments and the defaults registered by
+// server.RegisterDefaults. Run it through go generate from the module root:
+//
+//	go generate ./internal/server
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+
+	"github.com/spf13/viper"
+
+	"github.com/example/go-chi-rest/internal/configdoc"
+	"github.com/example/go-chi-rest/internal/server"
+)
+
+const intro = `Generated by ` + "`go generate ./internal/server`" + ` from ` + "`ServerConfig`" + `; do not edit.
+
+Keys are set in ` + "`configs/config.base.yaml`" + `, ` + "`configs/config.<env>.yaml`" + `, the ` + "`--config`" + ` file or the
+environment, in increasing order of precedence. An environment variable is only read for keys that have a
+default or appear in a config file, and nested keys keep their dots (` + "`APP_DB.PRIMARY_DSN`" + `), which most
+shells cannot export directly; use a config file for those.`
+
+func main() {
+	root := flag.String("root", ".", "module root")
+	out := flag.String("o", "CONFIG.md", "output file")
+	flag.Parse()
+
+	// defaults only: no config files and no environment, so the output is reproducible
+	server.RegisterDefaults()
+	md, err := configdoc.Generate(reflect.TypeOf(server.ServerConfig{}), configdoc.Options{
+		Title:     "Configuration reference",
+		Intro:     intro,
+		Root:      *root,
+		EnvPrefix: "APP",
+		Default: func(key string) any {
+			if !viper.IsSet(key) {
+				return nil
+			}
+			return viper.Get(key)
+		},
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*out, md, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
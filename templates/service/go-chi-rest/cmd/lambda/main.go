@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	chiadapter "github.com/awslabs/aws-lambda-go-api-proxy/chi"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/example/go-chi-rest/internal/server"
+)
+
+// Build-time variables (set with -ldflags)
+var (
+	version   = "0.0.0"
+	buildTime = "unknown"
+	commit    = ""
+)
+
+var (
+	initOnce sync.Once
+	adapter  *chiadapter.ChiLambda
+	router   http.Handler
+)
+
+// setup loads config, logger and router once per execution environment, so
+// warm invocations reuse them. Configuration comes from APP_* environment
+// variables and the optional files under APP_CONFIG_DIR (default "configs").
+func setup() {
+	initOnce.Do(func() {
+		viper.SetDefault("config-dir", "configs")
+		viper.SetDefault("env", "production")
+		if err := server.InitConfig(); err != nil {
+			fmt.Fprintf(os.Stderr, "config init failed: %v\n", err)
+			os.Exit(2)
+		}
+		cfg, err := server.LoadConfig()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(3)
+		}
+
+		// the logger lives as long as the execution environment
+		logger, _, err := server.NewLogger(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "logger init failed: %v\n", err)
+			os.Exit(1)
+		}
+		zap.ReplaceGlobals(logger)
+
+		zap.L().Info("starting prodstarter go-chi-rest lambda",
+			zap.String("version", version),
+			zap.String("commit", commit),
+			zap.String("buildTime", buildTime),
+			zap.String("env", cfg.Environment),
+		)
+
+		mux := server.NewRouter(cfg)
+		router = mux
+		adapter = chiadapter.New(mux)
+	})
+}
+
+// handler translates an API Gateway (REST API) proxy event into a request
+// on the chi router
+func handler(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	setup()
+	defer zap.L().Sync()
+	return adapter.ProxyWithContext(ctx, req)
+}
+
+func main() {
+	// Outside Lambda (no runtime API endpoint) serve plain HTTP on bind_addr,
+	// so the same binary can be run and debugged locally
+	if os.Getenv("AWS_LAMBDA_RUNTIME_API") == "" {
+		setup()
+		addr := viper.GetString("bind_addr")
+		zap.L().Info("not running in lambda; serving http", zap.String("addr", addr))
+		if err := http.ListenAndServe(addr, router); err != nil {
+			zap.L().Fatal("http server failed", zap.Error(err))
+		}
+		return
+	}
+	lambda.Start(handler)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestHandler(t *testing.T) {
+	// no config files next to the test binary; defaults and APP_* apply
+	t.Setenv("APP_CONFIG_DIR", t.TempDir())
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{"liveness", http.MethodGet, "/healthz", http.StatusOK},
+		{"readiness", http.MethodGet, "/readyz", http.StatusOK},
+		{"unknown route", http.MethodGet, "/does-not-exist", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := handler(context.Background(), events.APIGatewayProxyRequest{
+				HTTPMethod: tt.method,
+				Path:       tt.path,
+				Headers:    map[string]string{"X-Request-ID": "lambda-test-1"},
+				RequestContext: events.APIGatewayProxyRequestContext{
+					RequestID: "api-gw-1",
+					Stage:     "prod",
+				},
+			})
+			if err != nil {
+				t.Fatalf("handler: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d (body %s)", resp.StatusCode, tt.wantStatus, resp.Body)
+			}
+			if got := http.Header(resp.MultiValueHeaders).Get("X-Request-ID"); got != "lambda-test-1" {
+				t.Errorf("X-Request-ID = %q, want the caller's ID", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/example/go-chi-rest/internal/errcodes"
+	"github.com/example/go-chi-rest/internal/logsink"
+	"github.com/example/go-chi-rest/internal/server"
+)
+
+// redactedValue replaces secrets in configuration dumps
+const redactedValue = "[REDACTED]"
+
+// requireAdminToken answers 401 unless the request carries
+// "Authorization: Bearer <token>"; an empty token rejects everything
+func requireAdminToken(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" || !ok || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+			errcodes.Write(w, errcodes.FromRequest(r).New("UNAUTHORIZED"))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// statusRecorder remembers the status a handler answered with
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+// auditAdmin records every request to next that may change state (anything
+// but GET and HEAD) in the audit log, rejected ones included
+func auditAdmin(sink *logsink.RotatingAuditSink, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		err := sink.Record(logsink.AuditEvent{
+			Action:     r.Method,
+			Target:     r.URL.Path,
+			Status:     rec.status,
+			RemoteAddr: r.RemoteAddr,
+		})
+		if err != nil {
+			zap.L().Error("audit log write failed", zap.String("path", r.URL.Path), zap.Error(err))
+		}
+	})
+}
+
+// adminConfigHandler serves GET /admin/config to holders of the admin token:
+// the effective settings with secrets redacted. It is mounted on the metrics
+// listener, not the public API.
+func adminConfigHandler(token string, patterns []string) http.HandlerFunc {
+	return requireAdminToken(token, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			writeJSON(w, http.StatusMethodNotAllowed, nil)
+			return
+		}
+		settings := redactConfig(server.AllSettings(), patterns)
+		settings["redact_keys"] = patterns // matches "key" itself but is not a secret
+		writeJSON(w, http.StatusOK, settings)
+	})
+}
+
+// redactConfig returns a copy of m with the values of keys containing any of
+// patterns (case-insensitive) replaced by [REDACTED], descending into nested
+// maps and into lists such as inbound_webhooks entries or tenants
+func redactConfig(m map[string]any, patterns []string) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		if matchesAny(k, patterns) {
+			out[k] = redactedValue
+			continue
+		}
+		out[k] = redactValue(v, patterns)
+	}
+	return out
+}
+
+func redactValue(v any, patterns []string) any {
+	switch {
+	case isMap(v):
+		return redactConfig(toStringMap(v), patterns)
+	case isList(v):
+		list := toList(v)
+		out := make([]any, len(list))
+		for i, item := range list {
+			out[i] = redactValue(item, patterns)
+		}
+		return out
+	}
+	return v
+}
+
+func matchesAny(key string, patterns []string) bool {
+	key = strings.ToLower(key)
+	for _, p := range patterns {
+		if p != "" && strings.Contains(key, strings.ToLower(p)) {
+			return true
+		}
+	}
+	return false
+}
+
+func isMap(v any) bool {
+	switch v.(type) {
+	case map[string]any, map[any]any:
+		return true
+	}
+	return false
+}
+
+func isList(v any) bool {
+	switch v.(type) {
+	case []any, []map[string]any:
+		return true
+	}
+	return false
+}
+
+// toList normalizes []map[string]any (viper's decoding of lists of tables) to []any
+func toList(v any) []any {
+	if l, ok := v.([]any); ok {
+		return l
+	}
+	maps := v.([]map[string]any)
+	out := make([]any, len(maps))
+	for i, m := range maps {
+		out[i] = m
+	}
+	return out
+}
+
+// toStringMap normalizes map[any]any (from some YAML decoders) to map[string]any
+func toStringMap(v any) map[string]any {
+	if m, ok := v.(map[string]any); ok {
+		return m
+	}
+	out := make(map[string]any)
+	for k, val := range v.(map[any]any) {
+		out[fmt.Sprint(k)] = val
+	}
+	return out
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/example/go-chi-rest/internal/debugdump"
+	"github.com/example/go-chi-rest/internal/logsink"
+	"github.com/example/go-chi-rest/internal/scheduler"
+)
+
+func TestRedactConfig(t *testing.T) {
+	patterns := []string{"password", "secret", "key"}
+	in := map[string]any{
+		"database":   map[string]any{"host": "db", "password": "hunter2"},
+		"jwt_secret": "s3cret",
+		"API_KEY":    "abc",
+		"inbound_webhooks": []map[string]any{
+			{"name": "github", "secret": "gh"},
+		},
+		"tenants": []any{map[any]any{"host": "a.example.com", "api_key": "t1"}},
+	}
+	out := redactConfig(in, patterns)
+
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"nested password", out["database"].(map[string]any)["password"], redactedValue},
+		{"nested host kept", out["database"].(map[string]any)["host"], "db"},
+		{"jwt_secret", out["jwt_secret"], redactedValue},
+		{"upper-case key", out["API_KEY"], redactedValue},
+		{"list of tables", out["inbound_webhooks"].([]any)[0].(map[string]any)["secret"], redactedValue},
+		{"list entry kept", out["inbound_webhooks"].([]any)[0].(map[string]any)["name"], "github"},
+		{"list of yaml maps", out["tenants"].([]any)[0].(map[string]any)["api_key"], redactedValue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+	if in["database"].(map[string]any)["password"] != "hunter2" {
+		t.Error("input map was modified")
+	}
+}
+
+func TestRequireAdminToken(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	tests := []struct {
+		name, token, header string
+		want                int
+	}{
+		{"no header", "t0ken", "", http.StatusUnauthorized},
+		{"wrong token", "t0ken", "Bearer nope", http.StatusUnauthorized},
+		{"not bearer", "t0ken", "t0ken", http.StatusUnauthorized},
+		{"empty token disables", "", "Bearer ", http.StatusUnauthorized},
+		{"valid", "t0ken", "Bearer t0ken", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/config", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			requireAdminToken(tt.token, ok)(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuditAdmin(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := logsink.NewRotatingAuditSink(logsink.AuditConfig{Dir: dir, MaxSizeMB: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := auditAdmin(sink, requireAdminToken("t0ken", func(w http.ResponseWriter, r *http.Request) {}))
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/admin/config", nil),
+		httptest.NewRequest(http.MethodPost, "/admin/ip-filter", nil), // no token
+	} {
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	sink.Close()
+
+	b, err := os.ReadFile(filepath.Join(dir, "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	var e logsink.AuditEvent
+	if len(lines) != 1 || json.Unmarshal([]byte(lines[0]), &e) != nil {
+		t.Fatalf("audit log = %q, want only the POST", b)
+	}
+	if e.Action != http.MethodPost || e.Target != "/admin/ip-filter" || e.Status != http.StatusUnauthorized {
+		t.Errorf("event = %+v", e)
+	}
+}
+
+func TestScheduledJobsAuditPurge(t *testing.T) {
+	has := func(jobs []scheduler.Job) bool {
+		for _, j := range jobs {
+			if j.Name == "audit-log-purge" {
+				return true
+			}
+		}
+		return false
+	}
+	if has(scheduledJobs(logsink.AuditConfig{})) {
+		t.Error("purge job scheduled without an audit log")
+	}
+	if !has(scheduledJobs(logsink.AuditConfig{Dir: t.TempDir(), Retention: 24 * time.Hour})) {
+		t.Error("purge job missing")
+	}
+}
+
+func TestDebugDumpsUseAdminToken(t *testing.T) {
+	dumps, err := debugdump.New(debugdump.DumpConfig{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// mounted as in main
+	h := requireAdminToken("t0ken", dumps.Handler())
+	tests := []struct {
+		name, header string
+		want         int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"wrong token", "Bearer nope", http.StatusUnauthorized},
+		{"admin token", "Bearer t0ken", http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/admin/debug/goroutine-dump", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			h(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
@@ -8,16 +8,37 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
-	"strconv"
+	"sync"
 	"syscall"
 	"time"
 
-	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
+	"golang.org/x/net/http2"
+
+	"github.com/example/go-chi-rest/internal/chaos"
+	"github.com/example/go-chi-rest/internal/db"
+	"github.com/example/go-chi-rest/internal/debugcapture"
+	"github.com/example/go-chi-rest/internal/debugdump"
+	"github.com/example/go-chi-rest/internal/discovery"
+	"github.com/example/go-chi-rest/internal/election"
+	"github.com/example/go-chi-rest/internal/grpcclient"
+	"github.com/example/go-chi-rest/internal/h3"
+	"github.com/example/go-chi-rest/internal/har"
+	"github.com/example/go-chi-rest/internal/health"
+	"github.com/example/go-chi-rest/internal/logsink"
+	"github.com/example/go-chi-rest/internal/outbox"
+	"github.com/example/go-chi-rest/internal/scheduler"
+	"github.com/example/go-chi-rest/internal/search"
+	"github.com/example/go-chi-rest/internal/security"
+	"github.com/example/go-chi-rest/internal/server"
+	"github.com/example/go-chi-rest/internal/shutdown"
+	"github.com/example/go-chi-rest/internal/telemetry"
+	"github.com/example/go-chi-rest/internal/watchdog"
+	"github.com/example/go-chi-rest/internal/worker"
 )
 
 // Build-time variables (set with -ldflags)
@@ -27,50 +48,45 @@ var (
 	commit    = ""
 )
 
-// ServerConfig holds runtime configuration for the server
-type ServerConfig struct {
-	BindAddr           string        `mapstructure:"bind_addr"`
-	ReadTimeout        time.Duration `mapstructure:"read_timeout"`
-	WriteTimeout       time.Duration `mapstructure:"write_timeout"`
-	IdleTimeout        time.Duration `mapstructure:"idle_timeout"`
-	ShutdownTimeout    time.Duration `mapstructure:"shutdown_timeout"`
-	EnableMetrics      bool          `mapstructure:"enable_metrics"`
-	MetricsListen      string        `mapstructure:"metrics_listen"`
-	LogLevel           string        `mapstructure:"log_level"`
-	Environment        string        `mapstructure:"environment"`
-}
-
 func main() {
 	// Parse flags
 	pflag.String("config", "", "Path to config file (YAML/JSON/TOML)")
 	pflag.String("env", "development", "Environment name (development|staging|production)")
+	pflag.String("config-dir", "configs", "Directory holding config.base.yaml and config.<env>.yaml")
+	pflag.String("metrics-buckets", "", "Comma-separated latency histogram buckets in seconds, e.g. 0.005,0.01,0.05")
+	pflag.Bool("require-fips", false, "Refuse to start unless built with the fips tag")
 	pflag.Parse()
 	viper.BindPFlags(pflag.CommandLine)
 
 	// Init config
-	if err := initConfig(); err != nil {
+	if err := server.InitConfig(); err != nil {
 		fmt.Fprintf(os.Stderr, "config init failed: %v\n", err)
 		os.Exit(2)
 	}
 
-	// Load typed config
-	var cfg ServerConfig
-	if err := viper.Unmarshal(&cfg); err != nil {
-		fmt.Fprintf(os.Stderr, "failed to parse config: %v\n", err)
+	// Load typed config: decode, apply defaults and validate
+	cfg, err := server.LoadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(3)
 	}
 
-	// Set sensible defaults if missing
-	setDefaults(&cfg)
-
 	// Init logger
-	logger, err := initLogger(cfg)
+	logger, closeLogger, err := server.NewLogger(cfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "logger init failed: %v\n", err)
 		os.Exit(1)
 	}
-	defer logger.Sync()
 	zap.ReplaceGlobals(logger)
+	// reloads may swap the logger; flush and close whichever is current
+	var loggerMu sync.Mutex
+	loggerCfg := cfg
+	defer func() {
+		loggerMu.Lock()
+		defer loggerMu.Unlock()
+		zap.L().Sync()
+		closeLogger()
+	}()
 
 	zap.L().Info("starting prodstarter go-chi-rest server",
 		zap.String("version", version),
@@ -79,43 +95,260 @@ func main() {
 		zap.String("env", cfg.Environment),
 		zap.String("bind", cfg.BindAddr),
 	)
+	if security.IsFIPSMode() {
+		zap.L().Info("FIPS mode active: approved TLS cipher suites, HMAC-SHA256 webhooks and RS256/ES256 tokens only")
+	} else if viper.GetBool("require-fips") {
+		zap.L().Fatal("--require-fips set but the binary was built without the fips tag")
+	}
 
-	// Setup main router
-	r := chi.NewRouter()
-	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
-	r.Use(middleware.Recoverer)
-	// Custom logging middleware using zap
-	r.Use(zapLoggerMiddleware())
-	// Optional: add CORS, rate-limiting, auth middleware here
-
-	// Routes
-	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {
-		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
-	})
-	r.Get("/readyz", func(w http.ResponseWriter, r *http.Request) {
-		// In a real app verify upstream dependencies here
-		writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+	// Tracing (optional)
+	if cfg.Tracing.Enabled {
+		shutdownTracing, err := telemetry.InitJaeger(cfg.Tracing)
+		if err != nil {
+			zap.L().Fatal("tracing init failed", zap.Error(err))
+		}
+		defer shutdownTracing()
+	}
+
+	// OTLP metrics push (optional); Prometheus scraping keeps working alongside it
+	if cfg.OTelMetrics.Enabled {
+		shutdownMetrics, err := telemetry.InitOTelMetrics(cfg.OTelMetrics)
+		if err != nil {
+			zap.L().Fatal("otel metrics init failed", zap.Error(err))
+		}
+		defer shutdownMetrics()
+	}
+
+	// Background job pool; started before the HTTP server so handlers can submit work
+	pool := worker.NewPool(cfg.Worker, func(ctx context.Context, job worker.Job) error {
+		// replace with domain job handling
+		zap.L().Info("processing job", zap.String("job_id", job.ID()), zap.Int("bytes", len(job.Payload())))
+		return nil
 	})
+	pool.Start(context.Background())
+
+	// Probes: /readyz runs the dependency checks registered here
+	checker := health.NewHealthChecker(cfg.Health)
 
-	r.Route("/api/v1", func(r chi.Router) {
-		r.Get("/ping", func(w http.ResponseWriter, r *http.Request) {
-			writeJSON(w, http.StatusOK, map[string]string{"message": "pong"})
+	// Audit log (optional): admin requests that change state, in a rotated
+	// file under audit_log.dir that a nightly job purges
+	var auditSink *logsink.RotatingAuditSink
+	if cfg.AuditLog.Dir != "" {
+		if auditSink, err = logsink.NewRotatingAuditSink(cfg.AuditLog); err != nil {
+			zap.L().Fatal("audit log init failed", zap.Error(err))
+		}
+	}
+
+	// Cron jobs; started once the server is up, and only on the leader when
+	// leader election is enabled
+	sched, err := scheduler.NewScheduler(cfg.Scheduler, scheduledJobs(cfg.AuditLog))
+	if err != nil {
+		zap.L().Fatal("scheduler init failed", zap.Error(err))
+	}
+	sched.RequireLeader = cfg.Election.Enabled
+
+	// Optional leader election: singleton background jobs only run on the leader
+	electionCtx, stopElection := context.WithCancel(context.Background())
+	electionDone := make(chan struct{})
+	if cfg.Election.Enabled {
+		rdb := redis.NewClient(&redis.Options{Addr: cfg.Election.RedisAddr})
+		// followers keep serving requests, so a Redis outage does not make the pod unready
+		checker.RegisterWarning("redis", func(ctx context.Context) error {
+			return rdb.Ping(ctx).Err()
 		})
-		// register other handlers here
+		el := &election.Election{
+			CampaignKey: cfg.Election.Key,
+			CampaignTTL: cfg.Election.TTL,
+			Backend:     election.NewRedisBackend(rdb),
+			NodeID:      cfg.Election.NodeID,
+		}
+		go func() {
+			defer close(electionDone)
+			el.Run(electionCtx, sched.Lead, func(ctx context.Context) {
+				zap.L().Info("follower: background jobs paused")
+			})
+		}()
+	} else {
+		close(electionDone)
+	}
+
+	// PostgreSQL primary and read replicas (optional); opened here so
+	// shutdown can close the pools after requests and workers are done
+	if cfg.DB.PrimaryDSN != "" {
+		dbPool, err := db.Open(context.Background(), cfg.DB)
+		if err != nil {
+			zap.L().Fatal("database init failed", zap.Error(err))
+		}
+		checker.RegisterCritical("postgres", dbPool.Ping)
+		cfg.DB.Pool = dbPool
+	}
+	tunerCtx, stopTuner := context.WithCancel(context.Background())
+	if cfg.DB.Pool != nil && cfg.DB.AutoTune.Enabled {
+		go db.NewPoolAutoTuner(cfg.DB.Pool, cfg.DB.AutoTune).Run(tunerCtx)
+	}
+
+	// Downstream gRPC connection (optional); created here so shutdown can
+	// close it after requests are done
+	if cfg.GRPCClient.Target != "" {
+		conn, err := grpcclient.Dial(cfg.GRPCClient)
+		if err != nil {
+			zap.L().Fatal("grpc client init failed", zap.Error(err))
+		}
+		cfg.GRPCClient.Conn = conn
+	}
+
+	// Full-text search index (optional); opened here so reloads reuse it and
+	// shutdown can flush and unlock it after requests are done
+	if cfg.Search.IndexPath != "" {
+		idx, err := search.Open(cfg.Search.IndexPath)
+		if err != nil {
+			zap.L().Fatal("search index init failed", zap.Error(err))
+		}
+		cfg.Search.Index = idx
+	}
+
+	// Transactional outbox poller (optional); handlers add events with
+	// outbox.Write inside their own transaction
+	outboxCtx, stopOutbox := context.WithCancel(context.Background())
+	outboxDone := make(chan struct{})
+	if cfg.Outbox.Enabled && cfg.DB.Pool != nil {
+		ob := outbox.NewOutbox(cfg.DB.Pool, cfg.Outbox)
+		if err := ob.EnsureSchema(context.Background()); err != nil {
+			zap.L().Fatal("outbox init failed", zap.Error(err))
+		}
+		go func() {
+			defer close(outboxDone)
+			ob.Poll(outboxCtx, cfg.Outbox.PollInterval)
+		}()
+	} else {
+		close(outboxDone)
+	}
+
+	// HAR recording (outside production): GET /debug/har on the metrics
+	// listener, plus an append-only .har file when har.file is set
+	harCreator := har.Creator{Name: "go-chi-rest", Version: version}
+	var harFile *har.FileHARStore
+	if cfg.HAR.Enabled && cfg.Environment != "production" && cfg.HAR.File != "" {
+		var err error
+		if harFile, err = har.NewFileHARStore(cfg.HAR.File, harCreator); err != nil {
+			zap.L().Fatal("har file init failed", zap.Error(err))
+		}
+		cfg.HAR.Store = har.Stores{har.DefaultStore, harFile}
+	}
+
+	// IP filter (optional); built here so /admin/ip-filter can update its networks
+	if cfg.IPFilter.Mode != "" {
+		if cfg.IPFilter.Filter, err = security.NewIPFilter(cfg.IPFilter); err != nil {
+			zap.L().Fatal("invalid ip filter config", zap.Error(err))
+		}
+	}
+
+	// Setup main router; shared with the Lambda entry point
+	cfg.Checker = checker
+	cfg.Envelope.Version = version
+	if cfg.Backpressure.Enabled {
+		cfg.Backpressure.Pool = pool
+	}
+	if cfg.DataExport.Enabled {
+		cfg.DataExport.Pool = pool
+	}
+	live := server.NewAtomicConfig(cfg)
+	r := server.NewReloadableRouter(live)
+
+	// Config reloads swap the live config: the logger is rebuilt when its
+	// settings changed and CORS,
+	// security headers and rate limit rules apply on the next request.
+	// Listeners and the middleware chain keep their startup settings.
+	reload := func(source string, updated server.ServerConfig) {
+		// keep the dependencies wired above
+		updated.Checker, updated.Backpressure.Pool = cfg.Checker, cfg.Backpressure.Pool
+		updated.DB.Pool, updated.Search.Index = cfg.DB.Pool, cfg.Search.Index
+		updated.HAR.Store, updated.Envelope.Version = cfg.HAR.Store, cfg.Envelope.Version
+		updated.DataExport.Sources, updated.DataExport.Pool = cfg.DataExport.Sources, cfg.DataExport.Pool
+		updated.Erasure.Erasers, updated.Erasure.Publisher, updated.Erasure.Tombstones = cfg.Erasure.Erasers, cfg.Erasure.Publisher, cfg.Erasure.Tombstones
+		updated.Outbox.Publisher, updated.GRPCClient.Conn = cfg.Outbox.Publisher, cfg.GRPCClient.Conn
+		updated.IPFilter.Filter = cfg.IPFilter.Filter
+		// the file watcher and AppConfig reload from separate goroutines
+		loggerMu.Lock()
+		// an unchanged logger keeps its open files and syslog connections
+		if !server.SameLogger(loggerCfg, updated) {
+			if l, closeNew, err := server.NewLogger(updated); err != nil {
+				zap.L().Error("logger rebuild failed", zap.String("source", source), zap.Error(err))
+			} else {
+				prev, closePrev := zap.L(), closeLogger
+				zap.ReplaceGlobals(l)
+				loggerCfg, closeLogger = updated, closeNew
+				prev.Sync()
+				closePrev()
+			}
+		}
+		loggerMu.Unlock()
+		live.Store(updated)
+		zap.L().Info("configuration reloaded", zap.String("source", source))
+	}
+	configWatchCtx, stopConfigWatch := context.WithCancel(context.Background())
+	defer stopConfigWatch()
+	err = server.WatchConfigFile(configWatchCtx, func(updated server.ServerConfig, err error) {
+		if err != nil {
+			zap.L().Error("config reload rejected, keeping previous config", zap.String("file", viper.ConfigFileUsed()), zap.Error(err))
+			return
+		}
+		reload("file", updated)
 	})
+	if err != nil {
+		zap.L().Error("config file watch failed; edits need a restart", zap.Error(err))
+	}
+	// Hot configuration from AWS AppConfig (optional)
+	if cfg.AppConfig.Enabled {
+		watcher, err := server.NewAppConfigWatcher(cfg.AppConfig, func(updated server.ServerConfig) {
+			reload("appconfig", updated)
+		})
+		if err != nil {
+			zap.L().Fatal("appconfig init failed", zap.Error(err))
+		}
+		defer watcher.Close()
+	}
+
+	// Go runtime metrics, read at most once a second however often /metrics is scraped
+	runtimeMetrics := telemetry.NewRuntimeCollector(telemetry.BuildInfo{Version: version, Revision: commit}, time.Second)
+	if err := telemetry.RegisterRuntimeCollector(runtimeMetrics); err != nil {
+		zap.L().Fatal("runtime metrics registration failed", zap.Error(err))
+	}
 
 	// Metrics server (optional)
 	var metricsSrv *http.Server
 	if cfg.EnableMetrics {
 		metricsMux := http.NewServeMux()
 		metricsMux.Handle("/metrics", promhttp.Handler())
-		metricsMux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
-			writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
-		})
+		metricsMux.HandleFunc("/healthz", checker.Liveness)
+		if cfg.AdminToken != "" {
+			metricsMux.HandleFunc("/admin/config", adminConfigHandler(cfg.AdminToken, cfg.RedactKeys))
+			metricsMux.HandleFunc("/admin/faults", requireAdminToken(cfg.AdminToken, chaos.AdminHandler()))
+			dumps, err := debugdump.New(cfg.DebugDumps)
+			if err != nil {
+				zap.L().Fatal("debug dumps init failed", zap.Error(err))
+			}
+			metricsMux.HandleFunc("/admin/debug/", requireAdminToken(cfg.AdminToken, dumps.Handler()))
+			cleanupCtx, stopCleanup := context.WithCancel(context.Background())
+			defer stopCleanup()
+			go dumps.Cleanup(cleanupCtx)
+			if cfg.IPFilter.Filter != nil {
+				metricsMux.HandleFunc("/admin/ip-filter", requireAdminToken(cfg.AdminToken, cfg.IPFilter.Filter.AdminHandler()))
+			}
+		}
+		if cfg.Environment != "production" && cfg.AdminToken != "" {
+			metricsMux.HandleFunc("/debug/responses", requireAdminToken(cfg.AdminToken, debugcapture.ResponsesHandler))
+			if cfg.HAR.Enabled {
+				metricsMux.HandleFunc("/debug/har", requireAdminToken(cfg.AdminToken, har.Handler(harCreator)))
+			}
+		}
+		var metricsHandler http.Handler = metricsMux
+		if auditSink != nil {
+			metricsHandler = auditAdmin(auditSink, metricsMux)
+		}
 		metricsSrv = &http.Server{
 			Addr:         cfg.MetricsListen,
-			Handler:      metricsMux,
+			Handler:      metricsHandler,
 			ReadTimeout:  5 * time.Second,
 			WriteTimeout: 10 * time.Second,
 			IdleTimeout:  30 * time.Second,
@@ -136,186 +369,214 @@ func main() {
 		WriteTimeout: cfg.WriteTimeout,
 		IdleTimeout:  cfg.IdleTimeout,
 	}
+	useTLS := cfg.TLS.CertFile != "" && cfg.TLS.KeyFile != ""
+	if useTLS {
+		// certificates are served through GetCertificate so they can be swapped at runtime
+		certs, err := security.NewCertReloader(cfg.TLS)
+		if err != nil {
+			zap.L().Fatal("tls init failed", zap.Error(err))
+		}
+		srv.TLSConfig = security.NewTLSConfig(certs.GetCertificate)
+		if cfg.TLS.AutoReload {
+			watchCtx, stopWatch := context.WithCancel(context.Background())
+			defer stopWatch()
+			go func() {
+				if err := certs.Watch(watchCtx); err != nil {
+					zap.L().Error("tls certificate watcher stopped", zap.Error(err))
+				}
+			}()
+		}
+	}
+	if useTLS && cfg.EnableHTTP2 {
+		if err := http2.ConfigureServer(srv, &http2.Server{IdleTimeout: cfg.IdleTimeout}); err != nil {
+			zap.L().Fatal("http2 configuration failed", zap.Error(err))
+		}
+	}
+
+	// HTTP/3 (optional, experimental): the same router over QUIC, advertised
+	// to TCP clients with Alt-Svc
+	var h3srv *h3.Server
+	switch {
+	case cfg.HTTP3.Enabled && !h3.Built():
+		zap.L().Warn("http3.enabled ignored: build with -tags http3")
+	case cfg.HTTP3.Enabled:
+		tlsConfig := srv.TLSConfig
+		if tlsConfig == nil || cfg.HTTP3.TLSCertFile != cfg.TLS.CertFile || cfg.HTTP3.TLSKeyFile != cfg.TLS.KeyFile {
+			certs, err := security.NewCertReloader(security.TLSConfig{CertFile: cfg.HTTP3.TLSCertFile, KeyFile: cfg.HTTP3.TLSKeyFile})
+			if err != nil {
+				zap.L().Fatal("http3 tls init failed", zap.Error(err))
+			}
+			tlsConfig = security.NewTLSConfig(certs.GetCertificate)
+		}
+		h3srv, err = h3.Listen(cfg.HTTP3, r, tlsConfig)
+		if err != nil {
+			zap.L().Fatal("http3 listen failed", zap.Error(err))
+		}
+		srv.Handler = h3.AltSvc(cfg.HTTP3.Addr)(srv.Handler)
+		go func() {
+			zap.L().Info("http3 server listening", zap.String("addr", cfg.HTTP3.Addr))
+			if err := h3srv.Serve(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				zap.L().Error("http3 server failed", zap.Error(err))
+			}
+		}()
+	}
 
 	// Run server in background and listen for shutdown signals
 	serverErrors := make(chan error, 1)
 	go func() {
-		zap.L().Info("http server listening", zap.String("addr", cfg.BindAddr))
+		zap.L().Info("http server listening", zap.String("addr", cfg.BindAddr), zap.Bool("tls", useTLS), zap.Bool("http2", useTLS && cfg.EnableHTTP2))
+		if useTLS {
+			serverErrors <- srv.ListenAndServeTLS("", "")
+			return
+		}
 		serverErrors <- srv.ListenAndServe()
 	}()
+	// initialization is done; run migrations or warm caches before this point
+	checker.MarkStarted()
 
-	// Signal handling
-	shutdown := make(chan os.Signal, 1)
-	signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM)
+	sched.Start(context.Background())
 
-	select {
-	case err := <-serverErrors:
-		if !errors.Is(err, http.ErrServerClosed) {
-			zap.L().Fatal("server crashed", zap.Error(err))
-		}
-	case sig := <-shutdown:
-		zap.L().Info("shutdown signal received", zap.String("signal", sig.String()))
+	// Deadlock detection (optional); every request kicks the watchdog
+	var wd *watchdog.Watchdog
+	if cfg.Watchdog.Enabled {
+		wd = watchdog.Start(cfg.Watchdog.Interval)
 	}
 
-	// Create context for shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
-	defer cancel()
-
-	// Stop accepting new requests
-	if err := srv.Shutdown(ctx); err != nil {
-		zap.L().Error("graceful shutdown failed", zap.Error(err))
-	} else {
-		zap.L().Info("http server stopped")
-	}
-
-	// Shutdown metrics server if running
-	if metricsSrv != nil {
-		if err := metricsSrv.Shutdown(ctx); err != nil {
-			zap.L().Error("metrics server shutdown failed", zap.Error(err))
-		} else {
-			zap.L().Info("metrics server stopped")
+	// Service discovery (optional); registered once the listener is up
+	var consulReg *discovery.ConsulRegistration
+	if cfg.Consul.Enabled {
+		scheme := "http"
+		if useTLS {
+			scheme = "https"
 		}
-	}
-
-	zap.L().Info("shutdown complete")
-}
-
-// initConfig initializes viper configuration: file, env, defaults
-func initConfig() error {
-	cfgFile := viper.GetString("config")
-	viper.SetEnvPrefix("APP")
-	viper.AutomaticEnv()
-
-	// Support short env var names by replacing dots with underscores
-	viper.SetEnvKeyReplacer(nil)
-
-	// If config file provided, read it
-	if cfgFile != "" {
-		viper.SetConfigFile(cfgFile)
-		if err := viper.ReadInConfig(); err != nil {
-			return fmt.Errorf("read config file: %w", err)
+		consulReg, err = discovery.RegisterService(cfg.Consul, cfg.BindAddr, scheme)
+		if err != nil {
+			zap.L().Fatal("consul registration failed", zap.Error(err))
 		}
 	}
 
-	// set defaults
-	viper.SetDefault("bind_addr", ":8080")
-	viper.SetDefault("read_timeout", "5s")
-	viper.SetDefault("write_timeout", "10s")
-	viper.SetDefault("idle_timeout", "120s")
-	viper.SetDefault("shutdown_timeout", "15s")
-	viper.SetDefault("enable_metrics", true)
-	viper.SetDefault("metrics_listen", ":9090")
-	viper.SetDefault("log_level", "info")
-	viper.SetDefault("environment", viper.GetString("env"))
-
-	// normalize durations: allow strings in config
-	// BindStringToDuration not provided by viper directly; we'll unmarshal later
-
-	return nil
-}
-
-func setDefaults(cfg *ServerConfig) {
-	if cfg.BindAddr == "" {
-		cfg.BindAddr = viper.GetString("bind_addr")
+	// Shutdown hooks, run in priority order once a signal arrives; see
+	// shutdown.Priorities for the convention
+	hooks := shutdown.NewManager()
+	hooks.Register("watchdog", shutdown.PriorityStopAccepting, func(context.Context) error {
+		// traffic stops while draining; that is not a hang
+		if wd != nil {
+			wd.Stop()
+		}
+		return nil
+	})
+	if consulReg != nil {
+		// leave the registry first so no new traffic is routed here
+		hooks.Register("consul", shutdown.PriorityStopAccepting, func(context.Context) error {
+			consulReg.Deregister()
+			return nil
+		})
 	}
-	if cfg.ReadTimeout == 0 {
-		cfg.ReadTimeout = parseDurationOrDefault(viper.GetString("read_timeout"), 5*time.Second)
+	hooks.Register("http-server", shutdown.PriorityDrainRequests, srv.Shutdown)
+	if h3srv != nil {
+		hooks.Register("http3-server", shutdown.PriorityDrainRequests, h3srv.Shutdown)
 	}
-	if cfg.WriteTimeout == 0 {
-		cfg.WriteTimeout = parseDurationOrDefault(viper.GetString("write_timeout"), 10*time.Second)
+	// after the HTTP server, before leadership is given up
+	hooks.Register("scheduler", shutdown.PriorityStopWorkers, sched.Stop)
+	hooks.Register("leader-election", shutdown.PriorityStopWorkers, func(context.Context) error {
+		stopElection()
+		<-electionDone
+		return nil
+	})
+	hooks.Register("db-autotune", shutdown.PriorityStopWorkers, func(context.Context) error {
+		stopTuner()
+		return nil
+	})
+	hooks.Register("outbox-poller", shutdown.PriorityStopWorkers, func(context.Context) error {
+		stopOutbox()
+		<-outboxDone
+		return nil
+	})
+	hooks.Register("worker-pool", shutdown.PriorityStopWorkers, func(ctx context.Context) error {
+		// let in-flight and queued background jobs finish
+		return pool.Drain(ctx)
+	})
+	if cfg.DB.Pool != nil {
+		hooks.Register("postgres", shutdown.PriorityCloseDB, func(context.Context) error {
+			cfg.DB.Pool.Close()
+			return nil
+		})
 	}
-	if cfg.IdleTimeout == 0 {
-		cfg.IdleTimeout = parseDurationOrDefault(viper.GetString("idle_timeout"), 120*time.Second)
+	if cfg.GRPCClient.Conn != nil {
+		hooks.Register("grpc-client", shutdown.PriorityCloseConsumers, func(context.Context) error {
+			return cfg.GRPCClient.Conn.Close()
+		})
 	}
-	if cfg.ShutdownTimeout == 0 {
-		cfg.ShutdownTimeout = parseDurationOrDefault(viper.GetString("shutdown_timeout"), 15*time.Second)
+	if cfg.Search.Index != nil {
+		hooks.Register("search-index", shutdown.PriorityCloseConsumers, func(context.Context) error {
+			return cfg.Search.Index.Close()
+		})
 	}
-	if cfg.MetricsListen == "" {
-		cfg.MetricsListen = viper.GetString("metrics_listen")
+	if harFile != nil {
+		hooks.Register("har-file", shutdown.PriorityCloseConsumers, func(context.Context) error {
+			return harFile.Close()
+		})
 	}
-	if cfg.Environment == "" {
-		cfg.Environment = viper.GetString("environment")
+	if auditSink != nil {
+		hooks.Register("audit-log", shutdown.PriorityCloseConsumers, func(context.Context) error {
+			return auditSink.Close()
+		})
 	}
-	if cfg.LogLevel == "" {
-		cfg.LogLevel = viper.GetString("log_level")
+	if metricsSrv != nil {
+		// last, so the final state of the shutdown can still be scraped
+		hooks.Register("metrics-server", shutdown.PriorityCloseConsumers+10, metricsSrv.Shutdown)
 	}
-}
 
-func parseDurationOrDefault(s string, d time.Duration) time.Duration {
-	if s == "" {
-		return d
-	}
-	if dur, err := time.ParseDuration(s); err == nil {
-		return dur
+	// Signal handling
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case err := <-serverErrors:
+		if !errors.Is(err, http.ErrServerClosed) {
+			zap.L().Fatal("server crashed", zap.Error(err))
+		}
+	case sig := <-signals:
+		zap.L().Info("shutdown signal received", zap.String("signal", sig.String()))
 	}
-	// maybe provided as seconds integer
-	if secs, err := strconv.Atoi(s); err == nil {
-		return time.Duration(secs) * time.Second
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := hooks.Shutdown(ctx); err != nil {
+		zap.L().Error("graceful shutdown incomplete", zap.Error(err))
 	}
-	return d
-}
 
-// initLogger configures zap logger based on config
-func initLogger(cfg ServerConfig) (*zap.Logger, error) {
-	var lvl zap.AtomicLevel
-	switch cfg.LogLevel {
-	case "debug":
-		lvl = zap.NewAtomicLevelAt(zap.DebugLevel)
-	case "warn":
-		lvl = zap.NewAtomicLevelAt(zap.WarnLevel)
-	case "error":
-		lvl = zap.NewAtomicLevelAt(zap.ErrorLevel)
-	default:
-		lvl = zap.NewAtomicLevelAt(zap.InfoLevel)
-	}
-
-	cfgZap := zap.Config{
-		Level:       lvl,
-		Development: cfg.Environment != "production",
-		Encoding:    "json",
-		EncoderConfig: zap.NewProductionEncoderConfig(),
-		OutputPaths: []string{"stdout"},
-		ErrorOutputPaths: []string{"stderr"},
-	}
-
-	if cfg.Environment != "production" {
-		cfgZap.Encoding = "console"
-		enc := zap.NewDevelopmentEncoderConfig()
-		enc.TimeKey = "ts"
-		cfgZap.EncoderConfig = enc
-	}
-
-	return cfgZap.Build()
+	zap.L().Info("shutdown complete")
 }
 
-// zapLoggerMiddleware returns a chi middleware that logs requests with zap
-func zapLoggerMiddleware() func(next http.Handler) http.Handler {
-	logger := zap.L()
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			start := time.Now()
-			ww := &responseWriter{w, http.StatusOK}
-			next.ServeHTTP(ww, r)
-			logger.Info("request",
-				zap.String("method", r.Method),
-				zap.String("path", r.URL.Path),
-				zap.Int("status", ww.status),
-				zap.Duration("duration", time.Since(start)),
-				zap.String("remote", r.RemoteAddr),
-			)
+// scheduledJobs lists the cron jobs; with leader election enabled they only
+// run on the leader
+func scheduledJobs(audit logsink.AuditConfig) []scheduler.Job {
+	jobs := []scheduler.Job{
+		{
+			Name:     "leader-tick",
+			Schedule: "@every 1m",
+			Fn: func(ctx context.Context) error {
+				// replace with periodic maintenance (cleanup, report generation, ...)
+				zap.L().Info("leader job tick")
+				return nil
+			},
+		},
+	}
+	if audit.Dir != "" && audit.Retention > 0 {
+		// only the leader purges: put audit_log.dir on storage the replicas
+		// share, or rely on max_age_days, which every replica applies
+		jobs = append(jobs, scheduler.Job{
+			Name:     "audit-log-purge",
+			Schedule: "0 3 * * *",
+			Fn: func(ctx context.Context) error {
+				n, err := logsink.PurgeAuditLogs(audit.Dir, audit.Retention)
+				zap.L().Info("purged rotated audit logs", zap.Int("files", n), zap.String("dir", audit.Dir))
+				return err
+			},
 		})
 	}
-}
-
-// responseWriter wraps http.ResponseWriter to capture status code
-type responseWriter struct {
-	http.ResponseWriter
-	status int
-}
-
-func (rw *responseWriter) WriteHeader(code int) {
-	rw.status = code
-	rw.ResponseWriter.WriteHeader(code)
+	return jobs
 }
 
 // writeJSON is a helper to write JSON responses with safe headers
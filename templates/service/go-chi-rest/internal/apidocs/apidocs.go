@@ -0,0 +1,30 @@
+// Package apidocs serves the OpenAPI description of /api/v1 and, unless
+// built with -tags no_embed, an embedded Swagger UI pointed at it
+package apidocs
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// SpecPath is where the router mounts SpecHandler; the Swagger UI loads it
+const SpecPath = "/api/v1/openapi.json"
+
+//go:embed openapi.json
+var spec []byte
+
+// SpecHandler serves the embedded OpenAPI 3 document
+func SpecHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(spec)
+}
+
+// NewSwaggerUIHandler serves the Swagger UI; mount it with the /swagger/
+// prefix stripped. In production, or in a no_embed build, every request
+// gets 404.
+func NewSwaggerUIHandler(environment string) http.Handler {
+	if environment == "production" {
+		return http.NotFoundHandler()
+	}
+	return uiHandler()
+}
@@ -0,0 +1,69 @@
+//go:build !no_embed
+
+package apidocs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// get requests path from the UI mounted the way the router mounts it
+func get(env, path string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	mux.Handle("/swagger/", http.StripPrefix("/swagger/", NewSwaggerUIHandler(env)))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+func TestSwaggerUI(t *testing.T) {
+	tests := []struct {
+		env, path string
+		want      int
+	}{
+		{"development", "/swagger/index.html", http.StatusOK},
+		{"staging", "/swagger/swagger-ui-bundle.js", http.StatusOK},
+		{"production", "/swagger/index.html", http.StatusNotFound},
+		{"production", "/swagger/swagger-initializer.js", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env+tt.path, func(t *testing.T) {
+			if rec := get(tt.env, tt.path); rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+// The page loads its spec from SpecPath instead of the upstream petstore example
+func TestSwaggerUILoadsSpec(t *testing.T) {
+	index := get("development", "/swagger/index.html")
+	if !strings.Contains(index.Body.String(), "swagger-initializer.js") {
+		t.Fatal("index.html does not load swagger-initializer.js")
+	}
+	rec := get("development", "/swagger/swagger-initializer.js")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `url: "`+SpecPath+`"`) {
+		t.Errorf("initializer = %d %q, want it to load %s", rec.Code, rec.Body.String(), SpecPath)
+	}
+	if strings.Contains(rec.Body.String(), "petstore") {
+		t.Error("initializer still points at the petstore example")
+	}
+}
+
+func TestSpecHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	SpecHandler(rec, httptest.NewRequest(http.MethodGet, SpecPath, nil))
+	var doc struct {
+		OpenAPI string         `json:"openapi"`
+		Paths   map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("spec is not JSON: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") || len(doc.Paths) == 0 {
+		t.Errorf("spec = openapi %q with %d paths", doc.OpenAPI, len(doc.Paths))
+	}
+}
@@ -0,0 +1,41 @@
+//go:build !no_embed
+
+package apidocs
+
+import (
+	"bytes"
+	_ "embed"
+	"io/fs"
+	"net/http"
+	"time"
+
+	swaggerfiles "github.com/swaggo/files/v2"
+)
+
+// swagger-initializer.js replaces the upstream one, which loads the petstore
+// example, with one that loads SpecPath
+//
+//go:embed swagger-initializer.js
+var initializer []byte
+
+func uiHandler() http.Handler {
+	files := http.FileServer(http.FS(swaggerfiles.FS))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "swagger-initializer.js":
+			w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+			w.Write(initializer)
+			return
+		case "index.html":
+			// http.FileServer would redirect this to the directory URL
+			page, err := fs.ReadFile(swaggerfiles.FS, "index.html")
+			if err != nil {
+				http.NotFound(w, r)
+				return
+			}
+			http.ServeContent(w, r, "index.html", time.Time{}, bytes.NewReader(page))
+			return
+		}
+		files.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,10 @@
+//go:build no_embed
+
+package apidocs
+
+import "net/http"
+
+// uiHandler without the embedded assets: the UI is not available
+func uiHandler() http.Handler {
+	return http.NotFoundHandler()
+}
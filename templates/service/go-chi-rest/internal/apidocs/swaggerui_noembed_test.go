@@ -0,0 +1,17 @@
+//go:build no_embed
+
+package apidocs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSwaggerUINoEmbed(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewSwaggerUIHandler("development").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/index.html", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404 without the embedded UI", rec.Code)
+	}
+}
@@ -0,0 +1,132 @@
+// Package apischema validates request and response bodies of individual
+// routes against JSON Schema files kept in schemas/ and embedded in the
+// binary. It is meant to catch drift between the API and its documented
+// contract, mostly during development: every validated body is buffered.
+package apischema
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+	"go.uber.org/zap"
+
+	"github.com/example/go-chi-rest/internal/errcodes"
+)
+
+//go:embed schemas/*.json
+var schemas embed.FS
+
+// ValidateSchema returns middleware for one route. requestSchema and
+// responseSchema are paths inside the embedded FS, e.g.
+// "schemas/ping.response.json"; an empty path skips that direction.
+//
+// A request body that is not valid JSON or does not match requestSchema is
+// answered with 400 INVALID_REQUEST or VALIDATION_FAILED and never reaches
+// the handler. A 2xx JSON response that does not match responseSchema is
+// still sent, as it has already been written, and logged as a warning.
+//
+// It panics when a schema is missing or is not a valid JSON Schema, like
+// regexp.MustCompile, since both are mistakes in the route table.
+func ValidateSchema(requestSchema, responseSchema string) func(http.Handler) http.Handler {
+	req, resp := mustLoad(requestSchema), mustLoad(responseSchema)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if req != nil {
+				body, err := io.ReadAll(r.Body)
+				r.Body.Close()
+				if err != nil {
+					errcodes.Write(w, errcodes.FromRequest(r).New("INVALID_REQUEST", "cannot read body"))
+					return
+				}
+				result, err := req.Validate(gojsonschema.NewBytesLoader(body))
+				if err != nil {
+					errcodes.Write(w, errcodes.FromRequest(r).New("INVALID_REQUEST", "body is not valid JSON"))
+					return
+				}
+				if !result.Valid() {
+					errs := result.Errors()
+					msg := errs[0].Description()
+					if len(errs) > 1 {
+						msg += fmt.Sprintf(" (and %d more: %s)", len(errs)-1, describe(errs[1:]))
+					}
+					errcodes.Write(w, errcodes.FromRequest(r).New("VALIDATION_FAILED", errs[0].Field(), msg))
+					return
+				}
+				r.Body = io.NopCloser(bytes.NewReader(body))
+			}
+			if resp == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &captureWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(cw, r)
+			if cw.status < 200 || cw.status > 299 || cw.buf.Len() == 0 ||
+				!strings.Contains(w.Header().Get("Content-Type"), "json") {
+				return
+			}
+			result, err := resp.Validate(gojsonschema.NewBytesLoader(cw.buf.Bytes()))
+			switch {
+			case err != nil:
+				zap.L().Warn("response is not valid JSON",
+					zap.String("method", r.Method), zap.String("path", r.URL.Path),
+					zap.String("schema", responseSchema), zap.Error(err))
+			case !result.Valid():
+				zap.L().Warn("response does not match schema",
+					zap.String("method", r.Method), zap.String("path", r.URL.Path),
+					zap.String("schema", responseSchema), zap.String("errors", describe(result.Errors())))
+			}
+		})
+	}
+}
+
+func mustLoad(path string) *gojsonschema.Schema {
+	if path == "" {
+		return nil
+	}
+	data, err := schemas.ReadFile(path)
+	if err != nil {
+		panic(fmt.Sprintf("apischema: %v", err))
+	}
+	s, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(data))
+	if err != nil {
+		panic(fmt.Sprintf("apischema: %s: %v", path, err))
+	}
+	return s
+}
+
+// describe joins violations as "field: description" pairs
+func describe(errs []gojsonschema.ResultError) string {
+	parts := make([]string, len(errs))
+	for i, e := range errs {
+		parts[i] = e.Field() + ": " + e.Description()
+	}
+	return strings.Join(parts, "; ")
+}
+
+// captureWriter tees the body into buf
+type captureWriter struct {
+	http.ResponseWriter
+	status int
+	buf    bytes.Buffer
+}
+
+func (cw *captureWriter) WriteHeader(code int) {
+	cw.status = code
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+func (cw *captureWriter) Write(b []byte) (int, error) {
+	cw.buf.Write(b)
+	return cw.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (cw *captureWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
@@ -0,0 +1,127 @@
+package apischema
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+const pingSchema = "schemas/ping.response.json"
+
+func respond(status int, contentType, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}
+}
+
+func TestValidateSchemaResponse(t *testing.T) {
+	tests := []struct {
+		name        string
+		handler     http.HandlerFunc
+		wantWarning string
+	}{
+		{"matches", respond(http.StatusOK, "application/json", `{"message":"pong"}`), ""},
+		{"wrong key", respond(http.StatusOK, "application/json", `{"msg":"pong"}`), "response does not match schema"},
+		{"wrong type", respond(http.StatusOK, "application/json", `{"message":1}`), "response does not match schema"},
+		{"malformed", respond(http.StatusOK, "application/json", `{"message":`), "response is not valid JSON"},
+		{"error status skipped", respond(http.StatusInternalServerError, "application/json", `{"error":{}}`), ""},
+		{"not json skipped", respond(http.StatusOK, "text/plain", "pong"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.WarnLevel)
+			defer zap.ReplaceGlobals(zap.New(core))()
+
+			rec := httptest.NewRecorder()
+			ValidateSchema("", pingSchema)(tt.handler).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/ping", nil))
+
+			// the response is sent unchanged either way
+			want := httptest.NewRecorder()
+			tt.handler(want, httptest.NewRequest(http.MethodGet, "/api/v1/ping", nil))
+			if rec.Code != want.Code || rec.Body.String() != want.Body.String() {
+				t.Errorf("response = %d %q, want %d %q", rec.Code, rec.Body, want.Code, want.Body)
+			}
+
+			entries := logs.All()
+			if tt.wantWarning == "" {
+				if len(entries) != 0 {
+					t.Errorf("unexpected log %q", entries[0].Message)
+				}
+				return
+			}
+			if len(entries) != 1 || entries[0].Message != tt.wantWarning {
+				t.Fatalf("logs = %v, want one %q", entries, tt.wantWarning)
+			}
+			fields := entries[0].ContextMap()
+			if fields["schema"] != pingSchema || fields["path"] != "/api/v1/ping" {
+				t.Errorf("fields = %v", fields)
+			}
+			if tt.name == "wrong key" && !strings.Contains(fields["errors"].(string), "message") {
+				t.Errorf("errors field %q does not name the missing property", fields["errors"])
+			}
+		})
+	}
+}
+
+func TestValidateSchemaRequest(t *testing.T) {
+	tests := []struct {
+		name, body string
+		wantStatus int
+		wantCode   string
+	}{
+		{"valid", `{"message":"hi"}`, http.StatusOK, ""},
+		{"missing field", `{}`, http.StatusBadRequest, "VALIDATION_FAILED"},
+		{"several violations", `{"message":1,"extra":true}`, http.StatusBadRequest, "VALIDATION_FAILED"},
+		{"malformed", `{"message"`, http.StatusBadRequest, "INVALID_REQUEST"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			h := ValidateSchema(pingSchema, "")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := io.ReadAll(r.Body)
+				got = string(b)
+			}))
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/echo", strings.NewReader(tt.body)))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantCode == "" {
+				if got != tt.body {
+					t.Errorf("handler read %q, want the original body", got)
+				}
+				return
+			}
+			var body struct {
+				Error struct{ Code, Message string }
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Error.Code != tt.wantCode || body.Error.Message == "" {
+				t.Errorf("error = %+v, want %s", body.Error, tt.wantCode)
+			}
+			if tt.name == "several violations" && !strings.Contains(body.Error.Message, "more") {
+				t.Errorf("message %q does not mention the other violations", body.Error.Message)
+			}
+		})
+	}
+}
+
+func TestValidateSchemaMissingPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("missing schema did not panic")
+		}
+	}()
+	ValidateSchema("schemas/missing.json", "")
+}
@@ -0,0 +1,223 @@
+// Package authn authenticates callers of /api/v1 from a JWT bearer token and
+// puts the verified principal in the request context. Unlike the X-User-ID
+// header that reqctx copies into logs, the principal can decide whose data a
+// request may read or change.
+package authn
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/example/go-chi-rest/internal/errcodes"
+	"github.com/example/go-chi-rest/internal/security"
+)
+
+// JWTConfig configures bearer token verification. Exactly one of Secret and
+// PublicKeyFile is used; the key decides which signing algorithms are accepted.
+// A fips build accepts only RS256 and ES256 and refuses a Secret.
+type JWTConfig struct {
+	Secret        string        `mapstructure:"secret"`          // HS256/384/512 key
+	PublicKeyFile string        `mapstructure:"public_key_file"` // PEM RSA, ECDSA or Ed25519 public key; wins over secret
+	Issuer        string        `mapstructure:"issuer"`          // required iss claim when set
+	Audience      string        `mapstructure:"audience"`        // required aud claim when set
+	SubjectClaim  string        `mapstructure:"subject_claim"`   // claim holding the user ID; default sub
+	Leeway        time.Duration `mapstructure:"leeway"`          // clock skew allowed on exp and nbf
+}
+
+// AuthConfig configures caller authentication
+type AuthConfig struct {
+	JWT JWTConfig `mapstructure:"jwt"`
+}
+
+// Configured reports whether cfg sets up an authenticator
+func (c AuthConfig) Configured() bool {
+	return c.JWT.Secret != "" || c.JWT.PublicKeyFile != ""
+}
+
+// Principal is a verified caller
+type Principal struct {
+	Subject string
+	Claims  jwt.MapClaims
+}
+
+type ctxKey struct{}
+
+// subjectSlot carries the verified subject back out to middleware mounted
+// before the authenticator, such as the access log
+type subjectSlot struct{ subject string }
+
+type slotKey struct{}
+
+// TrackSubject returns ctx with an empty slot that WithPrincipal fills further
+// down the chain, and a function reporting the subject stored there. Call it
+// once the handler has returned.
+func TrackSubject(ctx context.Context) (context.Context, func() string) {
+	slot := &subjectSlot{}
+	return context.WithValue(ctx, slotKey{}, slot), func() string { return slot.subject }
+}
+
+// WithPrincipal returns ctx carrying p
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	if slot, ok := ctx.Value(slotKey{}).(*subjectSlot); ok {
+		slot.subject = p.Subject
+	}
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+// FromContext returns the verified principal of the request, if any
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(ctxKey{}).(Principal)
+	return p, ok
+}
+
+// Subject returns the verified user ID, or "" for anonymous requests
+func Subject(ctx context.Context) string {
+	p, _ := FromContext(ctx)
+	return p.Subject
+}
+
+// Authenticator verifies bearer tokens
+type Authenticator struct {
+	key    any
+	parser *jwt.Parser
+	claim  string
+}
+
+// fipsMethods are the only signing algorithms accepted in FIPS mode
+var fipsMethods = map[string]bool{"RS256": true, "ES256": true}
+
+// NewAuthenticator loads the verification key of cfg
+func NewAuthenticator(cfg AuthConfig) (*Authenticator, error) {
+	c := cfg.JWT
+	var (
+		key     any
+		methods []string
+	)
+	switch {
+	case c.PublicKeyFile != "":
+		pemBytes, err := os.ReadFile(c.PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("authn: %w", err)
+		}
+		if key, methods, err = parsePublicKey(pemBytes); err != nil {
+			return nil, fmt.Errorf("authn: %s: %w", c.PublicKeyFile, err)
+		}
+	case c.Secret != "":
+		if security.IsFIPSMode() {
+			return nil, errors.New("authn: auth.jwt.secret (HMAC) is not allowed in FIPS mode; set auth.jwt.public_key_file to an RSA or ECDSA P-256 key")
+		}
+		key, methods = []byte(c.Secret), []string{"HS256", "HS384", "HS512"}
+	default:
+		return nil, errors.New("authn: set auth.jwt.secret or auth.jwt.public_key_file")
+	}
+	if security.IsFIPSMode() {
+		approved := methods[:0]
+		for _, m := range methods {
+			if fipsMethods[m] {
+				approved = append(approved, m)
+			}
+		}
+		if len(approved) == 0 {
+			return nil, fmt.Errorf("authn: %s: key type not allowed in FIPS mode (want RSA or ECDSA)", c.PublicKeyFile)
+		}
+		methods = approved
+	}
+	opts := []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithExpirationRequired(), jwt.WithLeeway(c.Leeway)}
+	if c.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(c.Issuer))
+	}
+	if c.Audience != "" {
+		opts = append(opts, jwt.WithAudience(c.Audience))
+	}
+	claim := c.SubjectClaim
+	if claim == "" {
+		claim = "sub"
+	}
+	return &Authenticator{key: key, parser: jwt.NewParser(opts...), claim: claim}, nil
+}
+
+func parsePublicKey(pemBytes []byte) (any, []string, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, nil, errors.New("no PEM block")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return key, []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}, nil
+	case *ecdsa.PublicKey:
+		return key, []string{"ES256", "ES384", "ES512"}, nil
+	case ed25519.PublicKey:
+		return key, []string{"EdDSA"}, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported key type %T", key)
+}
+
+// Authenticate verifies a compact JWT and returns its principal
+func (a *Authenticator) Authenticate(token string) (Principal, error) {
+	claims := jwt.MapClaims{}
+	if _, err := a.parser.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) { return a.key, nil }); err != nil {
+		return Principal{}, err
+	}
+	sub, _ := claims[a.claim].(string)
+	if sub == "" {
+		return Principal{}, fmt.Errorf("authn: token has no %q claim", a.claim)
+	}
+	return Principal{Subject: sub, Claims: claims}, nil
+}
+
+// Middleware verifies the bearer token when one is sent: a valid token puts
+// its principal in the context, anything else is answered 401. Requests
+// without a token pass on anonymously; routes that need a user check Subject
+// or are wrapped in Require.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok {
+			unauthorized(w, r)
+			return
+		}
+		p, err := a.Authenticate(strings.TrimSpace(token))
+		if err != nil {
+			unauthorized(w, r)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), p)))
+	})
+}
+
+// Require answers 401 to requests without a verified principal
+func Require(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if Subject(r.Context()) == "" {
+			unauthorized(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	errcodes.Write(w, errcodes.FromRequest(r).New("UNAUTHORIZED"))
+}
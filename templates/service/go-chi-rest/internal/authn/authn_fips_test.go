@@ -0,0 +1,80 @@
+//go:build fips
+
+package authn
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writePublicKey stores pub as a PEM file in a temp dir
+func writePublicKey(t *testing.T, pub any) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwt.pub")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFIPSSigningMethods(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}
+
+	tests := []struct {
+		name  string
+		pub   any
+		token string
+		want  int
+	}{
+		{"RS256", &rsaKey.PublicKey, sign(t, jwt.SigningMethodRS256, rsaKey, claims), http.StatusOK},
+		{"RS512", &rsaKey.PublicKey, sign(t, jwt.SigningMethodRS512, rsaKey, claims), http.StatusUnauthorized},
+		{"PS256", &rsaKey.PublicKey, sign(t, jwt.SigningMethodPS256, rsaKey, claims), http.StatusUnauthorized},
+		{"HS256", &rsaKey.PublicKey, sign(t, jwt.SigningMethodHS256, []byte(testSecret), claims), http.StatusUnauthorized},
+		{"ES256", &ecKey.PublicKey, sign(t, jwt.SigningMethodES256, ecKey, claims), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := NewAuthenticator(AuthConfig{JWT: JWTConfig{PublicKeyFile: writePublicKey(t, tt.pub)}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rec := httptest.NewRecorder()
+			a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestFIPSRejectsSecret(t *testing.T) {
+	if _, err := NewAuthenticator(AuthConfig{JWT: JWTConfig{Secret: testSecret}}); err == nil {
+		t.Error("HMAC secret accepted in FIPS mode")
+	}
+}
@@ -0,0 +1,113 @@
+package authn
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/example/go-chi-rest/internal/security"
+)
+
+const testSecret = "test-secret"
+
+func sign(t *testing.T, method jwt.SigningMethod, key any, claims jwt.MapClaims) string {
+	t.Helper()
+	s, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestMiddleware(t *testing.T) {
+	if security.IsFIPSMode() {
+		t.Skip("HMAC secrets are refused in FIPS mode; see authn_fips_test.go")
+	}
+	a, err := NewAuthenticator(AuthConfig{JWT: JWTConfig{Secret: testSecret, Issuer: "issuer"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := jwt.MapClaims{"sub": "user-1", "iss": "issuer", "exp": time.Now().Add(time.Hour).Unix()}
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int
+		subject string
+	}{
+		{"no token is anonymous", nil, http.StatusOK, ""},
+		{"x-user-id alone is anonymous", map[string]string{"X-User-ID": "user-1"}, http.StatusOK, ""},
+		{"valid token", map[string]string{"Authorization": "Bearer " + sign(t, jwt.SigningMethodHS256, []byte(testSecret), valid)},
+			http.StatusOK, "user-1"},
+		{"token wins over x-user-id", map[string]string{
+			"Authorization": "Bearer " + sign(t, jwt.SigningMethodHS256, []byte(testSecret), valid),
+			"X-User-ID":     "user-2",
+		}, http.StatusOK, "user-1"},
+		{"expired token", map[string]string{"Authorization": "Bearer " + sign(t, jwt.SigningMethodHS256, []byte(testSecret),
+			jwt.MapClaims{"sub": "user-1", "iss": "issuer", "exp": time.Now().Add(-time.Hour).Unix()})},
+			http.StatusUnauthorized, ""},
+		{"token without exp", map[string]string{"Authorization": "Bearer " + sign(t, jwt.SigningMethodHS256, []byte(testSecret),
+			jwt.MapClaims{"sub": "user-1", "iss": "issuer"})},
+			http.StatusUnauthorized, ""},
+		{"wrong issuer", map[string]string{"Authorization": "Bearer " + sign(t, jwt.SigningMethodHS256, []byte(testSecret),
+			jwt.MapClaims{"sub": "user-1", "iss": "other", "exp": time.Now().Add(time.Hour).Unix()})},
+			http.StatusUnauthorized, ""},
+		{"wrong secret", map[string]string{"Authorization": "Bearer " + sign(t, jwt.SigningMethodHS256, []byte("other"), valid)},
+			http.StatusUnauthorized, ""},
+		{"unsigned token", map[string]string{"Authorization": "Bearer " + sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, valid)},
+			http.StatusUnauthorized, ""},
+		{"no subject", map[string]string{"Authorization": "Bearer " + sign(t, jwt.SigningMethodHS256, []byte(testSecret),
+			jwt.MapClaims{"iss": "issuer", "exp": time.Now().Add(time.Hour).Unix()})},
+			http.StatusUnauthorized, ""},
+		{"not a bearer token", map[string]string{"Authorization": "Basic dXNlcjpwYXNz"}, http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var subject string
+			h := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				subject = Subject(r.Context())
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if subject != tt.subject {
+				t.Errorf("subject = %q, want %q", subject, tt.subject)
+			}
+		})
+	}
+}
+
+func TestRequire(t *testing.T) {
+	h := Require(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", rec.Code)
+	}
+	if rec.Header().Get("WWW-Authenticate") == "" {
+		t.Error("missing WWW-Authenticate")
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req.WithContext(WithPrincipal(req.Context(), Principal{Subject: "user-1"})))
+	if rec.Code != http.StatusOK {
+		t.Errorf("with principal: status = %d, want 200", rec.Code)
+	}
+}
+
+func TestNewAuthenticatorNeedsKey(t *testing.T) {
+	if _, err := NewAuthenticator(AuthConfig{}); err == nil {
+		t.Error("want error without secret or public key")
+	}
+}
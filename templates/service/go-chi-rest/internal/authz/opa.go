@@ -0,0 +1,197 @@
+package authz
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/open-policy-agent/opa/rego"
+	"go.uber.org/zap"
+
+	"github.com/example/go-chi-rest/internal/authn"
+	"github.com/example/go-chi-rest/internal/cache"
+)
+
+//go:embed policy.rego
+var embeddedPolicy string
+
+// OPAConfig configures policy-based authorization backed by Open Policy Agent
+type OPAConfig struct {
+	Enabled    bool          `mapstructure:"enabled"`
+	Addr       string        `mapstructure:"addr"`        // OPA REST API base URL; empty uses the embedded policy
+	PolicyPath string        `mapstructure:"policy_path"` // e.g. data.http.authz.allow
+	CacheTTL   time.Duration `mapstructure:"cache_ttl"`   // 0 disables decision caching
+	CacheSize  int           `mapstructure:"cache_size"`  // cached decisions kept, least recently used evicted first; default 10000
+	Timeout    time.Duration `mapstructure:"timeout"`
+
+	// Input builds the OPA input document for a request. Defaults to DefaultInput.
+	Input func(*http.Request) map[string]any `mapstructure:"-"`
+}
+
+// DefaultInput builds an input document with method, path segments and the
+// subject verified by authn ("" for anonymous requests)
+func DefaultInput(r *http.Request) map[string]any {
+	return map[string]any{
+		"method":  r.Method,
+		"path":    strings.Split(strings.Trim(r.URL.Path, "/"), "/"),
+		"subject": authn.Subject(r.Context()),
+	}
+}
+
+type opaAuthorizer struct {
+	cfg    OPAConfig
+	client *http.Client
+	query  *rego.PreparedEvalQuery
+	err    error
+
+	cache *cache.Cache[string, bool] // nil when CacheTTL is 0
+}
+
+// NewOPAMiddleware returns a middleware that asks OPA whether the request is allowed
+// and responds 403 when the policy denies it.
+func NewOPAMiddleware(cfg OPAConfig) func(http.Handler) http.Handler {
+	if cfg.PolicyPath == "" {
+		cfg.PolicyPath = "data.http.authz.allow"
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 2 * time.Second
+	}
+	if cfg.Input == nil {
+		cfg.Input = DefaultInput
+	}
+
+	if cfg.CacheSize <= 0 {
+		cfg.CacheSize = 10000
+	}
+
+	a := &opaAuthorizer{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+	if cfg.CacheTTL > 0 {
+		a.cache = cache.New[string, bool](cache.CacheConfig{Name: "opa_decisions", Capacity: cfg.CacheSize})
+	}
+
+	if cfg.Addr == "" {
+		pq, err := rego.New(
+			rego.Query(cfg.PolicyPath),
+			rego.Module("policy.rego", embeddedPolicy),
+		).PrepareForEval(context.Background())
+		if err != nil {
+			a.err = fmt.Errorf("prepare embedded policy: %w", err)
+			zap.L().Error("opa: embedded policy unavailable, denying all requests", zap.Error(a.err))
+		} else {
+			a.query = &pq
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			input := a.cfg.Input(r)
+			allow, err := a.allowed(r.Context(), r, input)
+			if err != nil {
+				zap.L().Error("opa: authorization decision failed", zap.Error(err))
+				writeError(w, http.StatusServiceUnavailable, "authz_unavailable", "authorization service unavailable")
+				return
+			}
+			if !allow {
+				writeError(w, http.StatusForbidden, "forbidden", "access denied by policy")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// allowed returns the cached decision for (path, method, subject) or evaluates
+// the policy. Only decisions for authenticated subjects are cached: anonymous
+// requests could otherwise fill the cache with keys of their choosing.
+func (a *opaAuthorizer) allowed(ctx context.Context, r *http.Request, input map[string]any) (bool, error) {
+	subject := authn.Subject(r.Context())
+	key := r.URL.Path + "|" + r.Method + "|" + subject
+	useCache := a.cache != nil && subject != ""
+	if useCache {
+		if allow, ok := a.cache.Get(key); ok {
+			return allow, nil
+		}
+	}
+
+	var (
+		allow bool
+		err   error
+	)
+	if a.cfg.Addr != "" {
+		allow, err = a.queryServer(ctx, input)
+	} else {
+		allow, err = a.queryEmbedded(ctx, input)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if useCache {
+		a.cache.Set(key, allow, a.cfg.CacheTTL)
+	}
+	return allow, nil
+}
+
+// queryServer POSTs the input document to OPA's data API
+func (a *opaAuthorizer) queryServer(ctx context.Context, input map[string]any) (bool, error) {
+	body, err := json.Marshal(map[string]any{"input": input})
+	if err != nil {
+		return false, fmt.Errorf("encode opa input: %w", err)
+	}
+
+	path := strings.ReplaceAll(strings.TrimPrefix(a.cfg.PolicyPath, "data."), ".", "/")
+	url := strings.TrimRight(a.cfg.Addr, "/") + "/v1/data/" + path
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("build opa request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("query opa: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("query opa: unexpected status %d", resp.StatusCode)
+	}
+
+	var out struct {
+		Result bool `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return false, fmt.Errorf("decode opa response: %w", err)
+	}
+	return out.Result, nil
+}
+
+// queryEmbedded evaluates the embedded Rego policy in-process
+func (a *opaAuthorizer) queryEmbedded(ctx context.Context, input map[string]any) (bool, error) {
+	if a.query == nil {
+		return false, a.err
+	}
+	rs, err := a.query.Eval(ctx, rego.EvalInput(input))
+	if err != nil {
+		return false, fmt.Errorf("evaluate policy: %w", err)
+	}
+	return rs.Allowed(), nil
+}
+
+// writeError writes the standard JSON error shape
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]string{"code": code, "message": message},
+	})
+}
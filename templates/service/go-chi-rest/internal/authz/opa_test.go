@@ -0,0 +1,111 @@
+package authz
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/example/go-chi-rest/internal/authn"
+)
+
+// fakeOPA answers the data API with allow for the subject "alice" and counts calls
+func fakeOPA(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Path != "/v1/data/http/authz/allow" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		var body struct {
+			Input map[string]any `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		json.NewEncoder(w).Encode(map[string]bool{"result": body.Input["subject"] == "alice"})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func serveOPA(h http.Handler, subject string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/orders", nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if subject != "" {
+		req = req.WithContext(authn.WithPrincipal(req.Context(), authn.Principal{Subject: subject}))
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestOPAMiddleware(t *testing.T) {
+	srv, calls := fakeOPA(t)
+	h := NewOPAMiddleware(OPAConfig{Addr: srv.URL, CacheTTL: time.Minute})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	t.Run("deny", func(t *testing.T) {
+		rec := serveOPA(h, "bob", nil)
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("status = %d, want 403", rec.Code)
+		}
+		var body struct {
+			Error struct{ Code, Message string } `json:"error"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if body.Error.Code != "forbidden" || body.Error.Message == "" {
+			t.Errorf("body = %s", rec.Body)
+		}
+	})
+
+	t.Run("cached allow skips opa", func(t *testing.T) {
+		calls.Store(0)
+		for i := 0; i < 3; i++ {
+			if rec := serveOPA(h, "alice", nil); rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", rec.Code)
+			}
+		}
+		if n := calls.Load(); n != 1 {
+			t.Errorf("opa calls = %d, want 1", n)
+		}
+	})
+
+	t.Run("x-user-id is not a subject", func(t *testing.T) {
+		if rec := serveOPA(h, "", map[string]string{"X-User-ID": "alice"}); rec.Code != http.StatusForbidden {
+			t.Errorf("status = %d, want 403", rec.Code)
+		}
+	})
+
+	t.Run("anonymous decisions are not cached", func(t *testing.T) {
+		calls.Store(0)
+		for i := 0; i < 3; i++ {
+			serveOPA(h, "", nil)
+		}
+		if n := calls.Load(); n != 3 {
+			t.Errorf("opa calls = %d, want 3", n)
+		}
+	})
+}
+
+func TestOPADecisionCacheIsBounded(t *testing.T) {
+	srv, calls := fakeOPA(t)
+	h := NewOPAMiddleware(OPAConfig{Addr: srv.URL, CacheTTL: time.Minute, CacheSize: 2})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for _, subject := range []string{"alice", "bob", "carol"} {
+		serveOPA(h, subject, nil)
+	}
+	calls.Store(0)
+	serveOPA(h, "alice", nil) // evicted as least recently used
+	serveOPA(h, "carol", nil)
+	if n := calls.Load(); n != 1 {
+		t.Errorf("opa calls = %d, want 1", n)
+	}
+}
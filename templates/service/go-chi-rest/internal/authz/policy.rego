@@ -0,0 +1,17 @@
+# Default authorization policy evaluated in-process when no OPA server is
+# configured (opa.addr is empty). Replace with your own rules or point the
+# service at a central OPA deployment.
+package http.authz
+
+import rego.v1
+
+default allow := false
+
+# Read-only requests are allowed for everyone.
+allow if input.method in {"GET", "HEAD", "OPTIONS"}
+
+# State-changing requests require an authenticated subject.
+allow if {
+	input.method in {"POST", "PUT", "PATCH", "DELETE"}
+	input.subject != ""
+}
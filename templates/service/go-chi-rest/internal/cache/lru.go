@@ -0,0 +1,184 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	hitsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_hits_total",
+		Help: "Cache lookups that found a live entry.",
+	}, []string{"name"})
+	missesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_misses_total",
+		Help: "Cache lookups that found nothing or an expired entry.",
+	}, []string{"name"})
+	evictionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_evictions_total",
+		Help: "Entries removed due to capacity or expiry.",
+	}, []string{"name"})
+	sizeGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cache_size",
+		Help: "Current number of cached entries.",
+	}, []string{"name"})
+)
+
+// CacheConfig configures an LRU cache
+type CacheConfig struct {
+	Name            string        `mapstructure:"name"`     // metrics label
+	Capacity        int           `mapstructure:"capacity"` // 0 means unbounded
+	CleanupInterval time.Duration `mapstructure:"cleanup_interval"`
+}
+
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time // zero means no expiry
+}
+
+func (e *entry[K, V]) expired(now time.Time) bool {
+	return !e.expires.IsZero() && now.After(e.expires)
+}
+
+// Cache is a size-bounded LRU with per-entry TTL, safe for concurrent use
+type Cache[K comparable, V any] struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List // front = most recently used
+	items    map[K]*list.Element
+
+	hits, misses, evictions prometheus.Counter
+	size                    prometheus.Gauge
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// New creates a cache and starts its reaper when CleanupInterval > 0. Call Close to stop it.
+func New[K comparable, V any](cfg CacheConfig) *Cache[K, V] {
+	if cfg.Name == "" {
+		cfg.Name = "default"
+	}
+	c := &Cache[K, V]{
+		capacity:  cfg.Capacity,
+		ll:        list.New(),
+		items:     make(map[K]*list.Element),
+		hits:      hitsTotal.WithLabelValues(cfg.Name),
+		misses:    missesTotal.WithLabelValues(cfg.Name),
+		evictions: evictionsTotal.WithLabelValues(cfg.Name),
+		size:      sizeGauge.WithLabelValues(cfg.Name),
+		stop:      make(chan struct{}),
+	}
+	if cfg.CleanupInterval > 0 {
+		go c.reap(cfg.CleanupInterval)
+	}
+	return c
+}
+
+// Set stores value under key; ttl <= 0 never expires
+func (c *Cache[K, V]) Set(key K, value V, ttl time.Duration) {
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry[K, V])
+		e.value, e.expires = value, expires
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.ll.PushFront(&entry[K, V]{key: key, value: value, expires: expires})
+	if c.capacity > 0 && c.ll.Len() > c.capacity {
+		c.removeElement(c.ll.Back())
+		c.evictions.Inc()
+	}
+	c.size.Set(float64(c.ll.Len()))
+}
+
+// Get returns the value for key if present and not expired
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	el, ok := c.items[key]
+	if !ok {
+		c.misses.Inc()
+		return zero, false
+	}
+	e := el.Value.(*entry[K, V])
+	if e.expired(time.Now()) {
+		c.removeElement(el)
+		c.evictions.Inc()
+		c.size.Set(float64(c.ll.Len()))
+		c.misses.Inc()
+		return zero, false
+	}
+	c.ll.MoveToFront(el)
+	c.hits.Inc()
+	return e.value, true
+}
+
+// Delete removes key if present
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+		c.size.Set(float64(c.ll.Len()))
+	}
+}
+
+// Len returns the number of entries, including expired ones not yet reaped
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// Close stops the background reaper
+func (c *Cache[K, V]) Close() {
+	c.stopOnce.Do(func() { close(c.stop) })
+}
+
+func (c *Cache[K, V]) removeElement(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*entry[K, V]).key)
+}
+
+func (c *Cache[K, V]) reap(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.purgeExpired()
+		}
+	}
+}
+
+func (c *Cache[K, V]) purgeExpired() {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for el := c.ll.Back(); el != nil; {
+		prev := el.Prev()
+		if el.Value.(*entry[K, V]).expired(now) {
+			c.removeElement(el)
+			c.evictions.Inc()
+		}
+		el = prev
+	}
+	c.size.Set(float64(c.ll.Len()))
+}
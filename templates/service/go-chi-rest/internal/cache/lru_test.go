@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestLRUEviction(t *testing.T) {
+	c := New[string, int](CacheConfig{Name: "test_lru", Capacity: 2})
+	defer c.Close()
+
+	c.Set("a", 1, 0)
+	c.Set("b", 2, 0)
+	c.Get("a") // b is now least recently used
+	c.Set("c", 3, 0)
+
+	tests := []struct {
+		key  string
+		want int
+		ok   bool
+	}{
+		{"a", 1, true},
+		{"b", 0, false},
+		{"c", 3, true},
+	}
+	for _, tt := range tests {
+		if got, ok := c.Get(tt.key); got != tt.want || ok != tt.ok {
+			t.Errorf("Get(%s) = %d, %v, want %d, %v", tt.key, got, ok, tt.want, tt.ok)
+		}
+	}
+	if c.Len() != 2 {
+		t.Errorf("Len = %d, want 2", c.Len())
+	}
+	if got := testutil.ToFloat64(c.evictions); got != 1 {
+		t.Errorf("evictions = %v, want 1", got)
+	}
+	if hits, misses := testutil.ToFloat64(c.hits), testutil.ToFloat64(c.misses); hits != 3 || misses != 1 {
+		t.Errorf("hits/misses = %v/%v, want 3/1", hits, misses)
+	}
+}
+
+func TestUpdateRefreshesRecency(t *testing.T) {
+	c := New[string, int](CacheConfig{Name: "test_update", Capacity: 2})
+	defer c.Close()
+
+	c.Set("a", 1, 0)
+	c.Set("b", 2, 0)
+	c.Set("a", 10, 0)
+	c.Set("c", 3, 0)
+	if v, ok := c.Get("a"); !ok || v != 10 {
+		t.Errorf("Get(a) = %d, %v, want 10, true", v, ok)
+	}
+	if _, ok := c.Get("b"); ok {
+		t.Error("b survived, want it evicted")
+	}
+}
+
+func TestTTL(t *testing.T) {
+	c := New[string, int](CacheConfig{Name: "test_ttl"})
+	defer c.Close()
+
+	c.Set("short", 1, 20*time.Millisecond)
+	c.Set("forever", 2, 0)
+	time.Sleep(40 * time.Millisecond)
+	if _, ok := c.Get("short"); ok {
+		t.Error("expired entry returned")
+	}
+	if _, ok := c.Get("forever"); !ok {
+		t.Error("entry without TTL expired")
+	}
+	if c.Len() != 1 {
+		t.Errorf("Len = %d, want expired entry removed on Get", c.Len())
+	}
+}
+
+func TestReaperPurgesExpired(t *testing.T) {
+	c := New[int, int](CacheConfig{Name: "test_reaper", CleanupInterval: 10 * time.Millisecond})
+	defer c.Close()
+
+	for i := 0; i < 10; i++ {
+		c.Set(i, i, 5*time.Millisecond)
+	}
+	c.Set(100, 100, 0)
+	deadline := time.Now().Add(time.Second)
+	for c.Len() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Len = %d after reaping, want 1", c.Len())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	c := New[string, int](CacheConfig{Name: "test_concurrent", Capacity: 50, CleanupInterval: time.Millisecond})
+	defer c.Close()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				key := fmt.Sprintf("k%d", (g*500+i)%100)
+				c.Set(key, i, time.Millisecond)
+				c.Get(key)
+				if i%10 == 0 {
+					c.Delete(key)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	if c.Len() > 50 {
+		t.Errorf("Len = %d, exceeds capacity 50", c.Len())
+	}
+}
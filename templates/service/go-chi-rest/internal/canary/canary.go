@@ -0,0 +1,89 @@
+// Package canary sends part of the traffic to a canary deployment
+package canary
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	"go.uber.org/zap"
+
+	"github.com/example/go-chi-rest/internal/errcodes"
+	"github.com/example/go-chi-rest/internal/reqctx"
+)
+
+// CanaryConfig routes requests carrying CanaryHeader: CanaryValue, plus
+// CanaryPercent of the remaining traffic, to CanaryBackendURL
+type CanaryConfig struct {
+	Enabled          bool    `mapstructure:"enabled"`
+	CanaryHeader     string  `mapstructure:"header"`  // default X-Canary
+	CanaryValue      string  `mapstructure:"value"`   // default "always"
+	CanaryPercent    float64 `mapstructure:"percent"` // 0-100
+	CanaryBackendURL string  `mapstructure:"backend_url"`
+}
+
+// Validate reports an unusable backend URL or percentage
+func (c CanaryConfig) Validate() error {
+	var errs []error
+	if u, err := url.Parse(c.CanaryBackendURL); err != nil || u.Scheme == "" || u.Host == "" {
+		errs = append(errs, fmt.Errorf("backend_url: must be an absolute URL, e.g. http://app-canary:8080 (got %q)", c.CanaryBackendURL))
+	}
+	if c.CanaryPercent < 0 || c.CanaryPercent > 100 {
+		errs = append(errs, fmt.Errorf("percent: must be between 0 and 100 (got %v)", c.CanaryPercent))
+	}
+	return errors.Join(errs...)
+}
+
+// Bucket maps a request ID to 0..99. The same ID always lands in the same
+// bucket, so retries of a request hit the same backend.
+func Bucket(requestID string) int {
+	h := fnv.New32a()
+	h.Write([]byte(requestID))
+	return int(h.Sum32() % 100)
+}
+
+// NewCanaryMiddleware proxies a request to the canary when it carries the
+// override header, or when the bucket of its request ID is below
+// CanaryPercent; everything else continues to next. Requests without a
+// request ID stay on the stable backend. Place it after the request ID
+// middleware. Panics when cfg fails Validate, which ValidateConfig runs
+// before any config, initial or reloaded, is applied.
+func NewCanaryMiddleware(cfg CanaryConfig) func(http.Handler) http.Handler {
+	if err := cfg.Validate(); err != nil {
+		panic("canary: " + err.Error())
+	}
+	target, _ := url.Parse(cfg.CanaryBackendURL)
+	if cfg.CanaryHeader == "" {
+		cfg.CanaryHeader = "X-Canary"
+	}
+	if cfg.CanaryValue == "" {
+		cfg.CanaryValue = "always"
+	}
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		reqctx.LoggerFromContext(r.Context()).Warn("canary backend unavailable", zap.Error(err))
+		errcodes.Write(w, errcodes.FromRequest(r).New("SERVICE_UNAVAILABLE"))
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logger := reqctx.LoggerFromContext(r.Context())
+			if r.Header.Get(cfg.CanaryHeader) == cfg.CanaryValue {
+				logger.Debug("routed to canary", zap.String("reason", "header"))
+				proxy.ServeHTTP(w, r)
+				return
+			}
+			id := reqctx.FromContext(r.Context()).RequestID
+			if id != "" && float64(Bucket(id)) < cfg.CanaryPercent {
+				logger.Debug("routed to canary", zap.String("reason", "percent"))
+				proxy.ServeHTTP(w, r)
+				return
+			}
+			logger.Debug("routed to stable")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
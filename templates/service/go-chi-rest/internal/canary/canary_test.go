@@ -0,0 +1,105 @@
+package canary
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/example/go-chi-rest/internal/reqctx"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     CanaryConfig
+		wantErr bool
+	}{
+		{"valid", CanaryConfig{CanaryBackendURL: "http://app-canary:8080", CanaryPercent: 5}, false},
+		{"empty url", CanaryConfig{}, true},
+		{"relative url", CanaryConfig{CanaryBackendURL: "app-canary:8080"}, true},
+		{"unparsable url", CanaryConfig{CanaryBackendURL: "http://[::1"}, true},
+		{"percent above 100", CanaryConfig{CanaryBackendURL: "http://c", CanaryPercent: 101}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCanaryMiddlewareHeader(t *testing.T) {
+	canary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("canary"))
+	}))
+	defer canary.Close()
+	h := NewCanaryMiddleware(CanaryConfig{CanaryBackendURL: canary.URL})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("stable"))
+	}))
+
+	for header, want := range map[string]string{"always": "canary", "": "stable", "never": "stable"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/ping", nil)
+		if header != "" {
+			req.Header.Set("X-Canary", header)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Body.String() != want {
+			t.Errorf("X-Canary %q: served by %s, want %s", header, rec.Body, want)
+		}
+	}
+}
+
+// Routing by percent depends only on the request ID, so the same ID always
+// reaches the same backend
+func TestCanaryMiddlewarePercent(t *testing.T) {
+	canary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("canary"))
+	}))
+	defer canary.Close()
+	stable := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("stable")) })
+
+	for _, percent := range []float64{0, 30, 100} {
+		t.Run(fmt.Sprint(percent), func(t *testing.T) {
+			h := reqctx.NewContextEnrichmentMiddleware()(NewCanaryMiddleware(CanaryConfig{CanaryBackendURL: canary.URL, CanaryPercent: percent})(stable))
+			serve := func(id string) string {
+				req := httptest.NewRequest(http.MethodGet, "/api/v1/ping", nil)
+				req.Header.Set(reqctx.RequestIDHeader, id)
+				rec := httptest.NewRecorder()
+				h.ServeHTTP(rec, req)
+				return rec.Body.String()
+			}
+			canaried := 0
+			for i := 0; i < 200; i++ {
+				id := fmt.Sprintf("req-%d", i)
+				want := "stable"
+				if float64(Bucket(id)) < percent {
+					want = "canary"
+					canaried++
+				}
+				if got := serve(id); got != want {
+					t.Fatalf("%s (bucket %d): served by %s, want %s", id, Bucket(id), got, want)
+				}
+				if again := serve(id); again != want {
+					t.Fatalf("%s: second request served by %s", id, again)
+				}
+			}
+			if percent == 30 && (canaried < 40 || canaried > 80) {
+				t.Errorf("%d of 200 requests to the canary at 30%%", canaried)
+			}
+		})
+	}
+}
+
+func TestBucket(t *testing.T) {
+	if Bucket("req-1") != Bucket("req-1") {
+		t.Fatal("Bucket is not deterministic")
+	}
+	for i := 0; i < 1000; i++ {
+		if b := Bucket(fmt.Sprint(i)); b < 0 || b > 99 {
+			t.Fatalf("Bucket(%d) = %d", i, b)
+		}
+	}
+}
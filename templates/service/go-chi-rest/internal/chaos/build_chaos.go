@@ -0,0 +1,7 @@
+//go:build chaos
+
+package chaos
+
+// BuiltWithChaos reports whether the binary was built with the chaos tag,
+// which lets faults.enabled in the config activate faults at startup
+func BuiltWithChaos() bool { return true }
@@ -0,0 +1,157 @@
+// Package chaos injects failures into request handling to rehearse how the
+// service and its clients cope: added latency and 503 responses for a
+// configurable fraction of requests. Faults are off unless the binary is
+// built with the chaos tag and faults.enabled is set, or an operator turns
+// them on with POST /admin/faults.
+package chaos
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+
+	"github.com/example/go-chi-rest/internal/errcodes"
+)
+
+var injections = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "fault_injections_total",
+	Help: "Faults injected into requests, by type (latency, error).",
+}, []string{"type"})
+
+// FaultConfig configures injected faults
+type FaultConfig struct {
+	Enabled     bool          `mapstructure:"enabled"`      // honoured at startup only in chaos builds
+	ErrorRate   float64       `mapstructure:"error_rate"`   // fraction of requests answered 503
+	Latency     time.Duration `mapstructure:"latency"`      // delay added to delayed requests
+	LatencyRate float64       `mapstructure:"latency_rate"` // fraction of requests delayed
+}
+
+// randFloat decides which requests get a fault; tests replace it with a
+// seeded source
+var randFloat = rand.Float64
+
+// active holds the faults in force; nil or disabled means none
+var active atomic.Pointer[FaultConfig]
+
+// Active returns the faults in force
+func Active() FaultConfig {
+	if cfg := active.Load(); cfg != nil {
+		return *cfg
+	}
+	return FaultConfig{}
+}
+
+func activate(cfg FaultConfig) {
+	active.Store(&cfg)
+}
+
+// NewFaultInjector returns middleware injecting the faults in force. cfg
+// becomes the initial state when the binary was built with the chaos tag;
+// otherwise faults start disabled and only POST /admin/faults turns them on.
+// cfg only seeds the state while none is in force, so rebuilding the router
+// on a config reload keeps faults set through /admin/faults.
+func NewFaultInjector(cfg FaultConfig) func(http.Handler) http.Handler {
+	switch {
+	case cfg.Enabled && BuiltWithChaos():
+		seed := cfg
+		if active.CompareAndSwap(nil, &seed) {
+			zap.L().Warn("fault injection enabled",
+				zap.Float64("error_rate", cfg.ErrorRate),
+				zap.Duration("latency", cfg.Latency), zap.Float64("latency_rate", cfg.LatencyRate))
+		}
+	case cfg.Enabled:
+		zap.L().Warn("faults.enabled ignored: build with -tags chaos or use POST /admin/faults")
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cfg := active.Load()
+			if cfg == nil || !cfg.Enabled {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if cfg.Latency > 0 && randFloat() < cfg.LatencyRate {
+				injections.WithLabelValues("latency").Inc()
+				timer := time.NewTimer(cfg.Latency)
+				select {
+				case <-timer.C:
+				case <-r.Context().Done():
+					timer.Stop()
+					return
+				}
+			}
+			if randFloat() < cfg.ErrorRate {
+				injections.WithLabelValues("error").Inc()
+				errcodes.Write(w, errcodes.FromRequest(r).New("SERVICE_UNAVAILABLE"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// faultState is the /admin/faults document; latency is a Go duration string
+type faultState struct {
+	Enabled     bool    `json:"enabled"`
+	ErrorRate   float64 `json:"error_rate"`
+	Latency     string  `json:"latency,omitempty"`
+	LatencyRate float64 `json:"latency_rate"`
+}
+
+// AdminHandler serves /admin/faults. GET returns the faults in force; POST
+// replaces them, e.g. {"enabled": true, "error_rate": 0.01} or
+// {"enabled": true, "latency": "250ms", "latency_rate": 0.1}, and
+// {"enabled": false} turns them off. Mount it behind the admin token.
+func AdminHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			var st faultState
+			if err := json.NewDecoder(r.Body).Decode(&st); err != nil {
+				errcodes.Write(w, errcodes.FromRequest(r).New("INVALID_REQUEST", "body must be a JSON object"))
+				return
+			}
+			cfg := FaultConfig{Enabled: st.Enabled, ErrorRate: st.ErrorRate, LatencyRate: st.LatencyRate}
+			if st.Latency != "" {
+				d, err := time.ParseDuration(st.Latency)
+				if err != nil || d < 0 {
+					errcodes.Write(w, errcodes.FromRequest(r).New("VALIDATION_FAILED", "latency", "must be a duration such as 250ms"))
+					return
+				}
+				cfg.Latency = d
+			}
+			for _, rate := range []struct {
+				field string
+				value float64
+			}{{"error_rate", cfg.ErrorRate}, {"latency_rate", cfg.LatencyRate}} {
+				if rate.value < 0 || rate.value > 1 {
+					errcodes.Write(w, errcodes.FromRequest(r).New("VALIDATION_FAILED", rate.field, "must be between 0 and 1"))
+					return
+				}
+			}
+			activate(cfg)
+			zap.L().Warn("fault injection changed",
+				zap.Bool("enabled", cfg.Enabled), zap.Float64("error_rate", cfg.ErrorRate),
+				zap.Duration("latency", cfg.Latency), zap.Float64("latency_rate", cfg.LatencyRate),
+				zap.String("remote_addr", r.RemoteAddr))
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		cfg := Active()
+		st := faultState{Enabled: cfg.Enabled, ErrorRate: cfg.ErrorRate, LatencyRate: cfg.LatencyRate}
+		if cfg.Latency > 0 {
+			st.Latency = cfg.Latency.String()
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(st)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build chaos
+
+package chaos
+
+import "testing"
+
+func TestFaultInjectorSeedsOnce(t *testing.T) {
+	t.Cleanup(func() { active.Store(nil) })
+	injector(FaultConfig{Enabled: true, ErrorRate: 0.5})
+	if got := Active(); !got.Enabled || got.ErrorRate != 0.5 {
+		t.Fatalf("seeded faults = %+v", got)
+	}
+	postFaults(t, `{"enabled": false}`)
+	injector(FaultConfig{Enabled: true, ErrorRate: 0.5})
+	if got := Active(); got.Enabled {
+		t.Errorf("reload re-enabled faults an operator turned off: %+v", got)
+	}
+}
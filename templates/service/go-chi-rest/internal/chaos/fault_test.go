@@ -0,0 +1,103 @@
+package chaos
+
+import (
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// postFaults sets the faults in force through the admin endpoint
+func postFaults(t *testing.T, body string) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	AdminHandler()(rec, httptest.NewRequest(http.MethodPost, "/admin/faults", strings.NewReader(body)))
+	return rec.Code
+}
+
+func injector(cfg FaultConfig) http.Handler {
+	return NewFaultInjector(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+}
+
+// errorCount sends n requests through h and counts the 503s
+func errorCount(h http.Handler, n int) int {
+	errs := 0
+	for i := 0; i < n; i++ {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/ping", nil))
+		if rec.Code == http.StatusServiceUnavailable {
+			errs++
+		}
+	}
+	return errs
+}
+
+func TestFaultInjectorErrorRate(t *testing.T) {
+	t.Cleanup(func() { active.Store(nil); randFloat = rand.Float64 })
+	// a shuffled 0, 0.001, ... 0.999: every 1000 requests see each value
+	// once, in random order, so the drawn fraction matches the rate
+	perm, i := rand.New(rand.NewSource(1)).Perm(1000), 0
+	randFloat = func() float64 {
+		v := float64(perm[i%len(perm)]) / 1000
+		i++
+		return v
+	}
+	h := injector(FaultConfig{})
+	if n := errorCount(h, 100); n != 0 {
+		t.Fatalf("%d errors with faults off", n)
+	}
+	if code := postFaults(t, `{"enabled": true, "error_rate": 0.1}`); code != http.StatusOK {
+		t.Fatalf("POST /admin/faults = %d", code)
+	}
+	before := testutil.ToFloat64(injections.WithLabelValues("error"))
+	n := errorCount(h, 1000)
+	if n < 90 || n > 110 {
+		t.Errorf("%d of 1000 requests failed, want 100 within 10%%", n)
+	}
+	if got := testutil.ToFloat64(injections.WithLabelValues("error")) - before; got != float64(n) {
+		t.Errorf("fault_injections_total{type=\"error\"} grew by %v, want %d", got, n)
+	}
+	if code := postFaults(t, `{"enabled": false}`); code != http.StatusOK {
+		t.Fatalf("POST /admin/faults = %d", code)
+	}
+	if n := errorCount(h, 100); n != 0 {
+		t.Errorf("%d errors after turning faults off", n)
+	}
+}
+
+func TestFaultInjectorReloadKeepsAdminState(t *testing.T) {
+	t.Cleanup(func() { active.Store(nil) })
+	injector(FaultConfig{Enabled: true, ErrorRate: 0.5})
+	postFaults(t, `{"enabled": true, "error_rate": 1}`)
+	// a config reload rebuilds the router
+	h := injector(FaultConfig{Enabled: true, ErrorRate: 0.5})
+	if got := Active(); !got.Enabled || got.ErrorRate != 1 {
+		t.Errorf("faults after reload = %+v, want the admin-set error_rate 1", got)
+	}
+	if n := errorCount(h, 20); n != 20 {
+		t.Errorf("%d of 20 requests failed, want all", n)
+	}
+}
+
+func TestAdminHandler(t *testing.T) {
+	t.Cleanup(func() { active.Store(nil) })
+	tests := []struct {
+		name, body string
+		want       int
+	}{
+		{"rate out of range", `{"enabled": true, "error_rate": 2}`, http.StatusBadRequest},
+		{"bad latency", `{"enabled": true, "latency": "soon"}`, http.StatusBadRequest},
+		{"not json", `enabled`, http.StatusBadRequest},
+		{"valid", `{"enabled": true, "latency": "10ms", "latency_rate": 0.5}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := postFaults(t, tt.body); code != tt.want {
+				t.Errorf("status = %d, want %d", code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+// Package concurrency limits in-flight requests with a limit that adapts to
+// the latency the server is observing
+package concurrency
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/example/go-chi-rest/internal/errcodes"
+)
+
+var (
+	limitGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "adaptive_concurrency_limit",
+		Help: "Current adaptive concurrency limit.",
+	})
+	inFlightGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "adaptive_concurrency_in_flight",
+		Help: "Requests currently holding an adaptive concurrency slot.",
+	})
+	droppedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "adaptive_concurrency_dropped_total",
+		Help: "Requests rejected because the adaptive concurrency limit was reached.",
+	})
+)
+
+// ErrLimitExceeded is returned by Acquire when no slot frees up in time
+var ErrLimitExceeded = errors.New("concurrency limit exceeded")
+
+// AdaptiveLimiterConfig configures the limiter
+type AdaptiveLimiterConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	InitialLimit int           `mapstructure:"initial_limit"` // default 100
+	MinLimit     int           `mapstructure:"min_limit"`     // default 10
+	MaxLimit     int           `mapstructure:"max_limit"`     // default 1000
+	MaxWait      time.Duration `mapstructure:"max_wait"`      // how long Acquire queues for a slot; 0 rejects at once
+	ProbeEvery   int           `mapstructure:"probe_every"`   // samples between no-load RTT resets; default 1000
+}
+
+func (c AdaptiveLimiterConfig) withDefaults() AdaptiveLimiterConfig {
+	if c.MinLimit <= 0 {
+		c.MinLimit = 10
+	}
+	if c.MaxLimit <= 0 {
+		c.MaxLimit = 1000
+	}
+	if c.MaxLimit < c.MinLimit {
+		c.MaxLimit = c.MinLimit
+	}
+	if c.InitialLimit <= 0 {
+		c.InitialLimit = 100
+	}
+	if c.InitialLimit < c.MinLimit {
+		c.InitialLimit = c.MinLimit
+	}
+	if c.InitialLimit > c.MaxLimit {
+		c.InitialLimit = c.MaxLimit
+	}
+	if c.ProbeEvery <= 0 {
+		c.ProbeEvery = 1000
+	}
+	return c
+}
+
+// Limiter is a TCP Vegas style concurrency limiter. The lowest RTT seen is
+// taken as the no-load latency; by Little's Law, limit·(1 − rttNoLoad/rtt)
+// requests are then queueing rather than being served. The limit grows while
+// that estimate stays under alpha = 3·log10(limit) and shrinks once it
+// exceeds beta = 6·log10(limit). The no-load RTT is re-learned every
+// ProbeEvery samples so a permanent latency change is not read as queueing.
+type Limiter struct {
+	cfg AdaptiveLimiterConfig
+
+	mu        sync.Mutex
+	limit     float64
+	inFlight  int
+	rttNoLoad time.Duration
+	samples   int
+	waiters   list.List // of chan struct{}, closed when granted a slot
+}
+
+// NewLimiter returns a limiter starting at cfg.InitialLimit
+func NewLimiter(cfg AdaptiveLimiterConfig) *Limiter {
+	cfg = cfg.withDefaults()
+	l := &Limiter{cfg: cfg, limit: float64(cfg.InitialLimit)}
+	limitGauge.Set(l.limit)
+	return l
+}
+
+// Limit returns the current limit
+func (l *Limiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+// Acquire takes a slot, waiting up to MaxWait (or until ctx is done) for one
+// to free up, and returns ErrLimitExceeded otherwise. The returned function
+// releases the slot; the time between the two calls is the RTT sample.
+func (l *Limiter) Acquire(ctx context.Context) (func(), error) {
+	l.mu.Lock()
+	if l.inFlight < int(l.limit) && l.waiters.Len() == 0 {
+		l.inFlight++
+		inFlightGauge.Set(float64(l.inFlight))
+		l.mu.Unlock()
+		return l.releaser(), nil
+	}
+	if l.cfg.MaxWait <= 0 {
+		l.mu.Unlock()
+		droppedTotal.Inc()
+		return nil, ErrLimitExceeded
+	}
+	granted := make(chan struct{})
+	el := l.waiters.PushBack(granted)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(l.cfg.MaxWait)
+	defer timer.Stop()
+	select {
+	case <-granted:
+		return l.releaser(), nil
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	l.mu.Lock()
+	select {
+	case <-granted:
+		// handed a slot while giving up; keep it
+		l.mu.Unlock()
+		return l.releaser(), nil
+	default:
+		l.waiters.Remove(el)
+		l.mu.Unlock()
+	}
+	droppedTotal.Inc()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return nil, ErrLimitExceeded
+}
+
+func (l *Limiter) releaser() func() {
+	start := time.Now()
+	var once sync.Once
+	return func() {
+		once.Do(func() { l.release(time.Since(start)) })
+	}
+}
+
+func (l *Limiter) release(rtt time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.update(rtt, l.inFlight)
+	l.inFlight--
+	for l.inFlight < int(l.limit) && l.waiters.Len() > 0 {
+		granted := l.waiters.Remove(l.waiters.Front()).(chan struct{})
+		l.inFlight++
+		close(granted)
+	}
+	inFlightGauge.Set(float64(l.inFlight))
+}
+
+// update applies one RTT sample taken with inFlight requests running
+func (l *Limiter) update(rtt time.Duration, inFlight int) {
+	if rtt <= 0 {
+		return
+	}
+	l.samples++
+	if l.rttNoLoad == 0 || rtt < l.rttNoLoad || l.samples%l.cfg.ProbeEvery == 0 {
+		l.rttNoLoad = rtt
+		return
+	}
+
+	logLimit := math.Max(1, math.Log10(l.limit))
+	alpha, beta := 3*logLimit, 6*logLimit
+	queue := math.Ceil(l.limit * (1 - float64(l.rttNoLoad)/float64(rtt)))
+	limit := l.limit
+	switch {
+	case queue <= logLimit:
+		// nearly no queueing: grow fast, unless the load does not use the limit
+		if inFlight*2 >= int(l.limit) {
+			limit += beta
+		}
+	case queue < alpha:
+		if inFlight*2 >= int(l.limit) {
+			limit += logLimit
+		}
+	case queue > beta:
+		limit -= logLimit
+	}
+	limit = math.Max(float64(l.cfg.MinLimit), math.Min(float64(l.cfg.MaxLimit), limit))
+	if limit != l.limit {
+		l.limit = limit
+		limitGauge.Set(limit)
+	}
+}
+
+// NewAdaptiveLimiter returns middleware that holds a Limiter slot for the
+// duration of each request and answers 503 SERVICE_UNAVAILABLE with
+// Retry-After: 1 when none is available
+func NewAdaptiveLimiter(cfg AdaptiveLimiterConfig) func(http.Handler) http.Handler {
+	l := NewLimiter(cfg)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			release, err := l.Acquire(r.Context())
+			if err != nil {
+				w.Header().Set("Retry-After", "1")
+				errcodes.Write(w, errcodes.FromRequest(r).New("SERVICE_UNAVAILABLE"))
+				return
+			}
+			defer release()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// A server that handles capacity requests in baseRTT; beyond that requests
+// queue and the RTT grows with the load
+func simulatedRTT(load, capacity int, baseRTT time.Duration) time.Duration {
+	if load <= capacity {
+		return baseRTT
+	}
+	return baseRTT * time.Duration(load) / time.Duration(capacity)
+}
+
+func TestLimiterShrinksAsQueueingGrows(t *testing.T) {
+	l := NewLimiter(AdaptiveLimiterConfig{InitialLimit: 100, MinLimit: 5, MaxLimit: 200})
+	const capacity, base = 20, 10 * time.Millisecond
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.update(base, 1) // learn the no-load RTT
+
+	prev := l.limit
+	for load := capacity; load <= 200; load += 20 {
+		for i := 0; i < 10; i++ {
+			l.update(simulatedRTT(load, capacity, base), load)
+		}
+		if l.limit > prev {
+			t.Fatalf("load %d: limit grew from %v to %v while queueing", load, prev, l.limit)
+		}
+		prev = l.limit
+	}
+	if l.limit >= 100 {
+		t.Errorf("limit = %v, want it below the initial 100", l.limit)
+	}
+	if got := testutil.ToFloat64(limitGauge); got != l.limit {
+		t.Errorf("adaptive_concurrency_limit = %v, want %v", got, l.limit)
+	}
+}
+
+func TestLimiterGrowsWithoutQueueing(t *testing.T) {
+	tests := []struct {
+		name     string
+		inFlight int
+		grow     bool
+	}{
+		{"limit in use", 20, true},
+		{"limit unused", 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLimiter(AdaptiveLimiterConfig{InitialLimit: 20, MinLimit: 5, MaxLimit: 40})
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.update(10*time.Millisecond, 1)
+			for i := 0; i < 50; i++ {
+				l.update(10*time.Millisecond, tt.inFlight)
+			}
+			if tt.grow && l.limit != 40 {
+				t.Errorf("limit = %v, want MaxLimit 40", l.limit)
+			}
+			if !tt.grow && l.limit != 20 {
+				t.Errorf("limit = %v, want it to stay at 20", l.limit)
+			}
+		})
+	}
+}
+
+func TestAcquire(t *testing.T) {
+	l := NewLimiter(AdaptiveLimiterConfig{InitialLimit: 1, MinLimit: 1, MaxWait: time.Second})
+	release, err := l.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a queued request gets the slot when it is released
+	got := make(chan error, 1)
+	go func() {
+		r, err := l.Acquire(context.Background())
+		if err == nil {
+			r()
+		}
+		got <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	release()
+	release() // releasing twice frees one slot
+	if err := <-got; err != nil {
+		t.Fatalf("queued Acquire = %v", err)
+	}
+
+	// a cancelled wait gives up with the context error
+	release, _ = l.Acquire(context.Background())
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Acquire = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestAdaptiveLimiterMiddleware(t *testing.T) {
+	entered, unblock := make(chan struct{}), make(chan struct{})
+	h := NewAdaptiveLimiter(AdaptiveLimiterConfig{InitialLimit: 1, MinLimit: 1})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-unblock
+	}))
+	done := make(chan struct{})
+	go func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/ping", nil))
+		close(done)
+	}()
+	<-entered
+
+	dropped := testutil.ToFloat64(droppedTotal)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/ping", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "1" {
+		t.Errorf("over the limit: %d Retry-After %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if got := testutil.ToFloat64(droppedTotal); got != dropped+1 {
+		t.Errorf("adaptive_concurrency_dropped_total = %v, want %v", got, dropped+1)
+	}
+	close(unblock)
+	<-done
+}
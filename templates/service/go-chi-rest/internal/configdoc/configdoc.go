@@ -0,0 +1,333 @@
+// Package configdoc renders a configuration struct as a Markdown reference.
+// Keys and types come from reflection over the mapstructure tags, and
+// descriptions from the doc and line comments of the fields in the source.
+package configdoc
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var modulePath = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+
+// Options control Generate
+type Options struct {
+	Title     string               // document heading
+	Intro     string               // Markdown placed under the heading
+	Root      string               // module root, holding go.mod; field comments are read from its packages
+	EnvPrefix string               // viper env prefix, e.g. APP
+	Default   func(key string) any // default of a leaf key, nil when unset
+}
+
+// Row is one leaf key
+type Row struct {
+	Key, Type, Default, Description, Env string
+}
+
+// Section is a table of leaf keys; Key is empty for the top level
+type Section struct {
+	Key  string
+	Doc  string
+	Rows []Row
+}
+
+type fieldComment struct{ doc, line string }
+
+// Collect walks t, a struct type, and returns its sections sorted by key,
+// the top level first, each with its rows sorted by key
+func Collect(t reflect.Type, opts Options) ([]Section, error) {
+	comments, err := loadComments(opts.Root)
+	if err != nil {
+		return nil, err
+	}
+	c := collector{opts: opts, comments: comments, sections: map[string]*Section{}}
+	c.walk(t, "", "")
+	out := make([]Section, 0, len(c.sections))
+	for _, s := range c.sections {
+		sort.Slice(s.Rows, func(i, j int) bool { return s.Rows[i].Key < s.Rows[j].Key })
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out, nil
+}
+
+// Generate renders t as Markdown: one table with the columns Key, Type,
+// Default, Description and Environment Variable per struct, nested structs
+// as sub-sections. The output only depends on the source and defaults.
+func Generate(t reflect.Type, opts Options) ([]byte, error) {
+	sections, err := Collect(t, opts)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# %s\n\n", opts.Title)
+	if opts.Intro != "" {
+		b.WriteString(strings.TrimSpace(opts.Intro) + "\n\n")
+	}
+	for _, s := range sections {
+		if s.Key == "" {
+			b.WriteString("## Top-level keys\n\n")
+		} else {
+			depth := 1 + strings.Count(s.Key, ".")
+			if depth > 4 {
+				depth = 4
+			}
+			fmt.Fprintf(&b, "%s `%s`\n\n", strings.Repeat("#", depth+1), s.Key)
+			if s.Doc != "" {
+				b.WriteString(cell(s.Doc) + "\n\n")
+			}
+		}
+		b.WriteString("| Key | Type | Default | Description | Environment Variable |\n")
+		b.WriteString("| --- | --- | --- | --- | --- |\n")
+		for _, r := range s.Rows {
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s | %s |\n",
+				r.Key, cell(r.Type), code(r.Default), cell(r.Description), code(r.Env))
+		}
+		b.WriteString("\n")
+	}
+	return b.Bytes(), nil
+}
+
+type collector struct {
+	opts     Options
+	comments map[string]fieldComment // "pkgpath.Type.Field"
+	sections map[string]*Section
+}
+
+func (c *collector) section(key, docText string) *Section {
+	s, ok := c.sections[key]
+	if !ok {
+		s = &Section{Key: key, Doc: docText}
+		c.sections[key] = s
+	}
+	return s
+}
+
+// walk adds the fields of struct type t, found under prefix, to the section
+// named prefix
+func (c *collector) walk(t reflect.Type, prefix, sectionDoc string) {
+	s := c.section(prefix, sectionDoc)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("mapstructure"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && strings.Contains(opts, "squash") {
+			c.walk(indirect(f.Type), prefix, sectionDoc)
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		desc := c.describe(t, f)
+
+		ft := indirect(f.Type)
+		switch {
+		case isSection(ft):
+			c.walk(ft, key, desc)
+			continue
+		}
+		row := Row{Key: key, Type: typeName(f.Type), Default: c.defaultOf(key), Description: desc}
+		if ft.Kind() == reflect.Slice && isSection(indirect(ft.Elem())) {
+			// lists of objects only come from config files
+			c.walk(indirect(ft.Elem()), key+"[]", desc)
+		} else {
+			row.Env = c.env(key)
+		}
+		s.Rows = append(s.Rows, row)
+	}
+}
+
+func (c *collector) describe(owner reflect.Type, f reflect.StructField) string {
+	fc := c.comments[owner.PkgPath()+"."+owner.Name()+"."+f.Name]
+	switch {
+	case fc.doc != "" && fc.line != "":
+		return fc.doc + " (" + fc.line + ")"
+	case fc.doc != "":
+		return fc.doc
+	}
+	return fc.line
+}
+
+func (c *collector) defaultOf(key string) string {
+	if c.opts.Default == nil || strings.Contains(key, "[]") {
+		return ""
+	}
+	v := c.opts.Default(key)
+	if v == nil {
+		return ""
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice {
+		parts := make([]string, rv.Len())
+		for i := range parts {
+			parts[i] = fmt.Sprint(rv.Index(i).Interface())
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	}
+	return fmt.Sprint(v)
+}
+
+// env is the variable viper's AutomaticEnv reads for key
+func (c *collector) env(key string) string {
+	if c.opts.EnvPrefix == "" || strings.Contains(key, "[]") {
+		return ""
+	}
+	return c.opts.EnvPrefix + "_" + strings.ToUpper(key)
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+var timeType = reflect.TypeOf(time.Time{})
+
+// isSection reports whether t is documented as its own section rather than
+// as a single value
+func isSection(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != timeType
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+func typeName(t reflect.Type) string {
+	t = indirect(t)
+	switch {
+	case t == durationType:
+		return "duration"
+	case t == timeType:
+		return "time (RFC 3339)"
+	}
+	switch t.Kind() {
+	case reflect.Slice:
+		if isSection(indirect(t.Elem())) {
+			return "list of objects"
+		}
+		return "list of " + typeName(t.Elem())
+	case reflect.Map:
+		return "map of " + typeName(t.Key()) + " to " + typeName(t.Elem())
+	case reflect.Interface:
+		return "any"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	}
+	return t.Kind().String()
+}
+
+// loadComments reads the doc and line comments of every struct field in the
+// packages under root, keyed by "importpath.Type.Field"
+func loadComments(root string) (map[string]fieldComment, error) {
+	mod, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return nil, fmt.Errorf("configdoc: %w", err)
+	}
+	m := modulePath.FindSubmatch(mod)
+	if m == nil {
+		return nil, fmt.Errorf("configdoc: no module line in %s", filepath.Join(root, "go.mod"))
+	}
+	module := string(m[1])
+
+	comments := map[string]fieldComment{}
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		if path != root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "vendor" || d.Name() == "testdata") {
+			return filepath.SkipDir
+		}
+		fset := token.NewFileSet()
+		pkgs, err := parser.ParseDir(fset, path, func(fi fs.FileInfo) bool {
+			return !strings.HasSuffix(fi.Name(), "_test.go")
+		}, parser.ParseComments)
+		if err != nil {
+			return fmt.Errorf("configdoc: %w", err)
+		}
+		rel, _ := filepath.Rel(root, path)
+		importPath := module
+		if rel != "." {
+			importPath += "/" + filepath.ToSlash(rel)
+		}
+		for _, pkg := range pkgs {
+			for _, t := range doc.New(pkg, importPath, doc.AllDecls).Types {
+				addFieldComments(comments, importPath+"."+t.Name, t.Decl)
+			}
+		}
+		return nil
+	})
+	return comments, err
+}
+
+func addFieldComments(comments map[string]fieldComment, prefix string, decl *ast.GenDecl) {
+	for _, spec := range decl.Specs {
+		ts, ok := spec.(*ast.TypeSpec)
+		if !ok {
+			continue
+		}
+		st, ok := ts.Type.(*ast.StructType)
+		if !ok {
+			continue
+		}
+		for _, f := range st.Fields.List {
+			fc := fieldComment{doc: oneLine(f.Doc), line: oneLine(f.Comment)}
+			for _, n := range f.Names {
+				comments[prefix+"."+n.Name] = fc
+			}
+			if len(f.Names) == 0 { // embedded
+				comments[prefix+"."+embeddedName(f.Type)] = fc
+			}
+		}
+	}
+}
+
+func embeddedName(e ast.Expr) string {
+	switch t := e.(type) {
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+func oneLine(cg *ast.CommentGroup) string {
+	if cg == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(cg.Text()), " ")
+}
+
+// cell escapes text for a table cell
+func cell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+func code(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + cell(strings.ReplaceAll(s, "`", "'")) + "`"
+}
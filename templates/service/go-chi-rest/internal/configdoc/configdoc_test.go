@@ -0,0 +1,151 @@
+package configdoc
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+
+	"github.com/example/go-chi-rest/internal/server"
+)
+
+// leafKeys lists the keys a table row is expected for, walking the
+// mapstructure tags independently of the collector
+func leafKeys(t reflect.Type, prefix string, keys map[string]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("mapstructure"), ",")
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		if f.Anonymous && strings.Contains(opts, "squash") {
+			leafKeys(indirect(f.Type), prefix, keys)
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		ft := indirect(f.Type)
+		switch {
+		case ft.Kind() == reflect.Struct && ft != reflect.TypeOf(time.Time{}):
+			leafKeys(ft, name, keys)
+		case ft.Kind() == reflect.Slice && indirect(ft.Elem()).Kind() == reflect.Struct:
+			keys[name] = true
+			leafKeys(indirect(ft.Elem()), name+"[]", keys)
+		default:
+			keys[name] = true
+		}
+	}
+}
+
+// tableKeys returns the text of the first cell of every table body row
+func tableKeys(md []byte) map[string]bool {
+	keys := map[string]bool{}
+	doc := parser.NewWithExtensions(parser.CommonExtensions).Parse(md)
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		row, ok := node.(*ast.TableRow)
+		if !entering || !ok {
+			return ast.GoToNext
+		}
+		if _, body := row.GetParent().(*ast.TableBody); !body {
+			return ast.SkipChildren
+		}
+		var text bytes.Buffer
+		ast.WalkFunc(row.GetChildren()[0], func(n ast.Node, entering bool) ast.WalkStatus {
+			if leaf := n.AsLeaf(); entering && leaf != nil {
+				text.Write(leaf.Literal)
+			}
+			return ast.GoToNext
+		})
+		keys[text.String()] = true
+		return ast.SkipChildren
+	})
+	return keys
+}
+
+func generate(t *testing.T) []byte {
+	t.Helper()
+	server.RegisterDefaults()
+	md, err := Generate(reflect.TypeOf(server.ServerConfig{}), Options{Title: "Configuration reference", Root: "../..", EnvPrefix: "APP"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return md
+}
+
+func TestGenerateHasRowPerField(t *testing.T) {
+	want := map[string]bool{}
+	leafKeys(reflect.TypeOf(server.ServerConfig{}), "", want)
+	if len(want) < 50 {
+		t.Fatalf("found only %d ServerConfig keys", len(want))
+	}
+	got := tableKeys(generate(t))
+	for key := range want {
+		if !got[key] {
+			t.Errorf("no table row for %s", key)
+		}
+	}
+	for key := range got {
+		if !want[key] {
+			t.Errorf("row for unknown key %s", key)
+		}
+	}
+}
+
+func TestGenerateReproducible(t *testing.T) {
+	if first, second := generate(t), generate(t); !bytes.Equal(first, second) {
+		t.Error("two runs produced different output")
+	}
+}
+
+func TestCollect(t *testing.T) {
+	type inner struct {
+		Timeout time.Duration `mapstructure:"timeout"`
+	}
+	type Base struct {
+		Name string `mapstructure:"name"`
+	}
+	type cfg struct {
+		Base    `mapstructure:",squash"`
+		Zeta    int               `mapstructure:"zeta"`
+		Alpha   []string          `mapstructure:"alpha"`
+		Inner   *inner            `mapstructure:"inner"`
+		Items   []inner           `mapstructure:"items"`
+		Labels  map[string]string `mapstructure:"labels"`
+		Skipped string            `mapstructure:"-"`
+	}
+	sections, err := Collect(reflect.TypeOf(cfg{}), Options{Root: "../..", EnvPrefix: "APP", Default: func(key string) any {
+		if key == "zeta" {
+			return 3
+		}
+		return nil
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range sections {
+		for _, r := range s.Rows {
+			got = append(got, strings.Join([]string{r.Key, r.Type, r.Default, r.Env}, "|"))
+		}
+	}
+	want := []string{
+		"alpha|list of string||APP_ALPHA",
+		"items|list of objects||",
+		"labels|map of string to string||APP_LABELS",
+		"name|string||APP_NAME",
+		"zeta|int|3|APP_ZETA",
+		"inner.timeout|duration||APP_INNER.TIMEOUT",
+		"items[].timeout|duration||",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("rows:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
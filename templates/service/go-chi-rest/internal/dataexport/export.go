@@ -0,0 +1,311 @@
+// Package dataexport serves a user's personal data as a signed ZIP archive
+// (GDPR art. 15/20). Each DataSource contributes records; records are written
+// to one JSON file per category.
+package dataexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/example/go-chi-rest/internal/authn"
+	"github.com/example/go-chi-rest/internal/errcodes"
+	"github.com/example/go-chi-rest/internal/negotiate"
+	"github.com/example/go-chi-rest/internal/reqctx"
+	"github.com/example/go-chi-rest/internal/worker"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the archive
+const SignatureHeader = "X-Data-Export-Signature"
+
+// DataRecord is one item of personal data
+type DataRecord struct {
+	Category string // file in the archive, e.g. "orders" for orders.json
+	Data     any    // marshalled as JSON
+}
+
+// DataSource collects a user's records from one store
+type DataSource interface {
+	Collect(ctx context.Context, userID string) ([]DataRecord, error)
+}
+
+// ExportConfig configures GET /api/v1/me/export
+type ExportConfig struct {
+	Enabled                bool          `mapstructure:"enabled"`
+	Secret                 string        `mapstructure:"secret"`                    // HMAC key for SignatureHeader
+	DirectExportMaxRecords int           `mapstructure:"direct_export_max_records"` // larger exports are built on the worker pool; default 10000
+	ResultTTL              time.Duration `mapstructure:"result_ttl"`                // how long a queued archive can be fetched; default 1h
+
+	Sources []DataSource `mapstructure:"-"`
+	Pool    *worker.Pool `mapstructure:"-"` // nil builds every export in the request
+}
+
+// Handler serves the export routes
+type Handler struct {
+	cfg   ExportConfig
+	store *resultStore
+}
+
+// NewExportHandler returns the handler for cfg
+func NewExportHandler(cfg ExportConfig) *Handler {
+	if cfg.DirectExportMaxRecords <= 0 {
+		cfg.DirectExportMaxRecords = 10000
+	}
+	if cfg.ResultTTL <= 0 {
+		cfg.ResultTTL = time.Hour
+	}
+	return &Handler{cfg: cfg, store: newResultStore(cfg.ResultTTL)}
+}
+
+// Export serves GET /me/export for the user authenticated by
+// authn.Authenticator; anonymous requests get 401. Up to DirectExportMaxRecords records are archived in the request;
+// above that the archive is built on the worker pool and the response is 202
+// with a Location to poll.
+func (h *Handler) Export(w http.ResponseWriter, r *http.Request) {
+	userID := authn.Subject(r.Context())
+	if userID == "" {
+		errcodes.Write(w, errcodes.FromRequest(r).New("UNAUTHORIZED"))
+		return
+	}
+	log := reqctx.LoggerFromContext(r.Context())
+	records, err := h.collect(r.Context(), userID)
+	if err != nil {
+		log.Error("data export collection failed", zap.Error(err))
+		errcodes.Write(w, errcodes.FromRequest(r).New(errcodes.CodeInternal))
+		return
+	}
+	created := time.Now().UTC()
+
+	if len(records) <= h.cfg.DirectExportMaxRecords || h.cfg.Pool == nil {
+		archive, err := BuildArchive(records)
+		if err != nil {
+			log.Error("data export archive failed", zap.Error(err))
+			errcodes.Write(w, errcodes.FromRequest(r).New(errcodes.CodeInternal))
+			return
+		}
+		h.writeArchive(w, userID, created, archive)
+		return
+	}
+
+	id := uuid.NewString()
+	h.store.put(id, &result{userID: userID, created: created})
+	job := &exportJob{id: id, records: records, handler: h}
+	if err := h.cfg.Pool.Submit(job); err != nil {
+		h.store.remove(id)
+		log.Warn("data export not queued", zap.Error(err))
+		errcodes.Write(w, errcodes.FromRequest(r).New("SERVICE_UNAVAILABLE"))
+		return
+	}
+	location := strings.TrimSuffix(r.URL.Path, "/") + "/" + id
+	w.Header().Set("Location", location)
+	negotiate.WriteResponse(w, http.StatusAccepted, map[string]any{
+		"id":      id,
+		"status":  "pending",
+		"records": len(records),
+		"href":    location,
+	}, negotiate.AcceptedType(r.Context()))
+}
+
+// Status serves GET /me/export/{id}: 202 while the archive is being built,
+// then the archive itself. Exports queued by anyone but the authenticated
+// user are reported as not found.
+func (h *Handler) Status(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	userID := authn.Subject(r.Context())
+	if userID == "" {
+		errcodes.Write(w, errcodes.FromRequest(r).New("UNAUTHORIZED"))
+		return
+	}
+	res, ok := h.store.get(id)
+	if !ok || res.userID != userID {
+		errcodes.Write(w, errcodes.FromRequest(r).New("RESOURCE_NOT_FOUND", "export", id))
+		return
+	}
+	switch {
+	case res.err != nil:
+		errcodes.Write(w, errcodes.FromRequest(r).New(errcodes.CodeInternal))
+	case res.archive == nil:
+		negotiate.WriteResponse(w, http.StatusAccepted, map[string]any{"id": id, "status": "pending"}, negotiate.AcceptedType(r.Context()))
+	default:
+		h.writeArchive(w, userID, res.created, res.archive)
+	}
+}
+
+func (h *Handler) collect(ctx context.Context, userID string) ([]DataRecord, error) {
+	var all []DataRecord
+	for _, src := range h.cfg.Sources {
+		records, err := src.Collect(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("%T: %w", src, err)
+		}
+		all = append(all, records...)
+	}
+	return all, nil
+}
+
+func (h *Handler) writeArchive(w http.ResponseWriter, userID string, created time.Time, archive []byte) {
+	name := fmt.Sprintf("data-export-%s-%s.zip", safeFilename(userID), created.Format("20060102T150405Z"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	w.Header().Set("Content-Length", fmt.Sprint(len(archive)))
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set(SignatureHeader, Sign(h.cfg.Secret, archive))
+	w.WriteHeader(http.StatusOK)
+	w.Write(archive)
+}
+
+// BuildArchive writes records grouped by category to <category>.json files,
+// each holding a JSON array, in a ZIP archive
+func BuildArchive(records []DataRecord) ([]byte, error) {
+	byCategory := map[string][]any{}
+	for _, rec := range records {
+		category := rec.Category
+		if category == "" {
+			category = "other"
+		}
+		byCategory[category] = append(byCategory[category], rec.Data)
+	}
+	categories := make([]string, 0, len(byCategory))
+	for c := range byCategory {
+		categories = append(categories, c)
+	}
+	sort.Strings(categories)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, c := range categories {
+		f, err := zw.Create(safeFilename(c) + ".json")
+		if err != nil {
+			return nil, err
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(byCategory[c]); err != nil {
+			return nil, fmt.Errorf("category %s: %w", c, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Sign returns the hex HMAC-SHA256 of archive under secret
+func Sign(secret string, archive []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(archive)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the SignatureHeader value for archive
+func Verify(secret string, archive []byte, signature string) bool {
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(archive)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// safeFilename keeps letters, digits, '-', '_' and '.'
+func safeFilename(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// exportJob builds a queued archive on the worker pool
+type exportJob struct {
+	id      string
+	records []DataRecord
+	handler *Handler
+}
+
+var _ worker.Runnable = (*exportJob)(nil)
+
+func (j *exportJob) ID() string      { return "data-export-" + j.id }
+func (j *exportJob) Payload() []byte { return nil }
+
+// Run implements worker.Runnable
+func (j *exportJob) Run(context.Context) error {
+	archive, err := BuildArchive(j.records)
+	j.handler.store.finish(j.id, archive, err)
+	if err != nil {
+		return fmt.Errorf("data export %s: %w", j.id, err)
+	}
+	return nil
+}
+
+// result is a queued export; archive and err are nil while it is pending
+type result struct {
+	userID  string
+	created time.Time
+	archive []byte
+	err     error
+}
+
+// resultStore keeps queued exports in memory for ttl, so the poll has to reach
+// the replica that queued the job
+type resultStore struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	results map[string]*result
+}
+
+func newResultStore(ttl time.Duration) *resultStore {
+	return &resultStore{ttl: ttl, results: map[string]*result{}}
+}
+
+func (s *resultStore) put(id string, res *result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, v := range s.results {
+		if time.Since(v.created) > s.ttl {
+			delete(s.results, k)
+		}
+	}
+	s.results[id] = res
+}
+
+func (s *resultStore) get(id string) (result, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res, ok := s.results[id]
+	if !ok || time.Since(res.created) > s.ttl {
+		return result{}, false
+	}
+	return *res, true
+}
+
+func (s *resultStore) finish(id string, archive []byte, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if res, ok := s.results[id]; ok {
+		res.archive, res.err = archive, err
+	}
+}
+
+func (s *resultStore) remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.results, id)
+}
@@ -0,0 +1,128 @@
+package dataexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/example/go-chi-rest/internal/authn"
+)
+
+type sourceFunc func(ctx context.Context, userID string) ([]DataRecord, error)
+
+func (f sourceFunc) Collect(ctx context.Context, userID string) ([]DataRecord, error) {
+	return f(ctx, userID)
+}
+
+func asUser(r *http.Request, userID string) *http.Request {
+	return r.WithContext(authn.WithPrincipal(r.Context(), authn.Principal{Subject: userID}))
+}
+
+func TestExport(t *testing.T) {
+	const secret = "export-secret"
+	var collectedFor []string
+	h := NewExportHandler(ExportConfig{
+		Enabled: true,
+		Secret:  secret,
+		Sources: []DataSource{
+			sourceFunc(func(_ context.Context, userID string) ([]DataRecord, error) {
+				collectedFor = append(collectedFor, userID)
+				return []DataRecord{{Category: "profile", Data: map[string]string{"name": "Ada"}}}, nil
+			}),
+			sourceFunc(func(_ context.Context, userID string) ([]DataRecord, error) {
+				collectedFor = append(collectedFor, userID)
+				return []DataRecord{{Category: "orders", Data: 1}, {Category: "orders", Data: 2}}, nil
+			}),
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/me/export", nil)
+	req.Header.Set("X-User-ID", "someone-else")
+	rec := httptest.NewRecorder()
+	h.Export(rec, asUser(req, "user-1"))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	archive := rec.Body.Bytes()
+	if !Verify(secret, archive, rec.Header().Get(SignatureHeader)) {
+		t.Error("signature does not verify")
+	}
+	if Verify("other", archive, rec.Header().Get(SignatureHeader)) {
+		t.Error("signature verifies under the wrong secret")
+	}
+	if cd := rec.Header().Get("Content-Disposition"); !strings.HasPrefix(cd, `attachment; filename="data-export-user-1-`) {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+	if len(names) != 2 || names[0] != "orders.json" || names[1] != "profile.json" {
+		t.Errorf("archive entries = %v, want [orders.json profile.json]", names)
+	}
+	for _, u := range collectedFor {
+		if u != "user-1" {
+			t.Errorf("collected for %q, want the authenticated user-1", u)
+		}
+	}
+}
+
+func TestExportNeedsPrincipal(t *testing.T) {
+	h := NewExportHandler(ExportConfig{Enabled: true, Secret: "s"})
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/me/export", nil)
+	req.Header.Set("X-User-ID", "victim")
+	rec := httptest.NewRecorder()
+	h.Export(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", rec.Code)
+	}
+}
+
+func TestStatusOwnership(t *testing.T) {
+	h := NewExportHandler(ExportConfig{Enabled: true, Secret: "s"})
+	h.store.put("exp-1", &result{userID: "user-1", created: time.Now(), archive: []byte("zip")})
+
+	r := chi.NewRouter()
+	r.Get("/me/export/{id}", h.Status)
+	tests := []struct {
+		name    string
+		userID  string
+		headers map[string]string
+		want    int
+	}{
+		{"owner", "user-1", nil, http.StatusOK},
+		{"other user", "user-2", nil, http.StatusNotFound},
+		{"other user claiming the owner's id", "user-2", map[string]string{"X-User-ID": "user-1"}, http.StatusNotFound},
+		{"anonymous with the owner's id", "", map[string]string{"X-User-ID": "user-1"}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/me/export/exp-1", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			if tt.userID != "" {
+				req = asUser(req, tt.userID)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+var poolMaxConns = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "db_pool_max_conns",
+	Help: "Concurrent primary connections allowed by the auto-tuner.",
+})
+
+// PoolAutoTuneConfig configures PoolAutoTuner
+type PoolAutoTuneConfig struct {
+	Enabled               bool          `mapstructure:"enabled"`
+	AdjustInterval        time.Duration `mapstructure:"adjust_interval"`         // default 30s
+	TargetAcquireDuration time.Duration `mapstructure:"target_acquire_duration"` // default 5ms
+	StepSize              int32         `mapstructure:"step_size"`               // default 2
+	MinConns              int32         `mapstructure:"min_conns"`               // floor; default 4
+	MaxConns              int32         `mapstructure:"max_conns"`               // ceiling; default 50
+}
+
+func (c PoolAutoTuneConfig) withDefaults() PoolAutoTuneConfig {
+	if c.AdjustInterval <= 0 {
+		c.AdjustInterval = 30 * time.Second
+	}
+	if c.TargetAcquireDuration <= 0 {
+		c.TargetAcquireDuration = 5 * time.Millisecond
+	}
+	if c.StepSize <= 0 {
+		c.StepSize = 2
+	}
+	if c.MinConns <= 0 {
+		c.MinConns = 4
+	}
+	if c.MaxConns <= 0 {
+		c.MaxConns = 50
+	}
+	if c.MaxConns < c.MinConns {
+		c.MaxConns = c.MinConns
+	}
+	return c
+}
+
+// PoolStats are the pool counters the tuner reads; AcquireCount and
+// AcquireDuration are cumulative
+type PoolStats struct {
+	AcquireCount    int64
+	AcquireDuration time.Duration
+	AcquiredConns   int32
+	MaxConns        int32
+}
+
+// TunablePool is what PoolAutoTuner adjusts; ReplicaPool implements it for
+// its primary
+type TunablePool interface {
+	Stats() PoolStats
+	Resize(ctx context.Context, maxConns int32) error
+}
+
+// Stats implements TunablePool for the primary pool. With auto-tuning the
+// figures are the limiter's: time waited for a slot, slots held, the limit.
+func (p *ReplicaPool) Stats() PoolStats {
+	if p.limit != nil {
+		return p.limit.stats()
+	}
+	st := p.primary.Stat()
+	return PoolStats{
+		AcquireCount:    st.AcquireCount(),
+		AcquireDuration: st.AcquireDuration(),
+		AcquiredConns:   st.AcquiredConns(),
+		MaxConns:        st.MaxConns(),
+	}
+}
+
+// Resize implements TunablePool. pgxpool cannot change MaxConns of a running
+// pool, so Open creates the primary at the auto-tune ceiling and Resize only
+// moves the limit on concurrent use; shrinking lets held connections finish.
+func (p *ReplicaPool) Resize(_ context.Context, maxConns int32) error {
+	if p.limit == nil {
+		return errors.New("db: resize primary: pool was opened without autotune")
+	}
+	if ceiling := p.primary.Config().MaxConns; maxConns > ceiling {
+		return fmt.Errorf("db: resize primary: %d exceeds the pool's %d connections", maxConns, ceiling)
+	}
+	p.limit.setLimit(maxConns)
+	return nil
+}
+
+// PoolAutoTuner moves MaxConns by StepSize every AdjustInterval, based on
+// the average time queries waited to acquire a connection during the
+// interval: above 1.5×TargetAcquireDuration it grows, up to MaxConns; below
+// 0.5×TargetAcquireDuration with under half the connections in use it
+// shrinks, down to MinConns.
+type PoolAutoTuner struct {
+	pool TunablePool
+	cfg  PoolAutoTuneConfig
+
+	lastCount    int64
+	lastDuration time.Duration
+}
+
+// NewPoolAutoTuner returns a tuner for pool
+func NewPoolAutoTuner(pool TunablePool, cfg PoolAutoTuneConfig) *PoolAutoTuner {
+	t := &PoolAutoTuner{pool: pool, cfg: cfg.withDefaults()}
+	st := pool.Stats()
+	t.lastCount, t.lastDuration = st.AcquireCount, st.AcquireDuration
+	poolMaxConns.Set(float64(st.MaxConns))
+	return t
+}
+
+// Run adjusts the pool every AdjustInterval until ctx is cancelled
+func (t *PoolAutoTuner) Run(ctx context.Context) {
+	ticker := time.NewTicker(t.cfg.AdjustInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := t.Adjust(ctx); err != nil {
+				zap.L().Warn("db pool auto-tune failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Adjust runs one tuning step over the stats gathered since the previous one
+func (t *PoolAutoTuner) Adjust(ctx context.Context) error {
+	st := t.pool.Stats()
+	count, total := st.AcquireCount-t.lastCount, st.AcquireDuration-t.lastDuration
+	t.lastCount, t.lastDuration = st.AcquireCount, st.AcquireDuration
+	if count <= 0 || total < 0 {
+		// idle
+		return nil
+	}
+	avg := total / time.Duration(count)
+	target := t.cfg.TargetAcquireDuration
+
+	next := st.MaxConns
+	switch {
+	case avg > target*3/2:
+		next += t.cfg.StepSize
+		if next > t.cfg.MaxConns {
+			next = t.cfg.MaxConns
+		}
+	case avg < target/2 && st.AcquiredConns*2 < st.MaxConns:
+		next -= t.cfg.StepSize
+		if next < t.cfg.MinConns {
+			next = t.cfg.MinConns
+		}
+	}
+	if next == st.MaxConns {
+		return nil
+	}
+	if err := t.pool.Resize(ctx, next); err != nil {
+		return err
+	}
+	zap.L().Info("db pool resized",
+		zap.Int32("from", st.MaxConns), zap.Int32("to", next),
+		zap.Duration("avg_acquire", avg), zap.Int64("acquires", count),
+		zap.Int32("acquired_conns", st.AcquiredConns))
+	poolMaxConns.Set(float64(next))
+	return nil
+}
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakePool reports scripted stats and records resizes
+type fakePool struct {
+	stats   PoolStats
+	resized []int32
+}
+
+func (f *fakePool) Stats() PoolStats { return f.stats }
+
+func (f *fakePool) Resize(_ context.Context, maxConns int32) error {
+	f.resized = append(f.resized, maxConns)
+	f.stats.MaxConns = maxConns
+	return nil
+}
+
+func TestPoolAutoTunerAdjust(t *testing.T) {
+	cfg := PoolAutoTuneConfig{TargetAcquireDuration: 10 * time.Millisecond, StepSize: 2, MinConns: 4, MaxConns: 12}
+	tests := []struct {
+		name     string
+		max      int32
+		acquired int32
+		avg      time.Duration
+		want     int32 // 0: unchanged
+	}{
+		{"slow acquires grow by step", 8, 8, 20 * time.Millisecond, 10},
+		{"growth capped at ceiling", 11, 11, 20 * time.Millisecond, 12},
+		{"fast and idle shrinks", 8, 2, time.Millisecond, 6},
+		{"shrink floored", 5, 1, time.Millisecond, 4},
+		{"fast but busy stays", 8, 6, time.Millisecond, 0},
+		{"on target stays", 8, 8, 10 * time.Millisecond, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := &fakePool{stats: PoolStats{MaxConns: tt.max}}
+			tuner := NewPoolAutoTuner(pool, cfg)
+			pool.stats.AcquireCount = 100
+			pool.stats.AcquireDuration = 100 * tt.avg
+			pool.stats.AcquiredConns = tt.acquired
+			if err := tuner.Adjust(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			switch {
+			case tt.want == 0 && len(pool.resized) != 0:
+				t.Errorf("resized to %v, want unchanged", pool.resized)
+			case tt.want != 0 && (len(pool.resized) != 1 || pool.resized[0] != tt.want):
+				t.Errorf("resized to %v, want %d", pool.resized, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimiterResize(t *testing.T) {
+	l := newLimiter(1)
+	ctx := context.Background()
+	if err := l.acquire(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := l.acquire(short); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("acquire over the limit = %v, want DeadlineExceeded", err)
+	}
+
+	var got atomic.Bool
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if l.acquire(ctx) == nil {
+			got.Store(true)
+		}
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if got.Load() {
+		t.Fatal("acquired a second slot at limit 1")
+	}
+	l.setLimit(2) // growing admits the waiter without a release
+	<-done
+	if st := l.stats(); !got.Load() || st.AcquiredConns != 2 || st.MaxConns != 2 || st.AcquireCount != 2 {
+		t.Errorf("stats = %+v", st)
+	}
+
+	l.setLimit(1) // shrinking keeps both held slots until released
+	l.release()
+	if l.acquire(short) == nil {
+		t.Error("acquired while still at the lowered limit")
+	}
+	l.release()
+	if err := l.acquire(ctx); err != nil {
+		t.Errorf("acquire after release: %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// limiter is a semaphore whose size can change while it is held. Resize
+// gates the primary with it instead of reopening the pool.
+type limiter struct {
+	mu    sync.Mutex
+	limit int32
+	inUse int32
+	wake  chan struct{} // closed and replaced when a slot may have freed up
+
+	acquires int64         // cumulative, for Stats
+	waited   time.Duration // cumulative time spent in acquire
+}
+
+func newLimiter(limit int32) *limiter {
+	return &limiter{limit: limit, wake: make(chan struct{})}
+}
+
+// acquire takes a slot, waiting until one is free or ctx is done
+func (l *limiter) acquire(ctx context.Context) error {
+	start := time.Now()
+	for {
+		l.mu.Lock()
+		if l.inUse < l.limit {
+			l.inUse++
+			l.acquires++
+			l.waited += time.Since(start)
+			l.mu.Unlock()
+			return nil
+		}
+		wake := l.wake
+		l.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-wake:
+		}
+	}
+}
+
+func (l *limiter) release() {
+	l.mu.Lock()
+	l.inUse--
+	l.broadcast()
+	l.mu.Unlock()
+}
+
+// setLimit changes the size; slots held above a lower limit are kept until
+// released
+func (l *limiter) setLimit(limit int32) {
+	l.mu.Lock()
+	l.limit = limit
+	l.broadcast()
+	l.mu.Unlock()
+}
+
+// broadcast wakes every waiter; call with mu held
+func (l *limiter) broadcast() {
+	close(l.wake)
+	l.wake = make(chan struct{})
+}
+
+func (l *limiter) stats() PoolStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return PoolStats{AcquireCount: l.acquires, AcquireDuration: l.waited, AcquiredConns: l.inUse, MaxConns: l.limit}
+}
+
+// limitedRows gives the limiter slot back once the rows are done
+type limitedRows struct {
+	pgx.Rows
+	release func()
+	once    sync.Once
+}
+
+func (r *limitedRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.once.Do(r.release)
+	return false
+}
+
+func (r *limitedRows) Close() {
+	r.Rows.Close()
+	r.once.Do(r.release)
+}
@@ -0,0 +1,201 @@
+// Package db routes PostgreSQL queries between a primary and read replicas
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DBConfig configures the primary and optional read replicas; the database
+// is disabled when PrimaryDSN is empty
+type DBConfig struct {
+	PrimaryDSN  string   `mapstructure:"primary_dsn"`
+	ReplicaDSNs []string `mapstructure:"replica_dsns"`
+
+	// AutoTune limits the primary pool's concurrency from its acquire latency
+	AutoTune PoolAutoTuneConfig `mapstructure:"autotune"`
+
+	// Pool is opened from the DSNs by NewRouter when nil
+	Pool *ReplicaPool `mapstructure:"-"`
+}
+
+// Routing is a per-request hint for QueryContext
+type Routing int
+
+const (
+	// RouteAuto sends reads to a replica
+	RouteAuto Routing = iota
+	// ForcePrimary sends reads to the primary, e.g. to read your own writes
+	ForcePrimary
+)
+
+type contextKey int
+
+const (
+	routingKey contextKey = iota
+	poolKey
+)
+
+// WithRouting sets the routing hint used by QueryContext
+func WithRouting(ctx context.Context, r Routing) context.Context {
+	return context.WithValue(ctx, routingKey, r)
+}
+
+// RoutingFromContext returns the routing hint, RouteAuto when unset
+func RoutingFromContext(ctx context.Context) Routing {
+	r, _ := ctx.Value(routingKey).(Routing)
+	return r
+}
+
+// ReplicaPool holds one primary pool and any number of replica pools
+type ReplicaPool struct {
+	primary  *pgxpool.Pool
+	limit    *limiter // gates ExecContext and QueryContext on the primary; nil without auto-tuning
+	replicas []*pgxpool.Pool
+	next     atomic.Uint64
+}
+
+// NewReplicaPool wraps existing pools; with no replicas every query goes to
+// the primary
+func NewReplicaPool(primary *pgxpool.Pool, replicas ...*pgxpool.Pool) *ReplicaPool {
+	return &ReplicaPool{primary: primary, replicas: replicas}
+}
+
+// Open creates the pools from cfg. Connections are established lazily. With
+// AutoTune enabled the primary is opened at AutoTune.MaxConns and its
+// concurrency is limited to the DSN's pool_max_conns, clamped to
+// [MinConns, MaxConns], until Resize changes it.
+func Open(ctx context.Context, cfg DBConfig) (*ReplicaPool, error) {
+	if cfg.PrimaryDSN == "" {
+		return nil, errors.New("db: primary_dsn is required")
+	}
+	pcfg, err := pgxpool.ParseConfig(cfg.PrimaryDSN)
+	if err != nil {
+		return nil, fmt.Errorf("db: primary: %w", err)
+	}
+	var limit *limiter
+	if cfg.AutoTune.Enabled {
+		tune := cfg.AutoTune.withDefaults()
+		initial := pcfg.MaxConns
+		if initial < tune.MinConns {
+			initial = tune.MinConns
+		}
+		if initial > tune.MaxConns {
+			initial = tune.MaxConns
+		}
+		limit = newLimiter(initial)
+		pcfg.MaxConns = tune.MaxConns
+		if pcfg.MinConns > initial {
+			pcfg.MinConns = initial
+		}
+	}
+	primary, err := pgxpool.NewWithConfig(ctx, pcfg)
+	if err != nil {
+		return nil, fmt.Errorf("db: primary: %w", err)
+	}
+	p := &ReplicaPool{primary: primary, limit: limit}
+	for i, dsn := range cfg.ReplicaDSNs {
+		replica, err := pgxpool.New(ctx, dsn)
+		if err != nil {
+			p.Close()
+			return nil, fmt.Errorf("db: replica %d: %w", i, err)
+		}
+		p.replicas = append(p.replicas, replica)
+	}
+	return p, nil
+}
+
+// Primary returns the primary pool. Work done on it directly, such as
+// transactions, is not counted against the auto-tuned limit.
+func (p *ReplicaPool) Primary() *pgxpool.Pool {
+	return p.primary
+}
+
+// Replica returns the next replica in round-robin order, or the primary when
+// there are none
+func (p *ReplicaPool) Replica() *pgxpool.Pool {
+	if len(p.replicas) == 0 {
+		return p.primary
+	}
+	n := p.next.Add(1) - 1
+	return p.replicas[n%uint64(len(p.replicas))]
+}
+
+// ExecContext runs a statement on the primary
+func (p *ReplicaPool) ExecContext(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	if p.limit == nil {
+		return p.primary.Exec(ctx, sql, args...)
+	}
+	if err := p.limit.acquire(ctx); err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	defer p.limit.release()
+	return p.primary.Exec(ctx, sql, args...)
+}
+
+// QueryContext runs a read on a replica, or on the primary when ctx carries
+// ForcePrimary
+func (p *ReplicaPool) QueryContext(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	pool := p.pick(ctx)
+	if pool != p.primary || p.limit == nil {
+		return pool.Query(ctx, sql, args...)
+	}
+	if err := p.limit.acquire(ctx); err != nil {
+		return nil, err
+	}
+	rows, err := pool.Query(ctx, sql, args...)
+	if err != nil {
+		p.limit.release()
+		return nil, err
+	}
+	return &limitedRows{Rows: rows, release: p.limit.release}, nil
+}
+
+func (p *ReplicaPool) pick(ctx context.Context) *pgxpool.Pool {
+	if RoutingFromContext(ctx) == ForcePrimary {
+		return p.primary
+	}
+	return p.Replica()
+}
+
+// Ping checks the primary; replicas are best effort
+func (p *ReplicaPool) Ping(ctx context.Context) error {
+	return p.primary.Ping(ctx)
+}
+
+// Close closes every pool
+func (p *ReplicaPool) Close() {
+	for _, r := range p.replicas {
+		r.Close()
+	}
+	p.primary.Close()
+}
+
+// FromContext returns the pool injected by DBMiddleware, or nil
+func FromContext(ctx context.Context) *ReplicaPool {
+	p, _ := ctx.Value(poolKey).(*ReplicaPool)
+	return p
+}
+
+// DBMiddleware puts pool in the request context. Requests that may write
+// (POST, PUT, PATCH, DELETE) read from the primary, so a handler never reads
+// a replica that has not caught up with its own write yet.
+func DBMiddleware(pool *ReplicaPool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), poolKey, pool)
+			switch r.Method {
+			case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+				ctx = WithRouting(ctx, ForcePrimary)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// newPool returns a pool that never connects; pgxpool dials lazily, so
+// routing can be tested without a database
+func newPool(t *testing.T, host string) *pgxpool.Pool {
+	t.Helper()
+	p, err := pgxpool.New(context.Background(), "postgres://app@"+host+"/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(p.Close)
+	return p
+}
+
+func TestDBMiddlewareRouting(t *testing.T) {
+	primary, replica := newPool(t, "primary"), newPool(t, "replica")
+	pool := NewReplicaPool(primary, replica)
+
+	tests := []struct {
+		method string
+		want   *pgxpool.Pool
+	}{
+		{http.MethodGet, replica},
+		{http.MethodHead, replica},
+		{http.MethodPost, primary},
+		{http.MethodPut, primary},
+		{http.MethodPatch, primary},
+		{http.MethodDelete, primary},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			var got *pgxpool.Pool
+			h := DBMiddleware(pool)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = FromContext(r.Context()).pick(r.Context())
+			}))
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, "/orders", nil))
+			if got != tt.want {
+				t.Errorf("%s routed to %s, want %s", tt.method, got.Config().ConnConfig.Host, tt.want.Config().ConnConfig.Host)
+			}
+		})
+	}
+}
+
+func TestReplicaRoundRobin(t *testing.T) {
+	primary := newPool(t, "primary")
+	r1, r2 := newPool(t, "replica1"), newPool(t, "replica2")
+	pool := NewReplicaPool(primary, r1, r2)
+	want := []*pgxpool.Pool{r1, r2, r1, r2}
+	for i, w := range want {
+		if got := pool.Replica(); got != w {
+			t.Errorf("pick %d = %s", i, got.Config().ConnConfig.Host)
+		}
+	}
+
+	if got := NewReplicaPool(primary).pick(context.Background()); got != primary {
+		t.Error("without replicas reads must go to the primary")
+	}
+	if got := pool.pick(WithRouting(context.Background(), ForcePrimary)); got != primary {
+		t.Error("ForcePrimary read did not go to the primary")
+	}
+}
@@ -0,0 +1,165 @@
+// Package debugcapture records response bodies so that what a handler
+// actually returned can be inspected later. It is meant for development and
+// staging only: bodies may contain personal data or secrets.
+package debugcapture
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/example/go-chi-rest/internal/reqctx"
+)
+
+// PerEndpoint is how many responses DefaultStore keeps for each endpoint
+const PerEndpoint = 20
+
+// DefaultStore receives the responses captured by NewBodyCaptureMiddleware
+// and is served by ResponsesHandler
+var DefaultStore = NewStore(PerEndpoint)
+
+// Response is one captured response
+type Response struct {
+	CapturedAt  time.Time `json:"captured_at"`
+	RequestID   string    `json:"request_id,omitempty"`
+	Method      string    `json:"method"`
+	Endpoint    string    `json:"endpoint"` // chi route pattern, "unmatched" for 404s from the router
+	Path        string    `json:"path"`
+	Status      int       `json:"status"`
+	ContentType string    `json:"content_type,omitempty"`
+	Size        int64     `json:"size"` // bytes written, including any not captured
+	Truncated   bool      `json:"truncated"`
+	Body        string    `json:"body"`
+}
+
+// Store keeps the last responses per endpoint (method + route)
+type Store struct {
+	mu          sync.Mutex
+	perEndpoint int
+	byEndpoint  map[string][]Response
+}
+
+// NewStore returns a store keeping at most perEndpoint responses per endpoint
+func NewStore(perEndpoint int) *Store {
+	if perEndpoint <= 0 {
+		perEndpoint = PerEndpoint
+	}
+	return &Store{perEndpoint: perEndpoint, byEndpoint: make(map[string][]Response)}
+}
+
+// Add records resp, dropping the oldest response of its endpoint when full
+func (s *Store) Add(resp Response) {
+	key := resp.Method + " " + resp.Endpoint
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := append(s.byEndpoint[key], resp)
+	if len(list) > s.perEndpoint {
+		list = append([]Response(nil), list[len(list)-s.perEndpoint:]...)
+	}
+	s.byEndpoint[key] = list
+}
+
+// Responses returns every stored response, newest first
+func (s *Store) Responses() []Response {
+	s.mu.Lock()
+	out := make([]Response, 0, len(s.byEndpoint)*s.perEndpoint)
+	for _, list := range s.byEndpoint {
+		out = append(out, list...)
+	}
+	s.mu.Unlock()
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CapturedAt.After(out[j].CapturedAt) })
+	return out
+}
+
+type captureKey struct{}
+
+// CapturedBody returns the buffer the capture middleware is filling for the
+// current request, or nil when the request is not being captured
+func CapturedBody(ctx context.Context) *bytes.Buffer {
+	buf, _ := ctx.Value(captureKey{}).(*bytes.Buffer)
+	return buf
+}
+
+// NewBodyCaptureMiddleware records up to maxSize bytes of every response body
+// in DefaultStore. Do not mount it in production.
+func NewBodyCaptureMiddleware(maxSize int64) func(http.Handler) http.Handler {
+	return DefaultStore.Middleware(maxSize)
+}
+
+// Middleware records up to maxSize bytes of every response body in s
+func (s *Store) Middleware(maxSize int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cw := &captureWriter{ResponseWriter: w, status: http.StatusOK, max: maxSize}
+			r = r.WithContext(context.WithValue(r.Context(), captureKey{}, &cw.buf))
+			next.ServeHTTP(cw, r)
+
+			// unmatched paths share one bucket so scanners cannot grow the store
+			endpoint := "unmatched"
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				endpoint = rctx.RoutePattern()
+			}
+			s.Add(Response{
+				CapturedAt:  time.Now(),
+				RequestID:   reqctx.FromContext(r.Context()).RequestID,
+				Method:      r.Method,
+				Endpoint:    endpoint,
+				Path:        r.URL.Path,
+				Status:      cw.status,
+				ContentType: w.Header().Get("Content-Type"),
+				Size:        cw.size,
+				Truncated:   cw.size > int64(cw.buf.Len()),
+				Body:        cw.buf.String(),
+			})
+		})
+	}
+}
+
+// ResponsesHandler serves GET /debug/responses: DefaultStore as a JSON array
+func ResponsesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(DefaultStore.Responses())
+}
+
+// captureWriter tees the body into buf until max bytes have been captured
+type captureWriter struct {
+	http.ResponseWriter
+	status int
+	buf    bytes.Buffer
+	max    int64
+	size   int64
+}
+
+func (cw *captureWriter) WriteHeader(code int) {
+	cw.status = code
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+func (cw *captureWriter) Write(b []byte) (int, error) {
+	if room := cw.max - int64(cw.buf.Len()); room > 0 {
+		if int64(len(b)) > room {
+			cw.buf.Write(b[:room])
+		} else {
+			cw.buf.Write(b)
+		}
+	}
+	n, err := cw.ResponseWriter.Write(b)
+	cw.size += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (cw *captureWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
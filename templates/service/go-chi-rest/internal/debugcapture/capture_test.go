@@ -0,0 +1,94 @@
+package debugcapture
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestMiddlewareCapturesBody(t *testing.T) {
+	store := NewStore(2)
+	r := chi.NewRouter()
+	r.Use(store.Middleware(8))
+	r.Post("/api/v1/ping", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write(body)
+	})
+
+	tests := []struct {
+		name      string
+		body      string
+		want      string
+		truncated bool
+	}{
+		{"short body", "pong", "pong", false},
+		{"truncated at max", "0123456789", "01234567", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/ping", strings.NewReader(tt.body)))
+			if rec.Body.String() != tt.body {
+				t.Fatalf("client got %q, want %q", rec.Body, tt.body)
+			}
+			got := store.Responses()[0]
+			if got.Body != tt.want || got.Truncated != tt.truncated || got.Size != int64(len(tt.body)) {
+				t.Errorf("captured %+v", got)
+			}
+			if got.Endpoint != "/api/v1/ping" || got.Status != http.StatusAccepted || got.ContentType != "text/plain" {
+				t.Errorf("captured %+v", got)
+			}
+		})
+	}
+}
+
+func TestStoreKeepsLastPerEndpoint(t *testing.T) {
+	store := NewStore(2)
+	for _, body := range []string{"a", "b", "c"} {
+		store.Add(Response{Method: http.MethodGet, Endpoint: "/x", Body: body})
+	}
+	store.Add(Response{Method: http.MethodGet, Endpoint: "/y", Body: "y"})
+	var bodies []string
+	for _, resp := range store.Responses() {
+		bodies = append(bodies, resp.Body)
+	}
+	if got := strings.Join(bodies, ","); len(bodies) != 3 || strings.Contains(got, "a") {
+		t.Errorf("bodies = %s, want b, c and y", got)
+	}
+}
+
+func TestResponsesHandler(t *testing.T) {
+	DefaultStore = NewStore(PerEndpoint)
+	h := NewBodyCaptureMiddleware(64)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"message":"pong"}`)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/ping", nil))
+
+	rec := httptest.NewRecorder()
+	ResponsesHandler(rec, httptest.NewRequest(http.MethodGet, "/debug/responses", nil))
+	var got []Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Body != `{"message":"pong"}` || got[0].Endpoint != "unmatched" {
+		t.Errorf("responses = %+v", got)
+	}
+}
+
+func TestCaptureWriterUnwrap(t *testing.T) {
+	inner := httptest.NewRecorder()
+	cw := &captureWriter{ResponseWriter: inner, max: 1}
+	if err := http.NewResponseController(cw).Flush(); err != nil {
+		t.Errorf("Flush through captureWriter: %v", err)
+	}
+	if !inner.Flushed {
+		t.Error("inner writer not flushed")
+	}
+}
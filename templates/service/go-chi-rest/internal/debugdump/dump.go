@@ -0,0 +1,219 @@
+// Package debugdump writes heap profiles and goroutine dumps on demand and
+// serves them back, for diagnosing a running instance without a restart or
+// exposing net/http/pprof. Dumps are files in a dedicated directory and are
+// deleted after a while.
+package debugdump
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/example/go-chi-rest/internal/errcodes"
+)
+
+// DumpConfig configures the /admin/debug endpoints
+type DumpConfig struct {
+	Dir    string        `mapstructure:"dir"`     // where dumps are written; a new directory under the system temp dir when empty
+	MaxAge time.Duration `mapstructure:"max_age"` // dumps older than this are deleted
+}
+
+// dump file name prefixes; only files starting with one are served or deleted
+const (
+	heapPrefix      = "heap-"
+	goroutinePrefix = "goroutines-"
+)
+
+// Dumper writes and serves dumps
+type Dumper struct {
+	cfg DumpConfig
+}
+
+// New creates cfg.Dir, or a temporary directory when it is empty
+func New(cfg DumpConfig) (*Dumper, error) {
+	var err error
+	if cfg.Dir == "" {
+		cfg.Dir, err = os.MkdirTemp("", "debug-dumps-")
+	} else {
+		err = os.MkdirAll(cfg.Dir, 0o700)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = time.Hour
+	}
+	return &Dumper{cfg: cfg}, nil
+}
+
+// dumpResult is the response of the dump endpoints
+type dumpResult struct {
+	Path  string `json:"path"`
+	Name  string `json:"name"`
+	Size  int64  `json:"size"`
+	URL   string `json:"url"` // where GET serves the file
+	Until string `json:"expires_at"`
+}
+
+// Handler serves:
+//
+//	POST /admin/debug/heap-dump       heap profile (pprof format) after a GC
+//	POST /admin/debug/goroutine-dump  stacks of all goroutines as text
+//	GET  /admin/debug/files/<name>    a dump written by one of the above
+//
+// Mount it at /admin/debug/, behind the admin token.
+func (d *Dumper) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/admin/debug")
+		switch {
+		case path == "/heap-dump":
+			d.dump(w, r, heapPrefix+"*.pb.gz", writeHeap)
+		case path == "/goroutine-dump":
+			d.dump(w, r, goroutinePrefix+"*.txt", writeGoroutines)
+		case strings.HasPrefix(path, "/files/"):
+			d.serveFile(w, r, strings.TrimPrefix(path, "/files/"))
+		default:
+			errcodes.Write(w, errcodes.FromRequest(r).New("RESOURCE_NOT_FOUND", "path", r.URL.Path))
+		}
+	}
+}
+
+func (d *Dumper) dump(w http.ResponseWriter, r *http.Request, pattern string, write func(*os.File) error) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	f, err := os.CreateTemp(d.cfg.Dir, pattern)
+	if err == nil {
+		err = write(f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(f.Name())
+		}
+	}
+	if err != nil {
+		zap.L().Error("debug dump failed", zap.String("pattern", pattern), zap.Error(err))
+		errcodes.Write(w, errcodes.FromRequest(r).New(errcodes.CodeInternal))
+		return
+	}
+	fi, err := os.Stat(f.Name())
+	if err != nil {
+		errcodes.Write(w, errcodes.FromRequest(r).New(errcodes.CodeInternal))
+		return
+	}
+	zap.L().Info("debug dump written", zap.String("path", f.Name()), zap.Int64("size", fi.Size()),
+		zap.String("remote_addr", r.RemoteAddr))
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(dumpResult{
+		Path:  f.Name(),
+		Name:  fi.Name(),
+		Size:  fi.Size(),
+		URL:   "/admin/debug/files/" + fi.Name(),
+		Until: fi.ModTime().Add(d.cfg.MaxAge).UTC().Format(time.RFC3339),
+	})
+}
+
+func writeHeap(f *os.File) error {
+	runtime.GC() // the profile reflects the heap as of the last GC
+	return pprof.Lookup("heap").WriteTo(f, 0)
+}
+
+func writeGoroutines(f *os.File) error {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			_, err := f.Write(buf[:n])
+			return err
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// serveFile sends the dump called name. Only plain names of dump files are
+// accepted, so a request cannot reach other files in or outside the directory.
+func (d *Dumper) serveFile(w http.ResponseWriter, r *http.Request, name string) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !isDumpName(name) {
+		errcodes.Write(w, errcodes.FromRequest(r).New("RESOURCE_NOT_FOUND", "dump", name))
+		return
+	}
+	f, err := os.Open(filepath.Join(d.cfg.Dir, name))
+	if err != nil {
+		errcodes.Write(w, errcodes.FromRequest(r).New("RESOURCE_NOT_FOUND", "dump", name))
+		return
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil || !fi.Mode().IsRegular() {
+		errcodes.Write(w, errcodes.FromRequest(r).New("RESOURCE_NOT_FOUND", "dump", name))
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if strings.HasPrefix(name, goroutinePrefix) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	http.ServeContent(w, r, name, fi.ModTime(), f)
+}
+
+func isDumpName(name string) bool {
+	return (strings.HasPrefix(name, heapPrefix) || strings.HasPrefix(name, goroutinePrefix)) &&
+		!strings.ContainsAny(name, `/\`) && !strings.Contains(name, "..")
+}
+
+// Cleanup deletes dumps older than cfg.MaxAge every few minutes, or every
+// second for very short ages, until ctx is done
+func (d *Dumper) Cleanup(ctx context.Context) {
+	interval := d.cfg.MaxAge / 4
+	if interval > 5*time.Minute {
+		interval = 5 * time.Minute
+	}
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.purge(time.Now().Add(-d.cfg.MaxAge))
+		}
+	}
+}
+
+// purge removes dumps last modified before cutoff
+func (d *Dumper) purge(cutoff time.Time) {
+	entries, err := os.ReadDir(d.cfg.Dir)
+	if err != nil {
+		zap.L().Warn("debug dump cleanup failed", zap.Error(err))
+		return
+	}
+	for _, e := range entries {
+		fi, err := e.Info()
+		if err != nil || !fi.Mode().IsRegular() || !isDumpName(e.Name()) || !fi.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(d.cfg.Dir, e.Name())); err != nil && !os.IsNotExist(err) {
+			zap.L().Warn("debug dump cleanup failed", zap.String("name", e.Name()), zap.Error(err))
+		}
+	}
+}
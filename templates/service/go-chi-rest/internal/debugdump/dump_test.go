@@ -0,0 +1,98 @@
+package debugdump
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func call(h http.Handler, method, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec
+}
+
+func TestDumps(t *testing.T) {
+	d, err := New(DumpConfig{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := d.Handler()
+	for _, kind := range []string{"heap-dump", "goroutine-dump"} {
+		t.Run(kind, func(t *testing.T) {
+			rec := call(h, http.MethodPost, "/admin/debug/"+kind)
+			if rec.Code != http.StatusCreated {
+				t.Fatalf("POST = %d: %s", rec.Code, rec.Body)
+			}
+			var res dumpResult
+			if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+				t.Fatal(err)
+			}
+			if res.Size == 0 {
+				t.Errorf("%s is empty", res.Name)
+			}
+			if fi, err := os.Stat(res.Path); err != nil || fi.Size() != res.Size {
+				t.Errorf("stat %s: %v", res.Path, err)
+			}
+			got := call(h, http.MethodGet, res.URL)
+			if got.Code != http.StatusOK || int64(got.Body.Len()) != res.Size {
+				t.Errorf("GET %s = %d, %d bytes", res.URL, got.Code, got.Body.Len())
+			}
+			if kind == "goroutine-dump" && !strings.Contains(got.Body.String(), "goroutine ") {
+				t.Error("goroutine dump has no stacks")
+			}
+		})
+	}
+}
+
+func TestHandlerRejects(t *testing.T) {
+	dir := t.TempDir()
+	d, err := New(DumpConfig{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name, method, path string
+		want               int
+	}{
+		{"GET dump", http.MethodGet, "/admin/debug/heap-dump", http.StatusMethodNotAllowed},
+		{"traversal", http.MethodGet, "/admin/debug/files/heap-..%2Fsecret.txt", http.StatusNotFound},
+		{"not a dump", http.MethodGet, "/admin/debug/files/secret.txt", http.StatusNotFound},
+		{"unknown path", http.MethodPost, "/admin/debug/cpu-profile", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := call(d.Handler(), tt.method, tt.path); rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleanupShortMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	d, err := New(DumpConfig{Dir: dir, MaxAge: time.Nanosecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := filepath.Join(dir, goroutinePrefix+"1.txt")
+	os.WriteFile(old, []byte("stack"), 0o600)
+	other := filepath.Join(dir, "notes.txt")
+	os.WriteFile(other, []byte("keep"), 0o600)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+	d.Cleanup(ctx) // MaxAge/4 is 0, which time.NewTicker rejects
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("expired dump not deleted: %v", err)
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("non-dump file deleted: %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package deprecation
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+
+	"github.com/example/go-chi-rest/internal/errcodes"
+	"github.com/example/go-chi-rest/internal/reqctx"
+)
+
+var deprecatedCalls = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "deprecated_endpoint_calls_total",
+	Help: "Requests served by deprecated endpoints, by route pattern.",
+}, []string{"path"})
+
+// now is the clock policy dates are compared against
+var now = time.Now
+
+// DeprecationPolicy marks the routes matching PathPattern (a chi pattern such as
+// /api/v1/orders/{id}) as deprecated. Dates are RFC 3339 in config.
+type DeprecationPolicy struct {
+	PathPattern     string    `mapstructure:"path"`
+	Deprecated      bool      `mapstructure:"deprecated"`       // deprecated now, regardless of DeprecationDate
+	DeprecationDate time.Time `mapstructure:"deprecation_date"` // deprecated from this date on
+	SunsetDate      time.Time `mapstructure:"sunset_date"`      // 410 Gone from this date on
+	Link            string    `mapstructure:"link"`             // migration guide
+}
+
+// NewDeprecationMiddleware announces deprecated routes with the Deprecation,
+// Sunset and Link response headers (draft-ietf-httpapi-deprecation-header,
+// RFC 8594) and answers 410 ENDPOINT_GONE once a route's sunset date has passed.
+// Each call to a deprecated route is logged and counted.
+func NewDeprecationMiddleware(policies []DeprecationPolicy) func(http.Handler) http.Handler {
+	// a private mux gives the patterns exactly chi's matching rules
+	matcher := chi.NewRouter()
+	byPattern := make(map[string]DeprecationPolicy, len(policies))
+	for _, p := range policies {
+		matcher.Handle(p.PathPattern, http.NotFoundHandler())
+		byPattern[p.PathPattern] = p
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			pattern := matcher.Find(chi.NewRouteContext(), r.Method, r.URL.Path)
+			p, ok := byPattern[pattern]
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			t := now()
+			sunset := !p.SunsetDate.IsZero() && !t.Before(p.SunsetDate)
+			if !sunset && !p.Deprecated && (p.DeprecationDate.IsZero() || t.Before(p.DeprecationDate)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Deprecation", "true")
+			if !p.SunsetDate.IsZero() {
+				w.Header().Set("Sunset", p.SunsetDate.UTC().Format(http.TimeFormat))
+			}
+			if p.Link != "" {
+				w.Header().Add("Link", "<"+p.Link+">; rel=\"deprecation\"")
+			}
+			deprecatedCalls.WithLabelValues(p.PathPattern).Inc()
+			reqctx.LoggerFromContext(r.Context()).Warn("deprecated endpoint called",
+				zap.String("path", p.PathPattern),
+				zap.Bool("sunset", sunset),
+				zap.String("user_agent", r.UserAgent()),
+			)
+
+			if sunset {
+				errcodes.Write(w, errcodes.FromRequest(r).New("ENDPOINT_GONE", p.SunsetDate.UTC().Format("2006-01-02")))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
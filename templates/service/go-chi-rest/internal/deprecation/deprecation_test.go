@@ -0,0 +1,50 @@
+package deprecation
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeprecationMiddleware(t *testing.T) {
+	clock := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return clock }
+	t.Cleanup(func() { now = time.Now })
+
+	h := NewDeprecationMiddleware([]DeprecationPolicy{
+		{PathPattern: "/api/v1/orders/{id}", Deprecated: true, SunsetDate: clock.AddDate(0, 1, 0), Link: "https://docs.example.com/migrate"},
+		{PathPattern: "/api/v1/legacy", DeprecationDate: clock.AddDate(-1, 0, 0), SunsetDate: clock.AddDate(0, 0, -1)},
+		{PathPattern: "/api/v1/later", DeprecationDate: clock.AddDate(0, 0, 1)},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name, path  string
+		want        int
+		deprecation string
+		sunset      string
+		link        string
+	}{
+		{"not covered", "/api/v1/users", http.StatusOK, "", "", ""},
+		{"deprecated", "/api/v1/orders/42", http.StatusOK, "true", "Wed, 01 Jul 2026 12:00:00 GMT", `<https://docs.example.com/migrate>; rel="deprecation"`},
+		{"past sunset", "/api/v1/legacy", http.StatusGone, "true", "Sun, 31 May 2026 12:00:00 GMT", ""},
+		{"before deprecation date", "/api/v1/later", http.StatusOK, "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+			hdr := rec.Header()
+			if hdr.Get("Deprecation") != tt.deprecation || hdr.Get("Sunset") != tt.sunset || hdr.Get("Link") != tt.link {
+				t.Errorf("headers = Deprecation %q, Sunset %q, Link %q", hdr.Get("Deprecation"), hdr.Get("Sunset"), hdr.Get("Link"))
+			}
+			if tt.want == http.StatusGone && !strings.Contains(rec.Body.String(), `"ENDPOINT_GONE"`) {
+				t.Errorf("body = %s, want ENDPOINT_GONE", rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package discovery
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+
+	consul "github.com/hashicorp/consul/api"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+var registrationStatus = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "consul_registration_status",
+	Help: "1 while the service is registered with Consul, 0 otherwise.",
+}, []string{"service_id"})
+
+// ConsulConfig configures service registration with the local Consul agent
+type ConsulConfig struct {
+	Enabled             bool     `mapstructure:"enabled"`
+	Addr                string   `mapstructure:"addr"` // agent address, e.g. localhost:8500
+	ServiceName         string   `mapstructure:"service_name"`
+	ServiceID           string   `mapstructure:"service_id"` // default <service_name>-<hostname>-<port>
+	Tags                []string `mapstructure:"tags"`
+	HealthCheckInterval string   `mapstructure:"health_check_interval"` // e.g. 10s
+}
+
+// ConsulRegistration is a service registered with the agent
+type ConsulRegistration struct {
+	client *consul.Client
+	id     string
+}
+
+// RegisterService registers the server listening on bindAddr together with an
+// HTTP check against /healthz. scheme is "http" or "https". A host-less bind
+// address is advertised under the machine's hostname.
+func RegisterService(cfg ConsulConfig, bindAddr, scheme string) (*ConsulRegistration, error) {
+	host, portStr, err := net.SplitHostPort(bindAddr)
+	if err != nil {
+		return nil, fmt.Errorf("consul: bind address %q: %w", bindAddr, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("consul: bind address %q: %w", bindAddr, err)
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		if host, err = os.Hostname(); err != nil {
+			return nil, fmt.Errorf("consul: resolve hostname: %w", err)
+		}
+	}
+	id := cfg.ServiceID
+	if id == "" {
+		id = fmt.Sprintf("%s-%s-%d", cfg.ServiceName, host, port)
+	}
+	interval := cfg.HealthCheckInterval
+	if interval == "" {
+		interval = "10s"
+	}
+
+	apiCfg := consul.DefaultConfig()
+	if cfg.Addr != "" {
+		apiCfg.Address = cfg.Addr
+	}
+	client, err := consul.NewClient(apiCfg)
+	if err != nil {
+		return nil, fmt.Errorf("consul: create client: %w", err)
+	}
+
+	reg := &consul.AgentServiceRegistration{
+		ID:      id,
+		Name:    cfg.ServiceName,
+		Tags:    cfg.Tags,
+		Address: host,
+		Port:    port,
+		Check: &consul.AgentServiceCheck{
+			HTTP:          fmt.Sprintf("%s://%s/healthz", scheme, net.JoinHostPort(host, portStr)),
+			Interval:      interval,
+			Timeout:       "2s",
+			TLSSkipVerify: scheme == "https",
+			// drop instances that stay critical, e.g. after a crash that skipped deregistration
+			DeregisterCriticalServiceAfter: "1m",
+		},
+	}
+	if err := client.Agent().ServiceRegister(reg); err != nil {
+		return nil, fmt.Errorf("consul: register %s: %w", id, err)
+	}
+	registrationStatus.WithLabelValues(id).Set(1)
+	zap.L().Info("registered with consul", zap.String("service_id", id), zap.String("check", reg.Check.HTTP))
+	return &ConsulRegistration{client: client, id: id}, nil
+}
+
+// ID returns the registered service ID
+func (r *ConsulRegistration) ID() string {
+	return r.id
+}
+
+// Deregister removes the service from the agent; failures are logged, not
+// returned, since the agent drops critical services on its own eventually
+func (r *ConsulRegistration) Deregister() {
+	if err := r.client.Agent().ServiceDeregister(r.id); err != nil {
+		zap.L().Error("consul deregistration failed", zap.String("service_id", r.id), zap.Error(err))
+		return
+	}
+	registrationStatus.WithLabelValues(r.id).Set(0)
+	zap.L().Info("deregistered from consul", zap.String("service_id", r.id))
+}
@@ -0,0 +1,106 @@
+package discovery
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	consul "github.com/hashicorp/consul/api"
+)
+
+// fakeAgent records the registrations and deregistrations it receives
+type fakeAgent struct {
+	mu           sync.Mutex
+	registered   []consul.AgentServiceRegistration
+	deregistered []string
+}
+
+func (a *fakeAgent) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	switch {
+	case r.Method == http.MethodPut && r.URL.Path == "/v1/agent/service/register":
+		var reg consul.AgentServiceRegistration
+		if err := json.NewDecoder(r.Body).Decode(&reg); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		a.registered = append(a.registered, reg)
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/v1/agent/service/deregister/"):
+		a.deregistered = append(a.deregistered, strings.TrimPrefix(r.URL.Path, "/v1/agent/service/deregister/"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newFakeAgent(t *testing.T) (*fakeAgent, string) {
+	t.Helper()
+	agent := &fakeAgent{}
+	srv := httptest.NewServer(agent)
+	t.Cleanup(srv.Close)
+	return agent, strings.TrimPrefix(srv.URL, "http://")
+}
+
+func TestRegisterService(t *testing.T) {
+	agent, addr := newFakeAgent(t)
+	cfg := ConsulConfig{Addr: addr, ServiceName: "orders", Tags: []string{"v1"}, HealthCheckInterval: "5s"}
+
+	reg, err := RegisterService(cfg, "10.0.0.5:8080", "https")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(agent.registered) != 1 {
+		t.Fatalf("agent got %d registrations, want 1", len(agent.registered))
+	}
+	got := agent.registered[0]
+	if got.ID != "orders-10.0.0.5-8080" || reg.ID() != got.ID || got.Name != "orders" || got.Address != "10.0.0.5" || got.Port != 8080 {
+		t.Errorf("registration = %+v", got)
+	}
+	if len(got.Tags) != 1 || got.Tags[0] != "v1" {
+		t.Errorf("tags = %v", got.Tags)
+	}
+	check := got.Check
+	if check == nil || check.HTTP != "https://10.0.0.5:8080/healthz" || check.Interval != "5s" || !check.TLSSkipVerify || check.DeregisterCriticalServiceAfter != "1m" {
+		t.Errorf("check = %+v", check)
+	}
+
+	reg.Deregister()
+	if len(agent.deregistered) != 1 || agent.deregistered[0] != got.ID {
+		t.Errorf("deregistered = %v, want %s", agent.deregistered, got.ID)
+	}
+}
+
+func TestRegisterServiceDefaults(t *testing.T) {
+	agent, addr := newFakeAgent(t)
+	reg, err := RegisterService(ConsulConfig{Addr: addr, ServiceName: "orders", ServiceID: "orders-1"}, ":8080", "http")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := agent.registered[0]
+	if reg.ID() != "orders-1" || got.Address == "" || got.Check.Interval != "10s" || got.Check.TLSSkipVerify {
+		t.Errorf("registration = %+v, check = %+v", got, got.Check)
+	}
+	if !strings.HasPrefix(got.Check.HTTP, "http://"+got.Address+":8080/") {
+		t.Errorf("check URL = %s, want the advertised hostname", got.Check.HTTP)
+	}
+}
+
+func TestRegisterServiceErrors(t *testing.T) {
+	_, addr := newFakeAgent(t)
+	for _, bind := range []string{"8080", "host:port"} {
+		if _, err := RegisterService(ConsulConfig{Addr: addr, ServiceName: "orders"}, bind, "http"); err == nil {
+			t.Errorf("RegisterService(%q) succeeded", bind)
+		}
+	}
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "agent unavailable", http.StatusInternalServerError)
+	}))
+	defer down.Close()
+	if _, err := RegisterService(ConsulConfig{Addr: strings.TrimPrefix(down.URL, "http://"), ServiceName: "orders"}, ":8080", "http"); err == nil {
+		t.Error("registration against a failing agent succeeded")
+	}
+}
@@ -0,0 +1,157 @@
+package election
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// memBackend is an in-memory ElectionBackend; TTLs are not enforced
+type memBackend struct {
+	mu     sync.Mutex
+	holder string
+	fail   bool
+}
+
+func (b *memBackend) Campaign(ctx context.Context, key, node string, ttl time.Duration) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.fail {
+		return false, errors.New("backend unavailable")
+	}
+	if b.holder == "" {
+		b.holder = node
+	}
+	return b.holder == node, nil
+}
+
+func (b *memBackend) Resign(ctx context.Context, key, node string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.holder == node {
+		b.holder = ""
+	}
+	return nil
+}
+
+func (b *memBackend) setFail(fail bool) {
+	b.mu.Lock()
+	b.fail = fail
+	b.mu.Unlock()
+}
+
+// node runs an Election in the background and tracks whether it is leading
+type node struct {
+	cancel  context.CancelFunc
+	done    chan struct{}
+	leading atomic.Bool
+}
+
+func startNode(t *testing.T, backend ElectionBackend, id string, leaders *atomic.Int32) *node {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &node{cancel: cancel, done: make(chan struct{})}
+	e := &Election{CampaignKey: "leader", CampaignTTL: 30 * time.Millisecond, Backend: backend, NodeID: id}
+	go func() {
+		defer close(n.done)
+		e.Run(ctx, func(ctx context.Context) {
+			if leaders.Add(1) > 1 {
+				t.Errorf("%s: two leaders at once", id)
+			}
+			n.leading.Store(true)
+			<-ctx.Done()
+			n.leading.Store(false)
+			leaders.Add(-1)
+		}, nil)
+	}()
+	t.Cleanup(func() { n.stop() })
+	return n
+}
+
+func (n *node) stop() {
+	n.cancel()
+	<-n.done
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestElectionSingleLeaderAndFailover(t *testing.T) {
+	backend := &memBackend{}
+	var leaders atomic.Int32
+	a := startNode(t, backend, "a", &leaders)
+	b := startNode(t, backend, "b", &leaders)
+
+	waitFor(t, "a leader", func() bool { return leaders.Load() == 1 })
+	leader, follower := a, b
+	if b.leading.Load() {
+		leader, follower = b, a
+	}
+	time.Sleep(50 * time.Millisecond) // several renewals
+	if follower.leading.Load() {
+		t.Fatal("follower is leading")
+	}
+
+	leader.stop()
+	waitFor(t, "failover", follower.leading.Load)
+}
+
+func TestElectionStepsDownOnBackendError(t *testing.T) {
+	backend := &memBackend{}
+	var leaders atomic.Int32
+	n := startNode(t, backend, "a", &leaders)
+
+	waitFor(t, "leadership", n.leading.Load)
+	backend.setFail(true)
+	waitFor(t, "step down", func() bool { return !n.leading.Load() })
+	backend.setFail(false)
+	waitFor(t, "re-election", n.leading.Load)
+}
+
+func TestRedisBackend(t *testing.T) {
+	mr := miniredis.RunT(t)
+	b := NewRedisBackend(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	ctx := context.Background()
+
+	campaign := func(node string) bool {
+		t.Helper()
+		ok, err := b.Campaign(ctx, "leader", node, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ok
+	}
+	if !campaign("a") || campaign("b") || !campaign("a") {
+		t.Fatal("want a elected and renewed, b rejected")
+	}
+	if ttl := mr.TTL("leader"); ttl != time.Minute {
+		t.Errorf("TTL = %v, want 1m", ttl)
+	}
+	if err := b.Resign(ctx, "leader", "b"); err != nil || !mr.Exists("leader") {
+		t.Fatalf("non-holder resign removed the record (err %v)", err)
+	}
+	if err := b.Resign(ctx, "leader", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if !campaign("b") {
+		t.Error("b not elected after a resigned")
+	}
+	mr.FastForward(2 * time.Minute)
+	if !campaign("a") {
+		t.Error("a not elected after b's record expired")
+	}
+}
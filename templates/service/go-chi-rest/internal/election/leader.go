@@ -0,0 +1,132 @@
+package election
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+var isLeader = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "leader_election_is_leader",
+	Help: "1 if this node currently holds leadership, 0 otherwise.",
+}, []string{"node"})
+
+// ElectionConfig configures leader election from viper
+type ElectionConfig struct {
+	Enabled   bool          `mapstructure:"enabled"`
+	RedisAddr string        `mapstructure:"redis_addr"`
+	Key       string        `mapstructure:"key"`
+	TTL       time.Duration `mapstructure:"ttl"`
+	NodeID    string        `mapstructure:"node_id"` // defaults to the hostname
+}
+
+// ElectionBackend stores the leadership record. Implementations exist for Redis
+// (RedisBackend); an etcd lease/session based backend fits the same contract.
+type ElectionBackend interface {
+	// Campaign acquires key for node, or renews it if node already holds it, and
+	// reports whether node is the leader afterwards
+	Campaign(ctx context.Context, key, node string, ttl time.Duration) (bool, error)
+	// Resign gives up leadership if node holds it
+	Resign(ctx context.Context, key, node string) error
+}
+
+// Election campaigns for leadership of CampaignKey
+type Election struct {
+	CampaignKey string
+	CampaignTTL time.Duration
+	Backend     ElectionBackend
+	NodeID      string
+}
+
+// Run campaigns until ctx is cancelled. onLeader runs (in its own goroutine) each
+// time this node is elected; its context is cancelled when leadership is lost,
+// after which onFollower is called.
+func (e *Election) Run(ctx context.Context, onLeader, onFollower func(ctx context.Context)) error {
+	if e.Backend == nil || e.CampaignKey == "" {
+		return errors.New("election: Backend and CampaignKey are required")
+	}
+	if e.CampaignTTL <= 0 {
+		e.CampaignTTL = 15 * time.Second
+	}
+	if e.NodeID == "" {
+		e.NodeID, _ = os.Hostname()
+	}
+	log := zap.L().With(zap.String("node", e.NodeID), zap.String("key", e.CampaignKey))
+	gauge := isLeader.WithLabelValues(e.NodeID)
+	gauge.Set(0)
+
+	var (
+		leading  bool
+		stopLead func()
+	)
+	stepDown := func() {
+		stopLead()
+		leading = false
+		gauge.Set(0)
+	}
+
+	// renew well before the record expires
+	ticker := time.NewTicker(e.CampaignTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		elected, err := e.Backend.Campaign(ctx, e.CampaignKey, e.NodeID, e.CampaignTTL)
+		switch {
+		case ctx.Err() != nil:
+			// shutting down: keep the current state so we resign below
+			elected = leading
+		case err != nil:
+			// cannot confirm leadership: assume it is lost to avoid split brain
+			log.Warn("election campaign failed", zap.Error(err))
+			elected = false
+		}
+
+		switch {
+		case elected && !leading:
+			log.Info("became leader")
+			leading = true
+			gauge.Set(1)
+			stopLead = lead(ctx, onLeader)
+		case !elected && leading:
+			log.Info("lost leadership")
+			stepDown()
+			if onFollower != nil && ctx.Err() == nil {
+				onFollower(ctx)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if leading {
+				stepDown()
+				resignCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+				if err := e.Backend.Resign(resignCtx, e.CampaignKey, e.NodeID); err != nil {
+					log.Warn("election resign failed", zap.Error(err))
+				}
+				cancel()
+				log.Info("resigned leadership")
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// lead runs onLeader in a goroutine and returns a func that cancels it and waits
+func lead(ctx context.Context, onLeader func(ctx context.Context)) func() {
+	leaderCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		onLeader(leaderCtx)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
@@ -0,0 +1,51 @@
+package election
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// campaignScript takes the key if free, renews it if we already own it
+var campaignScript = redis.NewScript(`
+local cur = redis.call("GET", KEYS[1])
+if not cur then
+	redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+	return 1
+end
+if cur == ARGV[1] then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	return 1
+end
+return 0`)
+
+var resignScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// RedisBackend keeps the leader record in a single Redis key with a TTL
+type RedisBackend struct {
+	client redis.UniversalClient
+}
+
+// NewRedisBackend returns an ElectionBackend using client
+func NewRedisBackend(client redis.UniversalClient) *RedisBackend {
+	return &RedisBackend{client: client}
+}
+
+// Campaign implements ElectionBackend
+func (b *RedisBackend) Campaign(ctx context.Context, key, node string, ttl time.Duration) (bool, error) {
+	n, err := campaignScript.Run(ctx, b.client, []string{key}, node, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// Resign implements ElectionBackend
+func (b *RedisBackend) Resign(ctx context.Context, key, node string) error {
+	return resignScript.Run(ctx, b.client, []string{key}, node).Err()
+}
@@ -0,0 +1,169 @@
+// Package envelope wraps JSON responses in a consistent shape:
+//
+//	{"data": <body>, "meta": {"request_id": "…", "timestamp": "…", "version": "…"}}
+//	{"error": {"code": "…", "message": "…"}, "meta": {…}}
+//
+// Handlers keep writing plain JSON (negotiate.WriteResponse, errcodes.Write);
+// the middleware rewrites the body on the way out.
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/example/go-chi-rest/internal/reqctx"
+)
+
+// ResponseEnvelopeConfig configures the envelope; key names default to data and meta
+type ResponseEnvelopeConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	SuccessKey string `mapstructure:"success_key"`
+	MetaKey    string `mapstructure:"meta_key"`
+	// Version is reported in meta.version; set from the build version at startup
+	Version string `mapstructure:"-"`
+}
+
+// Meta describes the response
+type Meta struct {
+	RequestID string `json:"request_id,omitempty"`
+	Timestamp string `json:"timestamp"`
+	Version   string `json:"version,omitempty"`
+}
+
+// NewMeta returns the meta block for r
+func NewMeta(r *http.Request, version string) Meta {
+	return Meta{
+		RequestID: reqctx.FromContext(r.Context()).RequestID,
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+		Version:   version,
+	}
+}
+
+// state is shared between the middleware and DisableEnvelope further down the chain
+type state struct{ disabled bool }
+
+type stateKey struct{}
+
+// DisableEnvelope opts the routes it wraps out of the envelope, e.g. health
+// checks or documents with a fixed format such as the OpenAPI spec
+func DisableEnvelope(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if st, ok := r.Context().Value(stateKey{}).(*state); ok {
+			st.disabled = true
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// NewResponseEnvelopeMiddleware wraps JSON response bodies. Responses that are
+// not JSON (including MessagePack/CBOR from content negotiation), are empty,
+// or come from routes behind DisableEnvelope pass through unchanged. Error
+// responses (status >= 400) in the {"error":…} shape get the meta block next to
+// "error"; any other JSON body becomes the success value.
+func NewResponseEnvelopeMiddleware(cfg ResponseEnvelopeConfig) func(http.Handler) http.Handler {
+	if cfg.SuccessKey == "" {
+		cfg.SuccessKey = "data"
+	}
+	if cfg.MetaKey == "" {
+		cfg.MetaKey = "meta"
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			st := &state{}
+			ew := &envelopeWriter{ResponseWriter: w, st: st, status: http.StatusOK}
+			next.ServeHTTP(ew, r.WithContext(context.WithValue(r.Context(), stateKey{}, st)))
+			if !ew.buffering {
+				return
+			}
+			if ew.buf.Len() == 0 || !json.Valid(ew.buf.Bytes()) {
+				ew.flushRaw()
+				return
+			}
+			writeEnvelope(w, cfg, ew.status, json.RawMessage(ew.buf.Bytes()), NewMeta(r, cfg.Version))
+		})
+	}
+}
+
+// writeEnvelope writes data with meta under cfg's keys. An error body
+// ({"error":…}) keeps its "error" key instead of being nested.
+func writeEnvelope(w http.ResponseWriter, cfg ResponseEnvelopeConfig, status int, data json.RawMessage, meta Meta) {
+	body := map[string]any{cfg.MetaKey: meta}
+	var fields map[string]json.RawMessage
+	if status >= 400 && json.Unmarshal(data, &fields) == nil && fields["error"] != nil {
+		body["error"] = fields["error"]
+	} else {
+		body[cfg.SuccessKey] = data
+	}
+	w.Header().Del("Content-Length")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// envelopeWriter buffers JSON bodies and passes everything else through
+type envelopeWriter struct {
+	http.ResponseWriter
+	st          *state
+	status      int
+	wroteHeader bool
+	buffering   bool
+	buf         bytes.Buffer
+}
+
+func (ew *envelopeWriter) WriteHeader(code int) {
+	if ew.wroteHeader {
+		return
+	}
+	ew.wroteHeader = true
+	ew.status = code
+	ew.buffering = !ew.st.disabled && code != http.StatusNoContent && code != http.StatusNotModified &&
+		isJSON(ew.Header().Get("Content-Type"))
+	if !ew.buffering {
+		ew.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (ew *envelopeWriter) Write(b []byte) (int, error) {
+	if !ew.wroteHeader {
+		ew.WriteHeader(http.StatusOK)
+	}
+	if ew.buffering {
+		return ew.buf.Write(b)
+	}
+	return ew.ResponseWriter.Write(b)
+}
+
+// FlushError gives up on wrapping: a flushed body is being streamed.
+// http.ResponseController prefers it over Unwrap.
+func (ew *envelopeWriter) FlushError() error {
+	if ew.buffering {
+		ew.flushRaw()
+	}
+	return http.NewResponseController(ew.ResponseWriter).Flush()
+}
+
+func (ew *envelopeWriter) Flush() {
+	ew.FlushError()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (ew *envelopeWriter) Unwrap() http.ResponseWriter {
+	return ew.ResponseWriter
+}
+
+// flushRaw writes the buffered response unchanged and stops buffering
+func (ew *envelopeWriter) flushRaw() {
+	ew.buffering = false
+	ew.ResponseWriter.WriteHeader(ew.status)
+	ew.ResponseWriter.Write(ew.buf.Bytes())
+	ew.buf.Reset()
+}
+
+func isJSON(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mt == "application/json" || strings.HasSuffix(mt, "+json"))
+}
@@ -0,0 +1,85 @@
+package envelope
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestResponseEnvelopeMiddleware(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(NewResponseEnvelopeMiddleware(ResponseEnvelopeConfig{Version: "1.2.3"}))
+	writeJSON := func(status int, body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			io.WriteString(w, body)
+		}
+	}
+	r.Get("/ok", writeJSON(http.StatusOK, `{"id":1}`))
+	r.Get("/err", writeJSON(http.StatusNotFound, `{"error":{"code":"NOT_FOUND","message":"no"}}`))
+	r.Get("/text", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, "plain")
+	})
+	r.With(DisableEnvelope).Get("/raw", writeJSON(http.StatusOK, `{"status":"up"}`))
+	r.Get("/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"a":`)
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("flush: %v", err)
+		}
+		io.WriteString(w, `1}`)
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		check  func(t *testing.T, body []byte)
+	}{
+		{"/ok", http.StatusOK, func(t *testing.T, body []byte) {
+			var got struct {
+				Data map[string]int `json:"data"`
+				Meta Meta           `json:"meta"`
+			}
+			if err := json.Unmarshal(body, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Data["id"] != 1 || got.Meta.Version != "1.2.3" || got.Meta.Timestamp == "" {
+				t.Errorf("envelope = %s", body)
+			}
+		}},
+		{"/err", http.StatusNotFound, func(t *testing.T, body []byte) {
+			var got map[string]json.RawMessage
+			json.Unmarshal(body, &got)
+			if string(got["error"]) != `{"code":"NOT_FOUND","message":"no"}` || got["meta"] == nil || got["data"] != nil {
+				t.Errorf("error envelope = %s", body)
+			}
+		}},
+		{"/text", http.StatusOK, wantBody("plain")},
+		{"/raw", http.StatusOK, wantBody(`{"status":"up"}`)},
+		{"/stream", http.StatusOK, wantBody(`{"a":1}`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			tt.check(t, rec.Body.Bytes())
+		})
+	}
+}
+
+func wantBody(want string) func(*testing.T, []byte) {
+	return func(t *testing.T, body []byte) {
+		if string(body) != want {
+			t.Errorf("body = %s, want %s", body, want)
+		}
+	}
+}
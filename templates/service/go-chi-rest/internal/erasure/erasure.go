@@ -0,0 +1,215 @@
+// Package erasure implements the GDPR right to erasure: DELETE /api/v1/me
+// asks every registered DataEraser to delete the caller's data and keeps a
+// tombstone proving the request was made
+package erasure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/example/go-chi-rest/internal/authn"
+	"github.com/example/go-chi-rest/internal/db"
+	"github.com/example/go-chi-rest/internal/errcodes"
+	"github.com/example/go-chi-rest/internal/negotiate"
+	"github.com/example/go-chi-rest/internal/reqctx"
+)
+
+// DataEraser deletes a user's data from one store or service
+type DataEraser interface {
+	Erase(ctx context.Context, userID string) error
+}
+
+// EventPublisher sends an event to a message broker subject or topic
+type EventPublisher interface {
+	Publish(ctx context.Context, subject string, data []byte) error
+}
+
+// Tombstone records an erasure request. It holds no personal data beyond the
+// user ID and outlives everything the erasers delete.
+type Tombstone struct {
+	UserID    string
+	RequestID string
+	ErasedAt  time.Time
+}
+
+// TombstoneStore persists tombstones
+type TombstoneStore interface {
+	Record(ctx context.Context, t Tombstone) error
+}
+
+// ErasureConfig configures DELETE /api/v1/me
+type ErasureConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	Timeout      time.Duration `mapstructure:"timeout"`       // per eraser; default 30s
+	EventSubject string        `mapstructure:"event_subject"` // default users.data_erased
+
+	Erasers    map[string]DataEraser `mapstructure:"-"` // by name, reported in the response
+	Publisher  EventPublisher        `mapstructure:"-"` // nil only logs the event
+	Tombstones TombstoneStore        `mapstructure:"-"` // default PostgresTombstones
+}
+
+// UserDataErased is published once every eraser succeeded
+type UserDataErased struct {
+	Type      string    `json:"type"` // always "UserDataErased"
+	UserID    string    `json:"user_id"`
+	RequestID string    `json:"request_id"`
+	ErasedAt  time.Time `json:"erased_at"`
+}
+
+// Outcome is one eraser's result in the 207 response
+type Outcome struct {
+	Eraser string `json:"eraser"`
+	Status string `json:"status"` // erased | failed
+	Error  string `json:"error,omitempty"`
+}
+
+// Result is the 207 response body
+type Result struct {
+	UserID    string    `json:"user_id"`
+	RequestID string    `json:"request_id"`
+	Erased    bool      `json:"erased"` // every eraser succeeded
+	Outcomes  []Outcome `json:"outcomes"`
+}
+
+// NewErasureHandler serves DELETE /me for the user authenticated by
+// authn.Authenticator; anonymous requests get 401, whatever X-User-ID says.
+// The tombstone is stored first; without it nothing is deleted.
+// Erasers then run concurrently with their own timeout and a context that
+// survives the client going away, and one failing does not stop the others.
+// The response is always 207 Multi-Status with one Outcome per eraser; when
+// all succeed a UserDataErased event is published to EventSubject.
+func NewErasureHandler(cfg ErasureConfig) http.HandlerFunc {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	if cfg.EventSubject == "" {
+		cfg.EventSubject = "users.data_erased"
+	}
+	if cfg.Tombstones == nil {
+		cfg.Tombstones = &PostgresTombstones{}
+	}
+	names := make([]string, 0, len(cfg.Erasers))
+	for name := range cfg.Erasers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := authn.Subject(r.Context())
+		if userID == "" {
+			errcodes.Write(w, errcodes.FromRequest(r).New("UNAUTHORIZED"))
+			return
+		}
+		corr := reqctx.FromContext(r.Context())
+		log := reqctx.LoggerFromContext(r.Context()).With(zap.String("erased_user_id", userID))
+		tomb := Tombstone{UserID: userID, RequestID: corr.RequestID, ErasedAt: time.Now().UTC()}
+		if err := cfg.Tombstones.Record(r.Context(), tomb); err != nil {
+			log.Error("erasure tombstone not stored; nothing erased", zap.Error(err))
+			errcodes.Write(w, errcodes.FromRequest(r).New(errcodes.CodeInternal))
+			return
+		}
+
+		outcomes := make([]Outcome, len(names))
+		var wg sync.WaitGroup
+		for i, name := range names {
+			wg.Add(1)
+			go func(i int, name string) {
+				defer wg.Done()
+				ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+				defer cancel()
+				outcomes[i] = Outcome{Eraser: name, Status: "erased"}
+				if err := eraseSafely(ctx, cfg.Erasers[name], userID); err != nil {
+					log.Error("data eraser failed", zap.String("eraser", name), zap.Error(err))
+					outcomes[i] = Outcome{Eraser: name, Status: "failed", Error: "erasure failed; retry the request"}
+				}
+			}(i, name)
+		}
+		wg.Wait()
+
+		res := Result{UserID: userID, RequestID: corr.RequestID, Erased: true, Outcomes: outcomes}
+		for _, o := range outcomes {
+			if o.Status != "erased" {
+				res.Erased = false
+			}
+		}
+		if res.Erased {
+			publish(cfg, log, UserDataErased{Type: "UserDataErased", UserID: tomb.UserID, RequestID: tomb.RequestID, ErasedAt: tomb.ErasedAt})
+		}
+		log.Info("user data erasure finished", zap.Bool("complete", res.Erased))
+		negotiate.WriteResponse(w, http.StatusMultiStatus, res, negotiate.AcceptedType(r.Context()))
+	}
+}
+
+// eraseSafely turns a panicking eraser into a failure
+func eraseSafely(ctx context.Context, e DataEraser, userID string) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = errors.New("eraser panicked")
+		}
+	}()
+	return e.Erase(ctx, userID)
+}
+
+func publish(cfg ErasureConfig, log *zap.Logger, ev UserDataErased) {
+	data, _ := json.Marshal(ev)
+	if cfg.Publisher == nil {
+		log.Info("UserDataErased", zap.String("subject", cfg.EventSubject), zap.ByteString("event", data))
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := cfg.Publisher.Publish(ctx, cfg.EventSubject, data); err != nil {
+		log.Error("UserDataErased event not published", zap.String("subject", cfg.EventSubject), zap.Error(err))
+	}
+}
+
+// PostgresTombstones writes to the erasure_tombstones table through the pool
+// DBMiddleware puts in the request context, creating the table on first use.
+// Keep the table out of any retention job and out of the erasers.
+type PostgresTombstones struct {
+	mu    sync.Mutex
+	ready bool // table created
+}
+
+// ErrNoDatabase means no pool was in the request context
+var ErrNoDatabase = errors.New("erasure tombstones need db.DBMiddleware (db.primary_dsn)")
+
+// Record implements TombstoneStore
+func (p *PostgresTombstones) Record(ctx context.Context, t Tombstone) error {
+	pool := db.FromContext(ctx)
+	if pool == nil {
+		return ErrNoDatabase
+	}
+	if err := p.ensureTable(ctx, pool); err != nil {
+		return err
+	}
+	_, err := pool.ExecContext(ctx,
+		`INSERT INTO erasure_tombstones (user_id, request_id, erased_at) VALUES ($1, $2, $3)`,
+		t.UserID, t.RequestID, t.ErasedAt)
+	return err
+}
+
+func (p *PostgresTombstones) ensureTable(ctx context.Context, pool *db.ReplicaPool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ready {
+		return nil
+	}
+	if _, err := pool.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS erasure_tombstones (
+	id         BIGSERIAL PRIMARY KEY,
+	user_id    TEXT NOT NULL,
+	request_id TEXT NOT NULL,
+	erased_at  TIMESTAMPTZ NOT NULL
+)`); err != nil {
+		return err
+	}
+	p.ready = true
+	return nil
+}
@@ -0,0 +1,96 @@
+package erasure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/example/go-chi-rest/internal/authn"
+)
+
+type eraserFunc func(ctx context.Context, userID string) error
+
+func (f eraserFunc) Erase(ctx context.Context, userID string) error { return f(ctx, userID) }
+
+type memTombstones struct{ got []Tombstone }
+
+func (m *memTombstones) Record(_ context.Context, t Tombstone) error {
+	m.got = append(m.got, t)
+	return nil
+}
+
+func TestErasureHandler(t *testing.T) {
+	erased := make(chan string, 3)
+	ok := eraserFunc(func(_ context.Context, userID string) error {
+		erased <- userID
+		return nil
+	})
+	tombs := &memTombstones{}
+	h := NewErasureHandler(ErasureConfig{
+		Enabled: true,
+		Erasers: map[string]DataEraser{
+			"orders":  ok,
+			"profile": ok,
+			"search":  eraserFunc(func(context.Context, string) error { return errors.New("index down") }),
+		},
+		Tombstones: tombs,
+	})
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/me", nil)
+	req.Header.Set("X-User-ID", "someone-else")
+	req = req.WithContext(authn.WithPrincipal(req.Context(), authn.Principal{Subject: "user-1"}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusMultiStatus {
+		t.Fatalf("status = %d, want 207", rec.Code)
+	}
+	var res Result
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.UserID != "user-1" || res.Erased {
+		t.Errorf("result = %+v, want user-1 and erased=false", res)
+	}
+	statuses := map[string]string{}
+	for _, o := range res.Outcomes {
+		statuses[o.Eraser] = o.Status
+	}
+	want := map[string]string{"orders": "erased", "profile": "erased", "search": "failed"}
+	for name, status := range want {
+		if statuses[name] != status {
+			t.Errorf("%s: status = %q, want %q", name, statuses[name], status)
+		}
+	}
+	close(erased)
+	for userID := range erased {
+		if userID != "user-1" {
+			t.Errorf("eraser got user %q, want the authenticated user-1", userID)
+		}
+	}
+	if len(tombs.got) != 1 || tombs.got[0].UserID != "user-1" {
+		t.Errorf("tombstones = %+v", tombs.got)
+	}
+}
+
+func TestErasureHandlerIgnoresUserIDHeader(t *testing.T) {
+	tombs := &memTombstones{}
+	h := NewErasureHandler(ErasureConfig{
+		Enabled:    true,
+		Erasers:    map[string]DataEraser{"profile": eraserFunc(func(context.Context, string) error { return nil })},
+		Tombstones: tombs,
+	})
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/me", nil)
+	req.Header.Set("X-User-ID", "victim")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", rec.Code)
+	}
+	if len(tombs.got) != 0 {
+		t.Errorf("tombstone recorded for an anonymous request: %+v", tombs.got)
+	}
+}
@@ -0,0 +1,26 @@
+package errcodes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Write renders err as {"error":{"code":"…","message":"…"}} with its HTTP status
+func Write(w http.ResponseWriter, err *AppError) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(err.Status)
+	json.NewEncoder(w).Encode(map[string]*AppError{"error": err})
+}
+
+// FromRequest returns the default registry localized to the request's Accept-Language
+func FromRequest(r *http.Request) *Registry {
+	lang := r.Header.Get("Accept-Language")
+	if lang == "" {
+		return Default()
+	}
+	// first language tag, primary subtag only: "de-CH,de;q=0.9" -> "de"
+	lang = strings.TrimSpace(strings.SplitN(lang, ",", 2)[0])
+	lang = strings.SplitN(strings.SplitN(lang, ";", 2)[0], "-", 2)[0]
+	return Default().WithLocale(strings.ToLower(lang))
+}
@@ -0,0 +1,47 @@
+package errcodes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFromRequest(t *testing.T) {
+	tests := []struct {
+		header, want string
+	}{
+		{"", "access denied"},
+		{"de-CH,de;q=0.9,en;q=0.8", "Zugriff verweigert"},
+		{"DE", "Zugriff verweigert"},
+		{"fr-FR", "access denied"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Accept-Language", tt.header)
+			}
+			if got := FromRequest(req).New("FORBIDDEN").Message; got != tt.want {
+				t.Errorf("message = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Write(rec, New("RESOURCE_NOT_FOUND", "todo", "7"))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", rec.Code)
+	}
+	var body struct {
+		Error AppError `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Error.Code != "RESOURCE_NOT_FOUND" || body.Error.Message != `todo "7" not found` {
+		t.Errorf("body = %s", rec.Body)
+	}
+}
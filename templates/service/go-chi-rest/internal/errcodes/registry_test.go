@@ -0,0 +1,77 @@
+package errcodes
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name, locale, code string
+		args               []any
+		wantCode, wantMsg  string
+		wantStatus         int
+	}{
+		{"unknown code", "en", "NO_SUCH_CODE", []any{"x"}, CodeInternal, "an internal error occurred", http.StatusInternalServerError},
+		{"interpolated", "en", "RESOURCE_NOT_FOUND", []any{"todo", "42"}, "RESOURCE_NOT_FOUND", `todo "42" not found`, http.StatusNotFound},
+		{"no args", "en", "UNAUTHORIZED", nil, "UNAUTHORIZED", "authentication required", http.StatusUnauthorized},
+		{"localized", "de", "FORBIDDEN", nil, "FORBIDDEN", "Zugriff verweigert", http.StatusForbidden},
+		{"locale fallback", "fr", "FORBIDDEN", nil, "FORBIDDEN", "access denied", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Default().WithLocale(tt.locale).New(tt.code, tt.args...)
+			if err.Code != tt.wantCode || err.Message != tt.wantMsg || err.Status != tt.wantStatus {
+				t.Errorf("New(%s) = %+v, want %s %q %d", tt.code, err, tt.wantCode, tt.wantMsg, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestStatusesMatchRFCSemantics(t *testing.T) {
+	want := map[string]int{
+		CodeInternal:             http.StatusInternalServerError,
+		"INVALID_REQUEST":        http.StatusBadRequest,
+		"VALIDATION_FAILED":      http.StatusBadRequest,
+		"UNAUTHORIZED":           http.StatusUnauthorized,
+		"FORBIDDEN":              http.StatusForbidden,
+		"RESOURCE_NOT_FOUND":     http.StatusNotFound,
+		"CONFLICT":               http.StatusConflict,
+		"PAYLOAD_TOO_LARGE":      http.StatusRequestEntityTooLarge,
+		"UNSUPPORTED_MEDIA_TYPE": http.StatusUnsupportedMediaType,
+		"RATE_LIMITED":           http.StatusTooManyRequests,
+		"SERVICE_UNAVAILABLE":    http.StatusServiceUnavailable,
+		"TIMEOUT":                http.StatusGatewayTimeout,
+	}
+	r := Default()
+	for code, status := range want {
+		if got := r.Status(code); got != status {
+			t.Errorf("Status(%s) = %d, want %d", code, got, status)
+		}
+	}
+	for code, def := range r.codes {
+		if def.Status < 400 || def.Status > 599 || http.StatusText(def.Status) == "" {
+			t.Errorf("%s has status %d, want a registered 4xx/5xx", code, def.Status)
+		}
+	}
+	if got := r.Status("NO_SUCH_CODE"); got != http.StatusInternalServerError {
+		t.Errorf("Status(unknown) = %d, want 500", got)
+	}
+}
+
+func TestLoadRejectsIncompleteCatalogue(t *testing.T) {
+	tests := []struct {
+		name, yaml string
+	}{
+		{"no internal error", "NOT_FOUND:\n  status: 404\n  messages:\n    en: gone\n"},
+		{"no english message", "INTERNAL_ERROR:\n  status: 500\n  messages:\n    de: Fehler\n"},
+		{"not yaml", "INTERNAL_ERROR: ["},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Load([]byte(tt.yaml)); err == nil {
+				t.Error("Load succeeded, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package experiment
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"hash/fnv"
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+const (
+	// Control and Variant are the two cohorts a user can be assigned to
+	Control = "control"
+	Variant = "variant"
+
+	// CohortHeader lists the assignments on the response, e.g. "checkout=variant, search=control"
+	CohortHeader = "X-Experiment-Cohort"
+	// SessionCookie identifies anonymous users so their assignment stays stable
+	SessionCookie = "ab_session"
+)
+
+var assignmentsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "ab_experiment_assignments_total",
+	Help: "Experiment cohort assignments by experiment name and cohort.",
+}, []string{"name", "cohort"})
+
+// Experiment describes a two-cohort A/B test
+type Experiment struct {
+	Name          string  `mapstructure:"name"`
+	TrafficSplit  float64 `mapstructure:"traffic_split"`  // fraction of users in the variant, 0.0-1.0
+	VariantHeader string  `mapstructure:"variant_header"` // set on the request for variant users
+}
+
+type userIDKey struct{}
+type cohortsKey struct{}
+
+// WithUserID stores the authenticated user ID used for cohort assignment.
+// NewUserIDMiddleware sets it before the A/B middleware runs.
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+// NewUserIDMiddleware calls WithUserID with the ID userID returns for the
+// request (e.g. authn.Subject); empty IDs leave the request anonymous
+func NewUserIDMiddleware(userID func(context.Context) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if id := userID(r.Context()); id != "" {
+				r = r.WithContext(WithUserID(r.Context(), id))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ExperimentFromContext returns "control" or "variant" for the named experiment.
+// Unknown experiments report control.
+func ExperimentFromContext(ctx context.Context, name string) string {
+	if cohorts, ok := ctx.Value(cohortsKey{}).(map[string]string); ok {
+		if c, ok := cohorts[name]; ok {
+			return c
+		}
+	}
+	return Control
+}
+
+// NewABMiddleware assigns every request to a cohort per experiment. Assignment is
+// a stable hash of the user ID (from context) or, for anonymous users, a session cookie.
+func NewABMiddleware(experiments []Experiment) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(experiments) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			userID, _ := r.Context().Value(userIDKey{}).(string)
+			if userID == "" {
+				var err error
+				if userID, err = sessionID(w, r); err != nil {
+					// without an ID every anonymous user would hash alike;
+					// leave them unassigned, which reads as control
+					zap.L().Error("experiment session id", zap.Error(err))
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			cohorts := make(map[string]string, len(experiments))
+			labels := make([]string, 0, len(experiments))
+			for _, exp := range experiments {
+				cohort := Assign(exp, userID)
+				cohorts[exp.Name] = cohort
+				labels = append(labels, exp.Name+"="+cohort)
+				if cohort == Variant && exp.VariantHeader != "" {
+					r.Header.Set(exp.VariantHeader, "true")
+				}
+				assignmentsTotal.WithLabelValues(exp.Name, cohort).Inc()
+			}
+
+			w.Header().Set(CohortHeader, strings.Join(labels, ", "))
+			zap.L().Debug("experiment assignment", zap.String("user_id", userID), zap.Any("cohorts", cohorts))
+
+			ctx := context.WithValue(r.Context(), cohortsKey{}, cohorts)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Assign returns the cohort for userID: hash(name:userID) mod 100 against the split
+func Assign(exp Experiment, userID string) string {
+	h := fnv.New32a()
+	h.Write([]byte(exp.Name + ":" + userID))
+	if float64(h.Sum32()%100) < exp.TrafficSplit*100 {
+		return Variant
+	}
+	return Control
+}
+
+// sessionID returns the A/B session cookie, issuing one if missing
+func sessionID(w http.ResponseWriter, r *http.Request) (string, error) {
+	if c, err := r.Cookie(SessionCookie); err == nil && c.Value != "" {
+		return c.Value, nil
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(b)
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Value:    id,
+		Path:     "/",
+		MaxAge:   90 * 24 * 3600,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return id, nil
+}
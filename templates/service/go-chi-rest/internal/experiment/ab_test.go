@@ -0,0 +1,75 @@
+package experiment
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestAssignSplit(t *testing.T) {
+	exp := Experiment{Name: "checkout", TrafficSplit: 0.3}
+	variants := 0
+	for i := 0; i < 10000; i++ {
+		if Assign(exp, "user-"+strconv.Itoa(i)) == Variant {
+			variants++
+		}
+	}
+	if variants < 2500 || variants > 3500 {
+		t.Errorf("%d of 10000 in variant, want about 3000", variants)
+	}
+	if Assign(Experiment{Name: "off"}, "u1") != Control || Assign(Experiment{Name: "all", TrafficSplit: 1}, "u1") != Variant {
+		t.Error("0 and 1 splits not honoured")
+	}
+}
+
+// subjectKey stands in for the authn principal
+type subjectKey struct{}
+
+func TestABMiddlewareUsesSubject(t *testing.T) {
+	exps := []Experiment{{Name: "checkout", TrafficSplit: 0.5, VariantHeader: "X-Checkout-Variant"}}
+	var cohort string
+	h := NewUserIDMiddleware(func(ctx context.Context) string { return ctx.Value(subjectKey{}).(string) })(
+		NewABMiddleware(exps)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cohort = ExperimentFromContext(r.Context(), "checkout")
+		})))
+
+	for _, user := range []string{"alice", "bob", "carol", "dave"} {
+		want := Assign(exps[0], user)
+		for i := 0; i < 3; i++ {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/ping", nil)
+			req = req.WithContext(context.WithValue(req.Context(), subjectKey{}, user))
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if cohort != want {
+				t.Fatalf("%s: cohort %s, want %s", user, cohort, want)
+			}
+			if rec.Header().Get(CohortHeader) != "checkout="+want {
+				t.Errorf("%s: header %q", user, rec.Header().Get(CohortHeader))
+			}
+			if len(rec.Result().Cookies()) != 0 {
+				t.Errorf("%s: session cookie issued to an authenticated user", user)
+			}
+		}
+	}
+}
+
+func TestABMiddlewareAnonymousSession(t *testing.T) {
+	h := NewABMiddleware([]Experiment{{Name: "checkout", TrafficSplit: 0.5}})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != SessionCookie || len(cookies[0].Value) != 32 {
+		t.Fatalf("cookies = %v", cookies)
+	}
+	first := rec.Header().Get(CohortHeader)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookies[0])
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Header().Get(CohortHeader) != first || len(rec.Result().Cookies()) != 0 {
+		t.Errorf("returning session: cohort %q (was %q), cookies %v", rec.Header().Get(CohortHeader), first, rec.Result().Cookies())
+	}
+}
@@ -0,0 +1,91 @@
+// Package grpcclient hands handlers a shared gRPC connection and derives the
+// deadline of their gRPC calls from the incoming HTTP request, so a
+// downstream service stops working once the caller has given up
+package grpcclient
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// TimeoutHeader carries the caller's remaining budget in milliseconds
+const TimeoutHeader = "Request-Timeout"
+
+// GRPCClientConfig configures the downstream gRPC connection
+type GRPCClientConfig struct {
+	Target string `mapstructure:"target"` // e.g. dns:///orders:9090; empty disables the client
+
+	// Conn is dialled from Target by NewRouter when nil
+	Conn *grpc.ClientConn `mapstructure:"-"`
+}
+
+// Dial creates the connection for cfg. It uses plaintext; a service mesh
+// sidecar adds mTLS, otherwise set Conn with your own credentials.
+func Dial(cfg GRPCClientConfig) (*grpc.ClientConn, error) {
+	return grpc.NewClient(cfg.Target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+}
+
+type contextKey int
+
+const connKey contextKey = iota
+
+// ConnFromContext returns the connection injected by GRPCClientMiddleware, or nil
+func ConnFromContext(ctx context.Context) *grpc.ClientConn {
+	conn, _ := ctx.Value(connKey).(*grpc.ClientConn)
+	return conn
+}
+
+// GRPCClientMiddleware puts conn in the request context and, when the request
+// carries a valid Request-Timeout header earlier than the context's own
+// deadline, bounds the request context by it. Handlers then build each call's
+// context with NewGRPCCallContext.
+func GRPCClientMiddleware(conn *grpc.ClientConn) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), connKey, conn)
+			if deadline, ok := headerDeadline(r); ok {
+				if current, has := ctx.Deadline(); !has || deadline.Before(current) {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithDeadline(ctx, deadline)
+					defer cancel()
+				}
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// NewGRPCCallContext returns the context for a gRPC call made while serving
+// httpReq. Its deadline is the earlier of ctx's deadline and the
+// Request-Timeout header, minus subtractMs for the network hop; a deadline
+// that is already past makes the call fail with codes.DeadlineExceeded
+// without reaching the server. Without either deadline ctx is only made
+// cancellable.
+func NewGRPCCallContext(ctx context.Context, httpReq *http.Request, subtractMs int) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if hd, hok := headerDeadline(httpReq); hok && (!ok || hd.Before(deadline)) {
+		deadline, ok = hd, true
+	}
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, deadline.Add(-time.Duration(subtractMs)*time.Millisecond))
+}
+
+// headerDeadline reads Request-Timeout relative to now; missing, malformed or
+// non-positive values are ignored
+func headerDeadline(r *http.Request) (time.Time, bool) {
+	if r == nil {
+		return time.Time{}, false
+	}
+	ms, err := strconv.ParseInt(r.Header.Get(TimeoutHeader), 10, 64)
+	if err != nil || ms <= 0 {
+		return time.Time{}, false
+	}
+	return time.Now().Add(time.Duration(ms) * time.Millisecond), true
+}
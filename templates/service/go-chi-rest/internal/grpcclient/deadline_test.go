@@ -0,0 +1,130 @@
+package grpcclient
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// slowHealth answers Check after delay, or fails when the call's context ends first
+type slowHealth struct {
+	healthpb.UnimplementedHealthServer
+	delay time.Duration
+	calls atomic.Int32
+}
+
+func (s *slowHealth) Check(ctx context.Context, _ *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	s.calls.Add(1)
+	select {
+	case <-time.After(s.delay):
+		return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+func newSlowServer(t *testing.T, delay time.Duration) (*grpc.ClientConn, *slowHealth) {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	health := &slowHealth{delay: delay}
+	healthpb.RegisterHealthServer(srv, health)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, health
+}
+
+func TestNewGRPCCallContext(t *testing.T) {
+	tests := []struct {
+		name       string
+		timeoutMs  string // Request-Timeout header
+		subtractMs int
+		wantCode   codes.Code
+		wantCalls  int32
+	}{
+		{"no deadline", "", 0, codes.OK, 1},
+		{"enough budget", "2000", 10, codes.OK, 1},
+		{"slow server", "100", 10, codes.DeadlineExceeded, 1},
+		{"adjusted deadline in the past", "50", 100, codes.DeadlineExceeded, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, health := newSlowServer(t, 200*time.Millisecond)
+			// warm the connection so the calls below only measure the RPC
+			if _, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+				t.Fatal(err)
+			}
+			health.calls.Store(0)
+
+			var code codes.Code
+			h := GRPCClientMiddleware(conn)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctx, cancel := NewGRPCCallContext(r.Context(), r, tt.subtractMs)
+				defer cancel()
+				_, err := healthpb.NewHealthClient(ConnFromContext(r.Context())).Check(ctx, &healthpb.HealthCheckRequest{})
+				code = status.Code(err)
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/orders", nil)
+			if tt.timeoutMs != "" {
+				req.Header.Set(TimeoutHeader, tt.timeoutMs)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			if code != tt.wantCode {
+				t.Errorf("code = %v, want %v", code, tt.wantCode)
+			}
+			if n := health.calls.Load(); n != tt.wantCalls {
+				t.Errorf("server saw %d calls, want %d", n, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestGRPCClientMiddlewareDeadline(t *testing.T) {
+	parent, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	parentDeadline, _ := parent.Deadline()
+	tests := []struct {
+		name, header string
+		want         func(deadline time.Time, ok bool) bool
+	}{
+		{"header earlier", "100", func(d time.Time, ok bool) bool { return ok && time.Until(d) <= 100*time.Millisecond }},
+		{"header later", "60000", func(d time.Time, ok bool) bool { return ok && d.Equal(parentDeadline) }},
+		{"malformed", "soon", func(d time.Time, ok bool) bool { return ok && d.Equal(parentDeadline) }},
+		{"negative", "-5", func(d time.Time, ok bool) bool { return ok && d.Equal(parentDeadline) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deadline time.Time
+			var ok bool
+			h := GRPCClientMiddleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				deadline, ok = r.Context().Deadline()
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(parent)
+			req.Header.Set(TimeoutHeader, tt.header)
+			h.ServeHTTP(httptest.NewRecorder(), req)
+			if !tt.want(deadline, ok) {
+				t.Errorf("deadline = %v (%v), parent %v", deadline, ok, parentDeadline)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+// Package h3 serves the router over HTTP/3 (QUIC) next to the HTTP/1.1 and
+// HTTP/2 server. It is experimental and only compiled into binaries built
+// with the http3 tag; other builds ignore http3.enabled.
+package h3
+
+import (
+	"net"
+	"net/http"
+)
+
+// HTTP3Config configures the HTTP/3 listener
+type HTTP3Config struct {
+	Enabled     bool   `mapstructure:"enabled"`       // needs a build with -tags http3
+	Addr        string `mapstructure:"addr"`          // UDP address; defaults to bind_addr
+	TLSCertFile string `mapstructure:"tls_cert_file"` // defaults to tls.cert_file
+	TLSKeyFile  string `mapstructure:"tls_key_file"`  // defaults to tls.key_file
+}
+
+// AltSvc returns middleware advertising HTTP/3 on the port of addr with
+// `Alt-Svc: h3=":<port>"; ma=86400` on responses not already served over
+// HTTP/3. The host is left out so clients reconnect to the name they used.
+func AltSvc(addr string) func(http.Handler) http.Handler {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		port = addr
+	}
+	value := `h3=":` + port + `"; ma=86400`
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ProtoMajor < 3 {
+				w.Header().Set("Alt-Svc", value)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package h3
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAltSvc(t *testing.T) {
+	tests := []struct {
+		name, addr string
+		protoMajor int
+		want       string
+	}{
+		{"host and port", "0.0.0.0:8443", 1, `h3=":8443"; ma=86400`},
+		{"port only", ":443", 2, `h3=":443"; ma=86400`},
+		{"bare port", "9443", 1, `h3=":9443"; ma=86400`},
+		{"over http3", ":443", 3, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := AltSvc(tt.addr)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.ProtoMajor = tt.protoMajor
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if got := rec.Header().Get("Alt-Svc"); got != tt.want {
+				t.Errorf("Alt-Svc = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
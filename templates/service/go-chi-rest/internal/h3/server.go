@@ -0,0 +1,45 @@
+//go:build http3
+
+package h3
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+// Built reports whether the binary was built with the http3 tag
+func Built() bool { return true }
+
+// Server is an HTTP/3 server bound to its UDP socket
+type Server struct {
+	srv  *http3.Server
+	conn net.PacketConn
+}
+
+// Listen binds cfg.Addr over UDP and returns a server for handler. The
+// certificate comes from tlsConfig, which is copied with its ALPN set to h3.
+func Listen(cfg HTTP3Config, handler http.Handler, tlsConfig *tls.Config) (*Server, error) {
+	conn, err := net.ListenPacket("udp", cfg.Addr)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{
+		srv:  &http3.Server{Handler: handler, TLSConfig: http3.ConfigureTLSConfig(tlsConfig)},
+		conn: conn,
+	}, nil
+}
+
+// Serve handles connections until Shutdown and then returns
+// http.ErrServerClosed
+func (s *Server) Serve() error { return s.srv.Serve(s.conn) }
+
+// Shutdown sends GOAWAY to every connection and waits until in-flight
+// requests finish or ctx is done
+func (s *Server) Shutdown(ctx context.Context) error {
+	defer s.conn.Close()
+	return s.srv.Shutdown(ctx)
+}
@@ -0,0 +1,29 @@
+//go:build !http3
+
+package h3
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/http"
+)
+
+// Built reports whether the binary was built with the http3 tag
+func Built() bool { return false }
+
+// Server is a placeholder; builds without the http3 tag cannot serve HTTP/3
+type Server struct{}
+
+var errNotBuilt = errors.New("h3: built without the http3 tag")
+
+// Listen always fails without the http3 tag
+func Listen(HTTP3Config, http.Handler, *tls.Config) (*Server, error) {
+	return nil, errNotBuilt
+}
+
+// Serve always fails without the http3 tag
+func (s *Server) Serve() error { return errNotBuilt }
+
+// Shutdown does nothing without the http3 tag
+func (s *Server) Shutdown(context.Context) error { return nil }
@@ -0,0 +1,14 @@
+//go:build !http3
+
+package h3
+
+import "testing"
+
+func TestListenNotBuilt(t *testing.T) {
+	if Built() {
+		t.Fatal("Built() = true without the http3 tag")
+	}
+	if _, err := Listen(HTTP3Config{Addr: "127.0.0.1:0"}, nil, nil); err == nil {
+		t.Error("Listen succeeded without the http3 tag")
+	}
+}
@@ -0,0 +1,249 @@
+// Package har records served requests as HTTP Archive (HAR 1.2) entries so
+// that traffic can be inspected in browser dev tools or replayed with the CLI
+// tool's replay command. Like debugcapture, it is meant for development and
+// staging only: headers and bodies may contain credentials or personal data.
+package har
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Version is the HAR spec version written in Log.Version
+const Version = "1.2"
+
+// MaxEntries is how many entries DefaultStore keeps
+const MaxEntries = 1000
+
+// DefaultStore receives the entries recorded by the router and is served by
+// Handler
+var DefaultStore = NewMemoryHARStore(MaxEntries)
+
+// HAR is a HAR document
+type HAR struct {
+	Log Log `json:"log"`
+}
+
+// Log is the root of a HAR document
+type Log struct {
+	Version string  `json:"version"`
+	Creator Creator `json:"creator"`
+	Entries []Entry `json:"entries"`
+}
+
+// Creator names the application that wrote the log
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// NameValue is a header, query parameter or cookie
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// PostData is a captured request body
+type PostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// Request is the request half of an entry
+type Request struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Headers     []NameValue `json:"headers"`
+	QueryString []NameValue `json:"queryString"`
+	Cookies     []NameValue `json:"cookies"`
+	PostData    *PostData   `json:"postData,omitempty"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int64       `json:"bodySize"`
+}
+
+// Content is a captured response body
+type Content struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// Response is the response half of an entry
+type Response struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Headers     []NameValue `json:"headers"`
+	Cookies     []NameValue `json:"cookies"`
+	Content     Content     `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int64       `json:"bodySize"`
+}
+
+// Timings are phase durations in milliseconds; -1 means not applicable
+type Timings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// Entry is one request/response exchange
+type Entry struct {
+	StartedDateTime string   `json:"startedDateTime"`
+	Time            float64  `json:"time"` // total milliseconds
+	Request         Request  `json:"request"`
+	Response        Response `json:"response"`
+	Cache           struct{} `json:"cache"`
+	Timings         Timings  `json:"timings"`
+	ServerIPAddress string   `json:"serverIPAddress,omitempty"`
+	RequestID       string   `json:"_requestId,omitempty"` // custom field, see the HAR spec
+	Comment         string   `json:"comment,omitempty"`
+}
+
+// HARStore receives recorded entries
+type HARStore interface {
+	Add(Entry) error
+}
+
+// Stores fans an entry out to several stores and joins their errors
+type Stores []HARStore
+
+// Add adds e to every store
+func (s Stores) Add(e Entry) error {
+	var errs []error
+	for _, st := range s {
+		if err := st.Add(e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// MemoryHARStore keeps the most recent entries in a ring buffer
+type MemoryHARStore struct {
+	mu      sync.Mutex
+	entries []Entry
+	next    int
+	full    bool
+}
+
+// NewMemoryHARStore returns a store keeping at most size entries
+func NewMemoryHARStore(size int) *MemoryHARStore {
+	if size <= 0 {
+		size = MaxEntries
+	}
+	return &MemoryHARStore{entries: make([]Entry, size)}
+}
+
+// Add records e, overwriting the oldest entry when full
+func (s *MemoryHARStore) Add(e Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[s.next] = e
+	s.next = (s.next + 1) % len(s.entries)
+	if s.next == 0 {
+		s.full = true
+	}
+	return nil
+}
+
+// Entries returns the stored entries, oldest first
+func (s *MemoryHARStore) Entries() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.full {
+		return append([]Entry(nil), s.entries[:s.next]...)
+	}
+	out := make([]Entry, 0, len(s.entries))
+	out = append(out, s.entries[s.next:]...)
+	return append(out, s.entries[:s.next]...)
+}
+
+// Write writes the stored entries as a HAR document
+func (s *MemoryHARStore) Write(w io.Writer, creator Creator) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(HAR{Log: Log{Version: Version, Creator: creator, Entries: s.Entries()}})
+}
+
+// harTrailer closes the entries array and the document; FileHARStore writes
+// each entry in front of it so the file is valid JSON after every Add
+const harTrailer = "\n]}}\n"
+
+// FileHARStore appends entries to a .har file
+type FileHARStore struct {
+	mu    sync.Mutex
+	f     *os.File
+	count int
+}
+
+// NewFileHARStore creates (or truncates) path and writes an empty HAR log to it
+func NewFileHARStore(path string, creator Creator) (*FileHARStore, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	head, err := json.Marshal(struct {
+		Version string  `json:"version"`
+		Creator Creator `json:"creator"`
+	}{Version, creator})
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	// {"log":{"version":…,"creator":{…},"entries":[ + trailer
+	header := `{"log":` + string(head[:len(head)-1]) + `,"entries":[`
+	if _, err := f.WriteString(header + harTrailer); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &FileHARStore{f: f}, nil
+}
+
+// Add writes e before the trailer
+func (s *FileHARStore) Add(e Entry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.f.Seek(-int64(len(harTrailer)), io.SeekEnd); err != nil {
+		return fmt.Errorf("har file: %w", err)
+	}
+	sep := "\n"
+	if s.count > 0 {
+		sep = ",\n"
+	}
+	if _, err := s.f.WriteString(sep + string(b) + harTrailer); err != nil {
+		return fmt.Errorf("har file: %w", err)
+	}
+	s.count++
+	return nil
+}
+
+// Close closes the file
+func (s *FileHARStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Close()
+}
+
+// HARConfig enables request recording outside production
+type HARConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	File    string `mapstructure:"file"` // also append entries to this .har file
+	// Store receives the entries; the router uses DefaultStore when nil
+	Store HARStore `mapstructure:"-"`
+}
@@ -0,0 +1,111 @@
+package har
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func record(t *testing.T, store HARStore) {
+	t.Helper()
+	h := NewHARRecorder(store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s3cret"})
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/items?q=1", strings.NewReader(`{"name":"a"}`))
+	req.Header.Set("Authorization", "Bearer t0ken")
+	req.Header.Set("Cookie", "session=abc")
+	req.Header.Set("X-Request-ID", "req-1")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestHandlerWritesValidHAR(t *testing.T) {
+	DefaultStore = NewMemoryHARStore(10)
+	record(t, DefaultStore)
+
+	rec := httptest.NewRecorder()
+	Handler(Creator{Name: "go-chi-rest", Version: "test"})(rec, httptest.NewRequest(http.MethodGet, "/debug/har", nil))
+	var doc HAR
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid HAR JSON: %v", err)
+	}
+	if doc.Log.Version != Version || doc.Log.Creator.Name != "go-chi-rest" || len(doc.Log.Entries) != 1 {
+		t.Fatalf("log = %+v", doc.Log)
+	}
+	e := doc.Log.Entries[0]
+	if e.Request.PostData == nil || e.Request.PostData.Text != `{"name":"a"}` || e.Response.Content.Text != `{"name":"a"}` {
+		t.Errorf("bodies not recorded: %+v", e)
+	}
+	if e.Response.Status != http.StatusCreated {
+		t.Errorf("status = %d", e.Response.Status)
+	}
+}
+
+func TestRecorderRedactsCredentials(t *testing.T) {
+	store := NewMemoryHARStore(10)
+	record(t, store)
+	e := store.Entries()[0]
+
+	tests := []struct {
+		name    string
+		headers []NameValue
+		header  string
+		want    string
+	}{
+		{"authorization", e.Request.Headers, "Authorization", redacted},
+		{"request cookie", e.Request.Headers, "Cookie", redacted},
+		{"set-cookie", e.Response.Headers, "Set-Cookie", redacted},
+		{"other header kept", e.Request.Headers, "X-Request-Id", "req-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, nv := range tt.headers {
+				if strings.EqualFold(nv.Name, tt.header) {
+					if nv.Value != tt.want {
+						t.Errorf("%s = %q, want %q", tt.header, nv.Value, tt.want)
+					}
+					return
+				}
+			}
+			t.Errorf("%s not recorded", tt.header)
+		})
+	}
+	for _, c := range append(e.Request.Cookies, e.Response.Cookies...) {
+		if c.Value != redacted {
+			t.Errorf("cookie %s = %q", c.Name, c.Value)
+		}
+	}
+}
+
+func TestFileHARStoreIsValidAfterEachAdd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "requests.har")
+	store, err := NewFileHARStore(path, Creator{Name: "go-chi-rest"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	for want := 0; want < 3; want++ {
+		if want > 0 {
+			record(t, store)
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var doc HAR
+		if err := json.Unmarshal(b, &doc); err != nil {
+			t.Fatalf("after %d entries: %v", want, err)
+		}
+		if len(doc.Log.Entries) != want {
+			t.Errorf("entries = %d, want %d", len(doc.Log.Entries), want)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package har
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/example/go-chi-rest/internal/reqctx"
+)
+
+// MaxBodyBytes bounds the request and response body text kept per entry
+const MaxBodyBytes = 64 * 1024
+
+// redacted replaces credential header and cookie values in entries
+const redacted = "[REDACTED]"
+
+// CredentialHeaders are recorded with their values replaced by [REDACTED];
+// cookie values are always redacted
+var CredentialHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "X-Auth-Token"}
+
+// NewHARRecorder records every request passing through as an Entry in store.
+// Up to MaxBodyBytes of each body is kept; the request body is buffered
+// before the handler runs and handed on unchanged. DNS, connect and TLS
+// timings are client-side phases and are recorded as -1; send is the time
+// spent reading the captured request body, wait the time to the first
+// response byte, receive the rest of the handler. CredentialHeaders and cookie
+// values are redacted. Do not mount it in production.
+func NewHARRecorder(store HARStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			started := time.Now()
+
+			var reqBody []byte
+			if r.Body != nil && r.Body != http.NoBody {
+				reqBody, _ = io.ReadAll(io.LimitReader(r.Body, MaxBodyBytes))
+				r.Body = readCloser{io.MultiReader(bytes.NewReader(reqBody), r.Body), r.Body}
+			}
+			sent := time.Now()
+
+			rw := &recordWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rw, r)
+			done := time.Now()
+			if rw.firstByte.IsZero() {
+				rw.firstByte = done
+			}
+
+			entry := Entry{
+				StartedDateTime: started.UTC().Format(time.RFC3339Nano),
+				Time:            ms(done.Sub(started)),
+				Request:         harRequest(r, reqBody),
+				Response:        harResponse(r, rw),
+				Timings: Timings{
+					Blocked: -1, DNS: -1, Connect: -1, SSL: -1,
+					Send:    ms(sent.Sub(started)),
+					Wait:    ms(rw.firstByte.Sub(sent)),
+					Receive: ms(done.Sub(rw.firstByte)),
+				},
+				RequestID: reqctx.FromContext(r.Context()).RequestID,
+			}
+			if err := store.Add(entry); err != nil {
+				zap.L().Warn("har record failed", zap.Error(err))
+			}
+		})
+	}
+}
+
+// Handler serves GET /debug/har: DefaultStore as a .har download
+func Handler(creator Creator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", `attachment; filename="requests.har"`)
+		DefaultStore.Write(w, creator)
+	}
+}
+
+func harRequest(r *http.Request, body []byte) Request {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	req := Request{
+		Method:      r.Method,
+		URL:         scheme + "://" + r.Host + r.URL.RequestURI(),
+		HTTPVersion: r.Proto,
+		Headers:     headerValues(r.Header),
+		QueryString: nameValues(r.URL.Query()),
+		Cookies:     []NameValue{},
+		HeadersSize: -1,
+		BodySize:    r.ContentLength,
+	}
+	for _, c := range r.Cookies() {
+		req.Cookies = append(req.Cookies, NameValue{Name: c.Name, Value: redacted})
+	}
+	if len(body) > 0 {
+		req.PostData = &PostData{MimeType: r.Header.Get("Content-Type"), Text: string(body)}
+	}
+	return req
+}
+
+func harResponse(r *http.Request, rw *recordWriter) Response {
+	h := rw.Header()
+	resp := Response{
+		Status:      rw.status,
+		StatusText:  http.StatusText(rw.status),
+		HTTPVersion: r.Proto,
+		Headers:     headerValues(h),
+		Cookies:     []NameValue{},
+		Content: Content{
+			Size:     rw.size,
+			MimeType: h.Get("Content-Type"),
+			Text:     rw.buf.String(),
+		},
+		RedirectURL: h.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    rw.size,
+	}
+	if rw.size > int64(rw.buf.Len()) {
+		resp.Content.Comment = "truncated"
+	}
+	for _, c := range (&http.Response{Header: h}).Cookies() {
+		resp.Cookies = append(resp.Cookies, NameValue{Name: c.Name, Value: redacted})
+	}
+	return resp
+}
+
+func nameValues(m map[string][]string) []NameValue {
+	out := make([]NameValue, 0, len(m))
+	for k, vs := range m {
+		for _, v := range vs {
+			out = append(out, NameValue{Name: k, Value: v})
+		}
+	}
+	return out
+}
+
+// headerValues is nameValues with CredentialHeaders redacted
+func headerValues(h http.Header) []NameValue {
+	out := nameValues(h)
+	for i := range out {
+		for _, name := range CredentialHeaders {
+			if strings.EqualFold(out[i].Name, name) {
+				out[i].Value = redacted
+			}
+		}
+	}
+	return out
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// readCloser reads the buffered prefix and the rest of the original body
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// recordWriter notes the status and first-byte time and tees up to
+// MaxBodyBytes of the body
+type recordWriter struct {
+	http.ResponseWriter
+	status    int
+	firstByte time.Time
+	buf       bytes.Buffer
+	size      int64
+}
+
+func (rw *recordWriter) WriteHeader(code int) {
+	if rw.firstByte.IsZero() {
+		rw.firstByte = time.Now()
+		rw.status = code
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordWriter) Write(b []byte) (int, error) {
+	if rw.firstByte.IsZero() {
+		rw.firstByte = time.Now()
+	}
+	if room := MaxBodyBytes - rw.buf.Len(); room > 0 {
+		if len(b) > room {
+			rw.buf.Write(b[:room])
+		} else {
+			rw.buf.Write(b)
+		}
+	}
+	n, err := rw.ResponseWriter.Write(b)
+	rw.size += int64(n)
+	return n, err
+}
+
+// Flush keeps streaming handlers working behind the recorder
+func (rw *recordWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (rw *recordWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
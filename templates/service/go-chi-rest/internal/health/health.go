@@ -0,0 +1,250 @@
+// Package health serves the Kubernetes liveness, readiness and startup probes.
+//
+//   - /healthz (liveness): the process is alive; fails only once a deadlock has been
+//     reported, so Kubernetes restarts the pod instead of waiting forever
+//   - /readyz (readiness): every registered dependency check passes; a failing
+//     critical check returns 503 and takes the pod out of the Service endpoints,
+//     a failing warning check only reports "degraded" with 200
+//   - /startupz (startup): 503 until MarkStarted is called after initialization
+//     (migrations, cache warm-up), holding off the other two probes meanwhile
+//
+// Matching container probes:
+//
+//	startupProbe:
+//	  httpGet: { path: /startupz, port: http }
+//	  periodSeconds: 5
+//	  failureThreshold: 60   # allow up to 5m for startup
+//	livenessProbe:
+//	  httpGet: { path: /healthz, port: http }
+//	  periodSeconds: 10
+//	  failureThreshold: 3
+//	readinessProbe:
+//	  httpGet: { path: /readyz, port: http }
+//	  periodSeconds: 5
+//	  failureThreshold: 2
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+var checkStatus = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "health_check_status",
+	Help: "Result of the last run of each dependency check: 1 passing, 0 failing.",
+}, []string{"name", "severity"})
+
+// Severity decides what a failing check does to /readyz
+type Severity string
+
+const (
+	// Critical checks fail readiness (503)
+	Critical Severity = "critical"
+	// Warning checks only mark the service degraded (200)
+	Warning Severity = "warning"
+)
+
+// HealthConfig configures the dependency checks run by /readyz
+type HealthConfig struct {
+	CheckTimeout time.Duration `mapstructure:"check_timeout"` // per check, default 2s
+}
+
+// CheckFunc reports whether a dependency is usable
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name     string
+	severity Severity
+	fn       CheckFunc
+}
+
+// CheckResult is the outcome of one dependency check
+type CheckResult struct {
+	Status   string   `json:"status"`
+	Severity Severity `json:"severity"`
+	Critical bool     `json:"critical"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// HealthChecker runs the registered dependency checks and tracks the
+// startup and deadlock state reported by the application
+type HealthChecker struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks []check
+	// outcome of the last Check
+	failing, degraded bool
+
+	started          atomic.Bool
+	deadlockDetected atomic.Bool
+}
+
+// NewHealthChecker returns a checker with no dependencies registered
+func NewHealthChecker(cfg HealthConfig) *HealthChecker {
+	if cfg.CheckTimeout <= 0 {
+		cfg.CheckTimeout = 2 * time.Second
+	}
+	return &HealthChecker{timeout: cfg.CheckTimeout}
+}
+
+// Register adds a dependency check to /readyz; critical selects
+// RegisterCritical over RegisterWarning
+func (h *HealthChecker) Register(name string, critical bool, fn CheckFunc) {
+	if critical {
+		h.RegisterCritical(name, fn)
+		return
+	}
+	h.RegisterWarning(name, fn)
+}
+
+// RegisterCritical adds a check whose failure makes /readyz return 503
+func (h *HealthChecker) RegisterCritical(name string, fn CheckFunc) {
+	h.register(name, Critical, fn)
+}
+
+// RegisterWarning adds a check whose failure keeps /readyz at 200 but reports
+// the service as degraded
+func (h *HealthChecker) RegisterWarning(name string, fn CheckFunc) {
+	h.register(name, Warning, fn)
+}
+
+func (h *HealthChecker) register(name string, severity Severity, fn CheckFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, check{name: name, severity: severity, fn: fn})
+}
+
+// Failing reports whether a critical check failed in the last Check
+func (h *HealthChecker) Failing() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.failing
+}
+
+// Degraded reports whether only warning checks failed in the last Check
+func (h *HealthChecker) Degraded() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.degraded && !h.failing
+}
+
+// MarkStarted flips /startupz to 200; call it once initialization has finished
+func (h *HealthChecker) MarkStarted() {
+	h.started.Store(true)
+}
+
+// SetDeadlockDetected is called by the serving goroutine's watchdog when it
+// stops making progress; /healthz fails from then on
+func (h *HealthChecker) SetDeadlockDetected() {
+	h.deadlockDetected.Store(true)
+}
+
+// Check runs every registered check concurrently, each bounded by the check
+// timeout, and reports whether all critical checks passed. The outcome is
+// kept for Failing and Degraded.
+func (h *HealthChecker) Check(ctx context.Context) (map[string]CheckResult, bool) {
+	h.mu.RLock()
+	checks := append([]check(nil), h.checks...)
+	h.mu.RUnlock()
+
+	results := make(map[string]CheckResult, len(checks))
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	healthy, degraded := true, false
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c check) {
+			defer wg.Done()
+			cctx, cancel := context.WithTimeout(ctx, h.timeout)
+			defer cancel()
+			res := CheckResult{Status: "ok", Severity: c.severity, Critical: c.severity == Critical}
+			gauge := checkStatus.WithLabelValues(c.name, string(c.severity))
+			if err := c.fn(cctx); err != nil {
+				res.Status = "failing"
+				res.Error = err.Error()
+				gauge.Set(0)
+				zap.L().Warn("health check failed", zap.String("check", c.name), zap.String("severity", string(c.severity)), zap.Error(err))
+			} else {
+				gauge.Set(1)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			results[c.name] = res
+			if res.Status != "ok" {
+				if res.Critical {
+					healthy = false
+				} else {
+					degraded = true
+				}
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	h.mu.Lock()
+	h.failing, h.degraded = !healthy, degraded
+	h.mu.Unlock()
+	return results, healthy
+}
+
+// Liveness serves /healthz: 200 unless a deadlock has been reported. It runs
+// no dependency checks, so a database outage never restarts the pod.
+func (h *HealthChecker) Liveness(w http.ResponseWriter, r *http.Request) {
+	if h.deadlockDetected.Load() {
+		writeStatus(w, http.StatusServiceUnavailable, map[string]any{"status": "deadlocked"})
+		return
+	}
+	writeStatus(w, http.StatusOK, map[string]any{"status": "ok"})
+}
+
+// Readiness serves /readyz: 503 until started or while a critical check
+// fails, otherwise 200 with status "degraded" when a warning check fails and
+// "ready" when all pass. Per-check results are in the body.
+func (h *HealthChecker) Readiness(w http.ResponseWriter, r *http.Request) {
+	if !h.started.Load() {
+		writeStatus(w, http.StatusServiceUnavailable, map[string]any{"status": "starting"})
+		return
+	}
+	// status comes from this call's results, not Failing/Degraded, which a
+	// concurrent Check may already have overwritten
+	results, healthy := h.Check(r.Context())
+	degraded := false
+	for _, res := range results {
+		degraded = degraded || (res.Status != "ok" && !res.Critical)
+	}
+	switch {
+	case !healthy:
+		writeStatus(w, http.StatusServiceUnavailable, map[string]any{"status": "unavailable", "checks": results})
+	case degraded:
+		writeStatus(w, http.StatusOK, map[string]any{"status": "degraded", "checks": results})
+	default:
+		writeStatus(w, http.StatusOK, map[string]any{"status": "ready", "checks": results})
+	}
+}
+
+// Startup serves /startupz: 503 until MarkStarted has been called
+func (h *HealthChecker) Startup(w http.ResponseWriter, r *http.Request) {
+	if !h.started.Load() {
+		writeStatus(w, http.StatusServiceUnavailable, map[string]any{"status": "starting"})
+		return
+	}
+	writeStatus(w, http.StatusOK, map[string]any{"status": "started"})
+}
+
+func writeStatus(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
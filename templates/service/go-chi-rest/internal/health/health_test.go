@@ -0,0 +1,124 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func readiness(h *HealthChecker) (int, string) {
+	rec := httptest.NewRecorder()
+	h.Readiness(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var body struct{ Status string }
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	return rec.Code, body.Status
+}
+
+func TestReadiness(t *testing.T) {
+	fail := func(context.Context) error { return errors.New("down") }
+	pass := func(context.Context) error { return nil }
+	tests := []struct {
+		name       string
+		setup      func(h *HealthChecker)
+		wantCode   int
+		wantStatus string
+	}{
+		{"ready", func(h *HealthChecker) { h.RegisterCritical("db", pass) }, http.StatusOK, "ready"},
+		{"degraded", func(h *HealthChecker) { h.RegisterCritical("db", pass); h.RegisterWarning("cache", fail) }, http.StatusOK, "degraded"},
+		{"critical fails", func(h *HealthChecker) { h.RegisterCritical("db", fail); h.RegisterWarning("cache", fail) }, http.StatusServiceUnavailable, "unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHealthChecker(HealthConfig{})
+			tt.setup(h)
+			h.MarkStarted()
+			if code, status := readiness(h); code != tt.wantCode || status != tt.wantStatus {
+				t.Errorf("readiness = %d %s, want %d %s", code, status, tt.wantCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestReadinessNotStarted(t *testing.T) {
+	if code, status := readiness(NewHealthChecker(HealthConfig{})); code != http.StatusServiceUnavailable || status != "starting" {
+		t.Errorf("readiness = %d %s", code, status)
+	}
+}
+
+// A concurrent Check finishing in between must not change the answer of a
+// Readiness call whose own checks failed
+func TestReadinessUsesOwnResults(t *testing.T) {
+	h := NewHealthChecker(HealthConfig{})
+	var calls atomic.Int32
+	entered, release := make(chan struct{}), make(chan struct{})
+	h.RegisterCritical("db", func(context.Context) error {
+		if calls.Add(1) == 1 {
+			close(entered)
+			<-release
+			return errors.New("down")
+		}
+		return nil
+	})
+	h.MarkStarted()
+
+	done := make(chan int)
+	go func() {
+		code, _ := readiness(h)
+		done <- code
+	}()
+	<-entered
+	if code, _ := readiness(h); code != http.StatusOK {
+		t.Errorf("second readiness = %d, want 200", code)
+	}
+	close(release)
+	if code := <-done; code != http.StatusServiceUnavailable {
+		t.Errorf("first readiness = %d, want 503 from its own failing check", code)
+	}
+}
+
+// Each probe answers from its own signal: liveness from the deadlock flag,
+// startup from MarkStarted and readiness from both startup and the checks
+func TestProbesIndependent(t *testing.T) {
+	fail := func(context.Context) error { return errors.New("down") }
+	tests := []struct {
+		name                         string
+		setup                        func(h *HealthChecker)
+		liveness, readiness, startup int
+	}{
+		{"starting", func(h *HealthChecker) {}, http.StatusOK, http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+		{"started", func(h *HealthChecker) { h.MarkStarted() }, http.StatusOK, http.StatusOK, http.StatusOK},
+		{"dependency down", func(h *HealthChecker) { h.RegisterCritical("db", fail); h.MarkStarted() },
+			http.StatusOK, http.StatusServiceUnavailable, http.StatusOK},
+		{"deadlocked", func(h *HealthChecker) { h.MarkStarted(); h.SetDeadlockDetected() },
+			http.StatusServiceUnavailable, http.StatusOK, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHealthChecker(HealthConfig{})
+			tt.setup(h)
+			probes := []struct {
+				path    string
+				handler http.HandlerFunc
+				want    int
+			}{
+				{"/healthz", h.Liveness, tt.liveness},
+				{"/readyz", h.Readiness, tt.readiness},
+				{"/startupz", h.Startup, tt.startup},
+			}
+			for _, p := range probes {
+				rec := httptest.NewRecorder()
+				p.handler(rec, httptest.NewRequest(http.MethodGet, p.path, nil))
+				if rec.Code != p.want {
+					t.Errorf("%s = %d, want %d", p.path, rec.Code, p.want)
+				}
+				if rec.Header().Get("Cache-Control") != "no-store" {
+					t.Errorf("%s Cache-Control = %q", p.path, rec.Header().Get("Cache-Control"))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package httpclient
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.uber.org/zap"
+
+	"github.com/example/go-chi-rest/internal/reqctx"
+	"github.com/example/go-chi-rest/internal/retry"
+)
+
+var (
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_client_request_duration_seconds",
+		Help:    "Outbound HTTP request latency per attempt, by client, method and status (\"error\" for transport failures).",
+		Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"name", "method", "status"})
+	requestErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_client_request_errors_total",
+		Help: "Outbound HTTP attempts that failed with a transport error or a 5xx response, by client.",
+	}, []string{"name"})
+)
+
+// InstrumentedClientConfig configures a client built by NewInstrumentedClient
+type InstrumentedClientConfig struct {
+	Timeout             time.Duration `mapstructure:"timeout"` // whole call including retries
+	MaxIdleConns        int           `mapstructure:"max_idle_conns"`
+	TLSHandshakeTimeout time.Duration `mapstructure:"tls_handshake_timeout"`
+	DisableKeepAlives   bool          `mapstructure:"disable_keep_alives"`
+
+	CircuitBreakerConfig `mapstructure:"circuit_breaker"`
+	retry.RetryConfig    `mapstructure:"retry"`
+	Hedging              HedgingConfig `mapstructure:"hedging"`
+}
+
+// NewInstrumentedClient returns a client whose transport chain is, outermost first:
+// trace context and Istio header injection → circuit breaker → retry →
+// hedging (when enabled) → Prometheus metrics → base transport. The breaker
+// therefore counts a call once, after its retries, while metrics see every
+// attempt, hedged requests included.
+func NewInstrumentedClient(name string, cfg InstrumentedClientConfig) *http.Client {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	if cfg.MaxIdleConns <= 0 {
+		cfg.MaxIdleConns = 100
+	}
+	if cfg.TLSHandshakeTimeout <= 0 {
+		cfg.TLSHandshakeTimeout = 10 * time.Second
+	}
+	if cfg.RetryConfig.Operation == "" {
+		cfg.RetryConfig.Operation = name
+	}
+
+	base := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           (&net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConns,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		ExpectContinueTimeout: time.Second,
+		DisableKeepAlives:     cfg.DisableKeepAlives,
+	}
+	var rt http.RoundTripper = &metricsTransport{name: name, next: base}
+	if cfg.Hedging.Enabled {
+		h := cfg.Hedging.withDefaults()
+		rt = NewHedgingRoundTripper(h.Delay, h.Max, rt)
+	}
+	rt = retry.RetryableHTTPClient(cfg.RetryConfig, &http.Client{Transport: rt}).Transport
+	rt = NewCircuitBreaker(name, cfg.CircuitBreakerConfig, rt)
+	rt = &traceTransport{next: rt}
+
+	return &http.Client{Transport: rt, Timeout: cfg.Timeout}
+}
+
+// NewInstrumentedClientFromViper reads the client's settings from http_clients.<name>.*,
+// e.g. http_clients.payments.retry.max_attempts
+func NewInstrumentedClientFromViper(name string) *http.Client {
+	var cfg InstrumentedClientConfig
+	if err := viper.UnmarshalKey("http_clients."+name, &cfg); err != nil {
+		zap.L().Warn("invalid http client config, using defaults", zap.String("client", name), zap.Error(err))
+		cfg = InstrumentedClientConfig{}
+	}
+	return NewInstrumentedClient(name, cfg)
+}
+
+// traceTransport propagates the caller's trace context and Istio headers to
+// the upstream. The OpenTelemetry traceparent, when tracing is on, replaces the
+// one forwarded from the incoming request.
+type traceTransport struct {
+	next http.RoundTripper
+}
+
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrippers must not modify the caller's request
+	r := req.Clone(req.Context())
+	reqctx.InjectIstioHeaders(r.Context(), r)
+	otel.GetTextMapPropagator().Inject(r.Context(), propagation.HeaderCarrier(r.Header))
+	return t.next.RoundTrip(r)
+}
+
+// metricsTransport records every attempt
+type metricsTransport struct {
+	name string
+	next http.RoundTripper
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	requestDuration.WithLabelValues(t.name, req.Method, status).Observe(time.Since(start).Seconds())
+	if err != nil || resp.StatusCode >= 500 {
+		requestErrors.WithLabelValues(t.name).Inc()
+	}
+	return resp, err
+}
@@ -0,0 +1,146 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spf13/viper"
+
+	"github.com/example/go-chi-rest/internal/reqctx"
+	"github.com/example/go-chi-rest/internal/retry"
+)
+
+// upstream answers every request with status and counts the hits
+func upstream(t *testing.T, status int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func TestInstrumentedClientOpensCircuitOn5xx(t *testing.T) {
+	srv, hits := upstream(t, http.StatusInternalServerError)
+	client := NewInstrumentedClient("test_5xx", InstrumentedClientConfig{
+		CircuitBreakerConfig: CircuitBreakerConfig{FailureThreshold: 2, OpenTimeout: time.Hour},
+		RetryConfig:          retry.RetryConfig{MaxAttempts: 2, InitialDelay: time.Millisecond},
+	})
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusInternalServerError {
+			t.Fatalf("call %d: status %d, want the upstream's 500", i, resp.StatusCode)
+		}
+	}
+	// 500 is not retried, so each call is one failed attempt
+	if got := testutil.ToFloat64(requestErrors.WithLabelValues("test_5xx")); got != 2 {
+		t.Errorf("error counter = %v, want 2", got)
+	}
+
+	if _, err := client.Get(srv.URL); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("third call err = %v, want ErrCircuitOpen", err)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("upstream hit %d times, want 2: the open circuit must not call it", n)
+	}
+	if got := testutil.ToFloat64(breakerState.WithLabelValues("test_5xx")); got != float64(open) {
+		t.Errorf("breaker state gauge = %v, want %d", got, open)
+	}
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	status := atomic.Int32{}
+	status.Store(http.StatusBadGateway)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer srv.Close()
+	cb := NewCircuitBreaker("test_half_open", CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: 20 * time.Millisecond}, nil)
+	client := &http.Client{Transport: cb}
+
+	get := func() error {
+		resp, err := client.Get(srv.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+	get()
+	if err := get(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+
+	// a failed probe re-opens the circuit
+	time.Sleep(30 * time.Millisecond)
+	if err := get(); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if err := get(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("after failed probe err = %v, want ErrCircuitOpen", err)
+	}
+
+	// a successful probe closes it
+	status.Store(http.StatusOK)
+	time.Sleep(30 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		if err := get(); err != nil {
+			t.Fatalf("call %d after recovery: %v", i, err)
+		}
+	}
+}
+
+func TestNewInstrumentedClientFromViper(t *testing.T) {
+	srv, hits := upstream(t, http.StatusServiceUnavailable)
+	viper.Set("http_clients.test_viper.retry.max_attempts", 3)
+	viper.Set("http_clients.test_viper.retry.initial_delay", "1ms")
+	t.Cleanup(viper.Reset)
+
+	resp, err := NewInstrumentedClientFromViper("test_viper").Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if n := hits.Load(); n != 3 {
+		t.Errorf("upstream hit %d times, want max_attempts 3 from viper", n)
+	}
+}
+
+func TestInstrumentedClientForwardsIstioHeaders(t *testing.T) {
+	got := make(chan http.Header, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got <- r.Header.Clone()
+	}))
+	defer srv.Close()
+	client := NewInstrumentedClient("test_istio", InstrumentedClientConfig{})
+
+	h := reqctx.NewIstioHeaderPropagator()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, srv.URL, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		resp.Body.Close()
+	}))
+	in := httptest.NewRequest(http.MethodGet, "/", nil)
+	in.Header.Set("X-B3-TraceId", "80f198ee56343ba864fe8b2a57d3eff7")
+	in.Header.Set("X-Request-ID", "req-1")
+	h.ServeHTTP(httptest.NewRecorder(), in)
+
+	out := <-got
+	if out.Get("X-B3-TraceId") != "80f198ee56343ba864fe8b2a57d3eff7" || out.Get("X-Request-ID") != "req-1" {
+		t.Errorf("outbound headers = %v", out)
+	}
+}
@@ -0,0 +1,167 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var hedgeRequests = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "hedge_requests_total",
+	Help: "Additional requests issued by the hedging transport because the first had not answered within the hedging delay.",
+})
+
+// maxDrainBytes bounds how much of a losing response is read so its
+// connection can be reused; larger bodies are closed and the connection dropped
+const maxDrainBytes = 64 << 10
+
+// HedgingConfig enables request hedging for idempotent methods
+type HedgingConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	Delay   time.Duration `mapstructure:"delay"` // wait before each extra request; default 50ms
+	Max     int           `mapstructure:"max"`   // total requests per call, the first included; default 2
+}
+
+func (c HedgingConfig) withDefaults() HedgingConfig {
+	if c.Delay <= 0 {
+		c.Delay = 50 * time.Millisecond
+	}
+	if c.Max <= 0 {
+		c.Max = 2
+	}
+	return c
+}
+
+// hedgingTransport sends the request again every delay, up to max requests in
+// total, for as long as none has answered. The first response wins; the other
+// requests are cancelled and any response they still return is drained and
+// closed. A transport error from one request is only returned once no other
+// request is in flight.
+type hedgingTransport struct {
+	delay time.Duration
+	max   int
+	next  http.RoundTripper
+}
+
+type hedgeResult struct {
+	resp *http.Response
+	err  error
+	n    int // index of the request in the order sent
+}
+
+// NewHedgingRoundTripper wraps next (http.DefaultTransport when nil). Only
+// GET, HEAD and OPTIONS requests whose body, if any, can be replayed through
+// GetBody are hedged; everything else goes straight to next.
+func NewHedgingRoundTripper(delay time.Duration, max int, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if max < 1 {
+		max = 1
+	}
+	return &hedgingTransport{delay: delay, max: max, next: next}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *hedgingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.max < 2 || !hedgeable(req) {
+		return t.next.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	results := make(chan hedgeResult, t.max) // never blocks a sender
+	cancels := make([]context.CancelFunc, 0, t.max)
+	send := func() {
+		actx, cancel := context.WithCancel(ctx)
+		n := len(cancels)
+		cancels = append(cancels, cancel)
+		r := req.Clone(actx)
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				results <- hedgeResult{err: err, n: n}
+				return
+			}
+			r.Body = body
+		}
+		go func() {
+			resp, err := t.next.RoundTrip(r)
+			results <- hedgeResult{resp: resp, err: err, n: n}
+		}()
+	}
+
+	send()
+	pending := 1
+	timer := time.NewTimer(t.delay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			if len(cancels) < t.max {
+				send()
+				pending++
+				hedgeRequests.Inc()
+				timer.Reset(t.delay)
+			}
+		case res := <-results:
+			pending--
+			if res.err == nil {
+				for i, cancel := range cancels {
+					if i != res.n {
+						cancel()
+					}
+				}
+				go discard(results, pending)
+				res.resp.Body = &cancelOnClose{ReadCloser: res.resp.Body, cancel: cancels[res.n]}
+				return res.resp, nil
+			}
+			cancels[res.n]()
+			if pending == 0 {
+				return nil, res.err
+			}
+		case <-ctx.Done():
+			// every request is cancelled along with ctx; their results are
+			// still collected so no response body is left open
+			go discard(results, pending)
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// discard waits for the n cancelled requests still in flight and drains and
+// closes any response one of them produced before noticing the cancellation
+func discard(results <-chan hedgeResult, n int) {
+	for ; n > 0; n-- {
+		if res := <-results; res.err == nil {
+			io.CopyN(io.Discard, res.resp.Body, maxDrainBytes)
+			res.resp.Body.Close()
+		}
+	}
+}
+
+// hedgeable reports whether req may be sent more than once
+func hedgeable(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// cancelOnClose releases the winning request's context once the caller is
+// done with the body
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,141 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// quantile returns the q-th quantile of durations
+func quantile(durations []time.Duration, q float64) time.Duration {
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[int(q*float64(len(sorted)-1))]
+}
+
+func TestHedgingReducesTailLatency(t *testing.T) {
+	// every 7th request takes 200ms, so p90 is well above 100ms
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delay := 2 * time.Millisecond
+		if hits.Add(1)%7 == 0 {
+			delay = 200 * time.Millisecond
+		}
+		select {
+		case <-time.After(delay):
+			io.WriteString(w, "ok")
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+
+	measure := func(client *http.Client) []time.Duration {
+		var d []time.Duration
+		for i := 0; i < 70; i++ {
+			start := time.Now()
+			resp, err := client.Get(srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			d = append(d, time.Since(start))
+		}
+		return d
+	}
+	plain := measure(&http.Client{})
+	hedged := measure(&http.Client{Transport: NewHedgingRoundTripper(50*time.Millisecond, 2, nil)})
+
+	if quantile(plain, 0.9) < 100*time.Millisecond {
+		t.Fatalf("backend p90 = %v, want the simulated slow tail above 100ms", quantile(plain, 0.9))
+	}
+	if p99, hp99 := quantile(plain, 0.99), quantile(hedged, 0.99); hp99 > p99/2 {
+		t.Errorf("hedged p99 = %v, want at most half of %v", hp99, p99)
+	}
+}
+
+func TestHedgingOnlyIdempotent(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		time.Sleep(30 * time.Millisecond)
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: NewHedgingRoundTripper(5*time.Millisecond, 3, nil)}
+
+	resp, err := client.Post(srv.URL, "text/plain", strings.NewReader("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if n := hits.Load(); n != 1 {
+		t.Errorf("POST sent %d times", n)
+	}
+
+	hits.Store(0)
+	resp, err = client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	time.Sleep(50 * time.Millisecond)
+	if n := hits.Load(); n != 3 {
+		t.Errorf("GET sent %d times, want Max 3", n)
+	}
+}
+
+// trackedBody records whether it was closed
+type trackedBody struct {
+	io.Reader
+	closed atomic.Bool
+}
+
+func (b *trackedBody) Close() error { b.closed.Store(true); return nil }
+
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestHedgingClosesLosingResponse(t *testing.T) {
+	var calls atomic.Int32
+	loser := &trackedBody{Reader: strings.NewReader("late")}
+	release := make(chan struct{})
+	next := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if calls.Add(1) == 1 {
+			// the first request answers only after the hedge has won and
+			// ignores the cancellation, like a response already in flight
+			<-release
+			return &http.Response{StatusCode: http.StatusOK, Body: loser}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "http://upstream/", nil)
+	resp, err := NewHedgingRoundTripper(time.Millisecond, 2, next).RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "ok" {
+		t.Fatalf("body = %q, want the hedge's", body)
+	}
+
+	close(release)
+	deadline := time.Now().Add(time.Second)
+	for !loser.closed.Load() {
+		if time.Now().After(deadline) {
+			t.Fatal("losing response body was not closed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n, _ := loser.Read(make([]byte, 1)); n != 0 {
+		t.Error("losing response body was not drained")
+	}
+}
@@ -0,0 +1,206 @@
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"hash/maphash"
+	"math"
+	"strings"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+var (
+	bloomHits = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "bloom_filter_hits_total",
+		Help: "Idempotency keys the bloom filter reported as possibly seen, sending the request to the Redis lookup.",
+	})
+	bloomFalsePositives = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "bloom_filter_false_positives_total",
+		Help: "Bloom filter hits whose Redis lookup found nothing (estimated false positives, expired keys included).",
+	})
+)
+
+// Bloom filter backends
+const (
+	BloomLocal = "local" // in-process; forgets keys on restart
+	BloomRedis = "redis" // RedisBloom BF.* commands, shared by all replicas
+)
+
+// BloomConfig sizes the filter in front of the key lookup
+type BloomConfig struct {
+	Enabled           bool    `mapstructure:"enabled"`
+	Backend           string  `mapstructure:"backend"`             // local (default) | redis
+	Key               string  `mapstructure:"key"`                 // RedisBloom filter key; default "idempotency:bloom"
+	Capacity          uint    `mapstructure:"capacity"`            // expected keys; default 1,000,000
+	FalsePositiveRate float64 `mapstructure:"false_positive_rate"` // default 0.01
+}
+
+func (c BloomConfig) withDefaults() BloomConfig {
+	if c.Backend == "" {
+		c.Backend = BloomLocal
+	}
+	if c.Key == "" {
+		c.Key = "idempotency:bloom"
+	}
+	if c.Capacity == 0 {
+		c.Capacity = 1_000_000
+	}
+	if c.FalsePositiveRate <= 0 || c.FalsePositiveRate >= 1 {
+		c.FalsePositiveRate = 0.01
+	}
+	return c
+}
+
+// BloomFilter answers "definitely not added" (false) or "possibly added" (true)
+type BloomFilter interface {
+	Test(ctx context.Context, key string) (bool, error)
+	Add(ctx context.Context, key string) error
+}
+
+// BloomIdempotencyFrontend puts a bloom filter in front of the idempotency
+// lookup. Most keys are new, and for those the filter answers "definitely not
+// seen" without a Redis round trip. A miss is only an optimisation: the claim
+// that follows still refuses a key another replica holds, so a local filter
+// that has not seen a key is safe.
+type BloomIdempotencyFrontend struct {
+	filter BloomFilter
+}
+
+// NewBloomIdempotencyFrontend builds the configured filter; client is only
+// used by the redis backend
+func NewBloomIdempotencyFrontend(ctx context.Context, client redis.UniversalClient, cfg BloomConfig) (*BloomIdempotencyFrontend, error) {
+	cfg = cfg.withDefaults()
+	switch cfg.Backend {
+	case BloomLocal:
+		return &BloomIdempotencyFrontend{filter: NewLocalBloomFilter(cfg.Capacity, cfg.FalsePositiveRate)}, nil
+	case BloomRedis:
+		f, err := NewRedisBloomFilter(ctx, client, cfg)
+		if err != nil {
+			return nil, err
+		}
+		return &BloomIdempotencyFrontend{filter: f}, nil
+	default:
+		return nil, fmt.Errorf("unknown bloom backend %q", cfg.Backend)
+	}
+}
+
+// MightContain reports whether key may have been seen. Filter errors answer
+// true, falling back to the full lookup.
+func (b *BloomIdempotencyFrontend) MightContain(ctx context.Context, key string) bool {
+	seen, err := b.filter.Test(ctx, key)
+	if err != nil {
+		zap.L().Warn("bloom filter check failed", zap.Error(err))
+		return true
+	}
+	if seen {
+		bloomHits.Inc()
+	}
+	return seen
+}
+
+// Add records key as seen
+func (b *BloomIdempotencyFrontend) Add(ctx context.Context, key string) {
+	if err := b.filter.Add(ctx, key); err != nil {
+		zap.L().Warn("bloom filter add failed", zap.Error(err))
+	}
+}
+
+// FalsePositive counts a hit whose lookup came back empty
+func (b *BloomIdempotencyFrontend) FalsePositive() {
+	bloomFalsePositives.Inc()
+}
+
+// LocalBloomFilter is a lock-free in-memory bloom filter
+type LocalBloomFilter struct {
+	bits []atomic.Uint64
+	m    uint64 // bits
+	k    int    // hash functions
+	seed maphash.Seed
+}
+
+// NewLocalBloomFilter sizes a filter for n keys at false positive rate p:
+// m = -n·ln p / ln²2 bits and k = m/n·ln 2 hashes, about 1.2 MB and 7 hashes
+// for a million keys at 1%
+func NewLocalBloomFilter(n uint, p float64) *LocalBloomFilter {
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+	k := int(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &LocalBloomFilter{
+		bits: make([]atomic.Uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+		seed: maphash.MakeSeed(),
+	}
+}
+
+// Test implements BloomFilter
+func (f *LocalBloomFilter) Test(_ context.Context, key string) (bool, error) {
+	h1, h2 := f.hashes(key)
+	for i := 0; i < f.k; i++ {
+		bit := (h1 + uint64(i)*h2) % f.m
+		if f.bits[bit/64].Load()&(1<<(bit%64)) == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Add implements BloomFilter
+func (f *LocalBloomFilter) Add(_ context.Context, key string) error {
+	h1, h2 := f.hashes(key)
+	for i := 0; i < f.k; i++ {
+		bit := (h1 + uint64(i)*h2) % f.m
+		word, mask := &f.bits[bit/64], uint64(1)<<(bit%64)
+		for {
+			old := word.Load()
+			if old&mask != 0 || word.CompareAndSwap(old, old|mask) {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// hashes derives the k probe positions from one 64-bit hash (Kirsch and
+// Mitzenmacher double hashing); h2 is odd so the probes never repeat early
+func (f *LocalBloomFilter) hashes(key string) (uint64, uint64) {
+	h := maphash.String(f.seed, key)
+	return h & 0xffffffff, h>>32 | 1
+}
+
+// RedisBloomFilter uses a RedisBloom filter shared by every replica
+type RedisBloomFilter struct {
+	client redis.UniversalClient
+	key    string
+}
+
+// NewRedisBloomFilter reserves cfg.Key with the configured error rate and
+// capacity unless it already exists
+func NewRedisBloomFilter(ctx context.Context, client redis.UniversalClient, cfg BloomConfig) (*RedisBloomFilter, error) {
+	cfg = cfg.withDefaults()
+	err := client.Do(ctx, "BF.RESERVE", cfg.Key, cfg.FalsePositiveRate, cfg.Capacity).Err()
+	if err != nil && !strings.Contains(err.Error(), "exists") {
+		return nil, fmt.Errorf("bf.reserve %s: %w", cfg.Key, err)
+	}
+	return &RedisBloomFilter{client: client, key: cfg.Key}, nil
+}
+
+// Test implements BloomFilter
+func (f *RedisBloomFilter) Test(ctx context.Context, key string) (bool, error) {
+	return f.client.Do(ctx, "BF.EXISTS", f.key, key).Bool()
+}
+
+// Add implements BloomFilter
+func (f *RedisBloomFilter) Add(ctx context.Context, key string) error {
+	return f.client.Do(ctx, "BF.ADD", f.key, key).Err()
+}
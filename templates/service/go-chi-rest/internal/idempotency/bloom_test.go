@@ -0,0 +1,40 @@
+package idempotency
+
+import (
+	"context"
+	"strconv"
+	"testing"
+)
+
+func TestLocalBloomFilter(t *testing.T) {
+	ctx := context.Background()
+	f := NewLocalBloomFilter(1_000_000, 0.01)
+	f.Add(ctx, "seen")
+	if ok, _ := f.Test(ctx, "seen"); !ok {
+		t.Error("added key reported as not seen")
+	}
+
+	const n = 100_000
+	res := testing.Benchmark(func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			f.Test(ctx, "idempotency:key")
+		}
+	})
+	if ns := res.NsPerOp(); ns >= 1000 {
+		t.Errorf("Test takes %dns, want under 1µs", ns)
+	}
+
+	for i := 0; i < n; i++ {
+		f.Add(ctx, "added-"+strconv.Itoa(i))
+	}
+	falsePositives := 0
+	for i := 0; i < n; i++ {
+		if ok, _ := f.Test(ctx, "other-"+strconv.Itoa(i)); ok {
+			falsePositives++
+		}
+	}
+	// far below capacity, the rate must be well under the configured 1%
+	if rate := float64(falsePositives) / n; rate > 0.01 {
+		t.Errorf("false positive rate = %.4f, want <= 0.01", rate)
+	}
+}
@@ -0,0 +1,278 @@
+// Package idempotency replays the stored response when a client retries an
+// unsafe request with the same Idempotency-Key, so a POST is applied once
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/example/go-chi-rest/internal/authn"
+	"github.com/example/go-chi-rest/internal/errcodes"
+	"github.com/example/go-chi-rest/internal/reqctx"
+)
+
+// ReplayedHeader is set on responses served from the store
+const ReplayedHeader = "Idempotent-Replayed"
+
+// maxKeyLength bounds client-chosen keys
+const maxKeyLength = 255
+
+// inFlight, followed by ":" and the body hash, marks a key whose first
+// request is still being processed
+const inFlight = "in-flight"
+
+// IdempotencyConfig configures the middleware
+type IdempotencyConfig struct {
+	Enabled   bool          `mapstructure:"enabled"`
+	RedisAddr string        `mapstructure:"redis_addr"`
+	KeyPrefix string        `mapstructure:"key_prefix"` // default "idempotency:"
+	Header    string        `mapstructure:"header"`     // default Idempotency-Key
+	TTL       time.Duration `mapstructure:"ttl"`        // how long responses are replayed; default 24h
+	LockTTL   time.Duration `mapstructure:"lock_ttl"`   // how long an unfinished request holds its key; default 30s
+	// MaxBodyBytes bounds the request body read to hash it; default 1 MiB
+	MaxBodyBytes int64 `mapstructure:"max_body_bytes"`
+	// MaxResponseBytes bounds the stored response; larger ones are not replayed. Default 1 MiB
+	MaxResponseBytes int         `mapstructure:"max_response_bytes"`
+	Bloom            BloomConfig `mapstructure:"bloom"`
+}
+
+func (c IdempotencyConfig) withDefaults() IdempotencyConfig {
+	if c.KeyPrefix == "" {
+		c.KeyPrefix = "idempotency:"
+	}
+	if c.Header == "" {
+		c.Header = "Idempotency-Key"
+	}
+	if c.TTL <= 0 {
+		c.TTL = 24 * time.Hour
+	}
+	if c.LockTTL <= 0 {
+		c.LockTTL = 30 * time.Second
+	}
+	if c.MaxBodyBytes <= 0 {
+		c.MaxBodyBytes = 1 << 20
+	}
+	if c.MaxResponseBytes <= 0 {
+		c.MaxResponseBytes = 1 << 20
+	}
+	return c
+}
+
+// storedResponse is what a replay writes back
+type storedResponse struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+	BodyHash    string `json:"body_hash"` // of the request that produced it
+}
+
+// NewIdempotencyMiddleware handles POST, PUT, PATCH and DELETE requests that
+// carry the key header. The first request claims the key in Redis and its
+// response (unless 5xx) is stored for TTL; retries get that response back
+// with Idempotent-Replayed: true, and a retry arriving while the first is
+// still running gets 409 IDEMPOTENCY_KEY_IN_USE. Keys are scoped by the
+// authenticated principal (see authn), method and path, so callers cannot see
+// each other's responses; reusing a key with a different body gets 422
+// IDEMPOTENCY_KEY_REUSED. Responses above MaxResponseBytes are not stored.
+// frontend, when not nil, lets keys that were definitely never seen skip the
+// lookup and go straight to the claim. Redis errors let the request through.
+func NewIdempotencyMiddleware(client redis.UniversalClient, cfg IdempotencyConfig, frontend *BloomIdempotencyFrontend) func(http.Handler) http.Handler {
+	cfg = cfg.withDefaults()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(cfg.Header)
+			if id == "" || !unsafeMethod(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(id) > maxKeyLength {
+				errcodes.Write(w, errcodes.FromRequest(r).New("INVALID_REQUEST", cfg.Header+" is too long"))
+				return
+			}
+			body, err := io.ReadAll(io.LimitReader(r.Body, cfg.MaxBodyBytes+1))
+			r.Body.Close()
+			if err != nil {
+				errcodes.Write(w, errcodes.FromRequest(r).New("INVALID_REQUEST", "unreadable body"))
+				return
+			}
+			if int64(len(body)) > cfg.MaxBodyBytes {
+				errcodes.Write(w, errcodes.FromRequest(r).New("PAYLOAD_TOO_LARGE", cfg.MaxBodyBytes))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			sum := sha256.Sum256(body)
+			bodyHash := hex.EncodeToString(sum[:])
+
+			ctx := r.Context()
+			log := reqctx.LoggerFromContext(ctx)
+			key := cfg.KeyPrefix + scope(r, id)
+
+			if frontend == nil || frontend.MightContain(ctx, key) {
+				stored, err := lookup(ctx, client, key)
+				switch {
+				case err != nil:
+					log.Warn("idempotency lookup failed; processing request", zap.Error(err))
+					next.ServeHTTP(w, r)
+					return
+				case stored != nil:
+					replay(w, r, cfg, stored, bodyHash)
+					return
+				}
+				if frontend != nil {
+					frontend.FalsePositive()
+				}
+			}
+
+			claimed, err := client.SetNX(ctx, key, inFlight+":"+bodyHash, cfg.LockTTL).Result()
+			if err != nil {
+				log.Warn("idempotency claim failed; processing request", zap.Error(err))
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !claimed {
+				// claimed since the lookup, or a filter that missed a key seen elsewhere
+				if stored, err := lookup(ctx, client, key); err == nil && stored != nil {
+					replay(w, r, cfg, stored, bodyHash)
+					return
+				}
+				errcodes.Write(w, errcodes.FromRequest(r).New("IDEMPOTENCY_KEY_IN_USE", id))
+				return
+			}
+			if frontend != nil {
+				frontend.Add(ctx, key)
+			}
+
+			rec := &recorder{ResponseWriter: w, status: http.StatusOK, max: cfg.MaxResponseBytes}
+			next.ServeHTTP(rec, r)
+
+			// a fresh context: the client may be gone, the result must still be kept
+			sctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			if rec.status >= 500 {
+				// let the client retry a failure
+				client.Del(sctx, key)
+				return
+			}
+			if rec.overflow {
+				log.Warn("idempotent response too large to store; retries will be processed again",
+					zap.Int("max_response_bytes", cfg.MaxResponseBytes))
+				client.Del(sctx, key)
+				return
+			}
+			payload, _ := json.Marshal(storedResponse{
+				Status:      rec.status,
+				ContentType: rec.Header().Get("Content-Type"),
+				Body:        rec.body.Bytes(),
+				BodyHash:    bodyHash,
+			})
+			if err := client.Set(sctx, key, payload, cfg.TTL).Err(); err != nil {
+				log.Warn("idempotency store failed", zap.Error(err))
+			}
+		})
+	}
+}
+
+// scope derives the Redis key suffix from the principal, method, path and
+// the client's key, hashed to bound its length
+func scope(r *http.Request, id string) string {
+	h := sha256.New()
+	for _, part := range []string{authn.Subject(r.Context()), r.Method, r.URL.Path, id} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// lookup returns the stored response, one with only BodyHash set while the
+// key is in flight, or nil when the key is unknown
+func lookup(ctx context.Context, client redis.UniversalClient, key string) (*storedResponse, error) {
+	raw, err := client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if hash, ok := strings.CutPrefix(string(raw), inFlight+":"); ok {
+		return &storedResponse{BodyHash: hash}, nil
+	}
+	var stored storedResponse
+	if err := json.Unmarshal(raw, &stored); err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+func replay(w http.ResponseWriter, r *http.Request, cfg IdempotencyConfig, stored *storedResponse, bodyHash string) {
+	if stored.BodyHash != bodyHash {
+		errcodes.Write(w, errcodes.FromRequest(r).New("IDEMPOTENCY_KEY_REUSED", r.Header.Get(cfg.Header)))
+		return
+	}
+	if stored.Status == 0 {
+		errcodes.Write(w, errcodes.FromRequest(r).New("IDEMPOTENCY_KEY_IN_USE", r.Header.Get(cfg.Header)))
+		return
+	}
+	if stored.ContentType != "" {
+		w.Header().Set("Content-Type", stored.ContentType)
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(stored.Status)
+	w.Write(stored.Body)
+}
+
+func unsafeMethod(m string) bool {
+	switch m {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// recorder tees up to max bytes of the response so it can be stored
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+	max         int
+	overflow    bool // the body exceeded max and was not kept
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (rec *recorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+func (rec *recorder) WriteHeader(code int) {
+	if !rec.wroteHeader {
+		rec.wroteHeader = true
+		rec.status = code
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	if !rec.wroteHeader {
+		rec.WriteHeader(http.StatusOK)
+	}
+	if !rec.overflow {
+		if rec.body.Len()+len(b) > rec.max {
+			rec.overflow = true
+			rec.body = bytes.Buffer{}
+		} else {
+			rec.body.Write(b)
+		}
+	}
+	return rec.ResponseWriter.Write(b)
+}
@@ -0,0 +1,109 @@
+package idempotency
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/example/go-chi-rest/internal/authn"
+)
+
+func newTestMiddleware(t *testing.T, cfg IdempotencyConfig, next http.Handler) http.Handler {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	return NewIdempotencyMiddleware(client, cfg, nil)(next)
+}
+
+func send(h http.Handler, method, path, user, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Idempotency-Key", key)
+	if user != "" {
+		req = req.WithContext(authn.WithPrincipal(req.Context(), authn.Principal{Subject: user}))
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	var calls atomic.Int32
+	h := newTestMiddleware(t, IdempotencyConfig{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, "order "+string(rune('0'+n))+" "+string(body))
+	}))
+
+	first := send(h, http.MethodPost, "/orders", "alice", "k1", "item=1")
+	if first.Code != http.StatusCreated || first.Body.String() != "order 1 item=1" {
+		t.Fatalf("first: %d %q", first.Code, first.Body)
+	}
+
+	tests := []struct {
+		name               string
+		method, path, user string
+		body               string
+		want               int
+		replayed           bool
+	}{
+		{"retry is replayed", http.MethodPost, "/orders", "alice", "item=1", http.StatusCreated, true},
+		{"different body", http.MethodPost, "/orders", "alice", "item=2", http.StatusUnprocessableEntity, false},
+		{"other principal", http.MethodPost, "/orders", "bob", "item=1", http.StatusCreated, false},
+		{"anonymous caller", http.MethodPost, "/orders", "", "item=1", http.StatusCreated, false},
+		{"other path", http.MethodPost, "/carts", "alice", "item=1", http.StatusCreated, false},
+		{"other method", http.MethodPut, "/orders", "alice", "item=1", http.StatusCreated, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := calls.Load()
+			rec := send(h, tt.method, tt.path, tt.user, "k1", tt.body)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if got := rec.Header().Get(ReplayedHeader) == "true"; got != tt.replayed {
+				t.Errorf("replayed = %v, want %v", got, tt.replayed)
+			}
+			if tt.replayed {
+				if rec.Body.String() != first.Body.String() || calls.Load() != before {
+					t.Errorf("replay ran the handler or changed the body: %q", rec.Body)
+				}
+			}
+		})
+	}
+}
+
+func TestIdempotencyLargeResponseNotStored(t *testing.T) {
+	var calls atomic.Int32
+	h := newTestMiddleware(t, IdempotencyConfig{MaxResponseBytes: 8}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		io.WriteString(w, "0123456789")
+	}))
+	for i := 0; i < 2; i++ {
+		rec := send(h, http.MethodPost, "/orders", "alice", "k1", "")
+		if rec.Code != http.StatusOK || rec.Body.String() != "0123456789" {
+			t.Fatalf("request %d: %d %q", i, rec.Code, rec.Body)
+		}
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("handler calls = %d, want 2", n)
+	}
+}
+
+func TestRecorderUnwrap(t *testing.T) {
+	inner := httptest.NewRecorder()
+	rec := &recorder{ResponseWriter: inner, max: 1}
+	if err := http.NewResponseController(rec).Flush(); err != nil {
+		t.Errorf("Flush through recorder: %v", err)
+	}
+	if !inner.Flushed {
+		t.Error("inner writer not flushed")
+	}
+}
@@ -0,0 +1,277 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/example/go-chi-rest/internal/errcodes"
+	"github.com/example/go-chi-rest/internal/pagination"
+)
+
+// MediaType is the JSON:API media type
+const MediaType = "application/vnd.api+json"
+
+// BasePath prefixes the resource and relationship links
+var BasePath = "/api/v1"
+
+// Typer lets a model override the resource type derived from its struct name
+type Typer interface {
+	JSONAPIType() string
+}
+
+// Links is the JSON:API links object; empty members are omitted
+type Links struct {
+	Self  string `json:"self,omitempty"`
+	First string `json:"first,omitempty"`
+	Last  string `json:"last,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+}
+
+type relationLinks struct {
+	Self    string `json:"self"`
+	Related string `json:"related"`
+}
+
+type identifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type relationship struct {
+	Links relationLinks `json:"links"`
+	Data  any           `json:"data"` // *identifier, []identifier or nil
+}
+
+type resource struct {
+	Type          string                  `json:"type"`
+	ID            string                  `json:"id"`
+	Attributes    map[string]any          `json:"attributes,omitempty"`
+	Relationships map[string]relationship `json:"relationships,omitempty"`
+	Links         *Links                  `json:"links,omitempty"`
+}
+
+type document struct {
+	JSONAPI struct {
+		Version string `json:"version"`
+	} `json:"jsonapi"`
+	Data   any            `json:"data,omitempty"`
+	Errors []errorObject  `json:"errors,omitempty"`
+	Meta   map[string]any `json:"meta,omitempty"`
+	Links  *Links         `json:"links,omitempty"`
+}
+
+type errorObject struct {
+	Status string `json:"status"`
+	Code   string `json:"code"`
+	Title  string `json:"title"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// JSONAPIContentType sets the JSON:API Content-Type on every response
+func JSONAPIContentType() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", MediaType)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// EncodeResource writes data as a single resource object.
+// Fields are mapped with `jsonapi:"attr,name"`, `jsonapi:"relation,name"` and `jsonapi:"id"`
+// (or a field named ID).
+func EncodeResource[T any](w http.ResponseWriter, status int, data T, meta map[string]any) error {
+	res, err := toResource(reflect.ValueOf(data))
+	if err != nil {
+		return err
+	}
+	return write(w, status, document{Data: res, Meta: meta})
+}
+
+// EncodeCollection writes data as a resource collection with optional pagination links
+func EncodeCollection[T any](w http.ResponseWriter, status int, data []T, meta map[string]any, links *Links) error {
+	out := make([]*resource, 0, len(data))
+	for i := range data {
+		res, err := toResource(reflect.ValueOf(data[i]))
+		if err != nil {
+			return err
+		}
+		out = append(out, res)
+	}
+	return write(w, status, document{Data: out, Meta: meta, Links: links})
+}
+
+// EncodeError writes an AppError as a JSON:API error document
+func EncodeError(w http.ResponseWriter, appErr *errcodes.AppError) error {
+	return write(w, appErr.Status, document{Errors: []errorObject{{
+		Status: fmt.Sprint(appErr.Status),
+		Code:   appErr.Code,
+		Title:  http.StatusText(appErr.Status),
+		Detail: appErr.Message,
+	}}})
+}
+
+// PageLinks builds first/prev/next links for a cursor-paginated collection from
+// the current request. JSON:API "last" is omitted because cursors cannot jump to the end.
+func PageLinks(r *http.Request, p pagination.PageParams, nextCursor, prevCursor string) *Links {
+	link := func(cursor string, dir pagination.Direction) string {
+		u := *r.URL
+		q := u.Query()
+		q.Del("cursor")
+		q.Del("direction")
+		q.Set("limit", fmt.Sprint(p.Limit))
+		if cursor != "" {
+			q.Set("cursor", cursor)
+			q.Set("direction", string(dir))
+		}
+		u.RawQuery = q.Encode()
+		return u.RequestURI()
+	}
+	l := &Links{Self: r.URL.RequestURI(), First: link("", pagination.Forward)}
+	if prevCursor != "" {
+		l.Prev = link(prevCursor, pagination.Backward)
+	}
+	if nextCursor != "" {
+		l.Next = link(nextCursor, pagination.Forward)
+	}
+	return l
+}
+
+func write(w http.ResponseWriter, status int, doc document) error {
+	doc.JSONAPI.Version = "1.1"
+	w.Header().Set("Content-Type", MediaType)
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(doc)
+}
+
+// toResource converts a struct (or pointer to struct) into a resource object
+func toResource(v reflect.Value) (*resource, error) {
+	typ, id, sv, err := identify(v)
+	if err != nil {
+		return nil, err
+	}
+	res := &resource{Type: typ, ID: id, Links: &Links{Self: BasePath + "/" + typ + "/" + id}}
+
+	st := sv.Type()
+	for i := 0; i < st.NumField(); i++ {
+		f := st.Field(i)
+		kind, name, ok := parseTag(f.Tag.Get("jsonapi"))
+		if !ok || !f.IsExported() {
+			continue
+		}
+		fv := sv.Field(i)
+		switch kind {
+		case "attr":
+			if res.Attributes == nil {
+				res.Attributes = make(map[string]any)
+			}
+			res.Attributes[name] = fv.Interface()
+		case "relation":
+			rel, err := toRelationship(fv)
+			if err != nil {
+				return nil, fmt.Errorf("jsonapi: relation %s: %w", name, err)
+			}
+			self := BasePath + "/" + typ + "/" + id
+			rel.Links = relationLinks{Self: self + "/relationships/" + name, Related: self + "/" + name}
+			if res.Relationships == nil {
+				res.Relationships = make(map[string]relationship)
+			}
+			res.Relationships[name] = rel
+		}
+	}
+	return res, nil
+}
+
+func toRelationship(v reflect.Value) (relationship, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return relationship{}, nil // empty to-one relationship: data null
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Slice {
+		ids := make([]identifier, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			typ, id, _, err := identify(v.Index(i))
+			if err != nil {
+				return relationship{}, err
+			}
+			ids = append(ids, identifier{Type: typ, ID: id})
+		}
+		return relationship{Data: ids}, nil
+	}
+	typ, id, _, err := identify(v)
+	if err != nil {
+		return relationship{}, err
+	}
+	return relationship{Data: &identifier{Type: typ, ID: id}}, nil
+}
+
+// identify returns the resource type, id and dereferenced struct value
+func identify(v reflect.Value) (string, string, reflect.Value, error) {
+	orig := v
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", "", v, fmt.Errorf("jsonapi: nil resource")
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return "", "", v, fmt.Errorf("jsonapi: %s is not a struct", v.Type())
+	}
+
+	typ := kebab(v.Type().Name())
+	if t, ok := orig.Interface().(Typer); ok {
+		typ = t.JSONAPIType()
+	}
+
+	st := v.Type()
+	idx := -1
+	for i := 0; i < st.NumField(); i++ {
+		if kind, _, _ := parseTag(st.Field(i).Tag.Get("jsonapi")); kind == "id" {
+			idx = i
+			break
+		}
+		if st.Field(i).Name == "ID" && idx < 0 {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		return "", "", v, fmt.Errorf("jsonapi: %s has no id field", st.Name())
+	}
+	return typ, fmt.Sprint(v.Field(idx).Interface()), v, nil
+}
+
+// parseTag splits `attr,name` style tags; the name defaults to the kind for `id`
+func parseTag(tag string) (kind, name string, ok bool) {
+	if tag == "" || tag == "-" {
+		return "", "", false
+	}
+	kind, name, _ = strings.Cut(tag, ",")
+	return kind, name, name != "" || kind == "id"
+}
+
+// kebab converts a Go type name to kebab case: BlogPost -> blog-post, APIKey -> api-key
+func kebab(s string) string {
+	rs := []rune(s)
+	var b strings.Builder
+	for i, r := range rs {
+		if unicode.IsUpper(r) {
+			startsWord := i > 0 && (unicode.IsLower(rs[i-1]) ||
+				(i+1 < len(rs) && unicode.IsLower(rs[i+1]) && unicode.IsUpper(rs[i-1])))
+			if startsWord {
+				b.WriteByte('-')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,163 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/example/go-chi-rest/internal/errcodes"
+	"github.com/example/go-chi-rest/internal/pagination"
+)
+
+type Person struct {
+	ID   string `jsonapi:"id"`
+	Name string `jsonapi:"attr,name"`
+}
+
+func (Person) JSONAPIType() string { return "people" }
+
+type Tag struct {
+	ID int
+}
+
+type BlogPost struct {
+	ID       int     `jsonapi:"id"`
+	Title    string  `jsonapi:"attr,title"`
+	Draft    bool    `jsonapi:"attr,draft"`
+	Author   *Person `jsonapi:"relation,author"`
+	Editor   *Person `jsonapi:"relation,editor"`
+	Tags     []Tag   `jsonapi:"relation,tags"`
+	internal string
+}
+
+// decode returns the response body as generic JSON
+func decode(t *testing.T, rec *httptest.ResponseRecorder) map[string]any {
+	t.Helper()
+	if ct := rec.Header().Get("Content-Type"); ct != MediaType {
+		t.Errorf("Content-Type = %q, want %s", ct, MediaType)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+// path walks nested maps and slices, e.g. path(doc, "data", "links", "self")
+func path(v any, keys ...any) any {
+	for _, k := range keys {
+		switch k := k.(type) {
+		case string:
+			m, _ := v.(map[string]any)
+			v = m[k]
+		case int:
+			s, _ := v.([]any)
+			if k >= len(s) {
+				return nil
+			}
+			v = s[k]
+		}
+	}
+	return v
+}
+
+func TestEncodeResource(t *testing.T) {
+	post := BlogPost{ID: 7, Title: "Hello", Author: &Person{ID: "u1", Name: "Ann"}, Tags: []Tag{{1}, {2}}}
+	rec := httptest.NewRecorder()
+	if err := EncodeResource(rec, http.StatusOK, post, map[string]any{"request_id": "r1"}); err != nil {
+		t.Fatal(err)
+	}
+	doc := decode(t, rec)
+
+	tests := []struct {
+		name string
+		keys []any
+		want any
+	}{
+		{"version", []any{"jsonapi", "version"}, "1.1"},
+		{"type", []any{"data", "type"}, "blog-post"},
+		{"id", []any{"data", "id"}, "7"},
+		{"self link", []any{"data", "links", "self"}, "/api/v1/blog-post/7"},
+		{"attribute", []any{"data", "attributes", "title"}, "Hello"},
+		{"false attribute kept", []any{"data", "attributes", "draft"}, false},
+		{"to-one type", []any{"data", "relationships", "author", "data", "type"}, "people"},
+		{"to-one id", []any{"data", "relationships", "author", "data", "id"}, "u1"},
+		{"relationship self", []any{"data", "relationships", "author", "links", "self"}, "/api/v1/blog-post/7/relationships/author"},
+		{"relationship related", []any{"data", "relationships", "author", "links", "related"}, "/api/v1/blog-post/7/author"},
+		{"to-many", []any{"data", "relationships", "tags", "data", 1, "id"}, "2"},
+		{"to-many type", []any{"data", "relationships", "tags", "data", 0, "type"}, "tag"},
+		{"empty to-one is null", []any{"data", "relationships", "editor", "data"}, nil},
+		{"meta", []any{"meta", "request_id"}, "r1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := path(doc, tt.keys...); got != tt.want {
+				t.Errorf("%v = %v, want %v", tt.keys, got, tt.want)
+			}
+		})
+	}
+	if _, ok := path(doc, "data", "relationships").(map[string]any)["editor"]; !ok {
+		t.Error("empty relationship omitted, want data: null")
+	}
+	if _, ok := path(doc, "data", "attributes").(map[string]any)["internal"]; ok {
+		t.Error("untagged field encoded")
+	}
+}
+
+func TestEncodeCollectionWithPageLinks(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/tags?limit=2&cursor=abc&direction=forward&sort=id", nil)
+	links := PageLinks(req, pagination.PageParams{Limit: 2}, "n1", "p1")
+	rec := httptest.NewRecorder()
+	if err := EncodeCollection(rec, http.StatusOK, []Tag{{1}, {2}}, nil, links); err != nil {
+		t.Fatal(err)
+	}
+	doc := decode(t, rec)
+
+	if got := path(doc, "data", 1, "id"); got != "2" {
+		t.Errorf("data[1].id = %v, want 2", got)
+	}
+	want := map[string]any{
+		"self":  "/api/v1/tags?limit=2&cursor=abc&direction=forward&sort=id",
+		"first": "/api/v1/tags?limit=2&sort=id",
+		"next":  "/api/v1/tags?cursor=n1&direction=forward&limit=2&sort=id",
+		"prev":  "/api/v1/tags?cursor=p1&direction=backward&limit=2&sort=id",
+	}
+	for k, w := range want {
+		if got := path(doc, "links", k); got != w {
+			t.Errorf("links.%s = %v, want %v", k, got, w)
+		}
+	}
+	if path(doc, "links", "last") != nil {
+		t.Error("links.last set for a cursor-paginated collection")
+	}
+
+	rec = httptest.NewRecorder()
+	EncodeCollection(rec, http.StatusOK, []Tag{}, nil, nil)
+	if data, ok := decode(t, rec)["data"].([]any); !ok || len(data) != 0 {
+		t.Errorf("empty collection data = %v, want []", data)
+	}
+}
+
+func TestEncodeError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	EncodeError(rec, errcodes.New("RESOURCE_NOT_FOUND", "post", "7"))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", rec.Code)
+	}
+	doc := decode(t, rec)
+	if path(doc, "errors", 0, "status") != "404" || path(doc, "errors", 0, "code") != "RESOURCE_NOT_FOUND" || path(doc, "errors", 0, "title") != "Not Found" {
+		t.Errorf("errors = %v", doc["errors"])
+	}
+	if _, ok := doc["data"]; ok {
+		t.Error("error document contains data")
+	}
+}
+
+func TestKebab(t *testing.T) {
+	for in, want := range map[string]string{"BlogPost": "blog-post", "APIKey": "api-key", "User": "user", "HTTPServerConfig": "http-server-config"} {
+		if got := kebab(in); got != want {
+			t.Errorf("kebab(%s) = %s, want %s", in, got, want)
+		}
+	}
+}
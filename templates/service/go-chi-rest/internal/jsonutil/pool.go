@@ -0,0 +1,62 @@
+// Package jsonutil writes JSON responses without allocating an encoder and
+// buffer per call.
+//
+// Use WriteJSONFast on hot paths that always answer JSON: small, frequent
+// responses such as /api/v1/ping, where the per-call encoder and buffer
+// dominate the allocations. Use negotiate.WriteResponse everywhere else,
+// since it also serves MessagePack and CBOR. Either is fine for large or rare
+// responses, where encoding the value itself dominates.
+package jsonutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// maxPooledBytes keeps a single large response from pinning a big buffer in
+// the pool
+const maxPooledBytes = 64 << 10
+
+type pooledEncoder struct {
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+var encoders = sync.Pool{
+	New: func() any {
+		e := &pooledEncoder{}
+		e.enc = json.NewEncoder(&e.buf)
+		e.enc.SetEscapeHTML(false)
+		return e
+	},
+}
+
+// WriteJSONFast writes v as JSON with status, byte for byte what writeJSON in
+// cmd/server writes: Content-Type application/json; charset=utf-8, no HTML
+// escaping, a trailing newline and no body for a nil v. The body is encoded
+// into a pooled buffer first, so an encoding error still answers 500.
+func WriteJSONFast(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if v == nil {
+		w.WriteHeader(status)
+		return
+	}
+	e := encoders.Get().(*pooledEncoder)
+	defer func() {
+		if e.buf.Cap() <= maxPooledBytes {
+			e.buf.Reset()
+			encoders.Put(e)
+		}
+	}()
+	if err := e.enc.Encode(v); err != nil {
+		zap.L().Error("failed to encode json response", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(status)
+	w.Write(e.buf.Bytes())
+}
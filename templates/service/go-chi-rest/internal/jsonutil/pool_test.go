@@ -0,0 +1,132 @@
+package jsonutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/*.golden")
+
+// writeJSON is the per-call encoder writer of cmd/server that WriteJSONFast
+// must match byte for byte
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if v == nil {
+		return
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+}
+
+// record100 returns a struct value with 100 fields of mixed kinds
+func record100() any {
+	kinds := []any{"", 0, 0.0, false, []int(nil), (*string)(nil)}
+	fields := make([]reflect.StructField, 100)
+	for i := range fields {
+		fields[i] = reflect.StructField{
+			Name: fmt.Sprintf("F%03d", i),
+			Type: reflect.TypeOf(kinds[i%len(kinds)]),
+			Tag:  reflect.StructTag(fmt.Sprintf(`json:"field_%d"`, i)),
+		}
+	}
+	v := reflect.New(reflect.StructOf(fields)).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		switch f := v.Field(i); f.Kind() {
+		case reflect.String:
+			f.SetString(fmt.Sprintf("<value %d> & \"quoted\" é", i))
+		case reflect.Int:
+			f.SetInt(int64(i * 1000))
+		case reflect.Float64:
+			f.SetFloat(float64(i) / 7)
+		case reflect.Bool:
+			f.SetBool(i%4 == 3)
+		case reflect.Slice:
+			f.Set(reflect.ValueOf([]int{i, i + 1}))
+		}
+	}
+	return v.Interface()
+}
+
+func TestWriteJSONFastGolden(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteJSONFast(rec, http.StatusCreated, record100())
+
+	golden := filepath.Join("testdata", "record100.golden")
+	if *update {
+		if err := os.WriteFile(golden, rec.Body.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(rec.Body.Bytes(), want) {
+		t.Errorf("body differs from %s:\n%s", golden, rec.Body)
+	}
+}
+
+func TestWriteJSONFastMatchesWriteJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		v      any
+	}{
+		{"record", http.StatusOK, record100()},
+		{"map", http.StatusOK, map[string]any{"message": "pong", "html": "<b>"}},
+		{"nil", http.StatusNoContent, nil},
+		{"unencodable", http.StatusOK, map[string]any{"ch": make(chan int)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fast, slow := httptest.NewRecorder(), httptest.NewRecorder()
+			WriteJSONFast(fast, tt.status, tt.v)
+			writeJSON(slow, tt.status, tt.v)
+			if fast.Header().Get("Content-Type") != slow.Header().Get("Content-Type") {
+				t.Errorf("Content-Type %q, want %q", fast.Header().Get("Content-Type"), slow.Header().Get("Content-Type"))
+			}
+			if tt.name == "unencodable" {
+				// the pooled writer encodes first, so it can still answer 500
+				if fast.Code != http.StatusInternalServerError || fast.Body.Len() != 0 {
+					t.Errorf("got %d %q, want an empty 500", fast.Code, fast.Body)
+				}
+				return
+			}
+			if fast.Code != slow.Code || !bytes.Equal(fast.Body.Bytes(), slow.Body.Bytes()) {
+				t.Errorf("fast %d %q\nslow %d %q", fast.Code, fast.Body, slow.Code, slow.Body)
+			}
+		})
+	}
+}
+
+// discardWriter is a ResponseWriter that keeps nothing, so the benchmarks
+// measure the writers rather than a recorder's buffer
+type discardWriter struct{ h http.Header }
+
+func (d discardWriter) Header() http.Header         { return d.h }
+func (d discardWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (d discardWriter) WriteHeader(int)             {}
+
+func benchmarkWriter(b *testing.B, write func(http.ResponseWriter, int, any)) {
+	v := record100()
+	w := discardWriter{h: http.Header{}}
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			write(w, http.StatusOK, v)
+		}
+	})
+}
+
+func BenchmarkWriteJSON(b *testing.B)     { benchmarkWriter(b, writeJSON) }
+func BenchmarkWriteJSONFast(b *testing.B) { benchmarkWriter(b, WriteJSONFast) }
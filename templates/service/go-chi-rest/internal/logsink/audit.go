@@ -0,0 +1,109 @@
+package logsink
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// auditFile is the active audit log in AuditConfig.Dir. Lumberjack names
+// rotated files audit-<timestamp>.log[.gz], which PurgeAuditLogs matches; the
+// active file never does.
+const auditFile = "audit.log"
+
+// AuditConfig configures the rotated audit log
+type AuditConfig struct {
+	Dir        string        `mapstructure:"dir"`          // empty disables the audit log; the active file is <dir>/audit.log
+	MaxSizeMB  int           `mapstructure:"max_size_mb"`  // rotate once the file reaches this size
+	MaxAgeDays int           `mapstructure:"max_age_days"` // lumberjack deletes older rotated files when it rotates; 0 keeps them
+	MaxBackups int           `mapstructure:"max_backups"`  // rotated files kept; 0 keeps all
+	Compress   bool          `mapstructure:"compress"`     // gzip rotated files
+	Retention  time.Duration `mapstructure:"retention"`    // the nightly purge deletes rotated files older than this; 0 disables it
+}
+
+// AuditEvent is one line of the audit log
+type AuditEvent struct {
+	Time       time.Time `json:"time"`
+	Action     string    `json:"action"` // e.g. the HTTP method
+	Target     string    `json:"target"` // e.g. the request path
+	Status     int       `json:"status,omitempty"`
+	RemoteAddr string    `json:"remote_addr,omitempty"`
+}
+
+// RotatingAuditSink appends audit events as JSON lines to a size-rotated file
+type RotatingAuditSink struct {
+	lj *lumberjack.Logger
+}
+
+// NewRotatingAuditSink creates cfg.Dir and opens the audit log in it on the
+// first write
+func NewRotatingAuditSink(cfg AuditConfig) (*RotatingAuditSink, error) {
+	if cfg.Dir == "" {
+		return nil, errors.New("audit log: dir is empty")
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &RotatingAuditSink{lj: &lumberjack.Logger{
+		Filename:   filepath.Join(cfg.Dir, auditFile),
+		MaxSize:    cfg.MaxSizeMB,
+		MaxAge:     cfg.MaxAgeDays,
+		MaxBackups: cfg.MaxBackups,
+		Compress:   cfg.Compress,
+	}}, nil
+}
+
+// Record appends e, stamping it with the current time when e.Time is zero
+func (s *RotatingAuditSink) Record(e AuditEvent) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	// one Write per event, so lumberjack never splits a line across files
+	_, err = s.lj.Write(append(b, '\n'))
+	return err
+}
+
+// Rotate starts a new file now, whatever the size of the current one
+func (s *RotatingAuditSink) Rotate() error {
+	return s.lj.Rotate()
+}
+
+// Close closes the current file
+func (s *RotatingAuditSink) Close() error {
+	return s.lj.Close()
+}
+
+// PurgeAuditLogs deletes rotated audit logs (audit-*.log*) in dir last
+// modified more than olderThan ago and returns how many it deleted. The
+// active audit.log is never touched.
+func PurgeAuditLogs(dir string, olderThan time.Duration) (int, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "audit-*.log*"))
+	if err != nil {
+		return 0, err
+	}
+	cutoff := time.Now().Add(-olderThan)
+	var (
+		n    int
+		errs []error
+	)
+	for _, path := range matches {
+		info, err := os.Lstat(path)
+		if err != nil || !info.Mode().IsRegular() || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		n++
+	}
+	return n, errors.Join(errs...)
+}
@@ -0,0 +1,108 @@
+package logsink
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingAuditSinkCompresses(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewRotatingAuditSink(AuditConfig{Dir: dir, MaxSizeMB: 1, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ~1.1 MB of events, so the 1 MB file rotates once
+	target := "/admin/" + strings.Repeat("x", 1000)
+	for i := 0; i < 1100; i++ {
+		if err := sink.Record(AuditEvent{Action: "POST", Target: target, Status: 200}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// lumberjack compresses in the background, then removes the plain file
+	var gzipped []string
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		gzipped, _ = filepath.Glob(filepath.Join(dir, "audit-*.log.gz"))
+		plain, _ := filepath.Glob(filepath.Join(dir, "audit-*.log"))
+		if len(gzipped) == 1 && len(plain) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("rotated files: gzipped %v, plain %v; want one gzipped", gzipped, plain)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "audit.log")); err != nil {
+		t.Errorf("active file: %v", err)
+	}
+
+	f, err := os.Open(gzipped[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("rotated file is not gzip: %v", err)
+	}
+	scanner := bufio.NewScanner(zr)
+	scanner.Buffer(nil, 64<<10)
+	var lines int
+	for scanner.Scan() {
+		var e AuditEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.Target != target || e.Time.IsZero() {
+			t.Fatalf("line %d = %.80s, %v", lines+1, scanner.Text(), err)
+		}
+		lines++
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if lines == 0 || lines >= 1100 {
+		t.Errorf("rotated file holds %d events, want part of the 1100", lines)
+	}
+}
+
+func TestPurgeAuditLogs(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-48 * time.Hour)
+	files := map[string]time.Time{
+		"audit.log":                            old, // active file
+		"audit-2026-01-01T00-00-00.000.log.gz": old,
+		"audit-2026-01-02T00-00-00.000.log":    old,
+		"audit-2026-10-14T00-00-00.000.log.gz": time.Now(),
+		"app-2026-01-01T00-00-00.000.log.gz":   old,
+		"audit-notes.txt":                      old,
+	}
+	for name, mtime := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("x"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := PurgeAuditLogs(dir, 24*time.Hour)
+	if err != nil || n != 2 {
+		t.Fatalf("PurgeAuditLogs = %d, %v; want 2", n, err)
+	}
+	for name := range files {
+		_, err := os.Stat(filepath.Join(dir, name))
+		purged := name == "audit-2026-01-01T00-00-00.000.log.gz" || name == "audit-2026-01-02T00-00-00.000.log"
+		if purged != os.IsNotExist(err) {
+			t.Errorf("%s: purged = %v, want %v", name, os.IsNotExist(err), purged)
+		}
+	}
+}
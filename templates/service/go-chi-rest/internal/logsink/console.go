@@ -0,0 +1,246 @@
+package logsink
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mattn/go-isatty"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var bufferPool = buffer.NewPool()
+
+// ANSI colors per level; debug stays uncoloured
+const (
+	colorReset  = "\x1b[0m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorRed    = "\x1b[31m"
+)
+
+// NewColoredConsoleEncoder returns an encoder for humans at a terminal:
+//
+//	[INFO  2024-01-15T12:00:00Z] request method=GET path=/api/v1/ping status=200 duration=1.2ms
+//
+// Levels are coloured (info green, warn yellow, error and above red) unless
+// NO_COLOR is set or stdout is not a terminal.
+func NewColoredConsoleEncoder() zapcore.Encoder {
+	color := os.Getenv("NO_COLOR") == "" && isatty.IsTerminal(os.Stdout.Fd())
+	return newColoredConsoleEncoder(color)
+}
+
+func newColoredConsoleEncoder(color bool) *coloredConsoleEncoder {
+	return &coloredConsoleEncoder{buf: bufferPool.Get(), color: color}
+}
+
+// coloredConsoleEncoder renders fields as key=value pairs. Context fields
+// added with With are pre-encoded into buf; arrays, objects and reflected
+// values are rendered as JSON.
+type coloredConsoleEncoder struct {
+	buf       *buffer.Buffer
+	color     bool
+	namespace string // key prefix opened by OpenNamespace
+}
+
+func (e *coloredConsoleEncoder) Clone() zapcore.Encoder {
+	return e.clone()
+}
+
+func (e *coloredConsoleEncoder) clone() *coloredConsoleEncoder {
+	c := &coloredConsoleEncoder{buf: bufferPool.Get(), color: e.color, namespace: e.namespace}
+	c.buf.Write(e.buf.Bytes())
+	return c
+}
+
+func (e *coloredConsoleEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	line := bufferPool.Get()
+	line.AppendByte('[')
+	level := fmt.Sprintf("%-5s", ent.Level.CapitalString())
+	if c := levelColor(ent.Level); e.color && c != "" {
+		line.AppendString(c + level + colorReset)
+	} else {
+		line.AppendString(level)
+	}
+	line.AppendByte(' ')
+	line.AppendString(ent.Time.UTC().Format(time.RFC3339))
+	line.AppendString("] ")
+	if ent.LoggerName != "" {
+		line.AppendString(ent.LoggerName + ": ")
+	}
+	line.AppendString(ent.Message)
+
+	fe := e.clone()
+	for _, f := range fields {
+		f.AddTo(fe)
+	}
+	line.Write(fe.buf.Bytes())
+	fe.buf.Free()
+	if ent.Caller.Defined {
+		line.AppendString(" caller=" + ent.Caller.TrimmedPath())
+	}
+	line.AppendByte('\n')
+	if ent.Stack != "" {
+		line.AppendString(ent.Stack)
+		line.AppendByte('\n')
+	}
+	return line, nil
+}
+
+func levelColor(l zapcore.Level) string {
+	switch {
+	case l >= zapcore.ErrorLevel:
+		return colorRed
+	case l == zapcore.WarnLevel:
+		return colorYellow
+	case l == zapcore.InfoLevel:
+		return colorGreen
+	}
+	return ""
+}
+
+// key starts a " key=" pair
+func (e *coloredConsoleEncoder) key(k string) {
+	e.buf.AppendByte(' ')
+	e.buf.AppendString(e.namespace)
+	e.buf.AppendString(k)
+	e.buf.AppendByte('=')
+}
+
+// appendString writes s bare when unambiguous, quoted otherwise
+func (e *coloredConsoleEncoder) appendString(s string) {
+	if s == "" || strings.ContainsAny(s, " =\"\t\r\n") || !utf8.ValidString(s) {
+		e.buf.AppendString(strconv.Quote(s))
+		return
+	}
+	e.buf.AppendString(s)
+}
+
+// appendJSON renders complex values through zap's map encoder
+func (e *coloredConsoleEncoder) appendJSON(add func(zapcore.ObjectEncoder) error) error {
+	m := zapcore.NewMapObjectEncoder()
+	if err := add(m); err != nil {
+		return err
+	}
+	b, err := json.Marshal(m.Fields["v"])
+	if err != nil {
+		return err
+	}
+	e.buf.Write(b)
+	return nil
+}
+
+func (e *coloredConsoleEncoder) AddArray(k string, v zapcore.ArrayMarshaler) error {
+	e.key(k)
+	return e.appendJSON(func(m zapcore.ObjectEncoder) error { return m.AddArray("v", v) })
+}
+
+func (e *coloredConsoleEncoder) AddObject(k string, v zapcore.ObjectMarshaler) error {
+	e.key(k)
+	return e.appendJSON(func(m zapcore.ObjectEncoder) error { return m.AddObject("v", v) })
+}
+
+func (e *coloredConsoleEncoder) AddReflected(k string, v interface{}) error {
+	e.key(k)
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	e.buf.Write(b)
+	return nil
+}
+
+func (e *coloredConsoleEncoder) OpenNamespace(k string) {
+	e.namespace += k + "."
+}
+
+func (e *coloredConsoleEncoder) AddBinary(k string, v []byte) {
+	e.key(k)
+	e.buf.AppendString(base64.StdEncoding.EncodeToString(v))
+}
+
+func (e *coloredConsoleEncoder) AddByteString(k string, v []byte) {
+	e.key(k)
+	e.appendString(string(v))
+}
+
+func (e *coloredConsoleEncoder) AddBool(k string, v bool) {
+	e.key(k)
+	e.buf.AppendBool(v)
+}
+
+func (e *coloredConsoleEncoder) AddComplex128(k string, v complex128) {
+	e.key(k)
+	e.buf.AppendString(strconv.FormatComplex(v, 'g', -1, 128))
+}
+
+func (e *coloredConsoleEncoder) AddComplex64(k string, v complex64) {
+	e.key(k)
+	e.buf.AppendString(strconv.FormatComplex(complex128(v), 'g', -1, 64))
+}
+
+func (e *coloredConsoleEncoder) AddDuration(k string, v time.Duration) {
+	e.key(k)
+	e.buf.AppendString(v.String())
+}
+
+func (e *coloredConsoleEncoder) AddFloat64(k string, v float64) {
+	e.key(k)
+	e.appendFloat(v, 64)
+}
+
+func (e *coloredConsoleEncoder) AddFloat32(k string, v float32) {
+	e.key(k)
+	e.appendFloat(float64(v), 32)
+}
+
+func (e *coloredConsoleEncoder) appendFloat(v float64, bits int) {
+	switch {
+	case math.IsNaN(v):
+		e.buf.AppendString("NaN")
+	case math.IsInf(v, 1):
+		e.buf.AppendString("+Inf")
+	case math.IsInf(v, -1):
+		e.buf.AppendString("-Inf")
+	default:
+		e.buf.AppendFloat(v, bits)
+	}
+}
+
+func (e *coloredConsoleEncoder) AddInt(k string, v int)     { e.AddInt64(k, int64(v)) }
+func (e *coloredConsoleEncoder) AddInt32(k string, v int32) { e.AddInt64(k, int64(v)) }
+func (e *coloredConsoleEncoder) AddInt16(k string, v int16) { e.AddInt64(k, int64(v)) }
+func (e *coloredConsoleEncoder) AddInt8(k string, v int8)   { e.AddInt64(k, int64(v)) }
+
+func (e *coloredConsoleEncoder) AddInt64(k string, v int64) {
+	e.key(k)
+	e.buf.AppendInt(v)
+}
+
+func (e *coloredConsoleEncoder) AddString(k, v string) {
+	e.key(k)
+	e.appendString(v)
+}
+
+func (e *coloredConsoleEncoder) AddTime(k string, v time.Time) {
+	e.key(k)
+	e.buf.AppendString(v.UTC().Format(time.RFC3339Nano))
+}
+
+func (e *coloredConsoleEncoder) AddUint(k string, v uint)       { e.AddUint64(k, uint64(v)) }
+func (e *coloredConsoleEncoder) AddUint32(k string, v uint32)   { e.AddUint64(k, uint64(v)) }
+func (e *coloredConsoleEncoder) AddUint16(k string, v uint16)   { e.AddUint64(k, uint64(v)) }
+func (e *coloredConsoleEncoder) AddUint8(k string, v uint8)     { e.AddUint64(k, uint64(v)) }
+func (e *coloredConsoleEncoder) AddUintptr(k string, v uintptr) { e.AddUint64(k, uint64(v)) }
+
+func (e *coloredConsoleEncoder) AddUint64(k string, v uint64) {
+	e.key(k)
+	e.buf.AppendUint(v)
+}
@@ -0,0 +1,77 @@
+package logsink
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func encode(t *testing.T, enc zapcore.Encoder, level zapcore.Level, msg string, fields ...zap.Field) string {
+	t.Helper()
+	ent := zapcore.Entry{Level: level, Time: time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC), Message: msg}
+	buf, err := enc.EncodeEntry(ent, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer buf.Free()
+	return buf.String()
+}
+
+func TestColoredConsoleEncoderColors(t *testing.T) {
+	tests := []struct {
+		level zapcore.Level
+		color string
+	}{
+		{zapcore.InfoLevel, colorGreen},
+		{zapcore.WarnLevel, colorYellow},
+		{zapcore.ErrorLevel, colorRed},
+		{zapcore.DPanicLevel, colorRed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			got := encode(t, newColoredConsoleEncoder(true), tt.level, "hello")
+			if !strings.HasPrefix(got, "["+tt.color+tt.level.CapitalString()) || !strings.Contains(got, colorReset) {
+				t.Errorf("color output = %q", got)
+			}
+			if plain := encode(t, newColoredConsoleEncoder(false), tt.level, "hello"); strings.Contains(plain, "\x1b[") {
+				t.Errorf("no-color output has ANSI codes: %q", plain)
+			}
+		})
+	}
+	if got := encode(t, newColoredConsoleEncoder(true), zapcore.DebugLevel, "hello"); strings.Contains(got, "\x1b[") {
+		t.Errorf("debug is coloured: %q", got)
+	}
+}
+
+func TestColoredConsoleEncoderNoColorEnv(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	if got := encode(t, NewColoredConsoleEncoder(), zapcore.ErrorLevel, "hello"); strings.Contains(got, "\x1b[") {
+		t.Errorf("NO_COLOR output has ANSI codes: %q", got)
+	}
+}
+
+func TestColoredConsoleEncoderFields(t *testing.T) {
+	enc := newColoredConsoleEncoder(false)
+	enc.AddString("service", "api") // context field, as added by With
+	got := encode(t, enc, zapcore.InfoLevel, "request",
+		zap.String("method", "GET"),
+		zap.Int("status", 200),
+		zap.Duration("duration", 1200*time.Microsecond),
+		zap.String("ua", "curl 8.0"),
+		zap.Bool("cached", true),
+		zap.Strings("tags", []string{"a", "b"}),
+		zap.Namespace("db"),
+		zap.Int("rows", 3),
+	)
+	want := `[INFO  2024-01-15T12:00:00Z] request service=api method=GET status=200 duration=1.2ms ua="curl 8.0" cached=true tags=["a","b"] db.rows=3` + "\n"
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+	// the context field is not consumed by encoding an entry
+	if again := encode(t, enc, zapcore.InfoLevel, "next"); !strings.Contains(again, "service=api") || strings.Contains(again, "method=") {
+		t.Errorf("second entry = %q", again)
+	}
+}
@@ -0,0 +1,46 @@
+package logsink
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestBuildFileOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	cfg := zap.NewProductionConfig()
+	logger, closeFn, err := Build(cfg, []string{"file://" + path + "?max_size_mb=1&compress=false"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("first line", zap.String("k", "v"))
+	logger.Warn("second line")
+	logger.Debug("below the level")
+	logger.Sync()
+	if err := closeFn(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"msg":"first line"`) || !strings.Contains(lines[0], `"k":"v"`) ||
+		!strings.Contains(lines[1], `"msg":"second line"`) {
+		t.Errorf("log file:\n%s", b)
+	}
+}
+
+func TestBuildRejectsBadOutputs(t *testing.T) {
+	for _, out := range []string{"kafka://broker", "file://", "file:///tmp/x.log?max_size_mb=big"} {
+		t.Run(out, func(t *testing.T) {
+			if _, _, err := Build(zap.NewProductionConfig(), []string{"stdout", out}); err == nil {
+				t.Errorf("Build(%q) succeeded", out)
+			}
+		})
+	}
+}
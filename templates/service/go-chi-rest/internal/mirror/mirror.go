@@ -0,0 +1,131 @@
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+var (
+	mirrorRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "mirror_requests_total",
+		Help: "Shadow requests by outcome (HTTP status code or \"error\").",
+	}, []string{"status"})
+	mirrorDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "mirror_request_duration_seconds",
+		Help:    "Latency of shadow requests.",
+		Buckets: prometheus.DefBuckets,
+	})
+)
+
+// MirrorConfig configures traffic shadowing
+type MirrorConfig struct {
+	MaxBodySize int64         `mapstructure:"max_body_size"` // larger bodies are not mirrored
+	Timeout     time.Duration `mapstructure:"timeout"`
+	SampleRate  float64       `mapstructure:"sample_rate"` // 0.0-1.0
+}
+
+// NewMirrorMiddleware copies a sample of incoming requests to shadowURL in the
+// background. Shadow responses are discarded and shadow failures never affect
+// the primary request.
+func NewMirrorMiddleware(shadowURL string, cfg MirrorConfig) func(http.Handler) http.Handler {
+	target, err := url.Parse(shadowURL)
+	if err != nil {
+		panic("mirror: invalid shadow URL: " + err.Error())
+	}
+	if cfg.MaxBodySize == 0 {
+		cfg.MaxBodySize = 1 << 20
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 2 * time.Second
+	}
+	client := &http.Client{Timeout: cfg.Timeout}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if cfg.SampleRate <= 0 || rand.Float64() >= cfg.SampleRate {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var body []byte
+			if r.Body != nil && r.Body != http.NoBody {
+				buf, err := io.ReadAll(io.LimitReader(r.Body, cfg.MaxBodySize+1))
+				// hand the primary handler the bytes we consumed plus the rest
+				r.Body = readCloser{io.MultiReader(bytes.NewReader(buf), r.Body), r.Body}
+				if err != nil || int64(len(buf)) > cfg.MaxBodySize {
+					next.ServeHTTP(w, r)
+					return
+				}
+				body = buf
+			}
+
+			shadow := buildShadow(r, target, body)
+			go send(client, cfg.Timeout, shadow)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// buildShadow clones method, path, query and headers onto the shadow target
+func buildShadow(r *http.Request, target *url.URL, body []byte) *http.Request {
+	u := *target
+	u.Path = singleJoin(target.Path, r.URL.Path)
+	u.RawQuery = r.URL.RawQuery
+
+	shadow, _ := http.NewRequest(r.Method, u.String(), bytes.NewReader(body))
+	shadow.Header = r.Header.Clone()
+	shadow.Header.Set("X-Mirrored-From", r.Host)
+	shadow.Header.Del("Content-Length")
+	shadow.ContentLength = int64(len(body))
+	return shadow
+}
+
+func send(client *http.Client, timeout time.Duration, req *http.Request) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			zap.L().Error("mirror panic", zap.Any("panic", rec))
+		}
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	resp, err := client.Do(req.WithContext(ctx))
+	mirrorDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		mirrorRequests.WithLabelValues("error").Inc()
+		zap.L().Debug("shadow request failed", zap.String("url", req.URL.String()), zap.Error(err))
+		return
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	mirrorRequests.WithLabelValues(strconv.Itoa(resp.StatusCode)).Inc()
+}
+
+func singleJoin(a, b string) string {
+	switch {
+	case a == "" || a == "/":
+		return b
+	case a[len(a)-1] == '/' && len(b) > 0 && b[0] == '/':
+		return a + b[1:]
+	case a[len(a)-1] != '/' && (len(b) == 0 || b[0] != '/'):
+		return a + "/" + b
+	}
+	return a + b
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,115 @@
+package mirror
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type shadowReq struct {
+	method, uri, body, mirroredFrom string
+}
+
+// shadowServer records every request it receives on the returned channel
+func shadowServer(t *testing.T, delay time.Duration) (*httptest.Server, chan shadowReq) {
+	t.Helper()
+	got := make(chan shadowReq, 100)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- shadowReq{r.Method, r.URL.RequestURI(), string(body), r.Header.Get("X-Mirrored-From")}
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, got
+}
+
+// primary echoes the request body so tests can check it was left intact
+var primary = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	io.Copy(w, r.Body)
+})
+
+func serve(h http.Handler, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "http://api.example.com/api/v1/items?x=1", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMirrorSampleRate(t *testing.T) {
+	tests := []struct {
+		name string
+		rate float64
+		want int
+	}{
+		{"sample 1 mirrors all", 1, 10},
+		{"sample 0 mirrors none", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, got := shadowServer(t, 0)
+			h := NewMirrorMiddleware(srv.URL+"/shadow", MirrorConfig{SampleRate: tt.rate})(primary)
+			for i := 0; i < 10; i++ {
+				if rec := serve(h, "payload"); rec.Body.String() != "payload" {
+					t.Fatalf("primary body = %q", rec.Body)
+				}
+			}
+			want := shadowReq{http.MethodPost, "/shadow/api/v1/items?x=1", "payload", "api.example.com"}
+			n := 0
+			timeout := time.After(200 * time.Millisecond)
+		collect:
+			for n < 10 {
+				select {
+				case r := <-got:
+					if r != want {
+						t.Errorf("shadow request = %+v, want %+v", r, want)
+					}
+					n++
+				case <-timeout:
+					break collect
+				}
+			}
+			if n != tt.want {
+				t.Errorf("mirrored %d requests, want %d", n, tt.want)
+			}
+		})
+	}
+}
+
+func TestMirrorShadowTimeoutDoesNotDelayPrimary(t *testing.T) {
+	srv, got := shadowServer(t, time.Minute)
+	h := NewMirrorMiddleware(srv.URL, MirrorConfig{SampleRate: 1, Timeout: 50 * time.Millisecond})(primary)
+
+	start := time.Now()
+	rec := serve(h, "payload")
+	if elapsed := time.Since(start); elapsed >= 50*time.Millisecond {
+		t.Errorf("primary took %v, want it not to wait for the shadow", elapsed)
+	}
+	if rec.Code != http.StatusOK || rec.Body.String() != "payload" {
+		t.Errorf("primary = %d %q", rec.Code, rec.Body)
+	}
+	select {
+	case <-got:
+	case <-time.After(time.Second):
+		t.Error("shadow never received the request")
+	}
+}
+
+func TestMirrorSkipsLargeBodies(t *testing.T) {
+	srv, got := shadowServer(t, 0)
+	h := NewMirrorMiddleware(srv.URL, MirrorConfig{SampleRate: 1, MaxBodySize: 4})(primary)
+
+	if rec := serve(h, "too large"); rec.Body.String() != "too large" {
+		t.Errorf("primary body = %q, want the full body", rec.Body)
+	}
+	select {
+	case r := <-got:
+		t.Errorf("oversized body mirrored: %+v", r)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
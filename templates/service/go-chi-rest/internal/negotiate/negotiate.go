@@ -0,0 +1,139 @@
+package negotiate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Supported media types
+const (
+	JSON    = "application/json"
+	MsgPack = "application/msgpack"
+	CBOR    = "application/cbor"
+)
+
+type acceptedKey struct{}
+
+// cborDecoder decodes maps as map[string]any so they can be re-encoded as JSON
+var cborDecoder, _ = cbor.DecOptions{DefaultMapType: reflect.TypeOf(map[string]any(nil))}.DecMode()
+
+// AcceptedType returns the response media type negotiated for the request (JSON by default)
+func AcceptedType(ctx context.Context) string {
+	if v, ok := ctx.Value(acceptedKey{}).(string); ok {
+		return v
+	}
+	return JSON
+}
+
+// NewContentNegotiationMiddleware picks a response type from Accept and transcodes
+// MessagePack/CBOR request bodies to JSON so handlers only ever decode JSON
+func NewContentNegotiationMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if (ct == MsgPack || ct == CBOR) && r.Body != nil {
+				body, err := toJSON(ct, r.Body)
+				r.Body.Close()
+				if err != nil {
+					WriteResponse(w, http.StatusBadRequest, map[string]any{
+						"error": map[string]string{"code": "invalid_body", "message": err.Error()},
+					}, JSON)
+					return
+				}
+				r.Body = io.NopCloser(bytes.NewReader(body))
+				r.ContentLength = int64(len(body))
+				r.Header.Set("Content-Type", JSON)
+				r.Header.Set("Content-Length", strconv.Itoa(len(body)))
+			}
+
+			accepted := Negotiate(r.Header.Get("Accept"))
+			w.Header().Add("Vary", "Accept")
+			ctx := context.WithValue(r.Context(), acceptedKey{}, accepted)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Negotiate returns the supported type with the highest q-value in accept,
+// falling back to JSON for empty, wildcard or unsupported values
+func Negotiate(accept string) string {
+	best, bestQ := JSON, -1.0
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		switch mt {
+		case JSON, MsgPack, CBOR:
+		case "application/x-msgpack":
+			mt = MsgPack
+		default:
+			continue
+		}
+		if q > 0 && q > bestQ {
+			best, bestQ = mt, q
+		}
+	}
+	return best
+}
+
+// WriteResponse encodes v as accepted (JSON, MessagePack or CBOR) with the given status
+func WriteResponse(w http.ResponseWriter, status int, v any, accepted string) error {
+	var (
+		body []byte
+		err  error
+	)
+	switch accepted {
+	case MsgPack:
+		body, err = msgpack.Marshal(v)
+	case CBOR:
+		body, err = cbor.Marshal(v)
+	default:
+		accepted = JSON + "; charset=utf-8"
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		err = enc.Encode(v)
+		body = buf.Bytes()
+	}
+	if err != nil {
+		http.Error(w, "encoding error", http.StatusInternalServerError)
+		return fmt.Errorf("encode %s response: %w", accepted, err)
+	}
+	w.Header().Set("Content-Type", accepted)
+	w.WriteHeader(status)
+	_, err = w.Write(body)
+	return err
+}
+
+func toJSON(ct string, r io.Reader) ([]byte, error) {
+	var v any
+	switch ct {
+	case MsgPack:
+		if err := msgpack.NewDecoder(r).Decode(&v); err != nil {
+			return nil, fmt.Errorf("decode msgpack body: %w", err)
+		}
+	case CBOR:
+		if err := cborDecoder.NewDecoder(r).Decode(&v); err != nil {
+			return nil, fmt.Errorf("decode cbor body: %w", err)
+		}
+	}
+	return json.Marshal(v)
+}
@@ -0,0 +1,103 @@
+package negotiate
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type item struct {
+	Name  string   `json:"name" msgpack:"name" cbor:"name"`
+	Count int      `json:"count" msgpack:"count" cbor:"count"`
+	Tags  []string `json:"tags" msgpack:"tags" cbor:"tags"`
+}
+
+// echo decodes the (always JSON) request body and writes it back in the accepted type
+var echo = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	var v item
+	if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	WriteResponse(w, http.StatusOK, v, AcceptedType(r.Context()))
+})
+
+func TestRoundTrip(t *testing.T) {
+	in := item{Name: "widget", Count: 3, Tags: []string{"a", "b"}}
+	tests := []struct {
+		name      string
+		mediaType string
+		marshal   func(any) ([]byte, error)
+		unmarshal func([]byte, any) error
+	}{
+		{"msgpack", MsgPack, msgpack.Marshal, msgpack.Unmarshal},
+		{"cbor", CBOR, cbor.Marshal, cbor.Unmarshal},
+		{"json", JSON, json.Marshal, json.Unmarshal},
+	}
+	h := NewContentNegotiationMiddleware()(echo)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := tt.marshal(in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+			req.Header.Set("Content-Type", tt.mediaType)
+			req.Header.Set("Accept", tt.mediaType)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+			}
+			if ct, _, _ := mime.ParseMediaType(rec.Header().Get("Content-Type")); ct != tt.mediaType {
+				t.Errorf("Content-Type = %q, want %s", rec.Header().Get("Content-Type"), tt.mediaType)
+			}
+			var out item
+			if err := tt.unmarshal(rec.Body.Bytes(), &out); err != nil {
+				t.Fatal(err)
+			}
+			if out.Name != in.Name || out.Count != in.Count || len(out.Tags) != 2 || out.Tags[1] != "b" {
+				t.Errorf("round trip = %+v, want %+v", out, in)
+			}
+		})
+	}
+}
+
+func TestInvalidBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte{0xc1}))
+	req.Header.Set("Content-Type", MsgPack)
+	rec := httptest.NewRecorder()
+	NewContentNegotiationMiddleware()(echo).ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", rec.Code)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept, want string
+	}{
+		{"", JSON},
+		{"*/*", JSON},
+		{"text/html", JSON},
+		{"application/msgpack", MsgPack},
+		{"application/x-msgpack", MsgPack},
+		{"application/json;q=0.5, application/cbor", CBOR},
+		{"application/cbor;q=0.2, application/msgpack;q=0.9", MsgPack},
+		{"application/msgpack;q=0", JSON},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			if got := Negotiate(tt.accept); got != tt.want {
+				t.Errorf("Negotiate(%q) = %s, want %s", tt.accept, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,294 @@
+// Package outbox implements the transactional outbox: events are inserted into
+// outbox_events in the same transaction as the business data they describe,
+// and a poller publishes them to the broker afterwards. An event is therefore
+// published if and only if its transaction committed, without two-phase
+// commit. Delivery is at least once; consumers deduplicate on Message.ID.
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+
+	"github.com/example/go-chi-rest/internal/db"
+)
+
+var (
+	pendingEvents = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "outbox_events_pending",
+		Help: "Outbox events neither published nor dead-lettered, as of the last poll.",
+	})
+	publishedEvents = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "outbox_events_published_total",
+		Help: "Outbox events published to the broker.",
+	})
+	failedEvents = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "outbox_events_failed_total",
+		Help: "Failed outbox publish attempts, including those that dead-lettered the event.",
+	})
+)
+
+// Schema creates the outbox table. A failed event is retried at
+// next_attempt_at, which backs off exponentially; dead_lettered_at is set once
+// it has failed MaxAttempts times and the poller skips it from then on.
+const Schema = `CREATE TABLE IF NOT EXISTS outbox_events (
+	id               BIGSERIAL PRIMARY KEY,
+	topic            TEXT NOT NULL,
+	key              TEXT NOT NULL DEFAULT '',
+	payload          BYTEA NOT NULL,
+	created_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+	published_at     TIMESTAMPTZ,
+	attempts         INT NOT NULL DEFAULT 0,
+	last_error       TEXT,
+	dead_lettered_at TIMESTAMPTZ,
+	next_attempt_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now();
+CREATE INDEX IF NOT EXISTS outbox_events_unpublished
+	ON outbox_events (created_at, id) WHERE published_at IS NULL AND dead_lettered_at IS NULL`
+
+// OutboxConfig configures the poller
+type OutboxConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	PollInterval time.Duration `mapstructure:"poll_interval"` // default 1s
+	BatchSize    int           `mapstructure:"batch_size"`    // events per poll; default 100
+	MaxAttempts  int           `mapstructure:"max_attempts"`  // publish attempts before dead-lettering; default 10
+	RetryBackoff time.Duration `mapstructure:"retry_backoff"` // wait after the first failure, doubled per attempt; default 1s
+	MaxBackoff   time.Duration `mapstructure:"max_backoff"`   // cap on the wait between attempts; default 5m
+
+	Publisher Publisher `mapstructure:"-"` // nil uses LogPublisher
+}
+
+func (c OutboxConfig) withDefaults() OutboxConfig {
+	if c.PollInterval <= 0 {
+		c.PollInterval = time.Second
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 100
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 10
+	}
+	if c.RetryBackoff <= 0 {
+		c.RetryBackoff = time.Second
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = 5 * time.Minute
+	}
+	if c.Publisher == nil {
+		c.Publisher = LogPublisher{}
+	}
+	return c
+}
+
+// Event is what a caller writes to the outbox
+type Event struct {
+	Topic   string // Kafka topic or NATS subject
+	Key     string // partition key, optional
+	Payload []byte
+}
+
+// Message is an event read back for publishing
+type Message struct {
+	ID        int64 // stable across retries; use it to deduplicate
+	Topic     string
+	Key       string
+	Payload   []byte
+	CreatedAt time.Time
+}
+
+// Publisher delivers one message to the broker and returns once the broker
+// has acknowledged it
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// LogPublisher only logs messages; replace it with a Kafka or NATS producer
+type LogPublisher struct{}
+
+// Publish implements Publisher
+func (LogPublisher) Publish(_ context.Context, msg Message) error {
+	zap.L().Info("outbox event", zap.Int64("id", msg.ID), zap.String("topic", msg.Topic),
+		zap.String("key", msg.Key), zap.ByteString("payload", msg.Payload))
+	return nil
+}
+
+// Execer is satisfied by pgx.Tx, pgx.Conn and pgxpool.Pool
+type Execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// Outbox publishes the events Write stored
+type Outbox struct {
+	pool *db.ReplicaPool
+	cfg  OutboxConfig
+}
+
+// NewOutbox returns an outbox on the primary of pool
+func NewOutbox(pool *db.ReplicaPool, cfg OutboxConfig) *Outbox {
+	return &Outbox{pool: pool, cfg: cfg.withDefaults()}
+}
+
+// EnsureSchema creates outbox_events if it does not exist
+func (o *Outbox) EnsureSchema(ctx context.Context) error {
+	_, err := o.pool.ExecContext(ctx, Schema)
+	return err
+}
+
+// Write inserts ev through tx, the transaction that also writes the business
+// data, so the event is only published if that transaction commits:
+//
+//	tx, _ := pool.Begin(ctx)
+//	defer tx.Rollback(ctx)
+//	tx.Exec(ctx, `INSERT INTO orders ...`)
+//	outbox.Write(ctx, tx, outbox.Event{Topic: "orders.created", Key: orderID, Payload: body})
+//	tx.Commit(ctx)
+func Write(ctx context.Context, tx Execer, ev Event) error {
+	if ev.Topic == "" {
+		return errors.New("outbox: event topic is required")
+	}
+	_, err := tx.Exec(ctx, `INSERT INTO outbox_events (topic, key, payload) VALUES ($1, $2, $3)`,
+		ev.Topic, ev.Key, ev.Payload)
+	if err != nil {
+		return fmt.Errorf("outbox: write: %w", err)
+	}
+	return nil
+}
+
+// Poll publishes pending events every interval until ctx is cancelled. A full
+// batch is followed by the next one straight away.
+func (o *Outbox) Poll(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = o.cfg.PollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := o.PublishPending(ctx)
+		if err != nil && ctx.Err() == nil {
+			zap.L().Warn("outbox poll failed", zap.Error(err))
+		}
+		o.updatePending(ctx)
+		if err == nil && n == o.cfg.BatchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PublishPending publishes one batch of unpublished events, oldest first,
+// and returns how many were published. The rows are locked with SKIP LOCKED
+// for the duration, so several pollers never publish the same event; run a
+// single poller (e.g. on the election leader) when consumers need the
+// events in order. The batch stops at the first failure so later events do
+// not overtake it, and the batch also stops at an event still waiting out its
+// retry backoff. An event that has failed MaxAttempts times is dead-lettered
+// and no longer holds the others back.
+func (o *Outbox) PublishPending(ctx context.Context) (int, error) {
+	tx, err := o.pool.Primary().Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `SELECT id, topic, key, payload, created_at, attempts, next_attempt_at <= now()
+		FROM outbox_events
+		WHERE published_at IS NULL AND dead_lettered_at IS NULL
+		ORDER BY created_at, id LIMIT $1 FOR UPDATE SKIP LOCKED`, o.cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	pending, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (pendingEvent, error) {
+		var e pendingEvent
+		err := row.Scan(&e.ID, &e.Topic, &e.Key, &e.Payload, &e.CreatedAt, &e.attempts, &e.due)
+		return e, err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, e := range pending {
+		if !e.due {
+			break
+		}
+		msg := e.Message
+		if err := o.cfg.Publisher.Publish(ctx, msg); err != nil {
+			failedEvents.Inc()
+			if ferr := o.markFailed(ctx, tx, msg, e.attempts+1, err); ferr != nil {
+				return 0, ferr
+			}
+			break
+		}
+		if _, err := tx.Exec(ctx, `UPDATE outbox_events SET published_at = now(), attempts = attempts + 1 WHERE id = $1`, msg.ID); err != nil {
+			return 0, err
+		}
+		published++
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	publishedEvents.Add(float64(published))
+	return published, nil
+}
+
+// pendingEvent is a row read for publishing
+type pendingEvent struct {
+	Message
+	attempts int  // failed attempts so far
+	due      bool // next_attempt_at has passed
+}
+
+// markFailed records failed attempt number attempt and schedules the next one
+// after backoff, or dead-letters the event once MaxAttempts is reached
+func (o *Outbox) markFailed(ctx context.Context, tx pgx.Tx, msg Message, attempt int, cause error) error {
+	deadLettered := attempt >= o.cfg.MaxAttempts
+	wait := o.cfg.backoff(attempt)
+	_, err := tx.Exec(ctx, `UPDATE outbox_events SET attempts = $2, last_error = $3,
+		next_attempt_at = now() + make_interval(secs => $4),
+		dead_lettered_at = CASE WHEN $5 THEN now() END
+		WHERE id = $1`,
+		msg.ID, attempt, cause.Error(), wait.Seconds(), deadLettered)
+	if err != nil {
+		return err
+	}
+	log := zap.L().With(zap.Int64("event_id", msg.ID), zap.String("topic", msg.Topic), zap.Int("attempt", attempt), zap.Error(cause))
+	if deadLettered {
+		log.Error("outbox event dead-lettered", zap.Int("max_attempts", o.cfg.MaxAttempts))
+	} else {
+		log.Warn("outbox publish failed; will retry", zap.Duration("retry_in", wait))
+	}
+	return nil
+}
+
+// backoff is the wait after failed attempt number attempt (1-based):
+// RetryBackoff doubled per earlier failure, capped at MaxBackoff
+func (c OutboxConfig) backoff(attempt int) time.Duration {
+	wait := c.RetryBackoff
+	for i := 1; i < attempt && wait < c.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > c.MaxBackoff {
+		wait = c.MaxBackoff
+	}
+	return wait
+}
+
+func (o *Outbox) updatePending(ctx context.Context) {
+	var n int64
+	err := o.pool.Primary().QueryRow(ctx, `SELECT count(*) FROM outbox_events WHERE published_at IS NULL AND dead_lettered_at IS NULL`).Scan(&n)
+	if err == nil {
+		pendingEvents.Set(float64(n))
+	}
+}
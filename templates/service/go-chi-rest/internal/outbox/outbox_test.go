@@ -0,0 +1,133 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/example/go-chi-rest/internal/db"
+)
+
+func TestBackoff(t *testing.T) {
+	cfg := OutboxConfig{RetryBackoff: time.Second, MaxBackoff: 10 * time.Second}.withDefaults()
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{60, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := cfg.backoff(tt.attempt); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+// flakyPublisher fails the first failures calls, as a crashed broker would
+type flakyPublisher struct {
+	mu        sync.Mutex
+	failures  int
+	calls     []time.Time
+	published []int64
+}
+
+func (p *flakyPublisher) Publish(_ context.Context, msg Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, time.Now())
+	if len(p.calls) <= p.failures {
+		return errors.New("broker unavailable")
+	}
+	p.published = append(p.published, msg.ID)
+	return nil
+}
+
+// testOutbox needs a scratch PostgreSQL database in OUTBOX_TEST_DSN; its
+// outbox_events table is dropped
+func testOutbox(t *testing.T, cfg OutboxConfig) *Outbox {
+	t.Helper()
+	dsn := os.Getenv("OUTBOX_TEST_DSN")
+	if dsn == "" {
+		t.Skip("OUTBOX_TEST_DSN not set")
+	}
+	ctx := context.Background()
+	primary, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(primary.Close)
+	if _, err := primary.Exec(ctx, `DROP TABLE IF EXISTS outbox_events`); err != nil {
+		t.Fatal(err)
+	}
+	o := NewOutbox(db.NewReplicaPool(primary), cfg)
+	if err := o.EnsureSchema(ctx); err != nil {
+		t.Fatal(err)
+	}
+	return o
+}
+
+func TestEventSurvivesPublisherCrash(t *testing.T) {
+	pub := &flakyPublisher{failures: 3}
+	o := testOutbox(t, OutboxConfig{RetryBackoff: 50 * time.Millisecond, MaxAttempts: 5, Publisher: pub})
+	ctx := context.Background()
+	for _, topic := range []string{"orders.created", "orders.paid"} {
+		if err := Write(ctx, o.pool.Primary(), Event{Topic: topic, Payload: []byte("{}")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for total := 0; total < 2 && time.Now().Before(deadline); {
+		n, err := o.PublishPending(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		total += n
+		time.Sleep(5 * time.Millisecond) // poll ticks much faster than the backoff
+	}
+
+	pub.mu.Lock()
+	defer pub.mu.Unlock()
+	if len(pub.published) != 2 || pub.published[0] > pub.published[1] {
+		t.Fatalf("published %v, want both events in order", pub.published)
+	}
+	if len(pub.calls) != 5 {
+		t.Errorf("publish calls = %d, want 3 failures + 2 successes regardless of poll rate", len(pub.calls))
+	}
+	// waits of 50ms, 100ms, 200ms between the failed attempts
+	if gap := pub.calls[3].Sub(pub.calls[0]); gap < 350*time.Millisecond {
+		t.Errorf("retries took %s, want exponential backoff of at least 350ms", gap)
+	}
+}
+
+func TestEventDeadLettered(t *testing.T) {
+	pub := &flakyPublisher{failures: 100}
+	o := testOutbox(t, OutboxConfig{RetryBackoff: time.Millisecond, MaxAttempts: 3, Publisher: pub})
+	ctx := context.Background()
+	if err := Write(ctx, o.pool.Primary(), Event{Topic: "orders.created", Payload: []byte("{}")}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		o.PublishPending(ctx)
+		time.Sleep(10 * time.Millisecond)
+	}
+	var attempts int
+	var dead bool
+	err := o.pool.Primary().QueryRow(ctx, `SELECT attempts, dead_lettered_at IS NOT NULL FROM outbox_events`).Scan(&attempts, &dead)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 3 || !dead {
+		t.Errorf("attempts = %d, dead-lettered = %v; want 3, true", attempts, dead)
+	}
+}
@@ -0,0 +1,138 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultLimit is used when the client does not send a limit
+	DefaultLimit = 20
+	// MaxLimit caps the page size a client can request
+	MaxLimit = 100
+)
+
+// Direction of traversal relative to the cursor
+type Direction string
+
+const (
+	Forward  Direction = "forward"
+	Backward Direction = "backward"
+)
+
+// Cursor identifies a position in a list ordered by (createdAt, id)
+type Cursor struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// IsZero reports whether the cursor is unset (first page)
+func (c Cursor) IsZero() bool {
+	return c.ID == "" && c.CreatedAt.IsZero()
+}
+
+// PageParams holds the parsed pagination query parameters
+type PageParams struct {
+	Cursor    Cursor
+	Limit     int
+	Direction Direction
+}
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// EncodeCursor returns an opaque, URL-safe cursor token
+func EncodeCursor(id string, createdAt time.Time) string {
+	b, _ := json.Marshal(Cursor{ID: id, CreatedAt: createdAt.UTC()})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor parses a token produced by EncodeCursor
+func DecodeCursor(token string) (Cursor, error) {
+	var c Cursor
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return c, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return c, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	if c.ID == "" {
+		return c, fmt.Errorf("%w: missing id", ErrInvalidCursor)
+	}
+	return c, nil
+}
+
+// ParsePage reads cursor, limit and direction query parameters
+func ParsePage(r *http.Request) (PageParams, error) {
+	q := r.URL.Query()
+	p := PageParams{Limit: DefaultLimit, Direction: Forward}
+
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return p, fmt.Errorf("limit must be a positive integer")
+		}
+		if n > MaxLimit {
+			n = MaxLimit
+		}
+		p.Limit = n
+	}
+
+	switch d := Direction(q.Get("direction")); d {
+	case "":
+	case Forward, Backward:
+		p.Direction = d
+	default:
+		return p, fmt.Errorf("direction must be %q or %q", Forward, Backward)
+	}
+
+	if token := q.Get("cursor"); token != "" {
+		c, err := DecodeCursor(token)
+		if err != nil {
+			return p, err
+		}
+		p.Cursor = c
+	}
+	return p, nil
+}
+
+type pageInfo struct {
+	NextCursor string `json:"next_cursor"`
+	PrevCursor string `json:"prev_cursor"`
+	HasMore    bool   `json:"has_more"`
+}
+
+type page[T any] struct {
+	Data       []T      `json:"data"`
+	Pagination pageInfo `json:"pagination"`
+}
+
+// RespondPage writes a paginated list response. An empty nextCursor means there are
+// no further items in the forward direction.
+func RespondPage[T any](w http.ResponseWriter, items []T, nextCursor, prevCursor string) {
+	if items == nil {
+		items = []T{}
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(page[T]{
+		Data: items,
+		Pagination: pageInfo{
+			NextCursor: nextCursor,
+			PrevCursor: prevCursor,
+			HasMore:    nextCursor != "",
+		},
+	})
+	if err != nil {
+		zap.L().Error("failed to encode page response", zap.Error(err))
+	}
+}
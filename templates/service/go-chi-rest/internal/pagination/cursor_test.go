@@ -0,0 +1,80 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	created := time.Date(2026, 3, 1, 12, 30, 0, 123456789, time.FixedZone("CET", 3600))
+	c, err := DecodeCursor(EncodeCursor("order-42", created))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.ID != "order-42" || !c.CreatedAt.Equal(created) {
+		t.Errorf("decoded %+v", c)
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	tests := []struct{ name, token string }{
+		{"not base64", "%%%not-base64%%%"},
+		{"tampered", EncodeCursor("order-42", time.Now())[3:]},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("order-42"))},
+		{"missing id", base64.RawURLEncoding.EncodeToString([]byte(`{"createdAt":"2026-03-01T00:00:00Z"}`))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCursor(tt.token); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("err = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestParsePage(t *testing.T) {
+	tests := []struct {
+		query   string
+		limit   int
+		dir     Direction
+		wantErr bool
+	}{
+		{"", DefaultLimit, Forward, false},
+		{"limit=5&direction=backward", 5, Backward, false},
+		{"limit=1000", MaxLimit, Forward, false},
+		{"limit=0", 0, "", true},
+		{"direction=sideways", 0, "", true},
+		{"cursor=!!", 0, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			p, err := ParsePage(httptest.NewRequest(http.MethodGet, "/items?"+tt.query, nil))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (p.Limit != tt.limit || p.Direction != tt.dir) {
+				t.Errorf("page = %+v", p)
+			}
+		})
+	}
+}
+
+func TestRespondPage(t *testing.T) {
+	rec := httptest.NewRecorder()
+	RespondPage[string](rec, nil, "", "")
+	var got struct {
+		Data       []string `json:"data"`
+		Pagination pageInfo `json:"pagination"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Data == nil || got.Pagination.HasMore {
+		t.Errorf("empty page = %s", rec.Body)
+	}
+}
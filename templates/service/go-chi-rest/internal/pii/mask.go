@@ -0,0 +1,180 @@
+// Package pii masks personal data (emails, phone numbers, ...) before it
+// reaches logs. Field names are matched case-insensitively.
+package pii
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+)
+
+// Masked replaces every masked value
+const Masked = "[MASKED]"
+
+// fieldSet lowercases fields for case-insensitive lookups
+func fieldSet(fields []string) map[string]bool {
+	set := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		set[strings.ToLower(f)] = true
+	}
+	return set
+}
+
+// MaskQuery replaces the values of query parameters named in fields, keeping
+// the order and encoding of everything else: email=a%40b.c&page=2 becomes
+// email=[MASKED]&page=2
+func MaskQuery(rawQuery string, fields []string) string {
+	if rawQuery == "" || len(fields) == 0 {
+		return rawQuery
+	}
+	set := fieldSet(fields)
+	parts := strings.Split(rawQuery, "&")
+	for i, p := range parts {
+		key, _, hasValue := strings.Cut(p, "=")
+		if k, err := url.QueryUnescape(key); err == nil {
+			key = k
+		}
+		if hasValue && set[strings.ToLower(key)] {
+			parts[i] = key + "=" + Masked
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// MaskPathParams replaces the path segments holding route parameters named
+// in fields, e.g. /users/{email} with keys [email]: /users/a@b.c → /users/[MASKED]
+func MaskPathParams(path string, keys, values, fields []string) string {
+	if len(fields) == 0 || len(keys) == 0 {
+		return path
+	}
+	set := fieldSet(fields)
+	masked := make(map[string]bool)
+	for i, k := range keys {
+		if set[strings.ToLower(k)] && i < len(values) && values[i] != "" {
+			masked[values[i]] = true
+		}
+	}
+	if len(masked) == 0 {
+		return path
+	}
+	segs := strings.Split(path, "/")
+	for i, s := range segs {
+		if masked[s] {
+			segs[i] = Masked
+		} else if u, err := url.PathUnescape(s); err == nil && masked[u] {
+			segs[i] = Masked
+		}
+	}
+	return strings.Join(segs, "/")
+}
+
+// MaskJSONFields returns data with the value of every object member named in
+// fields, at any depth, replaced by "[MASKED]"; the value may be any JSON
+// type. It scans tokens instead of decoding, so key order and formatting are
+// kept and allocations stay at one output buffer. Malformed input is copied
+// through on a best-effort basis.
+func MaskJSONFields(data []byte, fields []string) []byte {
+	if len(fields) == 0 || len(data) == 0 {
+		return data
+	}
+	set := fieldSet(fields)
+	var buf bytes.Buffer
+	buf.Grow(len(data))
+
+	var inObject []bool // container stack: true for objects, false for arrays
+	expectKey := false
+	for i := 0; i < len(data); {
+		switch c := data[i]; c {
+		case '{', '[':
+			inObject = append(inObject, c == '{')
+			expectKey = c == '{'
+			buf.WriteByte(c)
+			i++
+		case '}', ']':
+			if len(inObject) > 0 {
+				inObject = inObject[:len(inObject)-1]
+			}
+			expectKey = false
+			buf.WriteByte(c)
+			i++
+		case ',':
+			expectKey = len(inObject) > 0 && inObject[len(inObject)-1]
+			buf.WriteByte(c)
+			i++
+		case '"':
+			end := skipString(data, i)
+			buf.Write(data[i:end])
+			isKey := expectKey
+			expectKey = false
+			var key []byte
+			if end-1 > i {
+				key = data[i+1 : end-1]
+			}
+			i = end
+			if !isKey || !set[strings.ToLower(string(key))] {
+				continue
+			}
+			// copy the separator, then replace the whole value
+			for i < len(data) && (data[i] == ':' || isSpace(data[i])) {
+				buf.WriteByte(data[i])
+				i++
+			}
+			i = skipValue(data, i)
+			buf.WriteString(`"` + Masked + `"`)
+		default:
+			buf.WriteByte(c)
+			i++
+		}
+	}
+	return buf.Bytes()
+}
+
+// skipString returns the index just past the string literal starting at data[i]
+func skipString(data []byte, i int) int {
+	for j := i + 1; j < len(data); j++ {
+		switch data[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1
+		}
+	}
+	return len(data)
+}
+
+// skipValue returns the index just past the JSON value starting at data[i]
+func skipValue(data []byte, i int) int {
+	if i >= len(data) {
+		return i
+	}
+	switch data[i] {
+	case '"':
+		return skipString(data, i)
+	case '{', '[':
+		depth := 0
+		for j := i; j < len(data); j++ {
+			switch data[j] {
+			case '"':
+				j = skipString(data, j) - 1
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return j + 1
+				}
+			}
+		}
+		return len(data)
+	default: // number, true, false, null
+		j := i
+		for j < len(data) && data[j] != ',' && data[j] != '}' && data[j] != ']' && !isSpace(data[j]) {
+			j++
+		}
+		return j
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
@@ -0,0 +1,90 @@
+package pii
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMaskQuery(t *testing.T) {
+	fields := []string{"email", "phone"}
+	tests := []struct{ in, want string }{
+		{"email=alice@example.com", "email=[MASKED]"},
+		{"email=alice%40example.com&page=2", "email=[MASKED]&page=2"},
+		{"EMAIL=a&Phone=1&sort=asc", "EMAIL=[MASKED]&Phone=[MASKED]&sort=asc"},
+		{"e%6Dail=x", "email=[MASKED]"},
+		{"email", "email"},
+		{"page=2", "page=2"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := MaskQuery(tt.in, fields); got != tt.want {
+			t.Errorf("MaskQuery(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if got := MaskQuery("email=a", nil); got != "email=a" {
+		t.Errorf("no fields: %q", got)
+	}
+}
+
+func TestMaskPathParams(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         string
+		keys, values []string
+		want         string
+	}{
+		{"masked", "/users/alice@example.com/orders", []string{"email"}, []string{"alice@example.com"}, "/users/[MASKED]/orders"},
+		{"escaped", "/users/alice%40example.com", []string{"email"}, []string{"alice@example.com"}, "/users/[MASKED]"},
+		{"other param kept", "/orders/42", []string{"id"}, []string{"42"}, "/orders/42"},
+		{"no params", "/users", nil, nil, "/users"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MaskPathParams(tt.path, tt.keys, tt.values, []string{"Email"}); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskJSONFields(t *testing.T) {
+	fields := []string{"email", "phone"}
+	tests := []struct {
+		name, in, want string
+	}{
+		{"nested phone",
+			`{"name":"Alice","contact":{"phone":"+1 555 0100","city":"Berlin"}}`,
+			`{"name":"Alice","contact":{"phone":"[MASKED]","city":"Berlin"}}`},
+		{"in arrays",
+			`[{"email":"a@x.io"},{"email":"b@x.io","id":2}]`,
+			`[{"email":"[MASKED]"},{"email":"[MASKED]","id":2}]`},
+		{"non-string values",
+			`{"phone":5550100,"email":null,"x":true}`,
+			`{"phone":"[MASKED]","email":"[MASKED]","x":true}`},
+		{"object value", `{"phone":{"home":"1","work":"2"},"n":1}`, `{"phone":"[MASKED]","n":1}`},
+		{"whitespace kept", "{\n  \"Email\" : \"a@x.io\",\n  \"n\": 1\n}", "{\n  \"Email\" : \"[MASKED]\",\n  \"n\": 1\n}"},
+		{"values are not keys", `{"note":"email","list":["phone"]}`, `{"note":"email","list":["phone"]}`},
+		{"escaped quotes", `{"bio":"say \"email\"","email":"a\"b"}`, `{"bio":"say \"email\"","email":"[MASKED]"}`},
+		{"not json", `email=a@x.io`, `email=a@x.io`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(MaskJSONFields([]byte(tt.in), fields))
+			if got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+			if json.Valid([]byte(tt.in)) && !json.Valid([]byte(got)) {
+				t.Errorf("masking produced invalid JSON: %s", got)
+			}
+		})
+	}
+}
+
+func BenchmarkMaskJSONFields(b *testing.B) {
+	data := []byte(`{"id":1,"user":{"name":"Alice","email":"a@x.io","phone":"555"},"items":[{"sku":"A","qty":2}]}`)
+	fields := []string{"email", "phone"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		MaskJSONFields(data, fields)
+	}
+}
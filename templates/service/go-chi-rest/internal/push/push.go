@@ -0,0 +1,59 @@
+package push
+
+import (
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// PushResources pushes each resource over HTTP/2 when the connection supports it.
+// A `Link: <url>; rel=preload` header is always added as a fallback for clients
+// and proxies that do not support (or strip) server push. Call before writing the body.
+func PushResources(w http.ResponseWriter, resources []string) {
+	pusher, canPush := findPusher(w)
+	for _, res := range resources {
+		w.Header().Add("Link", "<"+res+">; rel=preload"+asHint(res))
+		if !canPush {
+			continue
+		}
+		if err := pusher.Push(res, nil); err != nil && err != http.ErrNotSupported {
+			zap.L().Debug("http2 push failed", zap.String("resource", res), zap.Error(err))
+		}
+	}
+}
+
+// findPusher walks Unwrap through middleware writers to the connection's
+// http.Pusher; http.ResponseController has no Push
+func findPusher(w http.ResponseWriter) (http.Pusher, bool) {
+	for {
+		if p, ok := w.(http.Pusher); ok {
+			return p, true
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return nil, false
+		}
+		w = u.Unwrap()
+	}
+}
+
+// asHint adds an `as=` attribute for common asset types so browsers can prioritise them
+func asHint(res string) string {
+	path := res
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	switch {
+	case strings.HasSuffix(path, ".css"):
+		return "; as=style"
+	case strings.HasSuffix(path, ".js"), strings.HasSuffix(path, ".mjs"):
+		return "; as=script"
+	case strings.HasSuffix(path, ".woff2"), strings.HasSuffix(path, ".woff"):
+		return "; as=font; crossorigin"
+	case strings.HasSuffix(path, ".png"), strings.HasSuffix(path, ".jpg"),
+		strings.HasSuffix(path, ".svg"), strings.HasSuffix(path, ".webp"):
+		return "; as=image"
+	}
+	return ""
+}
@@ -0,0 +1,56 @@
+package push
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// pushRecorder is an HTTP/2 connection's writer
+type pushRecorder struct {
+	*httptest.ResponseRecorder
+	pushed []string
+}
+
+func (p *pushRecorder) Push(target string, _ *http.PushOptions) error {
+	p.pushed = append(p.pushed, target)
+	return nil
+}
+
+// middlewareWriter hides Push the way logging or metrics wrappers do
+type middlewareWriter struct{ http.ResponseWriter }
+
+func (m middlewareWriter) Unwrap() http.ResponseWriter { return m.ResponseWriter }
+
+func TestPushResources(t *testing.T) {
+	resources := []string{"/app.css", "/app.js?v=2", "/logo.svg"}
+	tests := []struct {
+		name     string
+		wrap     func(*pushRecorder) http.ResponseWriter
+		wantPush int
+	}{
+		{"direct pusher", func(p *pushRecorder) http.ResponseWriter { return p }, 3},
+		{"behind two wrappers", func(p *pushRecorder) http.ResponseWriter { return middlewareWriter{middlewareWriter{p}} }, 3},
+		{"no pusher", func(p *pushRecorder) http.ResponseWriter { return p.ResponseRecorder }, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &pushRecorder{ResponseRecorder: httptest.NewRecorder()}
+			w := tt.wrap(p)
+			PushResources(w, resources)
+			if len(p.pushed) != tt.wantPush {
+				t.Errorf("pushed %v, want %d resources", p.pushed, tt.wantPush)
+			}
+			links := w.Header().Values("Link")
+			want := []string{"</app.css>; rel=preload; as=style", "</app.js?v=2>; rel=preload; as=script", "</logo.svg>; rel=preload; as=image"}
+			if len(links) != len(want) {
+				t.Fatalf("Link = %q", links)
+			}
+			for i := range want {
+				if links[i] != want[i] {
+					t.Errorf("Link[%d] = %q, want %q", i, links[i], want[i])
+				}
+			}
+		})
+	}
+}
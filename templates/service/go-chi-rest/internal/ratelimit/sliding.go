@@ -0,0 +1,291 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/example/go-chi-rest/internal/authn"
+	"github.com/example/go-chi-rest/internal/errcodes"
+	"github.com/example/go-chi-rest/internal/reqctx"
+)
+
+// slidingScript trims entries older than the window, admits the request if the
+// window still has room and returns {admitted, count, oldest score}.
+// KEYS[1] window key; ARGV: now (ns), window (ns), limit, member, ttl (ms)
+var slidingScript = redis.NewScript(`
+local now, window, limit = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+local count = redis.call("ZCARD", KEYS[1])
+local admitted = 0
+if count < limit then
+	redis.call("ZADD", KEYS[1], now, ARGV[4])
+	count = count + 1
+	admitted = 1
+end
+redis.call("PEXPIRE", KEYS[1], ARGV[5])
+local oldest = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", "+inf", "WITHSCORES", "LIMIT", 0, 1)
+return {admitted, count, oldest[2] or tostring(now)}`)
+
+// RateLimitRule limits requests matching Path (a chi pattern such as
+// /api/v1/orders/{id}) and Methods (empty matches all) to Limit per Window,
+// counted separately for each identity selected by UserKey
+type RateLimitRule struct {
+	Name    string        `mapstructure:"name"` // reported in X-RateLimit-Policy; defaults to Path
+	Path    string        `mapstructure:"path"`
+	Methods []string      `mapstructure:"methods"`
+	UserKey string        `mapstructure:"user_key"` // user | tenant | ip | header:<Name>; default user. Anonymous requests are always counted by ip
+	Limit   int           `mapstructure:"limit"`
+	Window  time.Duration `mapstructure:"window"`
+
+	re *regexp.Regexp
+}
+
+// RateLimitConfig configures the sliding window limiter
+type RateLimitConfig struct {
+	Enabled   bool            `mapstructure:"enabled"`
+	RedisAddr string          `mapstructure:"redis_addr"`
+	KeyPrefix string          `mapstructure:"key_prefix"` // default "ratelimit:"
+	Rules     []RateLimitRule `mapstructure:"rules"`
+}
+
+// KeyFunc returns the counter key for a request matched by rule
+type KeyFunc func(r *http.Request, rule RateLimitRule) string
+
+// SlidingWindowRateLimiter counts requests in Redis sorted sets, one member per
+// request scored by its nanosecond timestamp, so the window slides smoothly
+// instead of resetting at fixed boundaries
+type SlidingWindowRateLimiter struct {
+	client redis.UniversalClient
+	prefix string
+	rules  atomic.Pointer[ruleSet]
+
+	// KeyFunc defaults to "user:<id>:path:<rule path>"
+	KeyFunc KeyFunc
+	// Now is the clock; tests replace it
+	Now func() time.Time
+
+	seq atomic.Uint64
+}
+
+// NewSlidingWindowRateLimiter compiles the rule patterns; rules are evaluated
+// in order and the first match wins
+func NewSlidingWindowRateLimiter(client redis.UniversalClient, cfg RateLimitConfig) (*SlidingWindowRateLimiter, error) {
+	rules, err := compileRules(cfg.Rules)
+	if err != nil {
+		return nil, err
+	}
+	prefix := cfg.KeyPrefix
+	if prefix == "" {
+		prefix = "ratelimit:"
+	}
+	l := &SlidingWindowRateLimiter{
+		client:  client,
+		prefix:  prefix,
+		KeyFunc: DefaultKeyFunc,
+		Now:     time.Now,
+	}
+	l.rules.Store(rules)
+	return l, nil
+}
+
+// ruleSet keeps the configured rules next to their compiled form, so a
+// reloaded config is only recompiled when the rules actually changed
+type ruleSet struct {
+	source []RateLimitRule
+	rules  []RateLimitRule
+}
+
+func compileRules(source []RateLimitRule) (*ruleSet, error) {
+	rules := make([]RateLimitRule, len(source))
+	for i, rule := range source {
+		if rule.Limit <= 0 || rule.Window <= 0 {
+			return nil, fmt.Errorf("rate limit rule %q: limit and window must be positive", rule.Path)
+		}
+		re, err := compilePattern(rule.Path)
+		if err != nil {
+			return nil, fmt.Errorf("rate limit rule %q: %w", rule.Path, err)
+		}
+		rule.re = re
+		if rule.Name == "" {
+			rule.Name = rule.Path
+		}
+		rules[i] = rule
+	}
+	return &ruleSet{source: source, rules: rules}, nil
+}
+
+// ReloadableMiddleware is Middleware with the rules and the enabled switch
+// read through load on every request. Invalid rules are logged and the
+// previous ones stay in force. The Redis address and key prefix are fixed at
+// construction.
+func (l *SlidingWindowRateLimiter) ReloadableMiddleware(load func() RateLimitConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		limited := l.Middleware(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cfg := load()
+			if !cfg.Enabled {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if current := l.rules.Load(); !reflect.DeepEqual(current.source, cfg.Rules) {
+				rules, err := compileRules(cfg.Rules)
+				if err != nil {
+					reqctx.LoggerFromContext(r.Context()).Error("reloaded rate limit rules rejected", zap.Error(err))
+					// remember the bad source so it is not recompiled per request
+					rules = &ruleSet{source: cfg.Rules, rules: current.rules}
+				}
+				l.rules.CompareAndSwap(current, rules)
+			}
+			limited.ServeHTTP(w, r)
+		})
+	}
+}
+
+// DefaultKeyFunc builds compound keys such as user:42:path:/api/v1/orders, so each
+// identity gets its own budget per rule rather than per concrete URL
+func DefaultKeyFunc(r *http.Request, rule RateLimitRule) string {
+	return identity(r, rule.UserKey) + ":path:" + rule.Path
+}
+
+// identity keys on the principal verified by authn. X-Tenant-ID and other
+// headers are chosen by the client, so they only select the budget of
+// authenticated requests; anonymous ones, which could send a fresh value each
+// time, are counted by client IP. That is the socket peer, or the address
+// reported by a trusted proxy (see security.NewRealIPMiddleware).
+func identity(r *http.Request, userKey string) string {
+	if sub := authn.Subject(r.Context()); sub != "" {
+		switch {
+		case userKey == "" || userKey == "user":
+			return "user:" + sub
+		case userKey == "tenant":
+			if t := reqctx.FromContext(r.Context()).TenantID; t != "" {
+				return "tenant:" + t
+			}
+			return "user:" + sub
+		case strings.HasPrefix(userKey, "header:"):
+			if v := r.Header.Get(strings.TrimPrefix(userKey, "header:")); v != "" {
+				return "key:" + v
+			}
+			return "user:" + sub
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// Allow records a request against key and reports whether it fits the rule,
+// plus the remaining budget and how long until the oldest entry leaves the window
+func (l *SlidingWindowRateLimiter) Allow(ctx context.Context, key string, rule RateLimitRule) (bool, int, time.Duration, error) {
+	now := l.Now().UnixNano()
+	member := strconv.FormatInt(now, 10) + "-" + strconv.FormatUint(l.seq.Add(1), 10)
+	res, err := slidingScript.Run(ctx, l.client, []string{l.prefix + key},
+		now, rule.Window.Nanoseconds(), rule.Limit, member, rule.Window.Milliseconds()+1000).Slice()
+	if err != nil {
+		return true, 0, 0, err
+	}
+	admitted, _ := res[0].(int64)
+	count, _ := res[1].(int64)
+	oldestStr, _ := res[2].(string)
+	oldest, _ := strconv.ParseFloat(oldestStr, 64)
+	retry := time.Duration(int64(oldest) + rule.Window.Nanoseconds() - now)
+	return admitted == 1, rule.Limit - int(count), retry, nil
+}
+
+func (l *SlidingWindowRateLimiter) match(r *http.Request) (RateLimitRule, bool) {
+	for _, rule := range l.rules.Load().rules {
+		if len(rule.Methods) > 0 && !containsFold(rule.Methods, r.Method) {
+			continue
+		}
+		if rule.re.MatchString(r.URL.Path) {
+			return rule, true
+		}
+	}
+	return RateLimitRule{}, false
+}
+
+// Middleware enforces the first matching rule, answering 429 RATE_LIMITED with
+// Retry-After once the window is full. Redis errors fail open.
+func (l *SlidingWindowRateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rule, ok := l.match(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		allowed, remaining, retry, err := l.Allow(r.Context(), l.KeyFunc(r, rule), rule)
+		if err != nil {
+			reqctx.LoggerFromContext(r.Context()).Warn("rate limiter unavailable, allowing request", zap.String("rule", rule.Name), zap.Error(err))
+			next.ServeHTTP(w, r)
+			return
+		}
+		if remaining < 0 {
+			remaining = 0
+		}
+		w.Header().Set("X-RateLimit-Policy", rule.Name)
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(rule.Limit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		if !allowed {
+			secs := int((retry + time.Second - 1) / time.Second)
+			if secs < 1 {
+				secs = 1
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(secs))
+			errcodes.Write(w, errcodes.FromRequest(r).New("RATE_LIMITED", secs))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// chiParam matches {name} and {name:regexp} segments of a chi pattern
+var chiParam = regexp.MustCompile(`\{([^{}:]+)(?::([^{}]+))?\}`)
+
+// compilePattern turns a chi route pattern into an anchored regexp: {id} matches
+// one path segment, {id:[0-9]+} its regexp, and a trailing * the rest of the path
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+	last := 0
+	for _, m := range chiParam.FindAllStringSubmatchIndex(pattern, -1) {
+		sb.WriteString(literal(pattern[last:m[0]]))
+		if m[4] >= 0 {
+			sb.WriteString("(?:" + pattern[m[4]:m[5]] + ")")
+		} else {
+			sb.WriteString("[^/]+")
+		}
+		last = m[1]
+	}
+	sb.WriteString(literal(pattern[last:]))
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+func literal(s string) string {
+	if strings.HasSuffix(s, "*") {
+		return regexp.QuoteMeta(strings.TrimSuffix(s, "*")) + ".*"
+	}
+	return regexp.QuoteMeta(s)
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
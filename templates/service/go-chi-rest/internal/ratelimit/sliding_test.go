@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/example/go-chi-rest/internal/authn"
+	"github.com/example/go-chi-rest/internal/reqctx"
+)
+
+func newTestLimiter(t *testing.T, rules ...RateLimitRule) (*SlidingWindowRateLimiter, *time.Time) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	l, err := NewSlidingWindowRateLimiter(redis.NewClient(&redis.Options{Addr: mr.Addr()}), RateLimitConfig{Rules: rules})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+	l.Now = func() time.Time { return now }
+	return l, &now
+}
+
+func request(h http.Handler, path, remoteAddr, userID string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = remoteAddr
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if userID != "" {
+		req = req.WithContext(authn.WithPrincipal(req.Context(), authn.Principal{Subject: userID}))
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestSlidingWindowRejectsEleventhRequest(t *testing.T) {
+	l, now := newTestLimiter(t, RateLimitRule{Name: "orders", Path: "/api/v1/orders/{id}", Limit: 10, Window: time.Minute})
+	h := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for i := 1; i <= 10; i++ {
+		rec := request(h, "/api/v1/orders/"+string(rune('a'+i)), "192.0.2.1:4000", "user-1", nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want 200", i, rec.Code)
+		}
+		if got := rec.Header().Get("X-RateLimit-Policy"); got != "orders" {
+			t.Errorf("X-RateLimit-Policy = %q, want orders", got)
+		}
+		*now = now.Add(time.Second)
+	}
+	rec := request(h, "/api/v1/orders/z", "192.0.2.1:4000", "user-1", nil)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("11th request: status = %d, want 429", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("missing Retry-After")
+	}
+	if rec := request(h, "/api/v1/orders/z", "192.0.2.1:4000", "user-2", nil); rec.Code != http.StatusOK {
+		t.Errorf("other user: status = %d, want 200", rec.Code)
+	}
+
+	// the first request leaves the window 60s after it was made
+	*now = now.Add(50 * time.Second)
+	if rec := request(h, "/api/v1/orders/z", "192.0.2.1:4000", "user-1", nil); rec.Code != http.StatusOK {
+		t.Errorf("after the window slid: status = %d, want 200", rec.Code)
+	}
+}
+
+func TestIdentity(t *testing.T) {
+	tests := []struct {
+		name    string
+		userKey string
+		userID  string
+		headers map[string]string
+		want    string
+	}{
+		{"principal", "user", "user-1", nil, "user:user-1"},
+		{"default is the principal", "", "user-1", nil, "user:user-1"},
+		{"x-user-id is not trusted", "user", "", map[string]string{"X-User-ID": "user-1"}, "ip:192.0.2.1"},
+		{"tenant of an authenticated caller", "tenant", "user-1", map[string]string{"X-Tenant-ID": "acme"}, "tenant:acme"},
+		{"tenant without a principal", "tenant", "", map[string]string{"X-Tenant-ID": "acme"}, "ip:192.0.2.1"},
+		{"header of an authenticated caller", "header:X-API-Key", "user-1", map[string]string{"X-API-Key": "k1"}, "key:k1"},
+		{"header without a principal", "header:X-API-Key", "", map[string]string{"X-API-Key": "k1"}, "ip:192.0.2.1"},
+		{"ip", "ip", "user-1", nil, "ip:192.0.2.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { got = identity(r, tt.userKey) })
+			request(reqctx.NewContextEnrichmentMiddleware()(h), "/", "192.0.2.1:4000", tt.userID, tt.headers)
+			if got != tt.want {
+				t.Errorf("identity = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
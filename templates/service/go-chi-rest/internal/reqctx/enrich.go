@@ -0,0 +1,118 @@
+package reqctx
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Correlation headers read from incoming requests
+const (
+	RequestIDHeader     = "X-Request-ID"
+	CorrelationIDHeader = "X-Correlation-ID"
+	TenantIDHeader      = "X-Tenant-ID"
+	UserIDHeader        = "X-User-ID"
+)
+
+// maxIDLength bounds header values copied into logs
+const maxIDLength = 128
+
+// Correlation holds the identifiers attached to a request
+type Correlation struct {
+	RequestID     string
+	CorrelationID string
+	TenantID      string
+	UserID        string // X-User-ID as sent by the client: unverified, for log correlation only; see authn.Subject
+}
+
+type ctxKey int
+
+const (
+	correlationKey ctxKey = iota
+	loggerKey
+)
+
+// NewContextEnrichmentMiddleware reads the correlation headers, generating a
+// UUID request ID when none is sent and defaulting the correlation ID to it,
+// and stores them plus a child zap logger carrying them in the request context.
+// X-Request-ID and X-Correlation-ID are echoed on the response, except when
+// NewRequestIDMiddleware already assigned and echoed the request ID.
+func NewContextEnrichmentMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c := Correlation{
+				RequestID:     headerID(r, RequestIDHeader),
+				CorrelationID: headerID(r, CorrelationIDHeader),
+				TenantID:      headerID(r, TenantIDHeader),
+				UserID:        headerID(r, UserIDHeader),
+			}
+			// an ID assigned by NewRequestIDMiddleware wins over the raw header
+			assigned, hasAssigned := r.Context().Value(requestIDKey{}).(string)
+			if hasAssigned {
+				c.RequestID = assigned
+			}
+			if c.RequestID == "" {
+				c.RequestID = uuid.NewString()
+			}
+			if c.CorrelationID == "" {
+				c.CorrelationID = c.RequestID
+			}
+
+			fields := []zap.Field{
+				zap.String("request_id", c.RequestID),
+				zap.String("correlation_id", c.CorrelationID),
+			}
+			if c.TenantID != "" {
+				fields = append(fields, zap.String("tenant_id", c.TenantID))
+			}
+			if c.UserID != "" {
+				// unverified, so kept apart from the access log's user_id
+				fields = append(fields, zap.String("client_user_id", c.UserID))
+			}
+
+			ctx := context.WithValue(r.Context(), correlationKey, c)
+			ctx = context.WithValue(ctx, loggerKey, zap.L().With(fields...))
+			// keep chi's middleware.GetReqID in agreement
+			ctx = context.WithValue(ctx, middleware.RequestIDKey, c.RequestID)
+
+			if !hasAssigned {
+				w.Header().Set(RequestIDHeader, c.RequestID)
+			}
+			w.Header().Set(CorrelationIDHeader, c.CorrelationID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// FromContext returns the request's correlation IDs (zero value outside the middleware)
+func FromContext(ctx context.Context) Correlation {
+	c, _ := ctx.Value(correlationKey).(Correlation)
+	return c
+}
+
+// LoggerFromContext returns the request-scoped logger with correlation fields
+// attached, or the global logger outside the middleware
+func LoggerFromContext(ctx context.Context) *zap.Logger {
+	if l, ok := ctx.Value(loggerKey).(*zap.Logger); ok {
+		return l
+	}
+	return zap.L()
+}
+
+// headerID returns the header value if it is a plausible identifier; anything
+// overlong or containing control characters is dropped rather than logged
+func headerID(r *http.Request, name string) string {
+	v := r.Header.Get(name)
+	if len(v) > maxIDLength {
+		return ""
+	}
+	for i := 0; i < len(v); i++ {
+		if v[i] < 0x21 || v[i] > 0x7e {
+			return ""
+		}
+	}
+	return v
+}
@@ -0,0 +1,102 @@
+package reqctx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// enrich runs the middleware and returns what the handler saw plus the response
+func enrich(t *testing.T, h func(http.Handler) http.Handler, headers map[string]string) (Correlation, *httptest.ResponseRecorder) {
+	t.Helper()
+	var got Correlation
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = FromContext(r.Context())
+		if id := middleware.GetReqID(r.Context()); id != got.RequestID {
+			t.Errorf("chi request ID = %q, want %q", id, got.RequestID)
+		}
+		LoggerFromContext(r.Context()).Info("handled")
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h(next).ServeHTTP(rec, req)
+	return got, rec
+}
+
+func TestContextEnrichment(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    Correlation // RequestID "uuid" means a generated UUID
+	}{
+		{"all headers propagated", map[string]string{RequestIDHeader: "req-1", CorrelationIDHeader: "corr-1", TenantIDHeader: "acme", UserIDHeader: "alice"},
+			Correlation{RequestID: "req-1", CorrelationID: "corr-1", TenantID: "acme", UserID: "alice"}},
+		{"correlation defaults to request ID", map[string]string{RequestIDHeader: "req-2"},
+			Correlation{RequestID: "req-2", CorrelationID: "req-2"}},
+		{"missing request ID generates a UUID", nil,
+			Correlation{RequestID: "uuid"}},
+		{"control characters dropped", map[string]string{RequestIDHeader: "bad\x01id", TenantIDHeader: "a b"},
+			Correlation{RequestID: "uuid"}},
+		{"overlong value dropped", map[string]string{CorrelationIDHeader: strings.Repeat("x", maxIDLength+1), RequestIDHeader: "req-3"},
+			Correlation{RequestID: "req-3", CorrelationID: "req-3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rec := enrich(t, NewContextEnrichmentMiddleware(), tt.headers)
+			want := tt.want
+			if want.RequestID == "uuid" {
+				if _, err := uuid.Parse(got.RequestID); err != nil {
+					t.Fatalf("request ID %q is not a UUID", got.RequestID)
+				}
+				want.RequestID, want.CorrelationID = got.RequestID, got.RequestID
+			}
+			if got != want {
+				t.Errorf("correlation = %+v, want %+v", got, want)
+			}
+			if rec.Header().Get(RequestIDHeader) != want.RequestID || rec.Header().Get(CorrelationIDHeader) != want.CorrelationID {
+				t.Errorf("response headers = %v", rec.Header())
+			}
+		})
+	}
+}
+
+func TestContextEnrichmentUsesAssignedID(t *testing.T) {
+	chain := func(next http.Handler) http.Handler {
+		return NewRequestIDMiddleware(RequestIDConfig{Format: FormatPrefix, Prefix: "req-"})(NewContextEnrichmentMiddleware()(next))
+	}
+	got, rec := enrich(t, chain, map[string]string{RequestIDHeader: "client-id"})
+	if !strings.HasPrefix(got.RequestID, "req-") || got.CorrelationID != got.RequestID {
+		t.Errorf("correlation = %+v, want the assigned req- ID", got)
+	}
+	if h := rec.Header().Values(RequestIDHeader); len(h) != 1 || h[0] != got.RequestID {
+		t.Errorf("X-Request-ID = %v, want only the assigned ID", h)
+	}
+}
+
+func TestLoggerFromContext(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	defer zap.ReplaceGlobals(zap.New(core))()
+
+	enrich(t, NewContextEnrichmentMiddleware(), map[string]string{RequestIDHeader: "req-9", TenantIDHeader: "acme"})
+	entries := logs.FilterMessage("handled").All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["request_id"] != "req-9" || fields["correlation_id"] != "req-9" || fields["tenant_id"] != "acme" {
+		t.Errorf("fields = %v", fields)
+	}
+	if _, ok := fields["client_user_id"]; ok {
+		t.Error("empty client_user_id logged")
+	}
+}
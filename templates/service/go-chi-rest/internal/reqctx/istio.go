@@ -0,0 +1,58 @@
+package reqctx
+
+import (
+	"context"
+	"net/http"
+)
+
+// IstioHeaders are the headers Envoy needs forwarded from an incoming request
+// to the outbound calls it causes, so the mesh can join them into one trace
+var IstioHeaders = []string{
+	"x-request-id",
+	"x-b3-traceid",
+	"x-b3-spanid",
+	"x-b3-parentspanid",
+	"x-b3-sampled",
+	"x-b3-flags",
+	"x-ot-span-context",
+	"traceparent",
+	"tracestate",
+}
+
+type istioKey struct{}
+
+// NewIstioHeaderPropagator stores the IstioHeaders present on the incoming
+// request in its context for InjectIstioHeaders. Values are kept verbatim:
+// Envoy matches them exactly.
+func NewIstioHeaderPropagator() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var h http.Header
+			for _, name := range IstioHeaders {
+				if v := r.Header.Get(name); v != "" {
+					if h == nil {
+						h = http.Header{}
+					}
+					h.Set(name, v)
+				}
+			}
+			if h == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), istioKey{}, h)))
+		})
+	}
+}
+
+// InjectIstioHeaders copies the headers stored by NewIstioHeaderPropagator
+// onto an outbound request. Headers the caller already set are left alone.
+// Clients from httpclient.NewInstrumentedClient call it for every request.
+func InjectIstioHeaders(ctx context.Context, req *http.Request) {
+	h, _ := ctx.Value(istioKey{}).(http.Header)
+	for name, vs := range h {
+		if req.Header.Get(name) == "" {
+			req.Header[name] = vs
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package reqctx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIstioHeaderPropagation(t *testing.T) {
+	var outbound http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		outbound = r.Header.Clone()
+	}))
+	defer upstream.Close()
+
+	h := NewIstioHeaderPropagator()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, upstream.URL, nil)
+		req.Header.Set("tracestate", "set-by-handler")
+		InjectIstioHeaders(r.Context(), req)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		resp.Body.Close()
+	}))
+
+	in := httptest.NewRequest(http.MethodGet, "/api/v1/ping", nil)
+	for _, name := range IstioHeaders {
+		in.Header.Set(name, "in-"+name)
+	}
+	in.Header.Set("Authorization", "Bearer secret")
+	h.ServeHTTP(httptest.NewRecorder(), in)
+
+	for _, name := range IstioHeaders {
+		want := "in-" + name
+		if name == "tracestate" {
+			want = "set-by-handler"
+		}
+		if got := outbound.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if outbound.Get("Authorization") != "" {
+		t.Error("non-mesh header was propagated")
+	}
+}
+
+func TestInjectIstioHeadersWithoutIncoming(t *testing.T) {
+	var injected http.Header
+	h := NewIstioHeaderPropagator()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, _ := http.NewRequest(http.MethodGet, "http://upstream", nil)
+		InjectIstioHeaders(r.Context(), req)
+		injected = req.Header
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if len(injected) != 0 {
+		t.Errorf("headers = %v, want none", injected)
+	}
+}
@@ -0,0 +1,76 @@
+package reqctx
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/oklog/ulid/v2"
+)
+
+// Request ID formats
+const (
+	FormatUUID   = "uuid"   // random UUID v4
+	FormatULID   = "ulid"   // lexicographically sortable by creation time
+	FormatPrefix = "prefix" // Prefix followed by a ULID, e.g. req-01J9Z3...
+)
+
+// RequestIDConfig controls how request IDs are generated and propagated
+type RequestIDConfig struct {
+	Format        string `mapstructure:"format"` // uuid|ulid|prefix
+	Prefix        string `mapstructure:"prefix"`
+	HeaderName    string `mapstructure:"header_name"`    // default X-Request-ID
+	TrustIncoming bool   `mapstructure:"trust_incoming"` // reuse the caller's ID, e.g. from an edge proxy
+}
+
+type requestIDKey struct{}
+
+// NewRequestIDMiddleware assigns every request an ID in the configured format
+// and reflects it in the HeaderName response header. With TrustIncoming, an ID
+// sent in HeaderName is reused after sanitization (at most 128 alphanumeric or
+// hyphen characters); one that sanitizes to nothing is replaced.
+// Mount it before NewContextEnrichmentMiddleware, which picks the ID up.
+func NewRequestIDMiddleware(cfg RequestIDConfig) func(http.Handler) http.Handler {
+	header := cfg.HeaderName
+	if header == "" {
+		header = RequestIDHeader
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var id string
+			if cfg.TrustIncoming {
+				id = sanitizeID(r.Header.Get(header))
+			}
+			if id == "" {
+				id = NewRequestID(cfg)
+			}
+			w.Header().Set(header, id)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+		})
+	}
+}
+
+// NewRequestID generates an ID in cfg.Format; unknown formats fall back to UUID v4
+func NewRequestID(cfg RequestIDConfig) string {
+	switch cfg.Format {
+	case FormatULID:
+		// ulid.Make is monotonic within a millisecond, so IDs sort in creation order
+		return ulid.Make().String()
+	case FormatPrefix:
+		return cfg.Prefix + ulid.Make().String()
+	default:
+		return uuid.NewString()
+	}
+}
+
+// sanitizeID keeps ASCII letters, digits and hyphens, truncated to maxIDLength
+func sanitizeID(v string) string {
+	out := make([]byte, 0, len(v))
+	for i := 0; i < len(v) && len(out) < maxIDLength; i++ {
+		c := v[i]
+		if c == '-' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') {
+			out = append(out, c)
+		}
+	}
+	return string(out)
+}
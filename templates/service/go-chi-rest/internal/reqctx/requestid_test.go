@@ -0,0 +1,94 @@
+package reqctx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+)
+
+var uuidV4 = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestNewRequestID(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   RequestIDConfig
+		valid func(id string) bool
+	}{
+		{"uuid", RequestIDConfig{Format: FormatUUID}, uuidV4.MatchString},
+		{"unknown falls back to uuid", RequestIDConfig{Format: "snowflake"}, uuidV4.MatchString},
+		{"ulid", RequestIDConfig{Format: FormatULID}, func(id string) bool {
+			_, err := ulid.ParseStrict(id)
+			return err == nil
+		}},
+		{"prefix", RequestIDConfig{Format: FormatPrefix, Prefix: "req-"}, func(id string) bool {
+			_, err := ulid.ParseStrict(strings.TrimPrefix(id, "req-"))
+			return strings.HasPrefix(id, "req-") && err == nil
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if id := NewRequestID(tt.cfg); !tt.valid(id) {
+				t.Errorf("NewRequestID = %q", id)
+			}
+		})
+	}
+}
+
+func TestULIDsSortInCreationOrder(t *testing.T) {
+	ids := make([]string, 1000)
+	for i := range ids {
+		ids[i] = NewRequestID(RequestIDConfig{Format: FormatULID})
+	}
+	if !sort.StringsAreSorted(ids) {
+		t.Error("ULIDs generated in sequence are not lexicographically ordered")
+	}
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	long := strings.Repeat("a", 200)
+	tests := []struct {
+		name     string
+		cfg      RequestIDConfig
+		incoming string
+		want     string // empty: a freshly generated UUID
+	}{
+		{"trusted incoming echoed", RequestIDConfig{TrustIncoming: true}, "edge-1234", "edge-1234"},
+		{"untrusted incoming replaced", RequestIDConfig{}, "edge-1234", ""},
+		{"incoming sanitized", RequestIDConfig{TrustIncoming: true}, "ab c;<d>-1", "abcd-1"},
+		{"incoming truncated", RequestIDConfig{TrustIncoming: true}, long, long[:maxIDLength]},
+		{"unusable incoming replaced", RequestIDConfig{TrustIncoming: true}, "<>;", ""},
+		{"custom header", RequestIDConfig{TrustIncoming: true, HeaderName: "X-Trace-Id"}, "t-1", "t-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := tt.cfg.HeaderName
+			if header == "" {
+				header = RequestIDHeader
+			}
+			var seen string
+			h := NewRequestIDMiddleware(tt.cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen, _ = r.Context().Value(requestIDKey{}).(string)
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(header, tt.incoming)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			got := rec.Header().Get(header)
+			if got != seen {
+				t.Errorf("response ID %q differs from context ID %q", got, seen)
+			}
+			if tt.want == "" && !uuidV4.MatchString(got) {
+				t.Errorf("ID = %q, want a generated UUID", got)
+			}
+			if tt.want != "" && got != tt.want {
+				t.Errorf("ID = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+// Package scheduler runs cron-scheduled jobs alongside the HTTP server
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+var (
+	jobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "scheduler_job_duration_seconds",
+		Help:    "Duration of scheduled job runs.",
+		Buckets: []float64{.01, .1, .5, 1, 5, 15, 60, 300, 900},
+	}, []string{"name"})
+	jobErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "scheduler_job_errors_total",
+		Help: "Scheduled job runs that returned an error or panicked.",
+	}, []string{"name"})
+)
+
+// parser accepts standard 5-field expressions, an optional leading seconds
+// field and descriptors such as @hourly or @every 30s
+var parser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// SchedulerConfig configures the scheduler from viper
+type SchedulerConfig struct {
+	Timezone string `mapstructure:"timezone"` // IANA name the schedules are read in; default UTC
+}
+
+// Job is a function run on a cron schedule
+type Job struct {
+	Name     string
+	Schedule string // e.g. "*/5 * * * *", "0 30 2 * * *" or "@every 1m"
+	Fn       func(ctx context.Context) error
+}
+
+// Scheduler runs jobs on their schedules. A run that is due while the
+// previous run of the same job is still going is skipped.
+type Scheduler struct {
+	// RequireLeader makes jobs run only while Lead is active, i.e. on the
+	// election leader; set it when leader election is enabled
+	RequireLeader bool
+
+	cron *cron.Cron
+
+	mu           sync.Mutex
+	ctx          context.Context // nil until Start
+	cancel       context.CancelFunc
+	leaderCtx    context.Context // nil unless Lead is running
+	leaderCancel context.CancelFunc
+}
+
+// NewScheduler parses every job's schedule and returns a scheduler that has
+// not been started yet
+func NewScheduler(cfg SchedulerConfig, jobs []Job) (*Scheduler, error) {
+	loc := time.UTC
+	if cfg.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(cfg.Timezone); err != nil {
+			return nil, fmt.Errorf("scheduler: timezone: %w", err)
+		}
+	}
+	s := &Scheduler{cron: cron.New(cron.WithParser(parser), cron.WithLocation(loc))}
+	seen := map[string]bool{}
+	for _, job := range jobs {
+		if job.Name == "" || job.Fn == nil {
+			return nil, fmt.Errorf("scheduler: job %q needs a name and a function", job.Name)
+		}
+		if seen[job.Name] {
+			return nil, fmt.Errorf("scheduler: duplicate job %q", job.Name)
+		}
+		seen[job.Name] = true
+		if _, err := s.cron.AddJob(job.Schedule, s.wrap(job)); err != nil {
+			return nil, fmt.Errorf("scheduler: job %q: schedule %q: %w", job.Name, job.Schedule, err)
+		}
+	}
+	return s, nil
+}
+
+// Start begins running jobs. Job contexts derive from ctx and, when
+// RequireLeader is set, are also cancelled when leadership is lost.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	s.mu.Unlock()
+	s.cron.Start()
+}
+
+// Stop stops scheduling, cancels running jobs and the current Lead, and
+// waits for the jobs to return or for ctx to be done
+func (s *Scheduler) Stop(ctx context.Context) error {
+	done := s.cron.Stop()
+	s.mu.Lock()
+	if s.cancel != nil {
+		s.cancel()
+	}
+	if s.leaderCancel != nil {
+		s.leaderCancel()
+	}
+	s.mu.Unlock()
+	select {
+	case <-done.Done():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Lead marks this node as leader until ctx is cancelled; it fits the
+// onLeader callback of election.Election.Run. Jobs started while leading
+// get a context that is cancelled when leadership is lost. Stop ends it early.
+func (s *Scheduler) Lead(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	s.mu.Lock()
+	s.leaderCtx, s.leaderCancel = ctx, cancel
+	s.mu.Unlock()
+	<-ctx.Done()
+	s.mu.Lock()
+	if s.leaderCtx == ctx {
+		s.leaderCtx, s.leaderCancel = nil, nil
+	}
+	s.mu.Unlock()
+}
+
+// runContext returns the context for a run, derived from the Start context
+// and, with RequireLeader, cancelled when leadership is lost. It returns nil
+// when the run has to wait for leadership.
+func (s *Scheduler) runContext() (context.Context, context.CancelFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx == nil || (s.RequireLeader && s.leaderCtx == nil) {
+		return nil, nil
+	}
+	ctx, cancel := context.WithCancel(s.ctx)
+	if s.RequireLeader {
+		leader := s.leaderCtx
+		go func() {
+			select {
+			case <-leader.Done():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+	return ctx, cancel
+}
+
+// wrap adds the overlap guard, logging and metrics to a job
+func (s *Scheduler) wrap(job Job) cron.Job {
+	var running sync.Mutex
+	return cron.FuncJob(func() {
+		log := zap.L().With(zap.String("job", job.Name))
+		if !running.TryLock() {
+			log.Warn("scheduled job skipped: previous run still in progress")
+			return
+		}
+		defer running.Unlock()
+		ctx, cancel := s.runContext()
+		if ctx == nil {
+			log.Debug("scheduled job skipped: not the leader")
+			return
+		}
+		defer cancel()
+
+		log.Info("scheduled job started")
+		start := time.Now()
+		err := runSafely(ctx, job.Fn)
+		elapsed := time.Since(start)
+		jobDuration.WithLabelValues(job.Name).Observe(elapsed.Seconds())
+		if err != nil {
+			jobErrors.WithLabelValues(job.Name).Inc()
+			log.Error("scheduled job failed", zap.Duration("duration", elapsed), zap.Error(err))
+			return
+		}
+		log.Info("scheduled job finished", zap.Duration("duration", elapsed))
+	})
+}
+
+// runSafely turns a panicking job into an error
+func runSafely(ctx context.Context, fn func(context.Context) error) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("panic: %v", rec)
+		}
+	}()
+	return fn(ctx)
+}
@@ -0,0 +1,170 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// blockingJob records whether it ran and blocks until its context ends
+func blockingJob(started chan<- struct{}) Job {
+	return Job{Name: "sync", Schedule: "@hourly", Fn: func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+}
+
+// runAsync runs one scheduled run of job and returns a channel closed when
+// the run returns
+func runAsync(s *Scheduler, job Job) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		s.wrap(job).Run()
+		close(done)
+	}()
+	return done
+}
+
+func waitClosed(t *testing.T, ch <-chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for %s", what)
+	}
+}
+
+// waitLeading waits until a Lead call has taken effect
+func waitLeading(s *Scheduler) {
+	for {
+		if ctx, cancel := s.runContext(); ctx != nil {
+			cancel()
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestNewSchedulerRejectsBadJobs(t *testing.T) {
+	fn := func(context.Context) error { return nil }
+	tests := []struct {
+		name string
+		cfg  SchedulerConfig
+		jobs []Job
+	}{
+		{"bad schedule", SchedulerConfig{}, []Job{{Name: "a", Schedule: "every minute", Fn: fn}}},
+		{"duplicate", SchedulerConfig{}, []Job{{Name: "a", Schedule: "@hourly", Fn: fn}, {Name: "a", Schedule: "@daily", Fn: fn}}},
+		{"no function", SchedulerConfig{}, []Job{{Name: "a", Schedule: "@hourly"}}},
+		{"bad timezone", SchedulerConfig{Timezone: "Mars/Olympus"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewScheduler(tt.cfg, tt.jobs); err == nil {
+				t.Error("NewScheduler succeeded")
+			}
+		})
+	}
+}
+
+func TestStopCancelsRunningJob(t *testing.T) {
+	for _, leader := range []bool{false, true} {
+		s, err := NewScheduler(SchedulerConfig{}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.RequireLeader = leader
+		s.Start(context.Background())
+		leading := make(chan struct{})
+		if leader {
+			go func() {
+				s.Lead(context.Background())
+				close(leading)
+			}()
+			waitLeading(s)
+		}
+
+		started := make(chan struct{})
+		done := runAsync(s, blockingJob(started))
+		waitClosed(t, started, "job start")
+		if err := s.Stop(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		waitClosed(t, done, "job cancellation")
+		if leader {
+			waitClosed(t, leading, "Lead to return")
+		}
+	}
+}
+
+func TestLeaderJobs(t *testing.T) {
+	s, err := NewScheduler(SchedulerConfig{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.RequireLeader = true
+	parent, stop := context.WithCancel(context.Background())
+	s.Start(parent)
+
+	ran := false
+	s.wrap(Job{Name: "skip", Fn: func(context.Context) error { ran = true; return nil }}).Run()
+	if ran {
+		t.Fatal("job ran without leadership")
+	}
+
+	lead, resign := context.WithCancel(context.Background())
+	go s.Lead(lead)
+	waitLeading(s)
+
+	// losing leadership cancels the run
+	started := make(chan struct{})
+	done := runAsync(s, blockingJob(started))
+	waitClosed(t, started, "job start")
+	resign()
+	waitClosed(t, done, "cancellation on lost leadership")
+
+	// so does cancelling the Start context while leading
+	go s.Lead(context.Background())
+	waitLeading(s)
+	started = make(chan struct{})
+	done = runAsync(s, blockingJob(started))
+	waitClosed(t, started, "job start")
+	stop()
+	waitClosed(t, done, "cancellation of the Start context")
+	s.Stop(context.Background())
+}
+
+func TestJobRunsOnSchedule(t *testing.T) {
+	ran := make(chan struct{})
+	var once sync.Once
+	s, err := NewScheduler(SchedulerConfig{}, []Job{
+		{Name: "tick", Schedule: "* * * * * *", Fn: func(context.Context) error {
+			once.Do(func() { close(ran) })
+			return nil
+		}},
+		{Name: "tick_fails", Schedule: "@every 1s", Fn: func(context.Context) error { return errors.New("boom") }},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := testutil.ToFloat64(jobErrors.WithLabelValues("tick_fails"))
+	s.Start(context.Background())
+	defer s.Stop(context.Background())
+
+	select {
+	case <-ran:
+	case <-time.After(3 * time.Second):
+		t.Fatal("1-second job did not run within 3s")
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for testutil.ToFloat64(jobErrors.WithLabelValues("tick_fails")) == before {
+		if time.Now().After(deadline) {
+			t.Fatal("failing job not counted in scheduler_job_errors_total")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
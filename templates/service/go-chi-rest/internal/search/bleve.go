@@ -0,0 +1,129 @@
+// Package search provides full-text search over an embedded Bleve index
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/standard"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var indexOperations = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "search_index_operations_total",
+	Help: "Search index operations, by operation (index, delete, search).",
+}, []string{"operation"})
+
+// SearchConfig locates the on-disk index; search is disabled when IndexPath is empty
+type SearchConfig struct {
+	IndexPath string `mapstructure:"index_path"`
+
+	// Index is opened from IndexPath at startup and closed on shutdown;
+	// handlers that feed the index share it through here
+	Index *Index `mapstructure:"-"`
+}
+
+// Hit is one matching document
+type Hit struct {
+	ID       string  `json:"id"`
+	Score    float64 `json:"score"`
+	Fragment string  `json:"fragment,omitempty"` // highlighted excerpt, if any
+}
+
+// SearchResult is a page of hits; Total counts all matches
+type SearchResult struct {
+	Total int64 `json:"total"`
+	Hits  []Hit `json:"hits"`
+}
+
+// Index is a Bleve index using the standard analyzer for all text fields
+type Index struct {
+	idx bleve.Index
+}
+
+// Open opens the index at path, creating it if it does not exist. An empty
+// path gives an in-memory index.
+func Open(path string) (*Index, error) {
+	if path == "" {
+		idx, err := bleve.NewMemOnly(newMapping())
+		if err != nil {
+			return nil, err
+		}
+		return &Index{idx: idx}, nil
+	}
+	idx, err := bleve.Open(path)
+	if errors.Is(err, bleve.ErrorIndexPathDoesNotExist) {
+		idx, err = bleve.New(path, newMapping())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open search index %s: %w", path, err)
+	}
+	return &Index{idx: idx}, nil
+}
+
+func newMapping() *mapping.IndexMappingImpl {
+	m := bleve.NewIndexMapping()
+	m.DefaultAnalyzer = standard.Name
+	return m
+}
+
+// Index adds or replaces the document stored under id; doc is a struct or
+// map whose string fields are analysed
+func (i *Index) Index(ctx context.Context, id string, doc any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	indexOperations.WithLabelValues("index").Inc()
+	return i.idx.Index(id, doc)
+}
+
+// Delete removes the document stored under id; unknown ids are not an error
+func (i *Index) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	indexOperations.WithLabelValues("delete").Inc()
+	return i.idx.Delete(id)
+}
+
+// Search runs query in Bleve query-string syntax (e.g. `+title:go -draft`)
+// and returns hits offset..offset+limit, best first
+func (i *Index) Search(ctx context.Context, query string, limit, offset int) (*SearchResult, error) {
+	indexOperations.WithLabelValues("search").Inc()
+	req := bleve.NewSearchRequestOptions(bleve.NewQueryStringQuery(query), limit, offset, false)
+	req.Highlight = bleve.NewHighlight()
+	res, err := i.idx.SearchInContext(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	out := &SearchResult{Total: int64(res.Total), Hits: make([]Hit, 0, len(res.Hits))}
+	for _, h := range res.Hits {
+		out.Hits = append(out.Hits, Hit{ID: h.ID, Score: h.Score, Fragment: firstFragment(h.Fragments)})
+	}
+	return out, nil
+}
+
+// firstFragment picks a fragment deterministically (by field name)
+func firstFragment(fragments map[string][]string) string {
+	fields := make([]string, 0, len(fragments))
+	for f := range fragments {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	for _, f := range fields {
+		if len(fragments[f]) > 0 {
+			return fragments[f][0]
+		}
+	}
+	return ""
+}
+
+// Close flushes and closes the index
+func (i *Index) Close() error {
+	return i.idx.Close()
+}
@@ -0,0 +1,53 @@
+package search
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+type doc struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+func TestIndexPersistsAcrossClose(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "search.bleve")
+	idx, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := map[string]doc{
+		"1": {Title: "Graceful shutdown in Go", Body: "drain requests, then close the index"},
+		"2": {Title: "Rate limiting", Body: "sliding window counters in Redis"},
+	}
+	for id, d := range docs {
+		if err := idx.Index(ctx, id, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := idx.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// reopening fails while the previous handle still holds the lock
+	idx, err = Open(path)
+	if err != nil {
+		t.Fatalf("reopen after Close: %v", err)
+	}
+	defer idx.Close()
+	res, err := idx.Search(ctx, "shutdown", 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 1 || res.Hits[0].ID != "1" || res.Hits[0].Fragment == "" {
+		t.Errorf("search = %+v", res)
+	}
+	if err := idx.Delete(ctx, "1"); err != nil {
+		t.Fatal(err)
+	}
+	if res, _ := idx.Search(ctx, "shutdown", 10, 0); res.Total != 0 {
+		t.Errorf("deleted document still found: %+v", res)
+	}
+}
@@ -0,0 +1,65 @@
+package search
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/blevesearch/bleve/v2"
+	"go.uber.org/zap"
+
+	"github.com/example/go-chi-rest/internal/errcodes"
+	"github.com/example/go-chi-rest/internal/negotiate"
+)
+
+const (
+	// DefaultLimit is used when the client does not send a limit
+	DefaultLimit = 10
+	// MaxLimit caps the page size a client can request
+	MaxLimit = 100
+)
+
+// NewSearchHandler serves GET ?q=…&limit=…&offset=… with a SearchResult
+func NewSearchHandler(idx *Index) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		query := q.Get("q")
+		if query == "" {
+			errcodes.Write(w, errcodes.FromRequest(r).New("INVALID_REQUEST", "q is required"))
+			return
+		}
+		if _, err := bleve.NewQueryStringQuery(query).Parse(); err != nil {
+			errcodes.Write(w, errcodes.FromRequest(r).New("INVALID_REQUEST", "q: "+err.Error()))
+			return
+		}
+		limit, ok := intParam(q.Get("limit"), DefaultLimit, 1)
+		if !ok {
+			errcodes.Write(w, errcodes.FromRequest(r).New("INVALID_REQUEST", "limit must be a positive integer"))
+			return
+		}
+		if limit > MaxLimit {
+			limit = MaxLimit
+		}
+		offset, ok := intParam(q.Get("offset"), 0, 0)
+		if !ok {
+			errcodes.Write(w, errcodes.FromRequest(r).New("INVALID_REQUEST", "offset must be a non-negative integer"))
+			return
+		}
+
+		res, err := idx.Search(r.Context(), query, limit, offset)
+		if err != nil {
+			zap.L().Error("search failed", zap.String("q", query), zap.Error(err))
+			errcodes.Write(w, errcodes.FromRequest(r).New(errcodes.CodeInternal))
+			return
+		}
+		negotiate.WriteResponse(w, http.StatusOK, res, negotiate.AcceptedType(r.Context()))
+	}
+}
+
+// intParam parses an optional integer query parameter of at least lowest
+func intParam(s string, def, lowest int) (int, bool) {
+	if s == "" {
+		return def, true
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil && n >= lowest
+}
@@ -0,0 +1,138 @@
+package security
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+var (
+	certReloadSuccess = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "tls_cert_reload_success_total",
+		Help: "TLS key pairs reloaded from disk.",
+	})
+	certReloadError = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "tls_cert_reload_error_total",
+		Help: "TLS key pair reloads that failed; the previous certificate stays in use.",
+	})
+)
+
+// reloadDebounce coalesces the burst of events a cert rotation produces
+const reloadDebounce = 200 * time.Millisecond
+
+// TLSConfig configures the server certificate
+type TLSConfig struct {
+	CertFile   string `mapstructure:"cert_file"`
+	KeyFile    string `mapstructure:"key_file"`
+	AutoReload bool   `mapstructure:"auto_reload"` // pick up rotated files without a restart
+}
+
+// CertReloader serves the current key pair through tls.Config.GetCertificate
+// and can swap in a new one when the files change
+type CertReloader struct {
+	cfg  TLSConfig
+	cert atomic.Pointer[tls.Certificate]
+}
+
+// NewCertReloader loads the initial key pair; failing here is fatal, unlike a later reload
+func NewCertReloader(cfg TLSConfig) (*CertReloader, error) {
+	c := &CertReloader{cfg: cfg}
+	cert, err := loadKeyPair(cfg)
+	if err != nil {
+		return nil, err
+	}
+	c.cert.Store(cert)
+	return c, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate
+func (c *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.cert.Load(), nil
+}
+
+// Reload re-reads the key pair; on error the current certificate is kept
+func (c *CertReloader) Reload() error {
+	cert, err := loadKeyPair(c.cfg)
+	if err != nil {
+		certReloadError.Inc()
+		zap.L().Error("tls certificate reload failed, keeping current certificate", zap.String("cert_file", c.cfg.CertFile), zap.Error(err))
+		return err
+	}
+	c.cert.Store(cert)
+	certReloadSuccess.Inc()
+	zap.L().Info("tls certificate reloaded", zap.String("cert_file", c.cfg.CertFile), zap.Time("not_after", cert.Leaf.NotAfter))
+	return nil
+}
+
+// Watch reloads the key pair whenever the cert or key file changes, until ctx
+// is cancelled. The parent directories are watched rather than the files so
+// rotations done by rename (cert-manager, Kubernetes secret volumes) are seen.
+func (c *CertReloader) Watch(ctx context.Context) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("tls watcher: %w", err)
+	}
+	defer w.Close()
+
+	watched := map[string]bool{}
+	for _, f := range []string{c.cfg.CertFile, c.cfg.KeyFile} {
+		dir := filepath.Dir(f)
+		if watched[dir] {
+			continue
+		}
+		if err := w.Add(dir); err != nil {
+			return fmt.Errorf("tls watcher: watch %s: %w", dir, err)
+		}
+		watched[dir] = true
+	}
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if ev.Has(fsnotify.Chmod) {
+				continue
+			}
+			// the files themselves, or a ..data style symlink swap next to them
+			if name := filepath.Base(ev.Name); name == filepath.Base(c.cfg.CertFile) || name == filepath.Base(c.cfg.KeyFile) || name == "..data" {
+				debounce = time.After(reloadDebounce)
+			}
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			zap.L().Warn("tls watcher error", zap.Error(err))
+		case <-debounce:
+			debounce = nil
+			c.Reload()
+		}
+	}
+}
+
+func loadKeyPair(cfg TLSConfig) (*tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load tls key pair: %w", err)
+	}
+	if cert.Leaf == nil {
+		// Go < 1.23 leaves Leaf unset
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return nil, fmt.Errorf("parse tls certificate: %w", err)
+		}
+	}
+	return &cert, nil
+}
@@ -0,0 +1,138 @@
+package security
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// writeSelfSigned writes a key pair for commonName, replacing the files by
+// rename as cert-manager does
+func writeSelfSigned(t *testing.T, certFile, keyFile, commonName string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeAtomic(t, keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	writeAtomic(t, certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func writeAtomic(t *testing.T, path string, data []byte) {
+	t.Helper()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// serveTLS accepts connections with reloader's certificate and completes the handshake
+func serveTLS(t *testing.T, reloader *CertReloader) string {
+	t.Helper()
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{GetCertificate: reloader.GetCertificate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { lis.Close() })
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	return lis.Addr().String()
+}
+
+// servedName dials addr and returns the CommonName of the certificate presented
+func servedName(t *testing.T, addr string) string {
+	t.Helper()
+	conn, err := tls.Dial("tcp", addr, &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+}
+
+func TestCertReloaderWatch(t *testing.T) {
+	dir := t.TempDir()
+	cfg := TLSConfig{CertFile: filepath.Join(dir, "tls.crt"), KeyFile: filepath.Join(dir, "tls.key"), AutoReload: true}
+	writeSelfSigned(t, cfg.CertFile, cfg.KeyFile, "v1")
+
+	reloader, err := NewCertReloader(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go reloader.Watch(ctx)
+	addr := serveTLS(t, reloader)
+	if got := servedName(t, addr); got != "v1" {
+		t.Fatalf("serving %q, want v1", got)
+	}
+	time.Sleep(50 * time.Millisecond) // let the watcher register its directories
+
+	writeSelfSigned(t, cfg.CertFile, cfg.KeyFile, "v2")
+	deadline := time.Now().Add(2 * time.Second)
+	for servedName(t, addr) != "v2" {
+		if time.Now().After(deadline) {
+			t.Fatal("rotated certificate not served within 2s")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	errorsBefore := testutil.ToFloat64(certReloadError)
+	writeAtomic(t, cfg.CertFile, []byte("not a certificate"))
+	deadline = time.Now().Add(2 * time.Second)
+	for testutil.ToFloat64(certReloadError) == errorsBefore {
+		if time.Now().After(deadline) {
+			t.Fatal("invalid certificate never triggered a reload")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if got := servedName(t, addr); got != "v2" {
+		t.Errorf("after a failed reload serving %q, want v2 kept", got)
+	}
+}
+
+func TestNewCertReloaderMissingFiles(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewCertReloader(TLSConfig{CertFile: filepath.Join(dir, "x.crt"), KeyFile: filepath.Join(dir, "x.key")}); err == nil {
+		t.Error("NewCertReloader succeeded without files")
+	}
+}
@@ -0,0 +1,82 @@
+package security
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CORSConfig configures cross-origin access; CORS is off while AllowedOrigins is empty
+type CORSConfig struct {
+	AllowedOrigins   []string `mapstructure:"allowed_origins"` // exact origins, or "*" for any
+	AllowedMethods   []string `mapstructure:"allowed_methods"` // default GET, HEAD, POST
+	AllowedHeaders   []string `mapstructure:"allowed_headers"` // request headers allowed in preflights
+	AllowCredentials bool     `mapstructure:"allow_credentials"`
+	MaxAge           int      `mapstructure:"max_age"` // seconds a preflight may be cached; 0 omits it
+}
+
+// NewCORSMiddleware answers preflight requests and adds the CORS headers to
+// responses for allowed origins. load is called on every request, so origin
+// changes apply without a restart. Disallowed origins get no CORS headers and
+// the browser blocks the response.
+func NewCORSMiddleware(load func() CORSConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			cfg := load()
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			wildcard, ok := originAllowed(cfg.AllowedOrigins, origin)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if wildcard && !cfg.AllowCredentials {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				// browsers reject "*" on credentialed requests
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			if cfg.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			// preflight
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			methods := cfg.AllowedMethods
+			if len(methods) == 0 {
+				methods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+			}
+			h.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+			if len(cfg.AllowedHeaders) > 0 {
+				h.Set("Access-Control-Allow-Headers", strings.Join(cfg.AllowedHeaders, ", "))
+			}
+			if cfg.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(cfg.MaxAge))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// originAllowed reports whether origin is allowed and whether that is by "*"
+func originAllowed(allowed []string, origin string) (wildcard, ok bool) {
+	for _, o := range allowed {
+		if o == "*" {
+			return true, true
+		}
+		if strings.EqualFold(o, origin) {
+			return false, true
+		}
+	}
+	return false, false
+}
@@ -0,0 +1,80 @@
+package security
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	tests := []struct {
+		name        string
+		cfg         CORSConfig
+		method      string
+		origin      string
+		preflight   bool
+		wantStatus  int
+		wantOrigin  string
+		wantMethods string
+		wantCreds   string
+	}{
+		{"no origin", CORSConfig{AllowedOrigins: []string{"*"}}, http.MethodGet, "", false, http.StatusOK, "", "", ""},
+		{"disabled", CORSConfig{}, http.MethodGet, "https://a.example", false, http.StatusOK, "", "", ""},
+		{"exact origin", CORSConfig{AllowedOrigins: []string{"https://a.example"}}, http.MethodGet, "https://A.example", false, http.StatusOK, "https://A.example", "", ""},
+		{"other origin", CORSConfig{AllowedOrigins: []string{"https://a.example"}}, http.MethodGet, "https://evil.example", false, http.StatusOK, "", "", ""},
+		{"wildcard", CORSConfig{AllowedOrigins: []string{"*"}}, http.MethodGet, "https://b.example", false, http.StatusOK, "*", "", ""},
+		{"wildcard with credentials echoes origin", CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true}, http.MethodGet, "https://b.example", false, http.StatusOK, "https://b.example", "", "true"},
+		{"preflight", CORSConfig{AllowedOrigins: []string{"https://a.example"}}, http.MethodOptions, "https://a.example", true, http.StatusNoContent, "https://a.example", "GET, HEAD, POST", ""},
+		{"preflight custom methods", CORSConfig{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"PUT"}}, http.MethodOptions, "https://a.example", true, http.StatusNoContent, "*", "PUT", ""},
+		{"plain OPTIONS passes through", CORSConfig{AllowedOrigins: []string{"*"}}, http.MethodOptions, "https://a.example", false, http.StatusOK, "*", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			h := NewCORSMiddleware(func() CORSConfig { return cfg })(ok)
+			req := httptest.NewRequest(tt.method, "/api/v1/ping", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.preflight {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPut)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			got := rec.Header()
+			if got.Get("Access-Control-Allow-Origin") != tt.wantOrigin ||
+				got.Get("Access-Control-Allow-Methods") != tt.wantMethods ||
+				got.Get("Access-Control-Allow-Credentials") != tt.wantCreds {
+				t.Errorf("headers = %v", got)
+			}
+			if tt.origin != "" && got.Get("Vary") != "Origin" {
+				t.Errorf("Vary = %q, want Origin", got.Get("Vary"))
+			}
+		})
+	}
+}
+
+// The config is read per request, so a change applies to the next one
+func TestCORSMiddlewareReload(t *testing.T) {
+	cfg := CORSConfig{AllowedOrigins: []string{"https://old.example"}}
+	h := NewCORSMiddleware(func() CORSConfig { return cfg })(http.NotFoundHandler())
+	allowed := func(origin string) bool {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Origin", origin)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Header().Get("Access-Control-Allow-Origin") == origin
+	}
+	if !allowed("https://old.example") || allowed("https://new.example") {
+		t.Fatal("initial origins not applied")
+	}
+	cfg.AllowedOrigins = []string{"https://new.example"}
+	if allowed("https://old.example") || !allowed("https://new.example") {
+		t.Error("changed origins not applied to the next request")
+	}
+}
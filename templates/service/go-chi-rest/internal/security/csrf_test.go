@@ -0,0 +1,75 @@
+package security
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var csrfKey = []byte("csrf-test-key")
+
+// signedToken builds a token as newCSRFToken does, issued at the given time
+func signedToken(key []byte, issued time.Time) string {
+	payload := make([]byte, 40)
+	binary.BigEndian.PutUint64(payload[32:], uint64(issued.Unix()))
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(csrfMAC(key, payload))
+}
+
+func TestCSRFMiddleware(t *testing.T) {
+	cfg := CSRFConfig{Key: csrfKey, TTL: time.Hour}
+	h := NewCSRFMiddleware(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if CSRFToken(r) == "" {
+			t.Error("CSRFToken empty in handler")
+		}
+	}))
+	valid := signedToken(csrfKey, time.Now())
+	expired := signedToken(csrfKey, time.Now().Add(-2*time.Hour))
+	forged := signedToken([]byte("other-key"), time.Now())
+
+	tests := []struct {
+		name, method, cookie, header string
+		want                         int
+		wantNewCookie                bool
+	}{
+		{"GET without cookie issues one", http.MethodGet, "", "", http.StatusOK, true},
+		{"GET with valid cookie keeps it", http.MethodGet, valid, "", http.StatusOK, false},
+		{"GET with expired cookie replaces it", http.MethodGet, expired, "", http.StatusOK, true},
+		{"POST with matching token", http.MethodPost, valid, valid, http.StatusOK, false},
+		{"DELETE with matching token", http.MethodDelete, valid, valid, http.StatusOK, false},
+		{"POST without header", http.MethodPost, valid, "", http.StatusForbidden, false},
+		{"POST without cookie", http.MethodPost, "", valid, http.StatusForbidden, false},
+		{"POST with mismatched header", http.MethodPost, valid, signedToken(csrfKey, time.Now().Add(-time.Minute)), http.StatusForbidden, false},
+		{"POST with expired token", http.MethodPost, expired, expired, http.StatusForbidden, false},
+		{"PUT with forged token", http.MethodPut, forged, forged, http.StatusForbidden, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "csrf_token", Value: tt.cookie})
+			}
+			if tt.header != "" {
+				req.Header.Set(CSRFHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+			cookies := rec.Result().Cookies()
+			if got := len(cookies) == 1; got != tt.wantNewCookie {
+				t.Fatalf("cookies = %v, want new cookie %v", cookies, tt.wantNewCookie)
+			}
+			if tt.wantNewCookie {
+				c := cookies[0]
+				if !validCSRFToken(cfg, c.Value) || c.SameSite != http.SameSiteStrictMode || c.HttpOnly {
+					t.Errorf("issued cookie = %+v", c)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+//go:build fips
+
+package security
+
+import "crypto/tls"
+
+// IsFIPSMode reports whether the binary was built with the fips tag
+func IsFIPSMode() bool { return true }
+
+// TLS 1.2 suites approved under FIPS 140-2: ECDHE key exchange with AES-GCM.
+// TLS 1.3 suites are not configurable in crypto/tls; build with
+// GOEXPERIMENT=boringcrypto to restrict those as well (see fips_boring.go).
+var tlsCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+}
+
+var tlsCurves = []tls.CurveID{tls.CurveP256, tls.CurveP384}
@@ -0,0 +1,7 @@
+//go:build fips && boringcrypto
+
+package security
+
+// with a BoringCrypto toolchain, also lock every TLS config in the process,
+// TLS 1.3 included, to FIPS-approved settings
+import _ "crypto/tls/fipsonly"
@@ -0,0 +1,14 @@
+//go:build !fips
+
+package security
+
+import "crypto/tls"
+
+// IsFIPSMode reports whether the binary was built with the fips tag
+func IsFIPSMode() bool { return false }
+
+// nil keeps the crypto/tls defaults
+var (
+	tlsCipherSuites []uint16
+	tlsCurves       []tls.CurveID
+)
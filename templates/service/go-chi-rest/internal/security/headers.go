@@ -0,0 +1,148 @@
+package security
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// HSTSConfig configures the Strict-Transport-Security header. MaxAge 0 omits it.
+type HSTSConfig struct {
+	MaxAge            int  `mapstructure:"max_age"` // seconds
+	IncludeSubdomains bool `mapstructure:"include_subdomains"`
+	Preload           bool `mapstructure:"preload"`
+}
+
+// SecurityHeadersConfig lists the response headers to add. Empty values skip the header.
+//
+// Nonce-based CSP for SPAs: generate a fresh nonce per request in a handler (or a
+// middleware placed after this one), render it into the <script nonce="..."> tags
+// and set your own Content-Security-Policy with 'nonce-<value>'. Because headers
+// already set by handlers are never overridden, the per-request policy wins over
+// the static ContentSecurityPolicy configured here.
+type SecurityHeadersConfig struct {
+	HSTS                  HSTSConfig `mapstructure:"hsts"`
+	ContentSecurityPolicy string     `mapstructure:"content_security_policy"`
+	XFrameOptions         string     `mapstructure:"x_frame_options"`
+	XContentTypeOptions   bool       `mapstructure:"x_content_type_options"`
+	ReferrerPolicy        string     `mapstructure:"referrer_policy"`
+	PermissionsPolicy     string     `mapstructure:"permissions_policy"`
+	ExposeHeaders         []string   `mapstructure:"expose_headers"` // Access-Control-Expose-Headers
+}
+
+// DefaultSecurityHeaders returns strict defaults for production and a relaxed CSP
+// ('unsafe-eval', 'unsafe-inline') elsewhere for easier debugging
+func DefaultSecurityHeaders(environment string) SecurityHeadersConfig {
+	cfg := SecurityHeadersConfig{
+		ContentSecurityPolicy: "default-src 'self'; frame-ancestors 'none'; object-src 'none'; base-uri 'self'",
+		XFrameOptions:         "DENY",
+		XContentTypeOptions:   true,
+		ReferrerPolicy:        "strict-origin-when-cross-origin",
+		PermissionsPolicy:     "camera=(), microphone=(), geolocation=()",
+	}
+	if environment == "production" {
+		cfg.HSTS = HSTSConfig{MaxAge: 63072000, IncludeSubdomains: true}
+		cfg.ReferrerPolicy = "no-referrer"
+		return cfg
+	}
+	cfg.ContentSecurityPolicy = "default-src 'self' 'unsafe-eval' 'unsafe-inline'; object-src 'none'"
+	cfg.XFrameOptions = "SAMEORIGIN"
+	return cfg
+}
+
+// headers renders cfg into header name/value pairs, skipping empty values
+func (cfg SecurityHeadersConfig) headers() [][2]string {
+	var hs [][2]string
+	add := func(name, value string) {
+		if value != "" {
+			hs = append(hs, [2]string{name, value})
+		}
+	}
+	if cfg.HSTS.MaxAge > 0 {
+		v := fmt.Sprintf("max-age=%d", cfg.HSTS.MaxAge)
+		if cfg.HSTS.IncludeSubdomains {
+			v += "; includeSubDomains"
+		}
+		if cfg.HSTS.Preload {
+			v += "; preload"
+		}
+		add("Strict-Transport-Security", v)
+	}
+	add("Content-Security-Policy", cfg.ContentSecurityPolicy)
+	add("X-Frame-Options", cfg.XFrameOptions)
+	if cfg.XContentTypeOptions {
+		add("X-Content-Type-Options", "nosniff")
+	}
+	add("Referrer-Policy", cfg.ReferrerPolicy)
+	add("Permissions-Policy", cfg.PermissionsPolicy)
+	add("Access-Control-Expose-Headers", strings.Join(cfg.ExposeHeaders, ", "))
+	return hs
+}
+
+// NewSecurityHeadersMiddleware adds the configured security headers to every
+// response. Headers already set by handlers are left untouched.
+func NewSecurityHeadersMiddleware(cfg SecurityHeadersConfig) func(http.Handler) http.Handler {
+	hs := cfg.headers()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(&headerWriter{ResponseWriter: w, headers: hs}, r)
+		})
+	}
+}
+
+// NewReloadableSecurityHeadersMiddleware is NewSecurityHeadersMiddleware
+// with the config read through load on every request, so reloads apply at once
+func NewReloadableSecurityHeadersMiddleware(load func() SecurityHeadersConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(&headerWriter{ResponseWriter: w, headers: load().headers()}, r)
+		})
+	}
+}
+
+// headerWriter applies defaults just before the header is flushed so values set
+// by the handler take precedence
+type headerWriter struct {
+	http.ResponseWriter
+	headers [][2]string
+	written bool
+}
+
+func (hw *headerWriter) apply() {
+	if hw.written {
+		return
+	}
+	hw.written = true
+	h := hw.ResponseWriter.Header()
+	for _, kv := range hw.headers {
+		if h.Get(kv[0]) == "" {
+			h.Set(kv[0], kv[1])
+		}
+	}
+}
+
+func (hw *headerWriter) WriteHeader(status int) {
+	hw.apply()
+	hw.ResponseWriter.WriteHeader(status)
+}
+
+func (hw *headerWriter) Write(b []byte) (int, error) {
+	hw.apply()
+	return hw.ResponseWriter.Write(b)
+}
+
+// FlushError applies the defaults first; http.ResponseController prefers it
+// over Unwrap, so a flush never sends the header without them
+func (hw *headerWriter) FlushError() error {
+	hw.apply()
+	return http.NewResponseController(hw.ResponseWriter).Flush()
+}
+
+func (hw *headerWriter) Flush() {
+	hw.FlushError()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (hw *headerWriter) Unwrap() http.ResponseWriter {
+	return hw.ResponseWriter
+}
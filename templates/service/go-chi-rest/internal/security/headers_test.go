@@ -0,0 +1,104 @@
+package security
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// wrapped hides the recorder's Flush behind Unwrap, like other middleware do
+type wrapped struct{ http.ResponseWriter }
+
+func (w wrapped) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+func TestSecurityHeadersMiddleware(t *testing.T) {
+	full := SecurityHeadersConfig{
+		HSTS:                  HSTSConfig{MaxAge: 60, IncludeSubdomains: true, Preload: true},
+		ContentSecurityPolicy: "default-src 'self'",
+		XFrameOptions:         "DENY",
+		XContentTypeOptions:   true,
+		ReferrerPolicy:        "no-referrer",
+		PermissionsPolicy:     "camera=()",
+		ExposeHeaders:         []string{"X-Request-ID", "ETag"},
+	}
+	tests := []struct {
+		name    string
+		cfg     SecurityHeadersConfig
+		handler http.HandlerFunc
+		want    map[string]string // "" means absent
+	}{
+		{
+			name: "every configured header once",
+			cfg:  full,
+			want: map[string]string{
+				"Strict-Transport-Security":     "max-age=60; includeSubDomains; preload",
+				"Content-Security-Policy":       "default-src 'self'",
+				"X-Frame-Options":               "DENY",
+				"X-Content-Type-Options":        "nosniff",
+				"Referrer-Policy":               "no-referrer",
+				"Permissions-Policy":            "camera=()",
+				"Access-Control-Expose-Headers": "X-Request-ID, ETag",
+			},
+		},
+		{
+			name: "empty values skip the header",
+			cfg:  SecurityHeadersConfig{XFrameOptions: "DENY"},
+			want: map[string]string{
+				"X-Frame-Options":           "DENY",
+				"Strict-Transport-Security": "",
+				"Content-Security-Policy":   "",
+				"X-Content-Type-Options":    "",
+				"Referrer-Policy":           "",
+			},
+		},
+		{
+			name: "handler value wins",
+			cfg:  full,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Security-Policy", "script-src 'nonce-abc'")
+			},
+			want: map[string]string{"Content-Security-Policy": "script-src 'nonce-abc'"},
+		},
+		{
+			name: "applied before an explicit flush",
+			cfg:  full,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if err := http.NewResponseController(w).Flush(); err != nil {
+					t.Errorf("flush: %v", err)
+				}
+				w.Header().Set("X-Frame-Options", "SAMEORIGIN") // too late, header already sent
+			},
+			want: map[string]string{"X-Frame-Options": "DENY"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := tt.handler
+			if h == nil {
+				h = func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) }
+			}
+			rec := httptest.NewRecorder()
+			NewSecurityHeadersMiddleware(tt.cfg)(h).ServeHTTP(wrapped{rec}, httptest.NewRequest(http.MethodGet, "/", nil))
+			for name, want := range tt.want {
+				got := rec.Result().Header.Values(name)
+				switch {
+				case want == "" && len(got) != 0:
+					t.Errorf("%s = %q, want absent", name, got)
+				case want != "" && (len(got) != 1 || got[0] != want):
+					t.Errorf("%s = %q, want exactly %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultSecurityHeaders(t *testing.T) {
+	prod := DefaultSecurityHeaders("production")
+	if prod.HSTS.MaxAge == 0 || prod.XFrameOptions != "DENY" || !prod.XContentTypeOptions {
+		t.Errorf("production defaults not strict: %+v", prod)
+	}
+	dev := DefaultSecurityHeaders("development")
+	if dev.HSTS.MaxAge != 0 || dev.ContentSecurityPolicy != "default-src 'self' 'unsafe-eval' 'unsafe-inline'; object-src 'none'" {
+		t.Errorf("development defaults: %+v", dev)
+	}
+}
@@ -0,0 +1,134 @@
+package security
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// IPFilterConfig configures allow/deny filtering by client IP
+type IPFilterConfig struct {
+	Mode       string   `mapstructure:"mode"`        // allow|deny; empty disables the filter
+	CIDRs      []string `mapstructure:"cidrs"`       // e.g. 10.0.0.0/8, 2001:db8::/32
+	TrustProxy bool     `mapstructure:"trust_proxy"` // removed: rejected at startup; list the proxies in trusted_proxies instead
+
+	// Filter is built at startup so the admin API can update it; nil builds one
+	Filter *IPFilterMiddleware `mapstructure:"-"`
+}
+
+// IPFilterMiddleware blocks requests by client IP. In allow mode only listed
+// networks pass; in deny mode listed networks are rejected.
+type IPFilterMiddleware struct {
+	allow bool
+
+	mu    sync.RWMutex
+	nets  []*net.IPNet
+	cidrs []string
+}
+
+// NewIPFilter parses cfg and returns a filter whose CIDRs can be updated at runtime
+func NewIPFilter(cfg IPFilterConfig) (*IPFilterMiddleware, error) {
+	f := &IPFilterMiddleware{}
+	switch cfg.Mode {
+	case "allow":
+		f.allow = true
+	case "deny":
+	default:
+		return nil, fmt.Errorf("ip filter: unknown mode %q (want allow|deny)", cfg.Mode)
+	}
+	if err := f.UpdateCIDRs(cfg.CIDRs); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// NewIPFilterMiddleware returns the filter as a middleware; it panics on invalid config
+func NewIPFilterMiddleware(cfg IPFilterConfig) func(http.Handler) http.Handler {
+	f, err := NewIPFilter(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return f.Middleware
+}
+
+// UpdateCIDRs atomically replaces the filtered networks. Nothing changes on error.
+func (f *IPFilterMiddleware) UpdateCIDRs(cidrs []string) error {
+	nets, err := parseCIDRs(cidrs)
+	if err != nil {
+		return fmt.Errorf("ip filter: %w", err)
+	}
+	f.mu.Lock()
+	f.nets, f.cidrs = nets, append([]string(nil), cidrs...)
+	f.mu.Unlock()
+	return nil
+}
+
+// CIDRs returns the filtered networks as last set
+func (f *IPFilterMiddleware) CIDRs() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return append([]string(nil), f.cidrs...)
+}
+
+// ipFilterState is the /admin/ip-filter document
+type ipFilterState struct {
+	Mode  string   `json:"mode"`
+	CIDRs []string `json:"cidrs"`
+}
+
+// AdminHandler serves /admin/ip-filter: GET returns the mode and networks in
+// force, POST {"cidrs": ["203.0.113.0/24"]} replaces the networks for the next
+// request. The mode is fixed at startup. Mount it behind the admin token.
+func (f *IPFilterMiddleware) AdminHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			var st ipFilterState
+			if err := json.NewDecoder(r.Body).Decode(&st); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid_request", "body must be a JSON object")
+				return
+			}
+			if err := f.UpdateCIDRs(st.CIDRs); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid_cidr", err.Error())
+				return
+			}
+			zap.L().Warn("ip filter changed", zap.Strings("cidrs", st.CIDRs), zap.String("remote_addr", r.RemoteAddr))
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		mode := "deny"
+		if f.allow {
+			mode = "allow"
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(ipFilterState{Mode: mode, CIDRs: f.CIDRs()})
+	}
+}
+
+// Middleware responds 403 to blocked client IPs. The client IP is
+// r.RemoteAddr, which NewRealIPMiddleware only rewrites for requests from
+// trusted proxies, so it must run after that and never sees spoofed headers.
+func (f *IPFilterMiddleware) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := hostIP(r.RemoteAddr)
+		if ip == nil || f.contains(ip) != f.allow {
+			zap.L().Warn("request blocked by ip filter", zap.String("ip", ip.String()), zap.String("path", r.URL.Path))
+			writeError(w, http.StatusForbidden, "forbidden", "access denied")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (f *IPFilterMiddleware) contains(ip net.IP) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return containsIP(f.nets, ip)
+}
@@ -0,0 +1,102 @@
+package security
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func serveFiltered(t *testing.T, h http.Handler, remoteAddr string, headers map[string]string) int {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func newFilteredHandler(t *testing.T, trusted []string, cfg IPFilterConfig) (http.Handler, *IPFilterMiddleware) {
+	t.Helper()
+	realIP, err := NewRealIPMiddleware(trusted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := NewIPFilter(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	return realIP(f.Middleware(ok)), f
+}
+
+func TestIPFilter(t *testing.T) {
+	allow := IPFilterConfig{Mode: "allow", CIDRs: []string{"203.0.113.0/24", "2001:db8::/32"}}
+	deny := IPFilterConfig{Mode: "deny", CIDRs: []string{"198.51.100.0/24"}}
+	proxies := []string{"10.0.0.0/8"}
+
+	tests := []struct {
+		name       string
+		trusted    []string
+		cfg        IPFilterConfig
+		remoteAddr string
+		headers    map[string]string
+		want       int
+	}{
+		{"ipv4 allowed", nil, allow, "203.0.113.7:4000", nil, http.StatusOK},
+		{"ipv4 not allowed", nil, allow, "192.0.2.1:4000", nil, http.StatusForbidden},
+		{"ipv6 allowed", nil, allow, "[2001:db8::1]:4000", nil, http.StatusOK},
+		{"ipv6 not allowed", nil, allow, "[2001:db9::1]:4000", nil, http.StatusForbidden},
+		{"ipv4 denied", nil, deny, "198.51.100.9:4000", nil, http.StatusForbidden},
+		{"ipv4 not denied", nil, deny, "192.0.2.1:4000", nil, http.StatusOK},
+		{"forwarded header ignored without trusted proxies", nil, allow, "192.0.2.1:4000",
+			map[string]string{"X-Forwarded-For": "203.0.113.7", "X-Real-IP": "203.0.113.7"}, http.StatusForbidden},
+		{"forwarded header ignored from untrusted peer", proxies, allow, "192.0.2.1:4000",
+			map[string]string{"X-Forwarded-For": "203.0.113.7"}, http.StatusForbidden},
+		{"client from trusted proxy", proxies, allow, "10.0.0.2:4000",
+			map[string]string{"X-Forwarded-For": "203.0.113.7"}, http.StatusOK},
+		{"trusted private hops in the chain are skipped", proxies, allow, "10.0.0.2:4000",
+			map[string]string{"X-Forwarded-For": "203.0.113.7, 10.1.2.3"}, http.StatusOK},
+		{"address prepended by the client is not used", proxies, allow, "10.0.0.2:4000",
+			map[string]string{"X-Forwarded-For": "203.0.113.7, 192.0.2.1"}, http.StatusForbidden},
+		{"denied client cannot hide behind a prepended address", proxies, deny, "10.0.0.2:4000",
+			map[string]string{"X-Forwarded-For": "192.0.2.1, 198.51.100.9"}, http.StatusForbidden},
+		{"x-real-ip from trusted proxy", proxies, allow, "10.0.0.2:4000",
+			map[string]string{"X-Real-IP": "2001:db8::5"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newFilteredHandler(t, tt.trusted, tt.cfg)
+			if got := serveFiltered(t, h, tt.remoteAddr, tt.headers); got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIPFilterUpdateCIDRs(t *testing.T) {
+	h, f := newFilteredHandler(t, nil, IPFilterConfig{Mode: "allow", CIDRs: []string{"203.0.113.0/24"}})
+	if got := serveFiltered(t, h, "192.0.2.1:4000", nil); got != http.StatusForbidden {
+		t.Fatalf("before update: status = %d, want 403", got)
+	}
+	if err := f.UpdateCIDRs([]string{"192.0.2.0/24"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := serveFiltered(t, h, "192.0.2.1:4000", nil); got != http.StatusOK {
+		t.Errorf("after update: status = %d, want 200", got)
+	}
+	if err := f.UpdateCIDRs([]string{"not-a-cidr"}); err == nil {
+		t.Error("UpdateCIDRs accepted an invalid CIDR")
+	}
+	if got := serveFiltered(t, h, "192.0.2.1:4000", nil); got != http.StatusOK {
+		t.Errorf("failed update changed the filter: status = %d, want 200", got)
+	}
+}
+
+func TestNewRealIPMiddlewareRejectsInvalidCIDR(t *testing.T) {
+	if _, err := NewRealIPMiddleware([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("want error for invalid CIDR")
+	}
+}
@@ -0,0 +1,86 @@
+package security
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// NewRealIPMiddleware replaces r.RemoteAddr with the client address from
+// X-Forwarded-For or X-Real-IP, but only when the connection comes from one
+// of the trusted proxy networks. Anyone else could put any address in those
+// headers, so without trusted proxies they are ignored and RemoteAddr stays
+// the socket peer.
+//
+// X-Forwarded-For is read from the right, skipping trusted proxies: the first
+// other address is the one the outermost trusted proxy saw. Entries further
+// left were supplied by the client and are never used.
+func NewRealIPMiddleware(trustedProxies []string) (func(http.Handler) http.Handler, error) {
+	trusted, err := parseCIDRs(trustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("trusted proxies: %w", err)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(trusted) > 0 && containsIP(trusted, hostIP(r.RemoteAddr)) {
+				if ip := forwardedClient(r, trusted); ip != nil {
+					r.RemoteAddr = ip.String()
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+func forwardedClient(r *http.Request, trusted []*net.IPNet) net.IP {
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		hops := strings.Split(strings.Join(xff, ","), ",")
+		var client net.IP
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(hops[i]))
+			if ip == nil {
+				break // garbage from here on was not written by a trusted proxy
+			}
+			client = ip
+			if !containsIP(trusted, ip) {
+				break
+			}
+		}
+		return client
+	}
+	return net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP")))
+}
+
+// hostIP parses the address part of a host:port or bare address
+func hostIP(addr string) net.IP {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	return net.ParseIP(host)
+}
+
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, c := range cidrs {
+		_, n, err := net.ParseCIDR(strings.TrimSpace(c))
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,14 @@
+package security
+
+import "crypto/tls"
+
+// NewTLSConfig returns the server TLS config: TLS 1.2 or later, certificates
+// from getCertificate, and in FIPS mode only approved cipher suites and curves
+func NewTLSConfig(getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)) *tls.Config {
+	return &tls.Config{
+		MinVersion:       tls.VersionTLS12,
+		CipherSuites:     tlsCipherSuites,
+		CurvePreferences: tlsCurves,
+		GetCertificate:   getCertificate,
+	}
+}
@@ -0,0 +1,43 @@
+package security
+
+import (
+	"crypto/tls"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Run with and without -tags fips: the offered TLS 1.2 suites must differ
+func TestNewTLSConfigCipherSuites(t *testing.T) {
+	cfg := NewTLSConfig(nil)
+	if cfg.MinVersion != tls.VersionTLS12 {
+		t.Errorf("MinVersion = %x", cfg.MinVersion)
+	}
+
+	suites := cfg.CipherSuites
+	if suites == nil {
+		for _, s := range tls.CipherSuites() {
+			suites = append(suites, s.ID)
+		}
+	}
+	hasChaCha := false
+	for _, id := range suites {
+		name := tls.CipherSuiteName(id)
+		if strings.Contains(name, "CHACHA20") {
+			hasChaCha = true
+		}
+		if IsFIPSMode() && (!strings.HasPrefix(name, "TLS_ECDHE_") || !strings.Contains(name, "_GCM_")) {
+			t.Errorf("FIPS mode offers %s", name)
+		}
+	}
+	if hasChaCha == IsFIPSMode() {
+		t.Errorf("FIPS mode %v, ChaCha20 offered %v; suites %v", IsFIPSMode(), hasChaCha, suites)
+	}
+
+	if IsFIPSMode() && !slices.Equal(cfg.CurvePreferences, []tls.CurveID{tls.CurveP256, tls.CurveP384}) {
+		t.Errorf("FIPS curves = %v", cfg.CurvePreferences)
+	}
+	if !IsFIPSMode() && cfg.CurvePreferences != nil {
+		t.Errorf("curves = %v, want the crypto/tls defaults", cfg.CurvePreferences)
+	}
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/appconfigdata"
+	"github.com/ghodss/yaml"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// AppConfigConfig selects the AWS AppConfig profile polled for configuration
+type AppConfigConfig struct {
+	Enabled         bool          `mapstructure:"enabled"`
+	AppID           string        `mapstructure:"app_id"`
+	ConfigProfileID string        `mapstructure:"config_profile_id"`
+	EnvironmentID   string        `mapstructure:"environment_id"`
+	PollInterval    time.Duration `mapstructure:"poll_interval"` // AppConfig enforces at least 15s
+}
+
+// sessionTokenTTL is when a poll token is replaced by a new session; AppConfig
+// tokens expire after 24 hours
+const sessionTokenTTL = 23 * time.Hour
+
+// appConfigAPI is the part of the appconfigdata client the watcher uses
+type appConfigAPI interface {
+	StartConfigurationSession(ctx context.Context, in *appconfigdata.StartConfigurationSessionInput, optFns ...func(*appconfigdata.Options)) (*appconfigdata.StartConfigurationSessionOutput, error)
+	GetLatestConfiguration(ctx context.Context, in *appconfigdata.GetLatestConfigurationInput, optFns ...func(*appconfigdata.Options)) (*appconfigdata.GetLatestConfigurationOutput, error)
+}
+
+// NewAppConfigWatcher polls AWS AppConfig and calls onUpdate with the
+// effective configuration each time the hosted YAML/JSON document changes,
+// starting with its first fetch. The document is overlaid on the current
+// settings (files, env, flags) and validated like the local config; invalid
+// documents are logged and skipped. Close stops polling.
+func NewAppConfigWatcher(cfg AppConfigConfig, onUpdate func(ServerConfig)) (io.Closer, error) {
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("load aws config: %w", err)
+	}
+	return newAppConfigWatcher(appconfigdata.NewFromConfig(awsCfg), cfg, onUpdate)
+}
+
+func newAppConfigWatcher(client appConfigAPI, cfg AppConfigConfig, onUpdate func(ServerConfig)) (*appConfigWatcher, error) {
+	if cfg.AppID == "" || cfg.ConfigProfileID == "" || cfg.EnvironmentID == "" {
+		return nil, errors.New("appconfig: app_id, config_profile_id and environment_id are required")
+	}
+	if cfg.PollInterval < 15*time.Second {
+		cfg.PollInterval = 15 * time.Second
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &appConfigWatcher{client: client, cfg: cfg, onUpdate: onUpdate, cancel: cancel, done: make(chan struct{})}
+	// a bad profile or missing permissions should fail startup, not a later poll
+	if err := w.startSession(ctx); err != nil {
+		cancel()
+		return nil, err
+	}
+	go w.run(ctx)
+	return w, nil
+}
+
+type appConfigWatcher struct {
+	client   appConfigAPI
+	cfg      AppConfigConfig
+	onUpdate func(ServerConfig)
+	cancel   context.CancelFunc
+	done     chan struct{}
+
+	token       *string
+	tokenIssued time.Time
+	last        []byte // last applied document
+}
+
+func (w *appConfigWatcher) startSession(ctx context.Context) error {
+	out, err := w.client.StartConfigurationSession(ctx, &appconfigdata.StartConfigurationSessionInput{
+		ApplicationIdentifier:                aws.String(w.cfg.AppID),
+		ConfigurationProfileIdentifier:       aws.String(w.cfg.ConfigProfileID),
+		EnvironmentIdentifier:                aws.String(w.cfg.EnvironmentID),
+		RequiredMinimumPollIntervalInSeconds: aws.Int32(int32(w.cfg.PollInterval / time.Second)),
+	})
+	if err != nil {
+		return fmt.Errorf("appconfig: start session: %w", err)
+	}
+	w.token, w.tokenIssued = out.InitialConfigurationToken, time.Now()
+	return nil
+}
+
+func (w *appConfigWatcher) run(ctx context.Context) {
+	defer close(w.done)
+	for {
+		wait := w.cfg.PollInterval
+		next, err := w.poll(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			zap.L().Warn("appconfig poll failed", zap.Error(err))
+			w.token = nil // start a new session next time
+		}
+		if next > wait {
+			wait = next
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// poll fetches the latest document and applies it if it changed; it returns
+// the poll interval requested by AppConfig
+func (w *appConfigWatcher) poll(ctx context.Context) (time.Duration, error) {
+	if w.token == nil || time.Since(w.tokenIssued) > sessionTokenTTL {
+		if err := w.startSession(ctx); err != nil {
+			return 0, err
+		}
+	}
+	out, err := w.client.GetLatestConfiguration(ctx, &appconfigdata.GetLatestConfigurationInput{ConfigurationToken: w.token})
+	if err != nil {
+		return 0, fmt.Errorf("appconfig: get latest configuration: %w", err)
+	}
+	w.token, w.tokenIssued = out.NextPollConfigurationToken, time.Now()
+	next := time.Duration(out.NextPollIntervalInSeconds) * time.Second
+
+	// an empty body means "unchanged since the last poll"; a new session
+	// resends the current document, which bytes.Equal filters out
+	if len(out.Configuration) == 0 || bytes.Equal(out.Configuration, w.last) {
+		return next, nil
+	}
+	cfg, err := parseRemoteConfig(out.Configuration)
+	if err != nil {
+		w.last = out.Configuration // do not retry a broken version every poll
+		zap.L().Error("appconfig document rejected", zap.String("version", aws.ToString(out.VersionLabel)), zap.Error(err))
+		return next, nil
+	}
+	w.last = out.Configuration
+	zap.L().Info("appconfig configuration applied", zap.String("version", aws.ToString(out.VersionLabel)))
+	w.onUpdate(cfg)
+	return next, nil
+}
+
+// parseRemoteConfig overlays a YAML or JSON document on a copy of the
+// current settings. A valid document becomes the live settings and is
+// applied again on top of later file reloads.
+func parseRemoteConfig(data []byte) (ServerConfig, error) {
+	var m map[string]interface{}
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return ServerConfig{}, fmt.Errorf("parse appconfig document: %w", err)
+	}
+	viperMu.Lock()
+	defer viperMu.Unlock()
+	v := viper.New()
+	if err := v.MergeConfigMap(liveSettings().AllSettings()); err != nil {
+		return ServerConfig{}, err
+	}
+	if err := v.MergeConfigMap(m); err != nil {
+		return ServerConfig{}, err
+	}
+	cfg, err := loadConfigFrom(v)
+	if err != nil {
+		return ServerConfig{}, err
+	}
+	settings, remoteOverlay = v, m
+	return cfg, nil
+}
+
+// Close stops polling and waits for an in-flight poll to finish
+func (w *appConfigWatcher) Close() error {
+	w.cancel()
+	<-w.done
+	return nil
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/appconfigdata"
+)
+
+// fakeAppConfig returns docs[i] on the i-th GetLatestConfiguration call
+type fakeAppConfig struct {
+	docs     []string
+	calls    int
+	sessions int
+}
+
+func (f *fakeAppConfig) StartConfigurationSession(context.Context, *appconfigdata.StartConfigurationSessionInput, ...func(*appconfigdata.Options)) (*appconfigdata.StartConfigurationSessionOutput, error) {
+	f.sessions++
+	return &appconfigdata.StartConfigurationSessionOutput{InitialConfigurationToken: aws.String("t0")}, nil
+}
+
+func (f *fakeAppConfig) GetLatestConfiguration(_ context.Context, in *appconfigdata.GetLatestConfigurationInput, _ ...func(*appconfigdata.Options)) (*appconfigdata.GetLatestConfigurationOutput, error) {
+	doc := f.docs[f.calls]
+	f.calls++
+	return &appconfigdata.GetLatestConfigurationOutput{
+		Configuration:              []byte(doc),
+		NextPollConfigurationToken: aws.String("t1"),
+		NextPollIntervalInSeconds:  30,
+	}, nil
+}
+
+func TestAppConfigWatcherAppliesChangesOnce(t *testing.T) {
+	useConfigFile(t, "config.yaml", "log_level: info\n")
+	if _, err := ReloadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	client := &fakeAppConfig{docs: []string{
+		"log_level: warn\n",
+		"",                  // unchanged
+		"log_level: warn\n", // resent after a new session
+		"log_level: [",      // broken, skipped
+		"log_level: error\n",
+	}}
+	var applied []string
+	w := &appConfigWatcher{client: client, cfg: AppConfigConfig{AppID: "a", ConfigProfileID: "p", EnvironmentID: "e"},
+		onUpdate: func(cfg ServerConfig) { applied = append(applied, cfg.LogLevel) }}
+
+	for range client.docs {
+		next, err := w.poll(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if next.Seconds() != 30 {
+			t.Errorf("next poll = %s, want 30s", next)
+		}
+	}
+	if len(applied) != 2 || applied[0] != "warn" || applied[1] != "error" {
+		t.Errorf("onUpdate calls = %v, want [warn error]", applied)
+	}
+	if client.sessions != 1 {
+		t.Errorf("sessions = %d, want 1", client.sessions)
+	}
+}
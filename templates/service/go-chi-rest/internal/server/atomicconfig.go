@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// AtomicConfig holds the live ServerConfig. Middleware that supports reloads
+// calls Load on every request instead of capturing a snapshot, so a Store
+// takes effect on the next request without a restart.
+type AtomicConfig struct {
+	v atomic.Value
+}
+
+// NewAtomicConfig returns an AtomicConfig holding cfg
+func NewAtomicConfig(cfg ServerConfig) *AtomicConfig {
+	a := &AtomicConfig{}
+	a.Store(cfg)
+	return a
+}
+
+// Load returns the current configuration
+func (a *AtomicConfig) Load() ServerConfig {
+	return a.v.Load().(ServerConfig)
+}
+
+// Store replaces the configuration seen by subsequent requests
+func (a *AtomicConfig) Store(cfg ServerConfig) {
+	a.v.Store(cfg)
+}
+
+// viperMu guards settings and remoteOverlay. Viper instances are not safe
+// for concurrent use: after startup the global one is only read, and reloads
+// build a private instance that replaces settings once it validates.
+var (
+	viperMu       sync.Mutex
+	settings      *viper.Viper   // the live settings; nil means the global viper
+	remoteOverlay map[string]any // last applied AppConfig document
+)
+
+func liveSettings() *viper.Viper {
+	if settings == nil {
+		return viper.GetViper()
+	}
+	return settings
+}
+
+// AllSettings returns the effective settings of the live configuration
+func AllSettings() map[string]any {
+	viperMu.Lock()
+	defer viperMu.Unlock()
+	return liveSettings().AllSettings()
+}
+
+// ReloadConfig re-reads the layered config files (base, environment and
+// --config) into a fresh viper, applies the last AppConfig document on top
+// and returns the resulting configuration. Environment variables and flags
+// keep their precedence. The live settings only change when it succeeds.
+func ReloadConfig() (ServerConfig, error) {
+	v := viper.New()
+	// what InitConfig found on the global viper before the flags were bound
+	for _, key := range []string{"config", "config-dir", "env"} {
+		v.SetDefault(key, viper.GetString(key))
+	}
+	if err := v.BindPFlags(pflag.CommandLine); err != nil {
+		return ServerConfig{}, err
+	}
+	if err := initViper(v); err != nil {
+		return ServerConfig{}, err
+	}
+
+	viperMu.Lock()
+	defer viperMu.Unlock()
+	if remoteOverlay != nil {
+		if err := v.MergeConfigMap(remoteOverlay); err != nil {
+			return ServerConfig{}, err
+		}
+	}
+	cfg, err := loadConfigFrom(v)
+	if err != nil {
+		return ServerConfig{}, err
+	}
+	settings = v
+	return cfg, nil
+}
+
+// WatchConfigFile calls onChange with the result of ReloadConfig whenever the
+// --config file is written or replaced, including Kubernetes ConfigMap
+// updates that swap a symlink, until ctx is done. Unlike viper.WatchConfig it
+// never writes to the global viper.
+func WatchConfigFile(ctx context.Context, onChange func(ServerConfig, error)) error {
+	file := viper.ConfigFileUsed()
+	if file == "" {
+		return nil
+	}
+	file = filepath.Clean(file)
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	// the directory, so replacing the file does not end the watch
+	if err := w.Add(filepath.Dir(file)); err != nil {
+		w.Close()
+		return err
+	}
+	target, _ := filepath.EvalSymlinks(file)
+	go func() {
+		defer w.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				zap.L().Warn("config file watch error", zap.String("file", file), zap.Error(err))
+			case e, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				current, _ := filepath.EvalSymlinks(file)
+				written := filepath.Clean(e.Name) == file && e.Op&(fsnotify.Write|fsnotify.Create) != 0
+				if !written && (current == "" || current == target) {
+					continue
+				}
+				target = current
+				onChange(ReloadConfig())
+			}
+		}
+	}()
+	return nil
+}
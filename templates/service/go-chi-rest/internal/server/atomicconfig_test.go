@@ -0,0 +1,142 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// useConfigFile points the global viper at a temporary config file the way
+// --config does and restores the package state afterwards
+func useConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	writeFile(t, path, content)
+	viper.Reset()
+	viper.Set("config", path)
+	viper.Set("config-dir", t.TempDir())
+	t.Cleanup(func() {
+		viper.Reset()
+		viperMu.Lock()
+		settings, remoteOverlay = nil, nil
+		viperMu.Unlock()
+	})
+	return path
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloadConfigKeepsConfigType(t *testing.T) {
+	path := useConfigFile(t, "config.json", `{"log_level": "warn"}`)
+	for i, level := range []string{"warn", "error"} {
+		if i > 0 {
+			writeFile(t, path, `{"log_level": "`+level+`"}`)
+		}
+		cfg, err := ReloadConfig()
+		if err != nil {
+			t.Fatalf("reload %d: %v", i, err)
+		}
+		if cfg.LogLevel != level {
+			t.Errorf("reload %d: log_level = %q, want %q", i, cfg.LogLevel, level)
+		}
+	}
+	if got := AllSettings()["log_level"]; got != "error" {
+		t.Errorf("AllSettings log_level = %v, want error", got)
+	}
+}
+
+func TestReloadConfigReappliesAppConfig(t *testing.T) {
+	path := useConfigFile(t, "config.yaml", "log_level: warn\nbind_addr: \":8081\"\n")
+	if _, err := ReloadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseRemoteConfig([]byte("log_level: debug\n")); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, path, "log_level: warn\nbind_addr: \":8082\"\n")
+	cfg, err := ReloadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.LogLevel != "debug" {
+		t.Errorf("log_level = %q, want the AppConfig value debug", cfg.LogLevel)
+	}
+	if cfg.BindAddr != ":8082" {
+		t.Errorf("bind_addr = %q, want the reloaded file value :8082", cfg.BindAddr)
+	}
+}
+
+func TestReloadConfigRejectedKeepsSettings(t *testing.T) {
+	path := useConfigFile(t, "config.yaml", "log_level: warn\n")
+	if _, err := ReloadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, path, "log_level: loud\n")
+	if _, err := ReloadConfig(); err == nil {
+		t.Fatal("invalid log_level accepted")
+	}
+	if got := AllSettings()["log_level"]; got != "warn" {
+		t.Errorf("AllSettings log_level = %v, want the previous warn", got)
+	}
+}
+
+func TestAllSettingsDuringReload(t *testing.T) {
+	useConfigFile(t, "config.yaml", "log_level: warn\n")
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := ReloadConfig(); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			AllSettings()
+		}()
+	}
+	wg.Wait()
+}
+
+// Storing new CORS origins changes the next response of a running router
+func TestReloadableRouterCORS(t *testing.T) {
+	cfg := defaultConfig(t)
+	cfg.CORS.AllowedOrigins = []string{"https://old.example"}
+	live := NewAtomicConfig(cfg)
+	srv := httptest.NewServer(NewReloadableRouter(live))
+	defer srv.Close()
+
+	allowOrigin := func(origin string) string {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/healthz", nil)
+		req.Header.Set("Origin", origin)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.Header.Get("Access-Control-Allow-Origin")
+	}
+	if got := allowOrigin("https://new.example"); got != "" {
+		t.Fatalf("new origin allowed before the reload: %q", got)
+	}
+
+	cfg.CORS.AllowedOrigins = []string{"https://new.example"}
+	live.Store(cfg)
+	if got := allowOrigin("https://new.example"); got != "https://new.example" {
+		t.Errorf("Access-Control-Allow-Origin = %q after Store, want the new origin", got)
+	}
+	if got := allowOrigin("https://old.example"); got != "" {
+		t.Errorf("removed origin still allowed: %q", got)
+	}
+}
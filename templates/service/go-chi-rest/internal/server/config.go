@@ -0,0 +1,490 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/example/go-chi-rest/internal/authn"
+	"github.com/example/go-chi-rest/internal/authz"
+	"github.com/example/go-chi-rest/internal/canary"
+	"github.com/example/go-chi-rest/internal/chaos"
+	"github.com/example/go-chi-rest/internal/concurrency"
+	"github.com/example/go-chi-rest/internal/dataexport"
+	"github.com/example/go-chi-rest/internal/db"
+	"github.com/example/go-chi-rest/internal/debugdump"
+	"github.com/example/go-chi-rest/internal/deprecation"
+	"github.com/example/go-chi-rest/internal/discovery"
+	"github.com/example/go-chi-rest/internal/election"
+	"github.com/example/go-chi-rest/internal/envelope"
+	"github.com/example/go-chi-rest/internal/erasure"
+	"github.com/example/go-chi-rest/internal/experiment"
+	"github.com/example/go-chi-rest/internal/grpcclient"
+	"github.com/example/go-chi-rest/internal/h3"
+	"github.com/example/go-chi-rest/internal/har"
+	"github.com/example/go-chi-rest/internal/health"
+	"github.com/example/go-chi-rest/internal/idempotency"
+	"github.com/example/go-chi-rest/internal/logsink"
+	"github.com/example/go-chi-rest/internal/outbox"
+	"github.com/example/go-chi-rest/internal/ratelimit"
+	"github.com/example/go-chi-rest/internal/reqctx"
+	"github.com/example/go-chi-rest/internal/scheduler"
+	"github.com/example/go-chi-rest/internal/search"
+	"github.com/example/go-chi-rest/internal/security"
+	"github.com/example/go-chi-rest/internal/slo"
+	"github.com/example/go-chi-rest/internal/telemetry"
+	"github.com/example/go-chi-rest/internal/tenant"
+	"github.com/example/go-chi-rest/internal/upload"
+	"github.com/example/go-chi-rest/internal/watchdog"
+	"github.com/example/go-chi-rest/internal/webhook"
+	"github.com/example/go-chi-rest/internal/worker"
+)
+
+//go:generate go run ../../cmd/configdocs -root ../.. -o ../../CONFIG.md
+
+// ServerConfig holds runtime configuration for the server; it is shared by
+// the HTTP server and the Lambda entry point. Field comments end up in
+// CONFIG.md, so regenerate it after changing them.
+type ServerConfig struct {
+	BindAddr        string                            `mapstructure:"bind_addr"`
+	ReadTimeout     time.Duration                     `mapstructure:"read_timeout"`
+	WriteTimeout    time.Duration                     `mapstructure:"write_timeout"`
+	IdleTimeout     time.Duration                     `mapstructure:"idle_timeout"`
+	ShutdownTimeout time.Duration                     `mapstructure:"shutdown_timeout"`
+	EnableMetrics   bool                              `mapstructure:"enable_metrics"`
+	MetricsListen   string                            `mapstructure:"metrics_listen"`
+	LogLevel        string                            `mapstructure:"log_level"`
+	LogOutputs      []string                          `mapstructure:"log_outputs"` // stdout, stderr, file:///path, syslog:///dev/log
+	LogFormat       string                            `mapstructure:"log_format"`  // json | console | colored-console; default json in production, console elsewhere
+	Log             LogConfig                         `mapstructure:"log"`
+	RequestID       reqctx.RequestIDConfig            `mapstructure:"request_id"`
+	RedactKeys      []string                          `mapstructure:"redact_keys"` // config keys containing these are never printed
+	AdminToken      string                            `mapstructure:"admin_token"` // bearer token for /admin/* and /debug/* on the metrics listener; empty disables them
+	PIIFields       []string                          `mapstructure:"pii_fields"`  // query, route and JSON fields masked in logs
+	Consul          discovery.ConsulConfig            `mapstructure:"consul"`
+	Environment     string                            `mapstructure:"environment"`
+	Auth            authn.AuthConfig                  `mapstructure:"auth"` // verifies bearer JWTs on /api/v1; needed by /me routes
+	OPA             authz.OPAConfig                   `mapstructure:"opa"`
+	Upload          upload.UploadConfig               `mapstructure:"upload"`
+	Tracing         telemetry.JaegerConfig            `mapstructure:"tracing"`
+	Worker          worker.PoolConfig                 `mapstructure:"worker"`
+	IPFilter        security.IPFilterConfig           `mapstructure:"ip_filter"`
+	TrustedProxies  []string                          `mapstructure:"trusted_proxies"`  // CIDRs of proxies whose X-Forwarded-For/X-Real-IP are believed
+	InboundWebhooks webhook.Verifiers                 `mapstructure:"inbound_webhooks"` // by name, for webhook.NewWebhookVerifier on your routes
+	Experiments     []experiment.Experiment           `mapstructure:"experiments"`
+	TLSCertFile     string                            `mapstructure:"tls_cert_file"` // deprecated: use tls.cert_file
+	TLSKeyFile      string                            `mapstructure:"tls_key_file"`  // deprecated: use tls.key_file
+	TLS             security.TLSConfig                `mapstructure:"tls"`
+	EnableHTTP2     bool                              `mapstructure:"enable_http2"`
+	HTTP3           h3.HTTP3Config                    `mapstructure:"http3"`             // experimental QUIC listener; needs -tags http3
+	EmbedSwaggerUI  bool                              `mapstructure:"embed_swagger_ui"`  // /swagger/ on the main server, 404 in production
+	CaptureMaxBytes int64                             `mapstructure:"capture_max_bytes"` // response bytes kept for /debug/responses outside production; 0 disables
+	ValidateSchemas bool                              `mapstructure:"validate_schemas"`  // check routes against internal/apischema/schemas; for development
+	Election        election.ElectionConfig           `mapstructure:"election"`
+	Metrics         telemetry.MetricsConfig           `mapstructure:"metrics"`
+	OTelMetrics     telemetry.OTelMetricsConfig       `mapstructure:"otel_metrics"`
+	SLO             slo.SLOConfig                     `mapstructure:"slo"`
+	Backpressure    worker.BackpressureConfig         `mapstructure:"backpressure"`
+	Health          health.HealthConfig               `mapstructure:"health"`
+	RateLimit       ratelimit.RateLimitConfig         `mapstructure:"rate_limit"`
+	Idempotency     idempotency.IdempotencyConfig     `mapstructure:"idempotency"` // replay responses for retried Idempotency-Key requests
+	Deprecations    []deprecation.DeprecationPolicy   `mapstructure:"deprecations"`
+	CORS            security.CORSConfig               `mapstructure:"cors"`                 // reloadable
+	SecurityHeaders security.SecurityHeadersConfig    `mapstructure:"security_headers"`     // reloadable; defaults follow environment
+	Tenants         []tenant.Tenant                   `mapstructure:"tenants"`              // non-empty: /api/v1 is served per subdomain tenant
+	AppConfig       AppConfigConfig                   `mapstructure:"appconfig"`            // hot configuration updates from AWS AppConfig
+	Watchdog        watchdog.WatchdogConfig           `mapstructure:"watchdog"`             // exit when no request completes for 2*interval
+	Search          search.SearchConfig               `mapstructure:"search"`               // GET /api/v1/search when index_path is set
+	DB              db.DBConfig                       `mapstructure:"db"`                   // PostgreSQL primary and read replicas
+	Canary          canary.CanaryConfig               `mapstructure:"canary"`               // /api/v1 traffic split to a canary deployment
+	HAR             har.HARConfig                     `mapstructure:"har"`                  // GET /debug/har outside production
+	Envelope        envelope.ResponseEnvelopeConfig   `mapstructure:"response_envelope"`    // wrap /api/v1 JSON in {data, meta}
+	DataExport      dataexport.ExportConfig           `mapstructure:"data_export"`          // GET /api/v1/me/export
+	Erasure         erasure.ErasureConfig             `mapstructure:"erasure"`              // DELETE /api/v1/me
+	Outbox          outbox.OutboxConfig               `mapstructure:"outbox"`               // transactional outbox poller; needs db
+	Scheduler       scheduler.SchedulerConfig         `mapstructure:"scheduler"`            // cron jobs, see scheduledJobs in main
+	GRPCClient      grpcclient.GRPCClientConfig       `mapstructure:"grpc_client"`          // downstream gRPC service; deadlines follow the request
+	Concurrency     concurrency.AdaptiveLimiterConfig `mapstructure:"adaptive_concurrency"` // latency-driven in-flight limit for /api/v1
+	Faults          chaos.FaultConfig                 `mapstructure:"faults"`               // injected latency and 503s for /api/v1; see POST /admin/faults
+	DebugDumps      debugdump.DumpConfig              `mapstructure:"debug_dumps"`          // heap and goroutine dumps on the metrics listener; see /admin/debug/
+	AuditLog        logsink.AuditConfig               `mapstructure:"audit_log"`            // state-changing admin requests, rotated and purged nightly
+	Checker         *health.HealthChecker             `mapstructure:"-"`                    // probes served by NewRouter; created when nil
+}
+
+// InitConfig initializes viper configuration: file, env, defaults.
+//
+// Precedence, lowest to highest:
+//
+//	defaults → <config-dir>/config.base.yaml → <config-dir>/config.<env>.yaml
+//	→ --config file → APP_* environment variables → flags
+//
+// Base and per-environment files are optional; viper only supports a single
+// config file, so they are merged in with mergeConfigFile.
+func InitConfig() error {
+	return initViper(viper.GetViper())
+}
+
+// initViper layers the environment, the config files and the defaults on v
+func initViper(v *viper.Viper) error {
+	v.SetEnvPrefix("APP")
+	// no key replacer: nested keys keep their dots (APP_DB.PRIMARY_DSN)
+	v.AutomaticEnv()
+
+	if err := mergeConfigFiles(v); err != nil {
+		return err
+	}
+
+	registerDefaults(v)
+
+	// normalize durations: allow strings in config
+	// BindStringToDuration not provided by viper directly; we'll unmarshal later
+
+	return nil
+}
+
+// RegisterDefaults sets the default of every key on the global viper; it is
+// called by InitConfig and by cmd/configdocs
+func RegisterDefaults() {
+	registerDefaults(viper.GetViper())
+}
+
+func registerDefaults(v *viper.Viper) {
+	v.SetDefault("bind_addr", ":8080")
+	v.SetDefault("read_timeout", "5s")
+	v.SetDefault("write_timeout", "10s")
+	v.SetDefault("idle_timeout", "120s")
+	v.SetDefault("shutdown_timeout", "15s")
+	v.SetDefault("enable_metrics", true)
+	v.SetDefault("metrics_listen", ":9090")
+	v.SetDefault("log_level", "info")
+	v.SetDefault("log_outputs", []string{"stdout"})
+	v.SetDefault("enable_http2", true)
+	v.SetDefault("embed_swagger_ui", false)
+	v.SetDefault("capture_max_bytes", 0)
+	v.SetDefault("validate_schemas", false)
+	v.SetDefault("environment", v.GetString("env"))
+	v.SetDefault("opa.enabled", false)
+	v.SetDefault("opa.policy_path", "data.http.authz.allow")
+	v.SetDefault("opa.cache_ttl", "30s")
+	v.SetDefault("opa.cache_size", 10000)
+	v.SetDefault("upload.max_file_size_mb", 10)
+	v.SetDefault("upload.allowed_mime", []string{"image/png", "image/jpeg", "image/gif", "application/pdf"})
+	v.SetDefault("worker.concurrency", 4)
+	v.SetDefault("worker.queue_size", 100)
+	v.SetDefault("admin_token", "")
+	v.SetDefault("redact_keys", []string{"password", "secret", "token", "key", "dsn", "credentials"})
+	v.SetDefault("log.include_query_params", false)
+	v.SetDefault("pii_fields", []string{"email", "phone", "ssn"})
+	v.SetDefault("log.include_user_agent", true)
+	v.SetDefault("log.exclude_paths", []string{"/healthz", "/readyz", "/startupz"})
+	v.SetDefault("request_id.format", "uuid")
+	v.SetDefault("request_id.header_name", "X-Request-ID")
+	v.SetDefault("request_id.trust_incoming", true)
+	v.SetDefault("appconfig.enabled", false)
+	v.SetDefault("appconfig.poll_interval", "60s")
+	v.SetDefault("watchdog.enabled", false)
+	v.SetDefault("watchdog.interval", "30s")
+	v.SetDefault("canary.enabled", false)
+	v.SetDefault("canary.header", "X-Canary")
+	v.SetDefault("canary.value", "always")
+	v.SetDefault("canary.percent", 0)
+	v.SetDefault("har.enabled", false)
+	v.SetDefault("har.file", "")
+	v.SetDefault("response_envelope.enabled", false)
+	v.SetDefault("response_envelope.success_key", "data")
+	v.SetDefault("response_envelope.meta_key", "meta")
+	v.SetDefault("data_export.enabled", false)
+	v.SetDefault("data_export.direct_export_max_records", 10000)
+	v.SetDefault("data_export.result_ttl", "1h")
+	v.SetDefault("erasure.enabled", false)
+	v.SetDefault("erasure.timeout", "30s")
+	v.SetDefault("erasure.event_subject", "users.data_erased")
+	v.SetDefault("outbox.enabled", false)
+	v.SetDefault("outbox.poll_interval", "1s")
+	v.SetDefault("outbox.batch_size", 100)
+	v.SetDefault("outbox.max_attempts", 10)
+	v.SetDefault("outbox.retry_backoff", "1s")
+	v.SetDefault("outbox.max_backoff", "5m")
+	v.SetDefault("scheduler.timezone", "UTC")
+	v.SetDefault("grpc_client.target", "")
+	v.SetDefault("db.autotune.enabled", false)
+	v.SetDefault("db.autotune.adjust_interval", "30s")
+	v.SetDefault("db.autotune.target_acquire_duration", "5ms")
+	v.SetDefault("db.autotune.step_size", 2)
+	v.SetDefault("db.autotune.min_conns", 4)
+	v.SetDefault("db.autotune.max_conns", 50)
+	v.SetDefault("adaptive_concurrency.enabled", false)
+	v.SetDefault("adaptive_concurrency.initial_limit", 100)
+	v.SetDefault("adaptive_concurrency.min_limit", 10)
+	v.SetDefault("adaptive_concurrency.max_limit", 1000)
+	v.SetDefault("adaptive_concurrency.max_wait", "0s")
+	v.SetDefault("adaptive_concurrency.probe_every", 1000)
+	v.SetDefault("auth.jwt.secret", "")
+	v.SetDefault("auth.jwt.public_key_file", "")
+	v.SetDefault("auth.jwt.issuer", "")
+	v.SetDefault("auth.jwt.audience", "")
+	v.SetDefault("auth.jwt.subject_claim", "sub")
+	v.SetDefault("auth.jwt.leeway", "30s")
+	v.SetDefault("faults.enabled", false)
+	v.SetDefault("faults.error_rate", 0)
+	v.SetDefault("faults.latency", "0s")
+	v.SetDefault("faults.latency_rate", 0)
+	v.SetDefault("debug_dumps.dir", "")
+	v.SetDefault("debug_dumps.max_age", "1h")
+	v.SetDefault("audit_log.dir", "")
+	v.SetDefault("audit_log.max_size_mb", 100)
+	v.SetDefault("audit_log.max_age_days", 0)
+	v.SetDefault("audit_log.max_backups", 0)
+	v.SetDefault("audit_log.compress", true)
+	v.SetDefault("audit_log.retention", "2160h")
+	v.SetDefault("http3.enabled", false)
+	v.SetDefault("http3.addr", "")
+	v.SetDefault("http3.tls_cert_file", "")
+	v.SetDefault("http3.tls_key_file", "")
+	v.SetDefault("cors.allowed_methods", []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"})
+	v.SetDefault("cors.allowed_headers", []string{"Accept", "Authorization", "Content-Type", "X-Request-ID"})
+	v.SetDefault("cors.max_age", 600)
+	v.SetDefault("consul.enabled", false)
+	v.SetDefault("consul.addr", "localhost:8500")
+	v.SetDefault("consul.service_name", "go-chi-rest")
+	v.SetDefault("consul.health_check_interval", "10s")
+	v.SetDefault("health.check_timeout", "2s")
+	v.SetDefault("rate_limit.enabled", false)
+	v.SetDefault("rate_limit.redis_addr", "localhost:6379")
+	v.SetDefault("rate_limit.key_prefix", "ratelimit:")
+	v.SetDefault("idempotency.enabled", false)
+	v.SetDefault("idempotency.redis_addr", "localhost:6379")
+	v.SetDefault("idempotency.key_prefix", "idempotency:")
+	v.SetDefault("idempotency.header", "Idempotency-Key")
+	v.SetDefault("idempotency.ttl", "24h")
+	v.SetDefault("idempotency.lock_ttl", "30s")
+	v.SetDefault("idempotency.max_body_bytes", 1024*1024)
+	v.SetDefault("idempotency.max_response_bytes", 1024*1024)
+	v.SetDefault("idempotency.bloom.enabled", false)
+	v.SetDefault("idempotency.bloom.backend", "local")
+	v.SetDefault("idempotency.bloom.capacity", 1000000)
+	v.SetDefault("idempotency.bloom.false_positive_rate", 0.01)
+	v.SetDefault("backpressure.enabled", false)
+	v.SetDefault("backpressure.max_queue_depth", 80)
+	v.SetDefault("backpressure.shed_status", 503)
+	v.SetDefault("backpressure.cpu_load_factor", 0)
+	v.SetDefault("tracing.enabled", false)
+	v.SetDefault("tracing.endpoint", "localhost:4318")
+	v.SetDefault("tracing.insecure", true)
+	v.SetDefault("tracing.service_name", "go-chi-rest")
+	v.SetDefault("tracing.sample_ratio", 1.0)
+	v.SetDefault("election.enabled", false)
+	v.SetDefault("election.redis_addr", "localhost:6379")
+	v.SetDefault("election.key", "go-chi-rest:leader")
+	v.SetDefault("election.ttl", "15s")
+	v.SetDefault("metrics.histogram_buckets", telemetry.DefaultBuckets)
+	v.SetDefault("metrics.native_histograms", false)
+	v.SetDefault("otel_metrics.enabled", false)
+	v.SetDefault("otel_metrics.exporter", "otlp-http")
+	v.SetDefault("otel_metrics.endpoint", "localhost:4318")
+	v.SetDefault("otel_metrics.insecure", true)
+	v.SetDefault("otel_metrics.interval", "30s")
+	v.SetDefault("otel_metrics.service_name", "go-chi-rest")
+	v.SetDefault("slo.enabled", false)
+	v.SetDefault("slo.name", "availability")
+	v.SetDefault("slo.objective", 0.999)
+	v.SetDefault("slo.window", "1h")
+}
+
+// mergeConfigFiles merges the optional base and per-environment files, then
+// the --config file, into v
+func mergeConfigFiles(v *viper.Viper) error {
+	dir := v.GetString("config-dir")
+	for _, name := range []string{"config.base.yaml", "config." + v.GetString("env") + ".yaml"} {
+		if err := mergeConfigFile(v, filepath.Join(dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	// If config file provided, merge it over the layered files
+	if cfgFile := v.GetString("config"); cfgFile != "" {
+		v.SetConfigFile(cfgFile)
+		if err := v.MergeInConfig(); err != nil {
+			return fmt.Errorf("read config file: %w", err)
+		}
+	}
+	return nil
+}
+
+// LoadConfig decodes the settings gathered by InitConfig into a ServerConfig,
+// applies the --metrics-buckets override and defaults, and validates the result
+func LoadConfig() (ServerConfig, error) {
+	return loadConfigFrom(viper.GetViper())
+}
+
+// loadConfigFrom is LoadConfig for an arbitrary viper instance
+func loadConfigFrom(v *viper.Viper) (ServerConfig, error) {
+	var cfg ServerConfig
+	// RFC 3339 strings decode into time.Time fields (deprecation dates)
+	decodeHook := viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		mapstructure.StringToTimeHookFunc(time.RFC3339),
+	))
+	if err := v.Unmarshal(&cfg, decodeHook); err != nil {
+		return cfg, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	if s := v.GetString("metrics-buckets"); s != "" {
+		buckets, err := parseBuckets(s)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid --metrics-buckets: %w", err)
+		}
+		cfg.Metrics.HistogramBuckets = buckets
+	}
+
+	// Set sensible defaults if missing
+	setDefaults(v, &cfg)
+	// security headers follow the environment unless configured
+	if !v.IsSet("security_headers") {
+		cfg.SecurityHeaders = security.DefaultSecurityHeaders(cfg.Environment)
+	}
+
+	// Fail fast on misconfiguration, before anything starts
+	if err := ValidateConfig(cfg); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// parseBuckets parses a comma-separated list of histogram upper bounds
+func parseBuckets(s string) ([]float64, error) {
+	parts := strings.Split(s, ",")
+	buckets := make([]float64, 0, len(parts))
+	for _, p := range parts {
+		b, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, fmt.Errorf("bucket %q: %w", p, err)
+		}
+		buckets = append(buckets, b)
+	}
+	return buckets, nil
+}
+
+// mergeConfigFile overlays a YAML file onto the configuration read so far;
+// keys it does not mention keep their earlier values
+func mergeConfigFile(v *viper.Viper, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var m map[string]interface{}
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := v.MergeConfigMap(m); err != nil {
+		return fmt.Errorf("merge %s: %w", path, err)
+	}
+	return nil
+}
+
+func setDefaults(v *viper.Viper, cfg *ServerConfig) {
+	if cfg.BindAddr == "" {
+		cfg.BindAddr = v.GetString("bind_addr")
+	}
+	if cfg.ReadTimeout == 0 {
+		cfg.ReadTimeout = parseDurationOrDefault(v.GetString("read_timeout"), 5*time.Second)
+	}
+	if cfg.WriteTimeout == 0 {
+		cfg.WriteTimeout = parseDurationOrDefault(v.GetString("write_timeout"), 10*time.Second)
+	}
+	if cfg.IdleTimeout == 0 {
+		cfg.IdleTimeout = parseDurationOrDefault(v.GetString("idle_timeout"), 120*time.Second)
+	}
+	if cfg.ShutdownTimeout == 0 {
+		cfg.ShutdownTimeout = parseDurationOrDefault(v.GetString("shutdown_timeout"), 15*time.Second)
+	}
+	if cfg.MetricsListen == "" {
+		cfg.MetricsListen = v.GetString("metrics_listen")
+	}
+	if cfg.Environment == "" {
+		cfg.Environment = v.GetString("environment")
+	}
+	if cfg.LogLevel == "" {
+		cfg.LogLevel = v.GetString("log_level")
+	}
+	if cfg.TLS.CertFile == "" && cfg.TLS.KeyFile == "" {
+		cfg.TLS.CertFile, cfg.TLS.KeyFile = cfg.TLSCertFile, cfg.TLSKeyFile
+	}
+	if cfg.HTTP3.Addr == "" {
+		cfg.HTTP3.Addr = cfg.BindAddr
+	}
+	if cfg.HTTP3.TLSCertFile == "" && cfg.HTTP3.TLSKeyFile == "" {
+		cfg.HTTP3.TLSCertFile, cfg.HTTP3.TLSKeyFile = cfg.TLS.CertFile, cfg.TLS.KeyFile
+	}
+}
+
+func parseDurationOrDefault(s string, d time.Duration) time.Duration {
+	if s == "" {
+		return d
+	}
+	if dur, err := time.ParseDuration(s); err == nil {
+		return dur
+	}
+	// maybe provided as seconds integer
+	if secs, err := strconv.Atoi(s); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	return d
+}
+
+// SameLogger reports whether a and b configure the same logger, so a reload
+// can keep the current one and its open files
+func SameLogger(a, b ServerConfig) bool {
+	return a.LogLevel == b.LogLevel && a.LogFormat == b.LogFormat &&
+		a.Environment == b.Environment && slices.Equal(a.LogOutputs, b.LogOutputs)
+}
+
+// NewLogger configures zap logger based on config. The returned func closes
+// the file and syslog outputs; call it once the logger is no longer in use.
+func NewLogger(cfg ServerConfig) (*zap.Logger, func() error, error) {
+	var lvl zap.AtomicLevel
+	switch cfg.LogLevel {
+	case "debug":
+		lvl = zap.NewAtomicLevelAt(zap.DebugLevel)
+	case "warn":
+		lvl = zap.NewAtomicLevelAt(zap.WarnLevel)
+	case "error":
+		lvl = zap.NewAtomicLevelAt(zap.ErrorLevel)
+	default:
+		lvl = zap.NewAtomicLevelAt(zap.InfoLevel)
+	}
+
+	cfgZap := zap.Config{
+		Level:         lvl,
+		Development:   cfg.Environment != "production",
+		Encoding:      "json",
+		EncoderConfig: zap.NewProductionEncoderConfig(),
+	}
+
+	if cfg.Environment != "production" {
+		cfgZap.Encoding = "console"
+		enc := zap.NewDevelopmentEncoderConfig()
+		enc.TimeKey = "ts"
+		cfgZap.EncoderConfig = enc
+	}
+	if cfg.LogFormat != "" {
+		cfgZap.Encoding = cfg.LogFormat
+	}
+
+	outputs := cfg.LogOutputs
+	if len(outputs) == 0 {
+		outputs = []string{"stdout"}
+	}
+	return logsink.Build(cfgZap, outputs)
+}
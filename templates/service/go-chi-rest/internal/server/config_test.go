@@ -0,0 +1,64 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestNewLoggerCloser(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	cfg := ServerConfig{Environment: "production", LogLevel: "info", LogOutputs: []string{"file://" + path}}
+	logger, closeFn, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("hello")
+	logger.Sync()
+	if err := closeFn(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if b, err := os.ReadFile(path); err != nil || len(b) == 0 {
+		t.Errorf("log file: %q, %v", b, err)
+	}
+}
+
+func TestSameLogger(t *testing.T) {
+	base := ServerConfig{Environment: "production", LogLevel: "info", LogFormat: "json", LogOutputs: []string{"stdout"}}
+	tests := []struct {
+		name   string
+		change func(*ServerConfig)
+		same   bool
+	}{
+		{"unrelated setting", func(c *ServerConfig) { c.BindAddr = ":9090" }, true},
+		{"level", func(c *ServerConfig) { c.LogLevel = "debug" }, false},
+		{"format", func(c *ServerConfig) { c.LogFormat = "console" }, false},
+		{"outputs", func(c *ServerConfig) { c.LogOutputs = []string{"stdout", "stderr"} }, false},
+		{"environment", func(c *ServerConfig) { c.Environment = "staging" }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := base
+			updated.LogOutputs = append([]string(nil), base.LogOutputs...)
+			tt.change(&updated)
+			if got := SameLogger(base, updated); got != tt.same {
+				t.Errorf("SameLogger = %v, want %v", got, tt.same)
+			}
+		})
+	}
+}
+
+func TestOTelEndpointDefaultsMatch(t *testing.T) {
+	v := viper.New()
+	registerDefaults(v)
+	cfg, err := loadConfigFrom(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// OTLP/HTTP listens on 4318, gRPC on 4317
+	if m := cfg.OTelMetrics; m.Exporter != "otlp-http" || m.Endpoint != "localhost:4318" {
+		t.Errorf("otel_metrics = %s %s, want otlp-http localhost:4318", m.Exporter, m.Endpoint)
+	}
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/example/go-chi-rest/internal/authn"
+	"github.com/example/go-chi-rest/internal/pii"
+	"github.com/example/go-chi-rest/internal/reqctx"
+	"github.com/example/go-chi-rest/internal/watchdog"
+)
+
+// zapLoggerMiddleware returns a chi middleware that logs requests with zap;
+// query parameters and route parameters named in piiFields are masked
+func zapLoggerMiddleware(cfg LogConfig, piiFields []string) func(next http.Handler) http.Handler {
+	excluded := make(map[string]bool, len(cfg.ExcludePaths))
+	for _, p := range cfg.ExcludePaths {
+		excluded[p] = true
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// heartbeat once the handler returns: a stuck handler never kicks
+			defer watchdog.Kick()
+			if excluded[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+			// request_id and correlation_id are already on the request logger
+			logger := reqctx.LoggerFromContext(r.Context())
+			// the authenticator runs inside /api/v1 and reports the subject back here
+			ctx, subject := authn.TrackSubject(r.Context())
+			r = r.WithContext(ctx)
+			start := time.Now()
+			body := &countingReader{ReadCloser: r.Body}
+			if r.Body != nil {
+				r.Body = body
+			}
+			ww := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(ww, r)
+			path := r.URL.Path
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				path = pii.MaskPathParams(path, rctx.URLParams.Keys, rctx.URLParams.Values, piiFields)
+			}
+			fields := []zap.Field{
+				zap.String("method", r.Method),
+				zap.String("path", path),
+				zap.Int("status", ww.status),
+				zap.Duration("duration", time.Since(start)),
+				zap.String("remote", r.RemoteAddr),
+				zap.Int64("bytes_read", body.n),
+				zap.Int64("bytes_written", ww.bytesWritten),
+			}
+			if sub := subject(); sub != "" {
+				fields = append(fields, zap.String("user_id", sub))
+			}
+			if cfg.IncludeQueryParams && r.URL.RawQuery != "" {
+				fields = append(fields, zap.String("query", pii.MaskQuery(r.URL.RawQuery, piiFields)))
+			}
+			if cfg.IncludeUserAgent {
+				fields = append(fields, zap.String("user_agent", r.UserAgent()))
+			}
+			if ref := r.Referer(); ref != "" {
+				fields = append(fields, zap.String("referer", ref))
+			}
+			// correlate log lines with traces (e.g. Loki -> Jaeger)
+			if sc := trace.SpanFromContext(r.Context()).SpanContext(); sc.IsValid() {
+				fields = append(fields,
+					zap.String("trace_id", sc.TraceID().String()),
+					zap.String("span_id", sc.SpanID().String()),
+				)
+			}
+			logger.Info("request", fields...)
+		})
+	}
+}
+
+// LogConfig controls the per-request access log
+type LogConfig struct {
+	IncludeQueryParams bool     `mapstructure:"include_query_params"` // may contain tokens or PII; off by default
+	IncludeUserAgent   bool     `mapstructure:"include_user_agent"`
+	ExcludePaths       []string `mapstructure:"exclude_paths"` // exact paths never logged, e.g. /healthz
+}
+
+// responseWriter wraps http.ResponseWriter to capture status code and body size
+type responseWriter struct {
+	http.ResponseWriter
+	status       int
+	bytesWritten int64
+}
+
+func (rw *responseWriter) WriteHeader(code int) {
+	rw.status = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytesWritten += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// countingReader counts the request body bytes consumed by handlers
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += int64(n)
+	return n, err
+}
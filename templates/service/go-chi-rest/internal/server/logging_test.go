@@ -0,0 +1,125 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/example/go-chi-rest/internal/authn"
+	"github.com/example/go-chi-rest/internal/reqctx"
+)
+
+func TestZapLoggerMiddleware(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	defer zap.ReplaceGlobals(zap.New(core))()
+
+	h := reqctx.NewContextEnrichmentMiddleware()(zapLoggerMiddleware(
+		LogConfig{IncludeQueryParams: true, IncludeUserAgent: true, ExcludePaths: []string{"/healthz"}},
+		[]string{"email"},
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("flush through the logging writer: %v", err)
+		}
+		io.WriteString(w, "hello")
+	})))
+
+	req := httptest.NewRequest(http.MethodPost, "/orders?email=a@example.com&page=2", strings.NewReader("12345678"))
+	req.Header.Set("User-Agent", "test-agent")
+	req.Header.Set("Referer", "https://example.com/")
+	req.Header.Set(reqctx.UserIDHeader, "alice")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	entries := logs.FilterMessage("request").All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d log lines, want 1 (excluded path must not log)", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	b, _ := json.Marshal(fields)
+	want := map[string]any{
+		"method":        "POST",
+		"path":          "/orders",
+		"status":        int64(http.StatusOK),
+		"bytes_read":    int64(8),
+		"bytes_written": int64(5),
+		"user_agent":    "test-agent",
+		"referer":       "https://example.com/",
+		// the header is unverified and never becomes user_id
+		"client_user_id": "alice",
+	}
+	for k, v := range want {
+		if fields[k] != v {
+			t.Errorf("%s = %v, want %v (%s)", k, fields[k], v, b)
+		}
+	}
+	if _, ok := fields["user_id"]; ok {
+		t.Errorf("anonymous request logged user_id: %s", b)
+	}
+	if fields["request_id"] == "" || fields["request_id"] == nil {
+		t.Errorf("request_id missing: %s", b)
+	}
+	if q, _ := fields["query"].(string); !strings.Contains(q, "page=2") || strings.Contains(q, "a@example.com") {
+		t.Errorf("query = %q, want email masked", q)
+	}
+}
+
+func TestZapLoggerMiddlewareLogsSubject(t *testing.T) {
+	// ES256, so the test also runs in FIPS mode
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "jwt.pub")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	authenticator, err := authn.NewAuthenticator(authn.AuthConfig{JWT: authn.JWTConfig{PublicKeyFile: keyFile}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	defer zap.ReplaceGlobals(zap.New(core))()
+	// the authenticator sits inside the logger, as on /api/v1
+	h := reqctx.NewContextEnrichmentMiddleware()(zapLoggerMiddleware(LogConfig{}, nil)(
+		authenticator.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/orders", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set(reqctx.UserIDHeader, "admin")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := logs.FilterMessage("request").All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d log lines, want 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["user_id"] != "user-1" || fields["client_user_id"] != "admin" {
+		t.Errorf("user_id = %v, client_user_id = %v; want the token subject and the forged header apart", fields["user_id"], fields["client_user_id"])
+	}
+}
@@ -0,0 +1,266 @@
+// Package server holds what the HTTP server and the Lambda entry point share:
+// configuration loading, the logger and the chi router with its middleware chain
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/example/go-chi-rest/internal/apidocs"
+	"github.com/example/go-chi-rest/internal/apischema"
+	"github.com/example/go-chi-rest/internal/authn"
+	"github.com/example/go-chi-rest/internal/authz"
+	"github.com/example/go-chi-rest/internal/canary"
+	"github.com/example/go-chi-rest/internal/chaos"
+	"github.com/example/go-chi-rest/internal/concurrency"
+	"github.com/example/go-chi-rest/internal/dataexport"
+	"github.com/example/go-chi-rest/internal/db"
+	"github.com/example/go-chi-rest/internal/debugcapture"
+	"github.com/example/go-chi-rest/internal/deprecation"
+	"github.com/example/go-chi-rest/internal/envelope"
+	"github.com/example/go-chi-rest/internal/erasure"
+	"github.com/example/go-chi-rest/internal/experiment"
+	"github.com/example/go-chi-rest/internal/grpcclient"
+	"github.com/example/go-chi-rest/internal/har"
+	"github.com/example/go-chi-rest/internal/health"
+	"github.com/example/go-chi-rest/internal/idempotency"
+	"github.com/example/go-chi-rest/internal/jsonutil"
+	"github.com/example/go-chi-rest/internal/negotiate"
+	"github.com/example/go-chi-rest/internal/ratelimit"
+	"github.com/example/go-chi-rest/internal/reqctx"
+	"github.com/example/go-chi-rest/internal/search"
+	"github.com/example/go-chi-rest/internal/security"
+	"github.com/example/go-chi-rest/internal/slo"
+	"github.com/example/go-chi-rest/internal/telemetry"
+	"github.com/example/go-chi-rest/internal/tenant"
+	"github.com/example/go-chi-rest/internal/upload"
+	"github.com/example/go-chi-rest/internal/worker"
+)
+
+// pong is the /api/v1/ping body, built once
+var pong = map[string]string{"message": "pong"}
+
+// NewRouter builds the main router: middleware chain, probes and /api/v1.
+// It registers the request metrics, so call it once per process.
+// Misconfiguration is fatal, as in the rest of startup.
+func NewRouter(cfg ServerConfig) *chi.Mux {
+	return NewReloadableRouter(NewAtomicConfig(cfg))
+}
+
+// NewReloadableRouter is NewRouter for a live configuration: CORS, security
+// headers and rate limit rules are read from live on every request. Everything
+// else, including which middleware is mounted, is fixed at construction.
+func NewReloadableRouter(live *AtomicConfig) *chi.Mux {
+	cfg := live.Load()
+	checker := cfg.Checker
+	if checker == nil {
+		checker = health.NewHealthChecker(cfg.Health)
+		checker.MarkStarted()
+	}
+
+	r := chi.NewRouter()
+	r.Use(reqctx.NewRequestIDMiddleware(cfg.RequestID))
+	// request/correlation/tenant/user IDs in context and on every request log line
+	r.Use(reqctx.NewContextEnrichmentMiddleware())
+	// Istio/B3/W3C trace headers for outbound calls, see reqctx.InjectIstioHeaders
+	r.Use(reqctx.NewIstioHeaderPropagator())
+	// client address from proxy headers, only for connections from trusted_proxies
+	realIP, err := security.NewRealIPMiddleware(cfg.TrustedProxies)
+	if err != nil {
+		zap.L().Fatal("invalid trusted proxies", zap.Error(err))
+	}
+	r.Use(realIP)
+	r.Use(middleware.Recoverer)
+	r.Use(security.NewReloadableSecurityHeadersMiddleware(func() security.SecurityHeadersConfig { return live.Load().SecurityHeaders }))
+	if cfg.IPFilter.Mode != "" {
+		// main builds the filter so /admin/ip-filter can update it
+		ipFilter := cfg.IPFilter.Filter
+		if ipFilter == nil {
+			if ipFilter, err = security.NewIPFilter(cfg.IPFilter); err != nil {
+				zap.L().Fatal("invalid ip filter config", zap.Error(err))
+			}
+		}
+		r.Use(ipFilter.Middleware)
+	}
+	if cfg.Tracing.Enabled {
+		r.Use(telemetry.Middleware("http.server"))
+	}
+	requestDuration, err := telemetry.NewRequestDuration(cfg.Metrics)
+	if err != nil {
+		zap.L().Fatal("metrics init failed", zap.Error(err))
+	}
+	r.Use(telemetry.RequestMetrics(requestDuration))
+	if cfg.SLO.Enabled {
+		tracker := slo.NewSLOTracker(cfg.SLO)
+		// the tracker lives as long as the router, i.e. the process
+		go tracker.Run(context.Background())
+		r.Use(slo.TrackSLO(tracker))
+	}
+	if cfg.Environment != "production" && cfg.CaptureMaxBytes > 0 {
+		// bodies for GET /debug/responses on the metrics listener
+		r.Use(debugcapture.NewBodyCaptureMiddleware(cfg.CaptureMaxBytes))
+	}
+	if cfg.Environment != "production" && cfg.HAR.Enabled {
+		// entries for GET /debug/har on the metrics listener
+		store := cfg.HAR.Store
+		if store == nil {
+			store = har.DefaultStore
+		}
+		r.Use(har.NewHARRecorder(store))
+	}
+	// Custom logging middleware using zap
+	r.Use(zapLoggerMiddleware(cfg.Log, cfg.PIIFields))
+	// no-op until cors.allowed_origins is set
+	r.Use(security.NewCORSMiddleware(func() security.CORSConfig { return live.Load().CORS }))
+
+	// Routes
+	r.Get("/healthz", checker.Liveness)
+	r.Get("/readyz", checker.Readiness)
+	r.Get("/startupz", checker.Startup)
+	if cfg.EmbedSwaggerUI {
+		r.Get("/swagger", http.RedirectHandler("/swagger/", http.StatusMovedPermanently).ServeHTTP)
+		r.Handle("/swagger/*", http.StripPrefix("/swagger/", apidocs.NewSwaggerUIHandler(cfg.Environment)))
+	}
+
+	r.Route("/api/v1", func(r chi.Router) {
+		if cfg.Canary.Enabled {
+			// first, so the canary applies its own middleware to what it serves
+			r.Use(canary.NewCanaryMiddleware(cfg.Canary))
+		}
+		if cfg.Envelope.Enabled {
+			// early, so errors from the middleware below are wrapped too
+			r.Use(envelope.NewResponseEnvelopeMiddleware(cfg.Envelope))
+		}
+		if cfg.Auth.Configured() {
+			// before authorization, rate limits and idempotency, which key on the principal
+			authenticator, err := authn.NewAuthenticator(cfg.Auth)
+			if err != nil {
+				zap.L().Fatal("authentication init failed", zap.Error(err))
+			}
+			r.Use(authenticator.Middleware)
+		}
+		if cfg.Faults.Enabled || (cfg.EnableMetrics && cfg.AdminToken != "") {
+			// after the envelope, so injected 503s look like real ones; also
+			// mounted with the admin API, whose POST /admin/faults turns faults on
+			r.Use(chaos.NewFaultInjector(cfg.Faults))
+		}
+		if len(cfg.Deprecations) > 0 {
+			r.Use(deprecation.NewDeprecationMiddleware(cfg.Deprecations))
+		}
+		if cfg.OPA.Enabled {
+			r.Use(authz.NewOPAMiddleware(cfg.OPA))
+		}
+		if cfg.RateLimit.Enabled {
+			limiter, err := ratelimit.NewSlidingWindowRateLimiter(redis.NewClient(&redis.Options{Addr: cfg.RateLimit.RedisAddr}), cfg.RateLimit)
+			if err != nil {
+				zap.L().Fatal("invalid rate limit config", zap.Error(err))
+			}
+			// rules and the enabled switch reload; redis_addr needs a restart
+			r.Use(limiter.ReloadableMiddleware(func() ratelimit.RateLimitConfig { return live.Load().RateLimit }))
+		}
+		if cfg.Idempotency.Enabled {
+			rdb := redis.NewClient(&redis.Options{Addr: cfg.Idempotency.RedisAddr})
+			var frontend *idempotency.BloomIdempotencyFrontend
+			if cfg.Idempotency.Bloom.Enabled {
+				if frontend, err = idempotency.NewBloomIdempotencyFrontend(context.Background(), rdb, cfg.Idempotency.Bloom); err != nil {
+					zap.L().Fatal("idempotency bloom filter init failed", zap.Error(err))
+				}
+			}
+			r.Use(idempotency.NewIdempotencyMiddleware(rdb, cfg.Idempotency, frontend))
+		}
+		if cfg.Concurrency.Enabled {
+			r.Use(concurrency.NewAdaptiveLimiter(cfg.Concurrency))
+		}
+		if cfg.Backpressure.Enabled && cfg.Backpressure.Pool != nil {
+			r.Use(worker.NewBackpressureMiddleware(cfg.Backpressure))
+		}
+		if len(cfg.Experiments) > 0 && cfg.Auth.Configured() {
+			// authenticated users keep their cohort across devices and sessions
+			r.Use(experiment.NewUserIDMiddleware(authn.Subject))
+		}
+		r.Use(experiment.NewABMiddleware(cfg.Experiments))
+		r.Use(negotiate.NewContentNegotiationMiddleware())
+		if cfg.DB.Pool != nil || cfg.DB.PrimaryDSN != "" {
+			pool := cfg.DB.Pool
+			if pool == nil {
+				var err error
+				if pool, err = db.Open(context.Background(), cfg.DB); err != nil {
+					zap.L().Fatal("failed to open database pools", zap.Error(err))
+				}
+			}
+			// handlers get it via db.FromContext; writes read from the primary
+			r.Use(db.DBMiddleware(pool))
+		}
+		if cfg.GRPCClient.Conn != nil || cfg.GRPCClient.Target != "" {
+			conn := cfg.GRPCClient.Conn
+			if conn == nil {
+				var err error
+				if conn, err = grpcclient.Dial(cfg.GRPCClient); err != nil {
+					zap.L().Fatal("failed to create grpc client", zap.Error(err))
+				}
+			}
+			// handlers get it via grpcclient.ConnFromContext and bound calls
+			// with grpcclient.NewGRPCCallContext
+			r.Use(grpcclient.GRPCClientMiddleware(conn))
+		}
+		if len(cfg.Tenants) > 0 {
+			// last, so tenant-specific routers still pass auth and rate limits
+			r.Use(tenant.NewTenantRouter(tenant.NewMapTenantStore(cfg.Tenants)))
+		}
+		if cfg.EmbedSwaggerUI {
+			r.With(envelope.DisableEnvelope).Get("/openapi.json", apidocs.SpecHandler)
+		}
+		ping := r
+		if cfg.ValidateSchemas {
+			ping = r.With(apischema.ValidateSchema("", "schemas/ping.response.json"))
+		}
+		ping.Get("/ping", func(w http.ResponseWriter, r *http.Request) {
+			// hot path: pooled encoder for JSON, the negotiated codec otherwise
+			if accepted := negotiate.AcceptedType(r.Context()); accepted != negotiate.JSON {
+				negotiate.WriteResponse(w, http.StatusOK, pong, accepted)
+				return
+			}
+			jsonutil.WriteJSONFast(w, http.StatusOK, pong)
+		})
+		// sample upload route; requires upload.s3.bucket to be configured
+		if cfg.Upload.S3.Bucket != "" {
+			backend, err := upload.NewS3Backend(context.Background(), cfg.Upload.S3)
+			if err != nil {
+				zap.L().Fatal("failed to init upload storage", zap.Error(err))
+			}
+			cfg.Upload.StorageBackend = backend
+			r.Post("/uploads", upload.NewUploadHandler(cfg.Upload))
+		}
+		// full-text search; index documents through cfg.Search.Index. main
+		// opens the index and closes it on shutdown; without it the index
+		// opened here lives as long as the process
+		if cfg.Search.IndexPath != "" || cfg.Search.Index != nil {
+			idx := cfg.Search.Index
+			if idx == nil {
+				var err error
+				if idx, err = search.Open(cfg.Search.IndexPath); err != nil {
+					zap.L().Fatal("failed to open search index", zap.Error(err))
+				}
+			}
+			r.Get("/search", search.NewSearchHandler(idx))
+		}
+		// GDPR export of the caller's data; register sources in data_export.Sources
+		if cfg.DataExport.Enabled {
+			export := dataexport.NewExportHandler(cfg.DataExport)
+			r.Get("/me/export", export.Export)
+			r.Get("/me/export/{id}", export.Status)
+		}
+		// GDPR erasure of the caller's data; register erasers in erasure.Erasers
+		if cfg.Erasure.Enabled {
+			r.Delete("/me", erasure.NewErasureHandler(cfg.Erasure))
+		}
+		// register other handlers here
+	})
+
+	return r
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/example/go-chi-rest/internal/security"
+	"github.com/example/go-chi-rest/internal/testutil"
+)
+
+func TestPingSLA(t *testing.T) {
+	if testing.Short() {
+		t.Skip("latency assertion skipped in -short mode")
+	}
+	r := NewRouter(defaultConfig(t))
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/ping", nil)
+	testutil.AssertResponseTime(t, r, req, 100*time.Millisecond) // warm up
+	testutil.AssertPercentile(t, r, req, 1000, 99, time.Millisecond)
+}
+
+func BenchmarkPing(b *testing.B) {
+	testutil.SLABenchmark(b, NewRouter(defaultConfig(b)), httptest.NewRequest(http.MethodGet, "/api/v1/ping", nil))
+}
+
+func TestRouterIPFilterUpdate(t *testing.T) {
+	cfg := defaultConfig(t)
+	cfg.IPFilter = security.IPFilterConfig{Mode: "deny", CIDRs: []string{"198.51.100.0/24"}}
+	filter, err := security.NewIPFilter(cfg.IPFilter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.IPFilter.Filter = filter
+	r := NewRouter(cfg)
+	ping := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/ping", nil)
+		req.RemoteAddr = "203.0.113.7:4000"
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := ping(); code != http.StatusOK {
+		t.Fatalf("before update: status = %d, want 200", code)
+	}
+
+	rec := httptest.NewRecorder()
+	filter.AdminHandler()(rec, httptest.NewRequest(http.MethodPost, "/admin/ip-filter", strings.NewReader(`{"cidrs": ["203.0.113.0/24"]}`)))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "203.0.113.0/24") {
+		t.Fatalf("update = %d %s", rec.Code, rec.Body)
+	}
+	if code := ping(); code != http.StatusForbidden {
+		t.Errorf("after update: status = %d, want 403", code)
+	}
+
+	rec = httptest.NewRecorder()
+	filter.AdminHandler()(rec, httptest.NewRequest(http.MethodPost, "/admin/ip-filter", strings.NewReader(`{"cidrs": ["not-a-cidr"]}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid update: status = %d, want 400", rec.Code)
+	}
+	if code := ping(); code != http.StatusForbidden {
+		t.Errorf("after invalid update: status = %d, want the previous networks kept", code)
+	}
+}
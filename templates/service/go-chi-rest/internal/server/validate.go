@@ -0,0 +1,151 @@
+package server
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/example/go-chi-rest/internal/reqctx"
+)
+
+//go:embed config.schema.json
+var configSchema []byte
+
+// configDocument is the part of ServerConfig checked by config.schema.json;
+// durations are expressed in seconds so the schema bounds stay readable
+type configDocument struct {
+	BindAddr        string  `json:"bind_addr"`
+	MetricsListen   string  `json:"metrics_listen"`
+	EnableMetrics   bool    `json:"enable_metrics"`
+	LogLevel        string  `json:"log_level"`
+	ShutdownTimeout float64 `json:"shutdown_timeout"`
+}
+
+// violationMessages replaces the schema library's generic wording for known fields
+var violationMessages = map[string]string{
+	"bind_addr":        "must be host:port, e.g. :8080 or 127.0.0.1:8080",
+	"metrics_listen":   "must be host:port, e.g. :9090",
+	"log_level":        "must be one of debug, info, warn, error",
+	"shutdown_timeout": "must be between 1s and 5m",
+}
+
+// ValidateConfig checks cfg against the embedded JSON Schema plus rules the schema
+// cannot express, returning every violation at once
+func ValidateConfig(cfg ServerConfig) error {
+	doc := configDocument{
+		BindAddr:        cfg.BindAddr,
+		MetricsListen:   cfg.MetricsListen,
+		EnableMetrics:   cfg.EnableMetrics,
+		LogLevel:        cfg.LogLevel,
+		ShutdownTimeout: cfg.ShutdownTimeout.Seconds(),
+	}
+	result, err := gojsonschema.Validate(gojsonschema.NewBytesLoader(configSchema), gojsonschema.NewGoLoader(doc))
+	if err != nil {
+		return fmt.Errorf("validate config: %w", err)
+	}
+
+	var violations []string
+	for _, e := range result.Errors() {
+		field := e.Field()
+		msg, ok := violationMessages[field]
+		if !ok {
+			msg = e.Description()
+		}
+		violations = append(violations, fmt.Sprintf("%s: %s (got %v)", field, msg, e.Value()))
+	}
+	for i := 1; i < len(cfg.Metrics.HistogramBuckets); i++ {
+		if cfg.Metrics.HistogramBuckets[i] <= cfg.Metrics.HistogramBuckets[i-1] {
+			violations = append(violations, fmt.Sprintf("metrics.histogram_buckets: must be strictly increasing (got %v)", cfg.Metrics.HistogramBuckets))
+			break
+		}
+	}
+	switch cfg.RequestID.Format {
+	case "", reqctx.FormatUUID, reqctx.FormatULID, reqctx.FormatPrefix:
+	default:
+		violations = append(violations, fmt.Sprintf("request_id.format: must be one of uuid, ulid, prefix (got %s)", cfg.RequestID.Format))
+	}
+	switch cfg.LogFormat {
+	case "", "json", "console", "colored-console":
+	default:
+		violations = append(violations, fmt.Sprintf("log_format: must be one of json, console, colored-console (got %s)", cfg.LogFormat))
+	}
+	if e := cfg.Envelope; e.Enabled && (e.SuccessKey == "" || e.MetaKey == "" || e.SuccessKey == e.MetaKey || e.SuccessKey == "error" || e.MetaKey == "error") {
+		violations = append(violations, fmt.Sprintf("response_envelope: success_key and meta_key must be distinct, non-empty and not \"error\" (got %q and %q)", e.SuccessKey, e.MetaKey))
+	}
+	if f := cfg.Faults; f.ErrorRate < 0 || f.ErrorRate > 1 || f.LatencyRate < 0 || f.LatencyRate > 1 {
+		violations = append(violations, fmt.Sprintf("faults: error_rate and latency_rate must be between 0 and 1 (got %v and %v)", f.ErrorRate, f.LatencyRate))
+	}
+	if h := cfg.HTTP3; h.Enabled && (h.TLSCertFile == "" || h.TLSKeyFile == "") {
+		violations = append(violations, "http3: needs a certificate, from http3.tls_cert_file/tls_key_file or tls.cert_file/key_file")
+	}
+	for _, c := range cfg.TrustedProxies {
+		if _, _, err := net.ParseCIDR(c); err != nil {
+			violations = append(violations, fmt.Sprintf("trusted_proxies: %q is not a CIDR, e.g. 10.0.0.0/8", c))
+		}
+	}
+	if cfg.IPFilter.TrustProxy {
+		violations = append(violations, "ip_filter.trust_proxy: no longer supported; the filter checks the client IP resolved from X-Forwarded-For for connections from trusted_proxies, so list your proxies' CIDRs there and remove this key")
+	}
+	webhookNames := make([]string, 0, len(cfg.InboundWebhooks))
+	for name := range cfg.InboundWebhooks {
+		webhookNames = append(webhookNames, name)
+	}
+	sort.Strings(webhookNames)
+	for _, name := range webhookNames {
+		if err := cfg.InboundWebhooks[name].Validate(); err != nil {
+			for _, msg := range strings.Split(err.Error(), "\n") {
+				violations = append(violations, "inbound_webhooks."+name+"."+msg)
+			}
+		}
+	}
+	if cfg.Canary.Enabled {
+		if err := cfg.Canary.Validate(); err != nil {
+			for _, msg := range strings.Split(err.Error(), "\n") {
+				violations = append(violations, "canary."+msg)
+			}
+		}
+	}
+	if cfg.DataExport.Enabled && cfg.DataExport.Secret == "" {
+		violations = append(violations, "data_export.secret: must be set when data_export.enabled is true")
+	}
+	if cfg.DataExport.Enabled && !cfg.Auth.Configured() {
+		violations = append(violations, "data_export: GET /api/v1/me/export needs an authenticated caller; set auth.jwt.secret or auth.jwt.public_key_file")
+	}
+	if cfg.Erasure.Enabled && !cfg.Auth.Configured() {
+		violations = append(violations, "erasure: DELETE /api/v1/me needs an authenticated caller; set auth.jwt.secret or auth.jwt.public_key_file")
+	}
+	if cfg.Erasure.Enabled && cfg.Erasure.Tombstones == nil && cfg.DB.PrimaryDSN == "" && cfg.DB.Pool == nil {
+		violations = append(violations, "erasure: needs db.primary_dsn for the tombstone table")
+	}
+	if c := cfg.Concurrency; c.Enabled && c.MaxLimit > 0 && c.MinLimit > c.MaxLimit {
+		violations = append(violations, fmt.Sprintf("adaptive_concurrency: min_limit %d exceeds max_limit %d", c.MinLimit, c.MaxLimit))
+	}
+	if cfg.Outbox.Enabled && cfg.DB.PrimaryDSN == "" && cfg.DB.Pool == nil {
+		violations = append(violations, "outbox: needs db.primary_dsn")
+	}
+	if cfg.EnableMetrics && sameListenAddr(cfg.BindAddr, cfg.MetricsListen) {
+		violations = append(violations, fmt.Sprintf("metrics_listen: must not share a port with bind_addr (got %s and %s)", cfg.MetricsListen, cfg.BindAddr))
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return errors.New("invalid configuration:\n  - " + strings.Join(violations, "\n  - "))
+}
+
+// sameListenAddr reports whether two listen addresses would collide: same port
+// and the same host, or either binding all interfaces
+func sameListenAddr(a, b string) bool {
+	hostA, portA, errA := net.SplitHostPort(a)
+	hostB, portB, errB := net.SplitHostPort(b)
+	if errA != nil || errB != nil || portA != portB {
+		return false
+	}
+	wildcard := func(h string) bool { return h == "" || h == "0.0.0.0" || h == "::" }
+	return hostA == hostB || wildcard(hostA) || wildcard(hostB)
+}
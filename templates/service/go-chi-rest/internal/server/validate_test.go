@@ -0,0 +1,96 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// defaultConfig loads ServerConfig from the registered defaults only
+func defaultConfig(t testing.TB) ServerConfig {
+	t.Helper()
+	v := viper.New()
+	registerDefaults(v)
+	cfg, err := loadConfigFrom(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestValidateConfig(t *testing.T) {
+	if err := ValidateConfig(defaultConfig(t)); err != nil {
+		t.Fatalf("defaults are invalid: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(*ServerConfig)
+		want   []string
+	}{
+		{"bad bind addr", func(c *ServerConfig) { c.BindAddr = "8080" }, []string{"bind_addr: must be host:port"}},
+		{"bad log level", func(c *ServerConfig) { c.LogLevel = "verbose" }, []string{"log_level: must be one of debug, info, warn, error"}},
+		{"shutdown timeout too long", func(c *ServerConfig) { c.ShutdownTimeout = time.Hour }, []string{"shutdown_timeout: must be between 1s and 5m"}},
+		{"metrics on the API port", func(c *ServerConfig) {
+			c.EnableMetrics, c.BindAddr, c.MetricsListen = true, ":8080", "127.0.0.1:8080"
+		}, []string{"metrics_listen: must not share a port with bind_addr"}},
+		{"buckets not increasing", func(c *ServerConfig) { c.Metrics.HistogramBuckets = []float64{0.1, 0.1} }, []string{"metrics.histogram_buckets"}},
+		{"bad trusted proxy", func(c *ServerConfig) { c.TrustedProxies = []string{"10.0.0.1"} }, []string{`trusted_proxies: "10.0.0.1" is not a CIDR`}},
+		{"ip filter trust_proxy", func(c *ServerConfig) {
+			c.IPFilter.TrustProxy, c.TrustedProxies = true, []string{"10.0.0.0/8"}
+		}, []string{"ip_filter.trust_proxy:", "trusted_proxies"}},
+		{"every violation at once", func(c *ServerConfig) {
+			c.BindAddr, c.LogLevel, c.LogFormat = "nope", "loud", "xml"
+		}, []string{"bind_addr:", "log_level:", "log_format:"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig(t)
+			tt.mutate(&cfg)
+			err := ValidateConfig(cfg)
+			if err == nil {
+				t.Fatal("ValidateConfig() = nil")
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(err.Error(), w) {
+					t.Errorf("ValidateConfig() = %v, want %q", err, w)
+				}
+			}
+		})
+	}
+}
+
+func TestSameListenAddr(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{":8080", ":8080", true},
+		{":8080", "127.0.0.1:8080", true},
+		{"0.0.0.0:9090", "10.0.0.1:9090", true},
+		{"127.0.0.1:8080", "10.0.0.1:8080", false},
+		{":8080", ":9090", false},
+		{"bad", ":8080", false},
+	}
+	for _, tt := range tests {
+		if got := sameListenAddr(tt.a, tt.b); got != tt.want {
+			t.Errorf("sameListenAddr(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestValidateConfigCanary(t *testing.T) {
+	cfg := defaultConfig(t)
+	cfg.Canary.Enabled = true
+	cfg.Canary.CanaryBackendURL = "not a url"
+	err := ValidateConfig(cfg)
+	if err == nil || !strings.Contains(err.Error(), "canary.backend_url") {
+		t.Errorf("ValidateConfig() = %v, want a canary.backend_url violation", err)
+	}
+	cfg.Canary.CanaryBackendURL = "http://app-canary:8080"
+	if err := ValidateConfig(cfg); err != nil {
+		t.Errorf("ValidateConfig() = %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+// Package shutdown orders graceful shutdown so that work is drained before
+// the resources it depends on are closed
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Priority convention; lower runs first. Leave gaps for hooks in between.
+const (
+	PriorityStopAccepting  = 10
+	PriorityDrainRequests  = 20
+	PriorityStopWorkers    = 30
+	PriorityCloseDB        = 40
+	PriorityCloseConsumers = 50
+)
+
+// Priorities describes the convention for logs and docs
+var Priorities = map[int]string{
+	PriorityStopAccepting:  "stop accepting HTTP (deregister, stop probes passing)",
+	PriorityDrainRequests:  "drain in-flight requests",
+	PriorityStopWorkers:    "stop background workers",
+	PriorityCloseDB:        "close DB pools",
+	PriorityCloseConsumers: "close message consumers",
+}
+
+type hook struct {
+	name     string
+	priority int
+	fn       func(ctx context.Context) error
+}
+
+// Manager runs registered shutdown hooks in priority order
+type Manager struct {
+	mu    sync.Mutex
+	hooks []hook
+}
+
+// NewManager returns an empty Manager
+func NewManager() *Manager {
+	return &Manager{}
+}
+
+// Register adds a hook. Hooks with the same priority run concurrently.
+func (m *Manager) Register(name string, priority int, fn func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook{name: name, priority: priority, fn: fn})
+}
+
+// Shutdown runs the hooks in ascending priority, waiting for each priority
+// level to finish before starting the next. A failing hook does not stop
+// later levels; every error is returned joined. Hooks receive ctx and should
+// give up when it expires; a level still running then is abandoned, later
+// levels are skipped, and both are named in the error. Call it once.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	hooks := append([]hook(nil), m.hooks...)
+	m.mu.Unlock()
+	sort.SliceStable(hooks, func(i, j int) bool { return hooks[i].priority < hooks[j].priority })
+
+	var errs []error
+	for start := 0; start < len(hooks); {
+		end := start
+		for end < len(hooks) && hooks[end].priority == hooks[start].priority {
+			end++
+		}
+		if ctx.Err() != nil {
+			names := make([]string, 0, len(hooks)-start)
+			for _, h := range hooks[start:] {
+				names = append(names, h.name)
+			}
+			zap.L().Error("shutdown hooks skipped", zap.Strings("hooks", names), zap.Error(ctx.Err()))
+			errs = append(errs, fmt.Errorf("not run: %s: %w", strings.Join(names, ", "), ctx.Err()))
+			break
+		}
+		errs = append(errs, runLevel(ctx, hooks[start:end])...)
+		start = end
+	}
+	return errors.Join(errs...)
+}
+
+// runLevel runs hooks concurrently and waits for them or for ctx. Hooks
+// still running when ctx expires are reported by name and left behind.
+func runLevel(ctx context.Context, hooks []hook) []error {
+	type result struct {
+		i   int
+		err error
+	}
+	results := make(chan result, len(hooks)) // abandoned hooks never block
+	for i, h := range hooks {
+		go func(i int, h hook) {
+			start := time.Now()
+			err := h.fn(ctx)
+			if err != nil {
+				zap.L().Error("shutdown hook failed", zap.String("hook", h.name), zap.Int("priority", h.priority), zap.Error(err))
+			} else {
+				zap.L().Info("shutdown hook done", zap.String("hook", h.name), zap.Int("priority", h.priority), zap.Duration("took", time.Since(start)))
+			}
+			results <- result{i, err}
+		}(i, h)
+	}
+
+	var errs []error
+	finished := make([]bool, len(hooks))
+	collect := func(res result) {
+		finished[res.i] = true
+		if res.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", hooks[res.i].name, res.err))
+		}
+	}
+	for n := 0; n < len(hooks); n++ {
+		select {
+		case res := <-results:
+			collect(res)
+		case <-ctx.Done():
+			// take what finished in the meantime, then name the rest
+			for drained := false; !drained; {
+				select {
+				case res := <-results:
+					collect(res)
+				default:
+					drained = true
+				}
+			}
+			var pending []string
+			for i, h := range hooks {
+				if !finished[i] {
+					pending = append(pending, h.name)
+				}
+			}
+			if len(pending) == 0 {
+				return errs
+			}
+			zap.L().Error("shutdown hooks still running", zap.Strings("hooks", pending), zap.Int("priority", hooks[0].priority), zap.Error(ctx.Err()))
+			return append(errs, fmt.Errorf("%s: %w", strings.Join(pending, ", "), ctx.Err()))
+		}
+	}
+	return errs
+}
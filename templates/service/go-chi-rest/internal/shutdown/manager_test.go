@@ -0,0 +1,70 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShutdownOrder(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		seq []string
+	)
+	hook := func(name string, delay time.Duration, err error) func(context.Context) error {
+		return func(context.Context) error {
+			time.Sleep(delay)
+			mu.Lock()
+			seq = append(seq, name)
+			mu.Unlock()
+			return err
+		}
+	}
+	m := NewManager()
+	m.Register("consumers", PriorityCloseConsumers, hook("consumers", 0, nil))
+	m.Register("db", PriorityCloseDB, hook("db", 0, errors.New("close failed")))
+	m.Register("http-slow", PriorityDrainRequests, hook("http-slow", 30*time.Millisecond, nil))
+	m.Register("http-fast", PriorityDrainRequests, hook("http-fast", 0, nil))
+	m.Register("deregister", PriorityStopAccepting, hook("deregister", 10*time.Millisecond, nil))
+
+	err := m.Shutdown(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "db: close failed") {
+		t.Errorf("err = %v, want the db failure", err)
+	}
+	want := "deregister,http-fast,http-slow,db,consumers"
+	if got := strings.Join(seq, ","); got != want {
+		t.Errorf("order = %s, want %s", got, want)
+	}
+}
+
+func TestShutdownReportsPendingHooks(t *testing.T) {
+	m := NewManager()
+	release := make(chan struct{})
+	defer close(release)
+	m.Register("stuck", PriorityStopWorkers, func(context.Context) error {
+		<-release // ignores ctx
+		return nil
+	})
+	m.Register("quick", PriorityStopWorkers, func(context.Context) error { return nil })
+	m.Register("db", PriorityCloseDB, func(context.Context) error {
+		t.Error("hook ran after the deadline")
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := m.Shutdown(ctx)
+	if time.Since(start) > time.Second {
+		t.Fatal("Shutdown waited for a hook that ignores ctx")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "stuck") || strings.Contains(err.Error(), "quick") {
+		t.Errorf("err = %v, want only the stuck hook reported", err)
+	}
+	if !strings.Contains(err.Error(), "not run: db") {
+		t.Errorf("err = %v, want the skipped db hook named", err)
+	}
+}
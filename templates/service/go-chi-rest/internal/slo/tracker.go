@@ -0,0 +1,191 @@
+package slo
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	budgetRemaining = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "slo_error_budget_remaining",
+		Help: "Fraction of the error budget left over the SLO window (negative when overspent).",
+	}, []string{"slo"})
+	burnRate1h = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "slo_burn_rate_1h",
+		Help: "Error budget burn rate over the last hour (1 = spending exactly on budget).",
+	}, []string{"slo"})
+	burnRate5m = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "slo_burn_rate_5m",
+		Help: "Error budget burn rate over the last five minutes.",
+	}, []string{"slo"})
+)
+
+const (
+	bucketCount = 60
+	bucketWidth = time.Minute
+	maxWindow   = bucketCount * bucketWidth
+	gaugePeriod = 30 * time.Second
+	defaultGoal = 0.999
+)
+
+// SLOConfig configures the availability SLO tracked by the server
+type SLOConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	Name           string        `mapstructure:"name"`
+	Objective      float64       `mapstructure:"objective"` // e.g. 0.999
+	WindowDuration time.Duration `mapstructure:"window"`    // budget window, at most 1h
+}
+
+type bucket struct {
+	minute int64 // unix minute this bucket currently counts
+	total  uint64
+	errors uint64
+}
+
+// SLOTracker counts requests and errors in a ring of 60 one-minute buckets
+// and derives error budget burn rates from them
+type SLOTracker struct {
+	Name           string
+	Objective      float64
+	ErrorOn        func(statusCode int) bool // default: 5xx
+	WindowDuration time.Duration             // budget window for slo_error_budget_remaining, at most 1h
+
+	mu      sync.Mutex
+	buckets [bucketCount]bucket
+}
+
+// NewSLOTracker returns a tracker for cfg; zero values fall back to a 99.9%
+// objective, 5xx errors and a 1h window
+func NewSLOTracker(cfg SLOConfig) *SLOTracker {
+	return &SLOTracker{Name: cfg.Name, Objective: cfg.Objective, WindowDuration: cfg.WindowDuration}
+}
+
+func (t *SLOTracker) isError(status int) bool {
+	if t.ErrorOn != nil {
+		return t.ErrorOn(status)
+	}
+	return status >= 500
+}
+
+func (t *SLOTracker) objective() float64 {
+	if t.Objective <= 0 || t.Objective >= 1 {
+		return defaultGoal
+	}
+	return t.Objective
+}
+
+// Record counts one request with the given response status
+func (t *SLOTracker) Record(status int) {
+	minute := time.Now().Unix() / int64(bucketWidth/time.Second)
+	isErr := t.isError(status)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b := &t.buckets[minute%bucketCount]
+	if b.minute != minute {
+		// the slot still holds a minute that fell out of the ring
+		*b = bucket{minute: minute}
+	}
+	b.total++
+	if isErr {
+		b.errors++
+	}
+}
+
+// counts sums the buckets covering the last window (rounded up to whole minutes)
+func (t *SLOTracker) counts(window time.Duration) (total, errs uint64) {
+	if window > maxWindow {
+		window = maxWindow
+	}
+	minutes := int64((window + bucketWidth - 1) / bucketWidth)
+	current := time.Now().Unix() / int64(bucketWidth/time.Second)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, b := range t.buckets {
+		if b.minute > current-minutes && b.minute <= current {
+			total += b.total
+			errs += b.errors
+		}
+	}
+	return total, errs
+}
+
+// BurnRate returns the error rate over window divided by the allowed error
+// rate (1 - Objective). 1 means the budget is spent exactly over the SLO
+// period; 10 means ten times too fast. Windows longer than 1h are capped.
+func (t *SLOTracker) BurnRate(window time.Duration) float64 {
+	total, errs := t.counts(window)
+	if total == 0 {
+		return 0
+	}
+	return (float64(errs) / float64(total)) / (1 - t.objective())
+}
+
+// ErrorBudgetRemaining returns the fraction of the error budget left over
+// WindowDuration: 1 with no errors, 0 when exactly spent, negative when overspent
+func (t *SLOTracker) ErrorBudgetRemaining() float64 {
+	window := t.WindowDuration
+	if window <= 0 {
+		window = maxWindow
+	}
+	return 1 - t.BurnRate(window)
+}
+
+// Run updates the SLO gauges every 30 seconds until ctx is cancelled
+func (t *SLOTracker) Run(ctx context.Context) {
+	name := t.Name
+	if name == "" {
+		name = "default"
+	}
+	ticker := time.NewTicker(gaugePeriod)
+	defer ticker.Stop()
+	for {
+		budgetRemaining.WithLabelValues(name).Set(t.ErrorBudgetRemaining())
+		burnRate1h.WithLabelValues(name).Set(t.BurnRate(time.Hour))
+		burnRate5m.WithLabelValues(name).Set(t.BurnRate(5 * time.Minute))
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// TrackSLO records every response status with tracker
+func TrackSLO(tracker *SLOTracker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			defer func() {
+				// a panic that escapes here is answered with 500 by the recoverer
+				if p := recover(); p != nil {
+					tracker.Record(http.StatusInternalServerError)
+					panic(p)
+				}
+				tracker.Record(sw.status)
+			}()
+			next.ServeHTTP(sw, r)
+		})
+	}
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
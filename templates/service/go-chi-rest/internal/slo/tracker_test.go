@@ -0,0 +1,85 @@
+package slo
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func serveN(h http.Handler, n int) {
+	for i := 0; i < n; i++ {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+}
+
+func TestBurnRate(t *testing.T) {
+	tracker := NewSLOTracker(SLOConfig{Objective: 0.999})
+	status := http.StatusOK
+	h := TrackSLO(tracker)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+
+	// 1% errors against a 0.1% budget burns it ten times too fast
+	serveN(h, 990)
+	status = http.StatusServiceUnavailable
+	serveN(h, 10)
+
+	for _, window := range []time.Duration{5 * time.Minute, time.Hour, 3 * time.Hour} {
+		if got := tracker.BurnRate(window); math.Abs(got-10) > 0.01 {
+			t.Errorf("BurnRate(%v) = %v, want ~10", window, got)
+		}
+	}
+	if got := tracker.ErrorBudgetRemaining(); math.Abs(got-(-9)) > 0.01 {
+		t.Errorf("ErrorBudgetRemaining = %v, want ~-9", got)
+	}
+}
+
+func TestBurnRateIgnoresExpiredBuckets(t *testing.T) {
+	tracker := NewSLOTracker(SLOConfig{})
+	now := time.Now().Unix() / 60
+	// two hours ago: outside the ring's window even though the slot is reused
+	tracker.buckets[(now-120)%bucketCount] = bucket{minute: now - 120, total: 100, errors: 100}
+	// ten minutes ago: inside 1h, outside 5m
+	tracker.buckets[(now-10)%bucketCount] = bucket{minute: now - 10, total: 1000, errors: 2}
+
+	if got := tracker.BurnRate(5 * time.Minute); got != 0 {
+		t.Errorf("BurnRate(5m) = %v, want 0 with no recent requests", got)
+	}
+	if got := tracker.BurnRate(time.Hour); math.Abs(got-2) > 0.01 {
+		t.Errorf("BurnRate(1h) = %v, want ~2", got)
+	}
+	if got := tracker.ErrorBudgetRemaining(); math.Abs(got-(-1)) > 0.01 {
+		t.Errorf("ErrorBudgetRemaining = %v, want ~-1", got)
+	}
+}
+
+func TestTrackSLOCountsPanicsAndCustomErrors(t *testing.T) {
+	tracker := NewSLOTracker(SLOConfig{Objective: 0.99})
+	tracker.ErrorOn = func(status int) bool { return status >= 500 || status == http.StatusTooManyRequests }
+
+	h := TrackSLO(tracker)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/panic":
+			panic("boom")
+		case "/limited":
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("panic was swallowed")
+			}
+		}()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+	}()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/limited", nil))
+	serveN(h, 8)
+
+	total, errs := tracker.counts(5 * time.Minute)
+	if total != 10 || errs != 2 {
+		t.Errorf("counts = %d total, %d errors, want 10, 2", total, errs)
+	}
+}
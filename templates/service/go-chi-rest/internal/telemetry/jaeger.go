@@ -0,0 +1,112 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// JaegerConfig configures trace export to Jaeger
+type JaegerConfig struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	Endpoint    string  `mapstructure:"endpoint"` // Jaeger OTLP/HTTP collector, e.g. jaeger:4318
+	Insecure    bool    `mapstructure:"insecure"`
+	ServiceName string  `mapstructure:"service_name"`
+	SampleRatio float64 `mapstructure:"sample_ratio"` // 1 samples everything, 0 < r < 1 samples probabilistically
+}
+
+// InitJaeger registers a global TracerProvider exporting to Jaeger and returns a
+// shutdown func that flushes pending spans.
+//
+// Jaeger ingests OTLP natively since 1.35 and the dedicated OTel Jaeger exporter has
+// been retired upstream, so spans are shipped over OTLP/HTTP.
+func InitJaeger(cfg JaegerConfig) (func(), error) {
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exp, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("create jaeger exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("build trace resource: %w", err)
+	}
+
+	sampler := sdktrace.AlwaysSample()
+	if cfg.SampleRatio > 0 && cfg.SampleRatio < 1 {
+		sampler = sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sampler),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		tp.Shutdown(ctx)
+	}, nil
+}
+
+// Middleware starts a server span per request and extracts the incoming trace context.
+// Mount it before the request logger so log lines carry trace_id/span_id.
+func Middleware(operation string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return otelhttp.NewHandler(next, operation)
+	}
+}
+
+// PropagatingHTTPClient returns a copy of base whose transport injects the W3C
+// traceparent header into outbound requests. The span is taken from each request's
+// context, falling back to ctx when the request carries none.
+func PropagatingHTTPClient(ctx context.Context, base *http.Client) *http.Client {
+	if base == nil {
+		base = http.DefaultClient
+	}
+	next := base.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	c := *base
+	c.Transport = &propagatingTransport{ctx: ctx, next: next}
+	return &c
+}
+
+type propagatingTransport struct {
+	ctx  context.Context
+	next http.RoundTripper
+}
+
+func (t *propagatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		ctx = t.ctx
+	}
+	// RoundTrippers must not mutate the caller's request
+	out := req.Clone(req.Context())
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(out.Header))
+	return t.next.RoundTrip(out)
+}
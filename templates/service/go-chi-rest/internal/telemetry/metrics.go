@@ -0,0 +1,170 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	otelprom "go.opentelemetry.io/contrib/bridges/prometheus"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// DefaultBuckets suit REST handlers with SLOs in the 10ms–1s range; the
+// Prometheus defaults waste resolution above 2.5s and have too little below 25ms
+var DefaultBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1.0, 2.5}
+
+// MetricsConfig tunes the request latency histogram
+type MetricsConfig struct {
+	HistogramBuckets []float64 `mapstructure:"histogram_buckets"` // upper bounds in seconds, strictly increasing
+	NativeHistograms bool      `mapstructure:"native_histograms"` // also expose sparse native buckets (Prometheus 2.40+ with native histograms enabled)
+}
+
+// OTelMetricsConfig configures pushing metrics over OTLP in addition to the
+// Prometheus /metrics endpoint
+type OTelMetricsConfig struct {
+	Enabled     bool          `mapstructure:"enabled"`
+	Exporter    string        `mapstructure:"exporter"` // otlp-http | otlp-grpc | stdout
+	Endpoint    string        `mapstructure:"endpoint"` // collector host:port (OTLP/HTTP 4318, gRPC 4317); empty uses the OTEL_EXPORTER_OTLP_* env vars
+	Insecure    bool          `mapstructure:"insecure"`
+	Interval    time.Duration `mapstructure:"interval"`
+	ServiceName string        `mapstructure:"service_name"`
+}
+
+// InitOTelMetrics starts a periodic reader that bridges everything registered
+// with the default Prometheus registry to an OTLP exporter. Prometheus stays the
+// source of truth: metrics are still defined with promauto and scraped at
+// /metrics; the bridge only pushes a copy. The returned func flushes and stops the reader.
+func InitOTelMetrics(cfg OTelMetricsConfig) (func(), error) {
+	ctx := context.Background()
+
+	var (
+		exp sdkmetric.Exporter
+		err error
+	)
+	switch cfg.Exporter {
+	case "", "otlp-http":
+		opts := []otlpmetrichttp.Option{}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlpmetrichttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		}
+		exp, err = otlpmetrichttp.New(ctx, opts...)
+	case "otlp-grpc":
+		opts := []otlpmetricgrpc.Option{}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlpmetricgrpc.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlpmetricgrpc.WithInsecure())
+		}
+		exp, err = otlpmetricgrpc.New(ctx, opts...)
+	case "stdout":
+		exp, err = stdoutmetric.New()
+	default:
+		return nil, fmt.Errorf("unknown metrics exporter %q (want otlp-http, otlp-grpc or stdout)", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create %s metrics exporter: %w", cfg.Exporter, err)
+	}
+
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	// schemaless so the merge never conflicts with the SDK's default schema version
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("build metrics resource: %w", err)
+	}
+
+	reader := sdkmetric.NewPeriodicReader(exp,
+		sdkmetric.WithInterval(interval),
+		sdkmetric.WithProducer(otelprom.NewMetricProducer()),
+	)
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader), sdkmetric.WithResource(res))
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		mp.Shutdown(ctx)
+	}, nil
+}
+
+// NewRequestDuration registers http_request_duration_seconds with the default
+// registry using cfg's buckets (DefaultBuckets when empty). Classic buckets are
+// always kept so older scrapers still work when native histograms are on.
+// Building a second router in the same process, as tests do, reuses the
+// histogram registered by the first along with its buckets.
+func NewRequestDuration(cfg MetricsConfig) (*prometheus.HistogramVec, error) {
+	opts := prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency, by method, route pattern and status.",
+		Buckets: cfg.HistogramBuckets,
+	}
+	if len(opts.Buckets) == 0 {
+		opts.Buckets = DefaultBuckets
+	}
+	if cfg.NativeHistograms {
+		opts.NativeHistogramBucketFactor = 1.1
+		opts.NativeHistogramMaxBucketNumber = 160
+		opts.NativeHistogramMinResetDuration = time.Hour
+	}
+	vec := prometheus.NewHistogramVec(opts, []string{"method", "route", "status"})
+	if err := prometheus.Register(vec); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(*prometheus.HistogramVec); ok {
+				return existing, nil
+			}
+		}
+		return nil, fmt.Errorf("register request duration histogram: %w", err)
+	}
+	return vec, nil
+}
+
+// RequestMetrics records every request in requestDuration, labelled with the
+// chi route pattern rather than the raw path to keep cardinality bounded
+func RequestMetrics(requestDuration *prometheus.HistogramVec) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(sw, r)
+
+			route := "unmatched"
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+			requestDuration.WithLabelValues(r.Method, route, strconv.Itoa(sw.status)).Observe(time.Since(start).Seconds())
+		})
+	}
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
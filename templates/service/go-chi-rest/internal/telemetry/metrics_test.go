@@ -0,0 +1,139 @@
+package telemetry
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// otlpCollector accepts OTLP/HTTP metric exports and reports every metric name received
+func otlpCollector(t *testing.T) (*httptest.Server, <-chan string) {
+	t.Helper()
+	names := make(chan string, 1000)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/metrics" {
+			http.NotFound(w, r)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		var req colmetricpb.ExportMetricsServiceRequest
+		if err := proto.Unmarshal(body, &req); err != nil {
+			t.Errorf("decode export: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, rm := range req.ResourceMetrics {
+			for _, sm := range rm.ScopeMetrics {
+				for _, m := range sm.Metrics {
+					select {
+					case names <- m.Name:
+					default:
+					}
+				}
+			}
+		}
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		out, _ := proto.Marshal(&colmetricpb.ExportMetricsServiceResponse{})
+		w.Write(out)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, names
+}
+
+func TestOTelMetricsPushesPrometheusMetrics(t *testing.T) {
+	srv, names := otlpCollector(t)
+	vec, err := NewRequestDuration(MetricsConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { prometheus.Unregister(vec) })
+	vec.WithLabelValues(http.MethodGet, "/api/v1/ping", "200").Observe(0.01)
+
+	shutdown, err := InitOTelMetrics(OTelMetricsConfig{
+		Exporter:    "otlp-http",
+		Endpoint:    strings.TrimPrefix(srv.URL, "http://"),
+		Insecure:    true,
+		Interval:    50 * time.Millisecond,
+		ServiceName: "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shutdown()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case name := <-names:
+			if name == "http_request_duration_seconds" {
+				return
+			}
+		case <-timeout:
+			t.Fatal("http_request_duration_seconds never reached the collector")
+		}
+	}
+}
+
+func TestInitOTelMetricsUnknownExporter(t *testing.T) {
+	if _, err := InitOTelMetrics(OTelMetricsConfig{Exporter: "zipkin"}); err == nil {
+		t.Error("unknown exporter accepted")
+	}
+}
+
+func TestNewRequestDurationBuckets(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  MetricsConfig
+		want []float64
+	}{
+		{"defaults", MetricsConfig{}, DefaultBuckets},
+		{"configured", MetricsConfig{HistogramBuckets: []float64{0.05, 0.2, 1}}, []float64{0.05, 0.2, 1}},
+		{"native keeps classic buckets", MetricsConfig{HistogramBuckets: []float64{0.1, 1}, NativeHistograms: true}, []float64{0.1, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vec, err := NewRequestDuration(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer prometheus.Unregister(vec)
+			vec.WithLabelValues(http.MethodGet, "/", "200").Observe(0.01)
+
+			families, err := prometheus.DefaultGatherer.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []float64
+			for _, mf := range families {
+				if mf.GetName() == "http_request_duration_seconds" {
+					for _, b := range mf.GetMetric()[0].GetHistogram().GetBucket() {
+						got = append(got, b.GetUpperBound())
+					}
+				}
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("buckets = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRequestDurationTwice(t *testing.T) {
+	first, err := NewRequestDuration(MetricsConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer prometheus.Unregister(first)
+	second, err := NewRequestDuration(MetricsConfig{})
+	if err != nil || second != first {
+		t.Errorf("second NewRequestDuration = %p, %v; want the registered %p", second, err, first)
+	}
+}
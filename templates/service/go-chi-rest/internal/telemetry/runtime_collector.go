@@ -0,0 +1,91 @@
+package telemetry
+
+import (
+	"runtime"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// BuildInfo is exposed as the labels of go_build_info
+type BuildInfo struct {
+	Version  string
+	Revision string
+}
+
+var (
+	goroutinesDesc   = prometheus.NewDesc("go_goroutines", "Number of goroutines that currently exist.", nil, nil)
+	heapAllocDesc    = prometheus.NewDesc("go_heap_alloc_bytes", "Bytes of allocated heap objects.", nil, nil)
+	heapInuseDesc    = prometheus.NewDesc("go_heap_inuse_bytes", "Bytes in in-use heap spans.", nil, nil)
+	stackInuseDesc   = prometheus.NewDesc("go_stack_inuse_bytes", "Bytes in stack spans.", nil, nil)
+	gcPauseDesc      = prometheus.NewDesc("go_gc_pause_seconds_total", "Cumulative stop-the-world time of garbage collections.", nil, nil)
+	gcCountDesc      = prometheus.NewDesc("go_gc_count_total", "Completed garbage collection cycles.", nil, nil)
+	osThreadsDesc    = prometheus.NewDesc("go_os_thread_count", "Number of OS threads created.", nil, nil)
+	buildInfoDesc    = prometheus.NewDesc("go_build_info", "Build metadata of the binary; always 1.", []string{"version", "revision", "os", "arch"}, nil)
+	runtimeDescs     = []*prometheus.Desc{goroutinesDesc, heapAllocDesc, heapInuseDesc, stackInuseDesc, gcPauseDesc, gcCountDesc, osThreadsDesc, buildInfoDesc}
+	threadCreateProf = pprof.Lookup("threadcreate")
+)
+
+// RuntimeCollector exposes Go runtime statistics read from runtime.ReadMemStats.
+// ReadMemStats stops the world, so a reading is reused for ttl: scrapes
+// arriving closer together, e.g. from several Prometheus replicas, share it.
+type RuntimeCollector struct {
+	info BuildInfo
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	readAt  time.Time
+	metrics []prometheus.Metric
+}
+
+// NewRuntimeCollector returns a collector caching its readings for ttl
+func NewRuntimeCollector(info BuildInfo, ttl time.Duration) *RuntimeCollector {
+	return &RuntimeCollector{info: info, ttl: ttl}
+}
+
+// Describe implements prometheus.Collector
+func (c *RuntimeCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range runtimeDescs {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector
+func (c *RuntimeCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	if now := time.Now(); c.metrics == nil || now.Sub(c.readAt) >= c.ttl {
+		c.metrics, c.readAt = c.read(), now
+	}
+	metrics := c.metrics
+	c.mu.Unlock()
+
+	for _, m := range metrics {
+		ch <- m
+	}
+}
+
+func (c *RuntimeCollector) read() []prometheus.Metric {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return []prometheus.Metric{
+		prometheus.MustNewConstMetric(goroutinesDesc, prometheus.GaugeValue, float64(runtime.NumGoroutine())),
+		prometheus.MustNewConstMetric(heapAllocDesc, prometheus.GaugeValue, float64(ms.HeapAlloc)),
+		prometheus.MustNewConstMetric(heapInuseDesc, prometheus.GaugeValue, float64(ms.HeapInuse)),
+		prometheus.MustNewConstMetric(stackInuseDesc, prometheus.GaugeValue, float64(ms.StackInuse)),
+		prometheus.MustNewConstMetric(gcPauseDesc, prometheus.CounterValue, float64(ms.PauseTotalNs)/1e9),
+		prometheus.MustNewConstMetric(gcCountDesc, prometheus.CounterValue, float64(ms.NumGC)),
+		prometheus.MustNewConstMetric(osThreadsDesc, prometheus.GaugeValue, float64(threadCreateProf.Count())),
+		prometheus.MustNewConstMetric(buildInfoDesc, prometheus.GaugeValue, 1,
+			c.info.Version, c.info.Revision, runtime.GOOS, runtime.GOARCH),
+	}
+}
+
+// RegisterRuntimeCollector replaces the client library's Go collector in the
+// default registry with c; both export go_goroutines
+func RegisterRuntimeCollector(c *RuntimeCollector) error {
+	prometheus.Unregister(collectors.NewGoCollector())
+	return prometheus.Register(c)
+}
@@ -0,0 +1,65 @@
+package telemetry
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// gauge gathers reg and returns the value of the unlabelled metric name
+func gauge(t *testing.T, reg *prometheus.Registry, name string) float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mf := range families {
+		if mf.GetName() == name {
+			return mf.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	t.Fatalf("%s not collected", name)
+	return 0
+}
+
+func TestRuntimeCollectorCaches(t *testing.T) {
+	c := NewRuntimeCollector(BuildInfo{Version: "1.2.3", Revision: "abc"}, time.Second)
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(c)
+
+	first := gauge(t, reg, "go_goroutines")
+	stop := make(chan struct{})
+	defer close(stop)
+	for i := 0; i < 50; i++ {
+		go func() { <-stop }()
+	}
+	if got := gauge(t, reg, "go_goroutines"); got != first {
+		t.Errorf("second Collect within the ttl read %v goroutines, want the cached %v", got, first)
+	}
+
+	c.mu.Lock()
+	c.readAt = c.readAt.Add(-time.Second) // let the cached reading expire
+	c.mu.Unlock()
+	if got := gauge(t, reg, "go_goroutines"); got <= 50 {
+		t.Errorf("Collect after the ttl read %v goroutines, want the 50 started since", got)
+	}
+}
+
+func TestRuntimeCollectorMetrics(t *testing.T) {
+	c := NewRuntimeCollector(BuildInfo{Version: "1.2.3", Revision: "abc"}, time.Second)
+	want := `
+# HELP go_build_info Build metadata of the binary; always 1.
+# TYPE go_build_info gauge
+go_build_info{arch="` + runtime.GOARCH + `",os="` + runtime.GOOS + `",revision="abc",version="1.2.3"} 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want), "go_build_info"); err != nil {
+		t.Error(err)
+	}
+	if n := testutil.CollectAndCount(c); n != len(runtimeDescs) {
+		t.Errorf("collected %d metrics, want %d", n, len(runtimeDescs))
+	}
+}
@@ -0,0 +1,182 @@
+// Package tenant resolves the tenant of a request from its subdomain and
+// optionally hands the request to a router of that tenant's own
+package tenant
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/example/go-chi-rest/internal/errcodes"
+	"github.com/example/go-chi-rest/internal/reqctx"
+)
+
+// TenantCacheTTL is how long NewTenantRouter reuses a resolved tenant
+var TenantCacheTTL = time.Minute
+
+// TenantCacheSize caps the number of hosts NewTenantRouter caches; lookups
+// for further hosts go to the store until entries expire
+var TenantCacheSize = 10000
+
+// ErrTenantNotFound is returned by a TenantStore for an unknown host
+var ErrTenantNotFound = errors.New("tenant not found")
+
+// Tenant holds per-tenant settings for handlers and middleware to consult
+type Tenant struct {
+	ID               string          `mapstructure:"id"`         // subdomain: acme.api.example.com → acme
+	RateLimit        int             `mapstructure:"rate_limit"` // requests per minute; 0 means the global limits apply
+	Features         map[string]bool `mapstructure:"features"`
+	AllowedEndpoints []string        `mapstructure:"allowed_endpoints"` // path prefixes; empty allows all
+	// Router, when set, serves the tenant's requests instead of the shared
+	// routes; build it with chi and mount handlers at "/"
+	Router http.Handler `mapstructure:"-"`
+}
+
+// Feature reports whether the named feature flag is on for t
+func (t *Tenant) Feature(name string) bool {
+	return t.Features[name]
+}
+
+// Allows reports whether path is within t's AllowedEndpoints
+func (t *Tenant) Allows(path string) bool {
+	if len(t.AllowedEndpoints) == 0 {
+		return true
+	}
+	for _, prefix := range t.AllowedEndpoints {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// TenantStore looks up the tenant serving host (the request's Host header,
+// lower-cased and without port); it returns ErrTenantNotFound for unknown hosts
+type TenantStore interface {
+	Resolve(ctx context.Context, host string) (*Tenant, error)
+}
+
+// MapTenantStore is an in-memory TenantStore keyed by tenant ID (subdomain)
+type MapTenantStore map[string]*Tenant
+
+// NewMapTenantStore indexes tenants by ID
+func NewMapTenantStore(tenants []Tenant) MapTenantStore {
+	m := make(MapTenantStore, len(tenants))
+	for i := range tenants {
+		m[tenants[i].ID] = &tenants[i]
+	}
+	return m
+}
+
+// Resolve returns the tenant named by host's subdomain
+func (m MapTenantStore) Resolve(_ context.Context, host string) (*Tenant, error) {
+	if t, ok := m[Subdomain(host)]; ok {
+		return t, nil
+	}
+	return nil, ErrTenantNotFound
+}
+
+// Subdomain returns the first label of host when host has at least three
+// labels (acme.api.example.com → acme), otherwise ""
+func Subdomain(host string) string {
+	host = normalizeHost(host)
+	labels := strings.Split(host, ".")
+	if len(labels) < 3 || net.ParseIP(host) != nil {
+		return ""
+	}
+	return labels[0]
+}
+
+// normalizeHost lower-cases host and strips its port and trailing dot, so
+// variants of one name share a cache entry
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+type tenantKey struct{}
+
+// TenantFromContext returns the tenant resolved by NewTenantRouter, or nil
+func TenantFromContext(ctx context.Context) *Tenant {
+	t, _ := ctx.Value(tenantKey{}).(*Tenant)
+	return t
+}
+
+// NewTenantRouter resolves every request's tenant from its Host header and
+// stores it in the context (see TenantFromContext). Requests of tenants with
+// a Router go to that router; the rest continue down the chain. Unknown
+// tenants get 404 RESOURCE_NOT_FOUND. Successful lookups are cached for
+// TenantCacheTTL, up to TenantCacheSize hosts.
+func NewTenantRouter(store TenantStore) func(http.Handler) http.Handler {
+	c := &cache{store: store, entries: make(map[string]cacheEntry)}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t, err := c.resolve(r.Context(), r.Host)
+			if errors.Is(err, ErrTenantNotFound) {
+				errcodes.Write(w, errcodes.FromRequest(r).New("RESOURCE_NOT_FOUND", "tenant", Subdomain(r.Host)))
+				return
+			}
+			if err != nil {
+				reqctx.LoggerFromContext(r.Context()).Error("tenant lookup failed", zap.String("host", r.Host), zap.Error(err))
+				errcodes.Write(w, errcodes.FromRequest(r).New(errcodes.CodeInternal))
+				return
+			}
+			r = r.WithContext(context.WithValue(r.Context(), tenantKey{}, t))
+			if t.Router != nil {
+				t.Router.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// cache memoizes successful lookups per normalized host; failures are not
+// cached so a newly added tenant works on the next request
+type cache struct {
+	store   TenantStore
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	tenant  *Tenant
+	expires time.Time
+}
+
+func (c *cache) resolve(ctx context.Context, host string) (*Tenant, error) {
+	host = normalizeHost(host)
+	now := time.Now()
+	c.mu.Lock()
+	e, ok := c.entries[host]
+	c.mu.Unlock()
+	if ok && now.Before(e.expires) {
+		return e.tenant, nil
+	}
+
+	t, err := c.store.Resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	if len(c.entries) >= TenantCacheSize {
+		for h, old := range c.entries {
+			if !now.Before(old.expires) {
+				delete(c.entries, h)
+			}
+		}
+	}
+	if len(c.entries) < TenantCacheSize {
+		c.entries[host] = cacheEntry{tenant: t, expires: now.Add(TenantCacheTTL)}
+	}
+	c.mu.Unlock()
+	return t, nil
+}
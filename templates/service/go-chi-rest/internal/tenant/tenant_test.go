@@ -0,0 +1,124 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestSubdomain(t *testing.T) {
+	tests := []struct{ host, want string }{
+		{"acme.api.example.com", "acme"},
+		{"Acme.API.example.com:443", "acme"},
+		{"acme.api.example.com.", "acme"},
+		{"example.com", ""},
+		{"10.0.0.1:8080", ""},
+	}
+	for _, tt := range tests {
+		if got := Subdomain(tt.host); got != tt.want {
+			t.Errorf("Subdomain(%q) = %q, want %q", tt.host, got, tt.want)
+		}
+	}
+}
+
+// countingStore counts Resolve calls and serves every host's subdomain
+type countingStore struct {
+	MapTenantStore
+	calls int
+	hosts []string
+}
+
+func (s *countingStore) Resolve(ctx context.Context, host string) (*Tenant, error) {
+	s.calls++
+	s.hosts = append(s.hosts, host)
+	return s.MapTenantStore.Resolve(ctx, host)
+}
+
+func serve(h http.Handler, host string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/ping", nil)
+	req.Host = host
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestTenantRouter(t *testing.T) {
+	store := &countingStore{MapTenantStore: NewMapTenantStore([]Tenant{{ID: "acme"}})}
+	var got *Tenant
+	h := NewTenantRouter(store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = TenantFromContext(r.Context())
+	}))
+
+	for _, host := range []string{"acme.api.example.com", "ACME.api.example.com:443", "acme.api.example.com:8080"} {
+		if rec := serve(h, host); rec.Code != http.StatusOK || got == nil || got.ID != "acme" {
+			t.Fatalf("%s: status %d, tenant %+v", host, rec.Code, got)
+		}
+	}
+	if store.calls != 1 || store.hosts[0] != "acme.api.example.com" {
+		t.Errorf("store called %d times with %v, want once with the normalized host", store.calls, store.hosts)
+	}
+	if rec := serve(h, "globex.api.example.com"); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown tenant: status %d, want 404", rec.Code)
+	}
+}
+
+func TestTenantCacheBounded(t *testing.T) {
+	defer func(n int) { TenantCacheSize = n }(TenantCacheSize)
+	TenantCacheSize = 3
+	store := &countingStore{MapTenantStore: NewMapTenantStore([]Tenant{{ID: "acme"}})}
+	c := &cache{store: store, entries: make(map[string]cacheEntry)}
+	for i := 0; i < 10; i++ {
+		if _, err := c.resolve(context.Background(), "acme.api"+strconv.Itoa(i)+".example.com"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(c.entries) != TenantCacheSize {
+		t.Errorf("cache holds %d hosts, want %d", len(c.entries), TenantCacheSize)
+	}
+}
+
+// Each subdomain gets its own tenant's answer: from the tenant's router when
+// it has one, otherwise from the shared routes with the tenant in context
+func TestTenantSpecificResponses(t *testing.T) {
+	acme := chi.NewRouter()
+	acme.Get("/api/v1/ping", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("acme router"))
+	})
+	store := NewMapTenantStore([]Tenant{
+		{ID: "acme", Router: acme},
+		{ID: "globex", Features: map[string]bool{"beta": true}},
+		{ID: "initech"},
+	})
+	shared := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tn := TenantFromContext(r.Context())
+		fmt.Fprintf(w, "shared %s beta=%v", tn.ID, tn.Feature("beta"))
+	})
+	h := NewTenantRouter(store)(shared)
+
+	tests := []struct {
+		host     string
+		wantCode int
+		wantBody string
+	}{
+		{"acme.api.example.com", http.StatusOK, "acme router"},
+		{"globex.api.example.com", http.StatusOK, "shared globex beta=true"},
+		{"initech.api.example.com", http.StatusOK, "shared initech beta=false"},
+		{"umbrella.api.example.com", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			rec := serve(h, tt.host)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
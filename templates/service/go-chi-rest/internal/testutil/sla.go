@@ -0,0 +1,80 @@
+// Package testutil holds helpers for the service's tests. AssertResponseTime
+// and AssertPercentile turn a latency budget into a test failure;
+// SLABenchmark reports the latency distribution from a benchmark.
+//
+// Wall-clock limits depend on the machine: keep them generous in CI, or
+// scale them with an environment variable there.
+package testutil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"gonum.org/v1/gonum/stat"
+)
+
+// AssertResponseTime serves req once and fails t when the handler took longer
+// than maxDuration
+func AssertResponseTime(t *testing.T, handler http.Handler, req *http.Request, maxDuration time.Duration) {
+	t.Helper()
+	if d := serve(handler, req); d > maxDuration {
+		t.Errorf("%s %s took %v, want at most %v", req.Method, req.URL.Path, d, maxDuration)
+	}
+}
+
+// AssertPercentile serves req n times and fails t when the p-th percentile
+// (0 < p <= 100, e.g. 99) of the response times exceeds maxDuration
+func AssertPercentile(t *testing.T, handler http.Handler, req *http.Request, n int, p float64, maxDuration time.Duration) {
+	t.Helper()
+	if n <= 0 || p <= 0 || p > 100 {
+		t.Fatalf("AssertPercentile: need n > 0 and 0 < p <= 100, got n=%d p=%v", n, p)
+	}
+	samples := make([]float64, n)
+	for i := range samples {
+		samples[i] = float64(serve(handler, req))
+	}
+	sort.Float64s(samples)
+	if d := time.Duration(stat.Quantile(p/100, stat.Empirical, samples, nil)); d > maxDuration {
+		t.Errorf("%s %s p%v over %d requests is %v, want at most %v", req.Method, req.URL.Path, p, n, d, maxDuration)
+	}
+}
+
+// SLABenchmark serves req b.N times and reports the p50, p99 and p999
+// response times as the p50-ns, p99-ns and p999-ns benchmark metrics, next
+// to the usual ns/op
+func SLABenchmark(b *testing.B, handler http.Handler, req *http.Request) {
+	b.Helper()
+	samples := make([]float64, b.N)
+	b.ResetTimer()
+	for i := range samples {
+		samples[i] = float64(serve(handler, req))
+	}
+	b.StopTimer()
+	if len(samples) == 0 {
+		return
+	}
+	sort.Float64s(samples)
+	for _, q := range []struct {
+		unit string
+		p    float64
+	}{{"p50-ns", 0.5}, {"p99-ns", 0.99}, {"p999-ns", 0.999}} {
+		b.ReportMetric(stat.Quantile(q.p, stat.Empirical, samples, nil), q.unit)
+	}
+}
+
+// serve runs handler on a fresh recorder and a copy of req, so a request can
+// be served repeatedly, and returns how long it took. A body is only replayed
+// when req.GetBody is set, as http.NewRequest does for in-memory bodies.
+func serve(handler http.Handler, req *http.Request) time.Duration {
+	r := req.Clone(req.Context())
+	if req.GetBody != nil {
+		r.Body, _ = req.GetBody()
+	}
+	rec := httptest.NewRecorder()
+	start := time.Now()
+	handler.ServeHTTP(rec, r)
+	return time.Since(start)
+}
@@ -0,0 +1,234 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// UploadBackend stores an uploaded object and returns a URL for it.
+// size is -1 when the length is not known up front (streamed multipart parts).
+type UploadBackend interface {
+	Store(ctx context.Context, name string, r io.Reader, size int64) (string, error)
+}
+
+// UploadConfig configures the multipart upload handler
+type UploadConfig struct {
+	MaxFileSizeMB int      `mapstructure:"max_file_size_mb"`
+	AllowedMIME   []string `mapstructure:"allowed_mime"` // empty allows any type
+	S3            S3Config `mapstructure:"s3"`
+
+	StorageBackend UploadBackend `mapstructure:"-"`
+	// ScanFunc optionally inspects the file stream (e.g. ClamAV) while it is uploaded.
+	// A non-nil error rejects the file and aborts the upload.
+	ScanFunc func(io.Reader) error `mapstructure:"-"`
+}
+
+// formField is the multipart field carrying the file
+const formField = "file"
+
+var errTooLarge = errors.New("file exceeds maximum size")
+
+// scanError marks a file rejected by ScanFunc
+type scanError struct{ err error }
+
+func (e *scanError) Error() string { return "file rejected by scanner: " + e.err.Error() }
+func (e *scanError) Unwrap() error { return e.err }
+
+// NewUploadHandler returns a handler that streams a multipart file to the storage backend.
+// The file is never fully buffered in memory: only the first 512 bytes are held for
+// content sniffing.
+func NewUploadHandler(cfg UploadConfig) http.HandlerFunc {
+	if cfg.MaxFileSizeMB <= 0 {
+		cfg.MaxFileSizeMB = 10
+	}
+	maxBytes := int64(cfg.MaxFileSizeMB) << 20
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if cfg.StorageBackend == nil {
+			writeError(w, http.StatusServiceUnavailable, "storage_unavailable", "upload storage is not configured")
+			return
+		}
+		// allow some headroom for multipart boundaries and headers
+		if r.ContentLength > maxBytes+(1<<20) {
+			writeError(w, http.StatusRequestEntityTooLarge, "file_too_large", errTooLarge.Error())
+			return
+		}
+
+		mr, err := r.MultipartReader()
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", "expected multipart/form-data body")
+			return
+		}
+
+		var part io.ReadCloser
+		var filename string
+		for {
+			p, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "invalid_request", "malformed multipart body")
+				return
+			}
+			if p.FormName() == formField {
+				part, filename = p, p.FileName()
+				break
+			}
+			p.Close()
+		}
+		if part == nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("missing %q field", formField))
+			return
+		}
+		defer part.Close()
+
+		// sniff the content type from the first 512 bytes
+		head := make([]byte, 512)
+		n, err := io.ReadFull(part, head)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			writeError(w, http.StatusBadRequest, "invalid_request", "failed to read file")
+			return
+		}
+		head = head[:n]
+		mime := http.DetectContentType(head)
+		if !mimeAllowed(mime, cfg.AllowedMIME) {
+			writeError(w, http.StatusUnsupportedMediaType, "unsupported_media_type", fmt.Sprintf("content type %q is not allowed", mime))
+			return
+		}
+
+		counter := &limitedReader{r: io.MultiReader(bytes.NewReader(head), part), max: maxBytes}
+		var body io.Reader = counter
+		if cfg.ScanFunc != nil {
+			sr := newScanningReader(body, cfg.ScanFunc)
+			defer sr.Close()
+			body = sr
+		}
+
+		url, err := cfg.StorageBackend.Store(r.Context(), objectName(filename), body, -1)
+		if err != nil {
+			var se *scanError
+			switch {
+			case errors.Is(err, errTooLarge):
+				writeError(w, http.StatusRequestEntityTooLarge, "file_too_large", errTooLarge.Error())
+			case errors.As(err, &se):
+				zap.L().Warn("upload rejected by scanner", zap.String("filename", filename), zap.Error(se.err))
+				writeError(w, http.StatusUnprocessableEntity, "file_rejected", "file rejected by content scanner")
+			default:
+				zap.L().Error("upload failed", zap.String("filename", filename), zap.Error(err))
+				writeError(w, http.StatusBadGateway, "upload_failed", "failed to store file")
+			}
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, map[string]any{"url": url, "size": counter.n})
+	}
+}
+
+// mimeAllowed reports whether mime (possibly carrying parameters) is in the allow list
+func mimeAllowed(mime string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	base := strings.TrimSpace(strings.SplitN(mime, ";", 2)[0])
+	for _, a := range allowed {
+		if strings.EqualFold(a, base) {
+			return true
+		}
+	}
+	return false
+}
+
+// objectName builds a collision-resistant object key from the client file name
+func objectName(filename string) string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	base := filepath.Base(filename)
+	if base == "." || base == string(filepath.Separator) {
+		base = "upload"
+	}
+	return hex.EncodeToString(b) + "-" + base
+}
+
+// limitedReader counts bytes read and fails once max is exceeded
+type limitedReader struct {
+	r   io.Reader
+	max int64
+	n   int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.n += int64(n)
+	if l.n > l.max {
+		return n, errTooLarge
+	}
+	return n, err
+}
+
+// scanningReader tees the stream into ScanFunc running in a goroutine. On EOF it waits
+// for the scan verdict so the backend never sees a clean EOF for a rejected file.
+type scanningReader struct {
+	tee  io.Reader
+	pw   *io.PipeWriter
+	done chan error
+}
+
+func newScanningReader(r io.Reader, scan func(io.Reader) error) *scanningReader {
+	pr, pw := io.Pipe()
+	s := &scanningReader{tee: io.TeeReader(r, pw), pw: pw, done: make(chan error, 1)}
+	go func() {
+		err := scan(pr)
+		if err != nil {
+			pr.CloseWithError(&scanError{err: err})
+		} else {
+			// keep draining so the tee never blocks
+			io.Copy(io.Discard, pr)
+		}
+		s.done <- err
+	}()
+	return s
+}
+
+func (s *scanningReader) Read(p []byte) (int, error) {
+	n, err := s.tee.Read(p)
+	if err == io.EOF {
+		s.pw.Close()
+		if scanErr := <-s.done; scanErr != nil {
+			return n, &scanError{err: scanErr}
+		}
+		return n, io.EOF
+	}
+	if err != nil {
+		s.pw.CloseWithError(err)
+	}
+	return n, err
+}
+
+// Close unblocks the scanner if the backend stopped reading early
+func (s *scanningReader) Close() error {
+	return s.pw.Close()
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]any{
+		"error": map[string]string{"code": code, "message": message},
+	})
+}
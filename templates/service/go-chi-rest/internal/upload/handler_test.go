@@ -0,0 +1,101 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// memBackend stands in for S3
+type memBackend struct {
+	objects map[string][]byte
+}
+
+func (m *memBackend) Store(_ context.Context, name string, r io.Reader, _ int64) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	m.objects[name] = data
+	return "https://bucket.example.com/" + name, nil
+}
+
+// multipartBody returns a form with content in the file field
+func multipartBody(t *testing.T, filename string, content []byte) (*bytes.Buffer, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, err := mw.CreateFormFile(formField, filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write(content)
+	mw.Close()
+	return &buf, mw.FormDataContentType()
+}
+
+func TestUploadHandler(t *testing.T) {
+	png := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 1024)...)
+	big := append(append([]byte{}, png...), bytes.Repeat([]byte{1}, 3<<20)...)
+	tests := []struct {
+		name          string
+		content       []byte
+		unknownLength bool
+		scan          func(io.Reader) error
+		want          int
+	}{
+		{"allowed png", png, false, nil, http.StatusCreated},
+		{"oversized", big, false, nil, http.StatusRequestEntityTooLarge},
+		{"oversized without content length", big, true, nil, http.StatusRequestEntityTooLarge},
+		{"disallowed mime", []byte("#!/bin/sh\necho hi\n"), false, nil, http.StatusUnsupportedMediaType},
+		{"rejected by scanner", png, false, func(r io.Reader) error {
+			io.Copy(io.Discard, r)
+			return errors.New("Eicar-Test-Signature")
+		}, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &memBackend{objects: map[string][]byte{}}
+			h := NewUploadHandler(UploadConfig{MaxFileSizeMB: 1, AllowedMIME: []string{"image/png"}, StorageBackend: backend, ScanFunc: tt.scan})
+			body, contentType := multipartBody(t, "logo.png", tt.content)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/uploads", body)
+			req.Header.Set("Content-Type", contentType)
+			if tt.unknownLength {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			h(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want != http.StatusCreated {
+				return
+			}
+			if len(backend.objects) != 1 {
+				t.Fatalf("stored %d objects", len(backend.objects))
+			}
+			for name, data := range backend.objects {
+				if !strings.HasSuffix(name, "-logo.png") || !bytes.Equal(data, tt.content) {
+					t.Errorf("stored %s with %d bytes, want %d", name, len(data), len(tt.content))
+				}
+			}
+		})
+	}
+}
+
+func TestUploadHandlerNoBackend(t *testing.T) {
+	body, contentType := multipartBody(t, "a.txt", []byte("hi"))
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/uploads", body)
+	req.Header.Set("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+	NewUploadHandler(UploadConfig{})(rec, req)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", rec.Code)
+	}
+}
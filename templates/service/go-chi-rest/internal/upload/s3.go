@@ -0,0 +1,60 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// S3Config configures the S3 storage backend
+type S3Config struct {
+	Bucket       string `mapstructure:"bucket"`
+	Region       string `mapstructure:"region"`
+	Prefix       string `mapstructure:"prefix"`
+	Endpoint     string `mapstructure:"endpoint"` // optional, e.g. MinIO or LocalStack
+	UsePathStyle bool   `mapstructure:"use_path_style"`
+}
+
+// S3Backend streams uploads to S3 using the multipart upload manager
+type S3Backend struct {
+	cfg      S3Config
+	uploader *manager.Uploader
+}
+
+// NewS3Backend creates an S3 backend using the default AWS credential chain
+func NewS3Backend(ctx context.Context, cfg S3Config) (*S3Backend, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 backend: bucket is required")
+	}
+	awsCfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(cfg.Region))
+	if err != nil {
+		return nil, fmt.Errorf("load aws config: %w", err)
+	}
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+		o.UsePathStyle = cfg.UsePathStyle
+	})
+	return &S3Backend{cfg: cfg, uploader: manager.NewUploader(client)}, nil
+}
+
+// Store uploads r under the configured prefix and returns the object URL.
+// The upload manager sends parts as they are read, so r is never buffered whole.
+func (b *S3Backend) Store(ctx context.Context, name string, r io.Reader, size int64) (string, error) {
+	out, err := b.uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(b.cfg.Bucket),
+		Key:    aws.String(path.Join(b.cfg.Prefix, name)),
+		Body:   r,
+	})
+	if err != nil {
+		return "", fmt.Errorf("s3 upload: %w", err)
+	}
+	return out.Location, nil
+}
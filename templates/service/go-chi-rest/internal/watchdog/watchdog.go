@@ -0,0 +1,129 @@
+// Package watchdog exits the process when it stops making progress. Go only
+// reports a deadlock when every goroutine is blocked; a server with live
+// listeners simply hangs, so liveness is inferred from heartbeats instead.
+package watchdog
+
+import (
+	"bytes"
+	"os"
+	"runtime/pprof"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+var kicksTotal = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "watchdog_kicks_total",
+	Help: "Heartbeats received by the watchdog.",
+})
+
+// DefaultInterval applies when Interval is not positive
+const DefaultInterval = 30 * time.Second
+
+// WatchdogConfig enables the watchdog. Every request kicks it, so the server
+// must see traffic (health probes count) at least every 2*Interval.
+type WatchdogConfig struct {
+	Enabled  bool          `mapstructure:"enabled"`
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+// Watchdog expects Kick at least every Interval. After 2*Interval without
+// one it logs a goroutine dump and calls Exit(1), leaving the restart to the
+// supervisor (Kubernetes, systemd).
+type Watchdog struct {
+	Interval time.Duration
+	Logger   *zap.Logger    // nil: zap.L()
+	Exit     func(code int) // nil: os.Exit
+
+	last     atomic.Int64 // unix nanos of the last kick
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// current is the watchdog kicked by the package-level Kick
+var current atomic.Pointer[Watchdog]
+
+// Start creates a watchdog, starts its monitor goroutine and makes it the
+// target of Kick
+func Start(interval time.Duration) *Watchdog {
+	w := &Watchdog{Interval: interval}
+	w.Start()
+	current.Store(w)
+	return w
+}
+
+// Kick sends a heartbeat to the watchdog started by Start; without one it
+// only counts
+func Kick() {
+	if w := current.Load(); w != nil {
+		w.Kick()
+		return
+	}
+	kicksTotal.Inc()
+}
+
+// Kick records a heartbeat
+func (w *Watchdog) Kick() {
+	w.last.Store(time.Now().UnixNano())
+	kicksTotal.Inc()
+}
+
+// Start launches the monitor goroutine; the deadline counts from now
+func (w *Watchdog) Start() {
+	if w.Interval <= 0 {
+		w.Interval = DefaultInterval
+	}
+	w.stop = make(chan struct{})
+	w.last.Store(time.Now().UnixNano())
+	go w.monitor()
+}
+
+// Stop ends monitoring, e.g. during graceful shutdown when requests drain
+func (w *Watchdog) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+		current.CompareAndSwap(w, nil)
+	})
+}
+
+func (w *Watchdog) monitor() {
+	// check several times per interval so the exit lands close to 2*Interval
+	t := time.NewTicker(w.Interval / 4)
+	defer t.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-t.C:
+		}
+		since := time.Since(time.Unix(0, w.last.Load()))
+		if since < 2*w.Interval {
+			continue
+		}
+		w.fire(since)
+		return
+	}
+}
+
+func (w *Watchdog) fire(since time.Duration) {
+	logger := w.Logger
+	if logger == nil {
+		logger = zap.L()
+	}
+	var dump bytes.Buffer
+	pprof.Lookup("goroutine").WriteTo(&dump, 2)
+	logger.Error("watchdog: no heartbeat, exiting",
+		zap.Duration("since_last_kick", since),
+		zap.Duration("interval", w.Interval),
+		zap.String("goroutines", dump.String()))
+	logger.Sync()
+	exit := w.Exit
+	if exit == nil {
+		exit = os.Exit
+	}
+	exit(1)
+}
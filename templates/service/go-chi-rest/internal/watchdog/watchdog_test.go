@@ -0,0 +1,99 @@
+package watchdog
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// newTestWatchdog records exits instead of terminating the test binary
+func newTestWatchdog(interval time.Duration) (*Watchdog, *observer.ObservedLogs, chan int) {
+	core, logs := observer.New(zapcore.ErrorLevel)
+	exits := make(chan int, 1)
+	w := &Watchdog{Interval: interval, Logger: zap.New(core), Exit: func(code int) { exits <- code }}
+	return w, logs, exits
+}
+
+func TestWatchdogFiresWithoutKicks(t *testing.T) {
+	const interval = 50 * time.Millisecond
+	w, logs, exits := newTestWatchdog(interval)
+	start := time.Now()
+	w.Start()
+	defer w.Stop()
+
+	select {
+	case code := <-exits:
+		elapsed := time.Since(start)
+		if code != 1 {
+			t.Errorf("exit code = %d, want 1", code)
+		}
+		// fires after 2*Interval, within one check (Interval/4) plus scheduling slack
+		if elapsed < 2*interval || elapsed > 2*interval+interval/4+100*time.Millisecond {
+			t.Errorf("fired after %v, want about %v", elapsed, 2*interval)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("watchdog did not fire")
+	}
+
+	entries := logs.FilterMessage("watchdog: no heartbeat, exiting").All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d watchdog log entries, want 1", len(entries))
+	}
+	if dump, _ := entries[0].ContextMap()["goroutines"].(string); !strings.Contains(dump, "goroutine ") || !strings.Contains(dump, "watchdog.(*Watchdog).fire") {
+		t.Errorf("log entry has no goroutine dump: %.200s", dump)
+	}
+}
+
+func TestWatchdogKickedDoesNotFire(t *testing.T) {
+	const interval = 50 * time.Millisecond
+	w, _, exits := newTestWatchdog(interval)
+	w.Start()
+	defer w.Stop()
+	before := testutil.ToFloat64(kicksTotal)
+
+	deadline := time.Now().Add(6 * interval)
+	for time.Now().Before(deadline) {
+		w.Kick()
+		select {
+		case <-exits:
+			t.Fatal("watchdog fired despite regular kicks")
+		case <-time.After(interval / 2):
+		}
+	}
+	if testutil.ToFloat64(kicksTotal) <= before {
+		t.Error("watchdog_kicks_total not incremented")
+	}
+}
+
+func TestWatchdogStop(t *testing.T) {
+	const interval = 20 * time.Millisecond
+	w, _, exits := newTestWatchdog(interval)
+	w.Start()
+	w.Stop()
+	w.Stop() // idempotent
+	select {
+	case <-exits:
+		t.Fatal("stopped watchdog fired")
+	case <-time.After(4 * interval):
+	}
+}
+
+// The package-level Kick reaches the watchdog made current by Start
+func TestPackageKick(t *testing.T) {
+	w := Start(time.Hour)
+	defer w.Stop()
+	w.last.Store(0)
+	Kick()
+	if w.last.Load() == 0 {
+		t.Error("Kick did not reach the current watchdog")
+	}
+	w.Stop()
+	if current.Load() != nil {
+		t.Error("Stop left the watchdog current")
+	}
+}
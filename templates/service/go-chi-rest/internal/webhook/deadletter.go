@@ -0,0 +1,42 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FileDeadLetterStore appends failed deliveries to a JSON Lines file
+type FileDeadLetterStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileDeadLetterStore returns a store writing to path (created if missing)
+func NewFileDeadLetterStore(path string) *FileDeadLetterStore {
+	return &FileDeadLetterStore{path: path}
+}
+
+// Append writes one delivery per line and syncs it to disk
+func (s *FileDeadLetterStore) Append(ctx context.Context, delivery WebhookDelivery) error {
+	line, err := json.Marshal(delivery)
+	if err != nil {
+		return fmt.Errorf("encode dead letter: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("open dead letter file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write dead letter: %w", err)
+	}
+	return f.Sync()
+}
@@ -0,0 +1,226 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+
+	"github.com/example/go-chi-rest/internal/retry"
+)
+
+var deliveriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "webhook_deliveries_total",
+	Help: "Outbound webhook deliveries by event and final status.",
+}, []string{"event", "status"})
+
+// SignatureHeader carries the HMAC-SHA256 of the request body
+const SignatureHeader = "X-Webhook-Signature"
+
+// Subscription is a registered webhook endpoint
+type Subscription struct {
+	URL    string   `mapstructure:"url" json:"url"`
+	Secret string   `mapstructure:"secret" json:"-"`
+	Events []string `mapstructure:"events" json:"events"` // empty or "*" matches every event
+}
+
+func (s Subscription) matches(event string) bool {
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, e := range s.Events {
+		if e == "*" || e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery records a delivery that exhausted its retries
+type WebhookDelivery struct {
+	ID        string          `json:"id"`
+	Event     string          `json:"event"`
+	URL       string          `json:"url"`
+	Payload   json.RawMessage `json:"payload"`
+	Attempts  int             `json:"attempts"`
+	LastError string          `json:"last_error"`
+	LastCode  int             `json:"last_status,omitempty"`
+	FailedAt  time.Time       `json:"failed_at"`
+}
+
+// DeadLetterStore persists undeliverable webhooks for later inspection or replay
+type DeadLetterStore interface {
+	Append(ctx context.Context, delivery WebhookDelivery) error
+}
+
+// Sender fans events out to matching subscriptions
+type Sender struct {
+	subs       []Subscription
+	client     *http.Client
+	deadLetter DeadLetterStore
+	retryCfg   retry.RetryConfig
+}
+
+// NewSender creates a Sender. deadLetter may be nil to drop failed deliveries.
+func NewSender(subs []Subscription, deadLetter DeadLetterStore) *Sender {
+	return &Sender{
+		subs:       subs,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		deadLetter: deadLetter,
+		retryCfg: retry.RetryConfig{
+			Operation:    "webhook",
+			MaxAttempts:  5,
+			InitialDelay: 500 * time.Millisecond,
+			MaxDelay:     30 * time.Second,
+			Multiplier:   2,
+			Jitter:       true,
+			RetryOn:      retryable,
+		},
+	}
+}
+
+// statusError is a non-2xx response from a subscriber
+type statusError struct{ code int }
+
+func (e *statusError) Error() string { return fmt.Sprintf("unexpected status %d", e.code) }
+
+// retryable skips retries for client errors other than 408 and 429
+func retryable(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) && se.code >= 400 && se.code < 500 {
+		return se.code == http.StatusRequestTimeout || se.code == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// Send delivers payload to every subscription interested in event. Deliveries run
+// concurrently; the returned error joins the failures of all subscriptions.
+func (s *Sender) Send(ctx context.Context, event string, payload any) error {
+	body, err := json.Marshal(map[string]any{
+		"event":     event,
+		"timestamp": time.Now().UTC(),
+		"data":      payload,
+	})
+	if err != nil {
+		return fmt.Errorf("encode webhook payload: %w", err)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, sub := range s.subs {
+		if !sub.matches(event) {
+			continue
+		}
+		wg.Add(1)
+		go func(sub Subscription) {
+			defer wg.Done()
+			if err := s.deliver(ctx, event, sub, body); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", sub.URL, err))
+				mu.Unlock()
+			}
+		}(sub)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// deliver posts body to one subscription with retries, dead-lettering on failure
+func (s *Sender) deliver(ctx context.Context, event string, sub Subscription, body []byte) error {
+	deliveryID := newID()
+	attempt := 0
+	lastCode := 0
+
+	err := retry.Do(ctx, s.retryCfg, func(ctx context.Context) error {
+		attempt++
+		code, err := s.post(ctx, sub, deliveryID, event, body)
+		lastCode = code
+		zap.L().Info("webhook delivery attempt",
+			zap.String("delivery_id", deliveryID),
+			zap.String("event", event),
+			zap.String("url", sub.URL),
+			zap.Int("attempt", attempt),
+			zap.Int("status", code),
+			zap.Error(err),
+		)
+		return err
+	})
+	if err == nil {
+		deliveriesTotal.WithLabelValues(event, "delivered").Inc()
+		return nil
+	}
+
+	deliveriesTotal.WithLabelValues(event, "failed").Inc()
+	if s.deadLetter != nil {
+		dl := WebhookDelivery{
+			ID:        deliveryID,
+			Event:     event,
+			URL:       sub.URL,
+			Payload:   body,
+			Attempts:  attempt,
+			LastError: err.Error(),
+			LastCode:  lastCode,
+			FailedAt:  time.Now().UTC(),
+		}
+		// use a fresh context: the caller's may already be cancelled
+		dlCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if dlErr := s.deadLetter.Append(dlCtx, dl); dlErr != nil {
+			zap.L().Error("failed to store dead-lettered webhook", zap.String("delivery_id", deliveryID), zap.Error(dlErr))
+		}
+	}
+	return err
+}
+
+func (s *Sender) post(ctx context.Context, sub Subscription, deliveryID, event string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", event)
+	req.Header.Set("X-Webhook-Delivery", deliveryID)
+	if sub.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(sub.Secret, body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, &statusError{code: resp.StatusCode}
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the signature header value "sha256=<hex hmac>"
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,139 @@
+package webhook
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// subscriber answers with statuses in order, repeating the last one
+func subscriber(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1)) - 1
+		body, _ := io.ReadAll(r.Body)
+		if got := r.Header.Get(SignatureHeader); got != Sign(testSecret, body) {
+			t.Errorf("signature = %q, want %q", got, Sign(testSecret, body))
+		}
+		if r.Header.Get("X-Webhook-Event") != "order.created" || r.Header.Get("X-Webhook-Delivery") == "" {
+			t.Errorf("headers = %v", r.Header)
+		}
+		w.WriteHeader(statuses[min(n, len(statuses)-1)])
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func newTestSender(dlPath string, subs ...Subscription) *Sender {
+	s := NewSender(subs, NewFileDeadLetterStore(dlPath))
+	s.retryCfg.InitialDelay = time.Millisecond
+	s.retryCfg.MaxDelay = time.Millisecond
+	s.retryCfg.Jitter = false
+	return s
+}
+
+func readDeadLetters(t *testing.T, path string) []WebhookDelivery {
+	t.Helper()
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var out []WebhookDelivery
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var d WebhookDelivery
+		if err := json.Unmarshal(sc.Bytes(), &d); err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+func TestSend(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantErr      bool
+		wantCalls    int32
+		wantLastCode int
+	}{
+		{"delivered", []int{http.StatusNoContent}, false, 1, 0},
+		{"retried until delivered", []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK}, false, 3, 0},
+		{"429 is retried", []int{http.StatusTooManyRequests, http.StatusOK}, false, 2, 0},
+		{"4xx is not retried", []int{http.StatusBadRequest}, true, 1, http.StatusBadRequest},
+		{"retries exhausted", []int{http.StatusServiceUnavailable}, true, 5, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, calls := subscriber(t, tt.statuses...)
+			dlPath := filepath.Join(t.TempDir(), "dead.jsonl")
+			s := newTestSender(dlPath, Subscription{URL: srv.URL, Secret: testSecret})
+
+			err := s.Send(context.Background(), "order.created", map[string]int{"id": 1})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Send error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("subscriber called %d times, want %d", got, tt.wantCalls)
+			}
+			dead := readDeadLetters(t, dlPath)
+			if !tt.wantErr {
+				if len(dead) != 0 {
+					t.Errorf("dead letters = %+v, want none", dead)
+				}
+				return
+			}
+			if len(dead) != 1 {
+				t.Fatalf("dead letters = %+v, want one", dead)
+			}
+			if d := dead[0]; d.URL != srv.URL || d.Event != "order.created" || d.Attempts != int(tt.wantCalls) || d.LastCode != tt.wantLastCode {
+				t.Errorf("dead letter = %+v", d)
+			}
+		})
+	}
+}
+
+func TestSendUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	dlPath := filepath.Join(t.TempDir(), "dead.jsonl")
+	s := newTestSender(dlPath, Subscription{URL: srv.URL})
+	s.retryCfg.MaxAttempts = 2
+
+	if err := s.Send(context.Background(), "order.created", nil); err == nil {
+		t.Fatal("Send to a closed server succeeded")
+	}
+	if dead := readDeadLetters(t, dlPath); len(dead) != 1 || dead[0].Attempts != 2 || dead[0].LastCode != 0 {
+		t.Errorf("dead letters = %+v", dead)
+	}
+}
+
+func TestSendMatchesEvents(t *testing.T) {
+	hit, hitCalls := subscriber(t, http.StatusOK)
+	miss, missCalls := subscriber(t, http.StatusOK)
+	all, allCalls := subscriber(t, http.StatusOK)
+	s := newTestSender(filepath.Join(t.TempDir(), "dead.jsonl"),
+		Subscription{URL: hit.URL, Secret: testSecret, Events: []string{"order.created"}},
+		Subscription{URL: miss.URL, Secret: testSecret, Events: []string{"order.deleted"}},
+		Subscription{URL: all.URL, Secret: testSecret, Events: []string{"*"}},
+	)
+	if err := s.Send(context.Background(), "order.created", nil); err != nil {
+		t.Fatal(err)
+	}
+	if hitCalls.Load() != 1 || missCalls.Load() != 0 || allCalls.Load() != 1 {
+		t.Errorf("calls = %d/%d/%d, want 1/0/1", hitCalls.Load(), missCalls.Load(), allCalls.Load())
+	}
+}
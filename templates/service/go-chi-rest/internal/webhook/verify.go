@@ -0,0 +1,213 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/example/go-chi-rest/internal/errcodes"
+	"github.com/example/go-chi-rest/internal/reqctx"
+	"github.com/example/go-chi-rest/internal/security"
+)
+
+// Signature schemes understood by the verifier
+const (
+	// SchemeHex: the header holds the hex HMAC of the body, optionally prefixed
+	// "sha256=" / "sha1=" (GitHub, and this package's Sender). With a
+	// TimestampHeader the HMAC covers "<timestamp>.<body>" instead, binding
+	// the timestamp to the signature.
+	SchemeHex = "hex"
+	// SchemeStripe: the header is "t=<unix>,v1=<hex>[,v1=…]" and the HMAC covers "<t>.<body>"
+	SchemeStripe = "stripe"
+)
+
+// defaultMaxBodyBytes bounds the body buffered for verification
+const defaultMaxBodyBytes = 1 << 20
+
+// WebhookVerifierConfig configures inbound webhook signature checks
+type WebhookVerifierConfig struct {
+	SignatureHeader    string        `mapstructure:"signature_header"` // e.g. X-Hub-Signature-256
+	Secret             string        `mapstructure:"secret"`
+	Algo               string        `mapstructure:"algo"`             // hmac-sha256 (default) | hmac-sha1 (not in FIPS mode)
+	Scheme             string        `mapstructure:"scheme"`           // hex (default) | stripe
+	TimestampHeader    string        `mapstructure:"timestamp_header"` // unix seconds, signed with the body; ignored by the stripe scheme, which signs its own
+	TimestampTolerance time.Duration `mapstructure:"timestamp_tolerance"`
+	MaxBodyBytes       int64         `mapstructure:"max_body_bytes"` // default 1 MiB
+}
+
+// Verifiers holds named verifier configs, e.g. inbound_webhooks.github
+type Verifiers map[string]WebhookVerifierConfig
+
+// errBadSignature covers every verification failure; the reason is only logged
+var errBadSignature = errors.New("webhook signature mismatch")
+
+// errNoSecret means the verifier has no key and rejects everything
+var errNoSecret = errors.New("webhook verifier has no secret")
+
+// Validate reports settings the verifier cannot work with
+func (c WebhookVerifierConfig) Validate() error {
+	var errs []error
+	if c.SignatureHeader == "" {
+		errs = append(errs, errors.New("signature_header: must be set"))
+	}
+	if c.Secret == "" {
+		errs = append(errs, errors.New("secret: must be set; a verifier without one rejects every request"))
+	}
+	switch c.Algo {
+	case "", "hmac-sha256", "hmac-sha1":
+	default:
+		errs = append(errs, fmt.Errorf("algo: unknown %q (want hmac-sha256|hmac-sha1)", c.Algo))
+	}
+	switch c.Scheme {
+	case "", SchemeHex, SchemeStripe:
+	default:
+		errs = append(errs, fmt.Errorf("scheme: unknown %q (want hex|stripe)", c.Scheme))
+	}
+	return errors.Join(errs...)
+}
+
+// NewWebhookVerifier rejects requests whose signature does not match the body
+// with 401 UNAUTHORIZED. The body is buffered and replaced, so the next handler
+// reads it as usual. With a timestamp configured, requests signed more than
+// TimestampTolerance away from now are rejected too, which stops replays.
+// In FIPS mode only hmac-sha256 is accepted. Without a Secret every request
+// is rejected.
+func NewWebhookVerifier(cfg WebhookVerifierConfig) func(http.Handler) http.Handler {
+	newHash := sha256.New
+	if cfg.Algo == "hmac-sha1" {
+		if security.IsFIPSMode() {
+			zap.L().Fatal("hmac-sha1 webhook signatures are not allowed in FIPS mode", zap.String("header", cfg.SignatureHeader))
+		}
+		newHash = sha1.New
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = defaultMaxBodyBytes
+	}
+	if cfg.Secret == "" {
+		zap.L().Error("webhook verifier has no secret; rejecting all requests", zap.String("header", cfg.SignatureHeader))
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(io.LimitReader(r.Body, cfg.MaxBodyBytes+1))
+			r.Body.Close()
+			if err != nil {
+				errcodes.Write(w, errcodes.FromRequest(r).New("INVALID_REQUEST", "unreadable body"))
+				return
+			}
+			if int64(len(body)) > cfg.MaxBodyBytes {
+				errcodes.Write(w, errcodes.FromRequest(r).New("PAYLOAD_TOO_LARGE", cfg.MaxBodyBytes))
+				return
+			}
+
+			if err := verify(cfg, newHash, r.Header, body, time.Now()); err != nil {
+				reqctx.LoggerFromContext(r.Context()).Warn("rejected inbound webhook",
+					zap.String("header", cfg.SignatureHeader), zap.Error(err))
+				errcodes.Write(w, errcodes.FromRequest(r).New("UNAUTHORIZED"))
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// GitHubWebhookVerifier checks X-Hub-Signature-256 ("sha256=<hex>") against secret
+func GitHubWebhookVerifier(secret string) func(http.Handler) http.Handler {
+	return NewWebhookVerifier(WebhookVerifierConfig{
+		SignatureHeader: "X-Hub-Signature-256",
+		Secret:          secret,
+		Algo:            "hmac-sha256",
+		Scheme:          SchemeHex,
+	})
+}
+
+// StripeWebhookVerifier checks Stripe-Signature against the endpoint secret,
+// rejecting events signed more than five minutes ago as Stripe's libraries do
+func StripeWebhookVerifier(secret string) func(http.Handler) http.Handler {
+	return NewWebhookVerifier(WebhookVerifierConfig{
+		SignatureHeader:    "Stripe-Signature",
+		Secret:             secret,
+		Algo:               "hmac-sha256",
+		Scheme:             SchemeStripe,
+		TimestampTolerance: 5 * time.Minute,
+	})
+}
+
+func verify(cfg WebhookVerifierConfig, newHash func() hash.Hash, h http.Header, body []byte, now time.Time) error {
+	if cfg.Secret == "" {
+		return errNoSecret
+	}
+	header := h.Get(cfg.SignatureHeader)
+	if header == "" {
+		return fmt.Errorf("%w: missing %s", errBadSignature, cfg.SignatureHeader)
+	}
+
+	var (
+		candidates []string
+		timestamp  string
+		payload    = body
+	)
+	if cfg.Scheme == SchemeStripe {
+		for _, part := range strings.Split(header, ",") {
+			k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+			switch k {
+			case "t":
+				timestamp = v
+			case "v1":
+				candidates = append(candidates, v)
+			}
+		}
+		if timestamp == "" {
+			return fmt.Errorf("%w: no timestamp in %s", errBadSignature, cfg.SignatureHeader)
+		}
+		payload = append([]byte(timestamp+"."), body...)
+	} else {
+		if _, v, ok := strings.Cut(header, "="); ok {
+			header = v // drop the "sha256=" style prefix
+		}
+		candidates = []string{header}
+		if cfg.TimestampHeader != "" {
+			timestamp = h.Get(cfg.TimestampHeader)
+			if timestamp == "" {
+				return fmt.Errorf("%w: missing %s", errBadSignature, cfg.TimestampHeader)
+			}
+			payload = append([]byte(timestamp+"."), body...)
+		}
+	}
+
+	if timestamp != "" && cfg.TimestampTolerance > 0 {
+		sec, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: bad timestamp %q", errBadSignature, timestamp)
+		}
+		if d := now.Sub(time.Unix(sec, 0)); d > cfg.TimestampTolerance || d < -cfg.TimestampTolerance {
+			return fmt.Errorf("%w: timestamp outside tolerance (%s)", errBadSignature, d.Round(time.Second))
+		}
+	}
+
+	mac := hmac.New(newHash, []byte(cfg.Secret))
+	mac.Write(payload)
+	expected := mac.Sum(nil)
+	for _, c := range candidates {
+		got, err := hex.DecodeString(c)
+		if err == nil && hmac.Equal(got, expected) {
+			return nil
+		}
+	}
+	return errBadSignature
+}
@@ -0,0 +1,135 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "whsec_test"
+
+func hexMAC(newHash func() hash.Hash, secret, payload string) string {
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func serveWebhook(h func(http.Handler) http.Handler, body string, headers map[string]string) (int, string) {
+	var got string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got = string(b)
+	})
+	req := httptest.NewRequest(http.MethodPost, "/hooks", strings.NewReader(body))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h(next).ServeHTTP(rec, req)
+	return rec.Code, got
+}
+
+func TestGitHubWebhookVerifier(t *testing.T) {
+	body := `{"action":"opened"}`
+	valid := "sha256=" + hexMAC(sha256.New, testSecret, body)
+	tests := []struct {
+		name string
+		body string
+		sig  string
+		want int
+	}{
+		{"valid", body, valid, http.StatusOK},
+		{"tampered body", `{"action":"closed"}`, valid, http.StatusUnauthorized},
+		{"tampered signature", body, "sha256=" + hexMAC(sha256.New, "other", body), http.StatusUnauthorized},
+		{"missing signature", body, "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, got := serveWebhook(GitHubWebhookVerifier(testSecret), tt.body, map[string]string{"X-Hub-Signature-256": tt.sig})
+			if code != tt.want {
+				t.Fatalf("status = %d, want %d", code, tt.want)
+			}
+			if code == http.StatusOK && got != tt.body {
+				t.Errorf("next handler read %q, want the original body", got)
+			}
+		})
+	}
+}
+
+func TestStripeWebhookVerifier(t *testing.T) {
+	body := `{"type":"charge.succeeded"}`
+	now := time.Now().Unix()
+	sign := func(ts int64, b string) string {
+		t := strconv.FormatInt(ts, 10)
+		return "t=" + t + ",v1=" + hexMAC(sha256.New, testSecret, t+"."+b)
+	}
+	tests := []struct {
+		name string
+		body string
+		sig  string
+		want int
+	}{
+		{"valid", body, sign(now, body), http.StatusOK},
+		{"tampered body", body + " ", sign(now, body), http.StatusUnauthorized},
+		{"tampered timestamp", body, strings.Replace(sign(now, body), "t="+strconv.FormatInt(now, 10), "t="+strconv.FormatInt(now+1, 10), 1), http.StatusUnauthorized},
+		{"outside tolerance", body, sign(now-int64(10*time.Minute/time.Second), body), http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code, _ := serveWebhook(StripeWebhookVerifier(testSecret), tt.body, map[string]string{"Stripe-Signature": tt.sig}); code != tt.want {
+				t.Errorf("status = %d, want %d", code, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookVerifierSignsTimestampHeader(t *testing.T) {
+	cfg := WebhookVerifierConfig{
+		SignatureHeader:    "X-Signature",
+		Secret:             testSecret,
+		Algo:               "hmac-sha256",
+		TimestampHeader:    "X-Timestamp",
+		TimestampTolerance: 5 * time.Minute,
+	}
+	body := `{"id":1}`
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	later := strconv.FormatInt(time.Now().Unix()+60, 10)
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		{"timestamp signed with the body", map[string]string{"X-Signature": hexMAC(sha256.New, testSecret, now+"."+body), "X-Timestamp": now}, http.StatusOK},
+		{"timestamp changed after signing", map[string]string{"X-Signature": hexMAC(sha256.New, testSecret, now+"."+body), "X-Timestamp": later}, http.StatusUnauthorized},
+		{"body alone signed", map[string]string{"X-Signature": hexMAC(sha256.New, testSecret, body), "X-Timestamp": now}, http.StatusUnauthorized},
+		{"missing timestamp", map[string]string{"X-Signature": hexMAC(sha256.New, testSecret, now+"."+body)}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code, _ := serveWebhook(NewWebhookVerifier(cfg), body, tt.headers); code != tt.want {
+				t.Errorf("status = %d, want %d", code, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookVerifierWithoutSecretFailsClosed(t *testing.T) {
+	body := `{"id":1}`
+	// an attacker can compute the HMAC under an empty key
+	sig := hexMAC(sha256.New, "", body)
+	h := NewWebhookVerifier(WebhookVerifierConfig{SignatureHeader: "X-Signature"})
+	if code, _ := serveWebhook(h, body, map[string]string{"X-Signature": sig}); code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", code)
+	}
+	if err := (WebhookVerifierConfig{SignatureHeader: "X-Signature"}).Validate(); err == nil {
+		t.Error("Validate accepted an empty secret")
+	}
+}
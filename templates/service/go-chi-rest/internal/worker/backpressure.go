@@ -0,0 +1,71 @@
+package worker
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var requestsShed = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "requests_shed_total",
+	Help: "Requests rejected by backpressure before reaching a handler, by reason.",
+}, []string{"reason"})
+
+// BackpressureConfig configures load shedding in front of handlers that feed the pool
+type BackpressureConfig struct {
+	Enabled       bool    `mapstructure:"enabled"`
+	Pool          *Pool   `mapstructure:"-"`
+	MaxQueueDepth int     `mapstructure:"max_queue_depth"` // shed once the queue holds this many jobs
+	ShedStatus    int     `mapstructure:"shed_status"`     // default 503
+	CPULoadFactor float64 `mapstructure:"cpu_load_factor"` // >0 caps in-flight requests at GOMAXPROCS*factor
+}
+
+// NewBackpressureMiddleware rejects requests with ShedStatus and Retry-After: 1
+// while the worker queue is at MaxQueueDepth, so clients back off instead of
+// piling up jobs that would only be refused by Submit. With CPULoadFactor set,
+// requests are also shed when more than GOMAXPROCS*CPULoadFactor are in flight.
+func NewBackpressureMiddleware(cfg BackpressureConfig) func(http.Handler) http.Handler {
+	if cfg.ShedStatus == 0 {
+		cfg.ShedStatus = http.StatusServiceUnavailable
+	}
+	maxInFlight := int64(0)
+	if cfg.CPULoadFactor > 0 {
+		maxInFlight = int64(float64(runtime.GOMAXPROCS(0)) * cfg.CPULoadFactor)
+		if maxInFlight < 1 {
+			maxInFlight = 1
+		}
+	}
+	var inFlight atomic.Int64
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if cfg.Pool != nil && cfg.Pool.QueueDepth() >= cfg.MaxQueueDepth {
+				shed(w, cfg.ShedStatus, "queue")
+				return
+			}
+			if maxInFlight > 0 {
+				if inFlight.Add(1) > maxInFlight {
+					inFlight.Add(-1)
+					shed(w, cfg.ShedStatus, "cpu")
+					return
+				}
+				defer inFlight.Add(-1)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func shed(w http.ResponseWriter, status int, reason string) {
+	requestsShed.WithLabelValues(reason).Inc()
+	w.Header().Set("Retry-After", "1")
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]string{"code": "LOAD_SHEDDING", "message": "server is overloaded, retry shortly"},
+	})
+}
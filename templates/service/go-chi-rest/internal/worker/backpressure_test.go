@@ -0,0 +1,93 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBackpressureQueueDepth(t *testing.T) {
+	pool := NewPool(PoolConfig{Concurrency: 1, QueueSize: 10}, func(context.Context, Job) error { return nil })
+	for i := 0; i < 5; i++ {
+		pool.Submit(NewJob("j", nil)) // not started: jobs stay queued
+	}
+
+	tests := []struct {
+		name     string
+		maxDepth int
+		status   int
+		want     int
+	}{
+		{"depth 0 sheds everything", 0, 0, http.StatusServiceUnavailable},
+		{"at depth sheds", 5, 0, http.StatusServiceUnavailable},
+		{"custom shed status", 5, http.StatusTooManyRequests, http.StatusTooManyRequests},
+		{"below depth passes", 100, 0, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := 0
+			h := NewBackpressureMiddleware(BackpressureConfig{Pool: pool, MaxQueueDepth: tt.maxDepth, ShedStatus: tt.status})(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called++ }))
+			for i := 0; i < 10; i++ {
+				rec := httptest.NewRecorder()
+				h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/jobs", nil))
+				if rec.Code != tt.want {
+					t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+				}
+				if tt.want != http.StatusOK {
+					var body struct {
+						Error struct{ Code string } `json:"error"`
+					}
+					json.Unmarshal(rec.Body.Bytes(), &body)
+					if body.Error.Code != "LOAD_SHEDDING" || rec.Header().Get("Retry-After") != "1" {
+						t.Errorf("shed response = %s %v", rec.Body, rec.Header())
+					}
+				}
+			}
+			wantCalls := 0
+			if tt.want == http.StatusOK {
+				wantCalls = 10
+			}
+			if called != wantCalls {
+				t.Errorf("next called %d times, want %d", called, wantCalls)
+			}
+		})
+	}
+}
+
+func TestBackpressureInFlight(t *testing.T) {
+	entered, release := make(chan struct{}), make(chan struct{})
+	// a factor this small still allows one request in flight
+	h := NewBackpressureMiddleware(BackpressureConfig{CPULoadFactor: 0.0001})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			entered <- struct{}{}
+			<-release
+		}))
+
+	done := make(chan int)
+	go func() {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		done <- rec.Code
+	}()
+	<-entered
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("second request status = %d, want 503", rec.Code)
+	}
+	close(release)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("first request status = %d, want 200", code)
+	}
+
+	go func() { <-entered }()
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status after the first finished = %d, want 200", rec.Code)
+	}
+}
@@ -0,0 +1,200 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+var (
+	queueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "worker_queue_depth",
+		Help: "Number of jobs waiting in the worker queue.",
+	})
+	jobsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "worker_jobs_total",
+		Help: "Jobs processed by the worker pool, by status.",
+	}, []string{"status"})
+	jobDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name: "worker_job_duration_seconds",
+		Help: "Time spent processing a single job.",
+	})
+)
+
+var (
+	ErrQueueFull  = errors.New("worker queue is full")
+	ErrPoolClosed = errors.New("worker pool is closed")
+)
+
+// Job is a unit of background work
+type Job interface {
+	ID() string
+	Payload() []byte
+}
+
+// Runnable is a Job that carries its own work; the pool calls Run instead
+// of its Handler
+type Runnable interface {
+	Job
+	Run(ctx context.Context) error
+}
+
+// Handler processes a single job
+type Handler func(ctx context.Context, job Job) error
+
+// PoolConfig configures the worker pool
+type PoolConfig struct {
+	Concurrency int `mapstructure:"concurrency"`
+	QueueSize   int `mapstructure:"queue_size"`
+}
+
+// Pool runs jobs on a fixed number of goroutines fed by a buffered queue
+type Pool struct {
+	Concurrency int
+
+	handler Handler
+	jobs    chan Job
+	wg      sync.WaitGroup
+
+	// jobCtx is passed to jobs; Drain cancels it when its deadline passes
+	jobCtx     context.Context
+	cancelJobs context.CancelFunc
+
+	mu     sync.RWMutex
+	closed bool
+	once   sync.Once
+}
+
+// NewPool creates a pool; call Start to begin processing
+func NewPool(cfg PoolConfig, handler Handler) *Pool {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = cfg.Concurrency * 10
+	}
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+	return &Pool{
+		Concurrency: cfg.Concurrency,
+		handler:     handler,
+		jobs:        make(chan Job, cfg.QueueSize),
+		jobCtx:      jobCtx,
+		cancelJobs:  cancelJobs,
+	}
+}
+
+// Submit enqueues a job without blocking
+func (p *Pool) Submit(job Job) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrPoolClosed
+	}
+	select {
+	case p.jobs <- job:
+		queueDepth.Inc()
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// QueueDepth returns the number of jobs waiting to be picked up
+func (p *Pool) QueueDepth() int {
+	return len(p.jobs)
+}
+
+// Start spins up the workers. Cancelling ctx stops intake; jobs already queued are
+// still processed so Drain can wait for them.
+func (p *Pool) Start(ctx context.Context) {
+	for i := 0; i < p.Concurrency; i++ {
+		p.wg.Add(1)
+		go p.work()
+	}
+	go func() {
+		<-ctx.Done()
+		p.close()
+	}()
+}
+
+// Drain stops intake and blocks until all queued and in-flight jobs have
+// finished or ctx is done. On ctx expiry the context passed to jobs is
+// cancelled and the error says how many jobs were still queued.
+func (p *Pool) Drain(ctx context.Context) error {
+	p.close()
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		p.cancelJobs()
+		return nil
+	case <-ctx.Done():
+		p.cancelJobs()
+		return fmt.Errorf("worker pool: %d jobs still queued: %w", len(p.jobs), ctx.Err())
+	}
+}
+
+func (p *Pool) close() {
+	p.once.Do(func() {
+		p.mu.Lock()
+		p.closed = true
+		close(p.jobs)
+		p.mu.Unlock()
+	})
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+	for job := range p.jobs {
+		queueDepth.Dec()
+		p.run(job)
+	}
+}
+
+// run executes a job with the pool's context so stopping intake does not
+// abort in-flight work; only an expired Drain does
+func (p *Pool) run(job Job) {
+	start := time.Now()
+	err := func() (err error) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				err = fmt.Errorf("panic: %v", rec)
+			}
+		}()
+		if r, ok := job.(Runnable); ok {
+			return r.Run(p.jobCtx)
+		}
+		return p.handler(p.jobCtx, job)
+	}()
+	jobDuration.Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		jobsTotal.WithLabelValues("error").Inc()
+		zap.L().Error("job failed", zap.String("job_id", job.ID()), zap.Error(err))
+		return
+	}
+	jobsTotal.WithLabelValues("success").Inc()
+}
+
+// basicJob is a simple Job implementation
+type basicJob struct {
+	id      string
+	payload []byte
+}
+
+func (j basicJob) ID() string      { return j.id }
+func (j basicJob) Payload() []byte { return j.payload }
+
+// NewJob returns a Job carrying an opaque payload
+func NewJob(id string, payload []byte) Job {
+	return basicJob{id: id, payload: payload}
+}
@@ -0,0 +1,74 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPoolDrainsAfterCancel(t *testing.T) {
+	var done atomic.Int32
+	started := make(chan struct{}, 100)
+	p := NewPool(PoolConfig{Concurrency: 10, QueueSize: 100}, func(ctx context.Context, job Job) error {
+		started <- struct{}{}
+		time.Sleep(time.Millisecond)
+		done.Add(1)
+		return nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	p.Start(ctx)
+	for i := 0; i < 100; i++ {
+		if err := p.Submit(NewJob(strconv.Itoa(i), nil)); err != nil {
+			t.Fatalf("submit %d: %v", i, err)
+		}
+	}
+	<-started
+	cancel() // mid-flight: stops intake, queued jobs still run
+
+	if err := p.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := done.Load(); n != 100 {
+		t.Errorf("completed %d jobs, want 100", n)
+	}
+	if err := p.Submit(NewJob("late", nil)); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("submit after drain = %v, want ErrPoolClosed", err)
+	}
+}
+
+func TestPoolDrainDeadline(t *testing.T) {
+	var cancelled atomic.Bool
+	p := NewPool(PoolConfig{Concurrency: 1, QueueSize: 10}, func(ctx context.Context, job Job) error {
+		<-ctx.Done()
+		cancelled.Store(true)
+		return ctx.Err()
+	})
+	p.Start(context.Background())
+	for i := 0; i < 3; i++ {
+		p.Submit(NewJob(strconv.Itoa(i), nil))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := p.Drain(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Drain = %v, want DeadlineExceeded", err)
+	}
+	p.wg.Wait() // cancelled jobs return promptly
+	if !cancelled.Load() {
+		t.Error("job context was not cancelled")
+	}
+}
+
+func TestSubmitQueueFull(t *testing.T) {
+	p := NewPool(PoolConfig{Concurrency: 1, QueueSize: 1}, func(context.Context, Job) error { return nil })
+	if err := p.Submit(NewJob("a", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Submit(NewJob("b", nil)); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("second submit = %v, want ErrQueueFull", err)
+	}
+}
@@ -0,0 +1,50 @@
+package graph
+
+//go:generate go run github.com/99designs/gqlgen generate
+
+import (
+	"sync"
+
+	"github.com/example/go-graphql/graph/model"
+)
+
+// Resolver is the root resolver; it holds the dependencies shared by all resolvers.
+// The in-memory store is a placeholder for a real repository.
+type Resolver struct {
+	mu     sync.RWMutex
+	todos  []*model.Todo
+	nextID int
+
+	subMu       sync.Mutex
+	subscribers map[chan *model.Todo]struct{}
+}
+
+// NewResolver returns a Resolver with an empty store
+func NewResolver() *Resolver {
+	return &Resolver{subscribers: make(map[chan *model.Todo]struct{})}
+}
+
+// subscribe registers a listener for new todos; call the returned func to remove it
+func (r *Resolver) subscribe() (chan *model.Todo, func()) {
+	ch := make(chan *model.Todo, 16)
+	r.subMu.Lock()
+	r.subscribers[ch] = struct{}{}
+	r.subMu.Unlock()
+	return ch, func() {
+		r.subMu.Lock()
+		delete(r.subscribers, ch)
+		r.subMu.Unlock()
+	}
+}
+
+// publish fans a new todo out to subscribers, dropping it for slow consumers
+func (r *Resolver) publish(t *model.Todo) {
+	r.subMu.Lock()
+	defer r.subMu.Unlock()
+	for ch := range r.subscribers {
+		select {
+		case ch <- t:
+		default:
+		}
+	}
+}
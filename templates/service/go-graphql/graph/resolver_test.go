@@ -0,0 +1,60 @@
+package graph
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/example/go-graphql/graph/model"
+)
+
+func TestResolversReturnCopies(t *testing.T) {
+	ctx := context.Background()
+	r := NewResolver()
+	created, err := r.Mutation().CreateTodo(ctx, model.NewTodo{Text: " write tests "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Text != "write tests" {
+		t.Errorf("text = %q", created.Text)
+	}
+
+	toggled, err := r.Mutation().ToggleTodo(ctx, created.ID)
+	if err != nil || !toggled.Done {
+		t.Fatalf("toggle = %+v, %v", toggled, err)
+	}
+	toggled.Text, created.Text = "changed", "changed"
+	got, _ := r.Query().Todo(ctx, created.ID)
+	if got.Text != "write tests" || !got.Done {
+		t.Errorf("stored todo = %+v; a returned value aliases the store", got)
+	}
+	got.Done = false
+	all, _ := r.Query().Todos(ctx)
+	if len(all) != 1 || !all[0].Done {
+		t.Errorf("todos = %+v", all)
+	}
+}
+
+// Run with -race: serialising a returned todo must not race with toggles
+func TestToggleTodoConcurrent(t *testing.T) {
+	ctx := context.Background()
+	r := NewResolver()
+	todo, _ := r.Mutation().CreateTodo(ctx, model.NewTodo{Text: "race"})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				got, err := r.Mutation().ToggleTodo(ctx, todo.ID)
+				if err == nil {
+					_ = got.Done
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if _, err := r.Mutation().ToggleTodo(ctx, "missing"); err == nil {
+		t.Error("toggling an unknown id succeeded")
+	}
+}
@@ -0,0 +1,114 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.81
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/go-graphql/graph/model"
+)
+
+// CreateTodo is the resolver for the createTodo field.
+func (r *mutationResolver) CreateTodo(ctx context.Context, input model.NewTodo) (*model.Todo, error) {
+	text := strings.TrimSpace(input.Text)
+	if text == "" {
+		return nil, fmt.Errorf("text must not be empty")
+	}
+
+	r.mu.Lock()
+	r.nextID++
+	todo := &model.Todo{
+		ID:        strconv.Itoa(r.nextID),
+		Text:      text,
+		CreatedAt: time.Now().UTC(),
+	}
+	r.todos = append(r.todos, todo)
+	created := *todo
+	r.mu.Unlock()
+
+	r.publish(&created)
+	return &created, nil
+}
+
+// ToggleTodo is the resolver for the toggleTodo field.
+func (r *mutationResolver) ToggleTodo(ctx context.Context, id string) (*model.Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.todos {
+		if t.ID == id {
+			t.Done = !t.Done
+			// a copy, so serialising it needs no lock
+			todo := *t
+			return &todo, nil
+		}
+	}
+	return nil, fmt.Errorf("todo %q not found", id)
+}
+
+// Todos is the resolver for the todos field.
+func (r *queryResolver) Todos(ctx context.Context) ([]*model.Todo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]*model.Todo, len(r.todos))
+	for i, t := range r.todos {
+		todo := *t
+		out[i] = &todo
+	}
+	return out, nil
+}
+
+// Todo is the resolver for the todo field.
+func (r *queryResolver) Todo(ctx context.Context, id string) (*model.Todo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, t := range r.todos {
+		if t.ID == id {
+			todo := *t
+			return &todo, nil
+		}
+	}
+	return nil, nil
+}
+
+// TodoCreated is the resolver for the todoCreated field.
+func (r *subscriptionResolver) TodoCreated(ctx context.Context) (<-chan *model.Todo, error) {
+	events, unsubscribe := r.subscribe()
+	out := make(chan *model.Todo, 1)
+	go func() {
+		// ctx is cancelled when the client disconnects or completes the subscription
+		defer close(out)
+		defer unsubscribe()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case t := <-events:
+				select {
+				case out <- t:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// Mutation returns MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// Subscription returns SubscriptionResolver implementation.
+func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
+
+type mutationResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
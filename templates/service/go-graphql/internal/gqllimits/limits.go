@@ -0,0 +1,158 @@
+// Package gqllimits rejects GraphQL operations that are too expensive to run:
+// ComplexityLimit caps the gqlgen complexity score and MaxDepthRule caps field
+// nesting. Introspection is exempt from both; gqlparser's MaxIntrospectionDepth
+// rule already bounds it.
+package gqllimits
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/99designs/gqlgen/complexity"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/validator"
+)
+
+var (
+	queryComplexity = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "graphql_query_complexity_histogram",
+		Help:    "Complexity score of GraphQL operations, introspection excluded.",
+		Buckets: []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500},
+	})
+	queriesRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "graphql_query_rejected_total",
+		Help: "GraphQL operations rejected by query limits, by reason (complexity|depth).",
+	}, []string{"reason"})
+)
+
+// CodeTooComplex is the "code" extension of complexity rejections
+const CodeTooComplex = "QUERY_TOO_COMPLEX"
+
+// ComplexityLimit is a gqlgen handler extension that rejects operations whose
+// complexity exceeds Max. Unlike extension.FixedComplexityLimit the error is
+// an ordinary GraphQL error (HTTP 200) carrying the score and the limit:
+//
+//	{"errors":[{"message":"query too complex","extensions":{"code":"QUERY_TOO_COMPLEX","complexity":N,"max":M}}]}
+type ComplexityLimit struct {
+	Max int
+
+	es graphql.ExecutableSchema
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = &ComplexityLimit{}
+
+// ExtensionName implements graphql.HandlerExtension
+func (*ComplexityLimit) ExtensionName() string { return "QueryComplexityLimit" }
+
+// Validate implements graphql.HandlerExtension
+func (c *ComplexityLimit) Validate(es graphql.ExecutableSchema) error {
+	if c.Max <= 0 {
+		return errors.New("query complexity limit must be positive")
+	}
+	c.es = es
+	return nil
+}
+
+// MutateOperationContext implements graphql.OperationContextMutator
+func (c *ComplexityLimit) MutateOperationContext(ctx context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	op := opCtx.Doc.Operations.ForName(opCtx.OperationName)
+	if op == nil || isIntrospection(op) {
+		return nil
+	}
+	score := complexity.Calculate(ctx, c.es, op, opCtx.Variables)
+	queryComplexity.Observe(float64(score))
+	if score <= c.Max {
+		return nil
+	}
+	queriesRejected.WithLabelValues("complexity").Inc()
+	return &gqlerror.Error{
+		Message: "query too complex",
+		Extensions: map[string]any{
+			"code":       CodeTooComplex,
+			"complexity": score,
+			"max":        c.Max,
+		},
+	}
+}
+
+// MaxDepthRule is a validation rule that rejects operations nesting fields
+// more than limit levels deep; { todos { id } } has depth 2. Fragments count
+// at the depth they are spread and introspection fields are skipped. gqlgen
+// reports validation failures with code GRAPHQL_VALIDATION_FAILED, so the
+// depth and limit are carried in the "depth" and "max" extensions.
+func MaxDepthRule(limit int) validator.Rule {
+	return validator.Rule{
+		Name: "MaxQueryDepth",
+		RuleFunc: func(observers *validator.Events, addError validator.AddErrFunc) {
+			observers.OnOperation(func(walker *validator.Walker, op *ast.OperationDefinition) {
+				depth := selectionDepth(walker.Document, op.SelectionSet, map[string]bool{})
+				if depth <= limit {
+					return
+				}
+				queriesRejected.WithLabelValues("depth").Inc()
+				addError(
+					validator.Message("query too deep"),
+					func(err *gqlerror.Error) {
+						err.Extensions = map[string]any{"depth": depth, "max": limit}
+						if op.Position != nil {
+							err.Locations = []gqlerror.Location{{Line: op.Position.Line, Column: op.Position.Column}}
+						}
+					},
+				)
+			})
+		},
+	}
+}
+
+// selectionDepth returns the deepest field nesting under set. visiting holds
+// the fragments on the current path so a fragment cycle (reported by
+// NoFragmentCycles) does not recurse forever.
+func selectionDepth(doc *ast.QueryDocument, set ast.SelectionSet, visiting map[string]bool) int {
+	deepest := 0
+	for _, sel := range set {
+		d := 0
+		switch s := sel.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name, "__") {
+				continue
+			}
+			d = 1 + selectionDepth(doc, s.SelectionSet, visiting)
+		case *ast.InlineFragment:
+			d = selectionDepth(doc, s.SelectionSet, visiting)
+		case *ast.FragmentSpread:
+			frag := doc.Fragments.ForName(s.Name)
+			if frag == nil || visiting[s.Name] {
+				continue
+			}
+			visiting[s.Name] = true
+			d = selectionDepth(doc, frag.SelectionSet, visiting)
+			delete(visiting, s.Name)
+		}
+		if d > deepest {
+			deepest = d
+		}
+	}
+	return deepest
+}
+
+// isIntrospection reports whether op only selects __schema, __type or __typename
+func isIntrospection(op *ast.OperationDefinition) bool {
+	if len(op.SelectionSet) == 0 {
+		return false
+	}
+	for _, sel := range op.SelectionSet {
+		f, ok := sel.(*ast.Field)
+		if !ok || !strings.HasPrefix(f.Name, "__") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package gqllimits
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/vektah/gqlparser/v2/validator/rules"
+
+	"github.com/example/go-graphql/graph"
+)
+
+const introspectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    types {
+      kind name
+      fields(includeDeprecated: true) {
+        name
+        args { name type { kind name ofType { kind name } } }
+        type { kind name ofType { kind name ofType { kind name ofType { kind name } } } }
+      }
+    }
+  }
+}`
+
+// newServer wires the limits as cmd/server does
+func newServer(maxComplexity, maxDepth int) http.Handler {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: graph.NewResolver()}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(&ComplexityLimit{Max: maxComplexity})
+	depthRule := MaxDepthRule(maxDepth)
+	srv.SetValidationRulesFn(func() *rules.Rules {
+		rs := rules.NewDefaultRules()
+		rs.AddRule(depthRule.Name, depthRule.RuleFunc)
+		return rs
+	})
+	srv.Use(extension.Introspection{})
+	return srv
+}
+
+type gqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func post(t *testing.T, h http.Handler, query string) gqlResponse {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"query": query})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	var resp gqlResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode %s: %v", rec.Body, err)
+	}
+	return resp
+}
+
+func TestIntrospectionExempt(t *testing.T) {
+	h := newServer(3, 1)
+	complexity := testutil.ToFloat64(queriesRejected.WithLabelValues("complexity"))
+	depth := testutil.ToFloat64(queriesRejected.WithLabelValues("depth"))
+
+	resp := post(t, h, introspectionQuery)
+	if len(resp.Errors) != 0 || !strings.Contains(string(resp.Data), `"Todo"`) {
+		t.Fatalf("introspection rejected: %+v", resp.Errors)
+	}
+	if testutil.ToFloat64(queriesRejected.WithLabelValues("complexity")) != complexity ||
+		testutil.ToFloat64(queriesRejected.WithLabelValues("depth")) != depth {
+		t.Error("introspection counted as a rejection")
+	}
+}
+
+func TestComplexityLimit(t *testing.T) {
+	h := newServer(4, 10)
+	before := testutil.ToFloat64(queriesRejected.WithLabelValues("complexity"))
+
+	if resp := post(t, h, `{ todo(id: "1") { id } }`); len(resp.Errors) != 0 {
+		t.Fatalf("cheap query rejected: %+v", resp.Errors)
+	}
+
+	// todos (1) + four leaf fields
+	resp := post(t, h, `{ todos { id text done createdAt } }`)
+	if len(resp.Errors) != 1 || string(resp.Data) != "null" {
+		t.Fatalf("response = %s %+v, want one error and no data", resp.Data, resp.Errors)
+	}
+	err := resp.Errors[0]
+	if err.Message != "query too complex" || err.Extensions["code"] != CodeTooComplex ||
+		err.Extensions["complexity"] != float64(5) || err.Extensions["max"] != float64(4) {
+		t.Errorf("error = %+v", err)
+	}
+	if got := testutil.ToFloat64(queriesRejected.WithLabelValues("complexity")); got != before+1 {
+		t.Errorf("rejected{complexity} = %v, want %v", got, before+1)
+	}
+}
+
+func TestMaxDepthRule(t *testing.T) {
+	h := newServer(1000, 1)
+	tests := []struct {
+		name, query string
+		wantDepth   float64 // 0 when the query is accepted
+	}{
+		{"flat", `{ __typename }`, 0},
+		{"nested", `{ todos { id } }`, 2},
+		{"through fragment", `{ ...Q } fragment Q on Query { todos { ...T } } fragment T on Todo { id }`, 2},
+		{"through inline fragment", `{ ... on Query { todo(id: "1") { id } } }`, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := post(t, h, tt.query)
+			if tt.wantDepth == 0 {
+				if len(resp.Errors) != 0 {
+					t.Fatalf("rejected: %+v", resp.Errors)
+				}
+				return
+			}
+			if len(resp.Errors) != 1 {
+				t.Fatalf("errors = %+v, want one", resp.Errors)
+			}
+			err := resp.Errors[0]
+			if err.Message != "query too deep" || err.Extensions["depth"] != tt.wantDepth || err.Extensions["max"] != float64(1) {
+				t.Errorf("error = %+v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package gqlmetrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var resolverDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "graphql_resolver_duration_seconds",
+	Help:    "Time spent in GraphQL field resolvers, by parent type and field.",
+	Buckets: []float64{.0005, .001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+}, []string{"type", "field"})
+
+// Extension is a gqlgen handler extension that records resolver latency.
+// Only fields backed by a resolver method are observed; trivial struct field
+// reads would add label cardinality without useful signal.
+type Extension struct{}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.FieldInterceptor
+} = Extension{}
+
+// ExtensionName implements graphql.HandlerExtension
+func (Extension) ExtensionName() string { return "PrometheusResolverMetrics" }
+
+// Validate implements graphql.HandlerExtension
+func (Extension) Validate(graphql.ExecutableSchema) error { return nil }
+
+// InterceptField implements graphql.FieldInterceptor
+func (Extension) InterceptField(ctx context.Context, next graphql.Resolver) (any, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !fc.IsResolver {
+		return next(ctx)
+	}
+	start := time.Now()
+	res, err := next(ctx)
+	resolverDuration.WithLabelValues(fc.Object, fc.Field.Name).Observe(time.Since(start).Seconds())
+	return res, err
+}
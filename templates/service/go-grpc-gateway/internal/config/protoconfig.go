@@ -0,0 +1,107 @@
+// Package config validates the merged viper configuration against the
+// protobuf schema in proto/config/v1, whose fields carry protovalidate rules
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/bufbuild/protovalidate-go"
+	"github.com/spf13/cast"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+var (
+	validatorOnce sync.Once
+	validator     *protovalidate.Validator
+	validatorErr  error
+)
+
+// ValidateProtoConfig checks msg against the buf.validate rules declared in
+// its schema. The returned error lists every violated field by path.
+func ValidateProtoConfig(msg proto.Message) error {
+	validatorOnce.Do(func() {
+		validator, validatorErr = protovalidate.New()
+	})
+	if validatorErr != nil {
+		return fmt.Errorf("init protovalidate: %w", validatorErr)
+	}
+	if err := validator.Validate(msg); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	return nil
+}
+
+// FromSettings fills msg from a viper settings map (viper.AllSettings()),
+// going through structpb and protojson. Keys without a matching field are
+// ignored; values are coerced the way viper.Unmarshal would (env vars arrive
+// as strings, durations as "15s" or nanoseconds).
+func FromSettings(settings map[string]any, msg proto.Message) error {
+	normalized, err := normalize(settings, msg.ProtoReflect().Descriptor())
+	if err != nil {
+		return err
+	}
+	st, err := structpb.NewStruct(normalized)
+	if err != nil {
+		return fmt.Errorf("config to struct: %w", err)
+	}
+	data, err := protojson.Marshal(st)
+	if err != nil {
+		return err
+	}
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, msg); err != nil {
+		return fmt.Errorf("config to %s: %w", msg.ProtoReflect().Descriptor().FullName(), err)
+	}
+	return nil
+}
+
+// normalize keeps the keys of m that are fields of md and converts their
+// values to what protojson expects for the field's type
+func normalize(m map[string]any, md protoreflect.MessageDescriptor) (map[string]any, error) {
+	out := make(map[string]any, len(m))
+	for key, val := range m {
+		fd := md.Fields().ByName(protoreflect.Name(key))
+		if fd == nil || val == nil {
+			continue
+		}
+		v, err := normalizeValue(val, fd)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		out[key] = v
+	}
+	return out, nil
+}
+
+func normalizeValue(val any, fd protoreflect.FieldDescriptor) (any, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return cast.ToBoolE(val)
+	case protoreflect.StringKind:
+		return cast.ToStringE(val)
+	case protoreflect.Int32Kind, protoreflect.Int64Kind, protoreflect.Uint32Kind, protoreflect.Uint64Kind,
+		protoreflect.Sint32Kind, protoreflect.Sint64Kind:
+		n, err := cast.ToInt64E(val)
+		return float64(n), err
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return cast.ToFloat64E(val)
+	case protoreflect.MessageKind:
+		if fd.Message().FullName() == "google.protobuf.Duration" {
+			d, err := cast.ToDurationE(val)
+			if err != nil {
+				return nil, err
+			}
+			return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s", nil
+		}
+		sub, ok := val.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("expected a section, got %T", val)
+		}
+		return normalize(sub, fd.Message())
+	}
+	return val, nil
+}
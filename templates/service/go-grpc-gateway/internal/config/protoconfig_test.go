@@ -0,0 +1,94 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+
+	configv1 "github.com/example/go-grpc-gateway/gen/config/v1"
+)
+
+// exampleSettings loads the documented example config the way the server does
+func exampleSettings(t *testing.T) map[string]any {
+	t.Helper()
+	v := viper.New()
+	v.SetConfigFile(filepath.Join("..", "..", "proto", "config", "v1", "config.yaml"))
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	return v.AllSettings()
+}
+
+func TestExampleConfigIsValid(t *testing.T) {
+	var cfg configv1.ServerConfig
+	if err := FromSettings(exampleSettings(t), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateProtoConfig(&cfg); err != nil {
+		t.Fatalf("example config rejected: %v", err)
+	}
+	if cfg.GetBindAddr() != ":8080" || cfg.GetIdleTimeout().AsDuration() != 2*time.Minute ||
+		!cfg.GetEnableMetrics() || cfg.GetHealth().GetCheckTimeout().AsDuration() != 2*time.Second {
+		t.Errorf("mapped config = %v", &cfg)
+	}
+}
+
+func TestValidateProtoConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		override   map[string]any
+		wantFields []string // empty: valid
+	}{
+		{"valid", nil, nil},
+		{"ipv6 bind address", map[string]any{"bind_addr": "[::1]:8080"}, nil},
+		{"env var strings", map[string]any{"enable_metrics": "false", "read_timeout": "30s"}, nil},
+		{"bad bind address", map[string]any{"bind_addr": "localhost"}, []string{"bind_addr"}},
+		{"bad log level", map[string]any{"log_level": "verbose"}, []string{"log_level"}},
+		{"zero timeout", map[string]any{"read_timeout": "0s"}, []string{"read_timeout"}},
+		{"nested field", map[string]any{"health": map[string]any{"check_timeout": "1m"}}, []string{"health.check_timeout"}},
+		{"several", map[string]any{"bind_addr": "8080", "environment": "qa"}, []string{"bind_addr", "environment"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := exampleSettings(t)
+			for k, v := range tt.override {
+				settings[k] = v
+			}
+			var cfg configv1.ServerConfig
+			if err := FromSettings(settings, &cfg); err != nil {
+				t.Fatal(err)
+			}
+			err := ValidateProtoConfig(&cfg)
+			if len(tt.wantFields) == 0 {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("invalid config accepted")
+			}
+			for _, f := range tt.wantFields {
+				if !strings.Contains(err.Error(), f) {
+					t.Errorf("error %q does not name %s", err, f)
+				}
+			}
+		})
+	}
+}
+
+func TestFromSettingsRejectsBadValues(t *testing.T) {
+	for name, settings := range map[string]map[string]any{
+		"duration": {"read_timeout": "soon"},
+		"bool":     {"enable_metrics": "maybe"},
+		"section":  {"health": "2s"},
+	} {
+		var cfg configv1.ServerConfig
+		if err := FromSettings(settings, &cfg); err == nil {
+			t.Errorf("%s: FromSettings succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/example/go-grpc-gateway/internal/ping"
+)
+
+// AppError mirrors the {"error":{"code","message"}} body used by the other service templates
+type AppError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// appCodes maps gRPC status codes to the shared error code catalogue;
+// NOT_IMPLEMENTED also covers unsupported methods on known routes
+var appCodes = map[codes.Code]string{
+	codes.InvalidArgument:    "INVALID_REQUEST",
+	codes.OutOfRange:         "INVALID_REQUEST",
+	codes.FailedPrecondition: "VALIDATION_FAILED",
+	codes.Unauthenticated:    "UNAUTHORIZED",
+	codes.PermissionDenied:   "FORBIDDEN",
+	codes.NotFound:           "RESOURCE_NOT_FOUND",
+	codes.AlreadyExists:      "CONFLICT",
+	codes.Aborted:            "CONFLICT",
+	codes.ResourceExhausted:  "RATE_LIMITED",
+	codes.Unavailable:        "SERVICE_UNAVAILABLE",
+	codes.DeadlineExceeded:   "TIMEOUT",
+	codes.Unimplemented:      "NOT_IMPLEMENTED",
+}
+
+// NewServeMux returns a gateway mux that forwards the chi request ID as
+// request-id metadata and renders errors in the AppError shape
+func NewServeMux(opts ...runtime.ServeMuxOption) *runtime.ServeMux {
+	opts = append([]runtime.ServeMuxOption{
+		runtime.WithMetadata(requestIDMetadata),
+		runtime.WithIncomingHeaderMatcher(headerMatcher),
+		runtime.WithErrorHandler(ErrorHandler),
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions:   protojson.MarshalOptions{EmitUnpopulated: true},
+			UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+		}),
+	}, opts...)
+	return runtime.NewServeMux(opts...)
+}
+
+// requestIDMetadata copies the request ID assigned by middleware.RequestID, which
+// honours an incoming X-Request-ID header, into outgoing gRPC metadata
+func requestIDMetadata(ctx context.Context, r *http.Request) metadata.MD {
+	id := middleware.GetReqID(ctx)
+	if id == "" {
+		id = r.Header.Get(middleware.RequestIDHeader)
+	}
+	if id == "" {
+		return nil
+	}
+	return metadata.Pairs(ping.RequestIDKey, id)
+}
+
+// headerMatcher keeps the default grpcgateway- forwarding but drops X-Request-ID,
+// which requestIDMetadata already forwards as request-id
+func headerMatcher(key string) (string, bool) {
+	if http.CanonicalHeaderKey(key) == middleware.RequestIDHeader {
+		return "", false
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// ErrorHandler implements runtime.ErrorHandlerFunc, translating gRPC statuses
+// and routing errors into {"error":{"code","message"}} responses
+func ErrorHandler(_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	var statusErr *runtime.HTTPStatusError
+	httpStatus := 0
+	if errors.As(err, &statusErr) {
+		httpStatus = statusErr.HTTPStatus
+		err = statusErr.Err
+	}
+
+	s := status.Convert(err)
+	if httpStatus == 0 {
+		httpStatus = runtime.HTTPStatusFromCode(s.Code())
+	}
+	code, ok := appCodes[s.Code()]
+	if !ok {
+		code = "INTERNAL_ERROR"
+	}
+	msg := s.Message()
+	switch s.Code() {
+	case codes.Internal, codes.Unknown, codes.DataLoss:
+		// don't leak backend internals to clients
+		zap.L().Error("grpc backend error",
+			zap.String("path", r.URL.Path),
+			zap.String("grpc_code", s.Code().String()),
+			zap.String("message", s.Message()),
+		)
+		msg = http.StatusText(httpStatus)
+	}
+
+	if s.Code() == codes.Unauthenticated {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+	w.Header().Del("Trailer")
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(httpStatus)
+	json.NewEncoder(w).Encode(map[string]AppError{
+		"error": {Code: code, Message: msg},
+	})
+}
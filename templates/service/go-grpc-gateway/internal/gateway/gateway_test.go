@@ -0,0 +1,157 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	pingv1 "github.com/example/go-grpc-gateway/gen/ping/v1"
+	"github.com/example/go-grpc-gateway/internal/ping"
+)
+
+// newTestRouter serves srv on an in-process gRPC server and mounts a gateway
+// talking to it under /api/v1, as cmd/server does
+func newTestRouter(t *testing.T, srv pingv1.PingServiceServer) http.Handler {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	grpcSrv := grpc.NewServer()
+	pingv1.RegisterPingServiceServer(grpcSrv, srv)
+	go grpcSrv.Serve(lis)
+	t.Cleanup(grpcSrv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	gwMux := NewServeMux()
+	if err := pingv1.RegisterPingServiceHandler(context.Background(), gwMux, conn); err != nil {
+		t.Fatal(err)
+	}
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Handle("/*", gwMux)
+	})
+	return r
+}
+
+func do(h http.Handler, method, path, body string, headers map[string]string) (*httptest.ResponseRecorder, map[string]any) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	var out map[string]any
+	json.Unmarshal(rec.Body.Bytes(), &out)
+	return rec, out
+}
+
+func TestGatewayPing(t *testing.T) {
+	h := newTestRouter(t, ping.NewService())
+
+	rec, body := do(h, http.MethodGet, "/api/v1/ping", "", map[string]string{"X-Request-ID": "req-123"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	if body["message"] != "pong" || body["requestId"] != "req-123" {
+		t.Errorf("body = %v, want pong with the forwarded request ID", body)
+	}
+
+	// without an incoming header the ID assigned by middleware.RequestID is forwarded
+	_, body = do(h, http.MethodGet, "/api/v1/ping", "", nil)
+	if id, _ := body["requestId"].(string); id == "" {
+		t.Errorf("body = %v, want a generated request ID", body)
+	}
+}
+
+func TestGatewayEcho(t *testing.T) {
+	h := newTestRouter(t, ping.NewService())
+	tests := []struct {
+		name, body string
+		want       int
+		wantCode   string
+	}{
+		{"echo", `{"message":" hi "}`, http.StatusOK, ""},
+		{"unknown fields ignored", `{"message":"hi","extra":1}`, http.StatusOK, ""},
+		{"empty message", `{"message":""}`, http.StatusBadRequest, "INVALID_REQUEST"},
+		{"malformed json", `{"message":`, http.StatusBadRequest, "INVALID_REQUEST"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, body := do(h, http.MethodPost, "/api/v1/echo", tt.body, map[string]string{"Content-Type": "application/json"})
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.wantCode == "" {
+				if body["message"] != "hi" {
+					t.Errorf("body = %v, want message hi", body)
+				}
+				return
+			}
+			errObj, _ := body["error"].(map[string]any)
+			if errObj["code"] != tt.wantCode || errObj["message"] == "" {
+				t.Errorf("body = %v, want error code %s", body, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestGatewayUnimplemented(t *testing.T) {
+	h := newTestRouter(t, pingv1.UnimplementedPingServiceServer{})
+	rec, body := do(h, http.MethodGet, "/api/v1/ping", "", nil)
+	if errObj, _ := body["error"].(map[string]any); rec.Code != http.StatusNotImplemented || errObj["code"] != "NOT_IMPLEMENTED" {
+		t.Errorf("got %d %v, want 501 NOT_IMPLEMENTED", rec.Code, body)
+	}
+}
+
+func TestErrorHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+		wantMsg    string
+	}{
+		{"not found", status.Error(codes.NotFound, "ping 7 not found"), http.StatusNotFound, "RESOURCE_NOT_FOUND", "ping 7 not found"},
+		{"unauthenticated", status.Error(codes.Unauthenticated, "token expired"), http.StatusUnauthorized, "UNAUTHORIZED", "token expired"},
+		{"internal hides details", status.Error(codes.Internal, "pq: password authentication failed"), http.StatusInternalServerError, "INTERNAL_ERROR", "Internal Server Error"},
+		{"plain error", errors.New("boom"), http.StatusInternalServerError, "INTERNAL_ERROR", "Internal Server Error"},
+		{"routing error", &runtime.HTTPStatusError{HTTPStatus: http.StatusMethodNotAllowed, Err: status.Error(codes.Unimplemented, "Method Not Allowed")}, http.StatusMethodNotAllowed, "NOT_IMPLEMENTED", "Method Not Allowed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			ErrorHandler(context.Background(), nil, nil, rec, httptest.NewRequest(http.MethodGet, "/api/v1/ping", nil), tt.err)
+			var body map[string]AppError
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if got := body["error"]; rec.Code != tt.wantStatus || got.Code != tt.wantCode || got.Message != tt.wantMsg {
+				t.Errorf("got %d %+v, want %d %s %q", rec.Code, got, tt.wantStatus, tt.wantCode, tt.wantMsg)
+			}
+			if tt.wantCode == "UNAUTHORIZED" && rec.Header().Get("WWW-Authenticate") != "Bearer" {
+				t.Error("missing WWW-Authenticate header")
+			}
+		})
+	}
+}
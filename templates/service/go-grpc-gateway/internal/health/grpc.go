@@ -0,0 +1,101 @@
+package health
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpchealth "google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCPollInterval is how often GRPCHealth re-runs the dependency checks
+var GRPCPollInterval = 10 * time.Second
+
+// GRPCHealth serves grpc.health.v1.Health from the checks registered on a
+// HealthChecker, for Kubernetes gRPC probes and client-side health checking.
+// The overall status ("") and every service passed to NewGRPCHealth are
+// SERVING while started and all critical checks pass, NOT_SERVING otherwise.
+type GRPCHealth struct {
+	*grpchealth.Server
+	checker  *HealthChecker
+	services []string
+}
+
+// NewGRPCHealth reports NOT_SERVING until Run has polled the checks once
+func NewGRPCHealth(checker *HealthChecker, services ...string) *GRPCHealth {
+	g := &GRPCHealth{Server: grpchealth.NewServer(), checker: checker, services: services}
+	checker.notServing.Store(true)
+	g.set(grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	return g
+}
+
+// Register adds the Health service to s
+func (g *GRPCHealth) Register(s *grpc.Server) {
+	grpc_health_v1.RegisterHealthServer(s, g)
+}
+
+// Run polls the checks now and every GRPCPollInterval. When ctx is done every
+// service switches to NOT_SERVING for good, so clients move away during
+// shutdown.
+func (g *GRPCHealth) Run(ctx context.Context) {
+	t := time.NewTicker(GRPCPollInterval)
+	defer t.Stop()
+	for {
+		g.poll(ctx)
+		select {
+		case <-ctx.Done():
+			g.checker.notServing.Store(true)
+			g.Shutdown()
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (g *GRPCHealth) poll(ctx context.Context) {
+	_, healthy := g.checker.Check(ctx)
+	serving := healthy && g.checker.started.Load()
+	st := grpc_health_v1.HealthCheckResponse_SERVING
+	if !serving {
+		st = grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	}
+	if g.checker.notServing.Swap(!serving) == serving {
+		zap.L().Info("grpc health status changed", zap.String("status", st.String()))
+	}
+	g.set(st)
+}
+
+func (g *GRPCHealth) set(st grpc_health_v1.HealthCheckResponse_ServingStatus) {
+	g.SetServingStatus("", st)
+	for _, svc := range g.services {
+		g.SetServingStatus(svc, st)
+	}
+}
+
+// ServeHealthStream sends the status of in.Service now and after every
+// change, until the client cancels
+func (g *GRPCHealth) ServeHealthStream(in *grpc_health_v1.HealthCheckRequest, stream grpc_health_v1.Health_WatchServer) error {
+	return g.Server.Watch(in, stream)
+}
+
+// Watch implements grpc_health_v1.HealthServer with ServeHealthStream
+func (g *GRPCHealth) Watch(in *grpc_health_v1.HealthCheckRequest, stream grpc_health_v1.Health_WatchServer) error {
+	return g.ServeHealthStream(in, stream)
+}
+
+// NewHealthInterceptor fails unary calls with UNAVAILABLE while the gRPC
+// health status is NOT_SERVING, so clients retry on a healthy replica.
+// Health checks themselves always pass through.
+func NewHealthInterceptor(checker *HealthChecker) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if checker.notServing.Load() && !strings.HasPrefix(info.FullMethod, "/grpc.health.v1.Health/") {
+			return nil, status.Error(codes.Unavailable, "service is not serving")
+		}
+		return handler(ctx, req)
+	}
+}
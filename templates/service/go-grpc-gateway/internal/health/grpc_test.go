@@ -0,0 +1,127 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	pingv1 "github.com/example/go-grpc-gateway/gen/ping/v1"
+	"github.com/example/go-grpc-gateway/internal/ping"
+)
+
+// newHealthServer serves the health service and the ping service behind the
+// health interceptor in process, and returns a client connection to them
+func newHealthServer(t *testing.T, checker *HealthChecker) (*GRPCHealth, *grpc.ClientConn) {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.UnaryInterceptor(NewHealthInterceptor(checker)))
+	hs := NewGRPCHealth(checker, "ping.v1.PingService")
+	hs.Register(srv)
+	pingv1.RegisterPingServiceServer(srv, ping.NewService())
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return hs, conn
+}
+
+func TestGRPCHealthTransitions(t *testing.T) {
+	defer func(d time.Duration) { GRPCPollInterval = d }(GRPCPollInterval)
+	GRPCPollInterval = 10 * time.Millisecond
+
+	checker := NewHealthChecker(HealthConfig{})
+	var dbDown atomic.Bool
+	checker.Register("db", true, func(context.Context) error {
+		if dbDown.Load() {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	hs, conn := newHealthServer(t, checker)
+	client := grpc_health_v1.NewHealthClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.Watch(ctx, &grpc_health_v1.HealthCheckRequest{Service: "ping.v1.PingService"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// next skips repeated updates until the status changes to want
+	next := func(want grpc_health_v1.HealthCheckResponse_ServingStatus) {
+		t.Helper()
+		for {
+			resp, err := stream.Recv()
+			if err != nil {
+				t.Fatalf("waiting for %s: %v", want, err)
+			}
+			if resp.Status == want {
+				return
+			}
+		}
+	}
+	pingErr := func() codes.Code {
+		_, err := pingv1.NewPingServiceClient(conn).Ping(ctx, &pingv1.PingRequest{})
+		return status.Code(err)
+	}
+
+	next(grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	if code := pingErr(); code != codes.Unavailable {
+		t.Errorf("Ping before the first poll = %s, want Unavailable", code)
+	}
+
+	runCtx, stop := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { hs.Run(runCtx); close(done) }()
+
+	checker.MarkStarted()
+	next(grpc_health_v1.HealthCheckResponse_SERVING)
+	if code := pingErr(); code != codes.OK {
+		t.Errorf("Ping while serving = %s, want OK", code)
+	}
+
+	dbDown.Store(true)
+	next(grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	if code := pingErr(); code != codes.Unavailable {
+		t.Errorf("Ping with the database down = %s, want Unavailable", code)
+	}
+	// health checks themselves are never rejected
+	resp, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if err != nil || resp.Status != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("Check = %v, %v", resp, err)
+	}
+
+	dbDown.Store(false)
+	next(grpc_health_v1.HealthCheckResponse_SERVING)
+
+	stop()
+	<-done
+	next(grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	if code := pingErr(); code != codes.Unavailable {
+		t.Errorf("Ping after shutdown = %s, want Unavailable", code)
+	}
+}
+
+func TestGRPCHealthUnknownService(t *testing.T) {
+	_, conn := newHealthServer(t, NewHealthChecker(HealthConfig{}))
+	_, err := grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "nope.v1.Nope"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Check of an unknown service = %v, want NotFound", err)
+	}
+}
@@ -0,0 +1,174 @@
+// Package health serves the Kubernetes liveness, readiness and startup probes
+// over HTTP and the grpc.health.v1 protocol (see GRPCHealth).
+//
+//   - /healthz (liveness): the process is alive; fails only once a deadlock has been
+//     reported, so Kubernetes restarts the pod instead of waiting forever
+//   - /readyz (readiness): every registered dependency check passes; a failing
+//     critical check returns 503 and takes the pod out of the Service endpoints
+//   - /startupz (startup): 503 until MarkStarted is called after initialization
+//     (migrations, cache warm-up), holding off the other two probes meanwhile
+//
+// Matching container probes:
+//
+//	startupProbe:
+//	  httpGet: { path: /startupz, port: http }
+//	  periodSeconds: 5
+//	  failureThreshold: 60   # allow up to 5m for startup
+//	livenessProbe:
+//	  httpGet: { path: /healthz, port: http }
+//	  periodSeconds: 10
+//	  failureThreshold: 3
+//	readinessProbe:
+//	  httpGet: { path: /readyz, port: http }
+//	  periodSeconds: 5
+//	  failureThreshold: 2
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// HealthConfig configures the dependency checks run by /readyz
+type HealthConfig struct {
+	CheckTimeout time.Duration `mapstructure:"check_timeout"` // per check, default 2s
+}
+
+// CheckFunc reports whether a dependency is usable
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name     string
+	critical bool
+	fn       CheckFunc
+}
+
+// CheckResult is the outcome of one dependency check
+type CheckResult struct {
+	Status   string `json:"status"`
+	Critical bool   `json:"critical"`
+	Error    string `json:"error,omitempty"`
+}
+
+// HealthChecker runs the registered dependency checks and tracks the
+// startup and deadlock state reported by the application
+type HealthChecker struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks []check
+
+	started          atomic.Bool
+	deadlockDetected atomic.Bool
+	notServing       atomic.Bool // last result of the gRPC health poller
+}
+
+// NewHealthChecker returns a checker with no dependencies registered
+func NewHealthChecker(cfg HealthConfig) *HealthChecker {
+	if cfg.CheckTimeout <= 0 {
+		cfg.CheckTimeout = 2 * time.Second
+	}
+	return &HealthChecker{timeout: cfg.CheckTimeout}
+}
+
+// Register adds a dependency check to /readyz. A failing critical check makes
+// the service unready; a non-critical one is only reported.
+func (h *HealthChecker) Register(name string, critical bool, fn CheckFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, check{name: name, critical: critical, fn: fn})
+}
+
+// MarkStarted flips /startupz to 200; call it once initialization has finished
+func (h *HealthChecker) MarkStarted() {
+	h.started.Store(true)
+}
+
+// SetDeadlockDetected is called by the serving goroutine's watchdog when it
+// stops making progress; /healthz fails from then on
+func (h *HealthChecker) SetDeadlockDetected() {
+	h.deadlockDetected.Store(true)
+}
+
+// Check runs every registered check concurrently, each bounded by the check
+// timeout, and reports whether all critical checks passed
+func (h *HealthChecker) Check(ctx context.Context) (map[string]CheckResult, bool) {
+	h.mu.RLock()
+	checks := append([]check(nil), h.checks...)
+	h.mu.RUnlock()
+
+	results := make(map[string]CheckResult, len(checks))
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	healthy := true
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c check) {
+			defer wg.Done()
+			cctx, cancel := context.WithTimeout(ctx, h.timeout)
+			defer cancel()
+			res := CheckResult{Status: "ok", Critical: c.critical}
+			if err := c.fn(cctx); err != nil {
+				res.Status = "failing"
+				res.Error = err.Error()
+				zap.L().Warn("health check failed", zap.String("check", c.name), zap.Bool("critical", c.critical), zap.Error(err))
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			results[c.name] = res
+			if res.Status != "ok" && c.critical {
+				healthy = false
+			}
+		}(c)
+	}
+	wg.Wait()
+	return results, healthy
+}
+
+// Liveness serves /healthz: 200 unless a deadlock has been reported
+func (h *HealthChecker) Liveness(w http.ResponseWriter, r *http.Request) {
+	if h.deadlockDetected.Load() {
+		writeStatus(w, http.StatusServiceUnavailable, map[string]any{"status": "deadlocked"})
+		return
+	}
+	writeStatus(w, http.StatusOK, map[string]any{"status": "ok"})
+}
+
+// Readiness serves /readyz: 200 once started and every critical dependency
+// check passes, 503 otherwise, with per-check results in the body
+func (h *HealthChecker) Readiness(w http.ResponseWriter, r *http.Request) {
+	if !h.started.Load() {
+		writeStatus(w, http.StatusServiceUnavailable, map[string]any{"status": "starting"})
+		return
+	}
+	results, healthy := h.Check(r.Context())
+	if !healthy {
+		writeStatus(w, http.StatusServiceUnavailable, map[string]any{"status": "unavailable", "checks": results})
+		return
+	}
+	writeStatus(w, http.StatusOK, map[string]any{"status": "ready", "checks": results})
+}
+
+// Startup serves /startupz: 503 until MarkStarted has been called
+func (h *HealthChecker) Startup(w http.ResponseWriter, r *http.Request) {
+	if !h.started.Load() {
+		writeStatus(w, http.StatusServiceUnavailable, map[string]any{"status": "starting"})
+		return
+	}
+	writeStatus(w, http.StatusOK, map[string]any{"status": "started"})
+}
+
+func writeStatus(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
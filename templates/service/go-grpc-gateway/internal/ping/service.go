@@ -0,0 +1,56 @@
+package ping
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pingv1 "github.com/example/go-grpc-gateway/gen/ping/v1"
+)
+
+// RequestIDKey is the gRPC metadata key carrying the HTTP request ID
+const RequestIDKey = "request-id"
+
+// Service is the sample PingService backend
+type Service struct {
+	pingv1.UnimplementedPingServiceServer
+}
+
+// NewService returns a ready PingService implementation
+func NewService() *Service {
+	return &Service{}
+}
+
+// Ping implements pingv1.PingServiceServer
+func (s *Service) Ping(ctx context.Context, _ *pingv1.PingRequest) (*pingv1.PingResponse, error) {
+	return &pingv1.PingResponse{
+		Message:   "pong",
+		RequestId: RequestID(ctx),
+		Time:      timestamppb.Now(),
+	}, nil
+}
+
+// Echo implements pingv1.PingServiceServer
+func (s *Service) Echo(ctx context.Context, req *pingv1.EchoRequest) (*pingv1.EchoResponse, error) {
+	msg := strings.TrimSpace(req.GetMessage())
+	if msg == "" {
+		return nil, status.Error(codes.InvalidArgument, "message must not be empty")
+	}
+	return &pingv1.EchoResponse{Message: msg, RequestId: RequestID(ctx)}, nil
+}
+
+// RequestID returns the request ID from incoming gRPC metadata, or ""
+func RequestID(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if v := md.Get(RequestIDKey); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
@@ -0,0 +1,180 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+var (
+	messagesPublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "nats_messages_published_total",
+		Help: "Messages published to NATS, by subject.",
+	}, []string{"subject"})
+	messagesConsumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "nats_messages_consumed_total",
+		Help: "Messages delivered to subscription handlers, by subject.",
+	}, []string{"subject"})
+)
+
+// NATSConfig configures the connection to a NATS cluster
+type NATSConfig struct {
+	Servers         []string      `mapstructure:"servers"`
+	NKey            string        `mapstructure:"nkey"`             // path to an NKey seed file
+	CredentialsFile string        `mapstructure:"credentials_file"` // JWT + seed .creds file
+	MaxReconnects   int           `mapstructure:"max_reconnects"`   // -1 retries forever
+	ReconnectWait   time.Duration `mapstructure:"reconnect_wait"`
+	AckWait         time.Duration `mapstructure:"ack_wait"` // JetStream publish ack timeout
+	Name            string        `mapstructure:"name"`
+}
+
+// NATSClient publishes and subscribes over a single NATS connection
+type NATSClient struct {
+	conn    *nats.Conn
+	js      nats.JetStreamContext
+	ackWait time.Duration
+	closed  chan struct{}
+}
+
+// NewNATSClient connects to cfg.Servers; reconnects are handled by the client library
+func NewNATSClient(cfg NATSConfig) (*NATSClient, error) {
+	if len(cfg.Servers) == 0 {
+		cfg.Servers = []string{nats.DefaultURL}
+	}
+	if cfg.ReconnectWait == 0 {
+		cfg.ReconnectWait = 2 * time.Second
+	}
+	if cfg.MaxReconnects == 0 {
+		cfg.MaxReconnects = nats.DefaultMaxReconnect
+	}
+	if cfg.AckWait == 0 {
+		cfg.AckWait = 5 * time.Second
+	}
+
+	c := &NATSClient{ackWait: cfg.AckWait, closed: make(chan struct{})}
+	opts := []nats.Option{
+		nats.Name(cfg.Name),
+		nats.MaxReconnects(cfg.MaxReconnects),
+		nats.ReconnectWait(cfg.ReconnectWait),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				zap.L().Warn("nats disconnected", zap.Error(err))
+			}
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			zap.L().Info("nats reconnected", zap.String("server", nc.ConnectedUrl()))
+		}),
+		nats.ErrorHandler(func(_ *nats.Conn, sub *nats.Subscription, err error) {
+			subject := ""
+			if sub != nil {
+				subject = sub.Subject
+			}
+			zap.L().Error("nats async error", zap.String("subject", subject), zap.Error(err))
+		}),
+		nats.ClosedHandler(func(*nats.Conn) { close(c.closed) }),
+	}
+	if cfg.CredentialsFile != "" {
+		opts = append(opts, nats.UserCredentials(cfg.CredentialsFile))
+	}
+	if cfg.NKey != "" {
+		opt, err := nats.NkeyOptionFromSeed(cfg.NKey)
+		if err != nil {
+			return nil, fmt.Errorf("load nkey seed: %w", err)
+		}
+		opts = append(opts, opt)
+	}
+
+	conn, err := nats.Connect(strings.Join(cfg.Servers, ","), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("connect to nats: %w", err)
+	}
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("jetstream context: %w", err)
+	}
+	c.conn, c.js = conn, js
+	return c, nil
+}
+
+// Conn exposes the underlying connection for health checks and advanced use
+func (c *NATSClient) Conn() *nats.Conn {
+	return c.conn
+}
+
+// Publish JSON-encodes payload and publishes it on subject
+func (c *NATSClient) Publish(ctx context.Context, subject string, payload any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode payload: %w", err)
+	}
+	if err := c.conn.Publish(subject, data); err != nil {
+		return fmt.Errorf("publish %s: %w", subject, err)
+	}
+	messagesPublished.WithLabelValues(subject).Inc()
+	return nil
+}
+
+// Subscribe registers handler for subject. Subscribers sharing a non-empty
+// queue group split the messages between them instead of each receiving a copy.
+func (c *NATSClient) Subscribe(subject string, queue string, handler func(*nats.Msg)) (*nats.Subscription, error) {
+	wrapped := func(msg *nats.Msg) {
+		messagesConsumed.WithLabelValues(subject).Inc()
+		handler(msg)
+	}
+	var (
+		sub *nats.Subscription
+		err error
+	)
+	if queue == "" {
+		sub, err = c.conn.Subscribe(subject, wrapped)
+	} else {
+		sub, err = c.conn.QueueSubscribe(subject, queue, wrapped)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("subscribe %s: %w", subject, err)
+	}
+	return sub, nil
+}
+
+// JetStreamPublish JSON-encodes payload and publishes it to a JetStream stream,
+// waiting up to AckWait for the server to persist it
+func (c *NATSClient) JetStreamPublish(stream, subject string, payload any) (*nats.PubAck, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("encode payload: %w", err)
+	}
+	ack, err := c.js.Publish(subject, data, nats.ExpectStream(stream), nats.AckWait(c.ackWait))
+	if err != nil {
+		return nil, fmt.Errorf("jetstream publish %s/%s: %w", stream, subject, err)
+	}
+	messagesPublished.WithLabelValues(subject).Inc()
+	return ack, nil
+}
+
+// Close drains all subscriptions, letting in-flight handlers finish, flushes
+// pending publishes and closes the connection. It gives up when ctx is done.
+func (c *NATSClient) Close(ctx context.Context) error {
+	if err := c.conn.Drain(); err != nil && !errors.Is(err, nats.ErrConnectionClosed) {
+		c.conn.Close()
+		return fmt.Errorf("drain nats connection: %w", err)
+	}
+	select {
+	case <-c.closed:
+		return nil
+	case <-ctx.Done():
+		c.conn.Close()
+		return ctx.Err()
+	}
+}
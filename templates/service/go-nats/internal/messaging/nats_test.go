@@ -0,0 +1,161 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	natsserver "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// newTestClient starts an in-process JetStream-enabled server and connects to it
+func newTestClient(t *testing.T) *NATSClient {
+	t.Helper()
+	opts := natsserver.DefaultTestOptions
+	opts.Port = -1
+	opts.JetStream = true
+	opts.StoreDir = t.TempDir()
+	srv := natsserver.RunServer(&opts)
+	t.Cleanup(srv.Shutdown)
+
+	c, err := NewNATSClient(NATSConfig{Servers: []string{srv.ClientURL()}, AckWait: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Conn().Close() })
+	return c
+}
+
+type order struct {
+	ID    int    `json:"id"`
+	Items string `json:"items"`
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	c := newTestClient(t)
+	got := make(chan order, 1)
+	if _, err := c.Subscribe("orders.created", "", func(m *nats.Msg) {
+		var o order
+		if err := json.Unmarshal(m.Data, &o); err != nil {
+			t.Error(err)
+		}
+		got <- o
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	published := testutil.ToFloat64(messagesPublished.WithLabelValues("orders.created"))
+	if err := c.Publish(context.Background(), "orders.created", order{ID: 1, Items: "book"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case o := <-got:
+		if o != (order{ID: 1, Items: "book"}) {
+			t.Errorf("received %+v", o)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("message not delivered")
+	}
+	if n := testutil.ToFloat64(messagesPublished.WithLabelValues("orders.created")); n != published+1 {
+		t.Errorf("published counter = %v, want %v", n, published+1)
+	}
+	if n := testutil.ToFloat64(messagesConsumed.WithLabelValues("orders.created")); n < 1 {
+		t.Errorf("consumed counter = %v, want >= 1", n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := c.Publish(ctx, "orders.created", order{}); err == nil {
+		t.Error("Publish with a cancelled context succeeded")
+	}
+}
+
+func TestQueueGroupDeliversOnce(t *testing.T) {
+	c := newTestClient(t)
+	const total = 20
+	var (
+		mu    sync.Mutex
+		seen  = map[string]int{}
+		count atomic.Int32
+		done  = make(chan struct{})
+	)
+	for i := 0; i < 2; i++ {
+		if _, err := c.Subscribe("jobs", "workers", func(m *nats.Msg) {
+			mu.Lock()
+			seen[string(m.Data)]++
+			mu.Unlock()
+			if count.Add(1) == total {
+				close(done)
+			}
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < total; i++ {
+		if err := c.Publish(context.Background(), "jobs", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("received %d of %d messages", count.Load(), total)
+	}
+	c.Conn().Flush()
+	time.Sleep(20 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(seen) != total || count.Load() != total {
+		t.Errorf("got %d deliveries of %d distinct messages, want each of %d once", count.Load(), len(seen), total)
+	}
+}
+
+func TestJetStreamPublish(t *testing.T) {
+	c := newTestClient(t)
+	if _, err := c.js.AddStream(&nats.StreamConfig{Name: "ORDERS", Subjects: []string{"orders.>"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	ack, err := c.JetStreamPublish("ORDERS", "orders.created", order{ID: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ack.Stream != "ORDERS" || ack.Sequence != 1 {
+		t.Errorf("ack = %+v, want ORDERS seq 1", ack)
+	}
+	if _, err := c.JetStreamPublish("OTHER", "orders.created", order{ID: 3}); err == nil {
+		t.Error("publish with a mismatched expected stream succeeded")
+	}
+}
+
+func TestCloseDrainsInFlightHandlers(t *testing.T) {
+	c := newTestClient(t)
+	var handled atomic.Int32
+	if _, err := c.Subscribe("slow", "", func(*nats.Msg) {
+		time.Sleep(20 * time.Millisecond)
+		handled.Add(1)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		c.Publish(context.Background(), "slow", i)
+	}
+	c.Conn().Flush()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := handled.Load(); n != 5 {
+		t.Errorf("handled %d messages before close, want 5", n)
+	}
+	if !c.Conn().IsClosed() {
+		t.Error("connection still open after Close")
+	}
+}
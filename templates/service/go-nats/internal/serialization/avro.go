@@ -0,0 +1,183 @@
+// Package serialization encodes message payloads for the wire. Avro payloads
+// use the Confluent framing, so they interoperate with Kafka clients and any
+// other consumer that resolves schemas from a Confluent-compatible registry.
+package serialization
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/hamba/avro/v2"
+	"github.com/hamba/avro/v2/registry"
+)
+
+// Subject naming strategies, as in the Confluent serializers
+const (
+	TopicNameStrategy       = "topic_name"        // <topic>-value (default)
+	RecordNameStrategy      = "record_name"       // <record full name>
+	TopicRecordNameStrategy = "topic_record_name" // <topic>-<record full name>
+)
+
+// magicByte starts every Confluent-framed message, followed by a big-endian
+// uint32 schema ID and the Avro binary body
+const magicByte = 0
+
+// SchemaRegistryConfig configures the Confluent-compatible schema registry
+type SchemaRegistryConfig struct {
+	URL                   string `mapstructure:"url"`
+	Username              string `mapstructure:"username"`
+	Password              string `mapstructure:"password"`
+	SubjectNamingStrategy string `mapstructure:"subject_naming_strategy"` // topic_name|record_name|topic_record_name
+}
+
+// AvroSerializer encodes and decodes Avro messages in the Confluent wire
+// format for one topic (NATS subject or Kafka topic). Schemas are registered
+// on first use and cached by ID, so the registry is only asked once per schema.
+type AvroSerializer struct {
+	registry registry.Registry
+	strategy string
+	topic    string
+
+	mu     sync.RWMutex
+	byID   map[int]avro.Schema
+	parsed map[string]registered // schema text → parsed schema and its ID
+}
+
+type registered struct {
+	id     int
+	schema avro.Schema
+}
+
+// NewAvroSerializer returns a serializer for topic using the registry at cfg.URL
+func NewAvroSerializer(cfg SchemaRegistryConfig, topic string) (*AvroSerializer, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("schema registry url is required")
+	}
+	var opts []registry.ClientFunc
+	if cfg.Username != "" {
+		opts = append(opts, registry.WithBasicAuth(cfg.Username, cfg.Password))
+	}
+	client, err := registry.NewClient(cfg.URL, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("schema registry client: %w", err)
+	}
+	return NewAvroSerializerWithRegistry(client, cfg.SubjectNamingStrategy, topic)
+}
+
+// NewAvroSerializerWithRegistry is NewAvroSerializer with a caller-supplied
+// registry client
+func NewAvroSerializerWithRegistry(reg registry.Registry, strategy, topic string) (*AvroSerializer, error) {
+	switch strategy {
+	case "":
+		strategy = TopicNameStrategy
+	case TopicNameStrategy, RecordNameStrategy, TopicRecordNameStrategy:
+	default:
+		return nil, fmt.Errorf("unknown subject naming strategy %q", strategy)
+	}
+	return &AvroSerializer{
+		registry: reg,
+		strategy: strategy,
+		topic:    topic,
+		byID:     make(map[int]avro.Schema),
+		parsed:   make(map[string]registered),
+	}, nil
+}
+
+// Serialize encodes value with the Avro schema (JSON schema text), registering
+// the schema under the configured subject the first time it is seen
+func (s *AvroSerializer) Serialize(schema string, value any) ([]byte, error) {
+	reg, err := s.register(schema)
+	if err != nil {
+		return nil, err
+	}
+	body, err := avro.Marshal(reg.schema, value)
+	if err != nil {
+		return nil, fmt.Errorf("avro encode: %w", err)
+	}
+	out := make([]byte, 5, 5+len(body))
+	out[0] = magicByte
+	binary.BigEndian.PutUint32(out[1:5], uint32(reg.id))
+	return append(out, body...), nil
+}
+
+// Deserialize decodes a Confluent-framed Avro message into out, fetching the
+// writer schema by the ID embedded in data
+func (s *AvroSerializer) Deserialize(data []byte, out any) error {
+	if len(data) < 5 || data[0] != magicByte {
+		return errors.New("not a schema registry framed avro message")
+	}
+	id := int(binary.BigEndian.Uint32(data[1:5]))
+	schema, err := s.schemaByID(id)
+	if err != nil {
+		return err
+	}
+	if err := avro.Unmarshal(schema, data[5:], out); err != nil {
+		return fmt.Errorf("avro decode (schema %d): %w", id, err)
+	}
+	return nil
+}
+
+func (s *AvroSerializer) register(schema string) (registered, error) {
+	s.mu.RLock()
+	reg, ok := s.parsed[schema]
+	s.mu.RUnlock()
+	if ok {
+		return reg, nil
+	}
+
+	parsed, err := avro.Parse(schema)
+	if err != nil {
+		return registered{}, fmt.Errorf("parse avro schema: %w", err)
+	}
+	subject, err := s.subject(parsed)
+	if err != nil {
+		return registered{}, err
+	}
+	// registering an existing schema returns its ID, so this is idempotent
+	id, _, err := s.registry.CreateSchema(context.Background(), subject, schema)
+	if err != nil {
+		return registered{}, fmt.Errorf("register schema under %s: %w", subject, err)
+	}
+
+	reg = registered{id: id, schema: parsed}
+	s.mu.Lock()
+	s.parsed[schema] = reg
+	s.byID[id] = parsed
+	s.mu.Unlock()
+	return reg, nil
+}
+
+func (s *AvroSerializer) schemaByID(id int) (avro.Schema, error) {
+	s.mu.RLock()
+	schema, ok := s.byID[id]
+	s.mu.RUnlock()
+	if ok {
+		return schema, nil
+	}
+	schema, err := s.registry.GetSchema(context.Background(), id)
+	if err != nil {
+		return nil, fmt.Errorf("fetch schema %d: %w", id, err)
+	}
+	s.mu.Lock()
+	s.byID[id] = schema
+	s.mu.Unlock()
+	return schema, nil
+}
+
+// subject names the registry subject for schema under the naming strategy
+func (s *AvroSerializer) subject(schema avro.Schema) (string, error) {
+	if s.strategy == TopicNameStrategy {
+		return s.topic + "-value", nil
+	}
+	named, ok := schema.(avro.NamedSchema)
+	if !ok {
+		return "", fmt.Errorf("%s strategy needs a named schema (record, enum or fixed)", s.strategy)
+	}
+	if s.strategy == RecordNameStrategy {
+		return named.FullName(), nil
+	}
+	return s.topic + "-" + named.FullName(), nil
+}
@@ -0,0 +1,175 @@
+package serialization
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const orderSchema = `{"type":"record","name":"Order","namespace":"com.example","fields":[
+	{"name":"id","type":"string"},
+	{"name":"amount_cents","type":"long"}
+]}`
+
+type order struct {
+	ID          string `avro:"id"`
+	AmountCents int64  `avro:"amount_cents"`
+}
+
+// fakeRegistry is a minimal Confluent schema registry: it assigns IDs on
+// registration and serves schemas by ID, counting the requests of each kind
+type fakeRegistry struct {
+	mu       sync.Mutex
+	schemas  []string          // index+1 is the ID
+	subjects map[string]string // subject → last registered schema
+	creates  int
+	fetches  int
+	auth     string
+}
+
+func newFakeRegistry(t *testing.T) (*fakeRegistry, string) {
+	t.Helper()
+	reg := &fakeRegistry{subjects: map[string]string{}}
+	srv := httptest.NewServer(reg)
+	t.Cleanup(srv.Close)
+	return reg, srv.URL
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if user, pass, ok := r.BasicAuth(); ok {
+		f.auth = user + ":" + pass
+	}
+	w.Header().Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	switch {
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/subjects/"):
+		var req struct{ Schema string }
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.creates++
+		subject := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/subjects/"), "/versions")
+		f.subjects[subject] = req.Schema
+		id := len(f.schemas) + 1
+		for i, s := range f.schemas {
+			if s == req.Schema {
+				id = i + 1
+			}
+		}
+		if id > len(f.schemas) {
+			f.schemas = append(f.schemas, req.Schema)
+		}
+		json.NewEncoder(w).Encode(map[string]int{"id": id})
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/schemas/ids/"):
+		f.fetches++
+		id, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/schemas/ids/"))
+		if id < 1 || id > len(f.schemas) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]any{"error_code": 40403, "message": "Schema not found"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"schema": f.schemas[id-1]})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestAvroRoundTrip(t *testing.T) {
+	reg, url := newFakeRegistry(t)
+	producer, err := NewAvroSerializer(SchemaRegistryConfig{URL: url, Username: "u", Password: "p"}, "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in := order{ID: "o-1", AmountCents: 1999}
+	data, err := producer.Serialize(orderSchema, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data[0] != magicByte || data[1] != 0 || data[2] != 0 || data[3] != 0 || data[4] != 1 {
+		t.Errorf("header = % x, want magic byte and schema ID 1", data[:5])
+	}
+	if _, err := producer.Serialize(orderSchema, order{ID: "o-2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a separate consumer knows no schemas and resolves the writer schema by ID
+	consumer, _ := NewAvroSerializer(SchemaRegistryConfig{URL: url}, "orders")
+	for i := 0; i < 3; i++ {
+		var out order
+		if err := consumer.Deserialize(data, &out); err != nil {
+			t.Fatal(err)
+		}
+		if out != in {
+			t.Errorf("decoded %+v, want %+v", out, in)
+		}
+	}
+
+	if reg.creates != 1 || reg.fetches != 1 {
+		t.Errorf("registry saw %d registrations and %d fetches, want 1 each (cached)", reg.creates, reg.fetches)
+	}
+	if reg.auth != "u:p" {
+		t.Errorf("basic auth = %q", reg.auth)
+	}
+}
+
+func TestAvroSubjectNaming(t *testing.T) {
+	tests := []struct {
+		strategy, want string
+	}{
+		{"", "orders-value"},
+		{TopicNameStrategy, "orders-value"},
+		{RecordNameStrategy, "com.example.Order"},
+		{TopicRecordNameStrategy, "orders-com.example.Order"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			reg, url := newFakeRegistry(t)
+			s, err := NewAvroSerializer(SchemaRegistryConfig{URL: url, SubjectNamingStrategy: tt.strategy}, "orders")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.Serialize(orderSchema, order{ID: "o-1"}); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := reg.subjects[tt.want]; !ok || len(reg.subjects) != 1 {
+				t.Errorf("subjects = %v, want %s", reg.subjects, tt.want)
+			}
+		})
+	}
+}
+
+func TestAvroErrors(t *testing.T) {
+	_, url := newFakeRegistry(t)
+	if _, err := NewAvroSerializer(SchemaRegistryConfig{}, "orders"); err == nil {
+		t.Error("missing registry url accepted")
+	}
+	if _, err := NewAvroSerializer(SchemaRegistryConfig{URL: url, SubjectNamingStrategy: "by_color"}, "orders"); err == nil {
+		t.Error("unknown naming strategy accepted")
+	}
+
+	s, _ := NewAvroSerializer(SchemaRegistryConfig{URL: url}, "orders")
+	var out order
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"short", []byte{0, 0}},
+		{"wrong magic byte", []byte{1, 0, 0, 0, 1, 2}},
+		{"unknown schema id", []byte{0, 0, 0, 0, 42, 2}},
+	}
+	for _, tt := range tests {
+		if err := s.Deserialize(tt.data, &out); err == nil {
+			t.Errorf("%s: Deserialize succeeded", tt.name)
+		}
+	}
+	if _, err := s.Serialize(`{"type":"record"`, out); err == nil {
+		t.Error("invalid schema accepted")
+	}
+}
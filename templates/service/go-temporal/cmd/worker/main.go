@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/worker"
+	"go.uber.org/zap"
+
+	"github.com/example/go-temporal/internal/orders"
+	"github.com/example/go-temporal/internal/temporalzap"
+)
+
+// Build-time variables (set with -ldflags)
+var (
+	version   = "0.0.0"
+	buildTime = "unknown"
+	commit    = ""
+)
+
+// ServerConfig holds runtime configuration for the worker
+type ServerConfig struct {
+	ShutdownTimeout         time.Duration `mapstructure:"shutdown_timeout"`
+	EnableMetrics           bool          `mapstructure:"enable_metrics"`
+	MetricsListen           string        `mapstructure:"metrics_listen"`
+	LogLevel                string        `mapstructure:"log_level"`
+	Environment             string        `mapstructure:"environment"`
+	TemporalHostPort        string        `mapstructure:"temporal_host_port"`
+	TemporalNamespace       string        `mapstructure:"temporal_namespace"`
+	TaskQueue               string        `mapstructure:"task_queue"`
+	MaxConcurrentActivities int           `mapstructure:"max_concurrent_activities"`
+}
+
+func main() {
+	// Parse flags
+	pflag.String("config", "", "Path to config file (YAML/JSON/TOML)")
+	pflag.String("env", "development", "Environment name (development|staging|production)")
+	pflag.Parse()
+	viper.BindPFlags(pflag.CommandLine)
+
+	// Init config
+	if err := initConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "config init failed: %v\n", err)
+		os.Exit(2)
+	}
+
+	// Load typed config
+	var cfg ServerConfig
+	if err := viper.Unmarshal(&cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to parse config: %v\n", err)
+		os.Exit(3)
+	}
+
+	// Set sensible defaults if missing
+	setDefaults(&cfg)
+
+	// Init logger
+	logger, err := initLogger(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "logger init failed: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+	zap.ReplaceGlobals(logger)
+
+	zap.L().Info("starting prodstarter go-temporal worker",
+		zap.String("version", version),
+		zap.String("commit", commit),
+		zap.String("buildTime", buildTime),
+		zap.String("env", cfg.Environment),
+	)
+
+	// Readiness flips once the worker is polling and back when it stops
+	var ready atomic.Bool
+
+	// Metrics + health server (optional)
+	var metricsSrv *http.Server
+	if cfg.EnableMetrics {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("/metrics", promhttp.Handler())
+		metricsMux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+		})
+		metricsMux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+			if !ready.Load() {
+				writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "not ready"})
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+		})
+		metricsSrv = &http.Server{
+			Addr:         cfg.MetricsListen,
+			Handler:      metricsMux,
+			ReadTimeout:  5 * time.Second,
+			WriteTimeout: 10 * time.Second,
+			IdleTimeout:  30 * time.Second,
+		}
+		go func() {
+			zap.L().Info("metrics server starting", zap.String("listen", cfg.MetricsListen))
+			if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				zap.L().Error("metrics server failed", zap.Error(err))
+			}
+		}()
+	}
+
+	// Temporal client; SDK logs go through zap
+	c, err := client.Dial(client.Options{
+		HostPort:  cfg.TemporalHostPort,
+		Namespace: cfg.TemporalNamespace,
+		Logger:    temporalzap.New(logger),
+	})
+	if err != nil {
+		zap.L().Fatal("temporal client init failed", zap.String("host_port", cfg.TemporalHostPort), zap.Error(err))
+	}
+	defer c.Close()
+
+	// Worker; WorkerStopTimeout gives running activities until the shutdown
+	// deadline to finish before their contexts are cancelled
+	w := worker.New(c, cfg.TaskQueue, worker.Options{
+		MaxConcurrentActivityExecutionSize: cfg.MaxConcurrentActivities,
+		WorkerStopTimeout:                  cfg.ShutdownTimeout,
+	})
+	w.RegisterWorkflow(orders.ProcessOrderWorkflow)
+	w.RegisterActivity(&orders.Activities{PickDelay: 200 * time.Millisecond})
+
+	if err := w.Start(); err != nil {
+		zap.L().Fatal("temporal worker start failed", zap.Error(err))
+	}
+	ready.Store(true)
+	zap.L().Info("temporal worker polling",
+		zap.String("namespace", cfg.TemporalNamespace),
+		zap.String("task_queue", cfg.TaskQueue),
+	)
+
+	// Signal handling
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-shutdown
+	zap.L().Info("shutdown signal received", zap.String("signal", sig.String()))
+	ready.Store(false)
+
+	// Create context for shutdown
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	// Stop polling and wait for in-flight tasks; Stop blocks for at most
+	// WorkerStopTimeout, the extra select guards against a stuck activity
+	workerStopped := make(chan struct{})
+	go func() {
+		w.Stop()
+		close(workerStopped)
+	}()
+	select {
+	case <-workerStopped:
+		zap.L().Info("temporal worker stopped")
+	case <-ctx.Done():
+		zap.L().Warn("temporal worker did not stop before the shutdown timeout")
+	}
+
+	// Shutdown metrics server if running
+	if metricsSrv != nil {
+		if err := metricsSrv.Shutdown(ctx); err != nil {
+			zap.L().Error("metrics server shutdown failed", zap.Error(err))
+		} else {
+			zap.L().Info("metrics server stopped")
+		}
+	}
+
+	zap.L().Info("shutdown complete")
+}
+
+// initConfig initializes viper configuration: file, env, defaults
+func initConfig() error {
+	cfgFile := viper.GetString("config")
+	viper.SetEnvPrefix("APP")
+	viper.AutomaticEnv()
+
+	if cfgFile != "" {
+		viper.SetConfigFile(cfgFile)
+		if err := viper.ReadInConfig(); err != nil {
+			return fmt.Errorf("read config file: %w", err)
+		}
+	}
+
+	// set defaults
+	viper.SetDefault("shutdown_timeout", "30s")
+	viper.SetDefault("enable_metrics", true)
+	viper.SetDefault("metrics_listen", ":9090")
+	viper.SetDefault("temporal_host_port", client.DefaultHostPort)
+	viper.SetDefault("temporal_namespace", client.DefaultNamespace)
+	viper.SetDefault("task_queue", orders.TaskQueue)
+	viper.SetDefault("max_concurrent_activities", 100)
+	viper.SetDefault("log_level", "info")
+	viper.SetDefault("environment", viper.GetString("env"))
+
+	return nil
+}
+
+func setDefaults(cfg *ServerConfig) {
+	if cfg.ShutdownTimeout == 0 {
+		cfg.ShutdownTimeout = parseDurationOrDefault(viper.GetString("shutdown_timeout"), 30*time.Second)
+	}
+	if cfg.MetricsListen == "" {
+		cfg.MetricsListen = viper.GetString("metrics_listen")
+	}
+	if cfg.TemporalHostPort == "" {
+		cfg.TemporalHostPort = viper.GetString("temporal_host_port")
+	}
+	if cfg.TemporalNamespace == "" {
+		cfg.TemporalNamespace = viper.GetString("temporal_namespace")
+	}
+	if cfg.TaskQueue == "" {
+		cfg.TaskQueue = viper.GetString("task_queue")
+	}
+	if cfg.MaxConcurrentActivities == 0 {
+		cfg.MaxConcurrentActivities = viper.GetInt("max_concurrent_activities")
+	}
+	if cfg.Environment == "" {
+		cfg.Environment = viper.GetString("environment")
+	}
+	if cfg.LogLevel == "" {
+		cfg.LogLevel = viper.GetString("log_level")
+	}
+}
+
+func parseDurationOrDefault(s string, d time.Duration) time.Duration {
+	if s == "" {
+		return d
+	}
+	if dur, err := time.ParseDuration(s); err == nil {
+		return dur
+	}
+	// maybe provided as seconds integer
+	if secs, err := strconv.Atoi(s); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	return d
+}
+
+// initLogger configures zap logger based on config
+func initLogger(cfg ServerConfig) (*zap.Logger, error) {
+	var lvl zap.AtomicLevel
+	switch cfg.LogLevel {
+	case "debug":
+		lvl = zap.NewAtomicLevelAt(zap.DebugLevel)
+	case "warn":
+		lvl = zap.NewAtomicLevelAt(zap.WarnLevel)
+	case "error":
+		lvl = zap.NewAtomicLevelAt(zap.ErrorLevel)
+	default:
+		lvl = zap.NewAtomicLevelAt(zap.InfoLevel)
+	}
+
+	cfgZap := zap.Config{
+		Level:            lvl,
+		Development:      cfg.Environment != "production",
+		Encoding:         "json",
+		EncoderConfig:    zap.NewProductionEncoderConfig(),
+		OutputPaths:      []string{"stdout"},
+		ErrorOutputPaths: []string{"stderr"},
+	}
+
+	if cfg.Environment != "production" {
+		cfgZap.Encoding = "console"
+		enc := zap.NewDevelopmentEncoderConfig()
+		enc.TimeKey = "ts"
+		cfgZap.EncoderConfig = enc
+	}
+
+	return cfgZap.Build()
+}
+
+// writeJSON is a helper to write JSON responses with safe headers
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if v == nil {
+		return
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		zap.L().Error("failed to encode json response", zap.Error(err))
+	}
+}
@@ -0,0 +1,85 @@
+package orders
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+)
+
+// Activities holds the dependencies of the order activities. Replace the
+// simulated steps with calls to your payment provider and warehouse.
+type Activities struct {
+	// PickDelay simulates the time it takes to pick one order line
+	PickDelay time.Duration
+}
+
+// ValidateOrderActivity rejects malformed orders with a non-retryable error
+func (a *Activities) ValidateOrderActivity(ctx context.Context, order Order) error {
+	var problems []string
+	if order.ID == "" {
+		problems = append(problems, "id is required")
+	}
+	if order.CustomerID == "" {
+		problems = append(problems, "customer_id is required")
+	}
+	if len(order.Items) == 0 {
+		problems = append(problems, "at least one item is required")
+	}
+	for i, it := range order.Items {
+		if it.SKU == "" || it.Quantity <= 0 {
+			problems = append(problems, fmt.Sprintf("items[%d] needs a sku and a positive quantity", i))
+		}
+	}
+	if order.AmountCents <= 0 {
+		problems = append(problems, "amount_cents must be positive")
+	}
+	if len(problems) > 0 {
+		return temporal.NewNonRetryableApplicationError(strings.Join(problems, "; "), ErrInvalidOrder, nil)
+	}
+	return nil
+}
+
+// ChargePaymentActivity charges the customer and returns the payment ID. The
+// activity may run more than once (retries, worker crashes), so the charge
+// must be idempotent: the workflow ID is passed as the idempotency key.
+func (a *Activities) ChargePaymentActivity(ctx context.Context, order Order) (string, error) {
+	info := activity.GetInfo(ctx)
+	activity.GetLogger(ctx).Info("charging payment",
+		"order_id", order.ID,
+		"amount_cents", order.AmountCents,
+		"currency", order.Currency,
+		"idempotency_key", info.WorkflowExecution.ID,
+		"attempt", info.Attempt,
+	)
+	return "pay_" + order.ID, nil
+}
+
+// FulfillOrderActivity picks every order line and returns a tracking number.
+// It heartbeats the index of the last picked line; after a retry it resumes
+// from there instead of picking items twice.
+func (a *Activities) FulfillOrderActivity(ctx context.Context, order Order) (string, error) {
+	logger := activity.GetLogger(ctx)
+
+	next := 0
+	if activity.HasHeartbeatDetails(ctx) {
+		if err := activity.GetHeartbeatDetails(ctx, &next); err == nil {
+			logger.Info("resuming fulfillment", "order_id", order.ID, "from_item", next)
+		}
+	}
+
+	for i := next; i < len(order.Items); i++ {
+		select {
+		case <-ctx.Done():
+			// cancelled, timed out or the worker is stopping
+			return "", ctx.Err()
+		case <-time.After(a.PickDelay):
+		}
+		logger.Debug("item picked", "order_id", order.ID, "sku", order.Items[i].SKU, "quantity", order.Items[i].Quantity)
+		activity.RecordHeartbeat(ctx, i+1)
+	}
+	return "TRK-" + strings.ToUpper(order.ID), nil
+}
@@ -0,0 +1,81 @@
+// Package orders contains the sample order-processing workflow and its
+// activities. Workflow code must be deterministic: no I/O, clocks or random
+// numbers outside the workflow package APIs; side effects belong in activities.
+package orders
+
+import (
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// TaskQueue is the queue the worker polls and clients submit orders to
+const TaskQueue = "orders"
+
+// ErrInvalidOrder is the application error type for orders that fail
+// validation; it is listed as non-retryable so bad input fails fast
+const ErrInvalidOrder = "InvalidOrder"
+
+// Item is a single order line
+type Item struct {
+	SKU      string `json:"sku"`
+	Quantity int    `json:"quantity"`
+}
+
+// Order is the workflow input
+type Order struct {
+	ID          string `json:"id"`
+	CustomerID  string `json:"customer_id"`
+	Items       []Item `json:"items"`
+	AmountCents int64  `json:"amount_cents"`
+	Currency    string `json:"currency"`
+}
+
+// OrderResult is the workflow output
+type OrderResult struct {
+	OrderID        string `json:"order_id"`
+	PaymentID      string `json:"payment_id"`
+	TrackingNumber string `json:"tracking_number"`
+}
+
+// ActivityOptions applied to every activity of ProcessOrderWorkflow. The
+// heartbeat timeout lets the server detect a crashed worker long before
+// StartToCloseTimeout expires; retries back off exponentially.
+var ActivityOptions = workflow.ActivityOptions{
+	StartToCloseTimeout: 5 * time.Minute,
+	HeartbeatTimeout:    10 * time.Second,
+	RetryPolicy: &temporal.RetryPolicy{
+		InitialInterval:        time.Second,
+		BackoffCoefficient:     2.0,
+		MaximumInterval:        time.Minute,
+		MaximumAttempts:        5,
+		NonRetryableErrorTypes: []string{ErrInvalidOrder},
+	},
+}
+
+// ProcessOrderWorkflow validates an order, charges the customer and ships
+// the items. Each step is an activity, so a worker restart resumes the
+// workflow after the last completed step instead of starting over.
+func ProcessOrderWorkflow(ctx workflow.Context, order Order) (OrderResult, error) {
+	ctx = workflow.WithActivityOptions(ctx, ActivityOptions)
+	logger := workflow.GetLogger(ctx)
+	logger.Info("processing order", "order_id", order.ID)
+
+	// a nil pointer is enough for method references; the worker registers the real instance
+	var a *Activities
+	result := OrderResult{OrderID: order.ID}
+
+	if err := workflow.ExecuteActivity(ctx, a.ValidateOrderActivity, order).Get(ctx, nil); err != nil {
+		return result, err
+	}
+	if err := workflow.ExecuteActivity(ctx, a.ChargePaymentActivity, order).Get(ctx, &result.PaymentID); err != nil {
+		return result, err
+	}
+	if err := workflow.ExecuteActivity(ctx, a.FulfillOrderActivity, order).Get(ctx, &result.TrackingNumber); err != nil {
+		return result, err
+	}
+
+	logger.Info("order processed", "order_id", order.ID, "payment_id", result.PaymentID, "tracking_number", result.TrackingNumber)
+	return result, nil
+}
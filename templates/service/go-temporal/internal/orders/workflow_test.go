@@ -0,0 +1,124 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func validOrder() Order {
+	return Order{
+		ID:          "o-1",
+		CustomerID:  "c-1",
+		Items:       []Item{{SKU: "A", Quantity: 1}, {SKU: "B", Quantity: 2}},
+		AmountCents: 1999,
+		Currency:    "EUR",
+	}
+}
+
+// newEnv returns a workflow environment with the activities registered and a
+// listener counting how often each activity was started
+func newEnv(t *testing.T) (*testsuite.TestWorkflowEnvironment, map[string]int) {
+	t.Helper()
+	var s testsuite.WorkflowTestSuite
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&Activities{})
+	started := map[string]int{}
+	env.SetOnActivityStartedListener(func(info *activity.Info, _ context.Context, _ converter.EncodedValues) {
+		started[info.ActivityType.Name]++
+	})
+	return env, started
+}
+
+func TestProcessOrderWorkflow(t *testing.T) {
+	env, started := newEnv(t)
+	env.ExecuteWorkflow(ProcessOrderWorkflow, validOrder())
+
+	if !env.IsWorkflowCompleted() {
+		t.Fatal("workflow did not complete")
+	}
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("workflow error: %v", err)
+	}
+	var res OrderResult
+	if err := env.GetWorkflowResult(&res); err != nil {
+		t.Fatal(err)
+	}
+	want := OrderResult{OrderID: "o-1", PaymentID: "pay_o-1", TrackingNumber: "TRK-O-1"}
+	if res != want {
+		t.Errorf("result = %+v, want %+v", res, want)
+	}
+	for _, name := range []string{"ValidateOrderActivity", "ChargePaymentActivity", "FulfillOrderActivity"} {
+		if started[name] != 1 {
+			t.Errorf("%s started %d times, want 1", name, started[name])
+		}
+	}
+}
+
+// An invalid order fails on the first validation attempt and never reaches payment
+func TestProcessOrderWorkflowInvalidOrder(t *testing.T) {
+	env, started := newEnv(t)
+	order := validOrder()
+	order.AmountCents = 0
+	env.ExecuteWorkflow(ProcessOrderWorkflow, order)
+
+	err := env.GetWorkflowError()
+	var appErr *temporal.ApplicationError
+	if !errors.As(err, &appErr) || appErr.Type() != ErrInvalidOrder {
+		t.Fatalf("workflow error = %v, want %s", err, ErrInvalidOrder)
+	}
+	if started["ValidateOrderActivity"] != 1 || started["ChargePaymentActivity"] != 0 {
+		t.Errorf("activities started = %v, want one validation and no charge", started)
+	}
+}
+
+func TestValidateOrderActivity(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(o *Order)
+		wantErr bool
+	}{
+		{"valid", func(o *Order) {}, false},
+		{"missing id", func(o *Order) { o.ID = "" }, true},
+		{"missing customer", func(o *Order) { o.CustomerID = "" }, true},
+		{"no items", func(o *Order) { o.Items = nil }, true},
+		{"zero quantity", func(o *Order) { o.Items[0].Quantity = 0 }, true},
+		{"negative amount", func(o *Order) { o.AmountCents = -1 }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s testsuite.WorkflowTestSuite
+			env := s.NewTestActivityEnvironment()
+			a := &Activities{}
+			env.RegisterActivity(a)
+			order := validOrder()
+			tt.mutate(&order)
+			if _, err := env.ExecuteActivity(a.ValidateOrderActivity, order); (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// A retried fulfillment resumes after the last heartbeated item
+func TestFulfillOrderActivityResumes(t *testing.T) {
+	var s testsuite.WorkflowTestSuite
+	env := s.NewTestActivityEnvironment()
+	a := &Activities{}
+	env.RegisterActivity(a)
+	env.SetHeartbeatDetails(len(validOrder().Items))
+
+	val, err := env.ExecuteActivity(a.FulfillOrderActivity, validOrder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tracking string
+	if err := val.Get(&tracking); err != nil || tracking != "TRK-O-1" {
+		t.Errorf("tracking = %q, %v", tracking, err)
+	}
+}
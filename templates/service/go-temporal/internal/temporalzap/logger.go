@@ -0,0 +1,33 @@
+// Package temporalzap adapts a zap logger to the Temporal SDK log.Logger
+// interface so SDK, workflow and activity logs share the service's output
+package temporalzap
+
+import (
+	"go.temporal.io/sdk/log"
+	"go.uber.org/zap"
+)
+
+// Logger implements log.Logger and log.WithLogger on top of zap
+type Logger struct {
+	s *zap.SugaredLogger
+}
+
+var (
+	_ log.Logger     = (*Logger)(nil)
+	_ log.WithLogger = (*Logger)(nil)
+)
+
+// New wraps l; keyvals passed by the SDK become zap fields
+func New(l *zap.Logger) *Logger {
+	return &Logger{s: l.WithOptions(zap.AddCallerSkip(1)).Sugar()}
+}
+
+func (l *Logger) Debug(msg string, keyvals ...interface{}) { l.s.Debugw(msg, keyvals...) }
+func (l *Logger) Info(msg string, keyvals ...interface{})  { l.s.Infow(msg, keyvals...) }
+func (l *Logger) Warn(msg string, keyvals ...interface{})  { l.s.Warnw(msg, keyvals...) }
+func (l *Logger) Error(msg string, keyvals ...interface{}) { l.s.Errorw(msg, keyvals...) }
+
+// With returns a logger that adds keyvals to every entry
+func (l *Logger) With(keyvals ...interface{}) log.Logger {
+	return &Logger{s: l.s.With(keyvals...)}
+}